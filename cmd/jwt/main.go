@@ -6,37 +6,57 @@ import (
 	"os"
 	"time"
 
+	"github.com/ad/leads-core/internal/auth"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 func main() {
 	var (
-		secret = flag.String("secret", "", "JWT secret key")
-		userID = flag.String("user", "", "User ID")
-		ttl    = flag.Duration("ttl", 24*time.Hour, "Token TTL (default: 24h)")
+		secret   = flag.String("secret", "", "JWT secret key")
+		userID   = flag.String("user", "", "User ID")
+		widgetID = flag.String("widget", "", "Widget ID (generates a widget-scoped token for public endpoints)")
+		role     = flag.String("role", "", "User role (e.g. admin)")
+		ttl      = flag.Duration("ttl", 24*time.Hour, "Token TTL (default: 24h)")
 	)
 	flag.Parse()
 
 	if *secret == "" {
 		fmt.Fprintf(os.Stderr, "Error: secret is required\n")
-		fmt.Fprintf(os.Stderr, "Usage: %s -secret=<jwt-secret> -user=<user-id> [-ttl=<duration>]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s -secret=<jwt-secret> (-user=<user-id> | -widget=<widget-id>) [-ttl=<duration>]\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Example: %s -secret=my-secret -user=user123 -ttl=1h\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	if *userID == "" {
-		fmt.Fprintf(os.Stderr, "Error: user ID is required\n")
-		fmt.Fprintf(os.Stderr, "Usage: %s -secret=<jwt-secret> -user=<user-id> [-ttl=<duration>]\n", os.Args[0])
+	if *userID == "" && *widgetID == "" {
+		fmt.Fprintf(os.Stderr, "Error: user ID or widget ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s -secret=<jwt-secret> (-user=<user-id> | -widget=<widget-id>) [-ttl=<duration>]\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Example: %s -secret=my-secret -user=user123 -ttl=1h\n", os.Args[0])
 		os.Exit(1)
 	}
 
+	// The jti claim lets the token be revoked before it expires
+	jti, err := auth.NewTokenID()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating token: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Create the Claims
 	now := time.Now()
 	claims := jwt.MapClaims{
-		"user_id": *userID,
-		"iat":     now.Unix(),
-		"exp":     now.Add(*ttl).Unix(),
+		"jti": jti,
+		"iat": now.Unix(),
+		"exp": now.Add(*ttl).Unix(),
+	}
+
+	// Widget-scoped tokens carry only the widget ID and grant no private API access
+	if *widgetID != "" {
+		claims["widget_id"] = *widgetID
+	} else {
+		claims["user_id"] = *userID
+		if *role != "" {
+			claims["role"] = *role
+		}
 	}
 
 	// Create token
@@ -55,7 +75,12 @@ func main() {
 	// Optional: Show token info in verbose mode
 	if os.Getenv("VERBOSE") == "1" {
 		fmt.Fprintf(os.Stderr, "Token generated successfully:\n")
-		fmt.Fprintf(os.Stderr, "  User ID: %s\n", *userID)
+		if *widgetID != "" {
+			fmt.Fprintf(os.Stderr, "  Widget ID: %s\n", *widgetID)
+		} else {
+			fmt.Fprintf(os.Stderr, "  User ID: %s\n", *userID)
+		}
+		fmt.Fprintf(os.Stderr, "  Token ID: %s\n", jti)
 		fmt.Fprintf(os.Stderr, "  Issued At: %s\n", now.Format(time.RFC3339))
 		fmt.Fprintf(os.Stderr, "  Expires At: %s\n", now.Add(*ttl).Format(time.RFC3339))
 		fmt.Fprintf(os.Stderr, "  TTL: %s\n", *ttl)
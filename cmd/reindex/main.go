@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ad/leads-core/internal/config"
+	"github.com/ad/leads-core/internal/storage"
+)
+
+func main() {
+	var (
+		addr     = flag.String("addr", "", "Redis address, comma-separated for a cluster")
+		password = flag.String("password", "", "Redis password")
+		db       = flag.Int("db", 0, "Redis database")
+		timeout  = flag.Duration("timeout", 10*time.Minute, "Timeout of the rebuild (default: 10m)")
+	)
+	flag.Parse()
+
+	if *addr == "" {
+		fmt.Fprintf(os.Stderr, "Error: Redis address is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s -addr=<host:port>[,<host:port>...] [-password=<password>] [-db=<db>] [-timeout=<duration>]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Example: %s -addr=localhost:6379\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	redisClient, err := storage.NewRedisClient(config.RedisConfig{
+		Addresses: strings.Split(*addr, ","),
+		Password:  *password,
+		DB:        *db,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to Redis: %v\n", err)
+		os.Exit(1)
+	}
+	defer redisClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	// Rebuilds the time, type, status, PII and owner indexes from the widget records
+	widgetRepo := storage.NewRedisWidgetRepository(redisClient, storage.NewRedisStatsRepository(redisClient))
+	start := time.Now()
+	if err := widgetRepo.RebuildIndexes(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Error rebuilding indexes: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Widget indexes rebuilt in %s\n", time.Since(start).Round(time.Millisecond))
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Time zone names resolve in the scratch image too
 
 	_ "github.com/joho/godotenv/autoload"
 
@@ -18,6 +19,8 @@ import (
 	"github.com/ad/leads-core/internal/config"
 	"github.com/ad/leads-core/internal/handlers"
 	"github.com/ad/leads-core/internal/middleware"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/notify"
 	"github.com/ad/leads-core/internal/services"
 	"github.com/ad/leads-core/internal/storage"
 	"github.com/ad/leads-core/internal/validation"
@@ -63,13 +66,12 @@ func main() {
 
 	// Initialize metrics
 	metrics.Init()
+	routes := metrics.NewRouteTemplates(routeTemplates...)
+	metrics.SetRouteTemplates(routes)
 
 	// Initialize alerts
 	monitoring.InitAlerts()
 
-	// Initialize middleware logging
-	middleware.InitLogging()
-
 	logger.Info("Starting Leads Core service")
 
 	// Load configuration
@@ -85,6 +87,9 @@ func main() {
 		"redis_addrs": cfg.Redis.Addresses,
 	})
 
+	// Initialize middleware logging
+	middleware.InitLogging(cfg.Server.AccessLogFields, routes)
+
 	// Initialize Redis client
 	redisClient, err := storage.NewRedisClient(cfg.Redis)
 	if err != nil {
@@ -106,11 +111,13 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	const redisHealthCheckInterval = 30 * time.Second
 	connectionMonitor := monitoring.NewConnectionMonitor(underlyingClient)
-	go connectionMonitor.StartHealthCheck(ctx, 30*time.Second)
+	go connectionMonitor.StartHealthCheck(ctx, redisHealthCheckInterval)
 
 	// Start performance monitoring
 	performanceMonitor := monitoring.NewPerformanceMonitor()
+	monitoring.SetSlowQueryThreshold(cfg.Monitoring.SlowQueryThreshold)
 	go performanceMonitor.StartMetricsCollection(ctx, 15*time.Second)
 
 	// Start system monitoring with alerts
@@ -135,26 +142,108 @@ func main() {
 
 	// Initialize repositories
 	statsRepo := storage.NewRedisStatsRepository(monitoredRedisClient)
+	statsRepo.SetDailyRetention(cfg.TTL.DailyStatsDays)
 	widgetRepo := storage.NewRedisWidgetRepository(monitoredRedisClient, statsRepo)
 	submissionRepo := storage.NewRedisSubmissionRepository(monitoredRedisClient)
+	if cfg.Submission.EncryptionKey != "" {
+		fieldCipher, err := storage.NewFieldCipher(cfg.Submission.EncryptionKey)
+		if err != nil {
+			logger.Fatal("Invalid submission encryption key", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		submissionRepo.SetFieldCipher(fieldCipher)
+	}
+	notificationRepo := storage.NewRedisNotificationRepository(monitoredRedisClient)
 
 	// Initialize services
 	ttlConfig := services.TTLConfig{
-		DemoDays: cfg.TTL.DemoDays,
-		FreeDays: cfg.TTL.FreeDays,
-		ProDays:  cfg.TTL.ProDays,
+		DemoDays:          cfg.TTL.DemoDays,
+		FreeDays:          cfg.TTL.FreeDays,
+		ProDays:           cfg.TTL.ProDays,
+		ExpireDemoWidgets: cfg.TTL.DemoWidgetExpiry,
+		PIIRetentionDays:  cfg.TTL.PIIRetentionDays,
+		DailyStatsDays:    cfg.TTL.DailyStatsDays,
+	}
+	// Stats reads can be served from an in-process cache, stale while Redis is slow
+	var serviceStatsRepo storage.StatsRepository = statsRepo
+	if cfg.Redis.StatsCacheTTL > 0 {
+		serviceStatsRepo = storage.NewCachedStatsRepository(statsRepo, cfg.Redis.StatsCacheTTL, cfg.Redis.StatsLatencyBudget, cfg.Redis.StatsMaxStale)
+	}
+	widgetService := services.NewWidgetService(widgetRepo, submissionRepo, serviceStatsRepo, ttlConfig)
+	widgetService.SetTypeRegistry(models.NewTypeRegistry(cfg.Plans.AllowedTypes, cfg.Plans.DeniedTypes))
+	widgetService.SetWidgetLimit(cfg.Plans.MaxWidgets, storage.NewRedisLockRepository(monitoredRedisClient), cfg.Plans.CreateLockTTL)
+	widgetService.SetWidgetNameRules(cfg.Plans.MaxNameLength, cfg.Plans.UniqueNames)
+	widgetService.SetConfigHistoryLimit(cfg.Plans.ConfigHistoryLimit)
+	widgetService.SetRegion(cfg.Region.Default, services.NoopGeoLocator{})
+	widgetService.SetMaxFieldLength(cfg.Submission.MaxFieldLength)
+	widgetService.SetClientTimeWindow(cfg.Submission.ClientTimeMaxAge, cfg.Submission.ClientTimeMaxSkew)
+	widgetService.SetReservedFieldNames(cfg.Submission.ReservedFields, cfg.Submission.ReservedFieldMode)
+	if cfg.Submission.FormTokenSecret != "" {
+		widgetService.SetFormTokenSigner(services.NewFormTokenSigner(cfg.Submission.FormTokenSecret))
+	}
+	widgetService.SetTailMaxWait(cfg.Submission.TailMaxWait)
+	widgetService.SetPausedTypesRepository(storage.NewRedisPausedTypesRepository(monitoredRedisClient))
+	widgetService.SetPreferencesRepository(storage.NewRedisPreferencesRepository(monitoredRedisClient))
+	if cfg.TTL.DemoWidgetExpiry {
+		go widgetService.StartExpiredWidgetsCleanup(ctx, time.Hour)
+	}
+	go widgetService.StartPIIRedaction(ctx, time.Hour)
+	if cfg.TTL.InactiveWidgetDays > 0 {
+		go widgetService.StartInactiveWidgetsSweep(ctx, cfg.TTL.InactiveSweepInterval, time.Duration(cfg.TTL.InactiveWidgetDays)*24*time.Hour)
 	}
-	widgetService := services.NewWidgetService(widgetRepo, submissionRepo, statsRepo, ttlConfig)
+
+	// Initialize submission notifications (digests are flushed in the background)
+	var notifier services.Notifier = services.NewChatNotifier(services.LogNotifier{})
+	if cfg.SMTP.Host != "" && cfg.SMTP.From != "" {
+		notifier = notify.NewEmailNotifier(notify.NewSMTPTransport(cfg.SMTP), cfg.SMTP.From, notifier)
+	}
+	notificationService := services.NewNotificationService(notificationRepo, widgetRepo, submissionRepo, notifier)
+	widgetService.SetNotificationService(notificationService)
+	go notificationService.StartDigestFlusher(ctx, cfg.Notifications.DigestInterval)
 
 	// Initialize export service
 	exportService := services.NewExportService(submissionRepo, widgetRepo)
+	exportService.SetFilenameConfig(services.ExportFilenameConfig{
+		Template:   cfg.Export.FilenameTemplate,
+		PerFormat:  cfg.Export.FilenameTemplates,
+		DateFormat: cfg.Export.FilenameDateFormat,
+	})
+	exportService.SetDailyQuota(cfg.Plans.DailyExports, storage.NewRedisExportQuotaRepository(monitoredRedisClient))
+	exportService.SetRowLimits(cfg.Plans.ExportRows)
+	exportService.SetRangeLimit(cfg.Export.MaxRange, cfg.Export.UnboundedMax)
+	exportService.SetConcurrencyLimit(cfg.Export.MaxConcurrent, cfg.Export.MaxConcurrentCluster, cfg.Export.SlotLeaseTTL, storage.NewRedisExportSlotRepository(monitoredRedisClient))
+
+	// Initialize asynchronous export jobs
+	exportJobRepo := storage.NewRedisExportJobRepository(monitoredRedisClient, cfg.Export.JobTTL)
+	exportJobService := services.NewExportJobService(exportJobRepo, widgetRepo, exportService, 100)
+	exportJobService.SetRetryPolicy(cfg.Export.JobMaxAttempts, cfg.Export.JobRetryBackoff)
+	if cfg.Export.Destination == "s3" {
+		exportJobService.SetDestination(services.NewS3ExportDestination(services.S3DestinationConfig{
+			Endpoint:  cfg.Export.S3Endpoint,
+			Region:    cfg.Export.S3Region,
+			Bucket:    cfg.Export.S3Bucket,
+			AccessKey: cfg.Export.S3AccessKey,
+			SecretKey: cfg.Export.S3SecretKey,
+			Prefix:    cfg.Export.S3Prefix,
+			URLTTL:    cfg.Export.S3URLTTL,
+		}))
+	}
+	exportJobService.Start(ctx, cfg.Export.JobWorkers)
 
 	// Initialize JWT validator
 	jwtValidator := auth.NewJWTValidator(cfg.JWT.Secret)
+	jwtValidator.SetLeeway(cfg.JWT.Leeway)
+	jwtValidator.SetRevocationList(storage.NewRedisRevokedTokenRepository(monitoredRedisClient))
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(jwtValidator, cfg.JWT.AllowDemo)
 	rateLimiter := middleware.NewRateLimiter(redisClient, cfg.RateLimit)
+	apiCORS := middleware.NewAPICORS(cfg.CORS)
+	maintenance := middleware.NewMaintenance(cfg.Maintenance)
+	maintenance.SetRepository(storage.NewRedisMaintenanceRepository(monitoredRedisClient))
+	problemErrors := middleware.NewProblemErrors(cfg.Server.ErrorFormat)
+	redisGate := middleware.NewRedisHealthGate(connectionMonitor.Health(), cfg.Redis.UnhealthyThreshold, redisHealthCheckInterval)
 
 	// Initialize validator
 	validator, err := validation.NewSchemaValidator()
@@ -163,12 +252,23 @@ func main() {
 			"error": err.Error(),
 		})
 	}
+	validator.SetJSONLimits(cfg.Server.MaxJSONDepth, cfg.Server.MaxJSONKeys)
+
+	// Initialize submission imports
+	importService := services.NewImportService(widgetService, validator)
 
 	// Initialize handlers
-	widgetHandler := handlers.NewWidgetHandler(widgetService, exportService, validator)
+	widgetHandler := handlers.NewWidgetHandler(widgetService, exportService, importService, validator)
+	widgetHandler.SetExportJobService(exportJobService)
 	publicHandler := handlers.NewPublicHandler(widgetService, validator)
 	userHandler := handlers.NewUserHandler(widgetService, validator)
 	healthHandler := handlers.NewHealthHandler(redisClient)
+	adminHandler := handlers.NewAdminHandler(maintenance, validator)
+	tokenService := services.NewTokenService(jwtValidator, storage.NewRedisRefreshTokenRepository(monitoredRedisClient), cfg.JWT.AccessTTL, cfg.JWT.RefreshTTL)
+	authHandler := handlers.NewAuthHandler(tokenService, validator)
+	adminHandler.SetWidgetService(widgetService)
+	adminHandler.SetJWTValidator(jwtValidator)
+	adminHandler.SetRateLimiter(rateLimiter)
 
 	// Panel handler
 	panelHandler := panel.NewHandler()
@@ -192,21 +292,38 @@ func main() {
 	mux.Handle("/settings", settingsHandler)
 
 	// Public endpoints (with logging, metrics, and rate limiting)
-	// These handle /widgets/{id}/submit and /widgets/{id}/events
-	publicChain := middleware.CORS(middleware.LogRequests(metrics.HTTPMiddleware(rateLimiter.RateLimit(http.HandlerFunc(routePublicWidgetEndpoints(publicHandler))))))
+	// These handle /widgets/{id}/submit, /widgets/{id}/events, /widgets/{id}/track and /widgets/{id}/form-token
+	publicChain := middleware.CORS(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(middleware.MarkWrites(isTrackingPixelRequest, maintenance.Handle(redisGate.Handle(authMiddleware.WidgetScope(rateLimiter.RateLimit(http.HandlerFunc(routePublicWidgetEndpoints(publicHandler)))))))))))
 	mux.Handle("/widgets/", publicChain)
 
 	// Private API endpoints (with logging, metrics, and authentication only - no rate limiting)
-	// API v1 endpoints for authenticated users
-	privateWidgetsChain := middleware.CORS(middleware.LogRequests(metrics.HTTPMiddleware(authMiddleware.Authenticate(http.HandlerFunc(routePrivateWidgetEndpoints(widgetHandler))))))
+	// API v1 endpoints for authenticated users; OPTIONS is answered without authentication
+	privateWidgetsChain := apiCORS.Handle(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(middleware.AnswerOptions(privateWidgetMethods, maintenance.Handle(redisGate.Handle(authMiddleware.Authenticate(http.HandlerFunc(routePrivateWidgetEndpoints(widgetHandler))))))))))
 
-	privateUsersChain := middleware.CORS(middleware.LogRequests(metrics.HTTPMiddleware(authMiddleware.Authenticate(http.HandlerFunc(routeUserEndpoints(userHandler))))))
+	privateUsersChain := apiCORS.Handle(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(middleware.AnswerOptions(userMethods, maintenance.Handle(redisGate.Handle(authMiddleware.Authenticate(http.HandlerFunc(routeUserEndpoints(userHandler))))))))))
 
 	mux.Handle("/api/v1/widgets/", privateWidgetsChain)
 	mux.Handle("/api/v1/widgets", privateWidgetsChain)
 	mux.Handle("/api/v1/users/", privateUsersChain)
 	mux.Handle("/api/v1/user", privateUsersChain)
 
+	// Admin endpoints bypass maintenance mode so it can be switched off again
+	adminChain := apiCORS.Handle(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(authMiddleware.Authenticate(http.HandlerFunc(adminHandler.Maintenance))))))
+	mux.Handle("/api/v1/admin/maintenance", adminChain)
+	pausedTypesChain := apiCORS.Handle(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(authMiddleware.Authenticate(http.HandlerFunc(adminHandler.PausedTypes))))))
+	mux.Handle("/api/v1/admin/paused-types", pausedTypesChain)
+	mux.Handle("/api/v1/admin/paused-types/", pausedTypesChain)
+	mux.Handle("/api/v1/admin/ratelimit", apiCORS.Handle(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(authMiddleware.Authenticate(http.HandlerFunc(adminHandler.RateLimit)))))))
+	mux.Handle("/api/v1/admin/indexes/rebuild", apiCORS.Handle(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(authMiddleware.Authenticate(http.HandlerFunc(adminHandler.RebuildIndexes)))))))
+	mux.Handle("/api/v1/admin/tokens/revoke", apiCORS.Handle(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(authMiddleware.Authenticate(http.HandlerFunc(adminHandler.RevokeToken)))))))
+	mux.Handle("/api/v1/admin/widgets/", apiCORS.Handle(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(authMiddleware.Authenticate(http.HandlerFunc(adminHandler.WidgetIndexes)))))))
+
+	// Token endpoints bypass maintenance mode, clients need fresh access tokens to read;
+	// refresh and revoke are authenticated by the refresh token itself
+	mux.Handle("/api/v1/auth/token", apiCORS.Handle(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(redisGate.Handle(authMiddleware.Authenticate(http.HandlerFunc(authHandler.IssueTokens))))))))
+	mux.Handle("/api/v1/auth/refresh", apiCORS.Handle(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(redisGate.Handle(http.HandlerFunc(authHandler.RefreshToken)))))))
+	mux.Handle("/api/v1/auth/revoke", apiCORS.Handle(middleware.LogRequests(metrics.HTTPMiddleware(problemErrors.Handle(redisGate.Handle(http.HandlerFunc(authHandler.RevokeToken)))))))
+
 	// Create HTTP server
 	server := &http.Server{
 		Addr:         net.JoinHostPort(cfg.Server.Host, cfg.Server.Port),
@@ -246,72 +363,182 @@ func main() {
 	logger.Info("Server exited gracefully")
 }
 
+// routeTemplates lists the routes handled below, used as the route label of HTTP metrics
+var routeTemplates = []string{
+	"/widgets/{id}/submit",
+	"/widgets/{id}/events",
+	"/widgets/{id}/track",
+	"/widgets/{id}/form-token",
+	"/api/v1/widgets",
+	"/api/v1/widgets/bulk-stats-reset",
+	"/api/v1/widgets/summary",
+	"/api/v1/widgets/types/overview",
+	"/api/v1/widgets/type-stats",
+	"/api/v1/widgets/compare",
+	"/api/v1/widgets/{id}",
+	"/api/v1/widgets/{id}/stats",
+	"/api/v1/widgets/{id}/stats/heatmap",
+	"/api/v1/widgets/{id}/stats/timeseries",
+	"/api/v1/widgets/{id}/fields/{field}/distribution",
+	"/api/v1/widgets/{id}/submissions",
+	"/api/v1/widgets/{id}/submissions/tail",
+	"/api/v1/widgets/{id}/submissions/by-correlation",
+	"/api/v1/widgets/{id}/submissions/bulk-delete",
+	"/api/v1/widgets/{id}/submissions/{sid}",
+	"/api/v1/widgets/{id}/config",
+	"/api/v1/widgets/{id}/config/history",
+	"/api/v1/widgets/{id}/config/restore/{version}",
+	"/api/v1/widgets/{id}/archive",
+	"/api/v1/widgets/{id}/import",
+	"/api/v1/widgets/{id}/export",
+	"/api/v1/widgets/{id}/export/jobs",
+	"/api/v1/widgets/{id}/export/jobs/{job_id}/cancel",
+	"/api/v1/widgets/{id}/export/jobs/{job_id}/download",
+	"/api/v1/widgets/{id}/schema/inferred",
+	"/api/v1/user",
+	"/api/v1/auth/token",
+	"/api/v1/auth/refresh",
+	"/api/v1/auth/revoke",
+	"/api/v1/users/{id}/ttl",
+	"/api/v1/users/{id}/preferences",
+	"/api/v1/admin/maintenance",
+	"/api/v1/admin/paused-types",
+	"/api/v1/admin/paused-types/{type}",
+	"/api/v1/admin/widgets/{id}/indexes",
+	"/api/v1/admin/indexes/rebuild",
+	"/api/v1/admin/tokens/revoke",
+	"/api/v1/admin/ratelimit",
+}
+
 // routePrivateWidgetEndpoints routes private widget endpoints for /api/v1/widgets/*
 func routePrivateWidgetEndpoints(handler *handlers.WidgetHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Remove /api/v1/widgets prefix to get the actual path
-		path := strings.TrimPrefix(r.URL.Path, "/api/v1/widgets")
+		methods, serve := privateWidgetRoute(handler, r.URL.Path)
+		if middleware.AllowMethods(w, r, methods...) {
+			serve(w, r)
+		}
+	}
+}
 
-		switch {
-		case path == "" || path == "/":
-			// GET /api/v1/widgets - list widgets
-			// POST /api/v1/widgets - create widget
-			switch r.Method {
-			case http.MethodGet:
-				handler.GetWidgets(w, r)
-			case http.MethodPost:
+// privateWidgetMethods returns the methods a /api/v1/widgets path accepts
+func privateWidgetMethods(path string) []string {
+	methods, _ := privateWidgetRoute(nil, path)
+	return methods
+}
+
+// privateWidgetRoute resolves a /api/v1/widgets path to the methods it accepts and the
+// handler serving them, which reconstructs the URL the handler expects
+func privateWidgetRoute(handler *handlers.WidgetHandler, fullPath string) ([]string, http.HandlerFunc) {
+	// Remove /api/v1/widgets prefix to get the actual path
+	path := strings.TrimPrefix(fullPath, "/api/v1/widgets")
+
+	// withPath reconstructs the URL as prefix + path for the handler
+	withPath := func(prefix string, serve func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			r.URL.Path = prefix + path
+			serve(w, r)
+		}
+	}
+
+	switch {
+	case path == "" || path == "/":
+		// GET /api/v1/widgets - list widgets
+		// POST /api/v1/widgets - create widget
+		return []string{http.MethodGet, http.MethodPost}, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
 				handler.CreateWidget(w, r)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		case path == "/summary":
-			// GET /api/v1/widgets/summary
-			if r.Method == http.MethodGet {
-				handler.GetWidgetsSummary(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		case strings.HasSuffix(path, "/stats"):
-			// GET /api/v1/widgets/{id}/stats
-			// Reconstruct URL as /widgets/{id}/stats for handler
-			r.URL.Path = "/widgets" + path
-			handler.GetWidgetStats(w, r)
-		case strings.HasSuffix(path, "/submissions"):
-			// GET /api/v1/widgets/{id}/submissions
-			// Reconstruct URL as /widgets/{id}/submissions for handler
-			r.URL.Path = "/widgets" + path
-			handler.GetWidgetSubmissions(w, r)
-		case strings.HasSuffix(path, "/config"):
-			// PUT /api/v1/widgets/{id}/config
-			// Reconstruct URL as /api/v1/widgets/{id}/config for handler
-			r.URL.Path = "/api/v1/widgets" + path
-			if r.Method == http.MethodPut {
-				handler.UpdateWidgetConfig(w, r)
 			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				handler.GetWidgets(w, r)
 			}
-		case strings.HasSuffix(path, "/export"):
-			// GET /api/v1/widgets/{id}/export
-			// Reconstruct URL as /widgets/{id}/export for handler
-			r.URL.Path = "/widgets" + path
-			handler.ExportWidgetSubmissions(w, r)
-		default:
-			// GET /api/v1/widgets/{id} - get widget
-			// POST /api/v1/widgets/{id} - update widget
-			// DELETE /api/v1/widgets/{id} - delete widget
-			// Reconstruct URL as /widgets/{id} for handler
-			r.URL.Path = "/widgets" + path
+		}
+	case path == "/bulk-stats-reset":
+		// POST /api/v1/widgets/bulk-stats-reset
+		return []string{http.MethodPost}, handler.BulkResetStats
+	case path == "/summary":
+		// GET /api/v1/widgets/summary
+		return []string{http.MethodGet}, handler.GetWidgetsSummary
+	case path == "/types/overview":
+		// GET /api/v1/widgets/types/overview
+		return []string{http.MethodGet}, handler.GetWidgetTypesOverview
+	case path == "/type-stats":
+		// GET /api/v1/widgets/type-stats
+		return []string{http.MethodGet}, handler.GetWidgetTypeStats
+	case path == "/compare":
+		// GET /api/v1/widgets/compare?a={id}&b={id}
+		return []string{http.MethodGet}, handler.CompareWidgets
+	case strings.HasSuffix(path, "/stats/heatmap"):
+		// GET /api/v1/widgets/{id}/stats/heatmap
+		return []string{http.MethodGet}, withPath("/widgets", handler.GetSubmissionHeatmap)
+	case strings.HasSuffix(path, "/stats/timeseries"):
+		// GET /api/v1/widgets/{id}/stats/timeseries
+		return []string{http.MethodGet}, withPath("/widgets", handler.GetWidgetStatsSeries)
+	case strings.Contains(path, "/fields/") && strings.HasSuffix(path, "/distribution"):
+		// GET /api/v1/widgets/{id}/fields/{field}/distribution
+		return []string{http.MethodGet}, withPath("/widgets", handler.GetFieldDistribution)
+	case strings.HasSuffix(path, "/stats"):
+		// GET /api/v1/widgets/{id}/stats
+		return []string{http.MethodGet}, withPath("/widgets", handler.GetWidgetStats)
+	case strings.HasSuffix(path, "/schema/inferred"):
+		// GET /api/v1/widgets/{id}/schema/inferred
+		return []string{http.MethodGet}, withPath("/widgets", handler.GetInferredSchema)
+	case strings.HasSuffix(path, "/submissions/by-correlation"):
+		// GET /api/v1/widgets/{id}/submissions/by-correlation
+		return []string{http.MethodGet}, withPath("/widgets", handler.GetSubmissionByCorrelation)
+	case strings.HasSuffix(path, "/submissions/tail"):
+		// GET /api/v1/widgets/{id}/submissions/tail
+		return []string{http.MethodGet}, withPath("/widgets", handler.TailSubmissions)
+	case strings.HasSuffix(path, "/submissions/bulk-delete"):
+		// POST /api/v1/widgets/{id}/submissions/bulk-delete
+		return []string{http.MethodPost}, withPath("/widgets", handler.BulkDeleteSubmissions)
+	case strings.Contains(path, "/submissions/"):
+		// DELETE /api/v1/widgets/{id}/submissions/{sid}
+		return []string{http.MethodDelete}, withPath("/widgets", handler.DeleteSubmission)
+	case strings.HasSuffix(path, "/submissions"):
+		// GET /api/v1/widgets/{id}/submissions
+		return []string{http.MethodGet}, withPath("/widgets", handler.GetWidgetSubmissions)
+	case strings.HasSuffix(path, "/config/history"):
+		// GET /api/v1/widgets/{id}/config/history
+		return []string{http.MethodGet}, withPath("/widgets", handler.GetWidgetConfigHistory)
+	case strings.Contains(path, "/config/restore/"):
+		// POST /api/v1/widgets/{id}/config/restore/{version}
+		return []string{http.MethodPost}, withPath("/widgets", handler.RestoreWidgetConfig)
+	case strings.HasSuffix(path, "/config"):
+		// PUT /api/v1/widgets/{id}/config
+		return []string{http.MethodPut}, withPath("/api/v1/widgets", handler.UpdateWidgetConfig)
+	case strings.HasSuffix(path, "/archive"):
+		// POST /api/v1/widgets/{id}/archive - archive widget
+		// DELETE /api/v1/widgets/{id}/archive - unarchive widget
+		return []string{http.MethodPost, http.MethodDelete}, withPath("/widgets", handler.ArchiveWidget)
+	case strings.HasSuffix(path, "/import"):
+		// POST /api/v1/widgets/{id}/import
+		return []string{http.MethodPost}, withPath("/widgets", handler.ImportWidgetSubmissions)
+	case strings.HasSuffix(path, "/export/jobs"):
+		// GET /api/v1/widgets/{id}/export/jobs - list export jobs
+		// POST /api/v1/widgets/{id}/export/jobs - create export job
+		return []string{http.MethodGet, http.MethodPost}, withPath("/widgets", handler.ExportJobs)
+	case strings.Contains(path, "/export/jobs/") && strings.HasSuffix(path, "/cancel"):
+		// POST /api/v1/widgets/{id}/export/jobs/{job_id}/cancel
+		return []string{http.MethodPost}, withPath("/widgets", handler.CancelExportJob)
+	case strings.Contains(path, "/export/jobs/") && strings.HasSuffix(path, "/download"):
+		// GET /api/v1/widgets/{id}/export/jobs/{job_id}/download
+		return []string{http.MethodGet}, withPath("/widgets", handler.DownloadExportJob)
+	case strings.HasSuffix(path, "/export"):
+		// GET /api/v1/widgets/{id}/export
+		return []string{http.MethodGet}, withPath("/widgets", handler.ExportWidgetSubmissions)
+	default:
+		// GET /api/v1/widgets/{id} - get widget
+		// POST /api/v1/widgets/{id} - update widget
+		// DELETE /api/v1/widgets/{id} - delete widget
+		return []string{http.MethodGet, http.MethodPost, http.MethodDelete}, withPath("/widgets", func(w http.ResponseWriter, r *http.Request) {
 			switch r.Method {
-			case http.MethodGet:
-				handler.GetWidget(w, r)
 			case http.MethodPost:
 				handler.UpdateWidget(w, r)
 			case http.MethodDelete:
 				handler.DeleteWidget(w, r)
 			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				handler.GetWidget(w, r)
 			}
-		}
+		})
 	}
 }
 
@@ -324,39 +551,73 @@ func routePublicWidgetEndpoints(handler *handlers.PublicHandler) http.HandlerFun
 		case strings.HasSuffix(path, "/submit"):
 			// POST /widgets/{id}/submit
 			handler.SubmitWidget(w, r)
+		case strings.HasSuffix(path, "/events") && r.Method == http.MethodGet:
+			// GET /widgets/{id}/events?type=view - tracking pixel
+			handler.TrackEvent(w, r)
 		case strings.HasSuffix(path, "/events"):
 			// POST /widgets/{id}/events
 			handler.RegisterEvent(w, r)
+		case strings.HasSuffix(path, "/track"):
+			// GET /widgets/{id}/track?field=value - tracking pixel
+			handler.TrackSubmission(w, r)
+		case strings.HasSuffix(path, "/form-token"):
+			// GET /widgets/{id}/form-token
+			handler.FormToken(w, r)
 		default:
 			http.Error(w, "Not found", http.StatusNotFound)
 		}
 	}
 }
 
+// isTrackingPixelRequest reports whether the request is a tracking pixel GET, which
+// registers events or submissions like a POST
+func isTrackingPixelRequest(r *http.Request) bool {
+	return r.Method == http.MethodGet && (strings.HasSuffix(r.URL.Path, "/events") || strings.HasSuffix(r.URL.Path, "/track"))
+}
+
 // routeUserEndpoints routes user endpoints for /api/v1/users/* and /api/v1/user
 func routeUserEndpoints(handler *handlers.UserHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path
+		methods, serve := userRoute(handler, r.URL.Path)
+		if methods == nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		if middleware.AllowMethods(w, r, methods...) {
+			serve(w, r)
+		}
+	}
+}
 
-		switch {
-		case path == "/api/v1/user":
-			// GET /api/v1/user
-			if r.Method == http.MethodGet {
-				handler.GetUser(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		case strings.HasPrefix(path, "/api/v1/users/") && strings.HasSuffix(path, "/ttl"):
-			// PUT /api/v1/users/{id}/ttl
-			// Remove the /api/v1 prefix and reconstruct URL as /users/{id}/ttl for handler
+// userMethods returns the methods a user endpoint path accepts, nil for unknown paths
+func userMethods(path string) []string {
+	methods, _ := userRoute(nil, path)
+	return methods
+}
+
+// userRoute resolves a user endpoint path to the methods it accepts and the handler
+// serving them
+func userRoute(handler *handlers.UserHandler, path string) ([]string, http.HandlerFunc) {
+	// withPath removes the /api/v1 prefix and reconstructs the URL as /users/{id}/... for handler
+	withPath := func(serve func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
 			r.URL.Path = strings.TrimPrefix(path, "/api/v1")
-			if r.Method == http.MethodPut {
-				handler.UpdateUserTTL(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		default:
-			http.Error(w, "Not found", http.StatusNotFound)
+			serve(w, r)
 		}
 	}
+
+	switch {
+	case path == "/api/v1/user":
+		// GET /api/v1/user
+		return []string{http.MethodGet}, handler.GetUser
+	case strings.HasPrefix(path, "/api/v1/users/") && strings.HasSuffix(path, "/ttl"):
+		// PUT /api/v1/users/{id}/ttl
+		return []string{http.MethodPut}, withPath(handler.UpdateUserTTL)
+	case strings.HasPrefix(path, "/api/v1/users/") && strings.HasSuffix(path, "/preferences"):
+		// GET /api/v1/users/{id}/preferences
+		// PUT /api/v1/users/{id}/preferences
+		return []string{http.MethodGet, http.MethodPut}, withPath(handler.UserPreferences)
+	default:
+		return nil, nil
+	}
 }
@@ -28,3 +28,65 @@ func TestServerStartup(t *testing.T) {
 	// This is a smoke test to catch import/compilation issues
 	t.Log("Basic compilation and handler tests passed")
 }
+
+func TestRoutePrivateWidgetEndpoints_Methods(t *testing.T) {
+	router := routePrivateWidgetEndpoints(&handlers.WidgetHandler{})
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		{http.MethodOptions, "/api/v1/widgets", http.StatusNoContent, "GET, POST, OPTIONS"},
+		{http.MethodOptions, "/api/v1/widgets/abc", http.StatusNoContent, "GET, POST, DELETE, OPTIONS"},
+		{http.MethodOptions, "/api/v1/widgets/abc/config", http.StatusNoContent, "PUT, OPTIONS"},
+		{http.MethodOptions, "/api/v1/widgets/abc/export/jobs", http.StatusNoContent, "GET, POST, OPTIONS"},
+		{http.MethodPut, "/api/v1/widgets/abc", http.StatusMethodNotAllowed, "GET, POST, DELETE, OPTIONS"},
+		{http.MethodGet, "/api/v1/widgets/abc/config", http.StatusMethodNotAllowed, "PUT, OPTIONS"},
+		{http.MethodDelete, "/api/v1/widgets/summary", http.StatusMethodNotAllowed, "GET, OPTIONS"},
+		{http.MethodPost, "/api/v1/widgets/type-stats", http.StatusMethodNotAllowed, "GET, OPTIONS"},
+		{http.MethodGet, "/api/v1/widgets/abc/export/jobs/job-1/cancel", http.StatusMethodNotAllowed, "POST, OPTIONS"},
+		{http.MethodGet, "/api/v1/widgets/abc/submissions/s1", http.StatusMethodNotAllowed, "DELETE, OPTIONS"},
+		{http.MethodDelete, "/api/v1/widgets/abc/submissions/bulk-delete", http.StatusMethodNotAllowed, "POST, OPTIONS"},
+		{http.MethodPost, "/api/v1/widgets/abc/stats/timeseries", http.StatusMethodNotAllowed, "GET, OPTIONS"},
+		{http.MethodPost, "/api/v1/widgets/abc/config/history", http.StatusMethodNotAllowed, "GET, OPTIONS"},
+		{http.MethodPut, "/api/v1/widgets/abc/config/restore/2", http.StatusMethodNotAllowed, "POST, OPTIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Expected Allow %q, got %q", tt.allow, got)
+			}
+		})
+	}
+}
+
+func TestRouteUserEndpoints_Methods(t *testing.T) {
+	router := routeUserEndpoints(&handlers.UserHandler{})
+
+	rec := httptest.NewRecorder()
+	router(rec, httptest.NewRequest(http.MethodOptions, "/api/v1/users/u1/preferences", nil))
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Allow") != "GET, PUT, OPTIONS" {
+		t.Errorf("Expected 204 with Allow: GET, PUT, OPTIONS, got %d %q", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	rec = httptest.NewRecorder()
+	router(rec, httptest.NewRequest(http.MethodPost, "/api/v1/user", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Errorf("Expected 405 with Allow: GET, OPTIONS, got %d %q", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	rec = httptest.NewRecorder()
+	router(rec, httptest.NewRequest(http.MethodOptions, "/api/v1/users/u1/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown paths, got %d", rec.Code)
+	}
+}
@@ -9,8 +9,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ad/leads-core/internal/errors"
 	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/storage"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
 )
 
 func TestJWTValidator_ValidateToken(t *testing.T) {
@@ -86,6 +90,46 @@ func TestJWTValidator_ValidateToken(t *testing.T) {
 	}
 }
 
+func TestJWTValidator_WidgetToken(t *testing.T) {
+	secret := "test-secret-key"
+	validator := NewJWTValidator(secret)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"widget_id": "widget-123",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"iat":       time.Now().Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("Failed to create test token: %v", err)
+	}
+
+	widgetID, err := validator.ValidateWidgetToken("Bearer " + tokenString)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if widgetID != "widget-123" {
+		t.Errorf("Expected widget ID widget-123, but got %s", widgetID)
+	}
+
+	// Widget-scoped tokens must not authenticate users
+	if user, err := validator.ValidateToken(tokenString); err == nil || user != nil {
+		t.Errorf("Expected widget token to be rejected for user authentication, got user %v", user)
+	}
+
+	// User tokens are not widget tokens
+	userToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "test-user-123",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	userTokenString, _ := userToken.SignedString([]byte(secret))
+
+	if _, err := validator.ValidateWidgetToken(userTokenString); err == nil {
+		t.Error("Expected user token to be rejected as widget token")
+	}
+}
+
 func TestJWTValidator_ExpiredToken(t *testing.T) {
 	secret := "test-secret-key"
 	validator := NewJWTValidator(secret)
@@ -111,6 +155,113 @@ func TestJWTValidator_ExpiredToken(t *testing.T) {
 	}
 }
 
+func TestJWTValidator_Leeway(t *testing.T) {
+	secret := "test-secret-key"
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		leeway *time.Duration
+		valid  bool
+	}{
+		{"expired within leeway", jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}, nil, true},
+		{"expired beyond leeway", jwt.MapClaims{"exp": now.Add(-2 * time.Minute).Unix()}, nil, false},
+		{"issued ahead within leeway", jwt.MapClaims{"iat": now.Add(10 * time.Second).Unix()}, nil, true},
+		{"issued ahead beyond leeway", jwt.MapClaims{"iat": now.Add(2 * time.Minute).Unix()}, nil, false},
+		{"not yet valid within leeway", jwt.MapClaims{"nbf": now.Add(10 * time.Second).Unix()}, nil, true},
+		{"expired without leeway", jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}, new(time.Duration), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewJWTValidator(secret)
+			if tt.leeway != nil {
+				validator.SetLeeway(*tt.leeway)
+			}
+
+			tt.claims["user_id"] = "test-user-123"
+			tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tt.claims).SignedString([]byte(secret))
+			if err != nil {
+				t.Fatalf("Failed to create token: %v", err)
+			}
+
+			_, err = validator.ValidateToken(tokenString)
+			if tt.valid && err != nil {
+				t.Errorf("Expected token to be accepted, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("Expected token to be rejected")
+			}
+		})
+	}
+}
+
+func TestJWTValidator_Revocation(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	client := storage.NewRedisClientWithUniversal(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	validator := NewJWTValidator("test-secret-key")
+	validator.SetLeeway(0)
+	validator.SetRevocationList(storage.NewRedisRevokedTokenRepository(client))
+
+	now := time.Now()
+	user := &models.User{ID: "test-user-123"}
+	revokedToken, err := validator.IssueToken(user, now, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	otherToken, err := validator.IssueToken(user, now, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	result, err := validator.RevokeToken(context.Background(), "Bearer "+revokedToken, now)
+	if err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if !result.Revoked || result.JTI == "" {
+		t.Fatalf("Expected the token to be revoked, got %+v", result)
+	}
+
+	if _, err := validator.ValidateToken(revokedToken); err == nil {
+		t.Error("Expected revoked token to be rejected")
+	}
+	if _, err := validator.ValidateToken(otherToken); err != nil {
+		t.Errorf("Expected other token to remain valid, got %v", err)
+	}
+
+	// The entry lives as long as the token
+	key := storage.GenerateRevokedTokenKey(result.JTI)
+	if ttl := mr.TTL(key); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("Expected revocation entry TTL of about an hour, got %v", ttl)
+	}
+	mr.FastForward(time.Hour + time.Second)
+	if mr.Exists(key) {
+		t.Error("Expected revocation entry to expire with the token")
+	}
+
+	// Expired tokens need no entry, tokens without jti can't be revoked
+	expired, err := validator.IssueToken(user, now.Add(-2*time.Hour), time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	if result, err := validator.RevokeToken(context.Background(), expired, now); err != nil || result.Revoked {
+		t.Errorf("Expected expired token not to be listed, got %+v, %v", result, err)
+	}
+	withoutID, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "test-user-123"}).SignedString([]byte("test-secret-key"))
+	if _, err := validator.RevokeToken(context.Background(), withoutID, now); err != errors.ErrTokenWithoutID {
+		t.Errorf("Expected ErrTokenWithoutID, got %v", err)
+	}
+	if _, err := validator.RevokeToken(context.Background(), "not-a-token", now); err == nil {
+		t.Error("Expected an invalid token to be refused")
+	}
+}
+
 func TestJWTValidator_WrongSecret(t *testing.T) {
 	secret := "test-secret-key"
 	wrongSecret := "wrong-secret-key"
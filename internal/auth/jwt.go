@@ -2,9 +2,13 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/ad/leads-core/internal/errors"
 	"github.com/ad/leads-core/internal/models"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -15,30 +19,208 @@ type ContextKey string
 const (
 	// UserContextKey is the context key for user data
 	UserContextKey ContextKey = "user"
+	// WidgetScopeContextKey is the context key for the widget ID of a widget-scoped token
+	WidgetScopeContextKey ContextKey = "widget_scope"
 )
 
+// DefaultJWTLeeway is the clock skew tolerated on the exp, nbf and iat claims
+const DefaultJWTLeeway = 30 * time.Second
+
+// RevocationList holds the jti claims of revoked tokens
+type RevocationList interface {
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
 // JWTValidator handles JWT token validation
 type JWTValidator struct {
-	secret []byte
+	secret      []byte
+	leeway      time.Duration
+	revocations RevocationList
 }
 
 // NewJWTValidator creates a new JWT validator
 func NewJWTValidator(secret string) *JWTValidator {
 	return &JWTValidator{
 		secret: []byte(secret),
+		leeway: DefaultJWTLeeway,
 	}
 }
 
+// SetLeeway sets the clock skew tolerated on the exp, nbf and iat claims, so tokens of
+// clients with slightly off clocks aren't rejected right after issue or before expiry
+// (0 disables it)
+func (v *JWTValidator) SetLeeway(leeway time.Duration) {
+	v.leeway = leeway
+}
+
+// SetRevocationList makes validation reject tokens whose jti is on the revocation list
+// and enables RevokeToken
+func (v *JWTValidator) SetRevocationList(revocations RevocationList) {
+	v.revocations = revocations
+}
+
 // Claims represents JWT claims structure
 type Claims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username,omitempty"`
 	Plan     string `json:"plan,omitempty"`
+	Role     string `json:"role,omitempty"`
+	WidgetID string `json:"widget_id,omitempty"` // Restricts the token to public endpoints of one widget
 	jwt.RegisteredClaims
 }
 
 // ValidateToken validates JWT token and returns user data
 func (v *JWTValidator) ValidateToken(tokenString string) (*models.User, error) {
+	claims, err := v.parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	// Widget-scoped tokens never grant private API access
+	if claims.WidgetID != "" {
+		return nil, fmt.Errorf("widget-scoped token cannot be used for user authentication")
+	}
+
+	// Validate required fields
+	if claims.UserID == "" {
+		return nil, fmt.Errorf("user_id claim is required")
+	}
+
+	// Create user model
+	user := &models.User{
+		ID:       claims.UserID,
+		Username: claims.Username,
+		Plan:     claims.Plan,
+		Role:     claims.Role,
+	}
+
+	return user, nil
+}
+
+// ParseToken validates a token and returns its claims
+func (v *JWTValidator) ParseToken(tokenString string) (*Claims, error) {
+	return v.parseClaims(tokenString)
+}
+
+// IsTokenRevoked checks if the jti is on the revocation list; without one nothing is revoked
+func (v *JWTValidator) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if v.revocations == nil || jti == "" {
+		return false, nil
+	}
+	return v.revocations.IsRevoked(ctx, jti)
+}
+
+// ValidateWidgetToken validates a widget-scoped token and returns the widget ID it is scoped to
+func (v *JWTValidator) ValidateWidgetToken(tokenString string) (string, error) {
+	claims, err := v.parseClaims(tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	if claims.WidgetID == "" {
+		return "", fmt.Errorf("widget_id claim is required")
+	}
+
+	return claims.WidgetID, nil
+}
+
+// IsWidgetToken reports whether the token carries a widget_id claim, without verifying
+// it. Tokens that can't be decoded aren't widget tokens.
+func (v *JWTValidator) IsWidgetToken(tokenString string) bool {
+	claims := &Claims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(strings.TrimPrefix(tokenString, "Bearer "), claims); err != nil {
+		return false
+	}
+	return claims.WidgetID != ""
+}
+
+// IssueToken signs an access token for the user, valid from now for ttl
+func (v *JWTValidator) IssueToken(user *models.User, now time.Time, ttl time.Duration) (string, error) {
+	jti, err := NewTokenID()
+	if err != nil {
+		return "", err
+	}
+	claims := &Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Plan:     user.Plan,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(v.secret)
+}
+
+// RevokeToken puts the token's jti on the revocation list until the token expires.
+// Expired tokens are still verified but not listed, they're rejected anyway.
+func (v *JWTValidator) RevokeToken(ctx context.Context, tokenString string, now time.Time) (*models.RevokedToken, error) {
+	if v.revocations == nil {
+		return nil, fmt.Errorf("token revocation is not enabled")
+	}
+
+	// Only the signature is verified, a token that isn't valid yet can still be revoked
+	claims, err := v.verifyClaims(tokenString, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrTokenInvalid, err)
+	}
+	if claims.ID == "" {
+		return nil, errors.ErrTokenWithoutID
+	}
+
+	result := &models.RevokedToken{JTI: claims.ID}
+	var ttl time.Duration
+	if claims.ExpiresAt != nil {
+		// Keep the entry as long as the leeway lets the token pass after expiry
+		expiresAt := claims.ExpiresAt.Add(v.leeway)
+		result.ExpiresAt = &expiresAt
+		if ttl = expiresAt.Sub(now); ttl <= 0 {
+			return result, nil
+		}
+	}
+
+	if err := v.revocations.Revoke(ctx, claims.ID, ttl); err != nil {
+		return nil, fmt.Errorf("failed to revoke token: %w", err)
+	}
+	result.Revoked = true
+	return result, nil
+}
+
+// NewTokenID returns a random jti claim value
+func NewTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// parseClaims parses and verifies a token and returns its claims, rejecting tokens on
+// the revocation list
+func (v *JWTValidator) parseClaims(tokenString string) (*Claims, error) {
+	claims, err := v.verifyClaims(tokenString, jwt.WithLeeway(v.leeway), jwt.WithIssuedAt())
+	if err != nil {
+		return nil, err
+	}
+
+	if v.revocations != nil && claims.ID != "" {
+		revoked, err := v.revocations.IsRevoked(context.Background(), claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if revoked {
+			return nil, fmt.Errorf("token has been revoked")
+		}
+	}
+
+	return claims, nil
+}
+
+// verifyClaims parses and verifies a token signature and returns its claims
+func (v *JWTValidator) verifyClaims(tokenString string, options ...jwt.ParserOption) (*Claims, error) {
 	// Remove "Bearer " prefix if present
 	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
@@ -49,7 +231,7 @@ func (v *JWTValidator) ValidateToken(tokenString string) (*models.User, error) {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return v.secret, nil
-	})
+	}, options...)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -61,19 +243,7 @@ func (v *JWTValidator) ValidateToken(tokenString string) (*models.User, error) {
 		return nil, fmt.Errorf("invalid token claims")
 	}
 
-	// Validate required fields
-	if claims.UserID == "" {
-		return nil, fmt.Errorf("user_id claim is required")
-	}
-
-	// Create user model
-	user := &models.User{
-		ID:       claims.UserID,
-		Username: claims.Username,
-		Plan:     claims.Plan,
-	}
-
-	return user, nil
+	return claims, nil
 }
 
 // GetUserFromContext extracts user from context
@@ -86,3 +256,14 @@ func GetUserFromContext(ctx context.Context) (*models.User, bool) {
 func SetUserInContext(ctx context.Context, user *models.User) context.Context {
 	return context.WithValue(ctx, UserContextKey, user)
 }
+
+// GetWidgetScopeFromContext extracts the widget ID of a widget-scoped token from context
+func GetWidgetScopeFromContext(ctx context.Context) (string, bool) {
+	widgetID, ok := ctx.Value(WidgetScopeContextKey).(string)
+	return widgetID, ok && widgetID != ""
+}
+
+// SetWidgetScopeInContext adds the widget ID of a widget-scoped token to context
+func SetWidgetScopeInContext(ctx context.Context, widgetID string) context.Context {
+	return context.WithValue(ctx, WidgetScopeContextKey, widgetID)
+}
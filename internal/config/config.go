@@ -15,19 +15,33 @@ const ConfigFileName = "/data/options.json"
 
 // Config holds all configuration for the application
 type Config struct {
-	Server    ServerConfig    `json:"SERVER"`
-	Redis     RedisConfig     `json:"REDIS"`
-	JWT       JWTConfig       `json:"JWT"`
-	RateLimit RateLimitConfig `json:"RATE_LIMIT"`
-	TTL       TTLConfig       `json:"TTL"`
+	Server        ServerConfig       `json:"SERVER"`
+	Redis         RedisConfig        `json:"REDIS"`
+	JWT           JWTConfig          `json:"JWT"`
+	RateLimit     RateLimitConfig    `json:"RATE_LIMIT"`
+	TTL           TTLConfig          `json:"TTL"`
+	Plans         PlanConfig         `json:"PLANS"`
+	Monitoring    MonitoringConfig   `json:"MONITORING"`
+	Region        RegionConfig       `json:"REGION"`
+	Submission    SubmissionConfig   `json:"SUBMISSION"`
+	CORS          CORSConfig         `json:"CORS"`
+	Notifications NotificationConfig `json:"NOTIFICATIONS"`
+	SMTP          SMTPConfig         `json:"SMTP"`
+	Maintenance   MaintenanceConfig  `json:"MAINTENANCE"`
+	Export        ExportConfig       `json:"EXPORT"`
 }
 
 // ServerConfig holds HTTP server configuration
 type ServerConfig struct {
-	Host         string        `json:"HOST"`
-	Port         string        `json:"PORT"`
-	ReadTimeout  time.Duration `json:"READ_TIMEOUT"`
-	WriteTimeout time.Duration `json:"WRITE_TIMEOUT"`
+	Host               string        `json:"HOST"`
+	Port               string        `json:"PORT"`
+	ReadTimeout        time.Duration `json:"READ_TIMEOUT"`
+	WriteTimeout       time.Duration `json:"WRITE_TIMEOUT"`
+	MaxJSONDepth       int           `json:"MAX_JSON_DEPTH"` // Max nesting depth of request bodies (0 disables)
+	MaxJSONKeys        int           `json:"MAX_JSON_KEYS"`  // Max total object keys of request bodies (0 disables)
+	AccessLogFields    []string
+	AccessLogFieldsStr string `json:"ACCESS_LOG_FIELDS"` // Comma-separated access log fields, empty for the default set
+	ErrorFormat        string `json:"ERROR_FORMAT"`      // simple ({"error": ...}, problem details on request) or problem (RFC 7807)
 }
 
 // RedisConfig holds Redis cluster configuration
@@ -39,25 +53,142 @@ type RedisConfig struct {
 	UseEmbedded    bool
 	EmbeddedPort   string `json:"REDKA_PORT"`
 	EmbeddedDBPath string `json:"REDKA_DB_PATH"`
+	// Write requests get 503 once Redis has been unhealthy this long (0 disables)
+	UnhealthyThreshold time.Duration `json:"UNHEALTHY_THRESHOLD"`
+
+	// Widget stats are cached this long (0 disables); reads slower than the latency
+	// budget or failing serve cached stats up to the max stale age instead
+	StatsCacheTTL      time.Duration `json:"STATS_CACHE_TTL"`
+	StatsLatencyBudget time.Duration `json:"STATS_LATENCY_BUDGET"`
+	StatsMaxStale      time.Duration `json:"STATS_MAX_STALE"`
 }
 
 // JWTConfig holds JWT token validation configuration
 type JWTConfig struct {
 	Secret    string `json:"SECRET"`
 	AllowDemo bool   `json:"ALLOW_DEMO"` // Allow demo mode for JWT
+
+	Leeway time.Duration `json:"LEEWAY"` // Clock skew tolerated on exp, nbf and iat
+
+	AccessTTL  time.Duration `json:"ACCESS_TTL"`  // Lifetime of access tokens issued with refresh tokens
+	RefreshTTL time.Duration `json:"REFRESH_TTL"` // Lifetime of refresh tokens
 }
 
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
 	IPPerMinute     int `json:"IP_PER_MINUTE"`
 	GlobalPerMinute int `json:"GLOBAL_PER_MINUTE"`
+	WidgetPerMinute int `json:"WIDGET_PER_MINUTE"` // Submits and events per widget, 0 disables
 }
 
 // TTLConfig holds TTL settings for different user plans
 type TTLConfig struct {
-	DemoDays int `json:"DEMO_DAYS"`
-	FreeDays int `json:"FREE_DAYS"`
-	ProDays  int `json:"PRO_DAYS"`
+	DemoDays         int  `json:"DEMO_DAYS"`
+	FreeDays         int  `json:"FREE_DAYS"`
+	ProDays          int  `json:"PRO_DAYS"`
+	DemoWidgetExpiry bool `json:"DEMO_WIDGET_EXPIRY"` // Remove demo-plan widgets after DemoDays
+	PIIRetentionDays int  `json:"PII_RETENTION_DAYS"` // Default age before configured PII fields are redacted
+	DailyStatsDays   int  `json:"DAILY_STATS_DAYS"`   // How long daily view, submit and close counters are kept
+
+	InactiveWidgetDays    int           `json:"INACTIVE_WIDGET_DAYS"`           // Hide widgets without activity for this long, 0 disables
+	InactiveSweepInterval time.Duration `json:"INACTIVE_WIDGET_SWEEP_INTERVAL"` // How often inactive widgets are looked for
+}
+
+// PlanConfig holds per-plan (tenant) restrictions
+type PlanConfig struct {
+	AllowedTypes    map[string][]string
+	AllowedTypesStr string `json:"ALLOWED_WIDGET_TYPES"` // e.g. "free:lead-form|banner,demo:lead-form"
+	DeniedTypes     map[string][]string
+	DeniedTypesStr  string        `json:"DENIED_WIDGET_TYPES"`    // e.g. "free:quiz|wheelOfFortune"
+	MaxWidgets      int           `json:"MAX_WIDGETS_PER_USER"`   // 0 = unlimited
+	CreateLockTTL   time.Duration `json:"WIDGET_CREATE_LOCK_TTL"` // Max time the per-user create lock is held
+	MaxNameLength   int           `json:"MAX_WIDGET_NAME_LENGTH"` // 0 = only the schema limit applies
+	UniqueNames     bool          `json:"UNIQUE_WIDGET_NAMES"`    // Reject names already used by the same user
+	DailyExports    map[string]int
+	DailyExportsStr string `json:"DAILY_EXPORT_LIMITS"` // Exports per user and UTC day by plan, e.g. "free:20,pro:200"
+
+	ExportRows    map[string]int
+	ExportRowsStr string `json:"EXPORT_ROW_LIMITS"` // Max submissions per export by plan, e.g. "free:1000"
+
+	ConfigHistoryLimit int `json:"WIDGET_CONFIG_HISTORY_LIMIT"` // Replaced configs kept per widget for restore
+}
+
+// MonitoringConfig holds monitoring and instrumentation settings
+type MonitoringConfig struct {
+	SlowQueryThreshold time.Duration `json:"SLOW_QUERY_THRESHOLD"` // 0 disables the slow-query log
+}
+
+// RegionConfig holds data residency settings for submissions
+type RegionConfig struct {
+	Default string `json:"DEFAULT"` // Region recorded on submissions when geo lookup resolves none
+}
+
+// SubmissionConfig holds submission validation settings
+type SubmissionConfig struct {
+	MaxFieldLength    int           `json:"MAX_FIELD_LENGTH"`     // Max characters per field value, 0 disables the limit
+	EncryptionKey     string        `json:"ENCRYPTION_KEY"`       // Base64 32-byte key for fields widgets mark as encrypted
+	FormTokenSecret   string        `json:"FORM_TOKEN_SECRET"`    // Signs form render times for min_fill_seconds, started_at is used when empty
+	ClientTimeMaxAge  time.Duration `json:"CLIENT_TIME_MAX_AGE"`  // Oldest accepted client occurred_at
+	ClientTimeMaxSkew time.Duration `json:"CLIENT_TIME_MAX_SKEW"` // Furthest accepted client occurred_at in the future
+	ReservedFields    []string
+	ReservedFieldsStr string `json:"RESERVED_FIELD_NAMES"` // Comma-separated data field names that would shadow submission attributes
+	ReservedFieldMode string `json:"RESERVED_FIELD_MODE"`  // reject, prefix (store as field_<name>) or off
+
+	TailMaxWait time.Duration `json:"SUBMISSIONS_TAIL_MAX_WAIT"` // How long a submissions tail request waits for new submissions
+}
+
+// CORSConfig holds CORS settings for the private API
+type CORSConfig struct {
+	AllowedOrigins    []string
+	AllowedOriginsStr string        `json:"ALLOWED_ORIGINS"` // Comma-separated, e.g. "https://dashboard.example.com"
+	MaxAge            time.Duration `json:"MAX_AGE"`         // How long browsers may cache preflight responses
+}
+
+// NotificationConfig holds submission notification settings
+type NotificationConfig struct {
+	DigestInterval time.Duration `json:"DIGEST_INTERVAL"` // How often digest-mode widgets get a summary
+}
+
+// SMTPConfig holds the mail server submission notifications are emailed through
+type SMTPConfig struct {
+	Host     string `json:"HOST"` // Email notifications are disabled when empty
+	Port     int    `json:"PORT"`
+	Username string `json:"USERNAME"` // Authenticates with PLAIN when set
+	Password string `json:"PASSWORD"`
+	From     string `json:"FROM"` // Sender address, e.g. "Leads <leads@example.com>"
+}
+
+// MaintenanceConfig holds maintenance (read-only) mode settings
+type MaintenanceConfig struct {
+	Enabled    bool          `json:"ENABLED"`     // Initial state, can be toggled at runtime by admins
+	RetryAfter time.Duration `json:"RETRY_AFTER"` // Advertised to rejected clients via Retry-After
+}
+
+// ExportConfig holds submission export settings
+type ExportConfig struct {
+	FilenameTemplate     string `json:"FILENAME_TEMPLATE"` // Placeholders: {name} {id} {kind} {date}
+	FilenameTemplates    map[string]string
+	FilenameTemplatesStr string        `json:"FILENAME_TEMPLATES"`   // Per-format overrides, e.g. "csv:{id}_{date},xlsx:{name}"
+	FilenameDateFormat   string        `json:"FILENAME_DATE_FORMAT"` // Go time layout for {date}
+	JobTTL               time.Duration `json:"JOB_TTL"`              // How long async export jobs and their files are kept
+	JobWorkers           int           `json:"JOB_WORKERS"`          // Number of background export workers
+	JobMaxAttempts       int           `json:"JOB_MAX_ATTEMPTS"`     // Runs per export job before it is marked failed, 1 disables retries
+	JobRetryBackoff      time.Duration `json:"JOB_RETRY_BACKOFF"`    // Delay before the first retry, doubled for each following one
+	Destination          string        `json:"DESTINATION"`          // Where export job files go: inline (Redis) or s3
+	S3Endpoint           string        `json:"S3_ENDPOINT"`          // S3-compatible endpoint, e.g. https://s3.eu-central-1.amazonaws.com
+	S3Region             string        `json:"S3_REGION"`
+	S3Bucket             string        `json:"S3_BUCKET"`
+	S3AccessKey          string        `json:"S3_ACCESS_KEY"`
+	S3SecretKey          string        `json:"S3_SECRET_KEY"`
+	S3Prefix             string        `json:"S3_PREFIX"`  // Prepended to object keys
+	S3URLTTL             time.Duration `json:"S3_URL_TTL"` // Lifetime of presigned download URLs
+
+	MaxRange     time.Duration `json:"MAX_RANGE"`                 // Widest from/to span of an export, 0 disables
+	UnboundedMax int           `json:"UNBOUNDED_MAX_SUBMISSIONS"` // Widgets with more submissions need 'from' to export, 0 disables
+
+	MaxConcurrent        int           `json:"MAX_CONCURRENT"`         // Exports running at once per instance, 0 disables
+	MaxConcurrentCluster int           `json:"MAX_CONCURRENT_CLUSTER"` // Exports running at once across all instances, 0 disables
+	SlotLeaseTTL         time.Duration `json:"SLOT_LEASE_TTL"`         // Cluster slots of crashed instances free up after this
 }
 
 // Load loads configuration from environment variables
@@ -68,27 +199,117 @@ func Load(args []string) (*Config, error) {
 			Port:         getEnv("PORT", "8080"),
 			ReadTimeout:  getEnvDuration("READ_TIMEOUT", 30*time.Second),
 			WriteTimeout: getEnvDuration("WRITE_TIMEOUT", 30*time.Second),
+			MaxJSONDepth: getEnvInt("MAX_JSON_DEPTH", 32),
+			MaxJSONKeys:  getEnvInt("MAX_JSON_KEYS", 1000),
+
+			AccessLogFieldsStr: getEnv("ACCESS_LOG_FIELDS", ""),
+			ErrorFormat:        getEnv("ERROR_FORMAT", "simple"),
 		},
 		Redis: RedisConfig{
-			AddressesStr:   getEnv("ADDRESSES", "localhost:6379"),
-			Password:       getEnv("PASSWORD", ""),
-			DB:             getEnvInt("DB", 0),
-			UseEmbedded:    false,
-			EmbeddedPort:   getEnv("REDKA_PORT", "6379"),
-			EmbeddedDBPath: getEnv("REDKA_DB_PATH", "file:redka.db"),
+			AddressesStr:       getEnv("ADDRESSES", "localhost:6379"),
+			Password:           getEnv("PASSWORD", ""),
+			DB:                 getEnvInt("DB", 0),
+			UseEmbedded:        false,
+			EmbeddedPort:       getEnv("REDKA_PORT", "6379"),
+			EmbeddedDBPath:     getEnv("REDKA_DB_PATH", "file:redka.db"),
+			UnhealthyThreshold: getEnvDuration("REDIS_UNHEALTHY_THRESHOLD", time.Minute),
+			StatsCacheTTL:      getEnvDuration("STATS_CACHE_TTL", 0),
+			StatsLatencyBudget: getEnvDuration("STATS_LATENCY_BUDGET", 200*time.Millisecond),
+			StatsMaxStale:      getEnvDuration("STATS_MAX_STALE", 5*time.Minute),
 		},
 		JWT: JWTConfig{
 			Secret:    getEnv("JWT_SECRET", ""),
 			AllowDemo: getEnv("JWT_ALLOW_DEMO", "false") == "true",
+
+			Leeway: getEnvDuration("JWT_LEEWAY", 30*time.Second),
+
+			AccessTTL:  getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
+			RefreshTTL: getEnvDuration("JWT_REFRESH_TTL", 30*24*time.Hour),
 		},
 		RateLimit: RateLimitConfig{
 			IPPerMinute:     getEnvInt("IP_PER_MINUTE", 1),
 			GlobalPerMinute: getEnvInt("GLOBAL_PER_MINUTE", 1000),
+			WidgetPerMinute: getEnvInt("WIDGET_PER_MINUTE", 0),
 		},
 		TTL: TTLConfig{
-			DemoDays: getEnvInt("DEMO_DAYS", 7),
-			FreeDays: getEnvInt("TTL_FREE_DAYS", 30),
-			ProDays:  getEnvInt("TTL_PRO_DAYS", 365),
+			DemoDays:         getEnvInt("DEMO_DAYS", 7),
+			FreeDays:         getEnvInt("TTL_FREE_DAYS", 30),
+			ProDays:          getEnvInt("TTL_PRO_DAYS", 365),
+			DemoWidgetExpiry: getEnv("DEMO_WIDGET_EXPIRY", "false") == "true",
+			PIIRetentionDays: getEnvInt("PII_RETENTION_DAYS", 30),
+			DailyStatsDays:   getEnvInt("DAILY_STATS_DAYS", 30),
+
+			InactiveWidgetDays:    getEnvInt("INACTIVE_WIDGET_DAYS", 0),
+			InactiveSweepInterval: getEnvDuration("INACTIVE_WIDGET_SWEEP_INTERVAL", time.Hour),
+		},
+		Plans: PlanConfig{
+			AllowedTypesStr: getEnv("ALLOWED_WIDGET_TYPES", ""),
+			DeniedTypesStr:  getEnv("DENIED_WIDGET_TYPES", ""),
+			MaxWidgets:      getEnvInt("MAX_WIDGETS_PER_USER", 0),
+			CreateLockTTL:   getEnvDuration("WIDGET_CREATE_LOCK_TTL", 5*time.Second),
+			MaxNameLength:   getEnvInt("MAX_WIDGET_NAME_LENGTH", 255),
+			UniqueNames:     getEnv("UNIQUE_WIDGET_NAMES", "false") == "true",
+			DailyExportsStr: getEnv("DAILY_EXPORT_LIMITS", ""),
+
+			ExportRowsStr: getEnv("EXPORT_ROW_LIMITS", ""),
+
+			ConfigHistoryLimit: getEnvInt("WIDGET_CONFIG_HISTORY_LIMIT", 20),
+		},
+		Monitoring: MonitoringConfig{
+			SlowQueryThreshold: getEnvDuration("SLOW_QUERY_THRESHOLD", 100*time.Millisecond),
+		},
+		Region: RegionConfig{
+			Default: getEnv("SUBMISSION_REGION", ""),
+		},
+		Submission: SubmissionConfig{
+			MaxFieldLength:    getEnvInt("MAX_FIELD_LENGTH", 10000),
+			EncryptionKey:     getEnv("SUBMISSION_ENCRYPTION_KEY", ""),
+			FormTokenSecret:   getEnv("SUBMISSION_FORM_TOKEN_SECRET", ""),
+			ClientTimeMaxAge:  getEnvDuration("SUBMISSION_CLIENT_TIME_MAX_AGE", 72*time.Hour),
+			ClientTimeMaxSkew: getEnvDuration("SUBMISSION_CLIENT_TIME_MAX_SKEW", 5*time.Minute),
+			ReservedFieldsStr: getEnv("RESERVED_FIELD_NAMES", "id,widget_id,created_at,received_at,ttl,region,trusted,widget_version"),
+			ReservedFieldMode: getEnv("RESERVED_FIELD_MODE", "reject"),
+			TailMaxWait:       getEnvDuration("SUBMISSIONS_TAIL_MAX_WAIT", 25*time.Second),
+		},
+		CORS: CORSConfig{
+			AllowedOriginsStr: getEnv("API_CORS_ALLOWED_ORIGINS", ""),
+			MaxAge:            getEnvDuration("API_CORS_MAX_AGE", 10*time.Minute),
+		},
+		Notifications: NotificationConfig{
+			DigestInterval: getEnvDuration("NOTIFICATION_DIGEST_INTERVAL", time.Hour),
+		},
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnvInt("SMTP_PORT", 587),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", ""),
+		},
+		Maintenance: MaintenanceConfig{
+			Enabled:    getEnv("MAINTENANCE_MODE", "false") == "true",
+			RetryAfter: getEnvDuration("MAINTENANCE_RETRY_AFTER", 5*time.Minute),
+		},
+		Export: ExportConfig{
+			FilenameTemplate:     getEnv("EXPORT_FILENAME_TEMPLATE", "{name}_{kind}_{date}"),
+			FilenameTemplatesStr: getEnv("EXPORT_FILENAME_TEMPLATES", ""),
+			FilenameDateFormat:   getEnv("EXPORT_FILENAME_DATE_FORMAT", "2006-01-02"),
+			JobTTL:               getEnvDuration("EXPORT_JOB_TTL", 24*time.Hour),
+			JobWorkers:           getEnvInt("EXPORT_JOB_WORKERS", 2),
+			MaxRange:             getEnvDuration("EXPORT_MAX_RANGE", 366*24*time.Hour),
+			UnboundedMax:         getEnvInt("EXPORT_UNBOUNDED_MAX_SUBMISSIONS", 10000),
+			JobMaxAttempts:       getEnvInt("EXPORT_JOB_MAX_ATTEMPTS", 3),
+			JobRetryBackoff:      getEnvDuration("EXPORT_JOB_RETRY_BACKOFF", 5*time.Second),
+			Destination:          getEnv("EXPORT_DESTINATION", "inline"),
+			S3Endpoint:           getEnv("EXPORT_S3_ENDPOINT", ""),
+			S3Region:             getEnv("EXPORT_S3_REGION", "us-east-1"),
+			S3Bucket:             getEnv("EXPORT_S3_BUCKET", ""),
+			S3AccessKey:          getEnv("EXPORT_S3_ACCESS_KEY", ""),
+			S3SecretKey:          getEnv("EXPORT_S3_SECRET_KEY", ""),
+			S3Prefix:             getEnv("EXPORT_S3_PREFIX", ""),
+			S3URLTTL:             getEnvDuration("EXPORT_S3_URL_TTL", time.Hour),
+			MaxConcurrent:        getEnvInt("EXPORT_MAX_CONCURRENT", 0),
+			MaxConcurrentCluster: getEnvInt("EXPORT_MAX_CONCURRENT_CLUSTER", 0),
+			SlotLeaseTTL:         getEnvDuration("EXPORT_SLOT_LEASE_TTL", 10*time.Minute),
 		},
 	}
 
@@ -113,18 +334,84 @@ func Load(args []string) (*Config, error) {
 		flags.StringVar(&config.Server.Port, "port", lookupEnvOrString("PORT", config.Server.Port), "PORT")
 		flags.DurationVar(&config.Server.ReadTimeout, "readTimeout", lookupEnvOrDuration("READ_TIMEOUT", config.Server.ReadTimeout), "READ_TIMEOUT")
 		flags.DurationVar(&config.Server.WriteTimeout, "writeTimeout", lookupEnvOrDuration("WRITE_TIMEOUT", config.Server.WriteTimeout), "WRITE_TIMEOUT")
+		flags.IntVar(&config.Server.MaxJSONDepth, "maxJSONDepth", lookupEnvOrInt("MAX_JSON_DEPTH", config.Server.MaxJSONDepth), "MAX_JSON_DEPTH")
+		flags.IntVar(&config.Server.MaxJSONKeys, "maxJSONKeys", lookupEnvOrInt("MAX_JSON_KEYS", config.Server.MaxJSONKeys), "MAX_JSON_KEYS")
+		flags.StringVar(&config.Server.AccessLogFieldsStr, "accessLogFields", lookupEnvOrString("ACCESS_LOG_FIELDS", config.Server.AccessLogFieldsStr), "ACCESS_LOG_FIELDS")
+		flags.StringVar(&config.Server.ErrorFormat, "errorFormat", lookupEnvOrString("ERROR_FORMAT", config.Server.ErrorFormat), "ERROR_FORMAT")
 		flags.StringVar(&config.Redis.AddressesStr, "redisAddresses", lookupEnvOrString("REDIS_ADDRESSES", config.Redis.AddressesStr), "REDIS_ADDRESSES")
 		flags.StringVar(&config.Redis.Password, "redisPassword", lookupEnvOrString("REDIS_PASSWORD", config.Redis.Password), "REDIS_PASSWORD")
 		flags.IntVar(&config.Redis.DB, "redisDB", lookupEnvOrInt("REDIS_DB", config.Redis.DB), "REDIS_DB")
 		flags.StringVar(&config.Redis.EmbeddedPort, "redisEmbeddedPort", lookupEnvOrString("REDKA_PORT", config.Redis.EmbeddedPort), "REDKA_PORT")
 		flags.StringVar(&config.Redis.EmbeddedDBPath, "redisEmbeddedDBPath", lookupEnvOrString("REDKA_DB_PATH", config.Redis.EmbeddedDBPath), "REDKA_DB_PATH")
+		flags.DurationVar(&config.Redis.UnhealthyThreshold, "redisUnhealthyThreshold", lookupEnvOrDuration("REDIS_UNHEALTHY_THRESHOLD", config.Redis.UnhealthyThreshold), "REDIS_UNHEALTHY_THRESHOLD")
+		flags.DurationVar(&config.Redis.StatsCacheTTL, "statsCacheTTL", lookupEnvOrDuration("STATS_CACHE_TTL", config.Redis.StatsCacheTTL), "STATS_CACHE_TTL")
+		flags.DurationVar(&config.Redis.StatsLatencyBudget, "statsLatencyBudget", lookupEnvOrDuration("STATS_LATENCY_BUDGET", config.Redis.StatsLatencyBudget), "STATS_LATENCY_BUDGET")
+		flags.DurationVar(&config.Redis.StatsMaxStale, "statsMaxStale", lookupEnvOrDuration("STATS_MAX_STALE", config.Redis.StatsMaxStale), "STATS_MAX_STALE")
 		flags.StringVar(&config.JWT.Secret, "jwtSecret", lookupEnvOrString("JWT_SECRET", config.JWT.Secret), "JWT_SECRET")
 		flags.BoolVar(&config.JWT.AllowDemo, "jwtAllowDemo", lookupEnvOrBool("JWT_ALLOW_DEMO", config.JWT.AllowDemo), "JWT_ALLOW_DEMO")
+		flags.DurationVar(&config.JWT.Leeway, "jwtLeeway", lookupEnvOrDuration("JWT_LEEWAY", config.JWT.Leeway), "JWT_LEEWAY")
+		flags.DurationVar(&config.JWT.AccessTTL, "jwtAccessTTL", lookupEnvOrDuration("JWT_ACCESS_TTL", config.JWT.AccessTTL), "JWT_ACCESS_TTL")
+		flags.DurationVar(&config.JWT.RefreshTTL, "jwtRefreshTTL", lookupEnvOrDuration("JWT_REFRESH_TTL", config.JWT.RefreshTTL), "JWT_REFRESH_TTL")
 		flags.IntVar(&config.RateLimit.IPPerMinute, "rateLimitIPPerMinute", lookupEnvOrInt("IP_PER_MINUTE", config.RateLimit.IPPerMinute), "IP_PER_MINUTE")
 		flags.IntVar(&config.RateLimit.GlobalPerMinute, "rateLimitGlobalPerMinute", lookupEnvOrInt("GLOBAL_PER_MINUTE", config.RateLimit.GlobalPerMinute), "GLOBAL_PER_MINUTE")
+		flags.IntVar(&config.RateLimit.WidgetPerMinute, "rateLimitWidgetPerMinute", lookupEnvOrInt("WIDGET_PER_MINUTE", config.RateLimit.WidgetPerMinute), "WIDGET_PER_MINUTE")
 		flags.IntVar(&config.TTL.DemoDays, "ttlDemoDays", lookupEnvOrInt("DEMO_DAYS", config.TTL.DemoDays), "DEMO_DAYS")
 		flags.IntVar(&config.TTL.FreeDays, "ttlFreeDays", lookupEnvOrInt("FREE_DAYS", config.TTL.FreeDays), "FREE_DAYS")
 		flags.IntVar(&config.TTL.ProDays, "ttlProDays", lookupEnvOrInt("PRO_DAYS", config.TTL.ProDays), "PRO_DAYS")
+		flags.BoolVar(&config.TTL.DemoWidgetExpiry, "demoWidgetExpiry", lookupEnvOrBool("DEMO_WIDGET_EXPIRY", config.TTL.DemoWidgetExpiry), "DEMO_WIDGET_EXPIRY")
+		flags.IntVar(&config.TTL.PIIRetentionDays, "piiRetentionDays", lookupEnvOrInt("PII_RETENTION_DAYS", config.TTL.PIIRetentionDays), "PII_RETENTION_DAYS")
+		flags.IntVar(&config.TTL.DailyStatsDays, "dailyStatsDays", lookupEnvOrInt("DAILY_STATS_DAYS", config.TTL.DailyStatsDays), "DAILY_STATS_DAYS")
+		flags.IntVar(&config.TTL.InactiveWidgetDays, "inactiveWidgetDays", lookupEnvOrInt("INACTIVE_WIDGET_DAYS", config.TTL.InactiveWidgetDays), "INACTIVE_WIDGET_DAYS")
+		flags.DurationVar(&config.TTL.InactiveSweepInterval, "inactiveWidgetSweepInterval", lookupEnvOrDuration("INACTIVE_WIDGET_SWEEP_INTERVAL", config.TTL.InactiveSweepInterval), "INACTIVE_WIDGET_SWEEP_INTERVAL")
+		flags.StringVar(&config.Plans.AllowedTypesStr, "allowedWidgetTypes", lookupEnvOrString("ALLOWED_WIDGET_TYPES", config.Plans.AllowedTypesStr), "ALLOWED_WIDGET_TYPES")
+		flags.StringVar(&config.Plans.DeniedTypesStr, "deniedWidgetTypes", lookupEnvOrString("DENIED_WIDGET_TYPES", config.Plans.DeniedTypesStr), "DENIED_WIDGET_TYPES")
+		flags.IntVar(&config.Plans.MaxWidgets, "maxWidgetsPerUser", lookupEnvOrInt("MAX_WIDGETS_PER_USER", config.Plans.MaxWidgets), "MAX_WIDGETS_PER_USER")
+		flags.DurationVar(&config.Plans.CreateLockTTL, "widgetCreateLockTTL", lookupEnvOrDuration("WIDGET_CREATE_LOCK_TTL", config.Plans.CreateLockTTL), "WIDGET_CREATE_LOCK_TTL")
+		flags.IntVar(&config.Plans.MaxNameLength, "maxWidgetNameLength", lookupEnvOrInt("MAX_WIDGET_NAME_LENGTH", config.Plans.MaxNameLength), "MAX_WIDGET_NAME_LENGTH")
+		flags.BoolVar(&config.Plans.UniqueNames, "uniqueWidgetNames", lookupEnvOrBool("UNIQUE_WIDGET_NAMES", config.Plans.UniqueNames), "UNIQUE_WIDGET_NAMES")
+		flags.IntVar(&config.Plans.ConfigHistoryLimit, "widgetConfigHistoryLimit", lookupEnvOrInt("WIDGET_CONFIG_HISTORY_LIMIT", config.Plans.ConfigHistoryLimit), "WIDGET_CONFIG_HISTORY_LIMIT")
+		flags.StringVar(&config.Plans.DailyExportsStr, "dailyExportLimits", lookupEnvOrString("DAILY_EXPORT_LIMITS", config.Plans.DailyExportsStr), "DAILY_EXPORT_LIMITS")
+		flags.StringVar(&config.Plans.ExportRowsStr, "exportRowLimits", lookupEnvOrString("EXPORT_ROW_LIMITS", config.Plans.ExportRowsStr), "EXPORT_ROW_LIMITS")
+		flags.DurationVar(&config.Monitoring.SlowQueryThreshold, "slowQueryThreshold", lookupEnvOrDuration("SLOW_QUERY_THRESHOLD", config.Monitoring.SlowQueryThreshold), "SLOW_QUERY_THRESHOLD")
+		flags.StringVar(&config.Region.Default, "submissionRegion", lookupEnvOrString("SUBMISSION_REGION", config.Region.Default), "SUBMISSION_REGION")
+		flags.IntVar(&config.Submission.MaxFieldLength, "maxFieldLength", lookupEnvOrInt("MAX_FIELD_LENGTH", config.Submission.MaxFieldLength), "MAX_FIELD_LENGTH")
+		flags.StringVar(&config.Submission.EncryptionKey, "submissionEncryptionKey", lookupEnvOrString("SUBMISSION_ENCRYPTION_KEY", config.Submission.EncryptionKey), "SUBMISSION_ENCRYPTION_KEY")
+		flags.StringVar(&config.Submission.FormTokenSecret, "submissionFormTokenSecret", lookupEnvOrString("SUBMISSION_FORM_TOKEN_SECRET", config.Submission.FormTokenSecret), "SUBMISSION_FORM_TOKEN_SECRET")
+		flags.DurationVar(&config.Submission.ClientTimeMaxAge, "submissionClientTimeMaxAge", lookupEnvOrDuration("SUBMISSION_CLIENT_TIME_MAX_AGE", config.Submission.ClientTimeMaxAge), "SUBMISSION_CLIENT_TIME_MAX_AGE")
+		flags.DurationVar(&config.Submission.ClientTimeMaxSkew, "submissionClientTimeMaxSkew", lookupEnvOrDuration("SUBMISSION_CLIENT_TIME_MAX_SKEW", config.Submission.ClientTimeMaxSkew), "SUBMISSION_CLIENT_TIME_MAX_SKEW")
+		flags.StringVar(&config.Submission.ReservedFieldsStr, "reservedFieldNames", lookupEnvOrString("RESERVED_FIELD_NAMES", config.Submission.ReservedFieldsStr), "RESERVED_FIELD_NAMES")
+		flags.StringVar(&config.Submission.ReservedFieldMode, "reservedFieldMode", lookupEnvOrString("RESERVED_FIELD_MODE", config.Submission.ReservedFieldMode), "RESERVED_FIELD_MODE")
+		flags.DurationVar(&config.Submission.TailMaxWait, "submissionsTailMaxWait", lookupEnvOrDuration("SUBMISSIONS_TAIL_MAX_WAIT", config.Submission.TailMaxWait), "SUBMISSIONS_TAIL_MAX_WAIT")
+		flags.StringVar(&config.CORS.AllowedOriginsStr, "apiCorsAllowedOrigins", lookupEnvOrString("API_CORS_ALLOWED_ORIGINS", config.CORS.AllowedOriginsStr), "API_CORS_ALLOWED_ORIGINS")
+		flags.DurationVar(&config.CORS.MaxAge, "apiCorsMaxAge", lookupEnvOrDuration("API_CORS_MAX_AGE", config.CORS.MaxAge), "API_CORS_MAX_AGE")
+		flags.DurationVar(&config.Notifications.DigestInterval, "notificationDigestInterval", lookupEnvOrDuration("NOTIFICATION_DIGEST_INTERVAL", config.Notifications.DigestInterval), "NOTIFICATION_DIGEST_INTERVAL")
+		flags.StringVar(&config.SMTP.Host, "smtpHost", lookupEnvOrString("SMTP_HOST", config.SMTP.Host), "SMTP_HOST")
+		flags.IntVar(&config.SMTP.Port, "smtpPort", lookupEnvOrInt("SMTP_PORT", config.SMTP.Port), "SMTP_PORT")
+		flags.StringVar(&config.SMTP.Username, "smtpUsername", lookupEnvOrString("SMTP_USERNAME", config.SMTP.Username), "SMTP_USERNAME")
+		flags.StringVar(&config.SMTP.Password, "smtpPassword", lookupEnvOrString("SMTP_PASSWORD", config.SMTP.Password), "SMTP_PASSWORD")
+		flags.StringVar(&config.SMTP.From, "smtpFrom", lookupEnvOrString("SMTP_FROM", config.SMTP.From), "SMTP_FROM")
+		flags.BoolVar(&config.Maintenance.Enabled, "maintenanceMode", lookupEnvOrBool("MAINTENANCE_MODE", config.Maintenance.Enabled), "MAINTENANCE_MODE")
+		flags.DurationVar(&config.Maintenance.RetryAfter, "maintenanceRetryAfter", lookupEnvOrDuration("MAINTENANCE_RETRY_AFTER", config.Maintenance.RetryAfter), "MAINTENANCE_RETRY_AFTER")
+		flags.StringVar(&config.Export.FilenameTemplate, "exportFilenameTemplate", lookupEnvOrString("EXPORT_FILENAME_TEMPLATE", config.Export.FilenameTemplate), "EXPORT_FILENAME_TEMPLATE")
+		flags.StringVar(&config.Export.FilenameTemplatesStr, "exportFilenameTemplates", lookupEnvOrString("EXPORT_FILENAME_TEMPLATES", config.Export.FilenameTemplatesStr), "EXPORT_FILENAME_TEMPLATES")
+		flags.StringVar(&config.Export.FilenameDateFormat, "exportFilenameDateFormat", lookupEnvOrString("EXPORT_FILENAME_DATE_FORMAT", config.Export.FilenameDateFormat), "EXPORT_FILENAME_DATE_FORMAT")
+		flags.DurationVar(&config.Export.JobTTL, "exportJobTTL", lookupEnvOrDuration("EXPORT_JOB_TTL", config.Export.JobTTL), "EXPORT_JOB_TTL")
+		flags.IntVar(&config.Export.JobWorkers, "exportJobWorkers", lookupEnvOrInt("EXPORT_JOB_WORKERS", config.Export.JobWorkers), "EXPORT_JOB_WORKERS")
+		flags.DurationVar(&config.Export.MaxRange, "exportMaxRange", lookupEnvOrDuration("EXPORT_MAX_RANGE", config.Export.MaxRange), "EXPORT_MAX_RANGE")
+		flags.IntVar(&config.Export.UnboundedMax, "exportUnboundedMaxSubmissions", lookupEnvOrInt("EXPORT_UNBOUNDED_MAX_SUBMISSIONS", config.Export.UnboundedMax), "EXPORT_UNBOUNDED_MAX_SUBMISSIONS")
+		flags.IntVar(&config.Export.JobMaxAttempts, "exportJobMaxAttempts", lookupEnvOrInt("EXPORT_JOB_MAX_ATTEMPTS", config.Export.JobMaxAttempts), "EXPORT_JOB_MAX_ATTEMPTS")
+		flags.DurationVar(&config.Export.JobRetryBackoff, "exportJobRetryBackoff", lookupEnvOrDuration("EXPORT_JOB_RETRY_BACKOFF", config.Export.JobRetryBackoff), "EXPORT_JOB_RETRY_BACKOFF")
+		flags.StringVar(&config.Export.Destination, "exportDestination", lookupEnvOrString("EXPORT_DESTINATION", config.Export.Destination), "EXPORT_DESTINATION")
+		flags.StringVar(&config.Export.S3Endpoint, "exportS3Endpoint", lookupEnvOrString("EXPORT_S3_ENDPOINT", config.Export.S3Endpoint), "EXPORT_S3_ENDPOINT")
+		flags.StringVar(&config.Export.S3Region, "exportS3Region", lookupEnvOrString("EXPORT_S3_REGION", config.Export.S3Region), "EXPORT_S3_REGION")
+		flags.StringVar(&config.Export.S3Bucket, "exportS3Bucket", lookupEnvOrString("EXPORT_S3_BUCKET", config.Export.S3Bucket), "EXPORT_S3_BUCKET")
+		flags.StringVar(&config.Export.S3AccessKey, "exportS3AccessKey", lookupEnvOrString("EXPORT_S3_ACCESS_KEY", config.Export.S3AccessKey), "EXPORT_S3_ACCESS_KEY")
+		flags.StringVar(&config.Export.S3SecretKey, "exportS3SecretKey", lookupEnvOrString("EXPORT_S3_SECRET_KEY", config.Export.S3SecretKey), "EXPORT_S3_SECRET_KEY")
+		flags.StringVar(&config.Export.S3Prefix, "exportS3Prefix", lookupEnvOrString("EXPORT_S3_PREFIX", config.Export.S3Prefix), "EXPORT_S3_PREFIX")
+		flags.DurationVar(&config.Export.S3URLTTL, "exportS3URLTTL", lookupEnvOrDuration("EXPORT_S3_URL_TTL", config.Export.S3URLTTL), "EXPORT_S3_URL_TTL")
+		flags.IntVar(&config.Export.MaxConcurrent, "exportMaxConcurrent", lookupEnvOrInt("EXPORT_MAX_CONCURRENT", config.Export.MaxConcurrent), "EXPORT_MAX_CONCURRENT")
+		flags.IntVar(&config.Export.MaxConcurrentCluster, "exportMaxConcurrentCluster", lookupEnvOrInt("EXPORT_MAX_CONCURRENT_CLUSTER", config.Export.MaxConcurrentCluster), "EXPORT_MAX_CONCURRENT_CLUSTER")
+		flags.DurationVar(&config.Export.SlotLeaseTTL, "exportSlotLeaseTTL", lookupEnvOrDuration("EXPORT_SLOT_LEASE_TTL", config.Export.SlotLeaseTTL), "EXPORT_SLOT_LEASE_TTL")
 
 		if err := flags.Parse(args[1:]); err != nil {
 			return config, fmt.Errorf("error parsing flags: %w", err)
@@ -135,6 +422,36 @@ func Load(args []string) (*Config, error) {
 		return nil, fmt.Errorf("JWT_SECRET environment variable is required")
 	}
 
+	switch config.Export.Destination {
+	case "inline":
+	case "s3":
+		if config.Export.S3Endpoint == "" || config.Export.S3Bucket == "" {
+			return nil, fmt.Errorf("EXPORT_S3_ENDPOINT and EXPORT_S3_BUCKET are required for the s3 export destination")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported EXPORT_DESTINATION %q, use inline or s3", config.Export.Destination)
+	}
+
+	switch config.Submission.ReservedFieldMode {
+	case "reject", "prefix", "off":
+	default:
+		return nil, fmt.Errorf("unsupported RESERVED_FIELD_MODE %q, use reject, prefix or off", config.Submission.ReservedFieldMode)
+	}
+
+	if config.TTL.InactiveWidgetDays > 0 && config.TTL.InactiveSweepInterval <= 0 {
+		return nil, fmt.Errorf("INACTIVE_WIDGET_SWEEP_INTERVAL must be positive, got %s", config.TTL.InactiveSweepInterval)
+	}
+
+	if config.Notifications.DigestInterval <= 0 {
+		return nil, fmt.Errorf("NOTIFICATION_DIGEST_INTERVAL must be positive, got %s", config.Notifications.DigestInterval)
+	}
+
+	switch config.Server.ErrorFormat {
+	case "simple", "problem":
+	default:
+		return nil, fmt.Errorf("unsupported ERROR_FORMAT %q, use simple or problem", config.Server.ErrorFormat)
+	}
+
 	// Преобразуем строку адресов Redis в слайс
 	if config.Redis.AddressesStr != "" {
 		config.Redis.Addresses = strings.Split(config.Redis.AddressesStr, ",")
@@ -145,9 +462,91 @@ func Load(args []string) (*Config, error) {
 		config.Redis.UseEmbedded = true
 	}
 
+	// Преобразуем строку разрешенных CORS источников в слайс
+	if config.CORS.AllowedOriginsStr != "" {
+		config.CORS.AllowedOrigins = strings.Split(config.CORS.AllowedOriginsStr, ",")
+	}
+
+	// Разбираем список полей журнала доступа
+	for _, field := range strings.Split(config.Server.AccessLogFieldsStr, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			config.Server.AccessLogFields = append(config.Server.AccessLogFields, field)
+		}
+	}
+
+	// Разбираем список зарезервированных имен полей отправок
+	for _, name := range strings.Split(config.Submission.ReservedFieldsStr, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			config.Submission.ReservedFields = append(config.Submission.ReservedFields, name)
+		}
+	}
+
+	// Разбираем списки разрешенных/запрещенных типов виджетов по тарифам
+	config.Plans.AllowedTypes = parsePlanLists(config.Plans.AllowedTypesStr)
+	config.Plans.DeniedTypes = parsePlanLists(config.Plans.DeniedTypesStr)
+	config.Plans.DailyExports = parsePlanLimits(config.Plans.DailyExportsStr)
+	config.Plans.ExportRows = parsePlanLimits(config.Plans.ExportRowsStr)
+
+	// Разбираем шаблоны имен файлов экспорта по форматам
+	config.Export.FilenameTemplates = parseFormatTemplates(config.Export.FilenameTemplatesStr)
+
 	return config, nil
 }
 
+// parsePlanLists parses "plan:a|b,plan2:c" into a map of plan -> values
+func parsePlanLists(value string) map[string][]string {
+	result := make(map[string][]string)
+	if strings.TrimSpace(value) == "" {
+		return result
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		plan, list, ok := strings.Cut(entry, ":")
+		plan = strings.TrimSpace(plan)
+		if !ok || plan == "" {
+			continue
+		}
+		for _, item := range strings.Split(list, "|") {
+			if item = strings.TrimSpace(item); item != "" {
+				result[plan] = append(result[plan], item)
+			}
+		}
+	}
+
+	return result
+}
+
+// parsePlanLimits parses "plan:10,plan2:100" into a map of plan -> limit, skipping
+// malformed entries
+func parsePlanLimits(value string) map[string]int {
+	result := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		plan, limitStr, ok := strings.Cut(entry, ":")
+		plan = strings.TrimSpace(plan)
+		if !ok || plan == "" {
+			continue
+		}
+		if limit, err := strconv.Atoi(strings.TrimSpace(limitStr)); err == nil && limit > 0 {
+			result[plan] = limit
+		}
+	}
+	return result
+}
+
+// parseFormatTemplates parses "csv:{id}_{date},xlsx:{name}" into a map of format -> template
+func parseFormatTemplates(value string) map[string]string {
+	result := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		format, template, ok := strings.Cut(entry, ":")
+		format = strings.ToLower(strings.TrimSpace(format))
+		template = strings.TrimSpace(template)
+		if ok && format != "" && template != "" {
+			result[format] = template
+		}
+	}
+	return result
+}
+
 // getEnv gets an environment variable with a default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
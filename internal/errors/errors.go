@@ -7,4 +7,23 @@ var (
 	ErrAccessDenied   = errors.New("access denied")
 	ErrAlreadyExists  = errors.New("already exists")
 	ErrWidgetDisabled = errors.New("widget is disabled")
+	ErrWidgetArchived = errors.New("widget is archived")
+	ErrTypePaused     = errors.New("widget type is paused")
+	ErrLimitReached   = errors.New("limit reached")
+	ErrBusy           = errors.New("resource is busy")
+	ErrJobNotFound    = errors.New("job not found")
+	ErrJobFinished    = errors.New("job already finished")
+
+	ErrSubmissionNotFound    = errors.New("submission not found")
+	ErrConfigVersionNotFound = errors.New("config version not found")
+
+	ErrPixelTrackingDisabled = errors.New("pixel tracking is not enabled")
+	ErrFormTokensDisabled    = errors.New("form tokens are not enabled")
+
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid")
+	ErrRefreshTokenExpired = errors.New("refresh token has expired")
+	ErrRefreshTokenRevoked = errors.New("refresh token has been revoked")
+
+	ErrTokenInvalid   = errors.New("token is invalid")
+	ErrTokenWithoutID = errors.New("token has no jti claim")
 )
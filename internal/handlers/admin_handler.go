@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ad/leads-core/internal/auth"
+	customErrors "github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/middleware"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/services"
+	"github.com/ad/leads-core/internal/validation"
+	"github.com/ad/leads-core/pkg/logger"
+)
+
+// AdminHandler handles administrative HTTP requests
+type AdminHandler struct {
+	maintenance   *middleware.Maintenance
+	validator     *validation.SchemaValidator
+	widgetService *services.WidgetService
+	jwtValidator  *auth.JWTValidator
+	rateLimiter   *middleware.RateLimiter
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(maintenance *middleware.Maintenance, validator *validation.SchemaValidator) *AdminHandler {
+	return &AdminHandler{
+		maintenance: maintenance,
+		validator:   validator,
+	}
+}
+
+// SetWidgetService enables the widget type pause and widget index endpoints
+func (h *AdminHandler) SetWidgetService(widgetService *services.WidgetService) {
+	h.widgetService = widgetService
+}
+
+// SetJWTValidator enables the token revocation endpoint; the validator needs a
+// revocation list
+func (h *AdminHandler) SetJWTValidator(jwtValidator *auth.JWTValidator) {
+	h.jwtValidator = jwtValidator
+}
+
+// SetRateLimiter enables the rate limit inspection endpoint
+func (h *AdminHandler) SetRateLimiter(rateLimiter *middleware.RateLimiter) {
+	h.rateLimiter = rateLimiter
+}
+
+// Maintenance handles GET and PUT /api/v1/admin/maintenance
+func (h *AdminHandler) Maintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	if !user.IsAdmin() {
+		writeErrorResponse(w, http.StatusForbidden, "Admin role required")
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var req models.MaintenanceUpdateRequest
+		if err := h.validator.ValidateAndDecode(r, "maintenance-update", &req); err != nil {
+			if valErr, ok := err.(*validation.ValidationError); ok {
+				writeValidationErrors(w, valErr.Errors)
+				return
+			}
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+
+		if err := h.maintenance.SetEnabled(r.Context(), req.Enabled); err != nil {
+			logger.Error("Failed to update maintenance mode", map[string]interface{}{
+				"action":  "update_maintenance",
+				"user_id": user.ID,
+				"error":   err.Error(),
+			})
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to update maintenance mode")
+			return
+		}
+
+		logger.Info("Maintenance mode updated", map[string]interface{}{
+			"action":  "update_maintenance",
+			"user_id": user.ID,
+			"enabled": req.Enabled,
+		})
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.Response{
+		Data: models.MaintenanceStatus{
+			Enabled:    h.maintenance.Enabled(r.Context()),
+			RetryAfter: int(h.maintenance.RetryAfter().Seconds()),
+		},
+	})
+}
+
+// PausedTypes handles GET /api/v1/admin/paused-types and PUT / DELETE
+// /api/v1/admin/paused-types/{type}, which pause and resume submissions to all
+// widgets of a type
+func (h *AdminHandler) PausedTypes(w http.ResponseWriter, r *http.Request) {
+	widgetType := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/admin/paused-types"), "/")
+	if (widgetType == "" && r.Method != http.MethodGet) ||
+		(widgetType != "" && r.Method != http.MethodPut && r.Method != http.MethodDelete) {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	if !user.IsAdmin() {
+		writeErrorResponse(w, http.StatusForbidden, "Admin role required")
+		return
+	}
+	if h.widgetService == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Pausing widget types is not enabled")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if !models.IsValidWidgetType(widgetType) {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid widget type")
+			return
+		}
+		if err := h.widgetService.PauseWidgetType(r.Context(), widgetType); err != nil {
+			logger.Error("Failed to pause widget type", map[string]interface{}{
+				"action": "pause_widget_type",
+				"type":   widgetType,
+				"error":  err.Error(),
+			})
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to pause widget type")
+			return
+		}
+		logger.Info("Widget type paused", map[string]interface{}{
+			"action":  "pause_widget_type",
+			"user_id": user.ID,
+			"type":    widgetType,
+		})
+	case http.MethodDelete:
+		resumed, err := h.widgetService.ResumeWidgetType(r.Context(), widgetType)
+		if err != nil {
+			logger.Error("Failed to resume widget type", map[string]interface{}{
+				"action": "resume_widget_type",
+				"type":   widgetType,
+				"error":  err.Error(),
+			})
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to resume widget type")
+			return
+		}
+		if !resumed {
+			writeErrorResponse(w, http.StatusNotFound, "Widget type is not paused")
+			return
+		}
+		logger.Info("Widget type resumed", map[string]interface{}{
+			"action":  "resume_widget_type",
+			"user_id": user.ID,
+			"type":    widgetType,
+		})
+	}
+
+	types, err := h.widgetService.ListPausedWidgetTypes(r.Context())
+	if err != nil {
+		logger.Error("Failed to list paused widget types", map[string]interface{}{
+			"action": "list_paused_widget_types",
+			"error":  err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to list paused widget types")
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: types})
+}
+
+// WidgetIndexes handles GET /api/v1/admin/widgets/{id}/indexes, reporting which type,
+// status, time and owner index keys hold the widget so index drift can be diagnosed
+func (h *AdminHandler) WidgetIndexes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	if !user.IsAdmin() {
+		writeErrorResponse(w, http.StatusForbidden, "Admin role required")
+		return
+	}
+	if h.widgetService == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Widget diagnostics are not enabled")
+		return
+	}
+
+	widgetID, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/admin/widgets/"), "/indexes")
+	if !ok || widgetID == "" || strings.Contains(widgetID, "/") {
+		writeErrorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	report, err := h.widgetService.GetWidgetIndexMembership(r.Context(), widgetID)
+	if err != nil {
+		if errors.Is(err, customErrors.ErrNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
+			return
+		}
+		logger.Error("Failed to check widget index membership", map[string]interface{}{
+			"action":    "widget_indexes",
+			"widget_id": widgetID,
+			"error":     err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to check widget indexes")
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: report})
+}
+
+// RebuildIndexes handles POST /api/v1/admin/indexes/rebuild, which reconstructs all
+// widget indexes from the widget records to repair drift left by partially failed writes
+func (h *AdminHandler) RebuildIndexes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	if !user.IsAdmin() {
+		writeErrorResponse(w, http.StatusForbidden, "Admin role required")
+		return
+	}
+	if h.widgetService == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Widget diagnostics are not enabled")
+		return
+	}
+
+	start := time.Now()
+	if err := h.widgetService.RebuildWidgetIndexes(r.Context()); err != nil {
+		logger.Error("Failed to rebuild widget indexes", map[string]interface{}{
+			"action":  "rebuild_indexes",
+			"user_id": user.ID,
+			"error":   err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to rebuild widget indexes")
+		return
+	}
+
+	logger.Info("Widget indexes rebuilt", map[string]interface{}{
+		"action":      "rebuild_indexes",
+		"user_id":     user.ID,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeToken handles POST /api/v1/admin/tokens/revoke, which puts an access token's
+// jti on the revocation list until the token expires
+func (h *AdminHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	if !user.IsAdmin() {
+		writeErrorResponse(w, http.StatusForbidden, "Admin role required")
+		return
+	}
+	if h.jwtValidator == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Token revocation is not enabled")
+		return
+	}
+
+	var req models.TokenRevokeRequest
+	if err := h.validator.ValidateAndDecode(r, "token-revoke", &req); err != nil {
+		if valErr, ok := err.(*validation.ValidationError); ok {
+			writeValidationErrors(w, valErr.Errors)
+			return
+		}
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	result, err := h.jwtValidator.RevokeToken(r.Context(), req.Token, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, customErrors.ErrTokenInvalid):
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid token")
+		case errors.Is(err, customErrors.ErrTokenWithoutID):
+			writeErrorResponse(w, http.StatusUnprocessableEntity, "Token has no jti claim and can't be revoked")
+		default:
+			logger.Error("Failed to revoke token", map[string]interface{}{
+				"action": "revoke_token",
+				"error":  err.Error(),
+			})
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to revoke token")
+		}
+		return
+	}
+
+	logger.Info("Token revoked", map[string]interface{}{
+		"action":  "revoke_token",
+		"user_id": user.ID,
+		"jti":     result.JTI,
+		"revoked": result.Revoked,
+	})
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: result})
+}
+
+// RateLimit handles GET and DELETE /api/v1/admin/ratelimit?ip=..., which show and reset
+// the IP's rate limit counter of the current window
+func (h *AdminHandler) RateLimit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	if !user.IsAdmin() {
+		writeErrorResponse(w, http.StatusForbidden, "Admin role required")
+		return
+	}
+	if h.rateLimiter == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Rate limit inspection is not enabled")
+		return
+	}
+
+	ip := strings.TrimSpace(r.URL.Query().Get("ip"))
+	if net.ParseIP(ip) == nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid ip parameter")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		reset, err := h.rateLimiter.Reset(r.Context(), ip)
+		if err != nil {
+			logger.Error("Failed to reset rate limit", map[string]interface{}{
+				"action": "reset_rate_limit",
+				"ip":     ip,
+				"error":  err.Error(),
+			})
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to reset rate limit")
+			return
+		}
+		logger.Info("Rate limit reset", map[string]interface{}{
+			"action":  "reset_rate_limit",
+			"user_id": user.ID,
+			"ip":      ip,
+			"reset":   reset,
+		})
+	}
+
+	usage, err := h.rateLimiter.Usage(r.Context(), ip)
+	if err != nil {
+		logger.Error("Failed to get rate limit usage", map[string]interface{}{
+			"action": "rate_limit_usage",
+			"ip":     ip,
+			"error":  err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get rate limit usage")
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: usage})
+}
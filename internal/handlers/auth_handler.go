@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ad/leads-core/internal/auth"
+	customErrors "github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/services"
+	"github.com/ad/leads-core/internal/validation"
+	"github.com/ad/leads-core/pkg/logger"
+)
+
+// AuthHandler handles token issuing, refresh and revocation requests
+type AuthHandler struct {
+	tokenService *services.TokenService
+	validator    *validation.SchemaValidator
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(tokenService *services.TokenService, validator *validation.SchemaValidator) *AuthHandler {
+	return &AuthHandler{
+		tokenService: tokenService,
+		validator:    validator,
+	}
+}
+
+// IssueTokens handles POST /api/v1/auth/token, issuing an access and refresh token pair
+// for the authenticated user. The refresh token is bound to the request's token.
+func (h *AuthHandler) IssueTokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	pair, err := h.tokenService.IssueTokens(r.Context(), user, r.Header.Get("Authorization"))
+	if err != nil {
+		if errors.Is(err, customErrors.ErrTokenInvalid) {
+			writeErrorResponse(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+		logger.Error("Failed to issue tokens", map[string]interface{}{
+			"action":  "issue_tokens",
+			"user_id": user.ID,
+			"error":   err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to issue tokens")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, pair)
+}
+
+// RefreshToken handles POST /api/v1/auth/refresh, exchanging a refresh token for a new
+// access token
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	req, ok := h.decodeRefreshTokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	pair, err := h.tokenService.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, customErrors.ErrRefreshTokenInvalid):
+			writeErrorResponse(w, http.StatusUnauthorized, "Invalid refresh token")
+		case errors.Is(err, customErrors.ErrRefreshTokenExpired):
+			writeErrorResponse(w, http.StatusUnauthorized, "Refresh token has expired")
+		case errors.Is(err, customErrors.ErrRefreshTokenRevoked):
+			writeErrorResponse(w, http.StatusUnauthorized, "Refresh token has been revoked")
+		default:
+			logger.Error("Failed to refresh token", map[string]interface{}{
+				"action": "refresh_token",
+				"error":  err.Error(),
+			})
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to refresh token")
+		}
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, pair)
+}
+
+// RevokeToken handles POST /api/v1/auth/revoke. Unknown tokens are not reported, as
+// in RFC 7009, so the endpoint can't be used to probe for valid tokens.
+func (h *AuthHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	req, ok := h.decodeRefreshTokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.tokenService.Revoke(r.Context(), req.RefreshToken); err != nil {
+		logger.Error("Failed to revoke refresh token", map[string]interface{}{
+			"action": "revoke_token",
+			"error":  err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to revoke refresh token")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeRefreshTokenRequest decodes a refresh token request body, writing the error
+// response when it's invalid
+func (h *AuthHandler) decodeRefreshTokenRequest(w http.ResponseWriter, r *http.Request) (*models.RefreshTokenRequest, bool) {
+	var req models.RefreshTokenRequest
+	if err := h.validator.ValidateAndDecode(r, "refresh-token", &req); err != nil {
+		if writeLimitError(w, err) {
+			return nil, false
+		}
+		if valErr, ok := err.(*validation.ValidationError); ok {
+			writeValidationErrors(w, valErr.Errors)
+			return nil, false
+		}
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
+		return nil, false
+	}
+	return &req, true
+}
@@ -40,6 +40,9 @@ func routePrivateWidgetEndpoints(handler *WidgetHandler) http.HandlerFunc {
 			default:
 				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			}
+		case path == "/bulk-stats-reset":
+			// POST /api/v1/widgets/bulk-stats-reset
+			handler.BulkResetStats(w, r)
 		case path == "/summary":
 			// GET /api/v1/widgets/summary
 			if r.Method == http.MethodGet {
@@ -52,11 +55,26 @@ func routePrivateWidgetEndpoints(handler *WidgetHandler) http.HandlerFunc {
 			// Reconstruct URL as /widgets/{id}/stats for handler
 			r.URL.Path = "/widgets" + path
 			handler.GetWidgetStats(w, r)
+		case strings.HasSuffix(path, "/schema/inferred"):
+			// GET /api/v1/widgets/{id}/schema/inferred
+			// Reconstruct URL as /widgets/{id}/schema/inferred for handler
+			r.URL.Path = "/widgets" + path
+			handler.GetInferredSchema(w, r)
+		case strings.Contains(path, "/submissions/"):
+			// DELETE /api/v1/widgets/{id}/submissions/{sid}
+			// Reconstruct URL as /widgets/{id}/submissions/{sid} for handler
+			r.URL.Path = "/widgets" + path
+			handler.DeleteSubmission(w, r)
 		case strings.HasSuffix(path, "/submissions"):
 			// GET /api/v1/widgets/{id}/submissions
 			// Reconstruct URL as /widgets/{id}/submissions for handler
 			r.URL.Path = "/widgets" + path
 			handler.GetWidgetSubmissions(w, r)
+		case strings.HasSuffix(path, "/import"):
+			// POST /api/v1/widgets/{id}/import
+			// Reconstruct URL as /widgets/{id}/import for handler
+			r.URL.Path = "/widgets" + path
+			handler.ImportWidgetSubmissions(w, r)
 		case strings.HasSuffix(path, "/export"):
 			// GET /api/v1/widgets/{id}/export
 			// Reconstruct URL as /widgets/{id}/export for handler
@@ -184,7 +202,7 @@ func setupE2EServer(t *testing.T) *E2ETestServer {
 	exportService := services.NewExportService(submissionRepo, widgetRepo)
 
 	// Initialize handlers
-	widgetHandler := NewWidgetHandler(widgetService, exportService, validator)
+	widgetHandler := NewWidgetHandler(widgetService, exportService, services.NewImportService(widgetService, validator), validator)
 	publicHandler := NewPublicHandler(widgetService, validator)
 
 	// Create router using the same structure as main server
@@ -204,7 +222,7 @@ func setupE2EServer(t *testing.T) *E2ETestServer {
 	})
 
 	// Public widget submission endpoint (no auth required)
-	publicChain := http.HandlerFunc(routePublicWidgetEndpoints(publicHandler))
+	publicChain := authMiddleware.WidgetScope(http.HandlerFunc(routePublicWidgetEndpoints(publicHandler)))
 	mux.Handle("/widgets/", publicChain)
 
 	// Private API endpoints using the same routing as main server
@@ -243,6 +261,18 @@ func (e2e *E2ETestServer) createTestToken(userID string) string {
 	return tokenString
 }
 
+// createWidgetToken creates a valid widget-scoped JWT token for testing
+func (e2e *E2ETestServer) createWidgetToken(widgetID string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"widget_id": widgetID,
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"iat":       time.Now().Unix(),
+	})
+
+	tokenString, _ := token.SignedString([]byte(e2e.config.JWT.Secret))
+	return tokenString
+}
+
 // makeRequest makes an HTTP request to the test server
 func (e2e *E2ETestServer) makeRequest(method, path string, body []byte, headers map[string]string) (*http.Response, error) {
 	url := e2e.baseURL + path
@@ -476,6 +506,115 @@ func TestE2E_PublicSubmission(t *testing.T) {
 	}
 }
 
+func TestE2E_WidgetScopedToken(t *testing.T) {
+	e2e := setupE2EServer(t)
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + e2e.createTestToken("test-user-id"),
+		"Content-Type":  "application/json",
+	}
+
+	createWidgetData := []byte(`{"name": "Scoped Widget", "type": "lead-form", "isVisible": true, "config": {}}`)
+	resp, err := e2e.makeRequest("POST", "/api/v1/widgets", createWidgetData, headers)
+	if err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var widgetData models.Widget
+	json.NewDecoder(resp.Body).Decode(&widgetData)
+	if widgetData.ID == "" {
+		t.Fatal("Widget ID is empty")
+	}
+
+	submissionData := []byte(`{"data": {"email": "john@example.com"}}`)
+
+	t.Run("matching scope is trusted", func(t *testing.T) {
+		scopedHeaders := map[string]string{
+			"Authorization": "Bearer " + e2e.createWidgetToken(widgetData.ID),
+			"Content-Type":  "application/json",
+		}
+
+		resp, err := e2e.makeRequest("POST", "/widgets/"+widgetData.ID+"/submit", submissionData, scopedHeaders)
+		if err != nil {
+			t.Fatalf("Failed to submit data: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			body, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 201, got %d. Body: %s", resp.StatusCode, body)
+		}
+
+		var response struct {
+			Data models.Submission `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if !response.Data.Trusted {
+			t.Error("Expected submission to be marked as trusted")
+		}
+	})
+
+	t.Run("mismatched scope is rejected", func(t *testing.T) {
+		scopedHeaders := map[string]string{
+			"Authorization": "Bearer " + e2e.createWidgetToken("other-widget"),
+			"Content-Type":  "application/json",
+		}
+
+		resp, err := e2e.makeRequest("POST", "/widgets/"+widgetData.ID+"/submit", submissionData, scopedHeaders)
+		if err != nil {
+			t.Fatalf("Failed to submit data: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("user token is ignored on public endpoints", func(t *testing.T) {
+		// An embed inside an authenticated dashboard sends the user's token along
+		resp, err := e2e.makeRequest("POST", "/widgets/"+widgetData.ID+"/submit", submissionData, headers)
+		if err != nil {
+			t.Fatalf("Failed to submit data: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			body, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 201, got %d. Body: %s", resp.StatusCode, body)
+		}
+
+		var response struct {
+			Data models.Submission `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Data.Trusted {
+			t.Error("Expected submission with a user token not to be trusted")
+		}
+	})
+
+	t.Run("widget token grants no private access", func(t *testing.T) {
+		scopedHeaders := map[string]string{
+			"Authorization": "Bearer " + e2e.createWidgetToken(widgetData.ID),
+		}
+
+		resp, err := e2e.makeRequest("GET", "/api/v1/widgets/"+widgetData.ID, nil, scopedHeaders)
+		if err != nil {
+			t.Fatalf("Failed to get widget: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", resp.StatusCode)
+		}
+	})
+}
+
 func TestE2E_Authorization(t *testing.T) {
 	e2e := setupE2EServer(t)
 
@@ -553,6 +692,108 @@ func TestE2E_Authorization(t *testing.T) {
 	}
 }
 
+func TestE2E_DeleteSubmission(t *testing.T) {
+	e2e := setupE2EServer(t)
+
+	ownerHeaders := map[string]string{
+		"Authorization": "Bearer " + e2e.createTestToken("owner"),
+		"Content-Type":  "application/json",
+	}
+	otherHeaders := map[string]string{
+		"Authorization": "Bearer " + e2e.createTestToken("other"),
+		"Content-Type":  "application/json",
+	}
+
+	resp, err := e2e.makeRequest("POST", "/api/v1/widgets", []byte(`{"name": "Spam Target", "type": "lead-form", "isVisible": true, "config": {}}`), ownerHeaders)
+	if err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var widgetData models.Widget
+	json.NewDecoder(resp.Body).Decode(&widgetData)
+	if widgetData.ID == "" {
+		t.Fatal("Widget ID is empty")
+	}
+
+	submit := func() string {
+		resp, err := e2e.makeRequest("POST", "/widgets/"+widgetData.ID+"/submit", []byte(`{"data": {"email": "spam@example.com"}}`), map[string]string{"Content-Type": "application/json"})
+		if err != nil {
+			t.Fatalf("Failed to submit data: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201 for submission, got %d", resp.StatusCode)
+		}
+		var response struct {
+			Data models.Submission `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&response)
+		return response.Data.ID
+	}
+	submissionPath := func(submissionID string) string {
+		return "/api/v1/widgets/" + widgetData.ID + "/submissions/" + submissionID
+	}
+
+	spamID := submit()
+	otherID := submit()
+
+	// Owner deletes their own submission
+	resp, err = e2e.makeRequest("DELETE", submissionPath(spamID), nil, ownerHeaders)
+	if err != nil {
+		t.Fatalf("Failed to delete submission: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", resp.StatusCode)
+	}
+
+	// Deleting it again finds nothing
+	resp, err = e2e.makeRequest("DELETE", submissionPath(spamID), nil, ownerHeaders)
+	if err != nil {
+		t.Fatalf("Failed to delete submission: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a deleted submission, got %d", resp.StatusCode)
+	}
+
+	// Another user can't delete the owner's submissions
+	resp, err = e2e.makeRequest("DELETE", submissionPath(otherID), nil, otherHeaders)
+	if err != nil {
+		t.Fatalf("Failed to delete submission: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a non-owner, got %d", resp.StatusCode)
+	}
+
+	// The remaining submission and the submit count reflect the deletion
+	resp, err = e2e.makeRequest("GET", "/api/v1/widgets/"+widgetData.ID+"/submissions", nil, ownerHeaders)
+	if err != nil {
+		t.Fatalf("Failed to get submissions: %v", err)
+	}
+	defer resp.Body.Close()
+	var listed struct {
+		Data []models.Submission `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&listed)
+	if len(listed.Data) != 1 || listed.Data[0].ID != otherID {
+		t.Errorf("Expected only submission %s to remain, got %+v", otherID, listed.Data)
+	}
+
+	resp, err = e2e.makeRequest("GET", "/api/v1/widgets/"+widgetData.ID+"/stats", nil, ownerHeaders)
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	defer resp.Body.Close()
+	var stats models.WidgetStats
+	json.NewDecoder(resp.Body).Decode(&stats)
+	if stats.Submits != 1 {
+		t.Errorf("Expected 1 submit after deletion, got %d", stats.Submits)
+	}
+}
+
 func TestE2E_InvalidRequests(t *testing.T) {
 	e2e := setupE2EServer(t)
 
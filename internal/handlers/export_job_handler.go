@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ad/leads-core/internal/auth"
+	customErrors "github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/services"
+	"github.com/ad/leads-core/pkg/logger"
+)
+
+// SetExportJobService enables asynchronous export jobs
+func (h *WidgetHandler) SetExportJobService(exportJobService *services.ExportJobService) {
+	h.exportJobService = exportJobService
+}
+
+// ExportJobs handles GET and POST /widgets/{id}/export/jobs
+func (h *WidgetHandler) ExportJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listExportJobs(w, r)
+	case http.MethodPost:
+		h.createExportJob(w, r)
+	default:
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// createExportJob queues an export with the same query parameters as GET /widgets/{id}/export
+func (h *WidgetHandler) createExportJob(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+	if h.exportJobService == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Export jobs are not enabled")
+		return
+	}
+
+	widgetID := extractWidgetID(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	options, err := parseExportOptions(r, h.defaultExportFormat(r, user.ID))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// The job keeps the cap and quota of the plan it was queued under; it counts towards
+	// the quota once it succeeds, used up quotas are turned down here already
+	options.MaxRows = h.exportService.RowLimit(user.Plan)
+	options.DailyQuota = h.exportService.DailyQuota(user.Plan)
+
+	if err := h.exportService.CheckDailyQuota(r.Context(), user.ID, user.Plan); err != nil {
+		writeQuotaExceeded(w, err)
+		return
+	}
+
+	job, err := h.exportJobService.CreateJob(r.Context(), widgetID, user.ID, options)
+	if err != nil {
+		if writeWidgetLookupError(w, user, err) {
+			return
+		}
+		if errors.Is(err, customErrors.ErrBusy) {
+			writeErrorResponse(w, http.StatusServiceUnavailable, "Too many pending exports, try again later")
+			return
+		}
+		var rangeErr *models.ExportRangeError
+		if errors.As(err, &rangeErr) {
+			writeErrorResponse(w, http.StatusBadRequest, rangeErr.Error())
+			return
+		}
+		logger.Error("Failed to create export job", map[string]interface{}{
+			"widget_id": widgetID,
+			"user_id":   user.ID,
+			"error":     err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to create export job")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusAccepted, job)
+}
+
+// listExportJobs lists the widget's export jobs, optionally filtered by ?status=
+func (h *WidgetHandler) listExportJobs(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+	if h.exportJobService == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Export jobs are not enabled")
+		return
+	}
+
+	widgetID := extractWidgetID(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	status := strings.TrimSpace(r.URL.Query().Get("status"))
+	if status != "" && !models.IsValidExportJobStatus(status) {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid status. Supported statuses: queued, running, retrying, completed, failed, cancelled")
+		return
+	}
+
+	opts := parsePaginationOptions(r)
+	jobs, total, err := h.exportJobService.ListJobs(r.Context(), widgetID, user.ID, status, opts)
+	if err != nil {
+		if writeWidgetLookupError(w, user, err) {
+			return
+		}
+		logger.Error("Failed to list export jobs", map[string]interface{}{
+			"widget_id": widgetID,
+			"user_id":   user.ID,
+			"error":     err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to list export jobs")
+		return
+	}
+
+	meta := &models.Meta{
+		Page:    opts.Page,
+		PerPage: opts.PerPage,
+		Total:   total,
+		HasMore: opts.Page*opts.PerPage < total,
+		Links:   paginationLinks(r, opts.Page, opts.PerPage, total),
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: jobs, Meta: meta})
+}
+
+// CancelExportJob handles POST /widgets/{id}/export/jobs/{job_id}/cancel
+func (h *WidgetHandler) CancelExportJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+	if h.exportJobService == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Export jobs are not enabled")
+		return
+	}
+
+	widgetID, jobID := extractExportJobIDs(r.URL.Path)
+	if widgetID == "" || jobID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID and job ID are required")
+		return
+	}
+
+	job, err := h.exportJobService.CancelJob(r.Context(), widgetID, user.ID, jobID)
+	if err != nil {
+		if writeWidgetLookupError(w, user, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, customErrors.ErrJobNotFound):
+			writeErrorResponse(w, http.StatusNotFound, "Export job not found")
+		case errors.Is(err, customErrors.ErrJobFinished):
+			writeErrorResponse(w, http.StatusConflict, "Export job already finished")
+		default:
+			logger.Error("Failed to cancel export job", map[string]interface{}{
+				"widget_id": widgetID,
+				"job_id":    jobID,
+				"error":     err.Error(),
+			})
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to cancel export job")
+		}
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, job)
+}
+
+// DownloadExportJob handles GET /widgets/{id}/export/jobs/{job_id}/download. Files
+// uploaded to object storage are served by redirecting to their presigned URL.
+func (h *WidgetHandler) DownloadExportJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+	if h.exportJobService == nil {
+		writeErrorResponse(w, http.StatusNotFound, "Export jobs are not enabled")
+		return
+	}
+
+	widgetID, jobID := extractExportJobIDs(r.URL.Path)
+	if widgetID == "" || jobID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID and job ID are required")
+		return
+	}
+
+	job, data, err := h.exportJobService.GetJobResult(r.Context(), widgetID, user.ID, jobID)
+	if err != nil {
+		if writeWidgetLookupError(w, user, err) {
+			return
+		}
+		if errors.Is(err, customErrors.ErrJobNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, "Export job not found")
+			return
+		}
+		logger.Error("Failed to get export job result", map[string]interface{}{
+			"widget_id": widgetID,
+			"job_id":    jobID,
+			"error":     err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get export job result")
+		return
+	}
+	if job.Status != models.ExportJobCompleted {
+		writeErrorResponse(w, http.StatusConflict, "Export job is not completed")
+		return
+	}
+	if job.DownloadURL != "" {
+		if job.DownloadExpires != nil && time.Now().After(*job.DownloadExpires) {
+			writeErrorResponse(w, http.StatusGone, "Export download link has expired")
+			return
+		}
+		http.Redirect(w, r, job.DownloadURL, http.StatusFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", models.ExportContentType(job.Format))
+	w.Header().Set("Content-Disposition", attachmentDisposition(job.Filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// extractExportJobIDs extracts widget and job IDs from /widgets/{id}/export/jobs/{job_id}/...
+func extractExportJobIDs(path string) (string, string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) >= 5 && parts[0] == "widgets" && parts[2] == "export" && parts[3] == "jobs" {
+		return parts[1], parts[4]
+	}
+	return "", ""
+}
@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/ad/leads-core/internal/auth"
+	customErrors "github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/middleware"
 	"github.com/ad/leads-core/internal/models"
 	"github.com/ad/leads-core/internal/services"
 	"github.com/ad/leads-core/internal/validation"
@@ -38,9 +44,19 @@ func (h *PublicHandler) SubmitWidget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Widget-scoped tokens may only be used for their own widget
+	scopedWidgetID, trusted := auth.GetWidgetScopeFromContext(r.Context())
+	if trusted && scopedWidgetID != widgetID {
+		writeErrorResponse(w, http.StatusForbidden, "Token is not valid for this widget")
+		return
+	}
+
 	// Parse and validate request
 	var req models.SubmissionRequest
 	if err := h.validator.ValidateAndDecode(r, "submission", &req); err != nil {
+		if writeLimitError(w, err) {
+			return
+		}
 		if valErr, ok := err.(*validation.ValidationError); ok {
 			writeErrorResponse(w, http.StatusBadRequest, "Validation error", valErr.Errors)
 			return
@@ -48,22 +64,14 @@ func (h *PublicHandler) SubmitWidget(w http.ResponseWriter, r *http.Request) {
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
+	req.Trusted = trusted
+	req.ClientIP = middleware.ClientIP(r)
+	req.Header = r.Header
 
 	// Submit widget
 	submission, err := h.widgetService.SubmitWidget(r.Context(), widgetID, req)
 	if err != nil {
-		logger.Error("Failed to submit widget", map[string]interface{}{
-			"action":    "submit_widget",
-			"widget_id": widgetID,
-			"error":     err.Error(),
-		})
-		if strings.Contains(err.Error(), "not found") {
-			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
-		} else if strings.Contains(err.Error(), "disabled") {
-			writeErrorResponse(w, http.StatusForbidden, "Widget is disabled")
-		} else {
-			writeErrorResponse(w, http.StatusBadRequest, err.Error())
-		}
+		writeSubmitError(w, widgetID, err)
 		return
 	}
 
@@ -89,6 +97,12 @@ func (h *PublicHandler) RegisterEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Widget-scoped tokens may only be used for their own widget
+	if scopedWidgetID, ok := auth.GetWidgetScopeFromContext(r.Context()); ok && scopedWidgetID != widgetID {
+		writeErrorResponse(w, http.StatusForbidden, "Token is not valid for this widget")
+		return
+	}
+
 	// Parse and validate request
 	var req models.EventRequest
 	if err := h.validator.ValidateAndDecode(r, "event", &req); err != nil {
@@ -100,36 +114,300 @@ func (h *PublicHandler) RegisterEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate event type
-	if req.Type != "view" && req.Type != "close" {
-		writeErrorResponse(w, http.StatusBadRequest, "Invalid event type. Must be 'view' or 'close'")
+	if h.registerEvent(w, r, widgetID, req) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// FormToken handles GET /widgets/{id}/form-token, which the embed calls when it renders
+// the form and sends back with the submission, so policies' min_fill_seconds are checked
+// against a render time the client can't forge
+func (h *PublicHandler) FormToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	// Register event
-	if err := h.widgetService.RegisterWidgetEvent(r.Context(), widgetID, req.Type); err != nil {
-		logger.Error("Failed to register event", map[string]interface{}{
-			"action":    "register_event",
-			"widget_id": widgetID,
-			"type":      req.Type,
-			"error":     err.Error(),
-		})
-		if strings.Contains(err.Error(), "not found") {
+	widgetID := extractWidgetIDFromFormTokenPath(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	token, err := h.widgetService.IssueFormToken(r.Context(), widgetID)
+	if err != nil {
+		switch {
+		case errors.Is(err, customErrors.ErrFormTokensDisabled):
+			writeErrorResponse(w, http.StatusNotFound, "Form tokens are not enabled")
+		case errors.Is(err, customErrors.ErrNotFound):
 			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
-		} else if strings.Contains(err.Error(), "disabled") {
-			writeErrorResponse(w, http.StatusForbidden, "Widget is disabled")
-		} else {
-			writeErrorResponse(w, http.StatusInternalServerError, "Failed to register event")
+		case errors.Is(err, customErrors.ErrWidgetArchived):
+			writeErrorResponse(w, http.StatusForbidden, "Widget is archived")
+		default:
+			logger.Error("Failed to issue form token", map[string]interface{}{
+				"action":    "issue_form_token",
+				"widget_id": widgetID,
+				"error":     err.Error(),
+			})
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to issue form token")
+		}
+		return
+	}
+
+	// Each render needs its own token
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: token})
+}
+
+// TrackEvent handles GET /widgets/{id}/events?type=view, the tracking pixel variant of
+// events for widgets with pixel tracking. It answers with a transparent GIF.
+func (h *PublicHandler) TrackEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	widgetID := extractWidgetIDFromEventPath(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	if scopedWidgetID, ok := auth.GetWidgetScopeFromContext(r.Context()); ok && scopedWidgetID != widgetID {
+		writeErrorResponse(w, http.StatusForbidden, "Token is not valid for this widget")
+		return
+	}
+
+	req := models.EventRequest{Type: r.URL.Query().Get("type"), Pixel: true}
+	if h.registerEvent(w, r, widgetID, req) {
+		writeTrackingPixel(w)
+	}
+}
+
+// TrackSubmission handles GET /widgets/{id}/track?field=value, the tracking pixel variant
+// of submit for widgets with pixel tracking: query params are the submission's string
+// data fields, checked like a submit body. It answers with a transparent GIF.
+func (h *PublicHandler) TrackSubmission(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	widgetID := extractWidgetIDFromTrackPath(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	scopedWidgetID, trusted := auth.GetWidgetScopeFromContext(r.Context())
+	if trusted && scopedWidgetID != widgetID {
+		writeErrorResponse(w, http.StatusForbidden, "Token is not valid for this widget")
+		return
+	}
+
+	// Build the submit body from the query, so the submission schema and JSON limits
+	// apply; fields given more than once are ambiguous
+	query := r.URL.Query()
+	data := make(map[string]string, len(query))
+	var fieldErrs models.FieldErrors
+	for field, values := range query {
+		if len(values) > 1 {
+			fieldErrs = append(fieldErrs, &models.FieldError{Field: field, Message: "must be given only once"})
+			continue
+		}
+		data[field] = values[0]
+	}
+	if len(fieldErrs) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "Validation error", fieldErrs)
+		return
+	}
+	body, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid query")
+		return
+	}
+
+	var req models.SubmissionRequest
+	if err := h.validator.ValidateAndDecodeBytes(body, "submission", &req); err != nil {
+		if writeLimitError(w, err) {
+			return
+		}
+		if valErr, ok := err.(*validation.ValidationError); ok {
+			writeErrorResponse(w, http.StatusBadRequest, "Validation error", valErr.Errors)
+			return
 		}
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid query")
 		return
 	}
+	req.Trusted = trusted
+	req.ClientIP = middleware.ClientIP(r)
+	req.Header = r.Header
+	req.Pixel = true
+
+	submission, err := h.widgetService.SubmitWidget(r.Context(), widgetID, req)
+	if err != nil {
+		writeSubmitError(w, widgetID, err)
+		return
+	}
+
+	logger.Debug("Widget submitted by tracking pixel", map[string]interface{}{
+		"action":        "track_submission",
+		"widget_id":     widgetID,
+		"submission_id": submission.ID,
+	})
+	writeTrackingPixel(w)
+}
+
+// registerEvent registers the validated event, handling the view dedup cookie, and
+// writes the error response when it fails
+func (h *PublicHandler) registerEvent(w http.ResponseWriter, r *http.Request, widgetID string, req models.EventRequest) bool {
+	// Validate event type
+	if req.Type != "view" && req.Type != "close" {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid event type. Must be 'view' or 'close'")
+		return false
+	}
+
+	// Visitors that already viewed the widget within its dedup window carry a cookie
+	if req.Type == "view" {
+		_, err := r.Cookie(viewCookieName(widgetID))
+		req.Seen = err == nil
+		req.ClientIP = middleware.ClientIP(r)
+	}
+
+	// Register event
+	dedupWindow, err := h.widgetService.RegisterEvent(r.Context(), widgetID, req)
+	if err != nil {
+		writeEventError(w, widgetID, req.Type, err)
+		return false
+	}
 
 	logger.Debug("Event registered successfully", map[string]interface{}{
 		"action":    "register_event",
 		"widget_id": widgetID,
 		"type":      req.Type,
 	})
-	w.WriteHeader(http.StatusNoContent)
+	if dedupWindow > 0 && !req.Seen {
+		setViewCookie(w, r, widgetID, dedupWindow)
+	}
+	return true
+}
+
+// writeSubmitError writes the error response of a failed submission
+func writeSubmitError(w http.ResponseWriter, widgetID string, err error) {
+	var fieldErrs models.FieldErrors
+	if errors.As(err, &fieldErrs) {
+		writeErrorResponse(w, http.StatusBadRequest, "Validation error", fieldErrs)
+		return
+	}
+	var headersErr *models.MissingHeadersError
+	if errors.As(err, &headersErr) {
+		writeErrorResponse(w, http.StatusForbidden, "Missing required headers", headersErr.Headers)
+		return
+	}
+	var policyErr *models.PolicyViolationError
+	if errors.As(err, &policyErr) {
+		details := map[string]interface{}{"code": policyErr.Reason}
+		if len(policyErr.Details) > 0 {
+			details["fields"] = policyErr.Details
+		}
+		writeErrorResponse(w, http.StatusForbidden, "Submission rejected by widget policy", details)
+		return
+	}
+	var geoErr *models.GeoBlockedError
+	if errors.As(err, &geoErr) {
+		writeErrorResponse(w, http.StatusForbidden, "Submissions from your location are not allowed", map[string]string{
+			"code":    "geo_blocked",
+			"country": geoErr.Country,
+		})
+		return
+	}
+	if errors.Is(err, customErrors.ErrWidgetArchived) {
+		writeErrorResponse(w, http.StatusForbidden, "Widget is archived")
+		return
+	}
+	if errors.Is(err, customErrors.ErrPixelTrackingDisabled) {
+		writeErrorResponse(w, http.StatusForbidden, "Pixel tracking is not enabled for this widget")
+		return
+	}
+	if errors.Is(err, customErrors.ErrTypePaused) {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Submissions are temporarily unavailable", map[string]string{
+			"code": "type_paused",
+		})
+		return
+	}
+	logger.Error("Failed to submit widget", map[string]interface{}{
+		"action":    "submit_widget",
+		"widget_id": widgetID,
+		"error":     err.Error(),
+	})
+	if strings.Contains(err.Error(), "not found") {
+		writeErrorResponse(w, http.StatusNotFound, "Widget not found")
+	} else if strings.Contains(err.Error(), "disabled") {
+		writeErrorResponse(w, http.StatusForbidden, "Widget is disabled")
+	} else {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+	}
+}
+
+// writeEventError writes the error response of a failed event registration
+func writeEventError(w http.ResponseWriter, widgetID, eventType string, err error) {
+	if errors.Is(err, customErrors.ErrPixelTrackingDisabled) {
+		writeErrorResponse(w, http.StatusForbidden, "Pixel tracking is not enabled for this widget")
+		return
+	}
+	logger.Error("Failed to register event", map[string]interface{}{
+		"action":    "register_event",
+		"widget_id": widgetID,
+		"type":      eventType,
+		"error":     err.Error(),
+	})
+	if strings.Contains(err.Error(), "not found") {
+		writeErrorResponse(w, http.StatusNotFound, "Widget not found")
+	} else if strings.Contains(err.Error(), "disabled") {
+		writeErrorResponse(w, http.StatusForbidden, "Widget is disabled")
+	} else {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to register event")
+	}
+}
+
+// trackingPixel is a transparent 1x1 GIF
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// writeTrackingPixel answers a tracking pixel request with the uncacheable GIF, so
+// every load reaches the server
+func writeTrackingPixel(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(trackingPixel)
+}
+
+// viewCookieName returns the name of the cookie marking a widget as viewed
+func viewCookieName(widgetID string) string {
+	return "lc_view_" + widgetID
+}
+
+// setViewCookie marks the widget as viewed by the visitor for the dedup window. Embeds
+// run on third-party sites, so over HTTPS the cookie is sent cross-site.
+func setViewCookie(w http.ResponseWriter, r *http.Request, widgetID string, window time.Duration) {
+	secure := r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+	sameSite := http.SameSiteLaxMode
+	if secure {
+		sameSite = http.SameSiteNoneMode
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     viewCookieName(widgetID),
+		Value:    "1",
+		Path:     "/",
+		MaxAge:   int(window.Seconds()),
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: sameSite,
+	})
 }
 
 // extractWidgetIDFromSubmitPath extracts widget ID from paths like /widgets/{id}/submit
@@ -143,6 +421,24 @@ func extractWidgetIDFromSubmitPath(path string) string {
 	return ""
 }
 
+// extractWidgetIDFromTrackPath extracts widget ID from paths like /widgets/{id}/track
+func extractWidgetIDFromTrackPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 3 && parts[0] == "widgets" && parts[2] == "track" {
+		return parts[1]
+	}
+	return ""
+}
+
+// extractWidgetIDFromFormTokenPath extracts widget ID from paths like /widgets/{id}/form-token
+func extractWidgetIDFromFormTokenPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 3 && parts[0] == "widgets" && parts[2] == "form-token" {
+		return parts[1]
+	}
+	return ""
+}
+
 // extractWidgetIDFromEventPath extracts widget ID from paths like /widgets/{id}/events
 func extractWidgetIDFromEventPath(path string) string {
 	// Remove leading/trailing slashes and split
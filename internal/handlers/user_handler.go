@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -122,6 +123,76 @@ func (h *UserHandler) UpdateUserTTL(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// UserPreferences handles GET and PUT /users/{id}/preferences
+func (h *UserHandler) UserPreferences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	// Extract user ID from URL
+	userID := extractUserIDFromPreferencesPath(r.URL.Path)
+	if userID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "User ID is required")
+		return
+	}
+
+	// Preferences are private to the user
+	if user.ID != userID {
+		writeErrorResponse(w, http.StatusForbidden, "Cannot access preferences of other users")
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		if userID == "demo" {
+			writeErrorResponse(w, http.StatusForbidden, "Cannot update preferences for demo user")
+			return
+		}
+
+		var req models.UserPreferences
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+		req.ExportFormat = strings.ToLower(strings.TrimSpace(req.ExportFormat))
+
+		if err := h.widgetService.UpdateUserPreferences(r.Context(), userID, &req); err != nil {
+			var fieldErrs models.FieldErrors
+			if errors.As(err, &fieldErrs) {
+				writeValidationErrors(w, fieldErrs)
+				return
+			}
+			logger.Error("Failed to update user preferences", map[string]interface{}{
+				"action":  "update_user_preferences",
+				"user_id": userID,
+				"error":   err.Error(),
+			})
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to update preferences")
+			return
+		}
+	}
+
+	preferences, err := h.widgetService.GetUserPreferences(r.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to get user preferences", map[string]interface{}{
+			"action":  "get_user_preferences",
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get preferences")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: preferences})
+}
+
 // extractUserIDFromTTLPath extracts user ID from paths like /users/{id}/ttl
 func extractUserIDFromTTLPath(path string) string {
 	// Remove leading/trailing slashes and split
@@ -132,3 +203,13 @@ func extractUserIDFromTTLPath(path string) string {
 	}
 	return ""
 }
+
+// extractUserIDFromPreferencesPath extracts user ID from paths like /users/{id}/preferences
+func extractUserIDFromPreferencesPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	// Expected format: ["users", "{id}", "preferences"]
+	if len(parts) == 3 && parts[0] == "users" && parts[2] == "preferences" {
+		return parts[1]
+	}
+	return ""
+}
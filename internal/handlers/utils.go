@@ -2,10 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
+	customErrors "github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/middleware"
 	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/validation"
 )
 
 // writeJSONResponse writes a JSON response
@@ -20,11 +28,9 @@ func writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{})
 	}
 }
 
-// writeErrorResponse writes an error response
+// writeErrorResponse writes an error response, as problem details when the request
+// gets them (see middleware.ProblemErrors)
 func writeErrorResponse(w http.ResponseWriter, statusCode int, message string, details ...interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
 	errorResp := models.ErrorResponse{
 		Error: message,
 	}
@@ -33,6 +39,14 @@ func writeErrorResponse(w http.ResponseWriter, statusCode int, message string, d
 		errorResp.Details = details[0]
 	}
 
+	if instance, ok := middleware.ProblemInstance(w); ok {
+		middleware.WriteProblem(w, statusCode, message, errorResp.Details, instance)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
 	if err := json.NewEncoder(w).Encode(errorResp); err != nil {
 		// Fallback to simple string response if JSON encoding fails
 		response := `{\"error\":\"` + strings.ReplaceAll(message, `"`, `\"`) + `\"}`
@@ -40,6 +54,144 @@ func writeErrorResponse(w http.ResponseWriter, statusCode int, message string, d
 	}
 }
 
+// writeWidgetLookupError writes the response for widget not found/access denied errors
+// and reports whether err was one of them. Both map to 404 to avoid leaking widget
+// existence; admins get an explicit 403 for access denied to ease debugging.
+func writeWidgetLookupError(w http.ResponseWriter, user *models.User, err error) bool {
+	switch {
+	case errors.Is(err, customErrors.ErrNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "Widget not found")
+	case errors.Is(err, customErrors.ErrAccessDenied):
+		if user != nil && user.IsAdmin() {
+			writeErrorResponse(w, http.StatusForbidden, "Access denied: widget belongs to another user")
+		} else {
+			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
+		}
+	case errors.Is(err, customErrors.ErrWidgetArchived):
+		writeErrorResponse(w, http.StatusConflict, "Widget is archived")
+	default:
+		return false
+	}
+	return true
+}
+
+// writeLimitError writes 422 for request bodies exceeding the JSON complexity limits
+func writeLimitError(w http.ResponseWriter, err error) bool {
+	var limitErr *validation.LimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	writeErrorResponse(w, http.StatusUnprocessableEntity, limitErr.Error())
+	return true
+}
+
+// writeQuotaExceeded writes 429 with Retry-After for an exhausted export quota
+func writeQuotaExceeded(w http.ResponseWriter, err error) {
+	var quotaErr *models.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(quotaErr.RetryAfter.Seconds()))))
+	}
+	writeErrorResponse(w, http.StatusTooManyRequests, "Daily export limit reached")
+}
+
+// writeExportBusy writes 429 with Retry-After when all export slots are taken
+func writeExportBusy(w http.ResponseWriter, err error) {
+	var busyErr *models.ExportBusyError
+	if errors.As(err, &busyErr) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(busyErr.RetryAfter.Seconds()))))
+	}
+	writeErrorResponse(w, http.StatusTooManyRequests, "Too many exports in progress, try again later")
+}
+
 func writeValidationErrors(w http.ResponseWriter, errors []*models.FieldError) {
 	writeErrorResponse(w, http.StatusBadRequest, "Validation failed", errors)
 }
+
+// attachmentDisposition builds a Content-Disposition header for a download. Non-ASCII
+// filenames get an ASCII fallback in filename plus the exact name in filename* (RFC 5987).
+func attachmentDisposition(filename string) string {
+	var fallback strings.Builder
+	ascii := true
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\':
+			fallback.WriteRune('_')
+		case r < 0x20 || r > 0x7e:
+			ascii = false
+			fallback.WriteRune('_')
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+
+	header := fmt.Sprintf(`attachment; filename="%s"`, fallback.String())
+	if !ascii {
+		header += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return header
+}
+
+// encodeRFC5987 percent-encodes everything except RFC 5987 attr-char
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0f])
+	}
+	return b.String()
+}
+
+// paginationLinks builds first/prev/next/last page URLs for a list response,
+// keeping all other query parameters of the request
+func paginationLinks(r *http.Request, page, perPage, total int) *models.PaginationLinks {
+	if perPage <= 0 {
+		return nil
+	}
+
+	// RequestURI keeps the public path, r.URL.Path is rewritten by the router
+	requestURL, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		requestURL = r.URL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+
+	pageURL := func(n int) string {
+		query := requestURL.Query()
+		query.Del("limit") // Alias of per_page
+		query.Set("page", strconv.Itoa(n))
+		query.Set("per_page", strconv.Itoa(perPage))
+		return (&url.URL{Scheme: scheme, Host: r.Host, Path: requestURL.Path, RawQuery: query.Encode()}).String()
+	}
+
+	lastPage := (total + perPage - 1) / perPage
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	links := &models.PaginationLinks{
+		First: pageURL(1),
+		Last:  pageURL(lastPage),
+	}
+	if page > 1 {
+		links.Prev = pageURL(min(page-1, lastPage))
+	}
+	if page < lastPage {
+		links.Next = pageURL(page + 1)
+	}
+	return links
+}
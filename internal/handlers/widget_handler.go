@@ -3,6 +3,8 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
@@ -16,18 +18,25 @@ import (
 	"github.com/ad/leads-core/pkg/logger"
 )
 
+// widgetTypeDeniedMessage is returned with 403 for widget types the user's plan can't use
+const widgetTypeDeniedMessage = "Widget type is not available for your plan"
+
 // WidgetHandler handles widget-related HTTP requests
 type WidgetHandler struct {
 	widgetService *services.WidgetService
 	exportService *services.ExportService
-	validator     *validation.SchemaValidator
+	// exportJobService is optional; export job endpoints respond 404 without it
+	exportJobService *services.ExportJobService
+	importService    *services.ImportService
+	validator        *validation.SchemaValidator
 }
 
 // NewWidgetHandler creates a new widget handler
-func NewWidgetHandler(widgetService *services.WidgetService, exportService *services.ExportService, validator *validation.SchemaValidator) *WidgetHandler {
+func NewWidgetHandler(widgetService *services.WidgetService, exportService *services.ExportService, importService *services.ImportService, validator *validation.SchemaValidator) *WidgetHandler {
 	return &WidgetHandler{
 		widgetService: widgetService,
 		exportService: exportService,
+		importService: importService,
 		validator:     validator,
 	}
 }
@@ -49,6 +58,9 @@ func (h *WidgetHandler) CreateWidget(w http.ResponseWriter, r *http.Request) {
 	// Parse and validate request
 	var req models.CreateWidgetRequest
 	if err := h.validator.ValidateAndDecode(r, "widget-create", &req); err != nil {
+		if writeLimitError(w, err) {
+			return
+		}
 		if valErr, ok := err.(*validation.ValidationError); ok {
 			writeValidationErrors(w, valErr.Errors)
 			return
@@ -57,7 +69,14 @@ func (h *WidgetHandler) CreateWidget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check that the widget type is available for the user's plan
+	if !h.widgetService.IsWidgetTypeAllowed(user.Plan, req.Type) {
+		writeErrorResponse(w, http.StatusForbidden, widgetTypeDeniedMessage)
+		return
+	}
+
 	// Create widget
+	req.Plan = user.Plan
 	widget, err := h.widgetService.CreateWidget(r.Context(), user.ID, req)
 	if err != nil {
 		logger.Error("Failed to create widget", map[string]interface{}{
@@ -65,7 +84,17 @@ func (h *WidgetHandler) CreateWidget(w http.ResponseWriter, r *http.Request) {
 			"user_id": user.ID,
 			"error":   err.Error(),
 		})
-		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		var fieldErrs models.FieldErrors
+		switch {
+		case errors.As(err, &fieldErrs):
+			writeValidationErrors(w, fieldErrs)
+		case errors.Is(err, customErrors.ErrLimitReached):
+			writeErrorResponse(w, http.StatusForbidden, "Widget limit reached")
+		case errors.Is(err, customErrors.ErrBusy):
+			writeErrorResponse(w, http.StatusConflict, "Another widget is being created, try again")
+		default:
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		}
 		return
 	}
 
@@ -92,9 +121,18 @@ func (h *WidgetHandler) GetWidgets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// ?ids= fetches specific widgets instead of a page
+	if r.URL.Query().Has("ids") {
+		h.getWidgetsByIDs(w, r, user)
+		return
+	}
+
 	// Parse pagination and filter parameters
 	opts := parsePaginationWithFilters(r)
 
+	// Drop type filters the user's plan has no access to
+	opts.Filters = models.ValidateFilterOptionsWithTypes(opts.Filters, h.widgetService.AllowedWidgetTypes(user.Plan))
+
 	// Get widgets with filtering support and type statistics
 	widgets, total, typeStats, err := h.widgetService.GetUserWidgetsWithStats(r.Context(), user.ID, opts)
 	if err != nil {
@@ -113,6 +151,7 @@ func (h *WidgetHandler) GetWidgets(w http.ResponseWriter, r *http.Request) {
 		PerPage:   opts.PerPage,
 		Total:     total, // This now reflects the filtered total count
 		HasMore:   len(widgets) == opts.PerPage,
+		Links:     paginationLinks(r, opts.Page, opts.PerPage, total),
 		TypeStats: typeStats, // Always include type statistics
 	}
 
@@ -126,6 +165,44 @@ func (h *WidgetHandler) GetWidgets(w http.ResponseWriter, r *http.Request) {
 	writeJSONResponse(w, http.StatusOK, models.WidgetsResponse{Widgets: widgets, Meta: meta})
 }
 
+// getWidgetsByIDs handles GET /widgets?ids=a,b,c, returning the owned widgets among the IDs
+func (h *WidgetHandler) getWidgetsByIDs(w http.ResponseWriter, r *http.Request, user *models.User) {
+	var widgetIDs []string
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			widgetIDs = append(widgetIDs, id)
+		}
+	}
+
+	if len(widgetIDs) == 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "At least one widget ID is required")
+		return
+	}
+	if len(widgetIDs) > models.MaxBatchGetWidgetIDs {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Too many widget IDs, maximum is %d", models.MaxBatchGetWidgetIDs))
+		return
+	}
+
+	widgets, err := h.widgetService.GetWidgetsByIDs(r.Context(), user.ID, widgetIDs)
+	if err != nil {
+		logger.Error("Failed to get widgets by IDs", map[string]interface{}{
+			"action":  "get_widgets_by_ids",
+			"user_id": user.ID,
+			"error":   err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widgets")
+		return
+	}
+
+	logger.Debug("Retrieved widgets by IDs successfully", map[string]interface{}{
+		"action":    "get_widgets_by_ids",
+		"user_id":   user.ID,
+		"requested": len(widgetIDs),
+		"count":     len(widgets),
+	})
+	writeJSONResponse(w, http.StatusOK, models.WidgetsResponse{Widgets: widgets})
+}
+
 // GetWidget handles GET /widgets/{id}
 func (h *WidgetHandler) GetWidget(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -156,9 +233,7 @@ func (h *WidgetHandler) GetWidget(w http.ResponseWriter, r *http.Request) {
 			"widget_id": widgetID,
 			"error":     err.Error(),
 		})
-		if errors.Is(err, customErrors.ErrNotFound) || errors.Is(err, customErrors.ErrAccessDenied) {
-			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
-		} else {
+		if !writeWidgetLookupError(w, user, err) {
 			writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widget")
 		}
 		return
@@ -196,6 +271,9 @@ func (h *WidgetHandler) UpdateWidget(w http.ResponseWriter, r *http.Request) {
 	// Parse and validate request
 	var req models.UpdateWidgetRequest
 	if err := h.validator.ValidateAndDecode(r, "widget-update", &req); err != nil {
+		if writeLimitError(w, err) {
+			return
+		}
 		if valErr, ok := err.(*validation.ValidationError); ok {
 			writeValidationErrors(w, valErr.Errors)
 			return
@@ -204,6 +282,12 @@ func (h *WidgetHandler) UpdateWidget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A type change must not move the widget to a type the plan can't create
+	if req.Type != nil && !h.widgetService.IsWidgetTypeAllowed(user.Plan, *req.Type) {
+		writeErrorResponse(w, http.StatusForbidden, widgetTypeDeniedMessage)
+		return
+	}
+
 	// Update widget
 	widget, err := h.widgetService.UpdateWidget(r.Context(), widgetID, user.ID, req)
 	if err != nil {
@@ -213,9 +297,16 @@ func (h *WidgetHandler) UpdateWidget(w http.ResponseWriter, r *http.Request) {
 			"widget_id": widgetID,
 			"error":     err.Error(),
 		})
-		if errors.Is(err, customErrors.ErrNotFound) || errors.Is(err, customErrors.ErrAccessDenied) {
-			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
-		} else {
+		var fieldErrs models.FieldErrors
+		if errors.As(err, &fieldErrs) {
+			writeValidationErrors(w, fieldErrs)
+			return
+		}
+		if errors.Is(err, customErrors.ErrBusy) {
+			writeErrorResponse(w, http.StatusConflict, "Another widget is being changed, try again")
+			return
+		}
+		if !writeWidgetLookupError(w, user, err) {
 			writeErrorResponse(w, http.StatusBadRequest, err.Error())
 		}
 		return
@@ -253,6 +344,9 @@ func (h *WidgetHandler) UpdateWidgetConfig(w http.ResponseWriter, r *http.Reques
 	// Parse and validate request
 	var req models.UpdateWidgetConfigRequest
 	if err := h.validator.ValidateAndDecode(r, "widget-config-update", &req); err != nil {
+		if writeLimitError(w, err) {
+			return
+		}
 		if valErr, ok := err.(*validation.ValidationError); ok {
 			writeValidationErrors(w, valErr.Errors)
 			return
@@ -278,9 +372,7 @@ func (h *WidgetHandler) UpdateWidgetConfig(w http.ResponseWriter, r *http.Reques
 			"widget_id": widgetID,
 			"error":     err.Error(),
 		})
-		if errors.Is(err, customErrors.ErrNotFound) || errors.Is(err, customErrors.ErrAccessDenied) {
-			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
-		} else {
+		if !writeWidgetLookupError(w, user, err) {
 			writeErrorResponse(w, http.StatusInternalServerError, "Failed to update widget config")
 		}
 		return
@@ -294,6 +386,95 @@ func (h *WidgetHandler) UpdateWidgetConfig(w http.ResponseWriter, r *http.Reques
 	writeJSONResponse(w, http.StatusOK, widget)
 }
 
+// GetWidgetConfigHistory handles GET /widgets/{id}/config/history
+func (h *WidgetHandler) GetWidgetConfigHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	widgetID := extractWidgetID(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	history, err := h.widgetService.GetConfigHistory(r.Context(), widgetID, user.ID)
+	if err != nil {
+		logger.Error("Failed to get widget config history", map[string]interface{}{
+			"action":    "get_widget_config_history",
+			"user_id":   user.ID,
+			"widget_id": widgetID,
+			"error":     err.Error(),
+		})
+		if !writeWidgetLookupError(w, user, err) {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widget config history")
+		}
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: history})
+}
+
+// RestoreWidgetConfig handles POST /widgets/{id}/config/restore/{version}
+func (h *WidgetHandler) RestoreWidgetConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	widgetID, versionStr := extractWidgetConfigVersion(r.URL.Path)
+	if widgetID == "" || versionStr == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID and config version are required")
+		return
+	}
+	version, err := strconv.Atoi(versionStr)
+	if err != nil || version < 1 {
+		writeErrorResponse(w, http.StatusBadRequest, "Config version must be a positive integer")
+		return
+	}
+
+	widget, err := h.widgetService.RestoreWidgetConfig(r.Context(), widgetID, user.ID, version)
+	if err != nil {
+		if writeWidgetLookupError(w, user, err) {
+			return
+		}
+		if errors.Is(err, customErrors.ErrConfigVersionNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, "Config version not found")
+			return
+		}
+		logger.Error("Failed to restore widget config", map[string]interface{}{
+			"action":    "restore_widget_config",
+			"user_id":   user.ID,
+			"widget_id": widgetID,
+			"version":   version,
+			"error":     err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to restore widget config")
+		return
+	}
+
+	logger.Info("Restored widget config", map[string]interface{}{
+		"action":    "restore_widget_config",
+		"user_id":   user.ID,
+		"widget_id": widgetID,
+		"version":   version,
+	})
+	writeJSONResponse(w, http.StatusOK, widget)
+}
+
 // DeleteWidget handles DELETE /widgets/{id}
 func (h *WidgetHandler) DeleteWidget(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
@@ -323,9 +504,7 @@ func (h *WidgetHandler) DeleteWidget(w http.ResponseWriter, r *http.Request) {
 			"widget_id": widgetID,
 			"error":     err.Error(),
 		})
-		if errors.Is(err, customErrors.ErrNotFound) || errors.Is(err, customErrors.ErrAccessDenied) {
-			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
-		} else {
+		if !writeWidgetLookupError(w, user, err) {
 			writeErrorResponse(w, http.StatusInternalServerError, "Failed to delete widget")
 		}
 		return
@@ -339,6 +518,47 @@ func (h *WidgetHandler) DeleteWidget(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// ArchiveWidget handles POST /widgets/{id}/archive, which archives the widget, and
+// DELETE /widgets/{id}/archive, which unarchives it
+func (h *WidgetHandler) ArchiveWidget(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	// Extract widget ID from URL
+	widgetID := extractWidgetID(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	archived := r.Method == http.MethodPost
+	widget, err := h.widgetService.SetWidgetArchived(r.Context(), widgetID, user.ID, archived)
+	if err != nil {
+		logger.Error("Failed to update widget archival", map[string]interface{}{
+			"action":    "archive_widget",
+			"user_id":   user.ID,
+			"widget_id": widgetID,
+			"archived":  archived,
+			"error":     err.Error(),
+		})
+		if !writeWidgetLookupError(w, user, err) {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to update widget archival")
+		}
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, widget)
+}
+
 // GetWidgetStats handles GET /widgets/{id}/stats
 func (h *WidgetHandler) GetWidgetStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -360,8 +580,8 @@ func (h *WidgetHandler) GetWidgetStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get stats
-	stats, err := h.widgetService.GetWidgetStats(r.Context(), widgetID, user.ID)
+	// Get stats, with an optional preview of the latest submission, e.g. ?preview=name,city
+	stats, err := h.widgetService.GetWidgetStats(r.Context(), widgetID, user.ID, parseFieldsParam(r, "preview"))
 	if err != nil {
 		logger.Error("Failed to get widget stats", map[string]interface{}{
 			"action":    "get_widget_stats",
@@ -369,9 +589,7 @@ func (h *WidgetHandler) GetWidgetStats(w http.ResponseWriter, r *http.Request) {
 			"widget_id": widgetID,
 			"error":     err.Error(),
 		})
-		if errors.Is(err, customErrors.ErrNotFound) || errors.Is(err, customErrors.ErrAccessDenied) {
-			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
-		} else {
+		if !writeWidgetLookupError(w, user, err) {
 			writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widget stats")
 		}
 		return
@@ -382,11 +600,14 @@ func (h *WidgetHandler) GetWidgetStats(w http.ResponseWriter, r *http.Request) {
 		"user_id":   user.ID,
 		"widget_id": widgetID,
 	})
+	if stats.CacheStatus != "" {
+		w.Header().Set("X-Cache", stats.CacheStatus)
+	}
 	writeJSONResponse(w, http.StatusOK, stats)
 }
 
-// GetWidgetSubmissions handles GET /widgets/{id}/submissions
-func (h *WidgetHandler) GetWidgetSubmissions(w http.ResponseWriter, r *http.Request) {
+// GetSubmissionHeatmap handles GET /widgets/{id}/stats/heatmap
+func (h *WidgetHandler) GetSubmissionHeatmap(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
@@ -406,45 +627,41 @@ func (h *WidgetHandler) GetWidgetSubmissions(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Parse pagination parameters
-	opts := parsePaginationOptions(r)
+	from, to, ok := parseTimeRange(w, r)
+	if !ok {
+		return
+	}
+
+	// Buckets follow the caller's local time, UTC by default
+	loc := time.UTC
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		parsedLoc, err := time.LoadLocation(tz)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid 'tz'. Use an IANA time zone name (e.g., Europe/Berlin)")
+			return
+		}
+		loc = parsedLoc
+	}
 
-	// Get submissions
-	submissions, total, err := h.widgetService.GetWidgetSubmissions(r.Context(), widgetID, user.ID, opts)
+	heatmap, err := h.widgetService.GetSubmissionHeatmap(r.Context(), widgetID, user.ID, from, to, loc)
 	if err != nil {
-		logger.Error("Failed to get widget submissions", map[string]interface{}{
-			"action":    "get_widget_submissions",
+		logger.Error("Failed to get submission heatmap", map[string]interface{}{
+			"action":    "get_submission_heatmap",
 			"user_id":   user.ID,
 			"widget_id": widgetID,
 			"error":     err.Error(),
 		})
-		if errors.Is(err, customErrors.ErrNotFound) || errors.Is(err, customErrors.ErrAccessDenied) {
-			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
-		} else {
-			writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widget submissions")
+		if !writeWidgetLookupError(w, user, err) {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to get submission heatmap")
 		}
 		return
 	}
 
-	// Calculate pagination metadata
-	meta := &models.Meta{
-		Page:    opts.Page,
-		PerPage: opts.PerPage,
-		Total:   total,
-		HasMore: len(submissions) == opts.PerPage,
-	}
-
-	logger.Debug("Retrieved widget submissions successfully", map[string]interface{}{
-		"action":    "get_widget_submissions",
-		"user_id":   user.ID,
-		"widget_id": widgetID,
-		"count":     len(submissions),
-	})
-	writeJSONResponse(w, http.StatusOK, models.Response{Data: submissions, Meta: meta})
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: heatmap})
 }
 
-// ExportWidgetSubmissions handles GET /widgets/{id}/export
-func (h *WidgetHandler) ExportWidgetSubmissions(w http.ResponseWriter, r *http.Request) {
+// GetWidgetStatsSeries handles GET /widgets/{id}/stats/timeseries?from=&to=&metric=
+func (h *WidgetHandler) GetWidgetStatsSeries(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
@@ -464,99 +681,58 @@ func (h *WidgetHandler) ExportWidgetSubmissions(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Parse query parameters
-	format := r.URL.Query().Get("format")
-	if format == "" {
-		format = "json" // Default format
+	metric := r.URL.Query().Get("metric")
+	if metric == "" {
+		metric = models.StatsMetricViews
 	}
-
-	// Validate format
-	if format != "csv" && format != "json" && format != "xlsx" {
-		writeErrorResponse(w, http.StatusBadRequest, "Invalid format. Supported formats: csv, json, xlsx")
+	if !models.IsValidStatsMetric(metric) {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid 'metric'. Supported metrics: views, submits, closes")
 		return
 	}
 
-	// Parse time range parameters
-	var from, to *time.Time
-	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
-		if parsedFrom, err := time.Parse(time.RFC3339, fromStr); err == nil {
-			from = &parsedFrom
-		} else {
-			writeErrorResponse(w, http.StatusBadRequest, "Invalid 'from' date format. Use RFC3339 format (e.g., 2023-01-01T00:00:00Z)")
-			return
-		}
+	from, to, ok := parseTimeRange(w, r)
+	if !ok {
+		return
 	}
 
-	if toStr := r.URL.Query().Get("to"); toStr != "" {
-		if parsedTo, err := time.Parse(time.RFC3339, toStr); err == nil {
-			to = &parsedTo
-		} else {
-			writeErrorResponse(w, http.StatusBadRequest, "Invalid 'to' date format. Use RFC3339 format (e.g., 2023-12-31T23:59:59Z)")
-			return
-		}
+	// Defaults to the days daily counters are kept for, up to now
+	end := time.Now()
+	if to != nil {
+		end = *to
 	}
-
-	// Create export options
-	options := models.ExportOptions{
-		Format: format,
-		From:   from,
-		To:     to,
+	start := end.AddDate(0, 0, -(h.widgetService.DailyStatsDays() - 1))
+	if from != nil {
+		start = *from
+	}
+	if start.After(end) {
+		writeErrorResponse(w, http.StatusBadRequest, "'from' must not be after 'to'")
+		return
+	}
+	if end.Sub(start) >= services.MaxStatsSeriesDays*24*time.Hour {
+		writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Time range must not exceed %d days", services.MaxStatsSeriesDays))
+		return
 	}
 
-	// Export submissions using export service
-	data, filename, err := h.exportService.ExportSubmissions(r.Context(), widgetID, user.ID, options)
+	series, err := h.widgetService.GetWidgetStatsSeries(r.Context(), widgetID, user.ID, metric, start, end)
 	if err != nil {
-		logger.Error("Failed to export widget submissions", map[string]interface{}{
-			"action":    "export_widget_submissions",
-			"widget_id": widgetID,
+		logger.Error("Failed to get widget stats series", map[string]interface{}{
+			"action":    "get_widget_stats_series",
 			"user_id":   user.ID,
-			"format":    format,
+			"widget_id": widgetID,
+			"metric":    metric,
 			"error":     err.Error(),
 		})
-
-		if err.Error() == "unauthorized" {
-			writeErrorResponse(w, http.StatusForbidden, "Access denied")
-			return
+		if !writeWidgetLookupError(w, user, err) {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widget stats series")
 		}
-
-		if err.Error() == "widget not found" {
-			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
-			return
-		}
-
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to export submissions")
 		return
 	}
 
-	// Set appropriate headers based on format
-	var contentType string
-	switch format {
-	case "csv":
-		contentType = "text/csv"
-	case "json":
-		contentType = "application/json"
-	case "xlsx":
-		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
-	}
-
-	w.Header().Set("Content-Type", contentType)
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
-	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
-
-	logger.Info("Widget submissions exported successfully", map[string]interface{}{
-		"action":    "export_widget_submissions",
-		"widget_id": widgetID,
-		"user_id":   user.ID,
-		"format":    format,
-		"filename":  filename,
-		"size":      len(data),
-	})
-
-	w.Write(data)
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: series})
 }
 
-// GetWidgetsSummary handles GET /widgets/summary
-func (h *WidgetHandler) GetWidgetsSummary(w http.ResponseWriter, r *http.Request) {
+// GetFieldDistribution handles GET /widgets/{id}/fields/{field}/distribution
+func (h *WidgetHandler) GetFieldDistribution(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
@@ -569,28 +745,841 @@ func (h *WidgetHandler) GetWidgetsSummary(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Get widgets summary
-	summary, err := h.widgetService.GetWidgetsSummary(r.Context(), user.ID)
+	widgetID, field := extractWidgetFieldName(r.URL.Path)
+	if widgetID == "" || field == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID and field are required")
+		return
+	}
+
+	from, to, ok := parseTimeRange(w, r)
+	if !ok {
+		return
+	}
+
+	distribution, err := h.widgetService.GetFieldDistribution(r.Context(), widgetID, user.ID, field, from, to)
 	if err != nil {
-		logger.Error("Failed to get widgets summary", map[string]interface{}{
-			"action":  "get_widgets_summary",
-			"user_id": user.ID,
-			"error":   err.Error(),
+		logger.Error("Failed to get field distribution", map[string]interface{}{
+			"action":    "get_field_distribution",
+			"user_id":   user.ID,
+			"widget_id": widgetID,
+			"field":     field,
+			"error":     err.Error(),
 		})
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widgets summary")
+		if !writeWidgetLookupError(w, user, err) {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to get field distribution")
+		}
 		return
 	}
 
-	logger.Debug("Retrieved widgets summary successfully", map[string]interface{}{
-		"action":  "get_widgets_summary",
-		"user_id": user.ID,
-	})
-	writeJSONResponse(w, http.StatusOK, models.Response{Data: summary})
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: distribution})
 }
 
-// parseFilterOptions parses filter parameters from request
-func parseFilterOptions(r *http.Request) *models.FilterOptions {
-	filters := &models.FilterOptions{}
+// CompareWidgets handles GET /widgets/compare?a={id}&b={id}, optionally over ?from=&to=
+func (h *WidgetHandler) CompareWidgets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	query := r.URL.Query()
+	widgetA := strings.TrimSpace(query.Get("a"))
+	widgetB := strings.TrimSpace(query.Get("b"))
+	if widgetA == "" || widgetB == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Both widget IDs 'a' and 'b' are required")
+		return
+	}
+	if widgetA == widgetB {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget IDs 'a' and 'b' must differ")
+		return
+	}
+
+	from, to, ok := parseTimeRange(w, r)
+	if !ok {
+		return
+	}
+
+	comparison, err := h.widgetService.CompareWidgets(r.Context(), user.ID, widgetA, widgetB, from, to)
+	if err != nil {
+		logger.Error("Failed to compare widgets", map[string]interface{}{
+			"action":   "compare_widgets",
+			"user_id":  user.ID,
+			"widget_a": widgetA,
+			"widget_b": widgetB,
+			"error":    err.Error(),
+		})
+		if !writeWidgetLookupError(w, user, err) {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to compare widgets")
+		}
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: comparison})
+}
+
+// parseTimeRange parses the optional RFC3339 ?from= and ?to= parameters, writing
+// 400 and returning false when either is malformed
+func parseTimeRange(w http.ResponseWriter, r *http.Request) (*time.Time, *time.Time, bool) {
+	query := r.URL.Query()
+	var from, to *time.Time
+	if fromStr := query.Get("from"); fromStr != "" {
+		parsedFrom, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid 'from' date format. Use RFC3339 format (e.g., 2023-01-01T00:00:00Z)")
+			return nil, nil, false
+		}
+		from = &parsedFrom
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		parsedTo, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid 'to' date format. Use RFC3339 format (e.g., 2023-12-31T23:59:59Z)")
+			return nil, nil, false
+		}
+		to = &parsedTo
+	}
+	return from, to, true
+}
+
+// GetWidgetSubmissions handles GET /widgets/{id}/submissions
+func (h *WidgetHandler) GetWidgetSubmissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	// Extract widget ID from URL
+	widgetID := extractWidgetID(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	// Parse pagination parameters
+	opts := parsePaginationOptions(r)
+	region := strings.TrimSpace(r.URL.Query().Get("region"))
+	search := strings.TrimSpace(r.URL.Query().Get("search"))
+	if region != "" && search != "" {
+		writeErrorResponse(w, http.StatusBadRequest, "The region filter and search can't be combined")
+		return
+	}
+
+	// ?cursor= (empty for the first page) switches to cursor pagination, which stays
+	// consistent while submissions arrive; region filtering and search are only paged
+	// by offset
+	var cursor *models.SubmissionCursor
+	useCursor := r.URL.Query().Has("cursor")
+	if useCursor {
+		if region != "" {
+			writeErrorResponse(w, http.StatusBadRequest, "Cursor pagination does not support the region filter")
+			return
+		}
+		if search != "" {
+			writeErrorResponse(w, http.StatusBadRequest, "Cursor pagination does not support search")
+			return
+		}
+		if value := r.URL.Query().Get("cursor"); value != "" {
+			parsed, err := models.ParseSubmissionCursor(value)
+			if err != nil {
+				writeErrorResponse(w, http.StatusBadRequest, "Invalid cursor, use the next_cursor of a previous response")
+				return
+			}
+			cursor = parsed
+		}
+	}
+
+	// Get submissions, optionally filtered by region or search text
+	var submissions []*models.Submission
+	var total int
+	var next *models.SubmissionCursor
+	var err error
+	if useCursor {
+		submissions, total, next, err = h.widgetService.GetWidgetSubmissionsAfter(r.Context(), widgetID, user.ID, cursor, opts.PerPage)
+	} else if search != "" {
+		submissions, total, err = h.widgetService.SearchWidgetSubmissions(r.Context(), widgetID, user.ID, models.SubmissionFilter{Search: search}, opts)
+	} else if region != "" {
+		submissions, total, err = h.widgetService.GetWidgetSubmissionsInRegion(r.Context(), widgetID, user.ID, region, opts)
+	} else {
+		submissions, total, err = h.widgetService.GetWidgetSubmissions(r.Context(), widgetID, user.ID, opts)
+	}
+	if err != nil {
+		logger.Error("Failed to get widget submissions", map[string]interface{}{
+			"action":    "get_widget_submissions",
+			"user_id":   user.ID,
+			"widget_id": widgetID,
+			"error":     err.Error(),
+		})
+		if !writeWidgetLookupError(w, user, err) {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widget submissions")
+		}
+		return
+	}
+
+	// Calculate pagination metadata; cursor pages have no page number or links
+	meta := &models.Meta{
+		Page:    opts.Page,
+		PerPage: opts.PerPage,
+		Total:   total,
+		HasMore: len(submissions) == opts.PerPage,
+		Links:   paginationLinks(r, opts.Page, opts.PerPage, total),
+	}
+	if useCursor {
+		meta = &models.Meta{PerPage: opts.PerPage, Total: total, HasMore: next != nil}
+		if next != nil {
+			meta.NextCursor = next.String()
+		}
+	}
+
+	logger.Debug("Retrieved widget submissions successfully", map[string]interface{}{
+		"action":    "get_widget_submissions",
+		"user_id":   user.ID,
+		"widget_id": widgetID,
+		"count":     len(submissions),
+	})
+
+	// Optional projection, e.g. ?fields=name,email; Redis keeps the whole data
+	// blob, so it only trims the response
+	if fields := parseFieldsParam(r, "fields"); len(fields) > 0 {
+		projected := make([]*models.ProjectedSubmission, 0, len(submissions))
+		for _, submission := range submissions {
+			projected = append(projected, submission.Project(fields))
+		}
+		writeJSONResponse(w, http.StatusOK, models.Response{Data: projected, Meta: meta})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: submissions, Meta: meta})
+}
+
+// GetSubmissionByCorrelation handles GET /widgets/{id}/submissions/by-correlation?key=...,
+// returning the latest submission whose correlation field holds the key
+func (h *WidgetHandler) GetSubmissionByCorrelation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	widgetID := extractWidgetID(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	key := strings.TrimSpace(r.URL.Query().Get("key"))
+	if key == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Correlation key is required")
+		return
+	}
+
+	submission, err := h.widgetService.GetSubmissionByCorrelation(r.Context(), widgetID, user.ID, key)
+	if err != nil {
+		if writeWidgetLookupError(w, user, err) {
+			return
+		}
+		if errors.Is(err, customErrors.ErrSubmissionNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, "Submission not found")
+			return
+		}
+		logger.Error("Failed to get submission by correlation key", map[string]interface{}{
+			"action":    "get_submission_by_correlation",
+			"user_id":   user.ID,
+			"widget_id": widgetID,
+			"error":     err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get submission")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: submission})
+}
+
+// DeleteSubmission handles DELETE /widgets/{id}/submissions/{sid}
+func (h *WidgetHandler) DeleteSubmission(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	widgetID, submissionID := extractWidgetSubmissionID(r.URL.Path)
+	if widgetID == "" || submissionID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID and submission ID are required")
+		return
+	}
+
+	if err := h.widgetService.DeleteSubmission(r.Context(), widgetID, user.ID, submissionID); err != nil {
+		if writeWidgetLookupError(w, user, err) {
+			return
+		}
+		if errors.Is(err, customErrors.ErrSubmissionNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, "Submission not found")
+			return
+		}
+		logger.Error("Failed to delete submission", map[string]interface{}{
+			"action":        "delete_submission",
+			"user_id":       user.ID,
+			"widget_id":     widgetID,
+			"submission_id": submissionID,
+			"error":         err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to delete submission")
+		return
+	}
+
+	logger.Info("Deleted submission", map[string]interface{}{
+		"action":        "delete_submission",
+		"user_id":       user.ID,
+		"widget_id":     widgetID,
+		"submission_id": submissionID,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// BulkDeleteSubmissions handles POST /widgets/{id}/submissions/bulk-delete, deleting the
+// submissions created between the from and to bounds, or all of them with confirm
+func (h *WidgetHandler) BulkDeleteSubmissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	widgetID := extractWidgetID(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	var req models.SubmissionBulkDeleteRequest
+	if err := h.validator.ValidateAndDecode(r, "submission-bulk-delete", &req); err != nil {
+		if valErr, ok := err.(*validation.ValidationError); ok {
+			writeValidationErrors(w, valErr.Errors)
+			return
+		}
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	// Guard against deleting everything by accident
+	if req.From == nil && req.To == nil && !req.Confirm {
+		writeErrorResponse(w, http.StatusBadRequest, "Set from or to, or confirm to delete all submissions")
+		return
+	}
+	var from, to time.Time
+	if req.From != nil {
+		from = *req.From
+	}
+	if req.To != nil {
+		to = *req.To
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		writeErrorResponse(w, http.StatusBadRequest, "from must not be after to")
+		return
+	}
+
+	deleted, err := h.widgetService.DeleteSubmissionsInRange(r.Context(), widgetID, user.ID, from, to)
+	if err != nil {
+		if writeWidgetLookupError(w, user, err) {
+			return
+		}
+		logger.Error("Failed to delete submissions", map[string]interface{}{
+			"action":    "bulk_delete_submissions",
+			"user_id":   user.ID,
+			"widget_id": widgetID,
+			"deleted":   deleted,
+			"error":     err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to delete submissions")
+		return
+	}
+
+	logger.Info("Deleted submissions in bulk", map[string]interface{}{
+		"action":    "bulk_delete_submissions",
+		"user_id":   user.ID,
+		"widget_id": widgetID,
+		"deleted":   deleted,
+	})
+	writeJSONResponse(w, http.StatusOK, models.SubmissionBulkDeleteResult{Deleted: deleted})
+}
+
+// TailSubmissions handles GET /widgets/{id}/submissions/tail?since=<cursor>, holding
+// the request open until submissions newer than the cursor arrive. Without since only
+// submissions arriving from now on are returned.
+func (h *WidgetHandler) TailSubmissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	widgetID := extractWidgetID(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	cursor := time.Now().Unix() - 1
+	if since := strings.TrimSpace(r.URL.Query().Get("since")); since != "" {
+		parsed, err := strconv.ParseInt(since, 10, 64)
+		if err != nil || parsed < 0 {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid 'since' cursor, use the cursor of a previous response")
+			return
+		}
+		cursor = parsed
+	}
+
+	tail, err := h.widgetService.TailSubmissions(r.Context(), widgetID, user.ID, cursor)
+	if err != nil {
+		logger.Error("Failed to tail widget submissions", map[string]interface{}{
+			"action":    "tail_submissions",
+			"user_id":   user.ID,
+			"widget_id": widgetID,
+			"error":     err.Error(),
+		})
+		if !writeWidgetLookupError(w, user, err) {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widget submissions")
+		}
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: tail})
+}
+
+// parseFieldsParam collects data field names from comma-separated or repeated values of
+// the query parameter, e.g. ?fields=
+func parseFieldsParam(r *http.Request, param string) []string {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, value := range r.URL.Query()[param] {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" && !seen[field] {
+				seen[field] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+// GetInferredSchema handles GET /widgets/{id}/schema/inferred
+func (h *WidgetHandler) GetInferredSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	// Extract widget ID from URL
+	widgetID := extractWidgetID(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	// Optional sample size, the service applies default and upper bound
+	sampleSize := 0
+	if sampleStr := r.URL.Query().Get("sample"); sampleStr != "" {
+		if n, err := strconv.Atoi(sampleStr); err == nil && n > 0 {
+			sampleSize = n
+		}
+	}
+
+	schema, err := h.widgetService.InferSubmissionSchema(r.Context(), widgetID, user.ID, sampleSize)
+	if err != nil {
+		logger.Error("Failed to infer submission schema", map[string]interface{}{
+			"action":    "get_inferred_schema",
+			"user_id":   user.ID,
+			"widget_id": widgetID,
+			"error":     err.Error(),
+		})
+		if !writeWidgetLookupError(w, user, err) {
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to infer submission schema")
+		}
+		return
+	}
+
+	logger.Debug("Inferred submission schema successfully", map[string]interface{}{
+		"action":      "get_inferred_schema",
+		"user_id":     user.ID,
+		"widget_id":   widgetID,
+		"sample_size": schema.SampleSize,
+	})
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: schema})
+}
+
+// Headers marking exports cut short by the plan's row cap
+const (
+	ExportTruncatedHeader = "X-Export-Truncated"
+	ExportRowLimitHeader  = "X-Export-Row-Limit"
+)
+
+// ExportWidgetSubmissions handles GET /widgets/{id}/export
+func (h *WidgetHandler) ExportWidgetSubmissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	// Extract widget ID from URL
+	widgetID := extractWidgetID(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	// Parse format, time range and filter parameters
+	options, err := parseExportOptions(r, h.defaultExportFormat(r, user.ID))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := options.Format
+	options.MaxRows = h.exportService.RowLimit(user.Plan)
+
+	// Count the export before running it, so parallel exports can't go over the quota;
+	// exports that fail give it back
+	releaseQuota, err := h.exportService.ReserveDailyExport(r.Context(), user.ID, h.exportService.DailyQuota(user.Plan))
+	if err != nil {
+		writeQuotaExceeded(w, err)
+		return
+	}
+
+	release, err := h.exportService.AcquireSlot(r.Context())
+	if err != nil {
+		releaseQuota()
+		writeExportBusy(w, err)
+		return
+	}
+	defer release()
+
+	// Export submissions using export service
+	result, err := h.exportService.Export(r.Context(), widgetID, user.ID, options)
+	if err != nil {
+		releaseQuota()
+		logger.Error("Failed to export widget submissions", map[string]interface{}{
+			"action":    "export_widget_submissions",
+			"widget_id": widgetID,
+			"user_id":   user.ID,
+			"format":    format,
+			"error":     err.Error(),
+		})
+
+		if err.Error() == "unauthorized" {
+			writeErrorResponse(w, http.StatusForbidden, "Access denied")
+			return
+		}
+
+		if err.Error() == "widget not found" {
+			writeErrorResponse(w, http.StatusNotFound, "Widget not found")
+			return
+		}
+
+		var rangeErr *models.ExportRangeError
+		if errors.As(err, &rangeErr) {
+			writeErrorResponse(w, http.StatusBadRequest, rangeErr.Error())
+			return
+		}
+
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to export submissions")
+		return
+	}
+
+	data, filename := result.Data, result.Filename
+
+	// Set appropriate headers based on format
+	w.Header().Set("Content-Type", models.ExportContentType(format))
+	w.Header().Set("Content-Disposition", attachmentDisposition(filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if result.Truncated {
+		// The plan's row cap left older submissions out
+		w.Header().Set(ExportTruncatedHeader, "true")
+		w.Header().Set(ExportRowLimitHeader, strconv.Itoa(options.MaxRows))
+	}
+
+	logger.Info("Widget submissions exported successfully", map[string]interface{}{
+		"action":    "export_widget_submissions",
+		"widget_id": widgetID,
+		"user_id":   user.ID,
+		"format":    format,
+		"filename":  filename,
+		"size":      len(data),
+	})
+
+	w.Write(data)
+}
+
+// ImportWidgetSubmissions handles POST /widgets/{id}/import
+// The body is NDJSON (one submission per line) and is parsed as a stream, or a multipart
+// upload of a CSV file in the file field. Failed lines are reported with line numbers;
+// with ?strict=true the import stops at the first one. Lines are stored as they are read,
+// so a stopped import still succeeds and reports the lines imported before it stopped.
+func (h *WidgetHandler) ImportWidgetSubmissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	// Extract widget ID from URL
+	widgetID := extractWidgetID(r.URL.Path)
+	if widgetID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Widget ID is required")
+		return
+	}
+
+	strict := r.URL.Query().Get("strict") == "true"
+
+	// Multipart uploads carry a CSV file, other bodies are NDJSON
+	format := "ndjson"
+	var summary *models.ImportSummary
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		format = "csv"
+		file, err := importCSVFile(r)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		summary, err = h.importService.ImportCSV(r.Context(), widgetID, user.ID, file, strict)
+		if err != nil {
+			writeImportError(w, user, err)
+			return
+		}
+	} else {
+		var err error
+		summary, err = h.importService.ImportNDJSON(r.Context(), widgetID, user.ID, r.Body, strict)
+		if err != nil {
+			writeImportError(w, user, err)
+			return
+		}
+	}
+
+	logger.Info("Widget submissions imported", map[string]interface{}{
+		"action":    "import_widget_submissions",
+		"widget_id": widgetID,
+		"user_id":   user.ID,
+		"format":    format,
+		"strict":    strict,
+		"imported":  summary.Imported,
+		"failed":    summary.Failed,
+		"aborted":   summary.Aborted,
+	})
+
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: summary})
+}
+
+// writeImportError writes the response for an import that couldn't start
+func writeImportError(w http.ResponseWriter, user *models.User, err error) {
+	var fileErr *models.ImportFileError
+	if errors.As(err, &fileErr) {
+		writeErrorResponse(w, http.StatusBadRequest, fileErr.Message)
+		return
+	}
+	if !writeWidgetLookupError(w, user, err) {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to import submissions")
+	}
+}
+
+// importCSVFile returns the "file" part of a multipart CSV import upload
+func importCSVFile(r *http.Request) (io.Reader, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid multipart body")
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("CSV file is required in the file field")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid multipart body")
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
+
+// BulkResetStats handles POST /widgets/bulk-stats-reset
+func (h *WidgetHandler) BulkResetStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	// Parse and validate request
+	var req models.BulkStatsResetRequest
+	if err := h.validator.ValidateAndDecode(r, "widget-bulk-stats-reset", &req); err != nil {
+		if valErr, ok := err.(*validation.ValidationError); ok {
+			writeValidationErrors(w, valErr.Errors)
+			return
+		}
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	results := h.widgetService.ResetWidgetsStats(r.Context(), user.ID, req.IDs, req.ResetDaily)
+
+	logger.Debug("Bulk stats reset completed", map[string]interface{}{
+		"action":      "bulk_stats_reset",
+		"user_id":     user.ID,
+		"count":       len(req.IDs),
+		"reset_daily": req.ResetDaily,
+	})
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: results})
+}
+
+// GetWidgetsSummary handles GET /widgets/summary
+func (h *WidgetHandler) GetWidgetsSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	// Get widgets summary
+	summary, err := h.widgetService.GetWidgetsSummary(r.Context(), user.ID)
+	if err != nil {
+		logger.Error("Failed to get widgets summary", map[string]interface{}{
+			"action":  "get_widgets_summary",
+			"user_id": user.ID,
+			"error":   err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widgets summary")
+		return
+	}
+
+	logger.Debug("Retrieved widgets summary successfully", map[string]interface{}{
+		"action":  "get_widgets_summary",
+		"user_id": user.ID,
+	})
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: summary})
+}
+
+// GetWidgetTypeStats handles GET /widgets/type-stats
+func (h *WidgetHandler) GetWidgetTypeStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	stats, err := h.widgetService.GetTypeStats(r.Context(), user.ID)
+	if err != nil {
+		logger.Error("Failed to get widget type stats", map[string]interface{}{
+			"action":  "get_widget_type_stats",
+			"user_id": user.ID,
+			"error":   err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widget type stats")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: stats})
+}
+
+// GetWidgetTypesOverview handles GET /widgets/types/overview
+func (h *WidgetHandler) GetWidgetTypesOverview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get user from context
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	overview, err := h.widgetService.GetWidgetTypesOverview(r.Context(), user.ID)
+	if err != nil {
+		logger.Error("Failed to get widget types overview", map[string]interface{}{
+			"action":  "get_widget_types_overview",
+			"user_id": user.ID,
+			"error":   err.Error(),
+		})
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get widget types overview")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.Response{Data: overview})
+}
+
+// parseFilterOptions parses filter parameters from request
+func parseFilterOptions(r *http.Request) *models.FilterOptions {
+	filters := &models.FilterOptions{}
 
 	// Parse type filter - handle comma-separated values
 	if typeParam := r.URL.Query().Get("type"); typeParam != "" {
@@ -621,6 +1610,23 @@ func parseFilterOptions(r *http.Request) *models.FilterOptions {
 		filters.Search = sanitized
 	}
 
+	// Parse creation date bounds - RFC3339, invalid values are ignored
+	if fromParam := r.URL.Query().Get("created_from"); fromParam != "" {
+		if from, err := time.Parse(time.RFC3339, fromParam); err == nil {
+			filters.CreatedFrom = &from
+		}
+	}
+	if toParam := r.URL.Query().Get("created_to"); toParam != "" {
+		if to, err := time.Parse(time.RFC3339, toParam); err == nil {
+			filters.CreatedTo = &to
+		}
+	}
+
+	// Archived widgets are only listed on request
+	if includeArchived, err := strconv.ParseBool(r.URL.Query().Get("includeArchived")); err == nil {
+		filters.IncludeArchived = includeArchived
+	}
+
 	// Validate and clean the filter options
 	return models.ValidateFilterOptions(filters)
 }
@@ -649,16 +1655,163 @@ func parsePaginationOptions(r *http.Request) models.PaginationOptions {
 		}
 	}
 
-	return models.PaginationOptions{
+	opts := models.PaginationOptions{
 		Page:    page,
 		PerPage: perPage,
 	}
+
+	// Unknown sort keys and directions fall back to the default order
+	if sortBy, ok := models.ParseSortBy(r.URL.Query().Get("sort_by")); ok {
+		opts.SortBy = sortBy
+	}
+	if sortOrder := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("sort_order"))); models.IsValidSortOrder(sortOrder) {
+		opts.SortOrder = sortOrder
+	}
+
+	return opts
+}
+
+// parseExportOptions parses export format, time range and filter parameters from request.
+// The format comes from ?format=, then the Accept header, then defaultFormat.
+func parseExportOptions(r *http.Request, defaultFormat string) (models.ExportOptions, error) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatFromAccept(r.Header.Get("Accept"))
+	}
+	if format == "" {
+		format = defaultFormat
+	}
+
+	// Validate format
+	if !models.IsValidExportFormat(format) {
+		return models.ExportOptions{}, fmt.Errorf("Invalid format. Supported formats: %s", strings.Join(models.ExportFormats, ", "))
+	}
+
+	// Parse time range parameters
+	var from, to *time.Time
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		parsedFrom, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return models.ExportOptions{}, fmt.Errorf("Invalid 'from' date format. Use RFC3339 format (e.g., 2023-01-01T00:00:00Z)")
+		}
+		from = &parsedFrom
+	}
+
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		parsedTo, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return models.ExportOptions{}, fmt.Errorf("Invalid 'to' date format. Use RFC3339 format (e.g., 2023-12-31T23:59:59Z)")
+		}
+		to = &parsedTo
+	}
+
+	// Parse field=key:value and search filters
+	fields, err := models.ParseFieldFilters(r.URL.Query()["field"])
+	if err != nil {
+		return models.ExportOptions{}, err
+	}
+
+	meta, err := models.ParseFieldFilters(r.URL.Query()["meta"])
+	if err != nil {
+		return models.ExportOptions{}, err
+	}
+
+	headers, err := models.ParseExportHeaders(r.URL.Query()["header"])
+	if err != nil {
+		return models.ExportOptions{}, err
+	}
+
+	var includeMeta bool
+	if value := r.URL.Query().Get("include_meta"); value != "" {
+		if includeMeta, err = strconv.ParseBool(value); err != nil {
+			return models.ExportOptions{}, fmt.Errorf("Invalid 'include_meta' value, use true or false")
+		}
+	}
+
+	// Indexed array columns need at least one expanded level
+	arrayMode := r.URL.Query().Get("arrayMode")
+	if arrayMode != "" && !models.IsValidExportArrayMode(arrayMode) {
+		return models.ExportOptions{}, fmt.Errorf("Invalid 'arrayMode' value. Supported modes: json, index, join")
+	}
+	maxDepth := 0
+	if arrayMode == models.ExportArrayIndex {
+		maxDepth = 1
+	}
+	if value := r.URL.Query().Get("maxDepth"); value != "" {
+		if maxDepth, err = strconv.Atoi(value); err != nil || maxDepth < 0 || maxDepth > models.MaxExportDepth {
+			return models.ExportOptions{}, fmt.Errorf("Invalid 'maxDepth' value, use 0 to %d", models.MaxExportDepth)
+		}
+	}
+
+	return models.ExportOptions{
+		Format: format,
+		From:   from,
+		To:     to,
+		Region: strings.TrimSpace(r.URL.Query().Get("region")),
+		Filter: models.SubmissionFilter{
+			Fields: fields,
+			Meta:   meta,
+			Search: strings.TrimSpace(r.URL.Query().Get("search")),
+		},
+		Headers:     headers,
+		IncludeMeta: includeMeta,
+		Fields:      parseFieldsParam(r, "fields"),
+		ArrayMode:   arrayMode,
+		MaxDepth:    maxDepth,
+	}, nil
+}
+
+// exportFormatFromAccept returns the first export format named by an Accept header,
+// or "" when it names none (wildcards don't count as a choice)
+func exportFormatFromAccept(accept string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(mediaRange, ";")
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "text/csv":
+			return "csv"
+		case "application/json":
+			return "json"
+		case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+			return "xlsx"
+		case "text/tab-separated-values":
+			return "tsv"
+		case "application/x-ndjson":
+			return "ndjson"
+		}
+	}
+	return ""
+}
+
+// defaultExportFormat returns the user's preferred export format, or json
+func (h *WidgetHandler) defaultExportFormat(r *http.Request, userID string) string {
+	preferences, err := h.widgetService.GetUserPreferences(r.Context(), userID)
+	if err != nil {
+		logger.Warn("Failed to get user preferences, using default export format", map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return "json"
+	}
+	if preferences.ExportFormat == "" {
+		return "json"
+	}
+	return preferences.ExportFormat
 }
 
 // parsePaginationWithFilters parses both pagination and filter parameters from request
 func parsePaginationWithFilters(r *http.Request) models.PaginationOptions {
 	opts := parsePaginationOptions(r)
 	opts.Filters = parseFilterOptions(r)
+
+	// ?sort=<key>[:<order>] is short for sort_by and sort_order, which take precedence
+	if opts.SortBy == "" {
+		if sortBy, order, ok := models.ParseWidgetSort(r.URL.Query().Get("sort")); ok {
+			opts.SortBy = sortBy
+			if opts.SortOrder == "" {
+				opts.SortOrder = order
+			}
+		}
+	}
 	return opts
 }
 
@@ -674,6 +1827,33 @@ func extractWidgetID(path string) string {
 	return ""
 }
 
+// extractWidgetFieldName extracts widget ID and field name from /widgets/{id}/fields/{field}/...
+func extractWidgetFieldName(path string) (string, string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) >= 4 && parts[0] == "widgets" && parts[2] == "fields" {
+		return parts[1], parts[3]
+	}
+	return "", ""
+}
+
+// extractWidgetSubmissionID extracts widget ID and submission ID from /widgets/{id}/submissions/{sid}
+func extractWidgetSubmissionID(path string) (string, string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 4 && parts[0] == "widgets" && parts[2] == "submissions" {
+		return parts[1], parts[3]
+	}
+	return "", ""
+}
+
+// extractWidgetConfigVersion extracts widget ID and version from /widgets/{id}/config/restore/{version}
+func extractWidgetConfigVersion(path string) (string, string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 5 && parts[0] == "widgets" && parts[2] == "config" && parts[3] == "restore" {
+		return parts[1], parts[4]
+	}
+	return "", ""
+}
+
 // extractWidgetConfigID extracts widget ID from config URL path
 func extractWidgetConfigID(path string) string {
 	// Extract from /api/v1/widgets/{id}/config
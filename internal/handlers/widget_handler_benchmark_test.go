@@ -222,7 +222,7 @@ func setupBenchmarkHandler(b *testing.B, widgetCount int) (*WidgetHandler, strin
 	// Create handler
 	validator := &validation.SchemaValidator{}
 	exportService := &services.ExportService{}
-	handler := NewWidgetHandler(widgetService, exportService, validator)
+	handler := NewWidgetHandler(widgetService, exportService, services.NewImportService(widgetService, validator), validator)
 
 	return handler, userID
 }
@@ -264,6 +264,16 @@ func (m *MockWidgetRepository) GetByID(ctx context.Context, id string) (*models.
 	return nil, fmt.Errorf("widget not found")
 }
 
+func (m *MockWidgetRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Widget, error) {
+	widgets := make([]*models.Widget, 0, len(ids))
+	for _, id := range ids {
+		if widget, exists := m.widgets[id]; exists {
+			widgets = append(widgets, widget)
+		}
+	}
+	return widgets, nil
+}
+
 func (m *MockWidgetRepository) GetByUserID(ctx context.Context, userID string, opts models.PaginationOptions) ([]*models.Widget, int, error) {
 	return m.GetByUserIDWithFilters(ctx, userID, opts)
 }
@@ -415,6 +425,34 @@ func (m *MockWidgetRepository) RebuildIndexes(ctx context.Context) error {
 	return nil
 }
 
+func (m *MockWidgetRepository) CleanupExpiredWidgets(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *MockWidgetRepository) GetIndexMembership(ctx context.Context, id string) (*models.WidgetIndexReport, error) {
+	return nil, nil
+}
+
+func (m *MockWidgetRepository) PushConfigHistory(ctx context.Context, widgetID string, version *models.WidgetConfigVersion, limit int) error {
+	return nil
+}
+
+func (m *MockWidgetRepository) GetConfigHistory(ctx context.Context, widgetID string) ([]*models.WidgetConfigVersion, error) {
+	return nil, nil
+}
+
+func (m *MockWidgetRepository) GetPIIWidgetIDs(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockWidgetRepository) GetVisibleWidgetIDs(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockWidgetRepository) GetWidgetIDByName(ctx context.Context, userID, name string) (string, error) {
+	return "", nil
+}
+
 // MockSubmissionRepository for benchmarking
 type MockSubmissionRepository struct{}
 
@@ -430,6 +468,26 @@ func (m *MockSubmissionRepository) GetByWidgetID(ctx context.Context, widgetID s
 	return []*models.Submission{}, 0, nil
 }
 
+func (m *MockSubmissionRepository) GetByWidgetIDAfter(ctx context.Context, widgetID string, cursor *models.SubmissionCursor, limit int) ([]*models.Submission, int, *models.SubmissionCursor, error) {
+	return []*models.Submission{}, 0, nil, nil
+}
+
+func (m *MockSubmissionRepository) GetCreatedBetween(ctx context.Context, widgetID string, after, until int64, limit int) ([]*models.Submission, error) {
+	return []*models.Submission{}, nil
+}
+
+func (m *MockSubmissionRepository) GetByCorrelation(ctx context.Context, widgetID, value string) (*models.Submission, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *MockSubmissionRepository) ClaimDedup(ctx context.Context, widgetID, fingerprint, submissionID string, window time.Duration) (string, error) {
+	return "", nil
+}
+
+func (m *MockSubmissionRepository) ReleaseDedup(ctx context.Context, widgetID, fingerprint string) error {
+	return nil
+}
+
 func (m *MockSubmissionRepository) UpdateTTL(ctx context.Context, userID string, ttl time.Duration) error {
 	return nil
 }
@@ -438,6 +496,26 @@ func (m *MockSubmissionRepository) UpdateWidgetSubmissionsTTL(ctx context.Contex
 	return nil
 }
 
+func (m *MockSubmissionRepository) RedactPII(ctx context.Context, widgetID string, fields []string, cutoff time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *MockSubmissionRepository) EvictOldest(ctx context.Context, widgetID string, keep int) (int, error) {
+	return 0, nil
+}
+
+func (m *MockSubmissionRepository) Delete(ctx context.Context, widgetID, submissionID string) error {
+	return nil
+}
+
+func (m *MockSubmissionRepository) SearchByWidgetID(ctx context.Context, widgetID string, filter models.SubmissionFilter, opts models.PaginationOptions) ([]*models.Submission, int, error) {
+	return nil, 0, nil
+}
+
+func (m *MockSubmissionRepository) DeleteByRange(ctx context.Context, widgetID string, from, to time.Time) (int, error) {
+	return 0, nil
+}
+
 func (m *MockSubmissionRepository) CleanupExpired(ctx context.Context) (int, error) {
 	return 0, nil
 }
@@ -466,6 +544,10 @@ func (m *MockStatsRepository) IncrementViews(ctx context.Context, widgetID strin
 	return nil
 }
 
+func (m *MockStatsRepository) MarkViewSeen(ctx context.Context, widgetID, visitor string, window time.Duration) (bool, error) {
+	return true, nil
+}
+
 func (m *MockStatsRepository) IncrementSubmits(ctx context.Context, widgetID string) error {
 	if stats, exists := m.stats[widgetID]; exists {
 		stats.Submits++
@@ -480,6 +562,34 @@ func (m *MockStatsRepository) IncrementCloses(ctx context.Context, widgetID stri
 	return nil
 }
 
+func (m *MockStatsRepository) IncrementSpam(ctx context.Context, widgetID string) error {
+	if stats, exists := m.stats[widgetID]; exists {
+		stats.Spam++
+	}
+	return nil
+}
+
 func (m *MockStatsRepository) GetDailyViews(ctx context.Context, widgetID, date string) (int64, error) {
 	return 0, nil
 }
+
+func (m *MockStatsRepository) GetDailySubmits(ctx context.Context, widgetID, date string) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockStatsRepository) GetDailyCloses(ctx context.Context, widgetID, date string) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockStatsRepository) GetDailySeries(ctx context.Context, widgetID, metric string, from, to time.Time) ([]models.DailyStat, error) {
+	return []models.DailyStat{}, nil
+}
+
+func (m *MockStatsRepository) ResetStats(ctx context.Context, widgetID string) error {
+	delete(m.stats, widgetID)
+	return nil
+}
+
+func (m *MockStatsRepository) ResetDailyStats(ctx context.Context, widgetID string) error {
+	return nil
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/ad/leads-core/internal/models"
@@ -325,6 +327,87 @@ func TestParsePaginationWithFilters(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "last activity sort",
+			query: "sort=last_activity",
+			expected: models.PaginationOptions{
+				Page:    1,
+				PerPage: 20,
+				Filters: &models.FilterOptions{Types: []string{}},
+				SortBy:  models.SortByLastActivity,
+			},
+		},
+		{
+			name:  "submissions sort",
+			query: "sort=submissions",
+			expected: models.PaginationOptions{
+				Page:    1,
+				PerPage: 20,
+				Filters: &models.FilterOptions{Types: []string{}},
+				SortBy:  models.SortBySubmits,
+			},
+		},
+		{
+			name:  "sort_by and sort_order",
+			query: "sort_by=submits&sort_order=asc",
+			expected: models.PaginationOptions{
+				Page:      1,
+				PerPage:   20,
+				Filters:   &models.FilterOptions{Types: []string{}},
+				SortBy:    models.SortBySubmits,
+				SortOrder: models.SortOrderAsc,
+			},
+		},
+		{
+			name:  "sort_by takes precedence over sort",
+			query: "sort=views:asc&sort_by=name",
+			expected: models.PaginationOptions{
+				Page:    1,
+				PerPage: 20,
+				Filters: &models.FilterOptions{Types: []string{}},
+				SortBy:  models.SortByName,
+			},
+		},
+		{
+			name:  "sort_order applies to sort",
+			query: "sort=views:asc&sort_order=desc",
+			expected: models.PaginationOptions{
+				Page:      1,
+				PerPage:   20,
+				Filters:   &models.FilterOptions{Types: []string{}},
+				SortBy:    models.SortByViews,
+				SortOrder: models.SortOrderDesc,
+			},
+		},
+		{
+			name:  "sort with direction",
+			query: "sort=Name:DESC",
+			expected: models.PaginationOptions{
+				Page:      1,
+				PerPage:   20,
+				Filters:   &models.FilterOptions{Types: []string{}},
+				SortBy:    models.SortByName,
+				SortOrder: models.SortOrderDesc,
+			},
+		},
+		{
+			name:  "unknown sort is ignored",
+			query: "sort=rating",
+			expected: models.PaginationOptions{
+				Page:    1,
+				PerPage: 20,
+				Filters: &models.FilterOptions{Types: []string{}},
+			},
+		},
+		{
+			name:  "unknown sort direction is ignored",
+			query: "sort=views:up",
+			expected: models.PaginationOptions{
+				Page:    1,
+				PerPage: 20,
+				Filters: &models.FilterOptions{Types: []string{}},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -345,6 +428,13 @@ func TestParsePaginationWithFilters(t *testing.T) {
 				t.Errorf("PerPage mismatch. Expected: %d, Got: %d", tt.expected.PerPage, result.PerPage)
 			}
 
+			if result.SortBy != tt.expected.SortBy {
+				t.Errorf("SortBy mismatch. Expected: %q, Got: %q", tt.expected.SortBy, result.SortBy)
+			}
+			if result.SortOrder != tt.expected.SortOrder {
+				t.Errorf("SortOrder mismatch. Expected: %q, Got: %q", tt.expected.SortOrder, result.SortOrder)
+			}
+
 			// Check filters
 			if result.Filters == nil {
 				t.Fatal("Filters should not be nil")
@@ -373,3 +463,93 @@ func TestParsePaginationWithFilters(t *testing.T) {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func TestPaginationLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		page     int
+		perPage  int
+		total    int
+		expected models.PaginationLinks
+	}{
+		{
+			name:    "first page",
+			target:  "/api/v1/widgets?type=lead-form&page=1&per_page=10",
+			page:    1,
+			perPage: 10,
+			total:   35,
+			expected: models.PaginationLinks{
+				First: "http://example.com/api/v1/widgets?page=1&per_page=10&type=lead-form",
+				Next:  "http://example.com/api/v1/widgets?page=2&per_page=10&type=lead-form",
+				Last:  "http://example.com/api/v1/widgets?page=4&per_page=10&type=lead-form",
+			},
+		},
+		{
+			name:    "middle page with limit alias",
+			target:  "/api/v1/widgets/w1/submissions?limit=10&page=2&search=a+b",
+			page:    2,
+			perPage: 10,
+			total:   35,
+			expected: models.PaginationLinks{
+				First: "http://example.com/api/v1/widgets/w1/submissions?page=1&per_page=10&search=a+b",
+				Prev:  "http://example.com/api/v1/widgets/w1/submissions?page=1&per_page=10&search=a+b",
+				Next:  "http://example.com/api/v1/widgets/w1/submissions?page=3&per_page=10&search=a+b",
+				Last:  "http://example.com/api/v1/widgets/w1/submissions?page=4&per_page=10&search=a+b",
+			},
+		},
+		{
+			name:    "last page",
+			target:  "/api/v1/widgets?page=4&per_page=10",
+			page:    4,
+			perPage: 10,
+			total:   35,
+			expected: models.PaginationLinks{
+				First: "http://example.com/api/v1/widgets?page=1&per_page=10",
+				Prev:  "http://example.com/api/v1/widgets?page=3&per_page=10",
+				Last:  "http://example.com/api/v1/widgets?page=4&per_page=10",
+			},
+		},
+		{
+			name:    "empty list",
+			target:  "/api/v1/widgets",
+			page:    1,
+			perPage: 20,
+			total:   0,
+			expected: models.PaginationLinks{
+				First: "http://example.com/api/v1/widgets?page=1&per_page=20",
+				Last:  "http://example.com/api/v1/widgets?page=1&per_page=20",
+			},
+		},
+		{
+			name:    "page past the end",
+			target:  "/api/v1/widgets?page=9",
+			page:    9,
+			perPage: 20,
+			total:   30,
+			expected: models.PaginationLinks{
+				First: "http://example.com/api/v1/widgets?page=1&per_page=20",
+				Prev:  "http://example.com/api/v1/widgets?page=2&per_page=20",
+				Last:  "http://example.com/api/v1/widgets?page=2&per_page=20",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.URL.Path = "/widgets" // Rewritten by the router
+
+			links := paginationLinks(req, tt.page, tt.perPage, tt.total)
+			if links == nil || *links != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, links)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/widgets", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	if links := paginationLinks(req, 1, 20, 0); !strings.HasPrefix(links.First, "https://example.com/") {
+		t.Errorf("Expected forwarded scheme to be used, got %s", links.First)
+	}
+}
@@ -3,10 +3,17 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -98,7 +105,7 @@ func setupIntegrationTestEnvironment(t *testing.T) *IntegrationTestEnvironment {
 	exportService := services.NewExportService(submissionRepo, widgetRepo)
 
 	// Create handler
-	handler := NewWidgetHandler(widgetService, exportService, validator)
+	handler := NewWidgetHandler(widgetService, exportService, services.NewImportService(widgetService, validator), validator)
 
 	// Create auth middleware
 	authMiddleware := middleware.NewAuthMiddleware(jwtValidator, false)
@@ -883,3 +890,4484 @@ func TestGetWidgets_Integration_MetaInformation(t *testing.T) {
 		})
 	}
 }
+
+func TestWidgets_Integration_PlanTypeRestrictions(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.WidgetService.SetTypeRegistry(models.NewTypeRegistry(nil, map[string][]string{"free": {"quiz"}}))
+	now := time.Now()
+
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, now.Add(-2*time.Hour))
+	env.createTestWidget("widget-2", "Quiz", "quiz", true, now.Add(-1*time.Hour))
+
+	withPlan := func(req *http.Request) *http.Request {
+		user := &models.User{ID: env.UserID, Plan: "free"}
+		return req.WithContext(auth.SetUserInContext(req.Context(), user))
+	}
+
+	t.Run("denied type filter is ignored", func(t *testing.T) {
+		req := withPlan(env.makeAuthenticatedRequest("GET", "/api/v1/widgets?type=lead-form,quiz", nil))
+		w := httptest.NewRecorder()
+
+		env.Handler.GetWidgets(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response models.WidgetsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if response.Meta.Total != 1 {
+			t.Errorf("Expected total count of 1, got %d", response.Meta.Total)
+		}
+	})
+
+	t.Run("denied type cannot be created", func(t *testing.T) {
+		body := []byte(`{"type":"quiz","name":"New Quiz","isVisible":true,"config":{}}`)
+		req := withPlan(env.makeAuthenticatedRequest("POST", "/api/v1/widgets", body))
+		w := httptest.NewRecorder()
+
+		env.Handler.CreateWidget(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusForbidden, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("type cannot be changed to a denied type", func(t *testing.T) {
+		req := withPlan(env.makeAuthenticatedRequest("POST", "/widgets/widget-1", []byte(`{"type":"quiz"}`)))
+		w := httptest.NewRecorder()
+
+		env.Handler.UpdateWidget(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusForbidden, w.Code, w.Body.String())
+		}
+		widget, err := env.WidgetRepo.GetByID(context.Background(), "widget-1")
+		if err != nil || widget.Type != "lead-form" {
+			t.Errorf("Expected the widget to stay a lead-form, got %v (err: %v)", widget, err)
+		}
+	})
+
+	t.Run("type can be changed to an allowed type", func(t *testing.T) {
+		req := withPlan(env.makeAuthenticatedRequest("POST", "/widgets/widget-2", []byte(`{"type":"banner"}`)))
+		w := httptest.NewRecorder()
+
+		env.Handler.UpdateWidget(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	})
+}
+
+// stubGeoLocator resolves regions from a fixed IP map
+type stubGeoLocator map[string]string
+
+func (g stubGeoLocator) Locate(ip string) string {
+	return g[ip]
+}
+
+func TestSubmitWidget_Integration_GeoRestrictions(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+
+	widget := env.createTestWidget("widget-geo", "Geo Form", "lead-form", true, time.Now())
+	widget.Config = map[string]interface{}{
+		models.WidgetConfigGeoKey: map[string]interface{}{
+			"allowed_countries": []interface{}{"DE", "FR"},
+			"blocked_countries": []interface{}{"FR"},
+		},
+	}
+	if err := env.WidgetRepo.Update(context.Background(), widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+
+	submit := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/widgets/widget-geo/submit", bytes.NewBufferString(`{"data":{"email":"a@example.com"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", ip)
+		w := httptest.NewRecorder()
+		publicHandler.SubmitWidget(w, req)
+		return w
+	}
+
+	// Geo lookup disabled: nothing is blocked
+	if w := submit("10.0.0.3"); w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d without geo lookup, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	env.WidgetService.SetRegion("", stubGeoLocator{"10.0.0.1": "de", "10.0.0.2": "fr", "10.0.0.3": "us"})
+
+	tests := []struct {
+		name   string
+		ip     string
+		status int
+	}{
+		{"allowed country", "10.0.0.1", http.StatusCreated},
+		{"blocked country", "10.0.0.2", http.StatusForbidden},
+		{"country outside the allow list", "10.0.0.3", http.StatusForbidden},
+		{"unknown country", "10.0.0.4", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := submit(tt.ip)
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status == http.StatusForbidden && !strings.Contains(w.Body.String(), `"code":"geo_blocked"`) {
+				t.Errorf("Expected geo_blocked code, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSubmissions_Integration_Region(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+	submit := func(ip string) *models.Submission {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/widgets/widget-1/submit", bytes.NewBufferString(`{"data":{"email":"a@example.com"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", ip)
+		w := httptest.NewRecorder()
+
+		publicHandler.SubmitWidget(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Data models.Submission `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return &response.Data
+	}
+
+	// Geo disabled and no default region
+	if submission := submit("10.0.0.1"); submission.Region != "" {
+		t.Errorf("Expected empty region when geo is disabled, got %q", submission.Region)
+	}
+
+	env.WidgetService.SetRegion("us", stubGeoLocator{"10.0.0.2": "de"})
+
+	if submission := submit("10.0.0.2"); submission.Region != "de" {
+		t.Errorf("Expected region from geo lookup, got %q", submission.Region)
+	}
+	if submission := submit("10.0.0.3"); submission.Region != "us" {
+		t.Errorf("Expected default region, got %q", submission.Region)
+	}
+
+	req := env.makeAuthenticatedRequest("GET", "/widgets/widget-1/submissions?region=DE", nil)
+	w := httptest.NewRecorder()
+
+	env.Handler.GetWidgetSubmissions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Data []*models.Submission `json:"data"`
+		Meta *models.Meta         `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.Meta.Total != 1 || len(response.Data) != 1 || response.Data[0].Region != "de" {
+		t.Errorf("Expected a single submission in region de, got %d (total %d)", len(response.Data), response.Meta.Total)
+	}
+}
+
+func TestSubmitWidget_Integration_Status(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+	ctx := context.Background()
+
+	for _, status := range []models.WidgetStatus{models.WidgetStatusActive, models.WidgetStatusPaused, models.WidgetStatusClosed} {
+		widget := &models.Widget{
+			ID:        "widget-" + string(status),
+			OwnerID:   env.UserID,
+			Name:      "Widget " + string(status),
+			Type:      "lead-form",
+			Config:    map[string]interface{}{},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		widget.SetStatus(status)
+		if err := env.WidgetRepo.Create(ctx, widget); err != nil {
+			t.Fatalf("Failed to create widget: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		widgetID       string
+		expectedStatus int
+		expectPaused   bool
+	}{
+		{name: "active accepts", widgetID: "widget-active", expectedStatus: http.StatusCreated},
+		{name: "paused accepts and marks", widgetID: "widget-paused", expectedStatus: http.StatusCreated, expectPaused: true},
+		{name: "closed rejects", widgetID: "widget-closed", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/widgets/"+tt.widgetID+"/submit", bytes.NewBufferString(`{"data":{"email":"a@example.com"}}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			publicHandler.SubmitWidget(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			var response struct {
+				Data models.Submission `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Data.ReceivedWhilePaused != tt.expectPaused {
+				t.Errorf("Expected received_while_paused=%t, got %t", tt.expectPaused, response.Data.ReceivedWhilePaused)
+			}
+		})
+	}
+
+	t.Run("isVisible alias closes widget", func(t *testing.T) {
+		hidden := false
+		widget, err := env.WidgetService.UpdateWidget(ctx, "widget-active", env.UserID, models.UpdateWidgetRequest{IsVisible: &hidden})
+		if err != nil {
+			t.Fatalf("Failed to update widget: %v", err)
+		}
+		if widget.Status != models.WidgetStatusClosed {
+			t.Errorf("Expected status closed, got %s", widget.Status)
+		}
+	})
+}
+
+func TestWidgetLookupError_AdminVsNormal(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	widget := &models.Widget{
+		ID:        "foreign-widget",
+		OwnerID:   "other-user",
+		Name:      "Foreign Widget",
+		Type:      "lead-form",
+		IsVisible: true,
+		Config:    map[string]interface{}{},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := env.WidgetRepo.Create(ctx, widget); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		user           *models.User
+		widgetID       string
+		expectedStatus int
+	}{
+		{name: "normal user access denied", user: &models.User{ID: env.UserID}, widgetID: "foreign-widget", expectedStatus: http.StatusNotFound},
+		{name: "normal user not found", user: &models.User{ID: env.UserID}, widgetID: "missing-widget", expectedStatus: http.StatusNotFound},
+		{name: "admin access denied", user: &models.User{ID: env.UserID, Role: models.RoleAdmin}, widgetID: "foreign-widget", expectedStatus: http.StatusForbidden},
+		{name: "admin not found", user: &models.User{ID: env.UserID, Role: models.RoleAdmin}, widgetID: "missing-widget", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/widgets/"+tt.widgetID, nil)
+			req = req.WithContext(auth.SetUserInContext(req.Context(), tt.user))
+			w := httptest.NewRecorder()
+
+			env.Handler.GetWidget(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetWidgetStats_Integration_Preview(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	widget := env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+	widget.Config = map[string]interface{}{
+		models.WidgetConfigPIIKey: map[string]interface{}{"fields": []interface{}{"email"}},
+	}
+	if err := env.WidgetRepo.Update(ctx, widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+
+	getStats := func(query string) models.WidgetStats {
+		t.Helper()
+		req := env.makeAuthenticatedRequest("GET", "/widgets/widget-1/stats"+query, nil)
+		w := httptest.NewRecorder()
+		env.Handler.GetWidgetStats(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var stats models.WidgetStats
+		if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+			t.Fatalf("Failed to decode stats: %v", err)
+		}
+		return stats
+	}
+
+	// No submissions yet, nothing to preview
+	if stats := getStats("?preview=name"); stats.Preview != nil {
+		t.Errorf("Expected no preview without submissions, got %+v", stats.Preview)
+	}
+
+	if err := storage.NewRedisSubmissionRepository(env.RedisClient).Create(ctx, &models.Submission{
+		ID:        "older",
+		WidgetID:  "widget-1",
+		Data:      map[string]interface{}{"name": "Old", "city": "Berlin"},
+		CreatedAt: time.Now().Add(-time.Hour),
+		TTL:       time.Hour,
+	}); err != nil {
+		t.Fatalf("Failed to create submission: %v", err)
+	}
+	latest, err := env.WidgetService.SubmitWidget(ctx, "widget-1", models.SubmissionRequest{
+		Data: map[string]interface{}{"name": "New", "city": "Paris", "email": "new@example.com", "phone": "123"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to submit widget: %v", err)
+	}
+
+	// The preview shows the requested fields of the latest submission, never PII fields
+	stats := getStats("?preview=name,city,email")
+	if stats.Preview == nil || stats.Preview.ID != latest.ID {
+		t.Fatalf("Expected a preview of the latest submission %s, got %+v", latest.ID, stats.Preview)
+	}
+	expected := map[string]interface{}{"name": "New", "city": "Paris"}
+	if !reflect.DeepEqual(stats.Preview.Data, expected) {
+		t.Errorf("Expected preview data %v, got %v", expected, stats.Preview.Data)
+	}
+	if stats.Submits != 1 {
+		t.Errorf("Expected stats to be returned as usual, got %+v", stats)
+	}
+
+	// Opt-in only
+	if stats := getStats(""); stats.Preview != nil {
+		t.Errorf("Expected no preview without ?preview=, got %+v", stats.Preview)
+	}
+}
+
+func TestGetWidgetStats_Integration_Rates(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+	env.createTestWidget("widget-2", "Banner", "banner", true, time.Now())
+
+	getStats := func(widgetID string) map[string]interface{} {
+		t.Helper()
+		req := env.makeAuthenticatedRequest("GET", "/widgets/"+widgetID+"/stats", nil)
+		w := httptest.NewRecorder()
+		env.Handler.GetWidgetStats(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var stats map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+			t.Fatalf("Failed to decode stats: %v", err)
+		}
+		return stats
+	}
+
+	// Widgets without views have zero rates, present in the response
+	if err := env.StatsRepo.IncrementSubmits(ctx, "widget-2"); err != nil {
+		t.Fatalf("Failed to increment submits: %v", err)
+	}
+	if stats := getStats("widget-2"); stats["submit_rate"] != 0.0 || stats["close_rate"] != 0.0 {
+		t.Errorf("Expected zero rates without views, got %v", stats)
+	}
+
+	for _, increment := range []func(context.Context, string) error{
+		env.StatsRepo.IncrementViews, env.StatsRepo.IncrementViews, env.StatsRepo.IncrementViews, env.StatsRepo.IncrementViews,
+		env.StatsRepo.IncrementSubmits, env.StatsRepo.IncrementCloses, env.StatsRepo.IncrementCloses,
+	} {
+		if err := increment(ctx, "widget-1"); err != nil {
+			t.Fatalf("Failed to increment: %v", err)
+		}
+	}
+	if stats := getStats("widget-1"); stats["submit_rate"] != 0.25 || stats["close_rate"] != 0.5 {
+		t.Errorf("Expected submit rate 0.25 and close rate 0.5, got %v", stats)
+	}
+
+	// The summary rates all submissions against all views
+	req := env.makeAuthenticatedRequest("GET", "/widgets/summary", nil)
+	w := httptest.NewRecorder()
+	env.Handler.GetWidgetsSummary(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Data models.WidgetsSummary `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	if response.Data.ConversionRate != 0.5 {
+		t.Errorf("Expected conversion rate 0.5 (2 submissions / 4 views), got %+v", response.Data)
+	}
+}
+
+func TestBulkResetStats_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+	if _, err := env.WidgetService.SubmitWidget(ctx, "widget-1", models.SubmissionRequest{
+		Data: map[string]interface{}{"email": "a@example.com"},
+	}); err != nil {
+		t.Fatalf("Failed to submit widget: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := env.StatsRepo.IncrementViews(ctx, "widget-1"); err != nil {
+			t.Fatalf("Failed to increment views: %v", err)
+		}
+	}
+
+	foreign := &models.Widget{
+		ID:        "foreign-widget",
+		OwnerID:   "other-user",
+		Name:      "Foreign Widget",
+		Type:      "lead-form",
+		IsVisible: true,
+		Config:    map[string]interface{}{},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := env.WidgetRepo.Create(ctx, foreign); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+	if err := env.StatsRepo.IncrementViews(ctx, "foreign-widget"); err != nil {
+		t.Fatalf("Failed to increment views: %v", err)
+	}
+
+	body := []byte(`{"ids":["widget-1","foreign-widget","missing-widget"],"reset_daily":true}`)
+	req := env.makeAuthenticatedRequest("POST", "/widgets/bulk-stats-reset", body)
+	w := httptest.NewRecorder()
+
+	env.Handler.BulkResetStats(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data []*models.BulkOperationResult `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Data) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(response.Data))
+	}
+	if !response.Data[0].Success {
+		t.Errorf("Expected reset of own widget to succeed, got error %q", response.Data[0].Error)
+	}
+	for _, result := range response.Data[1:] {
+		if result.Success || result.Error == "" {
+			t.Errorf("Expected reset of %s to fail", result.ID)
+		}
+	}
+
+	stats, err := env.StatsRepo.GetWidgetStats(ctx, "widget-1")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Views != 0 || stats.Submits != 0 {
+		t.Errorf("Expected counters to be reset, got views=%d submits=%d", stats.Views, stats.Submits)
+	}
+	daily, err := env.StatsRepo.GetDailyViews(ctx, "widget-1", time.Now().Format("2006-01-02"))
+	if err != nil {
+		t.Fatalf("Failed to get daily views: %v", err)
+	}
+	if daily != 0 {
+		t.Errorf("Expected daily views to be reset, got %d", daily)
+	}
+
+	foreignStats, err := env.StatsRepo.GetWidgetStats(ctx, "foreign-widget")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if foreignStats.Views != 1 {
+		t.Errorf("Expected foreign widget stats to be untouched, got views=%d", foreignStats.Views)
+	}
+
+	_, total, err := env.WidgetService.GetWidgetSubmissions(ctx, "widget-1", env.UserID, models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatalf("Failed to get submissions: %v", err)
+	}
+	if total != 1 {
+		t.Errorf("Expected submissions to be kept, got %d", total)
+	}
+
+	// Invalid payload
+	req = env.makeAuthenticatedRequest("POST", "/widgets/bulk-stats-reset", []byte(`{"ids":[]}`))
+	w = httptest.NewRecorder()
+	env.Handler.BulkResetStats(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for empty ids, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestSubmitWidget_Integration_FieldLengthLimits(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+	env.WidgetService.SetMaxFieldLength(20)
+
+	widget := &models.Widget{
+		ID:        "widget-1",
+		OwnerID:   env.UserID,
+		Name:      "Lead Form",
+		Type:      "lead-form",
+		IsVisible: true,
+		Config: map[string]interface{}{
+			models.WidgetConfigFieldMaxLengthsKey: map[string]interface{}{"message": 10},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := env.WidgetRepo.Create(context.Background(), widget); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		data           map[string]interface{}
+		expectedStatus int
+		expectedField  string
+	}{
+		{name: "at per-field limit", data: map[string]interface{}{"message": strings.Repeat("a", 10)}, expectedStatus: http.StatusCreated},
+		{name: "just over per-field limit", data: map[string]interface{}{"message": strings.Repeat("a", 11)}, expectedStatus: http.StatusBadRequest, expectedField: "data.message"},
+		{name: "per-field limit counts characters", data: map[string]interface{}{"message": strings.Repeat("ж", 10)}, expectedStatus: http.StatusCreated},
+		{name: "over global limit", data: map[string]interface{}{"name": strings.Repeat("a", 21)}, expectedStatus: http.StatusBadRequest, expectedField: "data.name"},
+		{name: "array item over global limit", data: map[string]interface{}{"tags": []string{"ok", strings.Repeat("a", 21)}}, expectedStatus: http.StatusBadRequest, expectedField: "data.tags.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]interface{}{"data": tt.data})
+			req := httptest.NewRequest("POST", "/widgets/widget-1/submit", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			publicHandler.SubmitWidget(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedField == "" {
+				return
+			}
+
+			var response struct {
+				Details []*models.FieldError `json:"details"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Details) != 1 || response.Details[0].Field != tt.expectedField {
+				t.Errorf("Expected a single error for %s, got %s", tt.expectedField, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestImportWidgetSubmissions_Integration(t *testing.T) {
+	lines := strings.Join([]string{
+		`{"data":{"email":"a@example.com"}}`,
+		`{"data":{"email":"b@example.com"},"created_at":"2024-01-02T03:04:05Z"}`,
+		`not json`,
+		``,
+		`{"data":{}}`,
+		`{"data":{"email":"c@example.com"}}`,
+	}, "\n")
+
+	tests := []struct {
+		name             string
+		query            string
+		expectedStatus   int
+		expectedImported int
+		expectedFailed   int
+		expectedLines    []int
+		expectedAborted  bool
+	}{
+		{name: "lenient continues past bad lines", expectedStatus: http.StatusOK, expectedImported: 3, expectedFailed: 2, expectedLines: []int{3, 5}},
+		{name: "strict stops at first bad line", query: "?strict=true", expectedStatus: http.StatusOK, expectedImported: 2, expectedFailed: 1, expectedLines: []int{3}, expectedAborted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := setupIntegrationTestEnvironment(t)
+			env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+			req := env.makeAuthenticatedRequest("POST", "/widgets/widget-1/import"+tt.query, []byte(lines))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+			w := httptest.NewRecorder()
+
+			env.Handler.ImportWidgetSubmissions(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			var response struct {
+				Data *models.ImportSummary `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			summary := response.Data
+			if summary == nil {
+				t.Fatalf("Expected an import summary, got %s", w.Body.String())
+			}
+
+			if summary.Imported != tt.expectedImported || summary.Failed != tt.expectedFailed {
+				t.Errorf("Expected imported=%d failed=%d, got imported=%d failed=%d", tt.expectedImported, tt.expectedFailed, summary.Imported, summary.Failed)
+			}
+			if summary.Aborted != tt.expectedAborted {
+				t.Errorf("Expected aborted=%v, got %v", tt.expectedAborted, summary.Aborted)
+			}
+			if len(summary.Errors) != len(tt.expectedLines) {
+				t.Fatalf("Expected %d line errors, got %d", len(tt.expectedLines), len(summary.Errors))
+			}
+			for i, line := range tt.expectedLines {
+				if summary.Errors[i].Line != line {
+					t.Errorf("Expected error on line %d, got line %d", line, summary.Errors[i].Line)
+				}
+			}
+
+			submissions, total, err := env.WidgetService.GetWidgetSubmissions(context.Background(), "widget-1", env.UserID, models.PaginationOptions{Page: 1, PerPage: 10})
+			if err != nil {
+				t.Fatalf("Failed to get submissions: %v", err)
+			}
+			if total != tt.expectedImported {
+				t.Errorf("Expected %d stored submissions, got %d", tt.expectedImported, total)
+			}
+			for _, submission := range submissions {
+				if submission.Data["email"] == "b@example.com" && !submission.CreatedAt.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+					t.Errorf("Expected original created_at to be kept, got %v", submission.CreatedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestImportWidgetSubmissions_Integration_ErrorCap(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+	body := strings.Repeat("not json\n", services.MaxImportErrors+5)
+	req := env.makeAuthenticatedRequest("POST", "/widgets/widget-1/import", []byte(body))
+	w := httptest.NewRecorder()
+
+	env.Handler.ImportWidgetSubmissions(w, req)
+
+	var response struct {
+		Data *models.ImportSummary `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Data == nil {
+		t.Fatalf("Failed to decode response: %v (%s)", err, w.Body.String())
+	}
+	if response.Data.Failed != services.MaxImportErrors+5 || len(response.Data.Errors) != services.MaxImportErrors || !response.Data.ErrorsTruncated {
+		t.Errorf("Expected %d failures with %d listed and truncation flag, got failed=%d listed=%d truncated=%t",
+			services.MaxImportErrors+5, services.MaxImportErrors, response.Data.Failed, len(response.Data.Errors), response.Data.ErrorsTruncated)
+	}
+}
+
+func TestImportWidgetSubmissions_Integration_CSV(t *testing.T) {
+	upload := func(env *IntegrationTestEnvironment, field, csvData, query string) (*httptest.ResponseRecorder, *models.ImportSummary) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile(field, "submissions.csv")
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		part.Write([]byte(csvData))
+		writer.Close()
+
+		req := env.makeAuthenticatedRequest("POST", "/widgets/widget-1/import"+query, body.Bytes())
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		env.Handler.ImportWidgetSubmissions(w, req)
+
+		var response struct {
+			Data *models.ImportSummary `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response.Data
+	}
+
+	t.Run("well-formed", func(t *testing.T) {
+		env := setupIntegrationTestEnvironment(t)
+		env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+		csvData := "\ufeffemail,name,created_at\n" +
+			"a@example.com,Ann,2024-01-02T03:04:05Z\n" +
+			"b@example.com,,\n" +
+			"\"c@example.com\",\"Smith, \"\"CJ\"\"\",\n"
+		w, summary := upload(env, "file", csvData, "")
+		if w.Code != http.StatusOK || summary == nil {
+			t.Fatalf("Expected status %d with a summary, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if summary.Imported != 3 || summary.Failed != 0 {
+			t.Errorf("Expected 3 imported and none failed, got %+v", summary)
+		}
+
+		submissions, _, err := env.WidgetService.GetWidgetSubmissions(context.Background(), "widget-1", env.UserID, models.PaginationOptions{Page: 1, PerPage: 10})
+		if err != nil {
+			t.Fatalf("Failed to get submissions: %v", err)
+		}
+		byEmail := make(map[string]*models.Submission)
+		for _, submission := range submissions {
+			byEmail[fmt.Sprint(submission.Data["email"])] = submission
+		}
+		if ann := byEmail["a@example.com"]; ann == nil || !ann.CreatedAt.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) || ann.Data["name"] != "Ann" {
+			t.Errorf("Expected Ann with the original created_at, got %+v", ann)
+		}
+		if b := byEmail["b@example.com"]; b == nil || b.Data["name"] != nil || time.Since(b.CreatedAt) > time.Minute {
+			t.Errorf("Expected b without empty cells and a current timestamp, got %+v", b)
+		}
+		if c := byEmail["c@example.com"]; c == nil || c.Data["name"] != `Smith, "CJ"` {
+			t.Errorf("Expected quoted values to be unescaped, got %+v", c)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		env := setupIntegrationTestEnvironment(t)
+		env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+		csvData := "email,created_at\n" +
+			"a@example.com,\n" + // line 2
+			"b@example.com,,extra\n" + // line 3: wrong number of fields
+			"c@example.com,yesterday\n" + // line 4: invalid created_at
+			",\n" + // line 5: no data
+			"d@example.com,2024-01-02T03:04:05Z\n"
+		w, summary := upload(env, "file", csvData, "")
+		if w.Code != http.StatusOK || summary == nil {
+			t.Fatalf("Expected status %d with a summary, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if summary.Imported != 2 || summary.Failed != 3 {
+			t.Errorf("Expected 2 imported and 3 failed, got %+v", summary)
+		}
+		var lines []int
+		for _, lineErr := range summary.Errors {
+			lines = append(lines, lineErr.Line)
+		}
+		if !reflect.DeepEqual(lines, []int{3, 4, 5}) {
+			t.Errorf("Expected errors on lines 3, 4 and 5, got %v", lines)
+		}
+
+		// Strict imports stop at the first bad row
+		env = setupIntegrationTestEnvironment(t)
+		env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+		if w, summary := upload(env, "file", csvData, "?strict=true"); w.Code != http.StatusOK || summary == nil || summary.Imported != 1 || !summary.Aborted {
+			t.Errorf("Expected strict import to abort after 1 row, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("invalid upload", func(t *testing.T) {
+		env := setupIntegrationTestEnvironment(t)
+		env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+		for name, tt := range map[string]struct{ field, csvData string }{
+			"missing file":     {field: "upload", csvData: "email\na@example.com\n"},
+			"empty file":       {field: "file", csvData: ""},
+			"duplicate column": {field: "file", csvData: "email,email\na@example.com,b@example.com\n"},
+		} {
+			if w, _ := upload(env, tt.field, tt.csvData, ""); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
+}
+
+func TestAdminMaintenance_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+
+	maintenance := middleware.NewMaintenance(config.MaintenanceConfig{RetryAfter: time.Minute})
+	maintenance.SetRepository(storage.NewRedisMaintenanceRepository(env.RedisClient))
+	adminHandler := NewAdminHandler(maintenance, env.Validator)
+	widgetsChain := maintenance.Handle(http.HandlerFunc(env.Handler.CreateWidget))
+
+	admin := &models.User{ID: env.UserID, Role: models.RoleAdmin}
+	toggle := func(user *models.User, enabled bool) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"enabled":%t}`, enabled)
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/maintenance", strings.NewReader(body))
+		req = req.WithContext(auth.SetUserInContext(req.Context(), user))
+		w := httptest.NewRecorder()
+		adminHandler.Maintenance(w, req)
+		return w
+	}
+	createWidget := func() *httptest.ResponseRecorder {
+		body := `{"type":"lead-form","name":"Maintenance Test","isVisible":true,"config":{}}`
+		req := httptest.NewRequest(http.MethodPost, "/widgets", strings.NewReader(body))
+		req = req.WithContext(auth.SetUserInContext(req.Context(), admin))
+		w := httptest.NewRecorder()
+		widgetsChain.ServeHTTP(w, req)
+		return w
+	}
+
+	// Non-admin users cannot toggle maintenance mode
+	if w := toggle(&models.User{ID: env.UserID}, true); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status %d for non-admin, got %d: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+	if maintenance.Enabled(context.Background()) {
+		t.Fatal("Expected maintenance mode to stay disabled")
+	}
+
+	// Admin enables maintenance mode, writes are rejected
+	w := toggle(admin, true)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Data models.MaintenanceStatus `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !response.Data.Enabled || response.Data.RetryAfter != 60 {
+		t.Errorf("Unexpected maintenance status: %+v", response.Data)
+	}
+	if w := createWidget(); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d during maintenance, got %d: %s", http.StatusServiceUnavailable, w.Code, w.Body.String())
+	}
+
+	// Admin disables maintenance mode, writes work again
+	if w := toggle(admin, false); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := createWidget(); w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d after maintenance, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+}
+
+func TestRedactExpiredPII_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	widget := &models.Widget{
+		ID:        "pii-widget",
+		OwnerID:   env.UserID,
+		Name:      "PII Form",
+		Type:      "lead-form",
+		IsVisible: true,
+		Config: map[string]interface{}{
+			models.WidgetConfigPIIKey: map[string]interface{}{
+				"fields":         []interface{}{"email"},
+				"retention_days": float64(30),
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := env.WidgetRepo.Create(ctx, widget); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+
+	oldCreatedAt := time.Now().AddDate(0, 0, -31).Truncate(time.Second)
+	old, err := env.WidgetService.ImportSubmission(ctx, widget, models.ImportSubmissionRequest{
+		Data:      map[string]interface{}{"email": "old@example.com", "source": "ads"},
+		CreatedAt: &oldCreatedAt,
+	})
+	if err != nil {
+		t.Fatalf("Failed to import old submission: %v", err)
+	}
+	recent, err := env.WidgetService.ImportSubmission(ctx, widget, models.ImportSubmissionRequest{
+		Data: map[string]interface{}{"email": "new@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to import recent submission: %v", err)
+	}
+
+	redacted, err := env.WidgetService.RedactExpiredPII(ctx)
+	if err != nil {
+		t.Fatalf("Failed to redact PII: %v", err)
+	}
+	if redacted != 1 {
+		t.Errorf("Expected 1 redacted submission, got %d", redacted)
+	}
+
+	submissions, total, err := env.WidgetService.GetWidgetSubmissions(ctx, widget.ID, env.UserID, models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatalf("Failed to get submissions: %v", err)
+	}
+	if total != 2 || len(submissions) != 2 {
+		t.Fatalf("Expected both submissions to remain, got %d of %d", len(submissions), total)
+	}
+	for _, submission := range submissions {
+		switch submission.ID {
+		case old.ID:
+			if submission.Data["email"] != "" || !submission.PIIRedacted {
+				t.Errorf("Expected old submission email to be redacted, got %+v", submission)
+			}
+			if submission.Data["source"] != "ads" {
+				t.Errorf("Expected non-PII fields to be kept, got %v", submission.Data["source"])
+			}
+			if !submission.CreatedAt.Equal(oldCreatedAt) {
+				t.Errorf("Expected created_at %v to be kept, got %v", oldCreatedAt, submission.CreatedAt)
+			}
+		case recent.ID:
+			if submission.Data["email"] != "new@example.com" || submission.PIIRedacted {
+				t.Errorf("Expected recent submission to be untouched, got %+v", submission)
+			}
+		}
+	}
+
+	stats, err := env.StatsRepo.GetWidgetStats(ctx, widget.ID)
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Submits != 2 {
+		t.Errorf("Expected redacted submission to stay counted, got %d submits", stats.Submits)
+	}
+
+	// Already processed submissions are not scanned again
+	if redacted, err := env.WidgetService.RedactExpiredPII(ctx); err != nil || redacted != 0 {
+		t.Errorf("Expected nothing to redact on second run, got %d (%v)", redacted, err)
+	}
+}
+
+func TestSubmitWidget_Integration_RequiredHeaders(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+
+	widget := &models.Widget{
+		ID:        "widget-1",
+		OwnerID:   env.UserID,
+		Name:      "Lead Form",
+		Type:      "lead-form",
+		IsVisible: true,
+		Config: map[string]interface{}{
+			models.WidgetConfigRequiredHeadersKey: map[string]interface{}{
+				"X-Widget-Token": "s3cret",
+				"Origin":         "",
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := env.WidgetRepo.Create(context.Background(), widget); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		headers         map[string]string
+		expectedStatus  int
+		expectedMissing []string
+	}{
+		{name: "all headers present", headers: map[string]string{"X-Widget-Token": "s3cret", "Origin": "https://example.com"}, expectedStatus: http.StatusCreated},
+		{name: "no headers", expectedStatus: http.StatusForbidden, expectedMissing: []string{"Origin", "X-Widget-Token"}},
+		{name: "wrong token", headers: map[string]string{"X-Widget-Token": "guess", "Origin": "https://example.com"}, expectedStatus: http.StatusForbidden, expectedMissing: []string{"X-Widget-Token"}},
+		{name: "missing origin", headers: map[string]string{"X-Widget-Token": "s3cret"}, expectedStatus: http.StatusForbidden, expectedMissing: []string{"Origin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]interface{}{"data": map[string]interface{}{"email": "a@example.com"}})
+			req := httptest.NewRequest("POST", "/widgets/widget-1/submit", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+
+			publicHandler.SubmitWidget(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedMissing == nil {
+				return
+			}
+
+			var response models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Error != "Missing required headers" {
+				t.Errorf("Expected missing headers error, got %q", response.Error)
+			}
+			if got := fmt.Sprint(response.Details); got != fmt.Sprint(tt.expectedMissing) {
+				t.Errorf("Expected missing headers %v, got %v", tt.expectedMissing, got)
+			}
+		})
+	}
+
+	// Widgets without the setting accept submissions without extra headers
+	env.createTestWidget("widget-2", "Open Form", "lead-form", true, time.Now())
+	body, _ := json.Marshal(map[string]interface{}{"data": map[string]interface{}{"email": "a@example.com"}})
+	req := httptest.NewRequest("POST", "/widgets/widget-2/submit", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	publicHandler.SubmitWidget(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+}
+
+func TestSubmitWidget_Integration_Acknowledgement(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+
+	configured := &models.Widget{
+		ID:        "widget-ack",
+		OwnerID:   env.UserID,
+		Name:      "Lead Form",
+		Type:      "lead-form",
+		IsVisible: true,
+		Config: map[string]interface{}{
+			models.WidgetConfigAcknowledgementKey: map[string]interface{}{
+				"message":      "Thanks, we'll call you back!",
+				"redirect_url": "https://example.com/thanks",
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := env.WidgetRepo.Create(context.Background(), configured); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+	env.createTestWidget("widget-default", "Plain Form", "lead-form", true, time.Now())
+
+	tests := []struct {
+		name     string
+		widgetID string
+		expected models.Acknowledgement
+	}{
+		{name: "configured", widgetID: "widget-ack", expected: models.Acknowledgement{Message: "Thanks, we'll call you back!", RedirectURL: "https://example.com/thanks"}},
+		{name: "default", widgetID: "widget-default", expected: models.Acknowledgement{Message: models.DefaultAcknowledgementMessage}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]interface{}{"data": map[string]interface{}{"email": "a@example.com"}})
+			req := httptest.NewRequest("POST", "/widgets/"+tt.widgetID+"/submit", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			publicHandler.SubmitWidget(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+			}
+
+			var response struct {
+				Data models.Submission `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Data.Acknowledgement == nil || *response.Data.Acknowledgement != tt.expected {
+				t.Errorf("Expected acknowledgement %+v, got %+v", tt.expected, response.Data.Acknowledgement)
+			}
+
+			// The acknowledgement is not stored with the submission
+			stored, _, err := env.WidgetService.GetWidgetSubmissions(context.Background(), tt.widgetID, env.UserID, models.PaginationOptions{Page: 1, PerPage: 10})
+			if err != nil || len(stored) != 1 {
+				t.Fatalf("Expected one stored submission, got %d (%v)", len(stored), err)
+			}
+			if stored[0].Acknowledgement != nil {
+				t.Errorf("Expected stored submission without acknowledgement, got %+v", stored[0].Acknowledgement)
+			}
+		})
+	}
+}
+
+func TestCreateWidget_Integration_WidgetLimitConcurrent(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+
+	const maxWidgets = 3
+	const attempts = 12
+	env.WidgetService.SetWidgetLimit(maxWidgets, storage.NewRedisLockRepository(env.RedisClient), 5*time.Second)
+
+	codes := make([]int, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := []byte(fmt.Sprintf(`{"type":"lead-form","name":"Form %d","isVisible":true,"config":{}}`, i))
+			w := httptest.NewRecorder()
+			env.Handler.CreateWidget(w, env.makeAuthenticatedRequest("POST", "/api/v1/widgets", body))
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	created, limited := 0, 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusForbidden:
+			limited++
+		default:
+			t.Errorf("Unexpected status %d", code)
+		}
+	}
+	if created != maxWidgets || limited != attempts-maxWidgets {
+		t.Errorf("Expected %d created and %d limited, got %d and %d", maxWidgets, attempts-maxWidgets, created, limited)
+	}
+
+	_, total, err := env.WidgetRepo.GetByUserID(context.Background(), env.UserID, models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatalf("Failed to list widgets: %v", err)
+	}
+	if total != maxWidgets {
+		t.Errorf("Expected %d stored widgets, got %d", maxWidgets, total)
+	}
+
+	if env.Redis.Exists(storage.GenerateUserLockKey(env.UserID)) {
+		t.Error("Expected user lock to be released")
+	}
+}
+
+func TestGetWidgetStatsSeries_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	env.Redis.Set(storage.GenerateDailySubmitsKey("widget-1", today.AddDate(0, 0, -2).Format("2006-01-02")), "3")
+	env.Redis.Set(storage.GenerateDailySubmitsKey("widget-1", today.Format("2006-01-02")), "5")
+	env.Redis.Set(storage.GenerateDailyViewsKey("widget-1", today.Format("2006-01-02")), "40")
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := env.makeAuthenticatedRequest("GET", "/widgets/widget-1/stats/timeseries?"+query, nil)
+		w := httptest.NewRecorder()
+		env.Handler.GetWidgetStatsSeries(w, req)
+		return w
+	}
+
+	t.Run("daily buckets with gaps filled", func(t *testing.T) {
+		from := url.QueryEscape(today.AddDate(0, 0, -3).Format(time.RFC3339))
+		w := get("metric=submits&from=" + from + "&to=" + url.QueryEscape(now.Format(time.RFC3339)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Data []models.DailyStat `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		var expected []models.DailyStat
+		for i, value := range []int64{0, 3, 0, 5} {
+			expected = append(expected, models.DailyStat{Date: today.AddDate(0, 0, i-3).Format("2006-01-02"), Value: value})
+		}
+		if !reflect.DeepEqual(response.Data, expected) {
+			t.Errorf("Expected series %v, got %v", expected, response.Data)
+		}
+	})
+
+	t.Run("defaults to views over the retention window", func(t *testing.T) {
+		w := get("")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Data []models.DailyStat `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if days := env.WidgetService.DailyStatsDays(); len(response.Data) != days {
+			t.Fatalf("Expected %d days, got %d", days, len(response.Data))
+		}
+		if last := response.Data[len(response.Data)-1]; last.Date != today.Format("2006-01-02") || last.Value != 40 {
+			t.Errorf("Expected today's views last, got %+v", last)
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, query := range []string{
+			"metric=clicks",
+			"from=2024-03-02T00:00:00Z&to=2024-03-01T00:00:00Z",
+			"from=2023-01-01T00:00:00Z&to=2024-03-01T00:00:00Z",
+			"from=yesterday",
+		} {
+			if w := get(query); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %q, got %d: %s", http.StatusBadRequest, query, w.Code, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("unknown widget", func(t *testing.T) {
+		req := env.makeAuthenticatedRequest("GET", "/widgets/missing-widget/stats/timeseries", nil)
+		w := httptest.NewRecorder()
+		env.Handler.GetWidgetStatsSeries(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusNotFound, w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestExportWidgetSubmissions_Integration_ContentDisposition(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+
+	tests := []struct {
+		name     string
+		widget   string
+		expected string
+	}{
+		{
+			name:     "ascii name",
+			widget:   "Contact Form",
+			expected: `attachment; filename="Contact_Form_submissions_` + time.Now().Format("2006-01-02") + `.json"`,
+		},
+		{
+			name:   "slashes and cyrillic",
+			widget: "Заявки/сайт",
+			expected: `attachment; filename="____________submissions_` + time.Now().Format("2006-01-02") + `.json"; ` +
+				`filename*=UTF-8''%D0%97%D0%B0%D1%8F%D0%B2%D0%BA%D0%B8_%D1%81%D0%B0%D0%B9%D1%82_submissions_` + time.Now().Format("2006-01-02") + `.json`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			widgetID := fmt.Sprintf("export-widget-%d", i)
+			env.createTestWidget(widgetID, tt.widget, "lead-form", true, time.Now())
+
+			req := env.makeAuthenticatedRequest("GET", "/widgets/"+widgetID+"/export?format=json", nil)
+			w := httptest.NewRecorder()
+			env.Handler.ExportWidgetSubmissions(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Disposition"); got != tt.expected {
+				t.Errorf("Expected Content-Disposition %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExportWidgetSubmissions_Integration_FieldSelection(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	submissionRepo := storage.NewRedisSubmissionRepository(env.RedisClient)
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+	if err := submissionRepo.Create(context.Background(), &models.Submission{
+		ID:        "submission-1",
+		WidgetID:  "widget-1",
+		Data:      map[string]interface{}{"name": "John", "email": "john@example.com", "message": "Hi"},
+		CreatedAt: time.Now(),
+		TTL:       time.Hour,
+	}); err != nil {
+		t.Fatalf("Failed to create submission: %v", err)
+	}
+
+	req := env.makeAuthenticatedRequest("GET", "/widgets/widget-1/export?format=csv&fields=email,phone,name", nil)
+	w := httptest.NewRecorder()
+	env.Handler.ExportWidgetSubmissions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Expected header and one row, got %v (%v)", records, err)
+	}
+	if got := strings.Join(records[0], ","); got != "ID,Created At,email,phone,name" {
+		t.Errorf("Expected selected columns in order, got %q", got)
+	}
+	if got := records[1]; got[2] != "john@example.com" || got[3] != "" || got[4] != "John" {
+		t.Errorf("Expected selected values with an empty unknown field, got %v", got)
+	}
+}
+
+func TestExportWidgetSubmissions_Integration_RangeLimit(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.Handler.exportService.SetRangeLimit(30*24*time.Hour, 0)
+	env.createTestWidget("export-range", "Range Form", "lead-form", true, time.Now())
+
+	export := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		env.Handler.ExportWidgetSubmissions(w, env.makeAuthenticatedRequest("GET", "/widgets/export-range/export?format=json&"+query, nil))
+		return w
+	}
+
+	w := export("from=2024-01-01T00:00:00Z&to=2024-03-01T00:00:00Z")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for an over-span range, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "maximum of 30 days") {
+		t.Errorf("Expected the max span in the error, got %s", w.Body.String())
+	}
+
+	if w := export("from=2024-01-01T00:00:00Z&to=2024-01-31T00:00:00Z"); w.Code != http.StatusOK {
+		t.Errorf("Expected a range within the limit to export, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := export(""); w.Code != http.StatusOK {
+		t.Errorf("Expected an unbounded export without a submission threshold, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetWidgets_Integration_ByIDs(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, now.Add(-2*time.Hour))
+	env.createTestWidget("widget-2", "Banner", "banner", true, now.Add(-1*time.Hour))
+	env.createTestWidget("widget-3", "Quiz", "quiz", true, now)
+
+	foreign := &models.Widget{
+		ID:        "foreign-widget",
+		OwnerID:   "other-user",
+		Name:      "Foreign Widget",
+		Type:      "lead-form",
+		IsVisible: true,
+		Config:    map[string]interface{}{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := env.WidgetRepo.Create(ctx, foreign); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+
+	req := env.makeAuthenticatedRequest("GET", "/api/v1/widgets?ids=widget-2,foreign-widget,missing-widget,widget-1,widget-2", nil)
+	w := httptest.NewRecorder()
+	env.Handler.GetWidgets(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Widgets []*models.Widget `json:"widgets"`
+		Meta    *models.Meta     `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Meta != nil {
+		t.Error("Expected no pagination meta for ID lookup")
+	}
+
+	var ids []string
+	for _, widget := range response.Widgets {
+		ids = append(ids, widget.ID)
+	}
+	if strings.Join(ids, ",") != "widget-2,widget-1" {
+		t.Errorf("Expected owned widgets [widget-2 widget-1] in request order, got %v", ids)
+	}
+
+	// Only foreign and missing IDs
+	req = env.makeAuthenticatedRequest("GET", "/api/v1/widgets?ids=foreign-widget,missing-widget", nil)
+	w = httptest.NewRecorder()
+	env.Handler.GetWidgets(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"widgets":[]`) {
+		t.Errorf("Expected empty widgets list, got %s", w.Body.String())
+	}
+
+	// Empty and oversized ID lists
+	tooMany := make([]string, models.MaxBatchGetWidgetIDs+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("widget-%d", i)
+	}
+	for _, query := range []string{"ids=", "ids=" + strings.Join(tooMany, ",")} {
+		req = env.makeAuthenticatedRequest("GET", "/api/v1/widgets?"+query, nil)
+		w = httptest.NewRecorder()
+		env.Handler.GetWidgets(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestSubmissions_Integration_FieldEncryption(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	fieldCipher, err := storage.NewFieldCipher("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatalf("Failed to create field cipher: %v", err)
+	}
+	submissionRepo := storage.NewRedisSubmissionRepository(env.RedisClient)
+	submissionRepo.SetFieldCipher(fieldCipher)
+	widgetService := services.NewWidgetService(env.WidgetRepo, submissionRepo, env.StatsRepo, services.TTLConfig{FreeDays: 30})
+	handler := NewWidgetHandler(widgetService, services.NewExportService(submissionRepo, env.WidgetRepo), services.NewImportService(widgetService, env.Validator), env.Validator)
+
+	widget := env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+	widget.Config = map[string]interface{}{models.WidgetConfigEncryptedFieldsKey: []interface{}{"email"}}
+	if err := env.WidgetRepo.Update(ctx, widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+
+	var submissionIDs []string
+	for _, data := range []map[string]interface{}{
+		{"email": "us@example.com", "country": "US"},
+		{"email": "de@example.com", "country": "DE"},
+	} {
+		submission, err := widgetService.SubmitWidget(ctx, "widget-1", models.SubmissionRequest{Data: data})
+		if err != nil {
+			t.Fatalf("Failed to submit widget: %v", err)
+		}
+		submissionIDs = append(submissionIDs, submission.ID)
+	}
+
+	// At rest only the designated field is encrypted
+	raw := env.Redis.HGet(storage.GenerateSubmissionKey("widget-1", submissionIDs[0]), "data")
+	var stored map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &stored); err != nil {
+		t.Fatalf("Failed to decode stored data: %v", err)
+	}
+	if email, _ := stored["email"].(string); email == "us@example.com" || !strings.HasPrefix(email, "enc:") {
+		t.Errorf("Expected email to be stored encrypted, got %q", email)
+	}
+	if stored["country"] != "US" {
+		t.Errorf("Expected country to be stored in plaintext, got %v", stored["country"])
+	}
+
+	// Reads decrypt transparently
+	submissions, _, err := widgetService.GetWidgetSubmissions(ctx, "widget-1", env.UserID, models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatalf("Failed to get submissions: %v", err)
+	}
+	for _, submission := range submissions {
+		if email, _ := submission.Data["email"].(string); !strings.HasSuffix(email, "@example.com") {
+			t.Errorf("Expected decrypted email, got %q", email)
+		}
+	}
+
+	// Exports filter on the plaintext field and decrypt the PII field
+	req := env.makeAuthenticatedRequest("GET", "/widgets/widget-1/export?format=csv&field=country:US", nil)
+	w := httptest.NewRecorder()
+	handler.ExportWidgetSubmissions(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "us@example.com") || strings.Contains(body, "de@example.com") || strings.Contains(body, "enc:") {
+		t.Errorf("Expected export of the US submission with decrypted email, got %s", body)
+	}
+}
+
+func TestSubmissions_Integration_FieldDefaults(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+	env.WidgetService.SetReservedFieldNames(models.DefaultReservedFieldNames, models.ReservedFieldsReject)
+
+	widget := env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+	widget.Config = map[string]interface{}{
+		models.WidgetConfigFieldDefaultsKey: map[string]interface{}{
+			"source":   "web",
+			"consent":  false,
+			"quantity": float64(1),
+			"tags":     []interface{}{"lead"},
+			"extra":    map[string]interface{}{"nested": true}, // Not a field value, skipped
+			"id":       "reserved",                             // Reserved name, skipped
+		},
+	}
+	if err := env.WidgetRepo.Update(ctx, widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		data     map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name: "missing fields get defaults",
+			data: map[string]interface{}{"email": "a@example.com"},
+			expected: map[string]interface{}{
+				"email": "a@example.com", "source": "web", "consent": false, "quantity": float64(1), "tags": []interface{}{"lead"},
+			},
+		},
+		{
+			name: "empty fields get defaults",
+			data: map[string]interface{}{"source": "  ", "tags": []interface{}{}, "quantity": nil},
+			expected: map[string]interface{}{
+				"source": "web", "consent": false, "quantity": float64(1), "tags": []interface{}{"lead"},
+			},
+		},
+		{
+			name: "provided values are untouched",
+			data: map[string]interface{}{"source": "ads", "consent": true, "quantity": float64(0), "tags": []interface{}{"vip"}},
+			expected: map[string]interface{}{
+				"source": "ads", "consent": true, "quantity": float64(0), "tags": []interface{}{"vip"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submission, err := env.WidgetService.SubmitWidget(ctx, "widget-1", models.SubmissionRequest{Data: tt.data})
+			if err != nil {
+				t.Fatalf("Failed to submit widget: %v", err)
+			}
+
+			stored, err := storage.NewRedisSubmissionRepository(env.RedisClient).GetByID(ctx, "widget-1", submission.ID)
+			if err != nil {
+				t.Fatalf("Failed to get submission: %v", err)
+			}
+			if !reflect.DeepEqual(stored.Data, tt.expected) {
+				t.Errorf("Expected data %v, got %v", tt.expected, stored.Data)
+			}
+		})
+	}
+}
+
+func TestCreateWidget_Integration_NameRules(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.WidgetService.SetWidgetLimit(0, storage.NewRedisLockRepository(env.RedisClient), 5*time.Second)
+
+	createWidget := func(name string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"type": "lead-form", "name": name, "isVisible": true, "config": map[string]interface{}{}})
+		w := httptest.NewRecorder()
+		env.Handler.CreateWidget(w, env.makeAuthenticatedRequest("POST", "/api/v1/widgets", body))
+		return w
+	}
+	renameWidget := func(widgetID, name string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"name": name})
+		w := httptest.NewRecorder()
+		env.Handler.UpdateWidget(w, env.makeAuthenticatedRequest("POST", "/widgets/"+widgetID, body))
+		return w
+	}
+	expectNameError := func(w *httptest.ResponseRecorder, message string) {
+		t.Helper()
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+		var response struct {
+			Details []*models.FieldError `json:"details"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Details) != 1 || response.Details[0].Field != "name" || !strings.Contains(response.Details[0].Message, message) {
+			t.Errorf("Expected name error %q, got %s", message, w.Body.String())
+		}
+	}
+
+	// Duplicates are allowed until uniqueness is enabled
+	env.WidgetService.SetWidgetNameRules(10, false)
+	if w := createWidget("Contacts"); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if w := createWidget("Contacts"); w.Code != http.StatusCreated {
+		t.Fatalf("Expected duplicate to be allowed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Over-length names, counted in characters
+	expectNameError(createWidget("Обратная связь"), "less than or equal to 10")
+	if w := createWidget("Заявки"); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	env.WidgetService.SetWidgetNameRules(10, true)
+	expectNameError(createWidget("  заявки "), "already used")
+
+	w := createWidget("Banner")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var banner models.Widget
+	if err := json.Unmarshal(w.Body.Bytes(), &banner); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	expectNameError(renameWidget(banner.ID, "ЗАЯВКИ"), "already used")
+	expectNameError(renameWidget(banner.ID, "Banner for the summer sale"), "less than or equal to 10")
+	if w := renameWidget(banner.ID, "BANNER"); w.Code != http.StatusOK {
+		t.Errorf("Expected renaming to own name in other case to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Names are released on rename and delete
+	if w := renameWidget(banner.ID, "Promo"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := createWidget("Banner"); w.Code != http.StatusCreated {
+		t.Errorf("Expected released name to be reusable, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := env.WidgetService.DeleteWidget(context.Background(), banner.ID, env.UserID); err != nil {
+		t.Fatalf("Failed to delete widget: %v", err)
+	}
+	if w := createWidget("Promo"); w.Code != http.StatusCreated {
+		t.Errorf("Expected name of deleted widget to be reusable, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestExportJobs_Integration_ListAndCancel(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobRepo := storage.NewRedisExportJobRepository(env.RedisClient, time.Hour)
+	jobService := services.NewExportJobService(jobRepo, env.WidgetRepo, services.NewExportService(storage.NewRedisSubmissionRepository(env.RedisClient), env.WidgetRepo), 10)
+	env.Handler.SetExportJobService(jobService)
+
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+	if _, err := env.WidgetService.SubmitWidget(ctx, "widget-1", models.SubmissionRequest{Data: map[string]interface{}{"email": "a@example.com"}}); err != nil {
+		t.Fatalf("Failed to submit widget: %v", err)
+	}
+
+	base := time.Now().Add(-10 * time.Minute)
+	for i, status := range []string{models.ExportJobCompleted, models.ExportJobFailed, models.ExportJobQueued, models.ExportJobQueued} {
+		job := &models.ExportJob{
+			ID:        fmt.Sprintf("job-%d", i+1),
+			WidgetID:  "widget-1",
+			OwnerID:   env.UserID,
+			Format:    "json",
+			Status:    status,
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+			UpdatedAt: base,
+		}
+		if err := jobRepo.Create(ctx, job); err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+	}
+
+	listJobs := func(query string) ([]models.ExportJob, models.Meta) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		env.Handler.ExportJobs(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-1/export/jobs"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %q, got %d: %s", http.StatusOK, query, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data []models.ExportJob `json:"data"`
+			Meta models.Meta        `json:"meta"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Data, response.Meta
+	}
+
+	jobs, meta := listJobs("")
+	if meta.Total != 4 || len(jobs) != 4 || jobs[0].ID != "job-4" {
+		t.Errorf("Expected 4 jobs newest first, got total %d: %+v", meta.Total, jobs)
+	}
+
+	jobs, meta = listJobs("?status=queued&per_page=1")
+	if meta.Total != 2 || !meta.HasMore || len(jobs) != 1 || jobs[0].ID != "job-4" {
+		t.Errorf("Expected first of 2 queued jobs, got total %d has_more %v: %+v", meta.Total, meta.HasMore, jobs)
+	}
+	jobs, meta = listJobs("?status=queued&per_page=1&page=2")
+	if meta.HasMore || len(jobs) != 1 || jobs[0].ID != "job-3" {
+		t.Errorf("Expected second queued job on page 2, got has_more %v: %+v", meta.HasMore, jobs)
+	}
+
+	w := httptest.NewRecorder()
+	env.Handler.ExportJobs(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-1/export/jobs?status=unknown", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for unknown status, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// Cancelling a queued job; a finished or missing one can't be cancelled
+	cancelJob := func(jobID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		env.Handler.CancelExportJob(w, env.makeAuthenticatedRequest("POST", "/widgets/widget-1/export/jobs/"+jobID+"/cancel", nil))
+		return w
+	}
+	if w := cancelJob("job-3"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"cancelled"`) {
+		t.Errorf("Expected queued job to be cancelled, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := cancelJob("job-3"); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d cancelling twice, got %d", http.StatusConflict, w.Code)
+	}
+	if w := cancelJob("job-1"); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d cancelling a completed job, got %d", http.StatusConflict, w.Code)
+	}
+	if w := cancelJob("missing"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a missing job, got %d", http.StatusNotFound, w.Code)
+	}
+	if jobs, meta := listJobs("?status=cancelled"); meta.Total != 1 || jobs[0].ID != "job-3" {
+		t.Errorf("Expected job-3 to be listed as cancelled, got %+v", jobs)
+	}
+
+	// Jobs of other users' widgets are hidden
+	env.createTestWidget("foreign-widget", "Foreign", "lead-form", true, time.Now())
+	foreign, _ := env.WidgetRepo.GetByID(ctx, "foreign-widget")
+	foreign.OwnerID = "other-user"
+	if err := env.WidgetRepo.Update(ctx, foreign); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+	w = httptest.NewRecorder()
+	env.Handler.ExportJobs(w, env.makeAuthenticatedRequest("GET", "/widgets/foreign-widget/export/jobs", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a foreign widget, got %d", http.StatusNotFound, w.Code)
+	}
+
+	// A job cancelled while queued is skipped by the worker
+	createJob := func() models.ExportJob {
+		t.Helper()
+		w := httptest.NewRecorder()
+		env.Handler.ExportJobs(w, env.makeAuthenticatedRequest("POST", "/widgets/widget-1/export/jobs?format=csv", nil))
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		var job models.ExportJob
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to decode job: %v", err)
+		}
+		return job
+	}
+	skipped := createJob()
+	if w := cancelJob(skipped.ID); w.Code != http.StatusOK {
+		t.Fatalf("Expected queued job to be cancelled, got %d: %s", w.Code, w.Body.String())
+	}
+	processed := createJob()
+	jobService.Start(ctx, 1)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := jobRepo.GetByID(ctx, "widget-1", processed.ID)
+		if err != nil {
+			t.Fatalf("Failed to get job: %v", err)
+		}
+		if job.IsFinished() {
+			if job.Status != models.ExportJobCompleted {
+				t.Fatalf("Expected job to complete, got %s (%s)", job.Status, job.Error)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for export job, status %s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if job, _ := jobRepo.GetByID(ctx, "widget-1", skipped.ID); job.Status != models.ExportJobCancelled {
+		t.Errorf("Expected cancelled job to stay cancelled, got %s", job.Status)
+	}
+
+	w = httptest.NewRecorder()
+	env.Handler.DownloadExportJob(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-1/export/jobs/"+processed.ID+"/download", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "a@example.com") {
+		t.Errorf("Expected export file download, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	env.Handler.DownloadExportJob(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-1/export/jobs/"+skipped.ID+"/download", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d downloading a cancelled job, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestExportWidgetSubmissions_Integration_PreferredFormat(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.WidgetService.SetPreferencesRepository(storage.NewRedisPreferencesRepository(env.RedisClient))
+	userHandler := NewUserHandler(env.WidgetService, env.Validator)
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+	updatePreferences := func(userID, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		userHandler.UserPreferences(w, env.makeAuthenticatedRequest("PUT", "/users/"+userID+"/preferences", []byte(body)))
+		return w
+	}
+	if w := updatePreferences(env.UserID, `{"export_format":"pdf"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown format, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := updatePreferences("other-user", `{"export_format":"csv"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for another user, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := updatePreferences(env.UserID, `{"export_format":"CSV"}`); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"export_format":"csv"`) {
+		t.Fatalf("Expected preference to be stored, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		accept      string
+		contentType string
+	}{
+		{"preference is the default", "", "", "text/csv"},
+		{"explicit param wins", "?format=json", "", "application/json"},
+		{"accept header wins", "", "application/json", "application/json"},
+		{"explicit param wins over accept header", "?format=csv", "application/json", "text/csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := env.makeAuthenticatedRequest("GET", "/widgets/widget-1/export"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			env.Handler.ExportWidgetSubmissions(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tt.contentType {
+				t.Errorf("Expected Content-Type %q, got %q", tt.contentType, contentType)
+			}
+		})
+	}
+
+	// Clearing the preference restores the json default
+	if w := updatePreferences(env.UserID, `{"export_format":""}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected preference to be cleared, got %d: %s", w.Code, w.Body.String())
+	}
+	w := httptest.NewRecorder()
+	env.Handler.ExportWidgetSubmissions(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-1/export", nil))
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected json export without a preference, got %q", contentType)
+	}
+}
+
+func TestSubmitWidget_Integration_ClientTimestamps(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.WidgetService.SetClientTimeWindow(72*time.Hour, 5*time.Minute)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+
+	allowed := env.createTestWidget("widget-offline", "Offline Form", "lead-form", true, time.Now())
+	allowed.Config = map[string]interface{}{models.WidgetConfigClientTimestampsKey: true}
+	if err := env.WidgetRepo.Update(context.Background(), allowed); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+	env.createTestWidget("widget-online", "Online Form", "lead-form", true, time.Now())
+
+	now := time.Now().Truncate(time.Second)
+	tests := []struct {
+		name       string
+		widgetID   string
+		occurredAt time.Time
+		status     int
+		clientTime bool
+	}{
+		{"accepted", "widget-offline", now.Add(-2 * time.Hour), http.StatusCreated, true},
+		{"small clock skew", "widget-offline", now.Add(time.Minute), http.StatusCreated, true},
+		{"too far in the past", "widget-offline", now.Add(-100 * time.Hour), http.StatusBadRequest, false},
+		{"too far in the future", "widget-offline", now.Add(time.Hour), http.StatusBadRequest, false},
+		{"widget doesn't allow it", "widget-online", now.Add(-2 * time.Hour), http.StatusCreated, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]interface{}{
+				"data":        map[string]interface{}{"email": "a@example.com"},
+				"occurred_at": tt.occurredAt.Format(time.RFC3339),
+			})
+			req := httptest.NewRequest("POST", "/widgets/"+tt.widgetID+"/submit", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			publicHandler.SubmitWidget(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status != http.StatusCreated {
+				if !strings.Contains(w.Body.String(), "occurred_at") {
+					t.Errorf("Expected error about occurred_at, got %s", w.Body.String())
+				}
+				return
+			}
+
+			var response struct {
+				Data models.Submission `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			stored, err := storage.NewRedisSubmissionRepository(env.RedisClient).GetByID(context.Background(), tt.widgetID, response.Data.ID)
+			if err != nil {
+				t.Fatalf("Failed to get submission: %v", err)
+			}
+
+			if tt.clientTime {
+				if !stored.CreatedAt.Equal(tt.occurredAt) {
+					t.Errorf("Expected created_at %v, got %v", tt.occurredAt, stored.CreatedAt)
+				}
+				if stored.ReceivedAt == nil || stored.ReceivedAt.Before(now) {
+					t.Errorf("Expected received_at to record the server time, got %v", stored.ReceivedAt)
+				}
+			} else {
+				if stored.CreatedAt.Before(now) || stored.ReceivedAt != nil {
+					t.Errorf("Expected server time without received_at, got created_at %v received_at %v", stored.CreatedAt, stored.ReceivedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestSubmitWidget_Integration_MaxSubmissionsEvictsOldest(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.WidgetService.SetClientTimeWindow(72*time.Hour, 5*time.Minute)
+	ctx := context.Background()
+
+	widget := env.createTestWidget("widget-1", "Feedback", "lead-form", true, time.Now())
+	widget.Config = map[string]interface{}{
+		models.WidgetConfigMaxSubmissionsKey:   float64(3),
+		models.WidgetConfigClientTimestampsKey: true, // Distinct creation times keep the order deterministic
+	}
+	if err := env.WidgetRepo.Update(ctx, widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour)
+	var submissionIDs []string
+	for i := 0; i < 5; i++ {
+		occurredAt := base.Add(time.Duration(i) * time.Minute)
+		submission, err := env.WidgetService.SubmitWidget(ctx, "widget-1", models.SubmissionRequest{
+			Data:       map[string]interface{}{"comment": fmt.Sprintf("comment %d", i)},
+			OccurredAt: &occurredAt,
+		})
+		if err != nil {
+			t.Fatalf("Failed to submit widget: %v", err)
+		}
+		submissionIDs = append(submissionIDs, submission.ID)
+
+		if count, _ := env.RedisClient.GetClient().ZCard(ctx, storage.GenerateWidgetSubmissionsKey("widget-1")).Result(); count > 3 {
+			t.Fatalf("Expected at most 3 stored submissions, got %d", count)
+		}
+	}
+
+	submissions, total, err := env.WidgetService.GetWidgetSubmissions(ctx, "widget-1", env.UserID, models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatalf("Failed to get submissions: %v", err)
+	}
+	if total != 3 || len(submissions) != 3 {
+		t.Fatalf("Expected 3 submissions, got total %d, %d loaded", total, len(submissions))
+	}
+	for i, submission := range submissions {
+		if expected := submissionIDs[4-i]; submission.ID != expected {
+			t.Errorf("Expected newest submissions to be kept, position %d has %s instead of %s", i, submission.ID, expected)
+		}
+	}
+
+	// Evicted submissions are deleted, not just unindexed
+	for _, submissionID := range submissionIDs[:2] {
+		if env.Redis.Exists(storage.GenerateSubmissionKey("widget-1", submissionID)) {
+			t.Errorf("Expected evicted submission %s to be deleted", submissionID)
+		}
+	}
+
+	// The submit counter drops with the evicted submissions
+	stats, err := env.StatsRepo.GetWidgetStats(ctx, "widget-1")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Submits != 3 {
+		t.Errorf("Expected 3 counted submits, got %d", stats.Submits)
+	}
+}
+
+func TestSubmitWidget_Integration_Dedupe(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	widget := env.createTestWidget("widget-1", "Contact", "lead-form", true, time.Now())
+	widget.Config = map[string]interface{}{models.WidgetConfigDedupeSecondsKey: float64(30)}
+	if err := env.WidgetRepo.Update(ctx, widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+	env.createTestWidget("widget-2", "No dedupe", "lead-form", true, time.Now())
+
+	submit := func(widgetID, email, ip string) string {
+		t.Helper()
+		submission, err := env.WidgetService.SubmitWidget(ctx, widgetID, models.SubmissionRequest{
+			Data:     map[string]interface{}{"email": email, "name": "Ann"},
+			ClientIP: ip,
+		})
+		if err != nil {
+			t.Fatalf("Failed to submit widget: %v", err)
+		}
+		return submission.ID
+	}
+	stored := func(widgetID string) (int64, int64) {
+		t.Helper()
+		count, _ := env.RedisClient.GetClient().ZCard(ctx, storage.GenerateWidgetSubmissionsKey(widgetID)).Result()
+		stats, err := env.StatsRepo.GetWidgetStats(ctx, widgetID)
+		if err != nil {
+			t.Fatalf("Failed to get stats: %v", err)
+		}
+		return count, stats.Submits
+	}
+
+	first := submit("widget-1", "a@example.com", "203.0.113.7")
+	if again := submit("widget-1", "a@example.com", "203.0.113.7"); again != first {
+		t.Errorf("Expected the duplicate to return submission %s, got %s", first, again)
+	}
+	if count, submits := stored("widget-1"); count != 1 || submits != 1 {
+		t.Errorf("Expected 1 stored and counted submission, got %d stored, %d counted", count, submits)
+	}
+
+	// Other data or another client is not a duplicate
+	if id := submit("widget-1", "b@example.com", "203.0.113.7"); id == first {
+		t.Error("Expected different data to create a new submission")
+	}
+	if id := submit("widget-1", "a@example.com", "198.51.100.1"); id == first {
+		t.Error("Expected another client to create a new submission")
+	}
+
+	// After the window the same data is stored again
+	env.Redis.FastForward(31 * time.Second)
+	if id := submit("widget-1", "a@example.com", "203.0.113.7"); id == first {
+		t.Error("Expected a new submission after the dedupe window")
+	}
+	if count, submits := stored("widget-1"); count != 4 || submits != 4 {
+		t.Errorf("Expected 4 stored and counted submissions, got %d stored, %d counted", count, submits)
+	}
+
+	// Widgets without a window store every submission
+	submit("widget-2", "a@example.com", "203.0.113.7")
+	submit("widget-2", "a@example.com", "203.0.113.7")
+	if count, submits := stored("widget-2"); count != 2 || submits != 2 {
+		t.Errorf("Expected 2 stored and counted submissions without dedupe, got %d stored, %d counted", count, submits)
+	}
+}
+
+func TestGetWidgetTypesOverview_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	env.createTestWidget("form-1", "Form 1", "lead-form", true, now)
+	env.createTestWidget("form-2", "Form 2", "lead-form", true, now)
+	env.createTestWidget("quiz-1", "Quiz", "quiz", true, now)
+	env.createTestWidget("banner-1", "Banner", "banner", true, now)
+
+	foreign := &models.Widget{ID: "foreign-1", OwnerID: "other-user", Name: "Foreign", Type: "survey", IsVisible: true, CreatedAt: now, UpdatedAt: now}
+	if err := env.WidgetRepo.Create(ctx, foreign); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+
+	submit := func(widgetID string, n int) {
+		for i := 0; i < n; i++ {
+			if _, err := env.WidgetService.SubmitWidget(ctx, widgetID, models.SubmissionRequest{Data: map[string]interface{}{"email": "a@example.com"}}); err != nil {
+				t.Fatalf("Failed to submit widget %s: %v", widgetID, err)
+			}
+		}
+	}
+	submit("form-1", 2)
+	submit("form-2", 3)
+	if err := env.WidgetService.RegisterWidgetEvent(ctx, "quiz-1", "view"); err != nil {
+		t.Fatalf("Failed to register event: %v", err)
+	}
+
+	req := env.makeAuthenticatedRequest("GET", "/api/v1/widgets/types/overview", nil)
+	w := httptest.NewRecorder()
+	env.Handler.GetWidgetTypesOverview(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data []models.WidgetTypeOverview `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	expected := []struct {
+		widgetType  string
+		count       int
+		submissions int64
+		active      bool
+	}{
+		{"banner", 1, 0, false},
+		{"lead-form", 2, 5, true},
+		{"quiz", 1, 0, true},
+	}
+	if len(response.Data) != len(expected) {
+		t.Fatalf("Expected %d types (foreign and unused types omitted), got %+v", len(expected), response.Data)
+	}
+	for i, tt := range expected {
+		overview := response.Data[i]
+		if overview.Type != tt.widgetType || overview.Count != tt.count || overview.TotalSubmissions != tt.submissions {
+			t.Errorf("Expected %s with %d widgets and %d submissions, got %+v", tt.widgetType, tt.count, tt.submissions, overview)
+		}
+		if (overview.LastActivity != nil) != tt.active {
+			t.Errorf("Expected %s last activity present=%v, got %v", tt.widgetType, tt.active, overview.LastActivity)
+		}
+	}
+}
+
+func TestGetWidgetTypeStats_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	getTypeStats := func() []models.TypeStats {
+		req := env.makeAuthenticatedRequest("GET", "/api/v1/widgets/type-stats", nil)
+		w := httptest.NewRecorder()
+		env.Handler.GetWidgetTypeStats(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Data []models.TypeStats `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Data == nil {
+			t.Fatalf("Expected data to be an array, got %s", w.Body.String())
+		}
+		return response.Data
+	}
+
+	t.Run("No widgets", func(t *testing.T) {
+		if stats := getTypeStats(); len(stats) != 0 {
+			t.Errorf("Expected no type stats, got %+v", stats)
+		}
+	})
+
+	t.Run("Mixed types", func(t *testing.T) {
+		now := time.Now()
+		env.createTestWidget("form-1", "Form 1", "lead-form", true, now)
+		env.createTestWidget("form-2", "Form 2", "lead-form", false, now)
+		env.createTestWidget("quiz-1", "Quiz", "quiz", true, now)
+		env.createTestWidget("banner-1", "Banner", "banner", true, now)
+
+		foreign := &models.Widget{ID: "foreign-1", OwnerID: "other-user", Name: "Foreign", Type: "quiz", IsVisible: true, CreatedAt: now, UpdatedAt: now}
+		if err := env.WidgetRepo.Create(ctx, foreign); err != nil {
+			t.Fatalf("Failed to create widget: %v", err)
+		}
+
+		expected := []models.TypeStats{
+			{Type: "lead-form", Count: 2},
+			{Type: "banner", Count: 1},
+			{Type: "quiz", Count: 1},
+		}
+		stats := getTypeStats()
+		if len(stats) != len(expected) {
+			t.Fatalf("Expected %d types (foreign and unused types omitted), got %+v", len(expected), stats)
+		}
+		for i, tt := range expected {
+			if stats[i] != tt {
+				t.Errorf("Expected %+v at %d, got %+v", tt, i, stats[i])
+			}
+		}
+	})
+}
+
+func TestJSONLimits_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.Validator.SetJSONLimits(8, 20)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+	env.createTestWidget("widget-limits", "Limits Form", "lead-form", true, time.Now())
+
+	t.Run("submission depth exceeded", func(t *testing.T) {
+		body := `{"data": {"a": ` + strings.Repeat("[", 10) + strings.Repeat("]", 10) + `}}`
+		req := httptest.NewRequest("POST", "/widgets/widget-limits/submit", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		publicHandler.SubmitWidget(w, req)
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status 422, got %d: %s", w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), "nesting depth") {
+			t.Errorf("Expected depth reason, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("config key count exceeded", func(t *testing.T) {
+		config := make(map[string]interface{})
+		for i := 0; i < 25; i++ {
+			config[fmt.Sprintf("key_%d", i)] = i
+		}
+		body, _ := json.Marshal(map[string]interface{}{"config": config})
+		req := env.makeAuthenticatedRequest("PUT", "/api/v1/widgets/widget-limits/config", body)
+		w := httptest.NewRecorder()
+
+		env.Handler.UpdateWidgetConfig(w, req)
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status 422, got %d: %s", w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), "key count") {
+			t.Errorf("Expected key count reason, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("within limits", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{"config": map[string]interface{}{"title": "Hi"}})
+		req := env.makeAuthenticatedRequest("PUT", "/api/v1/widgets/widget-limits/config", body)
+		w := httptest.NewRecorder()
+
+		env.Handler.UpdateWidgetConfig(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestSubmitWidget_Integration_WidgetVersionStamp(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+	env.createTestWidget("widget-versioned", "Versioned Form", "lead-form", true, time.Now())
+
+	submit := func(email string) {
+		body, _ := json.Marshal(map[string]interface{}{"data": map[string]interface{}{"email": email}})
+		req := httptest.NewRequest("POST", "/widgets/widget-versioned/submit", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		publicHandler.SubmitWidget(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	submit("before@example.com")
+
+	configBody, _ := json.Marshal(map[string]interface{}{"config": map[string]interface{}{"title": "New fields"}})
+	w := httptest.NewRecorder()
+	env.Handler.UpdateWidgetConfig(w, env.makeAuthenticatedRequest("PUT", "/api/v1/widgets/widget-versioned/config", configBody))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"version":2`) {
+		t.Errorf("Expected config update to bump the version, got %s", w.Body.String())
+	}
+
+	submit("after@example.com")
+
+	submissions, _, err := storage.NewRedisSubmissionRepository(env.RedisClient).GetByWidgetID(context.Background(), "widget-versioned", models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatalf("Failed to get submissions: %v", err)
+	}
+	versions := make(map[string]int)
+	for _, submission := range submissions {
+		versions[submission.Data["email"].(string)] = submission.WidgetVersion
+	}
+	if versions["before@example.com"] != 1 || versions["after@example.com"] != 2 {
+		t.Errorf("Expected version stamps 1 and 2, got %v", versions)
+	}
+
+	w = httptest.NewRecorder()
+	env.Handler.ExportWidgetSubmissions(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-versioned/export?format=csv", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "Widget Version") {
+		t.Errorf("Expected export to include the widget version column, got %s", w.Body.String())
+	}
+}
+
+func TestWidgetConfigHistory_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.WidgetService.SetConfigHistoryLimit(3)
+	env.createTestWidget("widget-history", "History Form", "lead-form", true, time.Now())
+
+	updateConfig := func(t *testing.T, title string) {
+		t.Helper()
+		body, _ := json.Marshal(map[string]interface{}{"config": map[string]interface{}{"title": title}})
+		w := httptest.NewRecorder()
+		env.Handler.UpdateWidgetConfig(w, env.makeAuthenticatedRequest("PUT", "/api/v1/widgets/widget-history/config", body))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	getHistory := func(t *testing.T) []models.WidgetConfigVersion {
+		t.Helper()
+		w := httptest.NewRecorder()
+		env.Handler.GetWidgetConfigHistory(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-history/config/history", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data []models.WidgetConfigVersion `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Data
+	}
+	versions := func(history []models.WidgetConfigVersion) []int {
+		result := make([]int, 0, len(history))
+		for _, entry := range history {
+			result = append(result, entry.Version)
+		}
+		return result
+	}
+
+	t.Run("grows with each update", func(t *testing.T) {
+		if history := getHistory(t); len(history) != 0 {
+			t.Fatalf("Expected empty history, got %v", versions(history))
+		}
+
+		updateConfig(t, "v2")
+		updateConfig(t, "v3")
+
+		history := getHistory(t)
+		if got := versions(history); !reflect.DeepEqual(got, []int{2, 1}) {
+			t.Fatalf("Expected versions [2 1], got %v", got)
+		}
+		if history[0].Config["title"] != "v2" || history[1].Config["test"] != "config" {
+			t.Errorf("Expected replaced configs newest first, got %+v", history)
+		}
+		if history[0].ReplacedAt.IsZero() {
+			t.Error("Expected replaced_at to be set")
+		}
+	})
+
+	t.Run("caps at the limit", func(t *testing.T) {
+		updateConfig(t, "v4")
+		updateConfig(t, "v5")
+
+		if got := versions(getHistory(t)); !reflect.DeepEqual(got, []int{4, 3, 2}) {
+			t.Errorf("Expected the newest 3 versions [4 3 2], got %v", got)
+		}
+	})
+
+	t.Run("restore reinstates an old config", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		env.Handler.RestoreWidgetConfig(w, env.makeAuthenticatedRequest("POST", "/widgets/widget-history/config/restore/3", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var restored models.Widget
+		if err := json.Unmarshal(w.Body.Bytes(), &restored); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if restored.Version != 6 || restored.Config["title"] != "v3" {
+			t.Errorf("Expected version 6 with the v3 config, got version %d with %v", restored.Version, restored.Config)
+		}
+
+		stored, err := env.WidgetRepo.GetByID(context.Background(), "widget-history")
+		if err != nil {
+			t.Fatalf("Failed to get widget: %v", err)
+		}
+		if stored.Version != 6 || stored.Config["title"] != "v3" {
+			t.Errorf("Expected stored version 6 with the v3 config, got version %d with %v", stored.Version, stored.Config)
+		}
+
+		history := getHistory(t)
+		if got := versions(history); !reflect.DeepEqual(got, []int{5, 4, 3}) {
+			t.Errorf("Expected the replaced version 5 to be added, got %v", got)
+		}
+		if history[0].Config["title"] != "v5" {
+			t.Errorf("Expected the replaced v5 config in the history, got %v", history[0].Config)
+		}
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		for path, status := range map[string]int{
+			"/widgets/widget-history/config/restore/1":   http.StatusNotFound,
+			"/widgets/widget-history/config/restore/abc": http.StatusBadRequest,
+		} {
+			w := httptest.NewRecorder()
+			env.Handler.RestoreWidgetConfig(w, env.makeAuthenticatedRequest("POST", path, nil))
+			if w.Code != status {
+				t.Errorf("Expected status %d for %s, got %d: %s", status, path, w.Code, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("deleted with the widget", func(t *testing.T) {
+		if err := env.WidgetRepo.Delete(context.Background(), "widget-history"); err != nil {
+			t.Fatalf("Failed to delete widget: %v", err)
+		}
+		if env.Redis.Exists(storage.GenerateWidgetConfigHistoryKey("widget-history")) {
+			t.Error("Expected the config history to be deleted with the widget")
+		}
+	})
+}
+
+// recordingExportDestination keeps uploaded export files in memory
+type recordingExportDestination struct {
+	mu      sync.Mutex
+	uploads map[string][]byte
+}
+
+func (d *recordingExportDestination) Upload(ctx context.Context, key, contentType string, data []byte) (string, time.Time, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.uploads[key] = data
+	return "https://storage.example.com/" + key + "?signature=test", time.Now().Add(time.Hour), nil
+}
+
+func TestExportJobs_Integration_UploadToDestination(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobRepo := storage.NewRedisExportJobRepository(env.RedisClient, time.Hour)
+	jobService := services.NewExportJobService(jobRepo, env.WidgetRepo, services.NewExportService(storage.NewRedisSubmissionRepository(env.RedisClient), env.WidgetRepo), 10)
+	destination := &recordingExportDestination{uploads: make(map[string][]byte)}
+	jobService.SetDestination(destination)
+	jobService.Start(ctx, 1)
+	env.Handler.SetExportJobService(jobService)
+
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+	if _, err := env.WidgetService.SubmitWidget(ctx, "widget-1", models.SubmissionRequest{Data: map[string]interface{}{"email": "a@example.com"}}); err != nil {
+		t.Fatalf("Failed to submit widget: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	env.Handler.ExportJobs(w, env.makeAuthenticatedRequest("POST", "/widgets/widget-1/export/jobs?format=csv", nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var created models.ExportJob
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode job: %v", err)
+	}
+
+	var job *models.ExportJob
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var err error
+		job, err = jobRepo.GetByID(ctx, "widget-1", created.ID)
+		if err != nil {
+			t.Fatalf("Failed to get job: %v", err)
+		}
+		if job.IsFinished() {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for export job, status %s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if job.Status != models.ExportJobCompleted || job.DownloadURL == "" || job.DownloadExpires == nil {
+		t.Fatalf("Expected completed job with a download URL, got %+v", job)
+	}
+	key := "widget-1/" + job.ID + "/" + job.Filename
+	if data, ok := destination.uploads[key]; !ok || !strings.Contains(string(data), "a@example.com") {
+		t.Errorf("Expected export file to be uploaded as %q, got %v", key, destination.uploads)
+	}
+	if _, err := jobRepo.GetResult(ctx, "widget-1", job.ID); err == nil {
+		t.Error("Expected uploaded export not to be kept in Redis")
+	}
+
+	w = httptest.NewRecorder()
+	env.Handler.DownloadExportJob(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-1/export/jobs/"+job.ID+"/download", nil))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != job.DownloadURL {
+		t.Errorf("Expected redirect to %s, got %d %s", job.DownloadURL, w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestSubmitWidget_Integration_CaptureParams(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+
+	widget := env.createTestWidget("widget-utm", "UTM Form", "lead-form", true, time.Now())
+	widget.Config = map[string]interface{}{
+		models.WidgetConfigCaptureParamsKey: []interface{}{"utm_source", "utm_medium", "utm_campaign"},
+		// Meta params are not form fields, so the field allowlist doesn't apply to them
+		models.WidgetConfigPublicPolicyKey: map[string]interface{}{"allowed_fields": []interface{}{"email"}},
+	}
+	if err := env.WidgetRepo.Update(context.Background(), widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+
+	for _, body := range []string{
+		`{"data":{"email":"a@example.com"},"meta":{"utm_source":"google","utm_medium":"cpc","gclid":"abc","utm_campaign":" "}}`,
+		`{"data":{"email":"b@example.com"},"meta":{"utm_source":"newsletter"}}`,
+	} {
+		req := httptest.NewRequest("POST", "/widgets/widget-utm/submit", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		publicHandler.SubmitWidget(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	submissions, _, err := storage.NewRedisSubmissionRepository(env.RedisClient).GetByWidgetID(context.Background(), "widget-utm", models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil || len(submissions) != 2 {
+		t.Fatalf("Expected 2 submissions, got %d (err: %v)", len(submissions), err)
+	}
+	for _, submission := range submissions {
+		if submission.Data["email"] != "a@example.com" {
+			continue
+		}
+		if len(submission.Meta) != 2 || submission.Meta["utm_source"] != "google" || submission.Meta["utm_medium"] != "cpc" {
+			t.Errorf("Expected only configured non-empty params in meta, got %v", submission.Meta)
+		}
+		if _, ok := submission.Data["utm_source"]; ok {
+			t.Errorf("Expected meta to stay out of data, got %v", submission.Data)
+		}
+	}
+
+	export := func(query string) [][]string {
+		w := httptest.NewRecorder()
+		env.Handler.ExportWidgetSubmissions(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-utm/export?format=csv&"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v", err)
+		}
+		return records
+	}
+	column := func(records [][]string, name string) int {
+		for i, header := range records[0] {
+			if header == name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	records := export("include_meta=true")
+	sourceCol, emailCol := column(records, "meta.utm_source"), column(records, "email")
+	if sourceCol < 0 || column(records, "meta.utm_medium") < 0 || len(records) != 3 {
+		t.Fatalf("Expected meta columns for captured params, got %v", records)
+	}
+	for _, row := range records[1:] {
+		expected := map[string]string{"a@example.com": "google", "b@example.com": "newsletter"}[row[emailCol]]
+		if row[sourceCol] != expected {
+			t.Errorf("Expected utm_source %q for %s, got %q", expected, row[emailCol], row[sourceCol])
+		}
+	}
+
+	if records := export(""); column(records, "meta.utm_source") >= 0 {
+		t.Errorf("Expected no meta columns without include_meta, got %v", records[0])
+	}
+
+	// Meta params are filterable like data fields
+	records = export("include_meta=true&meta=utm_source:Google")
+	if len(records) != 2 || records[1][column(records, "email")] != "a@example.com" {
+		t.Errorf("Expected only the google submission, got %v", records)
+	}
+}
+
+func TestExportWidgetSubmissions_Integration_RowLimits(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+	submissionRepo := storage.NewRedisSubmissionRepository(env.RedisClient)
+	exportService := services.NewExportService(submissionRepo, env.WidgetRepo)
+	exportService.SetRowLimits(map[string]int{"free": 2})
+	handler := NewWidgetHandler(env.WidgetService, exportService, services.NewImportService(env.WidgetService, env.Validator), env.Validator)
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		if err := submissionRepo.Create(ctx, &models.Submission{
+			ID:        fmt.Sprintf("submission-%d", i),
+			WidgetID:  "widget-1",
+			Data:      map[string]interface{}{"n": float64(i)},
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+			TTL:       time.Hour,
+		}); err != nil {
+			t.Fatalf("Failed to create submission: %v", err)
+		}
+	}
+
+	export := func(plan string) (*httptest.ResponseRecorder, []map[string]interface{}) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/widgets/widget-1/export?format=json", nil)
+		req = req.WithContext(auth.SetUserInContext(req.Context(), &models.User{ID: env.UserID, Plan: plan}))
+		w := httptest.NewRecorder()
+		handler.ExportWidgetSubmissions(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var exported struct {
+			Submissions []map[string]interface{} `json:"submissions"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &exported); err != nil {
+			t.Fatalf("Failed to decode export: %v", err)
+		}
+		return w, exported.Submissions
+	}
+
+	// Free exports are capped to the newest submissions
+	w, rows := export("free")
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows for a free user, got %d", len(rows))
+	}
+	for _, row := range rows {
+		if row["id"] == "submission-0" {
+			t.Errorf("Expected the oldest submission to be left out, got %v", rows)
+		}
+	}
+	if w.Header().Get(ExportTruncatedHeader) != "true" || w.Header().Get(ExportRowLimitHeader) != "2" {
+		t.Errorf("Expected truncation headers, got %v", w.Header())
+	}
+
+	// Plans without a cap get everything
+	w, rows = export("pro")
+	if len(rows) != 3 {
+		t.Errorf("Expected 3 rows for a pro user, got %d", len(rows))
+	}
+	if w.Header().Get(ExportTruncatedHeader) != "" {
+		t.Errorf("Expected no truncation header for a pro user, got %q", w.Header().Get(ExportTruncatedHeader))
+	}
+}
+
+func TestExportWidgetSubmissions_Integration_DailyQuota(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	exportService := services.NewExportService(storage.NewRedisSubmissionRepository(env.RedisClient), env.WidgetRepo)
+	exportService.SetDailyQuota(map[string]int{"free": 2}, storage.NewRedisExportQuotaRepository(env.RedisClient))
+	handler := NewWidgetHandler(env.WidgetService, exportService, services.NewImportService(env.WidgetService, env.Validator), env.Validator)
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+	exportWidget := func(widgetID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/widgets/"+widgetID+"/export?format=json", nil)
+		req = req.WithContext(auth.SetUserInContext(req.Context(), &models.User{ID: env.UserID, Plan: "free"}))
+		w := httptest.NewRecorder()
+		handler.ExportWidgetSubmissions(w, req)
+		return w
+	}
+	export := func() *httptest.ResponseRecorder { return exportWidget("widget-1") }
+
+	// Failed exports don't use up the quota
+	if w := exportWidget("missing"); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status %d for a missing widget, got %d: %s", http.StatusNotFound, w.Code, w.Body.String())
+	}
+	if count, _ := storage.NewRedisExportQuotaRepository(env.RedisClient).GetDailyExports(context.Background(), env.UserID, time.Now()); count != 0 {
+		t.Errorf("Expected a failed export not to be counted, got %d", count)
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := export(); w.Code != http.StatusOK {
+			t.Fatalf("Expected export %d to succeed, got %d: %s", i+1, w.Code, w.Body.String())
+		}
+	}
+
+	w := export()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d for the third export, got %d: %s", http.StatusTooManyRequests, w.Code, w.Body.String())
+	}
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter <= 0 || retryAfter > 24*60*60 {
+		t.Errorf("Expected Retry-After until the end of the day, got %q", w.Header().Get("Retry-After"))
+	}
+
+	// Counters are per day, so the next day starts from scratch
+	env.Redis.FastForward(48 * time.Hour)
+	if count, _ := storage.NewRedisExportQuotaRepository(env.RedisClient).GetDailyExports(context.Background(), env.UserID, time.Now()); count != 0 {
+		t.Errorf("Expected counter to expire, got %d", count)
+	}
+}
+
+func TestExportWidgetSubmissions_Integration_ConcurrencyLimit(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	slots := storage.NewRedisExportSlotRepository(env.RedisClient)
+	exportService := services.NewExportService(storage.NewRedisSubmissionRepository(env.RedisClient), env.WidgetRepo)
+	exportService.SetConcurrencyLimit(0, 1, time.Minute, slots)
+	handler := NewWidgetHandler(env.WidgetService, exportService, services.NewImportService(env.WidgetService, env.Validator), env.Validator)
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+	export := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ExportWidgetSubmissions(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-1/export?format=json", nil))
+		return w
+	}
+
+	// Finished exports give their slot back
+	for i := 0; i < 2; i++ {
+		if w := export(); w.Code != http.StatusOK {
+			t.Fatalf("Expected export %d to succeed, got %d: %s", i+1, w.Code, w.Body.String())
+		}
+	}
+
+	// Another instance holds the only cluster slot
+	leaseID, ok, err := slots.AcquireExportSlot(context.Background(), 1, time.Now(), time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("Failed to take the slot: %v", err)
+	}
+	w := export()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d while the slot is taken, got %d: %s", http.StatusTooManyRequests, w.Code, w.Body.String())
+	}
+	if retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || retryAfter <= 0 {
+		t.Errorf("Expected a Retry-After header, got %q", w.Header().Get("Retry-After"))
+	}
+
+	if err := slots.ReleaseExportSlot(context.Background(), leaseID); err != nil {
+		t.Fatalf("Failed to release the slot: %v", err)
+	}
+	if w := export(); w.Code != http.StatusOK {
+		t.Errorf("Expected export to succeed once the slot is released, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetWidgets_Integration_PaginationLinks(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		env.createTestWidget(fmt.Sprintf("widget-%d", i), fmt.Sprintf("Form %d", i), "lead-form", true, now.Add(time.Duration(-i)*time.Hour))
+	}
+	env.createTestWidget("banner-1", "Banner", "banner", true, now)
+
+	req := env.makeAuthenticatedRequest("GET", "/api/v1/widgets?type=lead-form&page=2&per_page=2", nil)
+	w := httptest.NewRecorder()
+	env.Handler.GetWidgets(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Meta *models.Meta `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	links := response.Meta.Links
+	if links == nil {
+		t.Fatal("Expected pagination links in meta")
+	}
+	base := "http://example.com/api/v1/widgets?"
+	if links.Prev != base+"page=1&per_page=2&type=lead-form" ||
+		links.Next != base+"page=3&per_page=2&type=lead-form" ||
+		links.Last != base+"page=3&per_page=2&type=lead-form" {
+		t.Errorf("Unexpected links for 5 filtered widgets: %+v", links)
+	}
+}
+
+func TestSubmitWidget_Integration_FieldTransforms(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+
+	widget := env.createTestWidget("widget-transforms", "Transform Form", "lead-form", true, time.Now())
+	widget.Config = map[string]interface{}{
+		models.WidgetConfigFieldTransformsKey: map[string]interface{}{
+			"email": []interface{}{"trim", "lower"},
+			"phone": []interface{}{"phone-normalize"},
+		},
+	}
+	if err := env.WidgetRepo.Update(context.Background(), widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+
+	body := `{"data":{"email":"  Lead@Example.COM ","phone":"+1 (555) 010-9999","name":" Jane "}}`
+	req := httptest.NewRequest("POST", "/widgets/widget-transforms/submit", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	publicHandler.SubmitWidget(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	submissions, _, err := storage.NewRedisSubmissionRepository(env.RedisClient).GetByWidgetID(context.Background(), "widget-transforms", models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil || len(submissions) != 1 {
+		t.Fatalf("Expected 1 submission, got %d (err: %v)", len(submissions), err)
+	}
+	data := submissions[0].Data
+	if data["email"] != "lead@example.com" || data["phone"] != "+15550109999" {
+		t.Errorf("Expected transformed email and phone, got %v", data)
+	}
+	if data["name"] != " Jane " {
+		t.Errorf("Expected fields without transforms to be stored as is, got %q", data["name"])
+	}
+}
+
+func TestSubmitWidget_Integration_EchoData(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+
+	transforms := map[string]interface{}{
+		"email": []interface{}{"trim", "lower"},
+		"phone": []interface{}{"phone-normalize"},
+	}
+	for _, tt := range []struct {
+		widgetID string
+		echo     bool
+	}{
+		{widgetID: "widget-minimal", echo: false},
+		{widgetID: "widget-echo", echo: true},
+	} {
+		t.Run(tt.widgetID, func(t *testing.T) {
+			widget := env.createTestWidget(tt.widgetID, "Echo Form", "lead-form", true, time.Now())
+			widget.Config = map[string]interface{}{models.WidgetConfigFieldTransformsKey: transforms}
+			if tt.echo {
+				widget.Config[models.WidgetConfigEchoDataKey] = true
+			}
+			if err := env.WidgetRepo.Update(context.Background(), widget); err != nil {
+				t.Fatalf("Failed to update widget: %v", err)
+			}
+
+			body := `{"data":{"email":"  Lead@Example.COM ","phone":"+1 (555) 010-9999"}}`
+			req := httptest.NewRequest("POST", "/widgets/"+tt.widgetID+"/submit", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			publicHandler.SubmitWidget(w, req)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var response struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Data["id"] == "" || response.Data["acknowledgement"] == nil {
+				t.Errorf("Expected submission ID and acknowledgement, got %v", response.Data)
+			}
+
+			data, echoed := response.Data["data"].(map[string]interface{})
+			if !tt.echo {
+				if _, ok := response.Data["data"]; ok {
+					t.Errorf("Expected data to be omitted by default, got %v", response.Data["data"])
+				}
+				return
+			}
+			if !echoed || data["email"] != "lead@example.com" || data["phone"] != "+15550109999" {
+				t.Errorf("Expected transformed values to be echoed, got %v", response.Data["data"])
+			}
+		})
+	}
+
+	// The minimal response doesn't affect what is stored
+	submissions, _, err := storage.NewRedisSubmissionRepository(env.RedisClient).GetByWidgetID(context.Background(), "widget-minimal", models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil || len(submissions) != 1 || submissions[0].Data["email"] != "lead@example.com" {
+		t.Errorf("Expected the transformed submission to be stored, got %v (err: %v)", submissions, err)
+	}
+}
+
+func TestSubmitWidget_Integration_ValidationModes(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+	repo := storage.NewRedisSubmissionRepository(env.RedisClient)
+
+	tests := []struct {
+		mode         string
+		expectedCode int
+		stored       bool
+		warnings     int
+	}{
+		{mode: "", expectedCode: http.StatusBadRequest},
+		{mode: "strict", expectedCode: http.StatusBadRequest},
+		{mode: "lenient", expectedCode: http.StatusCreated, stored: true, warnings: 1},
+		{mode: "off", expectedCode: http.StatusCreated, stored: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("mode "+tt.mode, func(t *testing.T) {
+			widgetID := "widget-validation-" + tt.mode
+			widget := env.createTestWidget(widgetID, "Validation Form", "lead-form", true, time.Now())
+			widget.Config = map[string]interface{}{
+				models.WidgetConfigFieldMaxLengthsKey: map[string]interface{}{"name": float64(5)},
+			}
+			if tt.mode != "" {
+				widget.Config[models.WidgetConfigValidationModeKey] = tt.mode
+			}
+			if err := env.WidgetRepo.Update(context.Background(), widget); err != nil {
+				t.Fatalf("Failed to update widget: %v", err)
+			}
+
+			body := `{"data":{"name":"Much too long","email":"a@example.com"}}`
+			req := httptest.NewRequest("POST", "/widgets/"+widgetID+"/submit", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			publicHandler.SubmitWidget(w, req)
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+
+			submissions, _, err := repo.GetByWidgetID(context.Background(), widgetID, models.PaginationOptions{Page: 1, PerPage: 10})
+			if err != nil {
+				t.Fatalf("Failed to get submissions: %v", err)
+			}
+			if !tt.stored {
+				if len(submissions) != 0 {
+					t.Errorf("Expected the submission to be rejected, got %d stored", len(submissions))
+				}
+				return
+			}
+			if len(submissions) != 1 {
+				t.Fatalf("Expected 1 stored submission, got %d", len(submissions))
+			}
+			warnings := submissions[0].ValidationWarnings
+			if len(warnings) != tt.warnings {
+				t.Fatalf("Expected %d validation warnings, got %v", tt.warnings, warnings)
+			}
+			if tt.warnings > 0 && warnings[0].Field != "data.name" {
+				t.Errorf("Expected a warning for data.name, got %q", warnings[0].Field)
+			}
+		})
+	}
+}
+
+func TestRegisterEvent_Integration_ViewDedup(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+
+	widget := env.createTestWidget("widget-dedup", "Dedup Form", "lead-form", true, time.Now())
+	widget.Config = map[string]interface{}{
+		models.WidgetConfigViewDedupKey: map[string]interface{}{"window_minutes": float64(30)},
+	}
+	if err := env.WidgetRepo.Update(ctx, widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+	env.createTestWidget("widget-raw", "Raw Form", "lead-form", true, time.Now())
+
+	view := func(widgetID, ip string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/widgets/"+widgetID+"/events", bytes.NewBufferString(`{"type":"view"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = ip + ":1234"
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		publicHandler.RegisterEvent(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+		}
+		return w
+	}
+	views := func(widgetID string) int64 {
+		stats, err := env.StatsRepo.GetWidgetStats(ctx, widgetID)
+		if err != nil {
+			t.Fatalf("Failed to get stats: %v", err)
+		}
+		return stats.Views
+	}
+
+	first := view("widget-dedup", "203.0.113.1")
+	cookies := first.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "lc_view_widget-dedup" || cookies[0].MaxAge != 30*60 {
+		t.Fatalf("Expected a 30 minute view cookie, got %v", cookies)
+	}
+
+	// Within the window: neither the cookie holder nor a cookieless client on the same IP counts again
+	if w := view("widget-dedup", "198.51.100.9", cookies[0]); len(w.Result().Cookies()) != 0 {
+		t.Error("Expected no new cookie for a visitor carrying one")
+	}
+	view("widget-dedup", "203.0.113.1")
+	if got := views("widget-dedup"); got != 1 {
+		t.Errorf("Expected repeated views within the window to count once, got %d", got)
+	}
+
+	view("widget-dedup", "203.0.113.2")
+	if got := views("widget-dedup"); got != 2 {
+		t.Errorf("Expected a new visitor to count, got %d", got)
+	}
+
+	// Outside the window the IP is counted again
+	env.Redis.FastForward(31 * time.Minute)
+	view("widget-dedup", "203.0.113.1")
+	if got := views("widget-dedup"); got != 3 {
+		t.Errorf("Expected a view after the window to count, got %d", got)
+	}
+
+	// Widgets without dedup keep raw counts and set no cookie
+	if w := view("widget-raw", "203.0.113.1"); len(w.Result().Cookies()) != 0 {
+		t.Error("Expected no view cookie without dedup")
+	}
+	view("widget-raw", "203.0.113.1")
+	if got := views("widget-raw"); got != 2 {
+		t.Errorf("Expected raw counts without dedup, got %d", got)
+	}
+}
+
+func TestGetSubmissionHeatmap_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.createTestWidget("widget-heatmap", "Heatmap Form", "lead-form", true, time.Now())
+
+	repo := storage.NewRedisSubmissionRepository(env.RedisClient)
+	times := []time.Time{
+		time.Date(2024, 3, 4, 9, 15, 0, 0, time.UTC),  // Monday 09:15 UTC
+		time.Date(2024, 3, 4, 9, 45, 0, 0, time.UTC),  // Monday 09:45 UTC
+		time.Date(2024, 3, 9, 20, 0, 0, 0, time.UTC),  // Saturday 20:00 UTC
+		time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC),  // Before the range
+		time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC), // After the range
+	}
+	for i, createdAt := range times {
+		submission := &models.Submission{
+			ID:        fmt.Sprintf("heatmap-%d", i),
+			WidgetID:  "widget-heatmap",
+			Data:      map[string]interface{}{"email": "a@example.com"},
+			CreatedAt: createdAt,
+			TTL:       24 * time.Hour,
+		}
+		if err := repo.Create(context.Background(), submission); err != nil {
+			t.Fatalf("Failed to create submission: %v", err)
+		}
+	}
+
+	getHeatmap := func(query string) models.SubmissionHeatmap {
+		t.Helper()
+		w := httptest.NewRecorder()
+		env.Handler.GetSubmissionHeatmap(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-heatmap/stats/heatmap?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data models.SubmissionHeatmap `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Data
+	}
+
+	rangeQuery := "from=2024-03-01T00:00:00Z&to=2024-03-15T00:00:00Z"
+
+	utc := getHeatmap(rangeQuery)
+	if utc.Total != 3 || utc.Truncated {
+		t.Fatalf("Expected 3 submissions in range without truncation, got %d (truncated: %v)", utc.Total, utc.Truncated)
+	}
+	if utc.Counts[time.Monday][9] != 2 || utc.Counts[time.Saturday][20] != 1 {
+		t.Errorf("Expected UTC buckets Monday 09 = 2 and Saturday 20 = 1, got %v", utc.Counts)
+	}
+
+	// +05:30: 09:15 -> 14:45, 09:45 -> 15:15, Saturday 20:00 -> Sunday 01:30
+	local := getHeatmap(rangeQuery + "&tz=Asia/Kolkata")
+	if local.Timezone != "Asia/Kolkata" || local.Total != 3 {
+		t.Fatalf("Expected 3 submissions in Asia/Kolkata, got %d in %q", local.Total, local.Timezone)
+	}
+	if local.Counts[time.Monday][14] != 1 || local.Counts[time.Monday][15] != 1 || local.Counts[time.Sunday][1] != 1 {
+		t.Errorf("Expected buckets shifted to local time, got %v", local.Counts)
+	}
+	if local.Counts[time.Monday][9] != 0 || local.Counts[time.Saturday][20] != 0 {
+		t.Errorf("Expected no UTC buckets in local heatmap, got %v", local.Counts)
+	}
+
+	if all := getHeatmap(""); all.Total != len(times) {
+		t.Errorf("Expected all %d submissions without a range, got %d", len(times), all.Total)
+	}
+
+	w := httptest.NewRecorder()
+	env.Handler.GetSubmissionHeatmap(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-heatmap/stats/heatmap?tz=Mars/Olympus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown time zone, got %d", w.Code)
+	}
+}
+
+// flakyExportDestination fails the first uploads, then records files like recordingExportDestination
+type flakyExportDestination struct {
+	recordingExportDestination
+	failures int
+	calls    int
+}
+
+func (d *flakyExportDestination) Upload(ctx context.Context, key, contentType string, data []byte) (string, time.Time, error) {
+	d.mu.Lock()
+	d.calls++
+	failed := d.calls <= d.failures
+	d.mu.Unlock()
+	if failed {
+		return "", time.Time{}, fmt.Errorf("storage unavailable")
+	}
+	return d.recordingExportDestination.Upload(ctx, key, contentType, data)
+}
+
+func TestExportJobs_Integration_Retry(t *testing.T) {
+	tests := []struct {
+		name           string
+		failures       int
+		expectedStatus string
+		expectedCalls  int
+	}{
+		{name: "transient failure succeeds on retry", failures: 1, expectedStatus: models.ExportJobCompleted, expectedCalls: 2},
+		{name: "permanent failure exhausts retries", failures: 100, expectedStatus: models.ExportJobFailed, expectedCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := setupIntegrationTestEnvironment(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			jobRepo := storage.NewRedisExportJobRepository(env.RedisClient, time.Hour)
+			jobService := services.NewExportJobService(jobRepo, env.WidgetRepo, services.NewExportService(storage.NewRedisSubmissionRepository(env.RedisClient), env.WidgetRepo), 10)
+			destination := &flakyExportDestination{
+				recordingExportDestination: recordingExportDestination{uploads: make(map[string][]byte)},
+				failures:                   tt.failures,
+			}
+			jobService.SetDestination(destination)
+			jobService.SetRetryPolicy(3, 50*time.Millisecond)
+			jobService.Start(ctx, 1)
+
+			env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+			if _, err := env.WidgetService.SubmitWidget(ctx, "widget-1", models.SubmissionRequest{Data: map[string]interface{}{"email": "a@example.com"}}); err != nil {
+				t.Fatalf("Failed to submit widget: %v", err)
+			}
+
+			created, err := jobService.CreateJob(ctx, "widget-1", env.UserID, models.ExportOptions{Format: "csv"})
+			if err != nil {
+				t.Fatalf("Failed to create export job: %v", err)
+			}
+
+			var job *models.ExportJob
+			sawRetrying := false
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				job, err = jobRepo.GetByID(ctx, "widget-1", created.ID)
+				if err != nil {
+					t.Fatalf("Failed to get job: %v", err)
+				}
+				if job.Status == models.ExportJobRetrying {
+					sawRetrying = true
+				}
+				if job.IsFinished() {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("Timed out waiting for export job, status %s", job.Status)
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			if job.Status != tt.expectedStatus {
+				t.Fatalf("Expected status %s, got %+v", tt.expectedStatus, job)
+			}
+			if !sawRetrying {
+				t.Error("Expected the job to be retrying between attempts")
+			}
+			if job.Attempts != tt.expectedCalls || destination.calls != tt.expectedCalls {
+				t.Errorf("Expected %d attempts, got %d (uploads tried: %d)", tt.expectedCalls, job.Attempts, destination.calls)
+			}
+			if tt.expectedStatus == models.ExportJobCompleted && len(destination.uploads) != 1 {
+				t.Errorf("Expected a single uploaded file, got %d", len(destination.uploads))
+			}
+			if tt.expectedStatus == models.ExportJobFailed && job.Error == "" {
+				t.Error("Expected failed job to carry an error")
+			}
+		})
+	}
+}
+
+func TestExportJobs_Integration_DailyQuota(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	quotaRepo := storage.NewRedisExportQuotaRepository(env.RedisClient)
+	exportService := services.NewExportService(storage.NewRedisSubmissionRepository(env.RedisClient), env.WidgetRepo)
+	exportService.SetDailyQuota(map[string]int{"free": 1}, quotaRepo)
+	jobRepo := storage.NewRedisExportJobRepository(env.RedisClient, time.Hour)
+	jobService := services.NewExportJobService(jobRepo, env.WidgetRepo, exportService, 10)
+	destination := &flakyExportDestination{
+		recordingExportDestination: recordingExportDestination{uploads: make(map[string][]byte)},
+		failures:                   1,
+	}
+	jobService.SetDestination(destination)
+	jobService.Start(ctx, 1)
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, time.Now())
+
+	runJob := func() *models.ExportJob {
+		t.Helper()
+		created, err := jobService.CreateJob(ctx, "widget-1", env.UserID, models.ExportOptions{Format: "csv", DailyQuota: exportService.DailyQuota("free")})
+		if err != nil {
+			t.Fatalf("Failed to create export job: %v", err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			job, err := jobRepo.GetByID(ctx, "widget-1", created.ID)
+			if err != nil {
+				t.Fatalf("Failed to get job: %v", err)
+			}
+			if job.IsFinished() {
+				return job
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for export job, status %s", job.Status)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	dailyExports := func() int {
+		count, _ := quotaRepo.GetDailyExports(ctx, env.UserID, time.Now())
+		return count
+	}
+
+	// Queued and failed jobs don't count towards the quota
+	if job := runJob(); job.Status != models.ExportJobFailed {
+		t.Fatalf("Expected the first job to fail, got %+v", job)
+	}
+	if count := dailyExports(); count != 0 {
+		t.Errorf("Expected a failed job not to be counted, got %d", count)
+	}
+
+	if job := runJob(); job.Status != models.ExportJobCompleted {
+		t.Fatalf("Expected the second job to complete, got %+v", job)
+	}
+	if count := dailyExports(); count != 1 {
+		t.Errorf("Expected the completed job to be counted, got %d", count)
+	}
+
+	// Jobs finishing past the limit fail
+	job := runJob()
+	if job.Status != models.ExportJobFailed || job.Error != "Daily export limit reached" {
+		t.Errorf("Expected the third job to fail on the quota, got %+v", job)
+	}
+	if count := dailyExports(); count != 1 {
+		t.Errorf("Expected the counter to stay at the limit, got %d", count)
+	}
+}
+
+func TestSubmitWidget_Integration_ReservedFieldNames(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+	env.createTestWidget("widget-reserved", "Reserved Form", "lead-form", true, time.Now())
+
+	submit := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/widgets/widget-reserved/submit", bytes.NewBufferString(`{"data":{"id":"spoofed","email":"a@example.com"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		publicHandler.SubmitWidget(w, req)
+		return w
+	}
+
+	env.WidgetService.SetReservedFieldNames(models.DefaultReservedFieldNames, models.ReservedFieldsReject)
+	w := submit()
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "data.id") {
+		t.Fatalf("Expected status 400 pointing at data.id, got %d: %s", w.Code, w.Body.String())
+	}
+
+	env.WidgetService.SetReservedFieldNames(models.DefaultReservedFieldNames, models.ReservedFieldsPrefix)
+	if w := submit(); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	submissions, _, err := storage.NewRedisSubmissionRepository(env.RedisClient).GetByWidgetID(context.Background(), "widget-reserved", models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil || len(submissions) != 1 {
+		t.Fatalf("Expected 1 submission, got %d (err: %v)", len(submissions), err)
+	}
+	data := submissions[0].Data
+	if _, ok := data["id"]; ok || data["field_id"] != "spoofed" || data["email"] != "a@example.com" {
+		t.Errorf("Expected id to be stored as field_id, got %v", data)
+	}
+	if submissions[0].ID == "spoofed" {
+		t.Error("Expected the submission ID not to be taken from data")
+	}
+}
+
+func TestGetWidgetSubmissions_Integration_FieldProjection(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.createTestWidget("widget-projection", "Projection Form", "lead-form", true, time.Now())
+
+	for _, data := range []map[string]interface{}{
+		{"name": "Jane", "email": "jane@example.com", "message": "Long text"},
+		{"name": "John", "phone": "+15550109999"},
+	} {
+		if _, err := env.WidgetService.SubmitWidget(context.Background(), "widget-projection", models.SubmissionRequest{Data: data}); err != nil {
+			t.Fatalf("Failed to submit widget: %v", err)
+		}
+	}
+
+	get := func(query string) []map[string]interface{} {
+		t.Helper()
+		w := httptest.NewRecorder()
+		env.Handler.GetWidgetSubmissions(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-projection/submissions"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Data
+	}
+
+	projected := get("?fields=name,email&fields=unknown")
+	if len(projected) != 2 {
+		t.Fatalf("Expected 2 submissions, got %d", len(projected))
+	}
+	for _, submission := range projected {
+		if len(submission) != 3 || submission["id"] == nil || submission["created_at"] == nil {
+			t.Errorf("Expected only id, created_at and data, got %v", submission)
+		}
+		data := submission["data"].(map[string]interface{})
+		for field := range data {
+			if field != "name" && field != "email" {
+				t.Errorf("Expected only requested fields, got %q in %v", field, data)
+			}
+		}
+		if data["name"] == nil {
+			t.Errorf("Expected name to be returned, got %v", data)
+		}
+		if data["name"] == "John" && len(data) != 1 {
+			t.Errorf("Expected missing fields to be omitted, got %v", data)
+		}
+	}
+
+	full := get("")
+	if len(full) != 2 || full[0]["widget_id"] != "widget-projection" {
+		t.Fatalf("Expected full submissions without projection, got %v", full)
+	}
+	for _, submission := range full {
+		if data := submission["data"].(map[string]interface{}); data["name"] == "Jane" && data["message"] != "Long text" {
+			t.Errorf("Expected all fields without projection, got %v", data)
+		}
+	}
+}
+
+func TestAdminPausedTypes_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.WidgetService.SetPausedTypesRepository(storage.NewRedisPausedTypesRepository(env.RedisClient))
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+	adminHandler := NewAdminHandler(middleware.NewMaintenance(config.MaintenanceConfig{}), env.Validator)
+	adminHandler.SetWidgetService(env.WidgetService)
+
+	env.createTestWidget("widget-popup", "Popup", "popup", true, time.Now())
+	env.createTestWidget("widget-form", "Lead Form", "lead-form", true, time.Now())
+
+	admin := &models.User{ID: env.UserID, Role: models.RoleAdmin}
+	adminRequest := func(user *models.User, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req = req.WithContext(auth.SetUserInContext(req.Context(), user))
+		w := httptest.NewRecorder()
+		adminHandler.PausedTypes(w, req)
+		return w
+	}
+	submit := func(widgetID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/widgets/"+widgetID+"/submit", bytes.NewBufferString(`{"data":{"email":"a@example.com"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		publicHandler.SubmitWidget(w, req)
+		return w
+	}
+
+	if w := adminRequest(&models.User{ID: env.UserID}, http.MethodPut, "/api/v1/admin/paused-types/popup"); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status %d for non-admin, got %d: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+	if w := adminRequest(admin, http.MethodPut, "/api/v1/admin/paused-types/unknown"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown type, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w := adminRequest(admin, http.MethodPut, "/api/v1/admin/paused-types/popup")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"type":"popup"`) {
+		t.Fatalf("Expected popup to be listed as paused, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := submit("widget-popup"); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "type_paused") {
+		t.Errorf("Expected status %d with type_paused for paused type, got %d: %s", http.StatusServiceUnavailable, w.Code, w.Body.String())
+	}
+	if w := submit("widget-form"); w.Code != http.StatusCreated {
+		t.Errorf("Expected other types to accept submissions, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := adminRequest(admin, http.MethodGet, "/api/v1/admin/paused-types"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"popup"`) {
+		t.Errorf("Expected paused types list with popup, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := adminRequest(admin, http.MethodDelete, "/api/v1/admin/paused-types/popup"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d on resume, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := adminRequest(admin, http.MethodDelete, "/api/v1/admin/paused-types/popup"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d when the type isn't paused, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := submit("widget-popup"); w.Code != http.StatusCreated {
+		t.Errorf("Expected submissions after resume, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAdminWidgetIndexes_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	adminHandler := NewAdminHandler(middleware.NewMaintenance(config.MaintenanceConfig{}), env.Validator)
+	adminHandler.SetWidgetService(env.WidgetService)
+
+	admin := &models.User{ID: "admin-1", Role: models.RoleAdmin}
+	indexes := func(user *models.User, widgetID string) (*httptest.ResponseRecorder, *models.WidgetIndexReport) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/widgets/"+widgetID+"/indexes", nil)
+		req = req.WithContext(auth.SetUserInContext(req.Context(), user))
+		w := httptest.NewRecorder()
+		adminHandler.WidgetIndexes(w, req)
+
+		var response struct {
+			Data models.WidgetIndexReport `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, &response.Data
+	}
+	members := func(report *models.WidgetIndexReport) map[string]*models.WidgetIndexEntry {
+		entries := make(map[string]*models.WidgetIndexEntry)
+		for i, entry := range report.Indexes {
+			if entry.Member {
+				entries[entry.Key] = &report.Indexes[i]
+			}
+		}
+		return entries
+	}
+
+	createdAt := time.Unix(1700000000, 0)
+	widget := env.createTestWidget("widget-1", "Lead Form", "lead-form", true, createdAt)
+
+	if w, _ := indexes(&models.User{ID: env.UserID}, "widget-1"); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status %d for non-admin, got %d", http.StatusForbidden, w.Code)
+	}
+	if w, _ := indexes(admin, "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown widget, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w, report := indexes(admin, "widget-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	found := members(report)
+	expected := []string{
+		storage.GenerateWidgetsByTypeKey("lead-form"),
+		storage.GenerateWidgetsByStatusKey(true),
+		storage.WidgetsByTimeKey,
+		storage.GenerateUserWidgetsKey(env.UserID),
+	}
+	if report.Drift || len(found) != len(expected) {
+		t.Errorf("Expected membership in exactly %v without drift, got %s", expected, w.Body.String())
+	}
+	for _, key := range expected {
+		if found[key] == nil {
+			t.Errorf("Expected widget in %s, got %s", key, w.Body.String())
+		}
+	}
+	if entry := found[storage.WidgetsByTimeKey]; entry == nil || entry.Score == nil || *entry.Score != float64(createdAt.Unix()) {
+		t.Errorf("Expected time index score %d, got %+v", createdAt.Unix(), entry)
+	}
+
+	// Changing the type moves the widget between type sets
+	widget.Type = "banner"
+	if err := env.WidgetRepo.Update(context.Background(), widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+	w, report = indexes(admin, "widget-1")
+	found = members(report)
+	if report.Drift || report.Type != "banner" || found[storage.GenerateWidgetsByTypeKey("banner")] == nil || found[storage.GenerateWidgetsByTypeKey("lead-form")] != nil {
+		t.Errorf("Expected widget only in the banner type set, got %s", w.Body.String())
+	}
+
+	// A stale entry left in the old type set shows up as drift
+	env.RedisClient.GetClient().SAdd(context.Background(), storage.GenerateWidgetsByTypeKey("lead-form"), "widget-1")
+	w, report = indexes(admin, "widget-1")
+	if entry := members(report)[storage.GenerateWidgetsByTypeKey("lead-form")]; !report.Drift || entry == nil || entry.Expected {
+		t.Errorf("Expected the stale lead-form entry to be reported as drift, got %s", w.Body.String())
+	}
+}
+
+func TestAdminRebuildIndexes_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+	client := env.RedisClient.GetClient()
+	adminHandler := NewAdminHandler(middleware.NewMaintenance(config.MaintenanceConfig{}), env.Validator)
+	adminHandler.SetWidgetService(env.WidgetService)
+
+	rebuild := func(user *models.User) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/indexes/rebuild", nil)
+		req = req.WithContext(auth.SetUserInContext(req.Context(), user))
+		w := httptest.NewRecorder()
+		adminHandler.RebuildIndexes(w, req)
+		return w
+	}
+
+	createdAt := time.Unix(1700000000, 0)
+	env.createTestWidget("widget-1", "Lead Form", "lead-form", true, createdAt)
+	env.createTestWidget("widget-2", "Banner", "banner", false, createdAt.Add(time.Minute))
+
+	// Drop entries of widget-1, leave stale entries of widget-2 and of a deleted widget
+	client.SRem(ctx, storage.GenerateWidgetsByTypeKey("lead-form"), "widget-1")
+	client.ZRem(ctx, storage.WidgetsByTimeKey, "widget-1")
+	client.ZRem(ctx, storage.GenerateUserWidgetsKey(env.UserID), "widget-1")
+	client.SAdd(ctx, storage.GenerateWidgetsByStatusKey(true), "widget-2")
+	client.SAdd(ctx, storage.GenerateWidgetsByTypeKey("quiz"), "deleted-widget")
+	client.ZAdd(ctx, storage.GenerateUserWidgetsKey("other-user"), redis.Z{Score: 1, Member: "deleted-widget"})
+
+	if w := rebuild(&models.User{ID: env.UserID}); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status %d for non-admin, got %d", http.StatusForbidden, w.Code)
+	}
+	if ids, _ := client.ZRange(ctx, storage.GenerateUserWidgetsKey(env.UserID), 0, -1).Result(); len(ids) != 1 {
+		t.Fatalf("Expected the forbidden rebuild to leave the indexes alone, got %v", ids)
+	}
+
+	if w := rebuild(&models.User{ID: "admin-1", Role: models.RoleAdmin}); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+
+	for _, widgetID := range []string{"widget-1", "widget-2"} {
+		report, err := env.WidgetService.GetWidgetIndexMembership(ctx, widgetID)
+		if err != nil {
+			t.Fatalf("Failed to check indexes of %s: %v", widgetID, err)
+		}
+		if report.Drift {
+			t.Errorf("Expected no index drift of %s after rebuild, got %+v", widgetID, report.Indexes)
+		}
+	}
+
+	if ids, _ := client.ZRange(ctx, storage.GenerateUserWidgetsKey(env.UserID), 0, -1).Result(); !reflect.DeepEqual(ids, []string{"widget-1", "widget-2"}) {
+		t.Errorf("Expected user widgets [widget-1 widget-2] in creation order, got %v", ids)
+	}
+	if score, err := client.ZScore(ctx, storage.WidgetsByTimeKey, "widget-1").Result(); err != nil || score != float64(createdAt.Unix()) {
+		t.Errorf("Expected time index score %d, got %v (%v)", createdAt.Unix(), score, err)
+	}
+	if member, _ := client.SIsMember(ctx, storage.GenerateWidgetsByStatusKey(true), "widget-2").Result(); member {
+		t.Error("Expected the stale visible entry of widget-2 to be removed")
+	}
+	if exists, _ := client.Exists(ctx, storage.GenerateWidgetsByTypeKey("quiz"), storage.GenerateUserWidgetsKey("other-user")).Result(); exists != 0 {
+		t.Errorf("Expected the indexes of the deleted widget to be removed, %d remain", exists)
+	}
+}
+
+func TestCompareWidgets_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	env.createTestWidget("widget-a", "Variant A", "lead-form", true, time.Now())
+	env.createTestWidget("widget-b", "Variant B", "lead-form", true, time.Now())
+	for widgetID, submits := range map[string]int{"widget-a": 1, "widget-b": 2} {
+		for i := 0; i < 4; i++ {
+			if err := env.StatsRepo.IncrementViews(ctx, widgetID); err != nil {
+				t.Fatalf("Failed to increment views: %v", err)
+			}
+		}
+		for i := 0; i < submits; i++ {
+			if _, err := env.WidgetService.SubmitWidget(ctx, widgetID, models.SubmissionRequest{
+				Data: map[string]interface{}{"email": fmt.Sprintf("%s-%d@example.com", widgetID, i)},
+			}); err != nil {
+				t.Fatalf("Failed to submit widget: %v", err)
+			}
+		}
+	}
+
+	foreign := &models.Widget{
+		ID:        "foreign-widget",
+		OwnerID:   "other-user",
+		Name:      "Foreign Widget",
+		Type:      "lead-form",
+		IsVisible: true,
+		Config:    map[string]interface{}{},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := env.WidgetRepo.Create(ctx, foreign); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+
+	compare := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		env.Handler.CompareWidgets(w, env.makeAuthenticatedRequest("GET", "/widgets/compare?"+query, nil))
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) models.WidgetComparison {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data models.WidgetComparison `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Data
+	}
+
+	allTime := decode(compare("a=widget-a&b=widget-b"))
+	if allTime.A.Views != 4 || allTime.A.Submits != 1 || allTime.B.Views != 4 || allTime.B.Submits != 2 {
+		t.Fatalf("Expected 4/1 and 4/2 views/submits, got A %+v and B %+v", allTime.A, allTime.B)
+	}
+	if allTime.A.ConversionRate != 0.25 || allTime.B.ConversionRate != 0.5 {
+		t.Errorf("Expected conversion rates 0.25 and 0.5, got %v and %v", allTime.A.ConversionRate, allTime.B.ConversionRate)
+	}
+	if allTime.Lift == nil || *allTime.Lift != 1 {
+		t.Errorf("Expected lift 1, got %v", allTime.Lift)
+	}
+	if allTime.A.Closes == nil {
+		t.Error("Expected all-time closes")
+	}
+
+	from := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	windowed := decode(compare("a=widget-a&b=widget-b&from=" + from))
+	if windowed.A.Views != 4 || windowed.A.Submits != 1 || windowed.B.Submits != 2 {
+		t.Errorf("Expected the window to cover today's activity, got A %+v and B %+v", windowed.A, windowed.B)
+	}
+	if windowed.A.Closes != nil {
+		t.Error("Expected no closes for a time range")
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	empty := decode(compare("a=widget-a&b=widget-b&from=" + future))
+	if empty.A.Submits != 0 || empty.B.Submits != 0 || empty.Lift != nil {
+		t.Errorf("Expected no submissions and no lift in a future window, got A %+v, B %+v, lift %v", empty.A, empty.B, empty.Lift)
+	}
+
+	if w := compare("a=widget-a&b=foreign-widget"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a foreign widget, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := compare("a=widget-a&b=missing-widget"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing widget, got %d", w.Code)
+	}
+	if w := compare("a=widget-a"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without widget b, got %d", w.Code)
+	}
+	if w := compare("a=widget-a&b=widget-a"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for the same widget twice, got %d", w.Code)
+	}
+}
+
+func TestTailSubmissions_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	env.createTestWidget("widget-tail", "Tail Widget", "lead-form", true, now)
+	repo := storage.NewRedisSubmissionRepository(env.RedisClient)
+	for i, age := range []time.Duration{2 * time.Minute, time.Minute} {
+		if err := repo.Create(ctx, &models.Submission{
+			ID:        fmt.Sprintf("submission-%d", i),
+			WidgetID:  "widget-tail",
+			Data:      map[string]interface{}{"email": fmt.Sprintf("user%d@example.com", i)},
+			CreatedAt: now.Add(-age),
+		}); err != nil {
+			t.Fatalf("Failed to create submission: %v", err)
+		}
+	}
+
+	tail := func(query string) (*httptest.ResponseRecorder, models.SubmissionTail) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		env.Handler.TailSubmissions(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-tail/submissions/tail"+query, nil))
+		var response struct {
+			Data models.SubmissionTail `json:"data"`
+		}
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return w, response.Data
+	}
+
+	// Existing submissions after the cursor are returned without waiting
+	start := time.Now()
+	w, result := tail(fmt.Sprintf("?since=%d", now.Add(-90*time.Second).Unix()))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected an immediate return, took %v", elapsed)
+	}
+	if len(result.Submissions) != 1 || result.Submissions[0].ID != "submission-1" {
+		t.Fatalf("Expected only submission-1, got %+v", result.Submissions)
+	}
+	if result.Cursor == "" {
+		t.Fatal("Expected a cursor")
+	}
+
+	// Nothing newer than the returned cursor: an empty result once the wait ends
+	env.WidgetService.SetTailMaxWait(300 * time.Millisecond)
+	start = time.Now()
+	w, result = tail("?since=" + result.Cursor)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("Expected the request to be held for the max wait, returned after %v", elapsed)
+	}
+	if len(result.Submissions) != 0 || result.Cursor == "" {
+		t.Errorf("Expected no submissions and a cursor, got %+v", result)
+	}
+
+	if w, _ := tail("?since=yesterday"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid cursor, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	env.Handler.TailSubmissions(w, env.makeAuthenticatedRequest("GET", "/widgets/missing-widget/submissions/tail", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown widget, got %d", w.Code)
+	}
+}
+
+func TestSubmitWidget_Integration_PublicPolicy(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+	ctx := context.Background()
+
+	widget := &models.Widget{
+		ID:        "widget-policy",
+		OwnerID:   env.UserID,
+		Name:      "Policy Form",
+		Type:      "lead-form",
+		IsVisible: true,
+		Config: map[string]interface{}{
+			models.WidgetConfigPublicPolicyKey: map[string]interface{}{
+				"allowed_domains":  []interface{}{"example.com"},
+				"honeypot_field":   "website",
+				"min_fill_seconds": float64(2),
+				"allowed_fields":   []interface{}{"email"},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := env.WidgetRepo.Create(ctx, widget); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+
+	submit := func(origin string, data string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"data":%s,"started_at":%q}`, data, time.Now().Add(-time.Minute).Format(time.RFC3339))
+		req := httptest.NewRequest("POST", "/widgets/widget-policy/submit", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		publicHandler.SubmitWidget(w, req)
+		return w
+	}
+
+	rejected := []struct {
+		name   string
+		origin string
+		data   string
+		code   string
+	}{
+		{"foreign origin", "https://evil.test", `{"email":"a@example.com"}`, models.PolicyReasonDomainNotAllowed},
+		{"unknown field", "https://example.com", `{"email":"a@example.com","phone":"123"}`, models.PolicyReasonFieldNotAllowed},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			w := submit(tt.origin, tt.data)
+			var response models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			details, _ := response.Details.(map[string]interface{})
+			if w.Code != http.StatusForbidden || details["code"] != tt.code {
+				t.Errorf("Expected status 403 with code %s, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// A filled honeypot looks accepted to the bot, but is only counted as spam
+	w := submit("https://example.com", `{"email":"a@example.com","website":"spam"}`)
+	var receipt struct {
+		Data models.Submission `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &receipt); err != nil || w.Code != http.StatusCreated || receipt.Data.ID == "" {
+		t.Fatalf("Expected status 201 with a submission ID for the honeypot, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := submit("https://www.example.com", `{"email":"a@example.com","website":""}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	submissions, _, err := storage.NewRedisSubmissionRepository(env.RedisClient).GetByWidgetID(ctx, "widget-policy", models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil || len(submissions) != 1 {
+		t.Fatalf("Expected 1 submission, got %d (err: %v)", len(submissions), err)
+	}
+	if submissions[0].ID == receipt.Data.ID {
+		t.Error("Expected the honeypot submission not to be stored")
+	}
+	if _, ok := submissions[0].Data["website"]; ok {
+		t.Errorf("Expected the honeypot field to be stripped, got %v", submissions[0].Data)
+	}
+
+	stats, err := env.StatsRepo.GetWidgetStats(ctx, "widget-policy")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Spam != 1 || stats.Submits != 1 {
+		t.Errorf("Expected 1 spam and 1 submit, got %d spam and %d submits", stats.Spam, stats.Submits)
+	}
+}
+
+func TestSubmitWidget_Integration_FormToken(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+
+	widget := env.createTestWidget("widget-1", "Contact", "lead-form", true, time.Now())
+	widget.Config = map[string]interface{}{
+		models.WidgetConfigPublicPolicyKey: map[string]interface{}{"min_fill_seconds": float64(3)},
+	}
+	if err := env.WidgetRepo.Update(ctx, widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+	env.createTestWidget("widget-2", "Other", "lead-form", true, time.Now())
+
+	formToken := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		publicHandler.FormToken(w, httptest.NewRequest("GET", "/widgets/widget-1/form-token", nil))
+		return w
+	}
+	submit := func(extra string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/widgets/widget-1/submit", bytes.NewBufferString(`{"data":{"email":"a@example.com"}`+extra+`}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		publicHandler.SubmitWidget(w, req)
+		return w
+	}
+	earlier := time.Now().Add(-time.Minute).Format(time.RFC3339)
+
+	if w := formToken(); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 while form tokens are disabled, got %d: %s", w.Code, w.Body.String())
+	}
+	// Without form tokens the client's started_at is trusted
+	if w := submit(`,"started_at":"` + earlier + `"`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 with started_at, got %d: %s", w.Code, w.Body.String())
+	}
+
+	signer := services.NewFormTokenSigner("form-secret")
+	env.WidgetService.SetFormTokenSigner(signer)
+
+	w := formToken()
+	var response struct {
+		Data models.FormToken `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || w.Code != http.StatusOK || response.Data.Token == "" {
+		t.Fatalf("Expected a form token, got %d: %s", w.Code, w.Body.String())
+	}
+
+	rejected := map[string]string{
+		"fresh token":        `,"form_token":"` + response.Data.Token + `"`,
+		"started_at only":    `,"started_at":"` + earlier + `"`,
+		"other widget token": `,"form_token":"` + signer.Issue("widget-2", time.Now().Add(-time.Minute)) + `"`,
+		"forged token":       `,"form_token":"` + strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10) + `.forged"`,
+	}
+	for name, extra := range rejected {
+		t.Run(name, func(t *testing.T) {
+			w := submit(extra)
+			var response models.ErrorResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			details, _ := response.Details.(map[string]interface{})
+			if w.Code != http.StatusForbidden || details["code"] != models.PolicyReasonTooFast {
+				t.Errorf("Expected status 403 with code %s, got %d: %s", models.PolicyReasonTooFast, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if w := submit(`,"form_token":"` + signer.Issue("widget-1", time.Now().Add(-time.Minute)) + `"`); w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 with a token rendered a minute ago, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetSubmissionByCorrelation_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	widget := &models.Widget{
+		ID:        "widget-correlated",
+		OwnerID:   env.UserID,
+		Name:      "Checkout Form",
+		Type:      "lead-form",
+		IsVisible: true,
+		Config:    map[string]interface{}{models.WidgetConfigCorrelationFieldKey: "order_id"},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := env.WidgetRepo.Create(ctx, widget); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+
+	var submitted *models.Submission
+	for _, orderID := range []interface{}{"ord-1", float64(42)} {
+		submission, err := env.WidgetService.SubmitWidget(ctx, widget.ID, models.SubmissionRequest{
+			Data: map[string]interface{}{"order_id": orderID, "email": "a@example.com"},
+		})
+		if err != nil {
+			t.Fatalf("Failed to submit widget: %v", err)
+		}
+		if orderID == "ord-1" {
+			submitted = submission
+		}
+	}
+
+	lookup := func(widgetID, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		path := "/widgets/" + widgetID + "/submissions/by-correlation?key=" + url.QueryEscape(key)
+		env.Handler.GetSubmissionByCorrelation(w, env.makeAuthenticatedRequest("GET", path, nil))
+		return w
+	}
+
+	w := lookup(widget.ID, "ord-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Data models.Submission `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Data.ID != submitted.ID || response.Data.Data["order_id"] != "ord-1" {
+		t.Errorf("Expected submission %s, got %+v", submitted.ID, response.Data)
+	}
+
+	if w := lookup(widget.ID, "42"); w.Code != http.StatusOK {
+		t.Errorf("Expected numeric correlation values to be found, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := lookup(widget.ID, "ord-unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown key, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := lookup(widget.ID, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a key, got %d", w.Code)
+	}
+
+	// Expired submissions are not returned
+	env.Redis.Del(storage.GenerateSubmissionKey(widget.ID, submitted.ID))
+	if w := lookup(widget.ID, "ord-1"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an expired submission, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestArchiveWidget_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+	now := time.Now()
+
+	env.createTestWidget("widget-1", "Lead Form Widget", "lead-form", true, now.Add(-2*time.Hour))
+	env.createTestWidget("widget-2", "Banner Widget", "banner", true, now.Add(-1*time.Hour))
+
+	listWidgetIDs := func(t *testing.T, query string) []string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		env.Handler.GetWidgets(w, env.makeAuthenticatedRequest("GET", "/api/v1/widgets"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Widgets []models.Widget `json:"widgets"`
+			Meta    models.Meta     `json:"meta"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		ids := make([]string, 0, len(response.Widgets))
+		for _, widget := range response.Widgets {
+			ids = append(ids, widget.ID)
+		}
+		if response.Meta.Total != len(ids) {
+			t.Errorf("Expected total %d, got %d", len(ids), response.Meta.Total)
+		}
+		return ids
+	}
+
+	w := httptest.NewRecorder()
+	env.Handler.ArchiveWidget(w, env.makeAuthenticatedRequest("POST", "/widgets/widget-1/archive", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var archived models.Widget
+	if err := json.Unmarshal(w.Body.Bytes(), &archived); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !archived.Archived || archived.ArchivedAt == nil {
+		t.Errorf("Expected widget to be archived with a timestamp, got %+v", archived)
+	}
+
+	t.Run("excluded by default", func(t *testing.T) {
+		for _, query := range []string{"", "?type=lead-form,banner", "?sort=submissions"} {
+			if ids := listWidgetIDs(t, query); len(ids) != 1 || ids[0] != "widget-2" {
+				t.Errorf("Expected only widget-2 for %q, got %v", query, ids)
+			}
+		}
+	})
+
+	t.Run("included on request", func(t *testing.T) {
+		if ids := listWidgetIDs(t, "?includeArchived=true"); len(ids) != 2 {
+			t.Errorf("Expected both widgets, got %v", ids)
+		}
+	})
+
+	t.Run("rejects submissions", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/widgets/widget-1/submit", bytes.NewBufferString(`{"data":{"email":"a@example.com"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		publicHandler.SubmitWidget(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		env.Handler.UpdateWidget(w, env.makeAuthenticatedRequest("POST", "/widgets/widget-1", []byte(`{"name":"Renamed"}`)))
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status 409, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("unarchive", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		env.Handler.ArchiveWidget(w, env.makeAuthenticatedRequest("DELETE", "/widgets/widget-1/archive", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if ids := listWidgetIDs(t, ""); len(ids) != 2 {
+			t.Errorf("Expected both widgets after unarchiving, got %v", ids)
+		}
+	})
+}
+
+func TestDisableInactiveWidgets_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+
+	stale := time.Now().AddDate(0, 0, -60)
+	env.createTestWidget("inactive-widget", "Inactive", "lead-form", true, stale)
+	env.createTestWidget("viewed-widget", "Viewed", "lead-form", true, stale)
+	env.createTestWidget("updated-widget", "Updated", "lead-form", true, time.Now())
+	if err := env.StatsRepo.IncrementViews(ctx, "viewed-widget"); err != nil {
+		t.Fatalf("Failed to record view: %v", err)
+	}
+
+	disabled, err := env.WidgetService.DisableInactiveWidgets(ctx, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to disable inactive widgets: %v", err)
+	}
+	if disabled != 1 {
+		t.Errorf("Expected 1 disabled widget, got %d", disabled)
+	}
+
+	expected := map[string]bool{"inactive-widget": false, "viewed-widget": true, "updated-widget": true}
+	for id, visible := range expected {
+		widget, err := env.WidgetRepo.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to get widget %s: %v", id, err)
+		}
+		if widget.IsVisible != visible {
+			t.Errorf("Expected widget %s visible=%v, got %v", id, visible, widget.IsVisible)
+		}
+	}
+
+	// Re-enabling counts as activity
+	body, _ := json.Marshal(map[string]interface{}{"isVisible": true})
+	w := httptest.NewRecorder()
+	env.Handler.UpdateWidget(w, env.makeAuthenticatedRequest("POST", "/widgets/inactive-widget", body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d re-enabling the widget, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if disabled, err := env.WidgetService.DisableInactiveWidgets(ctx, 30*24*time.Hour); err != nil || disabled != 0 {
+		t.Errorf("Expected re-enabled widget to be kept, got %d disabled (%v)", disabled, err)
+	}
+}
+
+func TestGetFieldDistribution_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	env.createTestWidget("widget-plans", "Plan Form", "lead-form", true, time.Now())
+
+	repo := storage.NewRedisSubmissionRepository(env.RedisClient)
+	base := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	submitted := []map[string]interface{}{
+		{"plan": "pro"},
+		{"plan": "free"},
+		{"plan": "pro"},
+		{"plan": "team", "addons": []interface{}{"sso", "audit"}},
+		{"email": "no-plan@example.com"},
+	}
+	for i, data := range submitted {
+		submission := &models.Submission{
+			ID:        fmt.Sprintf("plan-%d", i),
+			WidgetID:  "widget-plans",
+			Data:      data,
+			CreatedAt: base.Add(time.Duration(i) * time.Hour),
+			TTL:       24 * time.Hour,
+		}
+		if err := repo.Create(context.Background(), submission); err != nil {
+			t.Fatalf("Failed to create submission: %v", err)
+		}
+	}
+
+	getDistribution := func(field, query string) models.FieldDistribution {
+		t.Helper()
+		w := httptest.NewRecorder()
+		env.Handler.GetFieldDistribution(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-plans/fields/"+field+"/distribution?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data models.FieldDistribution `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Data
+	}
+
+	plans := getDistribution("plan", "")
+	expected := map[string]int{"pro": 2, "free": 1, "team": 1}
+	if !reflect.DeepEqual(plans.Buckets, expected) {
+		t.Errorf("Expected buckets %v, got %v", expected, plans.Buckets)
+	}
+	if plans.Total != 5 || plans.Missing != 1 || plans.Other != 0 || plans.Truncated {
+		t.Errorf("Expected 5 counted with 1 missing and nothing else, got %+v", plans)
+	}
+
+	// Only the first two submissions fall in the range
+	ranged := getDistribution("plan", "from=2024-03-04T00:00:00Z&to=2024-03-04T13:30:00Z")
+	if !reflect.DeepEqual(ranged.Buckets, map[string]int{"pro": 1, "free": 1}) || ranged.Total != 2 {
+		t.Errorf("Expected pro and free once within the range, got %+v", ranged)
+	}
+
+	// Each element of a list value counts
+	addons := getDistribution("addons", "")
+	if !reflect.DeepEqual(addons.Buckets, map[string]int{"sso": 1, "audit": 1}) || addons.Missing != 4 {
+		t.Errorf("Expected one bucket per list element, got %+v", addons)
+	}
+}
+
+func TestProblemDetailsErrors_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	problems := middleware.NewProblemErrors(middleware.ErrorFormatSimple)
+
+	serve := func(handler http.HandlerFunc, req *http.Request) (*httptest.ResponseRecorder, map[string]interface{}) {
+		t.Helper()
+		req.Header.Set("Accept", models.ProblemContentType)
+		w := httptest.NewRecorder()
+		problems.Handle(handler).ServeHTTP(w, req)
+		if w.Header().Get("Content-Type") != models.ProblemContentType {
+			t.Errorf("Expected Content-Type %s, got %s", models.ProblemContentType, w.Header().Get("Content-Type"))
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return w, body
+	}
+
+	w, notFound := serve(env.Handler.GetWidget, env.makeAuthenticatedRequest("GET", "/widgets/missing-widget", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, got %d: %s", w.Code, w.Body.String())
+	}
+	expected := map[string]interface{}{
+		"type":     models.ProblemTypePrefix + "not_found",
+		"title":    "Not Found",
+		"status":   float64(http.StatusNotFound),
+		"detail":   "Widget not found",
+		"instance": "/widgets/missing-widget",
+	}
+	if !reflect.DeepEqual(notFound, expected) {
+		t.Errorf("Expected %v, got %v", expected, notFound)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"type": "lead-form", "name": "", "config": map[string]interface{}{}})
+	w, invalid := serve(env.Handler.CreateWidget, env.makeAuthenticatedRequest("POST", "/api/v1/widgets", body))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if invalid["type"] != models.ProblemTypePrefix+"validation_failed" || invalid["status"] != float64(http.StatusBadRequest) || invalid["title"] != "Bad Request" {
+		t.Errorf("Expected a validation_failed problem, got %v", invalid)
+	}
+	fieldErrors, ok := invalid["errors"].([]interface{})
+	if !ok || len(fieldErrors) == 0 {
+		t.Fatalf("Expected field errors in the errors member, got %v", invalid["errors"])
+	}
+	if fieldErr, _ := fieldErrors[0].(map[string]interface{}); fieldErr["field"] == nil || fieldErr["message"] == nil {
+		t.Errorf("Expected field and message in field errors, got %v", fieldErrors[0])
+	}
+
+	// Without the Accept header errors keep the simple format
+	w = httptest.NewRecorder()
+	problems.Handle(http.HandlerFunc(env.Handler.GetWidget)).ServeHTTP(w, env.makeAuthenticatedRequest("GET", "/widgets/missing-widget", nil))
+	if w.Header().Get("Content-Type") != "application/json" || !strings.Contains(w.Body.String(), `"error":"Widget not found"`) {
+		t.Errorf("Expected simple error body, got %s", w.Body.String())
+	}
+}
+
+func TestTrackingPixel_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+	ctx := context.Background()
+
+	widget := env.createTestWidget("widget-1", "Pixel Form", "lead-form", true, time.Now())
+
+	trackEvent := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		publicHandler.TrackEvent(w, httptest.NewRequest("GET", "/widgets/widget-1/events?"+query, nil))
+		return w
+	}
+	trackSubmission := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		publicHandler.TrackSubmission(w, httptest.NewRequest("GET", "/widgets/widget-1/track?"+query, nil))
+		return w
+	}
+
+	// Pixel tracking is off by default
+	if w := trackEvent("type=view"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d without pixel tracking, got %d: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+	if w := trackSubmission("email=a@example.com"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d without pixel tracking, got %d: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+
+	widget.Config = map[string]interface{}{models.WidgetConfigPixelTrackingKey: true}
+	if err := env.WidgetRepo.Update(ctx, widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+
+	t.Run("view event", func(t *testing.T) {
+		w := trackEvent("type=view")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "image/gif" || !bytes.HasPrefix(w.Body.Bytes(), []byte("GIF89a")) {
+			t.Errorf("Expected a GIF pixel, got %q", w.Header().Get("Content-Type"))
+		}
+		if w.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("Expected an uncacheable pixel, got Cache-Control %q", w.Header().Get("Cache-Control"))
+		}
+
+		stats, err := env.StatsRepo.GetWidgetStats(ctx, "widget-1")
+		if err != nil {
+			t.Fatalf("Failed to get stats: %v", err)
+		}
+		if stats.Views != 1 {
+			t.Errorf("Expected 1 view, got %d", stats.Views)
+		}
+
+		if w := trackEvent("type=click"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an unknown event type, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("field capture", func(t *testing.T) {
+		w := trackSubmission("email=a@example.com&source=newsletter")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "image/gif" {
+			t.Errorf("Expected a GIF pixel, got %q", w.Header().Get("Content-Type"))
+		}
+
+		submissions, total, err := env.WidgetService.GetWidgetSubmissions(ctx, "widget-1", env.UserID, models.PaginationOptions{Page: 1, PerPage: 10})
+		if err != nil {
+			t.Fatalf("Failed to get submissions: %v", err)
+		}
+		if total != 1 {
+			t.Fatalf("Expected 1 submission, got %d", total)
+		}
+		if submissions[0].Data["email"] != "a@example.com" || submissions[0].Data["source"] != "newsletter" {
+			t.Errorf("Expected query params as data, got %v", submissions[0].Data)
+		}
+	})
+
+	t.Run("invalid fields", func(t *testing.T) {
+		for _, query := range []string{"", "email=a@example.com&email=b@example.com", "bad-name=1"} {
+			if w := trackSubmission(query); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %q, got %d: %s", http.StatusBadRequest, query, w.Code, w.Body.String())
+			}
+		}
+	})
+}
+
+func TestGetWidgetSubmissions_Integration_Cursor(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+	env.createTestWidget("widget-1", "Cursor Form", "lead-form", true, time.Now())
+
+	submissionRepo := storage.NewRedisSubmissionRepository(env.RedisClient)
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, id := range []string{"sub-1", "sub-2", "sub-3"} {
+		if err := submissionRepo.Create(ctx, &models.Submission{ID: id, WidgetID: "widget-1", Data: map[string]interface{}{"n": i}, CreatedAt: base.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatalf("Failed to create submission: %v", err)
+		}
+	}
+
+	get := func(query string) (*httptest.ResponseRecorder, []string, *models.Meta) {
+		w := httptest.NewRecorder()
+		env.Handler.GetWidgetSubmissions(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-1/submissions?"+query, nil))
+		var response struct {
+			Data []*models.Submission `json:"data"`
+			Meta *models.Meta         `json:"meta"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		var ids []string
+		for _, submission := range response.Data {
+			ids = append(ids, submission.ID)
+		}
+		return w, ids, response.Meta
+	}
+
+	w, ids, meta := get("cursor=&per_page=2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if strings.Join(ids, ",") != "sub-3,sub-2" || meta.Total != 3 || !meta.HasMore || meta.NextCursor == "" || meta.Links != nil {
+		t.Fatalf("Unexpected first cursor page %v with meta %+v", ids, meta)
+	}
+
+	w, ids, meta = get("cursor=" + meta.NextCursor + "&per_page=2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if strings.Join(ids, ",") != "sub-1" || meta.HasMore || meta.NextCursor != "" {
+		t.Errorf("Unexpected last cursor page %v with meta %+v", ids, meta)
+	}
+
+	// Without a cursor, pages are still numbered
+	if _, ids, meta = get("page=2&per_page=2"); strings.Join(ids, ",") != "sub-1" || meta.Page != 2 {
+		t.Errorf("Expected offset pagination without a cursor, got %v with meta %+v", ids, meta)
+	}
+
+	for _, query := range []string{"cursor=not-a-cursor", "cursor=&region=de"} {
+		if w, _, _ := get(query); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %q, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
+
+func TestSubmitWidget_Integration_FieldDefinitions(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	publicHandler := NewPublicHandler(env.WidgetService, env.Validator)
+
+	widget := env.createTestWidget("widget-1", "Defined Form", "lead-form", true, time.Now())
+	widget.Config = map[string]interface{}{
+		models.WidgetConfigFieldsKey: map[string]interface{}{
+			"email": map[string]interface{}{"type": "email", "required": true},
+			"phone": map[string]interface{}{"type": "text", "required": true},
+		},
+	}
+	if err := env.WidgetRepo.Update(context.Background(), widget); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+
+	submit := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/widgets/widget-1/submit", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		publicHandler.SubmitWidget(w, req)
+		return w
+	}
+
+	w := submit(`{"data":{"email":"not-an-email"}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	var response struct {
+		Error   string               `json:"error"`
+		Details []*models.FieldError `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Details) != 2 || response.Details[0].Field != "data.email" || response.Details[1].Field != "data.phone" {
+		t.Errorf("Expected errors for data.email and data.phone, got %s", w.Body.String())
+	}
+
+	// Fields without a definition are accepted
+	if w := submit(`{"data":{"email":"jane@example.com","phone":"555","comment":"hi"}}`); w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+}
+
+func TestBulkDeleteSubmissions_Integration(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	env.createTestWidget("widget-1", "Spam Target", "lead-form", true, base)
+	submissionRepo := storage.NewRedisSubmissionRepository(env.RedisClient)
+	for i, id := range []string{"old", "spam-1", "spam-2", "new"} {
+		if err := submissionRepo.Create(ctx, &models.Submission{
+			ID:        id,
+			WidgetID:  "widget-1",
+			Data:      map[string]interface{}{"name": id},
+			CreatedAt: base.Add(time.Duration(i) * time.Hour),
+		}); err != nil {
+			t.Fatalf("Failed to create submission: %v", err)
+		}
+	}
+
+	bulkDelete := func(body string) *httptest.ResponseRecorder {
+		req := env.makeAuthenticatedRequest("POST", "/widgets/widget-1/submissions/bulk-delete", []byte(body))
+		w := httptest.NewRecorder()
+		env.Handler.BulkDeleteSubmissions(w, req)
+		return w
+	}
+
+	// Neither bounds nor confirmation, or reversed bounds, delete nothing
+	for _, body := range []string{`{}`, `{"confirm": false}`, `{"from": "2024-01-15T13:00:00Z", "to": "2024-01-15T10:00:00Z"}`, `{"from": "yesterday"}`} {
+		if w := bulkDelete(body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d: %s", http.StatusBadRequest, body, w.Code, w.Body.String())
+		}
+	}
+
+	w := bulkDelete(`{"from": "2024-01-15T11:00:00Z", "to": "2024-01-15T12:00:00Z"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var result models.SubmissionBulkDeleteResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Deleted != 2 {
+		t.Errorf("Expected 2 deleted, got %d", result.Deleted)
+	}
+	for _, id := range []string{"old", "new"} {
+		if _, err := submissionRepo.GetByID(ctx, "widget-1", id); err != nil {
+			t.Errorf("Expected out-of-range submission %s to survive, got %v", id, err)
+		}
+	}
+
+	// Confirmed deletes without bounds clear the rest
+	w = bulkDelete(`{"confirm": true}`)
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || result.Deleted != 2 {
+		t.Errorf("Expected the remaining 2 deleted, got %s", w.Body.String())
+	}
+
+	// Other users' widgets are not found
+	req := env.makeAuthenticatedRequest("POST", "/widgets/widget-1/submissions/bulk-delete", []byte(`{"confirm": true}`))
+	req = req.WithContext(auth.SetUserInContext(req.Context(), &models.User{ID: "someone-else"}))
+	w = httptest.NewRecorder()
+	env.Handler.BulkDeleteSubmissions(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for another user, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestGetWidgetSubmissions_Integration_Search(t *testing.T) {
+	env := setupIntegrationTestEnvironment(t)
+	ctx := context.Background()
+	env.createTestWidget("widget-1", "Search Form", "lead-form", true, time.Now())
+
+	submissionRepo := storage.NewRedisSubmissionRepository(env.RedisClient)
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	data := []map[string]interface{}{
+		{"email": "jane@example.com", "message": "Need a quote"},
+		{"email": "john@example.com", "details": map[string]interface{}{"note": "Call JANE back"}},
+		{"email": "max@example.com", "message": "Just browsing"},
+	}
+	for i, values := range data {
+		if err := submissionRepo.Create(ctx, &models.Submission{ID: fmt.Sprintf("sub-%d", i+1), WidgetID: "widget-1", Data: values, CreatedAt: base.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatalf("Failed to create submission: %v", err)
+		}
+	}
+
+	get := func(query string) (*httptest.ResponseRecorder, []string, *models.Meta) {
+		w := httptest.NewRecorder()
+		env.Handler.GetWidgetSubmissions(w, env.makeAuthenticatedRequest("GET", "/widgets/widget-1/submissions?"+query, nil))
+		var response struct {
+			Data []*models.Submission `json:"data"`
+			Meta *models.Meta         `json:"meta"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		var ids []string
+		for _, submission := range response.Data {
+			ids = append(ids, submission.ID)
+		}
+		return w, ids, response.Meta
+	}
+
+	// Matches include nested values, newest first
+	w, ids, meta := get("search=jane")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !reflect.DeepEqual(ids, []string{"sub-2", "sub-1"}) || meta.Total != 2 {
+		t.Errorf("Expected sub-2 and sub-1 of 2 matches, got %v of %d", ids, meta.Total)
+	}
+
+	if _, ids, meta := get("search=jane&per_page=1&page=2"); !reflect.DeepEqual(ids, []string{"sub-1"}) || meta.Total != 2 {
+		t.Errorf("Expected sub-1 on the second page, got %v of %d", ids, meta.Total)
+	}
+
+	for _, query := range []string{"search=jane&cursor=", "search=jane&region=eu"} {
+		if w, _, _ := get(query); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
@@ -63,6 +63,7 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 		}
 
 		// Add user to context
+		setAccessLogUser(r.Context(), user.ID)
 		ctx := auth.SetUserInContext(r.Context(), user)
 		r = r.WithContext(ctx)
 
@@ -71,6 +72,32 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	})
 }
 
+// WidgetScope validates an optional widget-scoped token on public endpoints and
+// adds its widget ID to context. Requests without a widget token pass through
+// unchanged, so embeds in a page sending a user's token keep working.
+func (m *AuthMiddleware) WidgetScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" || !m.validator.IsWidgetToken(authHeader) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		widgetID, err := m.validator.ValidateWidgetToken(authHeader)
+		if err != nil {
+			logger.Debug("Widget token validation failed", map[string]interface{}{
+				"action": "widget_scope",
+				"error":  err.Error(),
+			})
+			writeErrorResponse(w, http.StatusUnauthorized, "Invalid or expired widget token")
+			return
+		}
+
+		ctx := auth.SetWidgetScopeInContext(r.Context(), widgetID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 // RequireAuth is a convenience method that combines authentication with authorization check
 func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 	return m.Authenticate(next)
@@ -78,6 +105,11 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 
 // writeErrorResponse writes an error response
 func writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	if instance, ok := ProblemInstance(w); ok {
+		WriteProblem(w, statusCode, message, nil, instance)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
@@ -150,3 +150,55 @@ func TestAuthMiddleware_MissingTokenIntegration(t *testing.T) {
 		t.Errorf("Expected status 401, got %d", rec.Code)
 	}
 }
+
+func TestAuthMiddleware_WidgetScope(t *testing.T) {
+	secret := "test-secret-for-middleware"
+	middleware := NewAuthMiddleware(auth.NewJWTValidator(secret), false)
+
+	sign := func(claims jwt.MapClaims) string {
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("Failed to create test token: %v", err)
+		}
+		return "Bearer " + tokenString
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name           string
+		authHeader     string
+		expectedStatus int
+		expectedScope  string
+	}{
+		{name: "no token", expectedStatus: http.StatusOK},
+		{name: "widget token", authHeader: sign(jwt.MapClaims{"user_id": "u1", "widget_id": "widget-1", "exp": exp}), expectedStatus: http.StatusOK, expectedScope: "widget-1"},
+		{name: "user token is ignored", authHeader: sign(jwt.MapClaims{"user_id": "u1", "exp": exp}), expectedStatus: http.StatusOK},
+		{name: "undecodable token is ignored", authHeader: "Bearer not-a-token", expectedStatus: http.StatusOK},
+		{name: "expired widget token", authHeader: sign(jwt.MapClaims{"user_id": "u1", "widget_id": "widget-1", "exp": time.Now().Add(-time.Hour).Unix()}), expectedStatus: http.StatusUnauthorized},
+		{name: "widget token with a bad signature", authHeader: sign(jwt.MapClaims{"user_id": "u1", "widget_id": "widget-1", "exp": exp}) + "x", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var scope string
+			handler := middleware.WidgetScope(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				scope, _ = auth.GetWidgetScopeFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/widgets/widget-1/submit", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+			if scope != tt.expectedScope {
+				t.Errorf("Expected scope %q, got %q", tt.expectedScope, scope)
+			}
+		})
+	}
+}
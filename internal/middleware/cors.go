@@ -1,6 +1,14 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ad/leads-core/internal/config"
+	"github.com/ad/leads-core/pkg/logger"
+)
 
 // CORS middleware
 func CORS(next http.Handler) http.Handler {
@@ -19,3 +27,78 @@ func CORS(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// APICORS provides CORS for the private API, allowing only configured origins.
+// Unlike CORS it never answers with "*" because requests carry credentials.
+type APICORS struct {
+	allowedOrigins map[string]bool
+	maxAge         int
+}
+
+// NewAPICORS creates a new private API CORS middleware
+func NewAPICORS(config config.CORSConfig) *APICORS {
+	allowed := make(map[string]bool, len(config.AllowedOrigins))
+	for _, origin := range config.AllowedOrigins {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			allowed[origin] = true
+		}
+	}
+
+	return &APICORS{
+		allowedOrigins: allowed,
+		maxAge:         int(config.MaxAge.Seconds()),
+	}
+}
+
+// Handle sets CORS headers for allowed origins, answers preflight requests
+// and rejects cross-origin requests from other origins
+func (c *APICORS) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+
+		// Non-browser and same-origin requests (e.g. the panel) need no CORS headers
+		if origin == "" || isSameOrigin(origin, r.Host) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+
+		if !c.allowedOrigins[origin] {
+			logger.Debug("CORS origin rejected", map[string]interface{}{
+				"action": "api_cors",
+				"origin": origin,
+				"path":   r.URL.Path,
+			})
+			writeErrorResponse(w, http.StatusForbidden, "Origin not allowed")
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+
+		// Handle preflight requests
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			if c.maxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(c.maxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isSameOrigin checks if the Origin header points at the requested host
+func isSameOrigin(origin, host string) bool {
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return parsed.Host != "" && strings.EqualFold(parsed.Host, host)
+}
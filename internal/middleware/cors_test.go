@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/internal/config"
+)
+
+func newTestAPICORS() *APICORS {
+	return NewAPICORS(config.CORSConfig{
+		AllowedOrigins: []string{"https://dashboard.example.com", " https://admin.example.com/ "},
+		MaxAge:         5 * time.Minute,
+	})
+}
+
+func TestAPICORS_Preflight(t *testing.T) {
+	called := false
+	handler := newTestAPICORS().Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/widgets", nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "Authorization, Content-Type")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if called {
+		t.Error("Expected preflight not to reach the next handler")
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://dashboard.example.com" {
+		t.Errorf("Expected allowed origin to be echoed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
+		t.Errorf("Expected Authorization to be allowed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "300" {
+		t.Errorf("Expected max age 300, got %q", got)
+	}
+}
+
+func TestAPICORS_CredentialedRequestFromAllowedOrigin(t *testing.T) {
+	called := false
+	handler := newTestAPICORS().Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/widgets", nil)
+	req.Header.Set("Origin", "https://admin.example.com")
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !called {
+		t.Fatalf("Expected request to reach the handler, got status %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://admin.example.com" {
+		t.Errorf("Expected allowed origin to be echoed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Expected credentials to be allowed, got %q", got)
+	}
+}
+
+func TestAPICORS_RejectsDisallowedOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+	}{
+		{name: "preflight", method: http.MethodOptions},
+		{name: "request", method: http.MethodGet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := newTestAPICORS().Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			req := httptest.NewRequest(tt.method, "/api/v1/widgets", nil)
+			req.Header.Set("Origin", "https://evil.example.com")
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+			}
+			if called {
+				t.Error("Expected disallowed origin not to reach the handler")
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+				t.Errorf("Expected no allowed origin header, got %q", got)
+			}
+		})
+	}
+}
+
+func TestAPICORS_SameOriginAndNoOrigin(t *testing.T) {
+	handler := newTestAPICORS().Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, origin := range []string{"", "http://example.com"} {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/api/v1/widgets", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected origin %q to pass through, got status %d", origin, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Expected no CORS headers for origin %q, got %q", origin, got)
+		}
+	}
+}
@@ -1,88 +1,148 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"time"
 
 	"github.com/ad/leads-core/pkg/logger"
+	"github.com/ad/leads-core/pkg/metrics"
+	"github.com/google/uuid"
 )
 
-// LoggingMiddleware logs HTTP requests with structured logging
+// RequestIDHeader carries the request ID; incoming values are kept, others are generated
+const RequestIDHeader = "X-Request-ID"
+
+// Access log fields that can be enabled
+const (
+	AccessLogMethod    = "method"
+	AccessLogRoute     = "route" // Templated path, e.g. /api/v1/widgets/{id}, free of IDs
+	AccessLogPath      = "path"  // Raw path
+	AccessLogQuery     = "query" // Raw query string, may contain personal data
+	AccessLogStatus    = "status"
+	AccessLogBytes     = "bytes"
+	AccessLogDuration  = "duration_ms"
+	AccessLogClientIP  = "client_ip"
+	AccessLogUserID    = "user_id"
+	AccessLogRequestID = "request_id"
+	AccessLogUserAgent = "user_agent"
+	AccessLogReferer   = "referer"
+)
+
+// slowRequestDuration is the latency from which successful requests are logged as warnings
+const slowRequestDuration = time.Second
+
+// DefaultAccessLogFields are logged when no fields are configured
+var DefaultAccessLogFields = []string{
+	AccessLogMethod, AccessLogRoute, AccessLogStatus, AccessLogBytes, AccessLogDuration,
+	AccessLogClientIP, AccessLogUserID, AccessLogRequestID,
+}
+
+// accessLogKey is the context key of the per-request access log entry
+type accessLogKey struct{}
+
+// accessLogEntry collects request details set further down the chain
+type accessLogEntry struct {
+	userID string
+}
+
+// setAccessLogUser records the authenticated user for the access log line
+func setAccessLogUser(ctx context.Context, userID string) {
+	if entry, ok := ctx.Value(accessLogKey{}).(*accessLogEntry); ok {
+		entry.userID = userID
+	}
+}
+
+// LoggingMiddleware writes one structured access log line per request
 type LoggingMiddleware struct {
 	logger *logger.FieldLogger
+	fields map[string]bool
+	routes *metrics.RouteTemplates
 }
 
-// NewLoggingMiddleware creates a new logging middleware
-func NewLoggingMiddleware() *LoggingMiddleware {
+// NewLoggingMiddleware creates a new logging middleware logging the given fields
+// (DefaultAccessLogFields when empty); routes template the route field, without
+// them it holds the raw path
+func NewLoggingMiddleware(fields []string, routes *metrics.RouteTemplates) *LoggingMiddleware {
+	if len(fields) == 0 {
+		fields = DefaultAccessLogFields
+	}
+	enabled := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		enabled[field] = true
+	}
+
 	return &LoggingMiddleware{
 		logger: logger.WithFields(map[string]interface{}{
 			"component": "http_logger",
 		}),
+		fields: enabled,
+		routes: routes,
 	}
 }
 
-// LogRequests wraps an HTTP handler with request logging
+// LogRequests wraps an HTTP handler with access logging
 func (lm *LoggingMiddleware) LogRequests(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		// Resolve the route before calling the handler, routers rewrite the path
+		path := r.URL.Path
+		route := path
+		if lm.routes != nil {
+			route = lm.routes.Match(path)
+		}
+
 		// Create a wrapped response writer to capture status code and response size
 		wrapped := &loggingResponseWriter{
 			ResponseWriter: w,
 			statusCode:     http.StatusOK,
 			responseSize:   0,
 		}
-
-		// Log request start
-		lm.logger.Info("HTTP request started", map[string]interface{}{
-			"method":      r.Method,
-			"url":         r.URL.String(),
-			"remote_addr": r.RemoteAddr,
-			"user_agent":  r.UserAgent(),
-			"referer":     r.Referer(),
-		})
+		entry := &accessLogEntry{}
 
 		// Call the next handler
-		next.ServeHTTP(wrapped, r)
+		next.ServeHTTP(wrapped, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, entry)))
 
-		// Calculate duration
 		duration := time.Since(start)
-
-		// Determine log level based on status code
-		logLevel := "info"
-		if wrapped.statusCode >= 400 && wrapped.statusCode < 500 {
-			logLevel = "warn"
-		} else if wrapped.statusCode >= 500 {
-			logLevel = "error"
+		values := map[string]interface{}{
+			AccessLogMethod:    r.Method,
+			AccessLogRoute:     route,
+			AccessLogPath:      path,
+			AccessLogQuery:     r.URL.RawQuery,
+			AccessLogStatus:    wrapped.statusCode,
+			AccessLogBytes:     wrapped.responseSize,
+			AccessLogDuration:  duration.Milliseconds(),
+			AccessLogClientIP:  ClientIP(r),
+			AccessLogUserID:    entry.userID,
+			AccessLogRequestID: requestID,
+			AccessLogUserAgent: r.UserAgent(),
+			AccessLogReferer:   r.Referer(),
 		}
-
-		// Log request completion
-		fields := map[string]interface{}{
-			"method":        r.Method,
-			"url":           r.URL.String(),
-			"status":        wrapped.statusCode,
-			"duration_ms":   duration.Milliseconds(),
-			"response_size": wrapped.responseSize,
-			"remote_addr":   r.RemoteAddr,
+		fields := make(map[string]interface{}, len(lm.fields))
+		for name, value := range values {
+			if lm.fields[name] {
+				fields[name] = value
+			}
 		}
 
-		switch logLevel {
-		case "warn":
-			lm.logger.Warn("HTTP request completed with client error", fields)
-		case "error":
+		// Determine log level based on status code and latency
+		switch {
+		case wrapped.statusCode >= 500:
 			lm.logger.Error("HTTP request completed with server error", fields)
+		case wrapped.statusCode >= 400:
+			lm.logger.Warn("HTTP request completed with client error", fields)
+		case duration > slowRequestDuration:
+			lm.logger.Warn("Slow HTTP request completed", fields)
 		default:
 			lm.logger.Info("HTTP request completed", fields)
 		}
-
-		// Log slow requests
-		if duration > 1*time.Second {
-			lm.logger.Warn("Slow HTTP request detected", map[string]interface{}{
-				"method":      r.Method,
-				"url":         r.URL.String(),
-				"duration_ms": duration.Milliseconds(),
-			})
-		}
 	})
 }
 
@@ -110,8 +170,8 @@ func (lrw *loggingResponseWriter) Write(b []byte) (int, error) {
 var defaultLoggingMiddleware *LoggingMiddleware
 
 // InitLogging initializes the global logging middleware
-func InitLogging() {
-	defaultLoggingMiddleware = NewLoggingMiddleware()
+func InitLogging(fields []string, routes *metrics.RouteTemplates) {
+	defaultLoggingMiddleware = NewLoggingMiddleware(fields, routes)
 }
 
 // LogRequests provides access to the global logging middleware
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ad/leads-core/pkg/logger"
+	"github.com/ad/leads-core/pkg/metrics"
+)
+
+// captureAccessLog runs one request through the logging middleware and returns its log entry
+func captureAccessLog(t *testing.T, fields []string, req *http.Request) (*httptest.ResponseRecorder, logger.LogEntry) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger.Init("leads-core-test", "test")
+	logger.SetOutput(&buf)
+
+	routes := metrics.NewRouteTemplates("/api/v1/widgets/{id}")
+	handler := NewLoggingMiddleware(fields, routes).LogRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setAccessLogUser(r.Context(), "user-1")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"Widget not found"}`))
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var entry logger.LogEntry
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry); err != nil {
+		t.Fatalf("Expected a single JSON log line, got %q: %v", buf.String(), err)
+	}
+	return rr, entry
+}
+
+func TestLoggingMiddleware_DefaultFields(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/widgets/abc-123?page=2", nil)
+	req.RemoteAddr = "203.0.113.7:1234"
+	req.Header.Set("User-Agent", "test-agent")
+
+	rr, entry := captureAccessLog(t, nil, req)
+
+	if entry.Level != "WARN" {
+		t.Errorf("Expected WARN level for a 4xx response, got %s", entry.Level)
+	}
+
+	expected := map[string]interface{}{
+		AccessLogMethod:   "GET",
+		AccessLogRoute:    "/api/v1/widgets/{id}",
+		AccessLogStatus:   float64(http.StatusNotFound),
+		AccessLogBytes:    float64(len(`{"error":"Widget not found"}`)),
+		AccessLogClientIP: "203.0.113.7",
+		AccessLogUserID:   "user-1",
+	}
+	for name, want := range expected {
+		if got := entry.Fields[name]; got != want {
+			t.Errorf("Expected %s=%v, got %v", name, want, got)
+		}
+	}
+	if _, ok := entry.Fields[AccessLogDuration]; !ok {
+		t.Error("Expected duration_ms field")
+	}
+
+	requestID := rr.Header().Get(RequestIDHeader)
+	if requestID == "" || entry.Fields[AccessLogRequestID] != requestID {
+		t.Errorf("Expected generated request ID %q to be logged, got %v", requestID, entry.Fields[AccessLogRequestID])
+	}
+
+	for _, name := range []string{AccessLogPath, AccessLogQuery, AccessLogUserAgent, AccessLogReferer} {
+		if _, ok := entry.Fields[name]; ok {
+			t.Errorf("Expected %s to be excluded by default", name)
+		}
+	}
+}
+
+func TestLoggingMiddleware_ConfiguredFields(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/widgets/abc-123?page=2", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+
+	rr, entry := captureAccessLog(t, []string{AccessLogPath, AccessLogQuery, AccessLogRequestID}, req)
+
+	if rr.Header().Get(RequestIDHeader) != "req-42" {
+		t.Errorf("Expected incoming request ID to be echoed, got %q", rr.Header().Get(RequestIDHeader))
+	}
+
+	expected := map[string]interface{}{
+		AccessLogPath:      "/api/v1/widgets/abc-123",
+		AccessLogQuery:     "page=2",
+		AccessLogRequestID: "req-42",
+	}
+	delete(entry.Fields, "component")
+	if len(entry.Fields) != len(expected) {
+		t.Errorf("Expected only the configured fields, got %v", entry.Fields)
+	}
+	for name, want := range expected {
+		if got := entry.Fields[name]; got != want {
+			t.Errorf("Expected %s=%v, got %v", name, want, got)
+		}
+	}
+}
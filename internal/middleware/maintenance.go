@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/ad/leads-core/internal/config"
+	"github.com/ad/leads-core/internal/storage"
+	"github.com/ad/leads-core/pkg/logger"
+)
+
+// MaintenanceCacheTTL is how long an instance uses the shared maintenance flag before
+// reading it again
+const MaintenanceCacheTTL = time.Second
+
+// Maintenance rejects mutating requests while maintenance mode is enabled. With a
+// repository the flag is shared by all instances, each caching it for cacheTTL;
+// MAINTENANCE_MODE applies until it is first toggled at runtime.
+type Maintenance struct {
+	enabled        atomic.Bool  // The flag, or its cached shared value with a repository
+	checkedAt      atomic.Int64 // Unix nanoseconds of the last shared flag read
+	defaultEnabled bool
+	repo           storage.MaintenanceRepository
+	cacheTTL       time.Duration
+	retryAfter     time.Duration
+}
+
+// NewMaintenance creates a new maintenance mode middleware
+func NewMaintenance(config config.MaintenanceConfig) *Maintenance {
+	m := &Maintenance{
+		defaultEnabled: config.Enabled,
+		cacheTTL:       MaintenanceCacheTTL,
+		retryAfter:     config.RetryAfter,
+	}
+	m.enabled.Store(config.Enabled)
+	return m
+}
+
+// SetRepository shares the maintenance flag through the repository, so toggling it
+// affects every instance
+func (m *Maintenance) SetRepository(repo storage.MaintenanceRepository) {
+	m.repo = repo
+}
+
+// Enabled reports whether maintenance mode is on. When the shared flag can't be read,
+// the last known value is used.
+func (m *Maintenance) Enabled(ctx context.Context) bool {
+	if m.repo == nil {
+		return m.enabled.Load()
+	}
+
+	now := time.Now().UnixNano()
+	if checkedAt := m.checkedAt.Load(); checkedAt != 0 && now-checkedAt < int64(m.cacheTTL) {
+		return m.enabled.Load()
+	}
+	m.checkedAt.Store(now)
+
+	enabled, set, err := m.repo.Get(ctx)
+	if err != nil {
+		logger.Error("Failed to read maintenance mode", map[string]interface{}{
+			"action": "maintenance_check",
+			"error":  err.Error(),
+		})
+		return m.enabled.Load()
+	}
+	if !set {
+		enabled = m.defaultEnabled
+	}
+	m.enabled.Store(enabled)
+	return enabled
+}
+
+// SetEnabled turns maintenance mode on or off, for all instances with a repository
+func (m *Maintenance) SetEnabled(ctx context.Context, enabled bool) error {
+	if m.repo != nil {
+		if err := m.repo.SetEnabled(ctx, enabled); err != nil {
+			return err
+		}
+		m.checkedAt.Store(time.Now().UnixNano())
+	}
+	if m.enabled.Swap(enabled) != enabled {
+		logger.Info("Maintenance mode changed", map[string]interface{}{
+			"action":  "maintenance_toggle",
+			"enabled": enabled,
+		})
+	}
+	return nil
+}
+
+// RetryAfter returns the delay advertised to clients in the Retry-After header
+func (m *Maintenance) RetryAfter() time.Duration {
+	return m.retryAfter
+}
+
+// Handle answers mutating requests with 503 during maintenance; reads pass through
+func (m *Maintenance) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isReadOnlyRequest(r) && m.Enabled(r.Context()) {
+			if seconds := int(m.retryAfter.Seconds()); seconds > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			}
+			if instance, ok := ProblemInstance(w); ok {
+				WriteProblem(w, http.StatusServiceUnavailable, "Service is in maintenance mode, write operations are temporarily disabled", map[string]string{"code": "maintenance"}, instance)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"maintenance","details":"Service is in maintenance mode, write operations are temporarily disabled"}`))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeRequestKey is the context key marking requests that modify data despite a
+// read-only method
+type writeRequestKey struct{}
+
+// MarkWrites marks the requests isWrite matches as modifying data, so maintenance mode
+// and the Redis health gate reject them even when they're GETs (e.g. tracking pixels)
+func MarkWrites(isWrite func(r *http.Request) bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isWrite(r) {
+			r = r.WithContext(context.WithValue(r.Context(), writeRequestKey{}, true))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isReadOnlyRequest reports whether the request does not modify data
+func isReadOnlyRequest(r *http.Request) bool {
+	if write, _ := r.Context().Value(writeRequestKey{}).(bool); write {
+		return false
+	}
+	return isReadOnlyMethod(r.Method)
+}
+
+// isReadOnlyMethod reports whether the HTTP method does not modify data
+func isReadOnlyMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/internal/config"
+	"github.com/ad/leads-core/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMaintenance_BlocksWritesAllowsReads(t *testing.T) {
+	maintenance := NewMaintenance(config.MaintenanceConfig{
+		Enabled:    true,
+		RetryAfter: 2 * time.Minute,
+	})
+	handler := maintenance.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// POST is rejected
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/widgets", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "120" {
+		t.Errorf("Expected Retry-After 120, got %q", got)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON body, got %q: %v", w.Body.String(), err)
+	}
+	if body["error"] != "maintenance" {
+		t.Errorf("Expected error 'maintenance', got %v", body["error"])
+	}
+
+	// GET passes through
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/widgets", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected GET to pass with status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestMaintenance_RuntimeToggle(t *testing.T) {
+	maintenance := NewMaintenance(config.MaintenanceConfig{RetryAfter: time.Minute})
+	handler := maintenance.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range []struct {
+		enabled  bool
+		expected int
+	}{
+		{false, http.StatusOK},
+		{true, http.StatusServiceUnavailable},
+		{false, http.StatusOK},
+	} {
+		if err := maintenance.SetEnabled(context.Background(), tc.enabled); err != nil {
+			t.Fatalf("SetEnabled failed: %v", err)
+		}
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/widgets/abc", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tc.expected {
+			t.Errorf("enabled=%v: expected status %d, got %d", tc.enabled, tc.expected, w.Code)
+		}
+	}
+}
+
+func TestMaintenance_BlocksMarkedWrites(t *testing.T) {
+	maintenance := NewMaintenance(config.MaintenanceConfig{Enabled: true})
+	isTrack := func(r *http.Request) bool { return r.URL.Path == "/widgets/widget-1/track" }
+	handler := MarkWrites(isTrack, maintenance.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/widgets/widget-1/track", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected marked GET to get status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/widgets/widget-1", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected unmarked GET to pass with status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestMaintenance_SharedAcrossInstances(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	repo := storage.NewRedisMaintenanceRepository(storage.NewRedisClientWithUniversal(redis.NewClient(&redis.Options{Addr: mr.Addr()})))
+
+	// MAINTENANCE_MODE applies until the flag is toggled
+	first := NewMaintenance(config.MaintenanceConfig{Enabled: true})
+	first.SetRepository(repo)
+	second := NewMaintenance(config.MaintenanceConfig{})
+	second.SetRepository(repo)
+	second.cacheTTL = 0
+	ctx := context.Background()
+	if !first.Enabled(ctx) || second.Enabled(ctx) {
+		t.Fatal("Expected each instance to start with its configured mode")
+	}
+
+	handler := second.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for _, enabled := range []bool{true, false} {
+		if err := first.SetEnabled(ctx, enabled); err != nil {
+			t.Fatalf("SetEnabled failed: %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/widgets", nil))
+		if expected := map[bool]int{true: http.StatusServiceUnavailable, false: http.StatusOK}[enabled]; w.Code != expected {
+			t.Errorf("enabled=%v on another instance: expected status %d, got %d", enabled, expected, w.Code)
+		}
+	}
+
+	// The last known value is kept while the flag can't be read
+	if err := first.SetEnabled(ctx, true); err != nil {
+		t.Fatalf("SetEnabled failed: %v", err)
+	}
+	if !second.Enabled(ctx) {
+		t.Fatal("Expected maintenance mode to be enabled")
+	}
+	mr.Close()
+	if !second.Enabled(ctx) {
+		t.Error("Expected the last known value while Redis is down")
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// AllowHeader returns the Allow header value for a route accepting the given methods;
+// OPTIONS is always listed
+func AllowHeader(methods ...string) string {
+	allowed := make([]string, 0, len(methods)+1)
+	for _, method := range methods {
+		if method != http.MethodOptions {
+			allowed = append(allowed, method)
+		}
+	}
+	return strings.Join(append(allowed, http.MethodOptions), ", ")
+}
+
+// AllowMethods answers OPTIONS requests with 204 and requests with other methods than
+// the given ones with 405, both advertising the route's methods in the Allow header.
+// It reports whether the request should be handled.
+func AllowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Allow", AllowHeader(methods...))
+		w.WriteHeader(http.StatusNoContent)
+		return false
+	}
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", AllowHeader(methods...))
+	writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	return false
+}
+
+// AnswerOptions answers OPTIONS requests to known routes before they reach
+// authentication, which tooling and preflight requests don't pass. methods resolves
+// the methods a path accepts, nil for unknown paths that are passed on.
+func AnswerOptions(methods func(path string) []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			if allowed := methods(r.URL.Path); len(allowed) > 0 {
+				AllowMethods(w, r, allowed...)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowMethods(t *testing.T) {
+	tests := []struct {
+		method  string
+		handled bool
+		status  int
+	}{
+		{http.MethodGet, true, http.StatusOK},
+		{http.MethodDelete, true, http.StatusOK},
+		{http.MethodOptions, false, http.StatusNoContent},
+		{http.MethodPut, false, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/api/v1/widgets/abc", nil)
+
+			if handled := AllowMethods(rr, req, http.MethodGet, http.MethodPost, http.MethodDelete); handled != tt.handled {
+				t.Fatalf("Expected handled=%v, got %v", tt.handled, handled)
+			}
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if !tt.handled && rr.Header().Get("Allow") != "GET, POST, DELETE, OPTIONS" {
+				t.Errorf("Expected Allow header listing the route methods, got %q", rr.Header().Get("Allow"))
+			}
+		})
+	}
+}
+
+func TestAnswerOptions(t *testing.T) {
+	methods := func(path string) []string {
+		if path == "/api/v1/user" {
+			return []string{http.MethodGet}
+		}
+		return nil
+	}
+	handler := AnswerOptions(methods, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeErrorResponse(w, http.StatusUnauthorized, "Authorization header is required")
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/api/v1/user", nil))
+	if rr.Code != http.StatusNoContent || rr.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Errorf("Expected 204 with Allow: GET, OPTIONS before authentication, got %d %q", rr.Code, rr.Header().Get("Allow"))
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/api/v1/unknown", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected OPTIONS to unknown paths to be passed on, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/user", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected other methods to be passed on, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ad/leads-core/internal/models"
+)
+
+// Error formats
+const (
+	ErrorFormatSimple  = "simple"  // {"error": ..., "details": ...}, problem details when accepted
+	ErrorFormatProblem = "problem" // RFC 7807 problem details for all requests
+)
+
+// ProblemErrors makes error responses of requests accepting application/problem+json,
+// or of all requests in the problem format, RFC 7807 problem details
+type ProblemErrors struct {
+	always bool
+}
+
+// NewProblemErrors creates the problem details middleware for the error format
+func NewProblemErrors(format string) *ProblemErrors {
+	return &ProblemErrors{always: format == ErrorFormatProblem}
+}
+
+// Handle marks the response writer of requests getting problem details, with the
+// request path as the problem instance
+func (p *ProblemErrors) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.always || acceptsProblem(r) {
+			w = &problemResponseWriter{ResponseWriter: w, instance: r.URL.Path}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acceptsProblem reports whether the Accept header lists application/problem+json
+// with a non-zero quality
+func acceptsProblem(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, entry := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(entry)
+			if err != nil || mediaType != models.ProblemContentType {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// problemResponseWriter marks a response whose errors are written as problem details
+type problemResponseWriter struct {
+	http.ResponseWriter
+	instance string
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (pw *problemResponseWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
+
+// ProblemInstance returns the problem instance of a response whose errors are written as
+// problem details, looking through writers wrapped further down the chain
+func ProblemInstance(w http.ResponseWriter) (string, bool) {
+	for {
+		switch rw := w.(type) {
+		case *problemResponseWriter:
+			return rw.instance, true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return "", false
+		}
+	}
+}
+
+// WriteProblem writes an error response as RFC 7807 problem details
+func WriteProblem(w http.ResponseWriter, statusCode int, message string, details interface{}, instance string) {
+	w.Header().Set("Content-Type", models.ProblemContentType)
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(models.NewProblemDetails(statusCode, message, details, instance))
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ad/leads-core/internal/models"
+)
+
+func TestProblemErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		accept  string
+		problem bool
+	}{
+		{"simple by default", ErrorFormatSimple, "application/json", false},
+		{"accepted", ErrorFormatSimple, "application/json, application/problem+json", true},
+		{"accepted with quality", ErrorFormatSimple, "application/problem+json;q=0.5", true},
+		{"refused", ErrorFormatSimple, "application/problem+json;q=0", false},
+		{"problem format", ErrorFormatProblem, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProblemErrors(tt.format).Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeErrorResponse(w, http.StatusUnauthorized, "Invalid or expired token")
+			}))
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/widgets/abc", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			handler.ServeHTTP(rr, req)
+
+			if !tt.problem {
+				if rr.Header().Get("Content-Type") != "application/json" || rr.Body.String() != `{"error":"Invalid or expired token"}` {
+					t.Errorf("Expected simple error body, got %q (%s)", rr.Body.String(), rr.Header().Get("Content-Type"))
+				}
+				return
+			}
+
+			if rr.Header().Get("Content-Type") != models.ProblemContentType {
+				t.Errorf("Expected Content-Type %s, got %s", models.ProblemContentType, rr.Header().Get("Content-Type"))
+			}
+			var problem models.ProblemDetails
+			if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("Failed to decode problem details: %v", err)
+			}
+			expected := models.ProblemDetails{
+				Type:     models.ProblemTypePrefix + "unauthorized",
+				Title:    "Unauthorized",
+				Status:   http.StatusUnauthorized,
+				Detail:   "Invalid or expired token",
+				Instance: "/api/v1/widgets/abc",
+			}
+			if problem.Type != expected.Type || problem.Title != expected.Title || problem.Status != expected.Status ||
+				problem.Detail != expected.Detail || problem.Instance != expected.Instance {
+				t.Errorf("Expected %+v, got %+v", expected, problem)
+			}
+		})
+	}
+}
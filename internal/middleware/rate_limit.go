@@ -2,14 +2,19 @@ package middleware
 
 import (
 	"context"
+	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ad/leads-core/internal/auth"
 	"github.com/ad/leads-core/internal/config"
+	"github.com/ad/leads-core/internal/models"
 	"github.com/ad/leads-core/internal/storage"
 	"github.com/ad/leads-core/pkg/logger"
+	"github.com/redis/go-redis/v9"
 )
 
 // RateLimiter provides rate limiting functionality
@@ -42,8 +47,12 @@ func (rl *RateLimiter) RateLimit(next http.Handler) http.Handler {
 			return
 		}
 
+		// Widget-scoped tokens are trusted integrations: skip the per-IP limit
+		_, trusted := auth.GetWidgetScopeFromContext(ctx)
+		widgetID := rateLimitedWidgetID(r.URL.Path)
+
 		// Check rate limits
-		if exceeded, err := rl.checkRateLimit(ctx, ip); err != nil {
+		if exceeded, err := rl.checkRateLimit(ctx, ip, widgetID, !trusted); err != nil {
 			logger.Error("Rate limit check failed", map[string]interface{}{
 				"action": "rate_limit",
 				"ip":     ip,
@@ -51,12 +60,16 @@ func (rl *RateLimiter) RateLimit(next http.Handler) http.Handler {
 			})
 			writeErrorResponse(w, http.StatusInternalServerError, "Internal server error")
 			return
-		} else if exceeded {
+		} else if exceeded != "" {
 			logger.Warn("Rate limit exceeded", map[string]interface{}{
-				"action": "rate_limit",
-				"ip":     ip,
-				"status": "exceeded",
+				"action":    "rate_limit",
+				"ip":        ip,
+				"widget_id": widgetID,
+				"limit":     exceeded,
+				"status":    "exceeded",
 			})
+			now := time.Now()
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(now.Truncate(time.Minute).Add(time.Minute).Sub(now).Seconds()))))
 			writeErrorResponse(w, http.StatusTooManyRequests, "Rate limit exceeded")
 			return
 		}
@@ -66,10 +79,17 @@ func (rl *RateLimiter) RateLimit(next http.Handler) http.Handler {
 	})
 }
 
-// checkRateLimit checks both IP and global rate limits
-func (rl *RateLimiter) checkRateLimit(ctx context.Context, ip string) (bool, error) {
-	now := time.Now()
-	window := now.Format("2006-01-02T15:04") // 1-minute window
+// Rate limits reported as exceeded by checkRateLimit
+const (
+	rateLimitIP     = "ip"
+	rateLimitGlobal = "global"
+	rateLimitWidget = "widget"
+)
+
+// checkRateLimit checks the global rate limit, the IP rate limit if checkIP is set and
+// the widget's rate limit if widgetID is set. It returns the exceeded limit, if any.
+func (rl *RateLimiter) checkRateLimit(ctx context.Context, ip, widgetID string, checkIP bool) (string, error) {
+	window := rateLimitWindow(time.Now())
 
 	pipe := rl.client.GetClient().TxPipeline()
 
@@ -83,25 +103,100 @@ func (rl *RateLimiter) checkRateLimit(ctx context.Context, ip string) (bool, err
 	globalCountCmd := pipe.Incr(ctx, globalKey)
 	pipe.Expire(ctx, globalKey, time.Minute)
 
+	// Check widget rate limit
+	var widgetCountCmd *redis.IntCmd
+	checkWidget := widgetID != "" && rl.config.WidgetPerMinute > 0
+	if checkWidget {
+		widgetKey := storage.GenerateRateLimitWidgetKey(widgetID, window)
+		widgetCountCmd = pipe.Incr(ctx, widgetKey)
+		pipe.Expire(ctx, widgetKey, time.Minute)
+	}
+
 	// Execute pipeline
 	_, err := pipe.Exec(ctx)
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
 	// Check limits
 	ipCount := ipCountCmd.Val()
 	globalCount := globalCountCmd.Val()
 
-	if ipCount > int64(rl.config.IPPerMinute) {
-		return true, nil
+	if checkIP && ipCount > int64(rl.config.IPPerMinute) {
+		return rateLimitIP, nil
+	}
+
+	if checkWidget && widgetCountCmd.Val() > int64(rl.config.WidgetPerMinute) {
+		return rateLimitWidget, nil
 	}
 
 	if globalCount > int64(rl.config.GlobalPerMinute) {
-		return true, nil
+		return rateLimitGlobal, nil
+	}
+
+	return "", nil
+}
+
+// rateLimitedWidgetID returns the widget ID of /widgets/{id}/submit, /events and /track
+// paths, which are limited per widget, or "" for other paths
+func rateLimitedWidgetID(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "widgets" || parts[1] == "" {
+		return ""
+	}
+	switch parts[2] {
+	case "submit", "events", "track":
+		return parts[1]
 	}
+	return ""
+}
+
+// Usage returns the IP's and the global counters of the current window
+func (rl *RateLimiter) Usage(ctx context.Context, ip string) (*models.RateLimitUsage, error) {
+	now := time.Now()
+	window := rateLimitWindow(now)
+
+	pipe := rl.client.GetClient().Pipeline()
+	ipCountCmd := pipe.Get(ctx, storage.GenerateRateLimitIPKey(ip, window))
+	globalCountCmd := pipe.Get(ctx, storage.GenerateRateLimitGlobalKey(window))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	// Missing keys mean no requests in this window yet
+	ipCount, _ := ipCountCmd.Int64()
+	globalCount, _ := globalCountCmd.Int64()
+
+	return &models.RateLimitUsage{
+		IP:          ip,
+		Window:      window,
+		Count:       ipCount,
+		Limit:       rl.config.IPPerMinute,
+		Limited:     ipCount >= int64(rl.config.IPPerMinute),
+		GlobalCount: globalCount,
+		GlobalLimit: rl.config.GlobalPerMinute,
+		ResetIn:     int(math.Ceil(now.Truncate(time.Minute).Add(time.Minute).Sub(now).Seconds())),
+	}, nil
+}
+
+// Reset clears the IP's counter of the current window, e.g. to lift a false-positive
+// block. The global counter is left alone. It reports whether there was a counter.
+func (rl *RateLimiter) Reset(ctx context.Context, ip string) (bool, error) {
+	deleted, err := rl.client.GetClient().Del(ctx, storage.GenerateRateLimitIPKey(ip, rateLimitWindow(time.Now()))).Result()
+	if err != nil {
+		return false, err
+	}
+	return deleted > 0, nil
+}
+
+// rateLimitWindow returns the one-minute window the time falls into, as used in keys
+func rateLimitWindow(now time.Time) string {
+	return now.Format("2006-01-02T15:04")
+}
 
-	return false, nil
+// ClientIP extracts the client IP address from the request
+func ClientIP(r *http.Request) string {
+	return getClientIP(r)
 }
 
 // getClientIP extracts the client IP address from the request
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/ad/leads-core/internal/config"
+	"github.com/ad/leads-core/internal/storage"
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 )
@@ -209,6 +210,136 @@ func TestRateLimitMiddleware_Integration(t *testing.T) {
 	}
 }
 
+func TestRateLimiter_UsageAndReset(t *testing.T) {
+	ctx := context.Background()
+	rl := NewRateLimiter(storage.NewRedisClientWithUniversal(setupTestRedisForRL(t).client), config.RateLimitConfig{
+		IPPerMinute:     2,
+		GlobalPerMinute: 10,
+	})
+	handler := rl.RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(ip string) int {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Counters are per minute, don't straddle a window boundary
+	if now := time.Now(); now.Second() >= 58 {
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+	}
+
+	for i := 0; i < 3; i++ {
+		request("192.168.1.100")
+	}
+	request("192.168.1.200")
+
+	usage, err := rl.Usage(ctx, "192.168.1.100")
+	if err != nil {
+		t.Fatalf("Usage failed: %v", err)
+	}
+	if usage.Count != 3 || !usage.Limited || usage.Limit != 2 || usage.GlobalCount != 4 || usage.GlobalLimit != 10 {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+	if usage.ResetIn <= 0 || usage.ResetIn > 60 {
+		t.Errorf("Expected reset within the minute, got %d seconds", usage.ResetIn)
+	}
+
+	if reset, err := rl.Reset(ctx, "192.168.1.100"); err != nil || !reset {
+		t.Fatalf("Expected reset to clear the counter, got %v, %v", reset, err)
+	}
+	usage, err = rl.Usage(ctx, "192.168.1.100")
+	if err != nil {
+		t.Fatalf("Usage failed: %v", err)
+	}
+	if usage.Count != 0 || usage.Limited || usage.GlobalCount != 4 {
+		t.Errorf("Expected a cleared IP counter and untouched global counter, got %+v", usage)
+	}
+	if code := request("192.168.1.100"); code != http.StatusOK {
+		t.Errorf("Expected requests to pass after reset, got status %d", code)
+	}
+
+	// Other IPs keep their counters
+	if usage, err := rl.Usage(ctx, "192.168.1.200"); err != nil || usage.Count != 1 {
+		t.Errorf("Expected other IP counter of 1, got %+v, %v", usage, err)
+	}
+	if reset, err := rl.Reset(ctx, "10.0.0.1"); err != nil || reset {
+		t.Errorf("Expected no counter to reset for an unseen IP, got %v, %v", reset, err)
+	}
+}
+
+func TestRateLimiter_WidgetLimit(t *testing.T) {
+	rl := NewRateLimiter(storage.NewRedisClientWithUniversal(setupTestRedisForRL(t).client), config.RateLimitConfig{
+		IPPerMinute:     2,
+		GlobalPerMinute: 100,
+		WidgetPerMinute: 3,
+	})
+	handler := rl.RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Counters are per minute, don't straddle a window boundary
+	if now := time.Now(); now.Second() >= 58 {
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+	}
+
+	// Submits and events from different IPs share the widget's counter
+	for i, path := range []string{"/widgets/w1/submit", "/widgets/w1/events", "/widgets/w1/submit"} {
+		if w := request(path, "10.0.0."+string(rune('1'+i))); w.Code != http.StatusOK {
+			t.Fatalf("Expected request %d within the widget limit to pass, got %d", i+1, w.Code)
+		}
+	}
+	w := request("/widgets/w1/submit", "10.0.0.9")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 over the widget limit, got %d", w.Code)
+	}
+	if retryAfter := w.Header().Get("Retry-After"); retryAfter == "" || retryAfter == "0" {
+		t.Errorf("Expected a Retry-After header, got %q", retryAfter)
+	}
+
+	// Other widgets and non-widget paths are not affected by the widget's counter
+	if w := request("/widgets/w2/submit", "10.0.1.1"); w.Code != http.StatusOK {
+		t.Errorf("Expected another widget to pass, got %d", w.Code)
+	}
+	if w := request("/widgets/w1", "10.0.1.2"); w.Code != http.StatusOK {
+		t.Errorf("Expected a non-widget path to pass, got %d", w.Code)
+	}
+
+	// The IP limit triggers independently of the widget limit
+	request("/widgets/w3/submit", "10.0.2.1")
+	request("/widgets/w3/submit", "10.0.2.1")
+	if w := request("/widgets/w3/submit", "10.0.2.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 over the IP limit, got %d", w.Code)
+	}
+}
+
+func TestRateLimitedWidgetID(t *testing.T) {
+	tests := map[string]string{
+		"/widgets/w1/submit":  "w1",
+		"/widgets/w1/events":  "w1",
+		"/widgets/w1/track":   "w1",
+		"/widgets/w1/":        "",
+		"/widgets//submit":    "",
+		"/api/v1/widgets/w1":  "",
+		"/widgets/w1/unknown": "",
+	}
+	for path, expected := range tests {
+		if got := rateLimitedWidgetID(path); got != expected {
+			t.Errorf("rateLimitedWidgetID(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
+
 func TestGetClientIP(t *testing.T) {
 	tests := []struct {
 		name       string
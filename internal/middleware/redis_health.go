@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ad/leads-core/pkg/monitoring"
+)
+
+// RedisHealthGate fails mutating requests fast with 503 once Redis has been unhealthy
+// for longer than the threshold, instead of letting each handler time out on Redis.
+// Requests pass again as soon as a health check succeeds.
+type RedisHealthGate struct {
+	health     *monitoring.HealthState
+	threshold  time.Duration
+	retryAfter time.Duration
+}
+
+// NewRedisHealthGate creates a new Redis health gate middleware (threshold 0 disables it)
+func NewRedisHealthGate(health *monitoring.HealthState, threshold, retryAfter time.Duration) *RedisHealthGate {
+	return &RedisHealthGate{
+		health:     health,
+		threshold:  threshold,
+		retryAfter: retryAfter,
+	}
+}
+
+// Unavailable reports whether Redis has been unhealthy for longer than the threshold
+func (g *RedisHealthGate) Unavailable() bool {
+	if g.threshold <= 0 {
+		return false
+	}
+	return g.health.UnhealthyFor(time.Now()) >= g.threshold
+}
+
+// Handle answers mutating requests with 503 while Redis is unavailable; reads pass through
+func (g *RedisHealthGate) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isReadOnlyRequest(r) && g.Unavailable() {
+			if seconds := int(g.retryAfter.Seconds()); seconds > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			}
+			if instance, ok := ProblemInstance(w); ok {
+				WriteProblem(w, http.StatusServiceUnavailable, "Storage is temporarily unavailable, write operations are rejected", map[string]string{"code": "storage_unavailable"}, instance)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"storage_unavailable","details":"Storage is temporarily unavailable, write operations are rejected"}`))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/pkg/monitoring"
+)
+
+func TestRedisHealthGate_FastFailsWritesAndRecovers(t *testing.T) {
+	health := monitoring.NewHealthState()
+	gate := NewRedisHealthGate(health, time.Minute, 30*time.Second)
+	handler := gate.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/api/v1/widgets", nil))
+		return w
+	}
+
+	// Healthy: writes pass
+	if w := serve(http.MethodPost); w.Code != http.StatusOK {
+		t.Fatalf("Expected POST to pass while healthy, got %d", w.Code)
+	}
+
+	// Unhealthy for less than the threshold: writes still pass
+	health.MarkUnhealthy(time.Now().Add(-30 * time.Second))
+	if w := serve(http.MethodPost); w.Code != http.StatusOK {
+		t.Fatalf("Expected POST to pass below threshold, got %d", w.Code)
+	}
+
+	// Unhealthy past the threshold: writes fail fast, reads pass
+	health.MarkHealthy()
+	health.MarkUnhealthy(time.Now().Add(-2 * time.Minute))
+	w := serve(http.MethodPost)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Expected Retry-After 30, got %q", got)
+	}
+	if w := serve(http.MethodGet); w.Code != http.StatusOK {
+		t.Errorf("Expected GET to pass while unhealthy, got %d", w.Code)
+	}
+
+	// Recovery: writes pass again
+	health.MarkHealthy()
+	if w := serve(http.MethodDelete); w.Code != http.StatusOK {
+		t.Errorf("Expected DELETE to pass after recovery, got %d", w.Code)
+	}
+}
+
+func TestRedisHealthGate_DisabledThreshold(t *testing.T) {
+	health := monitoring.NewHealthState()
+	health.MarkUnhealthy(time.Now().Add(-time.Hour))
+	gate := NewRedisHealthGate(health, 0, 0)
+
+	if gate.Unavailable() {
+		t.Error("Expected gate with zero threshold to stay open")
+	}
+}
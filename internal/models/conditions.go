@@ -0,0 +1,129 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Supported field condition operators
+const (
+	ConditionEquals       = "eq"
+	ConditionNotEquals    = "ne"
+	ConditionGreater      = "gt"
+	ConditionGreaterEqual = "gte"
+	ConditionLess         = "lt"
+	ConditionLessEqual    = "lte"
+	ConditionContains     = "contains"
+	ConditionExists       = "exists"
+)
+
+// FieldCondition compares a submission data field against a value,
+// e.g. {"field": "budget", "op": "gt", "value": 10000}
+type FieldCondition struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// FieldConditions is a list of conditions that must all match
+type FieldConditions []FieldCondition
+
+// Matches reports whether the data satisfies all conditions. An empty list always matches.
+func (c FieldConditions) Matches(data map[string]interface{}) bool {
+	for _, condition := range c {
+		if !condition.Matches(data) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether the data satisfies the condition. Ordering operators
+// compare numerically (numeric strings included) and fail on non-numeric values;
+// eq, ne and contains fall back to case-insensitive string comparison.
+// Unknown operators never match.
+func (c FieldCondition) Matches(data map[string]interface{}) bool {
+	actual, ok := data[c.Field]
+	if !ok || actual == nil {
+		return c.Op == ConditionNotEquals
+	}
+
+	switch c.Op {
+	case ConditionExists:
+		return true
+	case ConditionEquals:
+		return valuesEqual(actual, c.Value)
+	case ConditionNotEquals:
+		return !valuesEqual(actual, c.Value)
+	case ConditionContains:
+		return strings.Contains(strings.ToLower(fmt.Sprint(actual)), strings.ToLower(fmt.Sprint(c.Value)))
+	case ConditionGreater, ConditionGreaterEqual, ConditionLess, ConditionLessEqual:
+		a, okA := toNumber(actual)
+		b, okB := toNumber(c.Value)
+		if !okA || !okB {
+			return false
+		}
+		switch c.Op {
+		case ConditionGreater:
+			return a > b
+		case ConditionGreaterEqual:
+			return a >= b
+		case ConditionLess:
+			return a < b
+		default:
+			return a <= b
+		}
+	}
+	return false
+}
+
+// ParseFieldConditions parses conditions from a widget config value
+// (a list of {"field", "op", "value"} objects), skipping malformed entries
+func ParseFieldConditions(value interface{}) FieldConditions {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	conditions := make(FieldConditions, 0, len(items))
+	for _, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		field, _ := entry["field"].(string)
+		op, _ := entry["op"].(string)
+		if strings.TrimSpace(field) == "" || op == "" {
+			continue
+		}
+		conditions = append(conditions, FieldCondition{Field: field, Op: op, Value: entry["value"]})
+	}
+	return conditions
+}
+
+// valuesEqual compares numerically when both values are numbers, otherwise as case-insensitive strings
+func valuesEqual(a, b interface{}) bool {
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			return x == y
+		}
+	}
+	return strings.EqualFold(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// toNumber converts JSON numbers and numeric strings to float64
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
 // FieldError represents a single validation error
 type FieldError struct {
 	Field   string `json:"field"`
@@ -10,3 +16,119 @@ type FieldError struct {
 func (e *FieldError) String() string {
 	return "{" + e.Field + " " + e.Message + "}"
 }
+
+// FieldErrors is a list of validation errors that can be returned as an error
+type FieldErrors []*FieldError
+
+// Error returns string representation of FieldErrors
+func (e FieldErrors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		parts = append(parts, fieldErr.String())
+	}
+	return "validation failed: [" + strings.Join(parts, " ") + "]"
+}
+
+// MissingHeadersError reports required submission headers that are absent or invalid
+type MissingHeadersError struct {
+	Headers []string
+}
+
+// Error returns string representation of MissingHeadersError
+func (e *MissingHeadersError) Error() string {
+	return "missing required headers: " + strings.Join(e.Headers, ", ")
+}
+
+// GeoBlockedError reports a submission from a country the widget doesn't accept
+type GeoBlockedError struct {
+	Country string
+}
+
+// Error returns string representation of GeoBlockedError
+func (e *GeoBlockedError) Error() string {
+	return "submissions from " + e.Country + " are not allowed"
+}
+
+// PolicyViolationError reports a submission rejected by the widget's public policy
+type PolicyViolationError struct {
+	Reason  string   // One of the PolicyReason codes
+	Details []string // Data fields outside allowed_fields
+}
+
+// Error returns string representation of PolicyViolationError
+func (e *PolicyViolationError) Error() string {
+	if len(e.Details) > 0 {
+		return "submission rejected by widget policy: " + e.Reason + " (" + strings.Join(e.Details, ", ") + ")"
+	}
+	return "submission rejected by widget policy: " + e.Reason
+}
+
+// QuotaExceededError reports that the user used up their daily export quota
+type QuotaExceededError struct {
+	Limit      int
+	RetryAfter time.Duration // Until the quota resets
+}
+
+// Error returns string representation of QuotaExceededError
+func (e *QuotaExceededError) Error() string {
+	return "daily export limit of " + strconv.Itoa(e.Limit) + " reached"
+}
+
+// ExportBusyError reports that the maximum number of exports is already running, on
+// this instance or across all of them
+type ExportBusyError struct {
+	Limit      int
+	Cluster    bool          // The cluster-wide limit was hit
+	RetryAfter time.Duration // Suggested delay before trying again
+}
+
+// Error returns string representation of ExportBusyError
+func (e *ExportBusyError) Error() string {
+	scope := "instance"
+	if e.Cluster {
+		scope = "cluster"
+	}
+	return "export concurrency limit of " + strconv.Itoa(e.Limit) + " per " + scope + " reached"
+}
+
+// ImportFileError reports an import file that can't be imported at all, e.g. a CSV file
+// without a valid header row
+type ImportFileError struct {
+	Message string
+}
+
+// Error returns string representation of ImportFileError
+func (e *ImportFileError) Error() string {
+	return e.Message
+}
+
+// ExportRangeError reports an export time range wider than allowed, or a missing one
+// on a widget with too many submissions for a full-history export
+type ExportRangeError struct {
+	MaxRange  time.Duration // Widest allowed span (0 = unlimited)
+	Unbounded bool          // No 'from' was given
+}
+
+// Error returns string representation of ExportRangeError
+func (e *ExportRangeError) Error() string {
+	limit := ""
+	if e.MaxRange > 0 {
+		limit = " of at most " + formatSpan(e.MaxRange)
+	}
+	if e.Unbounded {
+		return "Widget has too many submissions to export its full history, narrow the range with 'from' and 'to'" + limit
+	}
+	return "Export time range exceeds the maximum of " + formatSpan(e.MaxRange) + ", narrow the range with 'from' and 'to'"
+}
+
+// formatSpan formats whole days as "N days", other spans as durations
+func formatSpan(span time.Duration) string {
+	const day = 24 * time.Hour
+	if span%day == 0 {
+		if span == day {
+			return "1 day"
+		}
+		return strconv.Itoa(int(span/day)) + " days"
+	}
+	return span.String()
+}
@@ -1,10 +1,18 @@
 package models
 
 import (
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // WidgetType represents supported widget types
@@ -57,11 +65,137 @@ func IsValidWidgetType(widgetType string) bool {
 	return validTypes[widgetType]
 }
 
+// WidgetStatus represents how a widget handles submissions
+type WidgetStatus string
+
+// Supported widget statuses
+const (
+	WidgetStatusActive WidgetStatus = "active" // Visible, accepts submissions
+	WidgetStatusPaused WidgetStatus = "paused" // Hidden, accepts submissions marked as received while paused
+	WidgetStatusClosed WidgetStatus = "closed" // Hidden, rejects submissions
+)
+
+// IsValidWidgetStatus checks if a widget status is valid
+func IsValidWidgetStatus(status string) bool {
+	switch WidgetStatus(status) {
+	case WidgetStatusActive, WidgetStatusPaused, WidgetStatusClosed:
+		return true
+	}
+	return false
+}
+
+// canonicalWidgetType resolves a widget type case-insensitively against the
+// given set and returns its canonical spelling (e.g. "WHEELOFFORTUNE" -> "wheelOfFortune")
+func canonicalWidgetType(widgetType string, validTypes map[string]bool) (string, bool) {
+	if validTypes[widgetType] {
+		return widgetType, true
+	}
+	for validType := range validTypes {
+		if strings.EqualFold(validType, widgetType) {
+			return validType, true
+		}
+	}
+	return "", false
+}
+
+// TypeRegistry resolves which widget types are available to a plan.
+// Plans without an allow list get every supported type; deny lists are applied afterwards.
+type TypeRegistry struct {
+	allowed map[string][]string // plan -> allowed types
+	denied  map[string][]string // plan -> denied types
+}
+
+// NewTypeRegistry creates a new type registry from per-plan allow/deny lists
+func NewTypeRegistry(allowed, denied map[string][]string) *TypeRegistry {
+	return &TypeRegistry{
+		allowed: allowed,
+		denied:  denied,
+	}
+}
+
+// TypesForPlan returns the set of widget types available to the plan
+func (r *TypeRegistry) TypesForPlan(plan string) map[string]bool {
+	validTypes := ValidWidgetTypes()
+	if r == nil {
+		return validTypes
+	}
+
+	types := validTypes
+	if allowed, ok := r.allowed[plan]; ok && len(allowed) > 0 {
+		types = make(map[string]bool)
+		for _, widgetType := range allowed {
+			if canonical, ok := canonicalWidgetType(strings.TrimSpace(widgetType), validTypes); ok {
+				types[canonical] = true
+			}
+		}
+	}
+
+	for _, widgetType := range r.denied[plan] {
+		if canonical, ok := canonicalWidgetType(strings.TrimSpace(widgetType), validTypes); ok {
+			delete(types, canonical)
+		}
+	}
+
+	return types
+}
+
+// IsAllowed checks if the widget type is available to the plan
+func (r *TypeRegistry) IsAllowed(plan, widgetType string) bool {
+	_, ok := canonicalWidgetType(widgetType, r.TypesForPlan(plan))
+	return ok
+}
+
 // User represents user data extracted from JWT token
 type User struct {
 	ID       string `json:"id"`
 	Username string `json:"username,omitempty"`
 	Plan     string `json:"plan,omitempty"` // "free", "pro", etc.
+	Role     string `json:"role,omitempty"` // "admin" or empty for regular users
+}
+
+// RoleAdmin is the role of users with access to admin-only behavior
+const RoleAdmin = "admin"
+
+// IsAdmin checks if the user has the admin role
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// RefreshToken represents a stored refresh token, with the user claims access tokens
+// issued for it carry
+type RefreshToken struct {
+	User      User
+	TokenID   string // jti of the access token it was issued with, revoking it revokes this one
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+// TokenPair represents issued tokens; refreshing an access token returns no refresh token
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"` // Seconds until the access token expires
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	RefreshExpiresIn int    `json:"refresh_expires_in,omitempty"` // Seconds until the refresh token expires
+}
+
+// RefreshTokenRequest represents request data for refreshing or revoking tokens
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenRevokeRequest represents request data for revoking an access token
+type TokenRevokeRequest struct {
+	Token string `json:"token"`
+}
+
+// RevokedToken represents the result of revoking an access token. Revoked is false for
+// tokens that had already expired, which need no revocation.
+type RevokedToken struct {
+	JTI       string     `json:"jti"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Until when the token stays on the revocation list, for good when unset
+	Revoked   bool       `json:"revoked"`
 }
 
 // Widget represents a widget created by a user
@@ -71,28 +205,857 @@ type Widget struct {
 	Type      string                 `json:"type"`
 	Name      string                 `json:"name"`
 	IsVisible bool                   `json:"isVisible"`
+	Status    WidgetStatus           `json:"status"`
 	Config    map[string]interface{} `json:"config"`
+	Version   int                    `json:"version"` // Config version, incremented on every config update
 	CreatedAt time.Time              `json:"created_at"`
 	UpdatedAt time.Time              `json:"updated_at"`
 	Stats     *WidgetStats           `json:"stats,omitempty"`
+	ExpiresAt *time.Time             `json:"expires_at,omitempty"` // Set for auto-expiring demo widgets
+
+	// Archived widgets are kept with their data and stay exportable, but are left out of
+	// listings by default, reject submissions and can't be edited until unarchived
+	Archived   bool       `json:"archived"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+
+	// StatsAvailable tells zero stats from unknown ones: listings always carry a stats
+	// object, zeroed with StatsAvailable false when the stats couldn't be read
+	StatsAvailable bool `json:"stats_available"`
+}
+
+// DemoWidgetExpiry returns when a demo widget created at createdAt expires
+func DemoWidgetExpiry(createdAt time.Time, demoDays int) time.Time {
+	return createdAt.AddDate(0, 0, demoDays)
+}
+
+// EffectiveStatus returns the widget status, deriving it from IsVisible for widgets without one
+func (f *Widget) EffectiveStatus() WidgetStatus {
+	if f.Status != "" {
+		return f.Status
+	}
+	if f.IsVisible {
+		return WidgetStatusActive
+	}
+	return WidgetStatusClosed
+}
+
+// SetStatus sets the widget status and keeps IsVisible in sync (only active widgets are visible)
+func (f *Widget) SetStatus(status WidgetStatus) {
+	f.Status = status
+	f.IsVisible = status == WidgetStatusActive
+}
+
+// WidgetConfigFieldMaxLengthsKey is the widget config key holding per-field value length limits
+const WidgetConfigFieldMaxLengthsKey = "field_max_lengths"
+
+// FieldMaxLengths returns per-field value length limits from the widget config
+func (f *Widget) FieldMaxLengths() map[string]int {
+	raw, ok := f.Config[WidgetConfigFieldMaxLengthsKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	limits := make(map[string]int, len(raw))
+	for field, value := range raw {
+		// Config is decoded from JSON, so numbers arrive as float64
+		if limit, ok := value.(float64); ok && limit > 0 {
+			limits[field] = int(limit)
+		}
+	}
+	return limits
+}
+
+// WidgetConfigFieldsKey is the widget config key defining submission fields checked on
+// submit, e.g. {"fields": {"email": {"type": "email", "required": true}}}
+const WidgetConfigFieldsKey = "fields"
+
+// Field types of field definitions
+const (
+	FieldTypeText   = "text"
+	FieldTypeEmail  = "email"
+	FieldTypeNumber = "number"
+)
+
+// FieldDefinition is a submission field defined in the widget config
+type FieldDefinition struct {
+	Type     string // One of the field types, other types are only checked for presence
+	Required bool
+}
+
+// FieldDefinitions returns the submission fields defined in the widget config
+func (f *Widget) FieldDefinitions() map[string]FieldDefinition {
+	raw, ok := f.Config[WidgetConfigFieldsKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	definitions := make(map[string]FieldDefinition, len(raw))
+	for field, value := range raw {
+		settings, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fieldType, _ := settings["type"].(string)
+		required, _ := settings["required"].(bool)
+		definitions[field] = FieldDefinition{Type: strings.ToLower(strings.TrimSpace(fieldType)), Required: required}
+	}
+	return definitions
+}
+
+// ValidationMode controls how submissions failing field validation are handled
+type ValidationMode string
+
+// Supported validation modes
+const (
+	ValidationModeStrict  ValidationMode = "strict"  // Reject the submission
+	ValidationModeLenient ValidationMode = "lenient" // Store it with the failures as warnings
+	ValidationModeOff     ValidationMode = "off"     // Skip field validation
+)
+
+// WidgetConfigValidationModeKey is the widget config key holding the validation mode,
+// e.g. {"validation_mode": "lenient"}
+const WidgetConfigValidationModeKey = "validation_mode"
+
+// ValidationMode returns the widget's validation mode, strict unless configured otherwise
+func (f *Widget) ValidationMode() ValidationMode {
+	switch mode := ValidationMode(fmt.Sprint(f.Config[WidgetConfigValidationModeKey])); mode {
+	case ValidationModeLenient, ValidationModeOff:
+		return mode
+	}
+	return ValidationModeStrict
+}
+
+// WidgetConfigViewDedupKey is the widget config key counting one view per visitor and
+// window, e.g. {"view_dedup": {"window_minutes": 30}}; without it every view is counted
+const WidgetConfigViewDedupKey = "view_dedup"
+
+// ViewDedupWindow returns the view deduplication window (0 = count every view)
+func (f *Widget) ViewDedupWindow() time.Duration {
+	settings, ok := f.Config[WidgetConfigViewDedupKey].(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	if minutes, ok := settings["window_minutes"].(float64); ok && minutes > 0 {
+		return time.Duration(minutes * float64(time.Minute))
+	}
+	return 0
+}
+
+// WidgetConfigFieldTransformsKey is the widget config key holding transforms applied to
+// submission fields before storage, in order, e.g. {"email": ["trim", "lower"]}
+const WidgetConfigFieldTransformsKey = "field_transforms"
+
+// FieldTransforms returns the ordered transform names per submission field
+func (f *Widget) FieldTransforms() map[string][]string {
+	raw, ok := f.Config[WidgetConfigFieldTransformsKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	transforms := make(map[string][]string, len(raw))
+	for field, value := range raw {
+		names, ok := value.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, name := range names {
+			if s, ok := name.(string); ok && s != "" {
+				transforms[field] = append(transforms[field], s)
+			}
+		}
+	}
+	return transforms
+}
+
+// WidgetConfigFieldDefaultsKey is the widget config key holding values stored for
+// submission fields that are missing or empty, e.g. {"source": "web", "consent": false}
+const WidgetConfigFieldDefaultsKey = "field_defaults"
+
+// FieldDefaults returns the default value per submission field. Defaults keep the JSON
+// type they're configured with; strings, numbers, booleans and lists of them are
+// supported, other values are skipped.
+func (f *Widget) FieldDefaults() map[string]interface{} {
+	raw, ok := f.Config[WidgetConfigFieldDefaultsKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	defaults := make(map[string]interface{}, len(raw))
+	for field, value := range raw {
+		if isFieldDefaultValue(value) {
+			defaults[field] = value
+		}
+	}
+	return defaults
+}
+
+// isFieldDefaultValue reports whether a configured default is a non-empty scalar or
+// list of scalars
+func isFieldDefaultValue(value interface{}) bool {
+	switch v := value.(type) {
+	case string, float64, bool:
+		return !isEmptyFieldValue(v)
+	case []interface{}:
+		for _, item := range v {
+			switch item.(type) {
+			case string, float64, bool:
+			default:
+				return false
+			}
+		}
+		return len(v) > 0
+	}
+	return false
+}
+
+// ApplyFieldDefaults sets the data fields that are missing, null, blank strings or empty
+// lists to their default. Provided values, including false and 0, are left as is.
+func ApplyFieldDefaults(data map[string]interface{}, defaults map[string]interface{}) {
+	for field, value := range defaults {
+		if !isEmptyFieldValue(data[field]) {
+			continue
+		}
+		// Copy lists, so submissions don't share the widget config's
+		if list, ok := value.([]interface{}); ok {
+			value = append([]interface{}(nil), list...)
+		}
+		data[field] = value
+	}
+}
+
+// isEmptyFieldValue reports whether a submitted value counts as not provided
+func isEmptyFieldValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// ValidateFieldLengths checks string values of submission data against length limits.
+// Overrides take precedence over defaultMax; a limit of 0 means unlimited.
+// Lengths are counted in characters, error paths use the "data.field[.index]" form.
+func ValidateFieldLengths(data map[string]interface{}, defaultMax int, overrides map[string]int) FieldErrors {
+	var errs FieldErrors
+
+	check := func(path, value string, limit int) {
+		if utf8.RuneCountInString(value) > limit {
+			errs = append(errs, &FieldError{
+				Field:   path,
+				Message: fmt.Sprintf("String length must be less than or equal to %d", limit),
+			})
+		}
+	}
+
+	for field, value := range data {
+		limit := defaultMax
+		if override, ok := overrides[field]; ok {
+			limit = override
+		}
+		if limit <= 0 {
+			continue
+		}
+
+		path := "data." + field
+		switch v := value.(type) {
+		case string:
+			check(path, v, limit)
+		case []interface{}:
+			for i, item := range v {
+				if s, ok := item.(string); ok {
+					check(path+"."+strconv.Itoa(i), s, limit)
+				}
+			}
+		}
+	}
+
+	// Keep errors in a stable order regardless of map iteration
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+
+	return errs
+}
+
+// NotificationMode represents how submission notifications are delivered to the widget owner
+type NotificationMode string
+
+// Supported notification modes
+const (
+	NotificationModeImmediate NotificationMode = "immediate" // One notification per submission
+	NotificationModeThrottled NotificationMode = "throttled" // At most one notification per interval
+	NotificationModeDigest    NotificationMode = "digest"    // Submissions batched into a periodic summary
+)
+
+// WidgetConfigNotificationsKey is the widget config key holding notification settings,
+// e.g. {"mode": "throttled", "interval_minutes": 10, "conditions": [{"field": "budget", "op": "gt", "value": 10000}]}
+const WidgetConfigNotificationsKey = "notifications"
+
+// DefaultNotificationInterval is the throttle interval used when the widget config sets none
+const DefaultNotificationInterval = 15 * time.Minute
+
+// NotificationSettings returns the notification mode and throttle interval from the widget config
+func (f *Widget) NotificationSettings() (NotificationMode, time.Duration) {
+	mode, interval := NotificationModeImmediate, DefaultNotificationInterval
+
+	settings, ok := f.Config[WidgetConfigNotificationsKey].(map[string]interface{})
+	if !ok {
+		return mode, interval
+	}
+
+	switch m := NotificationMode(fmt.Sprint(settings["mode"])); m {
+	case NotificationModeThrottled, NotificationModeDigest:
+		mode = m
+	}
+	if minutes, ok := settings["interval_minutes"].(float64); ok && minutes > 0 {
+		interval = time.Duration(minutes * float64(time.Minute))
+	}
+
+	return mode, interval
+}
+
+// NotificationConditions returns the conditions a submission must match to notify the owner
+func (f *Widget) NotificationConditions() FieldConditions {
+	settings, ok := f.Config[WidgetConfigNotificationsKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return ParseFieldConditions(settings["conditions"])
+}
+
+// Chat notification channels, posted to as incoming webhooks
+const (
+	NotificationChannelSlack = "slack"
+	NotificationChannelTeams = "teams"
+)
+
+// NotificationChannel is a chat channel submission notifications are posted to
+type NotificationChannel struct {
+	Type       string // NotificationChannelSlack or NotificationChannelTeams
+	WebhookURL string
+}
+
+// NotificationChannel returns the chat channel from the notification settings, e.g.
+// {"channel": {"type": "slack", "webhook_url": "https://hooks.slack.com/services/..."}}.
+// It is nil without one, for unknown types and for webhook URLs other than absolute https URLs.
+func (f *Widget) NotificationChannel() *NotificationChannel {
+	settings, ok := f.Config[WidgetConfigNotificationsKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	channel, ok := settings["channel"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	channelType, _ := channel["type"].(string)
+	if channelType != NotificationChannelSlack && channelType != NotificationChannelTeams {
+		return nil
+	}
+	webhookURL, _ := channel["webhook_url"].(string)
+	if u, err := url.Parse(webhookURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil
+	}
+
+	return &NotificationChannel{Type: channelType, WebhookURL: webhookURL}
+}
+
+// EmailNotification is the address submission notifications are emailed to
+type EmailNotification struct {
+	Recipient string
+}
+
+// EmailNotification returns the email settings from the notification settings, e.g.
+// {"email": {"enabled": true, "recipient": "owner@example.com"}}.
+// It is nil unless enabled with a valid recipient address.
+func (f *Widget) EmailNotification() *EmailNotification {
+	settings, ok := f.Config[WidgetConfigNotificationsKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	email, ok := settings["email"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if enabled, _ := email["enabled"].(bool); !enabled {
+		return nil
+	}
+	recipient, _ := email["recipient"].(string)
+	address, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return nil
+	}
+
+	return &EmailNotification{Recipient: address.Address}
+}
+
+// WidgetConfigAcknowledgementKey is the widget config key holding the post-submit acknowledgement,
+// e.g. {"message": "Thanks!", "redirect_url": "https://example.com/thanks"}
+const WidgetConfigAcknowledgementKey = "acknowledgement"
+
+// DefaultAcknowledgementMessage is shown after a submission when the widget config sets none
+const DefaultAcknowledgementMessage = "Thank you! Your submission has been received."
+
+// Acknowledgement returns the post-submit acknowledgement from the widget config.
+// Redirect URLs other than absolute http(s) URLs are ignored.
+func (f *Widget) Acknowledgement() *Acknowledgement {
+	ack := &Acknowledgement{Message: DefaultAcknowledgementMessage}
+
+	settings, ok := f.Config[WidgetConfigAcknowledgementKey].(map[string]interface{})
+	if !ok {
+		return ack
+	}
+
+	if message, ok := settings["message"].(string); ok && strings.TrimSpace(message) != "" {
+		ack.Message = message
+	}
+	if redirectURL, ok := settings["redirect_url"].(string); ok {
+		if u, err := url.Parse(redirectURL); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+			ack.RedirectURL = redirectURL
+		}
+	}
+
+	return ack
+}
+
+// WidgetConfigEchoDataKey is the widget config key returning the stored data (after
+// transforms) in submit responses, e.g. {"echo_data": true}
+const WidgetConfigEchoDataKey = "echo_data"
+
+// EchoesData reports whether submit responses include the stored submission data
+func (f *Widget) EchoesData() bool {
+	echo, _ := f.Config[WidgetConfigEchoDataKey].(bool)
+	return echo
+}
+
+// WidgetConfigGeoKey is the widget config key restricting public submissions by client
+// country, e.g. {"allowed_countries": ["DE", "FR"], "blocked_countries": ["RU"]}
+const WidgetConfigGeoKey = "geo"
+
+// GeoRestrictions holds the widget's allowed and blocked client countries (ISO codes)
+type GeoRestrictions struct {
+	AllowedCountries []string
+	BlockedCountries []string
+}
+
+// GeoRestrictions returns the country restrictions from the widget config
+func (f *Widget) GeoRestrictions() GeoRestrictions {
+	settings, ok := f.Config[WidgetConfigGeoKey].(map[string]interface{})
+	if !ok {
+		return GeoRestrictions{}
+	}
+	return GeoRestrictions{
+		AllowedCountries: stringList(settings["allowed_countries"]),
+		BlockedCountries: stringList(settings["blocked_countries"]),
+	}
+}
+
+// IsEmpty reports whether no restrictions are configured
+func (g GeoRestrictions) IsEmpty() bool {
+	return len(g.AllowedCountries) == 0 && len(g.BlockedCountries) == 0
+}
+
+// Allows reports whether submissions from the country are accepted. Blocked countries
+// are rejected, and with an allow list only listed countries pass. Unknown countries
+// (empty code) are always accepted, so an unresolvable IP never blocks a lead.
+func (g GeoRestrictions) Allows(country string) bool {
+	if country == "" {
+		return true
+	}
+	for _, blocked := range g.BlockedCountries {
+		if strings.EqualFold(blocked, country) {
+			return false
+		}
+	}
+	if len(g.AllowedCountries) == 0 {
+		return true
+	}
+	for _, allowed := range g.AllowedCountries {
+		if strings.EqualFold(allowed, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// stringList extracts the non-empty strings of a config list value
+func stringList(value interface{}) []string {
+	raw, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var items []string
+	for _, item := range raw {
+		if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+			items = append(items, strings.TrimSpace(s))
+		}
+	}
+	return items
+}
+
+// WidgetConfigRequiredHeadersKey is the widget config key holding headers public submissions
+// must carry, e.g. {"X-Widget-Token": "secret", "Origin": ""}; an empty value only requires
+// the header to be present and non-empty
+const WidgetConfigRequiredHeadersKey = "required_headers"
+
+// RequiredHeaders returns the headers required on public submissions from the widget config
+func (f *Widget) RequiredHeaders() map[string]string {
+	raw, ok := f.Config[WidgetConfigRequiredHeadersKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	headers := make(map[string]string, len(raw))
+	for name, value := range raw {
+		if name == "" {
+			continue
+		}
+		expected, _ := value.(string)
+		headers[name] = expected
+	}
+	return headers
+}
+
+// MissingRequiredHeaders returns the sorted names of required headers that are absent,
+// empty or don't match their expected value
+func MissingRequiredHeaders(header http.Header, required map[string]string) []string {
+	var missing []string
+	for name, expected := range required {
+		value := header.Get(name)
+		if value == "" || (expected != "" && subtle.ConstantTimeCompare([]byte(value), []byte(expected)) != 1) {
+			missing = append(missing, http.CanonicalHeaderKey(name))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// WidgetConfigPIIKey is the widget config key holding PII redaction settings,
+// e.g. {"fields": ["email", "phone"], "retention_days": 30}
+const WidgetConfigPIIKey = "pii"
+
+// PIISettings returns the submission fields to redact and the age in days after which
+// they are redacted; days is 0 when the widget config sets none
+func (f *Widget) PIISettings() (fields []string, days int) {
+	settings, ok := f.Config[WidgetConfigPIIKey].(map[string]interface{})
+	if !ok {
+		return nil, 0
+	}
+
+	if raw, ok := settings["fields"].([]interface{}); ok {
+		for _, field := range raw {
+			if name, ok := field.(string); ok && name != "" {
+				fields = append(fields, name)
+			}
+		}
+	}
+	if value, ok := settings["retention_days"].(float64); ok && value > 0 {
+		days = int(value)
+	}
+
+	return fields, days
+}
+
+// NormalizeWidgetName returns the form of a widget name compared by the per-user
+// uniqueness check: trimmed and case-folded
+func NormalizeWidgetName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// WidgetConfigEncryptedFieldsKey is the widget config key listing submission fields
+// encrypted at rest, e.g. ["email", "phone"]; other fields stay plaintext and filterable
+const WidgetConfigEncryptedFieldsKey = "encrypted_fields"
+
+// EncryptedFields returns the submission fields to encrypt at rest
+func (f *Widget) EncryptedFields() []string {
+	raw, ok := f.Config[WidgetConfigEncryptedFieldsKey].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var fields []string
+	for _, field := range raw {
+		if name, ok := field.(string); ok && name != "" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// WidgetConfigMaxSubmissionsKey is the widget config key capping the number of stored
+// submissions; beyond it the oldest are evicted, e.g. {"max_submissions": 50}
+const WidgetConfigMaxSubmissionsKey = "max_submissions"
+
+// MaxSubmissions returns the cap on stored submissions (0 = unlimited)
+func (f *Widget) MaxSubmissions() int {
+	if limit, ok := f.Config[WidgetConfigMaxSubmissionsKey].(float64); ok && limit > 0 {
+		return int(limit)
+	}
+	return 0
+}
+
+// WidgetConfigDedupeSecondsKey is the widget config key of the window in which repeated
+// identical submissions from the same client return the first one instead of being
+// stored again, e.g. {"dedupe_seconds": 30}
+const WidgetConfigDedupeSecondsKey = "dedupe_seconds"
+
+// SubmitDedupWindow returns the submission deduplication window (0 = store every submission)
+func (f *Widget) SubmitDedupWindow() time.Duration {
+	if seconds, ok := f.Config[WidgetConfigDedupeSecondsKey].(float64); ok && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return 0
+}
+
+// WidgetConfigCorrelationFieldKey is the widget config key naming the data field clients
+// look submissions up by, e.g. {"correlation_field": "order_id"}
+const WidgetConfigCorrelationFieldKey = "correlation_field"
+
+// CorrelationField returns the data field submissions are indexed by ("" = none)
+func (f *Widget) CorrelationField() string {
+	field, _ := f.Config[WidgetConfigCorrelationFieldKey].(string)
+	return strings.TrimSpace(field)
+}
+
+// CorrelationValue returns the submission's correlation field value as a string; only
+// non-empty strings and numbers can be looked up
+func CorrelationValue(data map[string]interface{}, field string) string {
+	if field == "" {
+		return ""
+	}
+	switch value := data[field].(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return ""
+}
+
+// WidgetConfigExportHeadersKey is the widget config key renaming export columns, e.g.
+// {"export_headers": {"email": "EmailAddress", "Created At": "SubmittedAt"}}
+const WidgetConfigExportHeadersKey = "export_headers"
+
+// ExportHeaders returns the widget's export column renames (column name -> header)
+func (f *Widget) ExportHeaders() map[string]string {
+	raw, ok := f.Config[WidgetConfigExportHeadersKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	headers := make(map[string]string, len(raw))
+	for column, value := range raw {
+		header, _ := value.(string)
+		if column == "" || strings.TrimSpace(header) == "" {
+			continue
+		}
+		headers[column] = strings.TrimSpace(header)
+	}
+	return headers
+}
+
+// WidgetConfigCaptureParamsKey is the widget config key listing the page query params
+// (e.g. UTM tags) kept in submission meta, e.g. {"capture_params": ["utm_source", "utm_medium"]}
+const WidgetConfigCaptureParamsKey = "capture_params"
+
+// CaptureParams returns the meta params submissions of the widget keep
+func (f *Widget) CaptureParams() []string {
+	return stringList(f.Config[WidgetConfigCaptureParamsKey])
+}
+
+// CapturedMeta returns the submitted meta params the widget captures, without empty values
+func CapturedMeta(meta map[string]string, params []string) map[string]string {
+	var captured map[string]string
+	for _, param := range params {
+		value := strings.TrimSpace(meta[param])
+		if value == "" {
+			continue
+		}
+		if captured == nil {
+			captured = make(map[string]string, len(params))
+		}
+		captured[param] = value
+	}
+	return captured
+}
+
+// WidgetConfigClientTimestampsKey is the widget config key allowing submissions to carry
+// their client capture time in occurred_at, e.g. {"client_timestamps": true}
+const WidgetConfigClientTimestampsKey = "client_timestamps"
+
+// AllowsClientTimestamps reports whether submissions may set their creation time
+func (f *Widget) AllowsClientTimestamps() bool {
+	allowed, _ := f.Config[WidgetConfigClientTimestampsKey].(bool)
+	return allowed
+}
+
+// WidgetConfigPixelTrackingKey is the widget config key accepting views and submissions
+// as GET requests with query params, for tracking pixels, e.g. {"pixel_tracking": true}
+const WidgetConfigPixelTrackingKey = "pixel_tracking"
+
+// AllowsPixelTracking reports whether events and submissions may be sent as GET requests
+func (f *Widget) AllowsPixelTracking() bool {
+	allowed, _ := f.Config[WidgetConfigPixelTrackingKey].(bool)
+	return allowed
+}
+
+// RedactFields blanks the given fields of submission data, reporting whether anything changed
+func RedactFields(data map[string]interface{}, fields []string) bool {
+	changed := false
+	for _, field := range fields {
+		if value, ok := data[field]; ok && value != "" {
+			data[field] = ""
+			changed = true
+		}
+	}
+	return changed
+}
+
+// SetVisible sets IsVisible as an alias for the active/closed statuses
+func (f *Widget) SetVisible(visible bool) {
+	if visible {
+		f.SetStatus(WidgetStatusActive)
+	} else {
+		f.SetStatus(WidgetStatusClosed)
+	}
+}
+
+// Submission represents a submission to a widget
+type Submission struct {
+	ID                  string                 `json:"id"`
+	WidgetID            string                 `json:"widget_id"`
+	Data                map[string]interface{} `json:"data,omitempty"` // Left out of submit responses unless the widget echoes data
+	CreatedAt           time.Time              `json:"created_at"`
+	ReceivedAt          *time.Time             `json:"received_at,omitempty"`    // Server receipt time when CreatedAt is a client timestamp
+	WidgetVersion       int                    `json:"widget_version,omitempty"` // Widget config version the submission was captured under
+	TTL                 time.Duration          `json:"ttl,omitempty"`
+	Trusted             bool                   `json:"trusted,omitempty"`               // Submitted with a widget-scoped token
+	Region              string                 `json:"region,omitempty"`                // Data residency region (compliance metadata)
+	ReceivedWhilePaused bool                   `json:"received_while_paused,omitempty"` // Submitted while the widget was paused
+	PIIRedacted         bool                   `json:"pii_redacted,omitempty"`          // PII fields were blanked after the retention window
+	ValidationWarnings  FieldErrors            `json:"validation_warnings,omitempty"`   // Validation failures accepted in lenient mode
+	EncryptedFields     []string               `json:"-"`                               // Data fields encrypted at rest
+	CorrelationValue    string                 `json:"-"`                               // Indexed for lookups by the widget's correlation field
+	Meta                map[string]string      `json:"meta,omitempty"`                  // Captured page query params, e.g. UTM tags
+	Acknowledgement     *Acknowledgement       `json:"acknowledgement,omitempty"`       // Returned to the embed on submit, not stored
+}
+
+// ProjectedSubmission is a submission reduced to a subset of its data fields
+type ProjectedSubmission struct {
+	ID        string                 `json:"id"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// Project returns the submission with only the given data fields; fields the
+// submission doesn't have are omitted
+func (s *Submission) Project(fields []string) *ProjectedSubmission {
+	data := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := s.Data[field]; ok {
+			data[field] = value
+		}
+	}
+	return &ProjectedSubmission{ID: s.ID, CreatedAt: s.CreatedAt, Data: data}
 }
 
-// Submission represents a submission to a widget
-type Submission struct {
-	ID        string                 `json:"id"`
-	WidgetID  string                 `json:"widget_id"`
-	Data      map[string]interface{} `json:"data"`
-	CreatedAt time.Time              `json:"created_at"`
-	TTL       time.Duration          `json:"ttl,omitempty"`
+// PreviewFields returns the requested fields that may be shown in previews: the
+// widget's PII and encrypted fields are left out
+func (f *Widget) PreviewFields(fields []string) []string {
+	piiFields, _ := f.PIISettings()
+	sensitive := make(map[string]bool)
+	for _, field := range append(piiFields, f.EncryptedFields()...) {
+		sensitive[field] = true
+	}
+
+	allowed := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if !sensitive[field] {
+			allowed = append(allowed, field)
+		}
+	}
+	return allowed
+}
+
+// Acknowledgement is what the embed shows after a successful submission
+type Acknowledgement struct {
+	Message     string `json:"message"`
+	RedirectURL string `json:"redirect_url,omitempty"`
 }
 
 // WidgetStats represents statistics for a widget
 type WidgetStats struct {
-	WidgetID string    `json:"widget_id"`
-	Views    int64     `json:"views"`
-	Submits  int64     `json:"submits"`
-	Closes   int64     `json:"closes"`
-	LastView time.Time `json:"last_view,omitempty"`
+	WidgetID   string    `json:"widget_id"`
+	Views      int64     `json:"views"`
+	Submits    int64     `json:"submits"`
+	Closes     int64     `json:"closes"`
+	Spam       int64     `json:"spam"` // Submissions silently dropped as spam, not counted as submits
+	LastView   time.Time `json:"last_view,omitempty"`
+	LastSubmit time.Time `json:"last_submit,omitempty"`
+
+	// Computed from the counters when stats are read, not stored
+	SubmitRate float64 `json:"submit_rate"` // Submits per view
+	CloseRate  float64 `json:"close_rate"`  // Closes per view
+
+	Preview *ProjectedSubmission `json:"preview,omitempty"` // Requested fields of the latest submission
+
+	Stale       bool   `json:"stale,omitempty"` // Cached stats served because a fresh read was too slow or failed
+	CacheStatus string `json:"-"`               // StatsCacheHit or StatsCacheMiss when stats are cached, empty otherwise
+}
+
+// Stats metrics with daily counters
+const (
+	StatsMetricViews   = "views"
+	StatsMetricSubmits = "submits"
+	StatsMetricCloses  = "closes"
+)
+
+// IsValidStatsMetric checks if the stats metric has daily counters
+func IsValidStatsMetric(metric string) bool {
+	return metric == StatsMetricViews || metric == StatsMetricSubmits || metric == StatsMetricCloses
+}
+
+// DailyStat is a stats metric's count on one day
+type DailyStat struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Value int64  `json:"value"`
+}
+
+// Stats cache statuses, sent in the X-Cache header
+const (
+	StatsCacheHit  = "HIT"
+	StatsCacheMiss = "MISS"
+)
+
+// LastActivity returns the latest of the last view and last submission times
+// (zero when the widget had no activity)
+func (s *WidgetStats) LastActivity() time.Time {
+	if s.LastSubmit.After(s.LastView) {
+		return s.LastSubmit
+	}
+	return s.LastView
+}
+
+// ConversionRate returns submits per view (0 when the widget had no views)
+func (s *WidgetStats) ConversionRate() float64 {
+	if s.Views <= 0 {
+		return 0
+	}
+	return float64(s.Submits) / float64(s.Views)
+}
+
+// ComputeRates sets the submit and close rates from the counters (0 when the widget
+// had no views)
+func (s *WidgetStats) ComputeRates() {
+	s.SubmitRate = s.ConversionRate()
+	s.CloseRate = 0
+	if s.Views > 0 {
+		s.CloseRate = float64(s.Closes) / float64(s.Views)
+	}
 }
 
 // CreateWidgetRequest represents request data for creating a widget
@@ -100,14 +1063,17 @@ type CreateWidgetRequest struct {
 	Type      string                 `json:"type"`
 	Name      string                 `json:"name"`
 	IsVisible bool                   `json:"isVisible"`
+	Status    *WidgetStatus          `json:"status,omitempty"` // Overrides isVisible when set
 	Config    map[string]interface{} `json:"config"`
+	Plan      string                 `json:"-"` // Owner's plan, set by the handler
 }
 
 // UpdateWidgetRequest represents request data for updating a widget
 type UpdateWidgetRequest struct {
-	Type      *string `json:"type,omitempty"`
-	Name      *string `json:"name,omitempty"`
-	IsVisible *bool   `json:"isVisible,omitempty"`
+	Type      *string       `json:"type,omitempty"`
+	Name      *string       `json:"name,omitempty"`
+	IsVisible *bool         `json:"isVisible,omitempty"`
+	Status    *WidgetStatus `json:"status,omitempty"` // Overrides isVisible when both are set
 }
 
 // UpdateWidgetConfigRequest represents request data for updating widget config
@@ -115,14 +1081,38 @@ type UpdateWidgetConfigRequest struct {
 	Config map[string]interface{} `json:"config"`
 }
 
+// WidgetConfigVersion is a replaced widget config kept in the widget's config history
+type WidgetConfigVersion struct {
+	Version    int                    `json:"version"` // Widget version the config belonged to
+	Config     map[string]interface{} `json:"config"`
+	ReplacedAt time.Time              `json:"replaced_at"` // When a newer config replaced it
+}
+
+// FormToken is a signed time a widget form was rendered, sent back with the submission
+type FormToken struct {
+	Token    string    `json:"token"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
 // SubmissionRequest represents request data for creating a submission
 type SubmissionRequest struct {
-	Data map[string]interface{} `json:"data"`
+	Data       map[string]interface{} `json:"data"`
+	OccurredAt *time.Time             `json:"occurred_at,omitempty"` // Client capture time, honored if the widget allows it
+	StartedAt  *time.Time             `json:"started_at,omitempty"`  // Client time the form was shown, for the policy's min fill time
+	FormToken  string                 `json:"form_token,omitempty"`  // Signed render time, replaces started_at when form tokens are enabled
+	Meta       map[string]string      `json:"meta,omitempty"`        // Page query params, kept as listed in the widget's capture_params
+	Trusted    bool                   `json:"-"`                     // Set by the handler for widget-scoped tokens
+	ClientIP   string                 `json:"-"`                     // Set by the handler for geo region lookup
+	Header     http.Header            `json:"-"`                     // Set by the handler for required header checks
+	Pixel      bool                   `json:"-"`                     // Set by the handler for GET tracking pixel submissions
 }
 
 // EventRequest represents request data for widget events
 type EventRequest struct {
-	Type string `json:"type"` // "view", "close"
+	Type     string `json:"type"` // "view", "close"
+	Seen     bool   `json:"-"`    // Set by the handler when the visitor's view cookie is present
+	ClientIP string `json:"-"`    // Set by the handler for view deduplication
+	Pixel    bool   `json:"-"`    // Set by the handler for GET tracking pixel events
 }
 
 // FilterOptions represents filtering parameters for widgets
@@ -130,6 +1120,11 @@ type FilterOptions struct {
 	Types     []string `json:"types,omitempty"`     // Filter by widget types
 	IsVisible *bool    `json:"isVisible,omitempty"` // Filter by visibility status (nil = all)
 	Search    string   `json:"search,omitempty"`    // Search by widget name
+
+	IncludeArchived bool `json:"includeArchived,omitempty"` // List archived widgets too, they are left out by default
+
+	CreatedFrom *time.Time `json:"created_from,omitempty"` // Filter by creation time, inclusive (nil = unbounded)
+	CreatedTo   *time.Time `json:"created_to,omitempty"`   // Filter by creation time, inclusive (nil = unbounded)
 }
 
 // PaginationOptions represents pagination parameters
@@ -137,6 +1132,71 @@ type PaginationOptions struct {
 	Page    int            `json:"page"`
 	PerPage int            `json:"per_page"`
 	Filters *FilterOptions `json:"filters,omitempty"` // Optional filtering parameters
+
+	SortBy    string `json:"sort_by,omitempty"`    // Widget list sort key, SortByCreated by default
+	SortOrder string `json:"sort_order,omitempty"` // SortOrderAsc or SortOrderDesc, the sort key's default when empty
+}
+
+// IsDefaultOrder reports whether the options ask for the default newest-first order
+func (o PaginationOptions) IsDefaultOrder() bool {
+	return (o.SortBy == "" || o.SortBy == SortByCreated) && o.SortDescending()
+}
+
+// SortDescending reports whether the sort key orders larger values first: names sort
+// ascending (A to Z) by default, times and counts descending
+func (o PaginationOptions) SortDescending() bool {
+	switch o.SortOrder {
+	case SortOrderAsc:
+		return false
+	case SortOrderDesc:
+		return true
+	}
+	return o.SortBy != SortByName
+}
+
+// Widget list sort keys and directions. Ties are broken by creation time (newest first),
+// then by ID, so pages stay consistent across requests.
+const (
+	SortByCreated      = "created"       // Creation time, the default
+	SortByName         = "name"          // Widget name, case-insensitive
+	SortBySubmits      = "submits"       // Submission count
+	SortByViews        = "views"         // View count
+	SortByLastActivity = "last_activity" // Most recent view or submission
+
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+// SortSubmissions is accepted as a name of SortBySubmits, as in ?sort=submissions
+const SortSubmissions = "submissions"
+
+// ParseSortBy normalizes a widget list sort key, mapping SortSubmissions to
+// SortBySubmits. ok is false for unknown keys.
+func ParseSortBy(value string) (sortBy string, ok bool) {
+	switch sortBy = strings.ToLower(strings.TrimSpace(value)); sortBy {
+	case SortByCreated, SortByName, SortBySubmits, SortByViews, SortByLastActivity:
+		return sortBy, true
+	case SortSubmissions:
+		return SortBySubmits, true
+	}
+	return "", false
+}
+
+// IsValidSortOrder checks if the sort direction is supported
+func IsValidSortOrder(order string) bool {
+	return order == SortOrderAsc || order == SortOrderDesc
+}
+
+// ParseWidgetSort parses a ?sort= value of the form <key> or <key>:<order>, the short
+// form of sort_by and sort_order, into its key and order, the order empty when not
+// given. ok is false for unknown keys and orders.
+func ParseWidgetSort(value string) (sortBy, order string, ok bool) {
+	key, order, _ := strings.Cut(strings.ToLower(strings.TrimSpace(value)), ":")
+	sortBy, ok = ParseSortBy(key)
+	if !ok || (order != "" && !IsValidSortOrder(order)) {
+		return "", "", false
+	}
+	return sortBy, order, true
 }
 
 // PaginatedResponse represents a paginated response
@@ -153,11 +1213,23 @@ type TypeStats struct {
 
 // Meta represents pagination metadata
 type Meta struct {
-	Page      int          `json:"page"`
-	PerPage   int          `json:"per_page"`
-	Total     int          `json:"total"`
-	HasMore   bool         `json:"has_more"`
-	TypeStats []*TypeStats `json:"type_stats,omitempty"` // Statistics by widget types
+	Page      int              `json:"page"`
+	PerPage   int              `json:"per_page"`
+	Total     int              `json:"total"`
+	HasMore   bool             `json:"has_more"`
+	Links     *PaginationLinks `json:"links,omitempty"`      // Navigation URLs keeping the request's filters
+	TypeStats []*TypeStats     `json:"type_stats,omitempty"` // Statistics by widget types
+
+	NextCursor string `json:"next_cursor,omitempty"` // Cursor of the next page of cursor-paginated lists, omitted on the last
+}
+
+// PaginationLinks holds URLs of neighbouring pages of a list response. Prev is
+// omitted on the first page and Next on the last.
+type PaginationLinks struct {
+	First string `json:"first"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last"`
 }
 
 // WidgetsResponse represents a response containing multiple widgets
@@ -178,6 +1250,60 @@ type ErrorResponse struct {
 	Details interface{} `json:"details,omitempty"`
 }
 
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypePrefix prefixes the error code in problem type URIs
+const ProblemTypePrefix = "urn:leads-core:problem:"
+
+// ProblemDetails represents an RFC 7807 error response. Field errors go in the errors
+// extension member, other error details in details.
+type ProblemDetails struct {
+	Type     string        `json:"type"`
+	Title    string        `json:"title"`
+	Status   int           `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Instance string        `json:"instance,omitempty"`
+	Errors   []*FieldError `json:"errors,omitempty"`
+	Details  interface{}   `json:"details,omitempty"`
+}
+
+// NewProblemDetails builds the problem details of an error response. The type URI ends
+// in the code of the details when they have one, validation_failed for field errors and
+// the status text (e.g. not_found) otherwise.
+func NewProblemDetails(statusCode int, message string, details interface{}, instance string) *ProblemDetails {
+	problem := &ProblemDetails{
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   message,
+		Instance: instance,
+	}
+
+	code := strings.ToLower(strings.ReplaceAll(problem.Title, " ", "_"))
+	switch d := details.(type) {
+	case nil:
+	case []*FieldError:
+		code, problem.Errors = "validation_failed", d
+	case FieldErrors:
+		code, problem.Errors = "validation_failed", d
+	case map[string]string:
+		if d["code"] != "" {
+			code = d["code"]
+		}
+		problem.Details = d
+	case map[string]interface{}:
+		if c, ok := d["code"].(string); ok && c != "" {
+			code = c
+		}
+		problem.Details = d
+	default:
+		problem.Details = d
+	}
+	problem.Type = ProblemTypePrefix + code
+
+	return problem
+}
+
 // WidgetsSummary represents a summary of user's widgets
 type WidgetsSummary struct {
 	TotalWidgets     int `json:"total_widgets"`
@@ -185,21 +1311,167 @@ type WidgetsSummary struct {
 	DisabledWidgets  int `json:"disabled_widgets"`
 	TotalViews       int `json:"total_views"`
 	TotalSubmissions int `json:"total_submissions"`
+
+	ConversionRate float64 `json:"conversion_rate"` // Total submissions per total view (0 without views)
+}
+
+// WidgetTypeOverview aggregates a user's widgets of one type
+type WidgetTypeOverview struct {
+	Type             string     `json:"type"`
+	Count            int        `json:"count"`
+	TotalSubmissions int64      `json:"total_submissions"`
+	LastActivity     *time.Time `json:"last_activity,omitempty"` // Latest view or submission, absent without activity
+}
+
+// InferredField describes a submission data field inferred from a sample of submissions
+type InferredField struct {
+	Name  string         `json:"name"`
+	Type  string         `json:"type"`  // Most frequent type: string, number, bool, object, array or null
+	Count int            `json:"count"` // Number of sampled submissions containing the field
+	Types map[string]int `json:"types"` // Occurrences per type
+}
+
+// InferredSchema represents the shape of widget submission data inferred from a sample
+type InferredSchema struct {
+	WidgetID   string           `json:"widget_id"`
+	SampleSize int              `json:"sample_size"` // Number of submissions scanned
+	Fields     []*InferredField `json:"fields"`
+}
+
+// SubmissionHeatmap counts submissions by local day of week and hour of day
+type SubmissionHeatmap struct {
+	WidgetID  string     `json:"widget_id"`
+	Timezone  string     `json:"timezone"`
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+	Counts    [7][24]int `json:"counts"`              // Counts[weekday][hour], weekday 0 = Sunday
+	Total     int        `json:"total"`               // Submissions counted
+	Truncated bool       `json:"truncated,omitempty"` // The scan cap was hit before the range was covered
+}
+
+// FieldDistribution counts submissions by the value of one data field
+type FieldDistribution struct {
+	WidgetID  string         `json:"widget_id"`
+	Field     string         `json:"field"`
+	From      *time.Time     `json:"from,omitempty"`
+	To        *time.Time     `json:"to,omitempty"`
+	Buckets   map[string]int `json:"buckets"`             // Submissions per value, the most frequent values only
+	Other     int            `json:"other"`               // Submissions with values beyond the returned buckets
+	Missing   int            `json:"missing"`             // Submissions without a value for the field
+	Total     int            `json:"total"`               // Submissions counted
+	Truncated bool           `json:"truncated,omitempty"` // The scan cap was hit before the range was covered
+}
+
+// ComparedWidget holds one side of a widget comparison
+type ComparedWidget struct {
+	WidgetID       string  `json:"widget_id"`
+	Name           string  `json:"name"`
+	Type           string  `json:"type"`
+	Views          int64   `json:"views"`
+	Submits        int64   `json:"submits"`
+	Closes         *int64  `json:"closes,omitempty"` // All-time only, closes are not tracked per day
+	ConversionRate float64 `json:"conversion_rate"`  // Submits per view
+}
+
+// WidgetComparison compares the stats of two widgets, e.g. the variants of an A/B test
+type WidgetComparison struct {
+	A         *ComparedWidget `json:"a"`
+	B         *ComparedWidget `json:"b"`
+	Lift      *float64        `json:"lift"` // Relative conversion rate difference of B over A, null when A has no conversions
+	From      *time.Time      `json:"from,omitempty"`
+	To        *time.Time      `json:"to,omitempty"`
+	Truncated bool            `json:"truncated,omitempty"` // The submission scan cap was hit before the range was covered
+}
+
+// SubmissionTail holds submissions created after a tail cursor, oldest first
+type SubmissionTail struct {
+	Submissions []*Submission `json:"submissions"`
+	Cursor      string        `json:"cursor"` // Opaque value to pass as since on the next call
+}
+
+// SubmissionCursor is a position in a widget's submissions, newest first: the index
+// score (creation Unix time) and ID of the last submission of a page
+type SubmissionCursor struct {
+	Score int64
+	ID    string
+}
+
+// String encodes the cursor as the opaque value clients pass back
+func (c SubmissionCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.Score, 10) + ":" + c.ID))
+}
+
+// ParseSubmissionCursor decodes a cursor of a previous response
+func ParseSubmissionCursor(value string) (*SubmissionCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor encoding")
+	}
+	score, id, ok := strings.Cut(string(decoded), ":")
+	if !ok || id == "" {
+		return nil, fmt.Errorf("invalid cursor format")
+	}
+	parsed, err := strconv.ParseInt(score, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor score")
+	}
+	return &SubmissionCursor{Score: parsed, ID: id}, nil
+}
+
+// valueContains reports whether a data value, or any value nested in it, contains the
+// lowercase term when formatted as text
+func valueContains(value interface{}, term string) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return strings.Contains(strings.ToLower(v), term)
+	case float64:
+		// JSON numbers, formatted without exponent so phone numbers match
+		return strings.Contains(strconv.FormatFloat(v, 'f', -1, 64), term)
+	case map[string]interface{}:
+		for _, nested := range v {
+			if valueContains(nested, term) {
+				return true
+			}
+		}
+		return false
+	case []interface{}:
+		for _, nested := range v {
+			if valueContains(nested, term) {
+				return true
+			}
+		}
+		return false
+	default:
+		return strings.Contains(strings.ToLower(fmt.Sprint(v)), term)
+	}
 }
 
 // ToRedisHash converts Widget to map for Redis HSET
 func (f *Widget) ToRedisHash() map[string]interface{} {
 	configJSON, _ := json.Marshal(f.Config)
-	return map[string]interface{}{
+	hash := map[string]interface{}{
 		"id":         f.ID,
 		"owner_id":   f.OwnerID,
 		"type":       f.Type,
 		"name":       f.Name,
 		"isVisible":  strconv.FormatBool(f.IsVisible),
+		"status":     string(f.EffectiveStatus()),
 		"config":     string(configJSON),
+		"version":    f.Version,
 		"created_at": f.CreatedAt.Unix(),
 		"updated_at": f.UpdatedAt.Unix(),
 	}
+	if f.ExpiresAt != nil {
+		hash["expires_at"] = f.ExpiresAt.Unix()
+	}
+	hash["archived"] = strconv.FormatBool(f.Archived)
+	hash["archived_at"] = 0
+	if f.ArchivedAt != nil {
+		hash["archived_at"] = f.ArchivedAt.Unix()
+	}
+	return hash
 }
 
 // FromRedisHash converts Redis hash to Widget
@@ -209,6 +1481,10 @@ func (f *Widget) FromRedisHash(hash map[string]string) error {
 	f.Type = hash["type"]
 	f.Name = hash["name"]
 	f.IsVisible = hash["isVisible"] == "true"
+	f.Status = WidgetStatus(hash["status"])
+	if f.Status == "" {
+		f.Status = f.EffectiveStatus() // Widgets stored before statuses existed
+	}
 
 	if configStr, ok := hash["config"]; ok && configStr != "" {
 		if err := json.Unmarshal([]byte(configStr), &f.Config); err != nil {
@@ -216,6 +1492,11 @@ func (f *Widget) FromRedisHash(hash map[string]string) error {
 		}
 	}
 
+	f.Version, _ = strconv.Atoi(hash["version"])
+	if f.Version < 1 {
+		f.Version = 1 // Widgets stored before versioning existed
+	}
+
 	if createdAtStr, ok := hash["created_at"]; ok && createdAtStr != "" {
 		if timestamp, err := strconv.ParseInt(createdAtStr, 10, 64); err == nil {
 			f.CreatedAt = time.Unix(timestamp, 0)
@@ -228,18 +1509,50 @@ func (f *Widget) FromRedisHash(hash map[string]string) error {
 		}
 	}
 
+	if expiresAtStr, ok := hash["expires_at"]; ok && expiresAtStr != "" {
+		if timestamp, err := strconv.ParseInt(expiresAtStr, 10, 64); err == nil {
+			expiresAt := time.Unix(timestamp, 0)
+			f.ExpiresAt = &expiresAt
+		}
+	}
+
+	f.Archived = hash["archived"] == "true"
+	if timestamp, err := strconv.ParseInt(hash["archived_at"], 10, 64); err == nil && timestamp > 0 {
+		archivedAt := time.Unix(timestamp, 0)
+		f.ArchivedAt = &archivedAt
+	}
+
 	return nil
 }
 
 // ToRedisHash converts Submission to map for Redis HSET
 func (s *Submission) ToRedisHash() map[string]interface{} {
 	dataJSON, _ := json.Marshal(s.Data)
-	return map[string]interface{}{
-		"id":         s.ID,
-		"widget_id":  s.WidgetID,
-		"data":       string(dataJSON),
-		"created_at": s.CreatedAt.Unix(),
+	hash := map[string]interface{}{
+		"id":                    s.ID,
+		"widget_id":             s.WidgetID,
+		"data":                  string(dataJSON),
+		"created_at":            s.CreatedAt.Unix(),
+		"trusted":               strconv.FormatBool(s.Trusted),
+		"region":                s.Region,
+		"received_while_paused": strconv.FormatBool(s.ReceivedWhilePaused),
+		"pii_redacted":          strconv.FormatBool(s.PIIRedacted),
+	}
+	if s.ReceivedAt != nil {
+		hash["received_at"] = s.ReceivedAt.Unix()
+	}
+	if s.WidgetVersion > 0 {
+		hash["widget_version"] = s.WidgetVersion
 	}
+	if len(s.ValidationWarnings) > 0 {
+		warningsJSON, _ := json.Marshal(s.ValidationWarnings)
+		hash["validation_warnings"] = string(warningsJSON)
+	}
+	if len(s.Meta) > 0 {
+		metaJSON, _ := json.Marshal(s.Meta)
+		hash["meta"] = string(metaJSON)
+	}
+	return hash
 }
 
 // FromRedisHash converts Redis hash to Submission
@@ -259,14 +1572,165 @@ func (s *Submission) FromRedisHash(hash map[string]string) error {
 		}
 	}
 
+	if receivedAtStr, ok := hash["received_at"]; ok && receivedAtStr != "" {
+		if timestamp, err := strconv.ParseInt(receivedAtStr, 10, 64); err == nil {
+			receivedAt := time.Unix(timestamp, 0)
+			s.ReceivedAt = &receivedAt
+		}
+	}
+
+	s.WidgetVersion, _ = strconv.Atoi(hash["widget_version"])
+	s.Trusted = hash["trusted"] == "true"
+	s.Region = hash["region"]
+	s.ReceivedWhilePaused = hash["received_while_paused"] == "true"
+	s.PIIRedacted = hash["pii_redacted"] == "true"
+
+	if warningsStr, ok := hash["validation_warnings"]; ok && warningsStr != "" {
+		if err := json.Unmarshal([]byte(warningsStr), &s.ValidationWarnings); err != nil {
+			return err
+		}
+	}
+
+	if metaStr, ok := hash["meta"]; ok && metaStr != "" {
+		if err := json.Unmarshal([]byte(metaStr), &s.Meta); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// MaxBatchGetWidgetIDs caps the number of IDs accepted by GET /widgets?ids=
+const MaxBatchGetWidgetIDs = 100
+
+// BulkStatsResetRequest represents request data for resetting stats of several widgets
+type BulkStatsResetRequest struct {
+	IDs        []string `json:"ids"`
+	ResetDaily bool     `json:"reset_daily,omitempty"` // Also remove daily view, submit and close counters
+}
+
+// BulkOperationResult represents the outcome of a bulk operation for a single widget
+type BulkOperationResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SubmissionBulkDeleteRequest represents request data for deleting the submissions of a
+// widget created in a time range; without bounds Confirm must be set to delete them all
+type SubmissionBulkDeleteRequest struct {
+	From    *time.Time `json:"from,omitempty"`
+	To      *time.Time `json:"to,omitempty"`
+	Confirm bool       `json:"confirm,omitempty"`
+}
+
+// SubmissionBulkDeleteResult reports the number of submissions deleted in bulk
+type SubmissionBulkDeleteResult struct {
+	Deleted int `json:"deleted"`
+}
+
+// MaintenanceUpdateRequest represents request data for toggling maintenance mode
+type MaintenanceUpdateRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// MaintenanceStatus represents the current maintenance mode state
+type MaintenanceStatus struct {
+	Enabled    bool `json:"enabled"`
+	RetryAfter int  `json:"retry_after"` // Seconds advertised to rejected clients
+}
+
+// RateLimitUsage represents the rate limit counters of an IP in the current one-minute window
+type RateLimitUsage struct {
+	IP          string `json:"ip"`
+	Window      string `json:"window"`
+	Count       int64  `json:"count"`
+	Limit       int    `json:"limit"`
+	Limited     bool   `json:"limited"` // Whether requests from the IP are rejected for the rest of the window
+	GlobalCount int64  `json:"global_count"`
+	GlobalLimit int    `json:"global_limit"`
+	ResetIn     int    `json:"reset_in"` // Seconds until the window ends
+}
+
+// PausedWidgetType is a widget type whose widgets don't accept submissions for now
+type PausedWidgetType struct {
+	Type     string    `json:"type"`
+	PausedAt time.Time `json:"paused_at"`
+}
+
+// WidgetIndexEntry reports a widget's membership in one index key
+type WidgetIndexEntry struct {
+	Key      string   `json:"key"`
+	Expected bool     `json:"expected"`        // The widget record says it belongs here
+	Member   bool     `json:"member"`          // The key actually holds the widget
+	Score    *float64 `json:"score,omitempty"` // Sorted set score of members
+}
+
+// WidgetIndexReport lists the index keys holding a widget, to diagnose index drift
+type WidgetIndexReport struct {
+	WidgetID  string             `json:"widget_id"`
+	Exists    bool               `json:"exists"` // The widget record is present
+	Type      string             `json:"type,omitempty"`
+	IsVisible bool               `json:"isVisible"`
+	OwnerID   string             `json:"owner_id,omitempty"`
+	Indexes   []WidgetIndexEntry `json:"indexes"`
+	Drift     bool               `json:"drift"` // Some membership differs from the expected one
+}
+
 // UpdateTTLRequest represents request data for updating TTL
 type UpdateTTLRequest struct {
 	TTLDays int `json:"ttl_days"`
 }
 
+// ExportFormats lists the supported export formats
+var ExportFormats = []string{"csv", "json", "xlsx", "tsv", "ndjson"}
+
+// IsValidExportFormat checks if the export format is supported
+func IsValidExportFormat(format string) bool {
+	for _, f := range ExportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// How arrays in data fields are written to CSV and XLSX exports
+const (
+	ExportArrayJSON  = "json"  // JSON-encoded in one column (default)
+	ExportArrayIndex = "index" // One column per element, e.g. tags.0, tags.1
+	ExportArrayJoin  = "join"  // Elements joined with ";" in one column
+)
+
+// MaxExportDepth bounds how many levels of nested data fields exports expand into columns
+const MaxExportDepth = 10
+
+// IsValidExportArrayMode checks if the export array mode is supported
+func IsValidExportArrayMode(mode string) bool {
+	return mode == ExportArrayJSON || mode == ExportArrayIndex || mode == ExportArrayJoin
+}
+
+// ExportContentType returns the Content-Type of an export format
+func ExportContentType(format string) string {
+	switch format {
+	case "csv":
+		return "text/csv"
+	case "tsv":
+		return "text/tab-separated-values"
+	case "ndjson":
+		return "application/x-ndjson"
+	case "xlsx":
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/json"
+	}
+}
+
+// UserPreferences holds per-user settings. Empty values mean the server default.
+type UserPreferences struct {
+	ExportFormat string `json:"export_format"` // Used by exports without an explicit format
+}
+
 // MetricsResponse represents metrics data
 type MetricsResponse struct {
 	Timestamp time.Time      `json:"timestamp"`
@@ -294,7 +1758,7 @@ type RuntimeMetrics struct {
 
 // ExportRequest represents request data for exporting submissions
 type ExportRequest struct {
-	Format string     `json:"format"` // "csv", "json", "xlsx"
+	Format string     `json:"format"` // "csv", "json", "xlsx", "tsv", "ndjson"
 	From   *time.Time `json:"from,omitempty"`
 	To     *time.Time `json:"to,omitempty"`
 }
@@ -304,10 +1768,243 @@ type ExportOptions struct {
 	Format string
 	From   *time.Time
 	To     *time.Time
+	Region string // Only export submissions from this region (empty = all)
+	Filter SubmissionFilter
+
+	Headers     map[string]string // Column name -> header, overriding the widget's export_headers
+	IncludeMeta bool              // Export captured meta params (meta.* columns in CSV/XLSX)
+	Fields      []string          // Data fields to export, in this order (empty = all discovered fields)
+
+	// Nested data fields in CSV/XLSX: objects (and arrays in index mode) are expanded into
+	// dotted columns up to MaxDepth levels, deeper values are JSON-encoded
+	ArrayMode string // One of the ExportArray* modes, ExportArrayJSON when empty
+	MaxDepth  int
+
+	MaxRows    int // Export at most this many submissions, the newest (0 = all); set from the user's plan
+	DailyQuota int // Export jobs count towards a daily quota of this many exports once they succeed (0 = unlimited); set from the user's plan
+}
+
+// SubmissionFilter selects submissions by data field values and free-text search.
+// All conditions must match; comparisons are case-insensitive.
+type SubmissionFilter struct {
+	Fields map[string]string // Field name -> exact value
+	Meta   map[string]string // Meta param -> exact value
+	Search string            // Substring of any data value, nested ones included
+}
+
+// IsEmpty reports whether the filter has no conditions
+func (f SubmissionFilter) IsEmpty() bool {
+	return len(f.Fields) == 0 && len(f.Meta) == 0 && f.Search == ""
+}
+
+// Matches reports whether the submission satisfies all filter conditions
+func (f SubmissionFilter) Matches(submission *Submission) bool {
+	for field, expected := range f.Fields {
+		value, ok := submission.Data[field]
+		if !ok || value == nil || !strings.EqualFold(fmt.Sprint(value), expected) {
+			return false
+		}
+	}
+	for param, expected := range f.Meta {
+		if value, ok := submission.Meta[param]; !ok || !strings.EqualFold(value, expected) {
+			return false
+		}
+	}
+
+	if f.Search == "" {
+		return true
+	}
+	return valueContains(submission.Data, strings.ToLower(f.Search))
+}
+
+// ParseFieldFilters parses "key:value" field filters, as given in ?field= query parameters
+func ParseFieldFilters(filters []string) (map[string]string, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	fields := make(map[string]string, len(filters))
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid field filter %q, expected key:value", filter)
+		}
+		fields[key] = strings.TrimSpace(value)
+	}
+	return fields, nil
+}
+
+// ParseExportHeaders parses repeated column:header query values into a column rename map
+func ParseExportHeaders(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	headers := make(map[string]string, len(values))
+	for _, value := range values {
+		column, header, ok := strings.Cut(value, ":")
+		column, header = strings.TrimSpace(column), strings.TrimSpace(header)
+		if !ok || column == "" || header == "" {
+			return nil, fmt.Errorf("invalid header mapping %q, expected column:header", value)
+		}
+		headers[column] = header
+	}
+	return headers, nil
+}
+
+// Export job statuses
+const (
+	ExportJobQueued    = "queued"
+	ExportJobRunning   = "running"
+	ExportJobRetrying  = "retrying" // Failed attempt, waiting to be run again
+	ExportJobCompleted = "completed"
+	ExportJobFailed    = "failed"
+	ExportJobCancelled = "cancelled"
+)
+
+// IsValidExportJobStatus checks if the export job status is supported
+func IsValidExportJobStatus(status string) bool {
+	switch status {
+	case ExportJobQueued, ExportJobRunning, ExportJobRetrying, ExportJobCompleted, ExportJobFailed, ExportJobCancelled:
+		return true
+	}
+	return false
+}
+
+// ExportJob represents an asynchronous submissions export
+type ExportJob struct {
+	ID              string        `json:"id"`
+	WidgetID        string        `json:"widget_id"`
+	OwnerID         string        `json:"-"`
+	Format          string        `json:"format"`
+	Status          string        `json:"status"`
+	Attempts        int           `json:"attempts"`                   // Runs started so far, including retries
+	CancelRequested bool          `json:"cancel_requested,omitempty"` // Running job will be discarded when done
+	Filename        string        `json:"filename,omitempty"`
+	Size            int           `json:"size,omitempty"`
+	DownloadURL     string        `json:"download_url,omitempty"`        // Presigned URL when the file was uploaded to object storage
+	DownloadExpires *time.Time    `json:"download_expires_at,omitempty"` // When DownloadURL stops working
+	Error           string        `json:"error,omitempty"`
+	Options         ExportOptions `json:"-"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// IsFinished reports whether the job reached a final status
+func (j *ExportJob) IsFinished() bool {
+	return j.Status == ExportJobCompleted || j.Status == ExportJobFailed || j.Status == ExportJobCancelled
+}
+
+// ToRedisHash converts ExportJob to map for Redis HSET
+func (j *ExportJob) ToRedisHash() map[string]interface{} {
+	optionsJSON, _ := json.Marshal(j.Options)
+	return map[string]interface{}{
+		"id":               j.ID,
+		"widget_id":        j.WidgetID,
+		"owner_id":         j.OwnerID,
+		"format":           j.Format,
+		"status":           j.Status,
+		"attempts":         j.Attempts,
+		"cancel_requested": strconv.FormatBool(j.CancelRequested),
+		"filename":         j.Filename,
+		"size":             j.Size,
+		"error":            j.Error,
+		"options":          string(optionsJSON),
+		"created_at":       j.CreatedAt.Unix(),
+		"updated_at":       j.UpdatedAt.Unix(),
+	}
+}
+
+// FromRedisHash converts Redis hash to ExportJob
+func (j *ExportJob) FromRedisHash(hash map[string]string) error {
+	j.ID = hash["id"]
+	j.WidgetID = hash["widget_id"]
+	j.OwnerID = hash["owner_id"]
+	j.Format = hash["format"]
+	j.Status = hash["status"]
+	j.CancelRequested = hash["cancel_requested"] == "true"
+	j.Filename = hash["filename"]
+	j.DownloadURL = hash["download_url"]
+	j.Error = hash["error"]
+
+	if sizeStr := hash["size"]; sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil {
+			j.Size = size
+		}
+	}
+	if attemptsStr := hash["attempts"]; attemptsStr != "" {
+		if attempts, err := strconv.Atoi(attemptsStr); err == nil {
+			j.Attempts = attempts
+		}
+	}
+
+	if optionsStr := hash["options"]; optionsStr != "" {
+		if err := json.Unmarshal([]byte(optionsStr), &j.Options); err != nil {
+			return err
+		}
+	}
+
+	if createdAtStr := hash["created_at"]; createdAtStr != "" {
+		if timestamp, err := strconv.ParseInt(createdAtStr, 10, 64); err == nil {
+			j.CreatedAt = time.Unix(timestamp, 0)
+		}
+	}
+	if updatedAtStr := hash["updated_at"]; updatedAtStr != "" {
+		if timestamp, err := strconv.ParseInt(updatedAtStr, 10, 64); err == nil {
+			j.UpdatedAt = time.Unix(timestamp, 0)
+		}
+	}
+	if expiresStr := hash["download_expires_at"]; expiresStr != "" {
+		if timestamp, err := strconv.ParseInt(expiresStr, 10, 64); err == nil {
+			expires := time.Unix(timestamp, 0)
+			j.DownloadExpires = &expires
+		}
+	}
+
+	return nil
+}
+
+// ImportSubmissionRequest represents a single line of an NDJSON submissions import
+type ImportSubmissionRequest struct {
+	Data      map[string]interface{} `json:"data"`
+	CreatedAt *time.Time             `json:"created_at,omitempty"` // Defaults to the import time
+}
+
+// ImportLineError describes why a single import line was rejected
+type ImportLineError struct {
+	Line    int           `json:"line"`
+	Error   string        `json:"error"`
+	Details []*FieldError `json:"details,omitempty"`
+}
+
+// ImportSummary represents the outcome of a submissions import
+type ImportSummary struct {
+	Imported        int                `json:"imported"`
+	Failed          int                `json:"failed"`
+	Aborted         bool               `json:"aborted,omitempty"` // Stopped early; lines before the failed one stay imported
+	Errors          []*ImportLineError `json:"errors,omitempty"`
+	ErrorsTruncated bool               `json:"errors_truncated,omitempty"` // More lines failed than are listed
+}
+
+// AddError records a failed line, keeping at most maxErrors line errors
+func (s *ImportSummary) AddError(lineErr *ImportLineError, maxErrors int) {
+	s.Failed++
+	if len(s.Errors) < maxErrors {
+		s.Errors = append(s.Errors, lineErr)
+	} else {
+		s.ErrorsTruncated = true
+	}
 }
 
 // ValidateFilterOptions validates filter options and returns cleaned version
 func ValidateFilterOptions(filters *FilterOptions) *FilterOptions {
+	return ValidateFilterOptionsWithTypes(filters, ValidWidgetTypes())
+}
+
+// ValidateFilterOptionsWithTypes validates filter options against a restricted set of widget types.
+// Types outside the set are treated as invalid and dropped.
+func ValidateFilterOptionsWithTypes(filters *FilterOptions, validTypes map[string]bool) *FilterOptions {
 	if filters == nil {
 		return nil
 	}
@@ -316,15 +2013,18 @@ func ValidateFilterOptions(filters *FilterOptions) *FilterOptions {
 		Types:     make([]string, 0),
 		IsVisible: filters.IsVisible,
 		Search:    strings.TrimSpace(filters.Search),
-	}
 
-	// Validate and clean widget types using centralized validation
-	validTypes := ValidWidgetTypes()
+		IncludeArchived: filters.IncludeArchived,
 
+		CreatedFrom: filters.CreatedFrom,
+		CreatedTo:   filters.CreatedTo,
+	}
+
+	// Validate and clean widget types (case-insensitive, canonical spelling)
 	for _, widgetType := range filters.Types {
 		cleanType := strings.TrimSpace(widgetType)
-		if cleanType != "" && validTypes[cleanType] {
-			validated.Types = append(validated.Types, cleanType)
+		if canonical, ok := canonicalWidgetType(cleanType, validTypes); ok {
+			validated.Types = append(validated.Types, canonical)
 		}
 	}
 
@@ -336,7 +2036,7 @@ func (f *FilterOptions) HasFilters() bool {
 	if f == nil {
 		return false
 	}
-	return len(f.Types) > 0 || f.IsVisible != nil || f.Search != ""
+	return len(f.Types) > 0 || f.IsVisible != nil || f.Search != "" || f.HasCreatedFilter()
 }
 
 // HasTypeFilter returns true if type filter is applied
@@ -349,6 +2049,11 @@ func (f *FilterOptions) HasVisibilityFilter() bool {
 	return f != nil && f.IsVisible != nil
 }
 
+// HasCreatedFilter returns true if a creation date bound is applied
+func (f *FilterOptions) HasCreatedFilter() bool {
+	return f != nil && (f.CreatedFrom != nil || f.CreatedTo != nil)
+}
+
 // HasSearchFilter returns true if search filter is applied
 func (f *FilterOptions) HasSearchFilter() bool {
 	return f != nil && f.Search != ""
@@ -3,6 +3,8 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -89,6 +91,35 @@ func TestWidgetFromRedisHash(t *testing.T) {
 	}
 }
 
+func TestWidget_Status(t *testing.T) {
+	// Widgets stored before statuses existed derive them from isVisible
+	legacy := &Widget{}
+	if err := legacy.FromRedisHash(map[string]string{"id": "w1", "isVisible": "false"}); err != nil {
+		t.Fatalf("Failed to parse widget: %v", err)
+	}
+	if legacy.Status != WidgetStatusClosed {
+		t.Errorf("Expected status closed, got %s", legacy.Status)
+	}
+
+	widget := &Widget{}
+	widget.SetStatus(WidgetStatusPaused)
+	if widget.IsVisible {
+		t.Error("Expected paused widget to be hidden")
+	}
+	if hash := widget.ToRedisHash(); hash["status"] != "paused" {
+		t.Errorf("Expected status paused in hash, got %v", hash["status"])
+	}
+
+	widget.SetVisible(true)
+	if widget.Status != WidgetStatusActive || !widget.IsVisible {
+		t.Errorf("Expected visible widget to be active, got %s", widget.Status)
+	}
+
+	if IsValidWidgetStatus("archived") {
+		t.Error("Expected unknown status to be invalid")
+	}
+}
+
 func TestWidget_JSONSerialization(t *testing.T) {
 	original := &Widget{
 		ID:        "test-widget-1",
@@ -367,6 +398,48 @@ func TestValidateFilterOptions(t *testing.T) {
 	}
 }
 
+func TestTypeRegistry_TypesForPlan(t *testing.T) {
+	registry := NewTypeRegistry(
+		map[string][]string{"free": {"lead-form", "Banner"}},
+		map[string][]string{"free": {"banner"}, "pro": {"quiz"}},
+	)
+
+	free := registry.TypesForPlan("free")
+	if len(free) != 1 || !free["lead-form"] {
+		t.Errorf("Expected only lead-form for free plan, got %v", free)
+	}
+
+	pro := registry.TypesForPlan("pro")
+	if pro["quiz"] {
+		t.Error("Expected quiz to be denied for pro plan")
+	}
+	if len(pro) != len(ValidWidgetTypes())-1 {
+		t.Errorf("Expected %d types for pro plan, got %d", len(ValidWidgetTypes())-1, len(pro))
+	}
+
+	if !registry.IsAllowed("enterprise", "quiz") {
+		t.Error("Expected all types to be allowed for unconfigured plan")
+	}
+	if registry.IsAllowed("pro", "QUIZ") {
+		t.Error("Expected denied type to be rejected case-insensitively")
+	}
+
+	var nilRegistry *TypeRegistry
+	if !nilRegistry.IsAllowed("free", "quiz") {
+		t.Error("Expected nil registry to allow all types")
+	}
+}
+
+func TestValidateFilterOptionsWithTypes(t *testing.T) {
+	filters := &FilterOptions{Types: []string{"lead-form", "QUIZ"}}
+	allowed := map[string]bool{"lead-form": true, "banner": true}
+
+	result := ValidateFilterOptionsWithTypes(filters, allowed)
+	if len(result.Types) != 1 || result.Types[0] != "lead-form" {
+		t.Errorf("Expected only lead-form to remain, got %v", result.Types)
+	}
+}
+
 func TestFilterOptions_HasFilters(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -533,3 +606,341 @@ func TestFilterOptions_HasSearchFilter(t *testing.T) {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func TestValidateFieldLengths(t *testing.T) {
+	data := map[string]interface{}{
+		"message": "hello world",
+		"name":    "bob",
+		"tags":    []interface{}{"short", "much too long"},
+		"age":     float64(42),
+	}
+
+	errs := ValidateFieldLengths(data, 5, map[string]int{"message": 11, "name": 2})
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].Field != "data.name" || errs[1].Field != "data.tags.1" {
+		t.Errorf("Unexpected error paths: %s, %s", errs[0].Field, errs[1].Field)
+	}
+
+	if errs := ValidateFieldLengths(data, 0, nil); len(errs) != 0 {
+		t.Errorf("Expected no errors when limit is disabled, got %v", errs)
+	}
+}
+
+func TestDemoWidgetExpiry(t *testing.T) {
+	createdAt := time.Date(2024, 2, 25, 10, 30, 0, 0, time.UTC)
+
+	if got := DemoWidgetExpiry(createdAt, 7); !got.Equal(time.Date(2024, 3, 3, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected expiry 7 days later across the month end, got %v", got)
+	}
+
+	widget := &Widget{ID: "w1", CreatedAt: createdAt, UpdatedAt: createdAt}
+	expiresAt := DemoWidgetExpiry(createdAt, 7)
+	widget.ExpiresAt = &expiresAt
+
+	hash := make(map[string]string)
+	for key, value := range widget.ToRedisHash() {
+		hash[key] = fmt.Sprint(value)
+	}
+	restored := &Widget{}
+	if err := restored.FromRedisHash(hash); err != nil {
+		t.Fatalf("FromRedisHash failed: %v", err)
+	}
+	if restored.ExpiresAt == nil || !restored.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Expected expires_at to round-trip, got %v", restored.ExpiresAt)
+	}
+}
+
+func TestWidgetStats_ComputeRates(t *testing.T) {
+	tests := []struct {
+		name       string
+		stats      WidgetStats
+		submitRate float64
+		closeRate  float64
+	}{
+		{name: "no views", stats: WidgetStats{Submits: 3, Closes: 2}},
+		{name: "no events", stats: WidgetStats{}},
+		{name: "views", stats: WidgetStats{Views: 200, Submits: 50, Closes: 20}, submitRate: 0.25, closeRate: 0.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := tt.stats
+			stats.ComputeRates()
+			if stats.SubmitRate != tt.submitRate || stats.CloseRate != tt.closeRate {
+				t.Errorf("Expected rates %v/%v, got %v/%v", tt.submitRate, tt.closeRate, stats.SubmitRate, stats.CloseRate)
+			}
+		})
+	}
+}
+
+func TestWidgetAcknowledgement(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		expected Acknowledgement
+	}{
+		{name: "unset", config: nil, expected: Acknowledgement{Message: DefaultAcknowledgementMessage}},
+		{name: "message only", config: map[string]interface{}{"message": "Thanks!"}, expected: Acknowledgement{Message: "Thanks!"}},
+		{name: "blank message", config: map[string]interface{}{"message": "  "}, expected: Acknowledgement{Message: DefaultAcknowledgementMessage}},
+		{name: "redirect", config: map[string]interface{}{"redirect_url": "https://example.com/thanks"}, expected: Acknowledgement{Message: DefaultAcknowledgementMessage, RedirectURL: "https://example.com/thanks"}},
+		{name: "unsafe redirect", config: map[string]interface{}{"redirect_url": "javascript:alert(1)"}, expected: Acknowledgement{Message: DefaultAcknowledgementMessage}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			widget := &Widget{Config: map[string]interface{}{}}
+			if tt.config != nil {
+				widget.Config[WidgetConfigAcknowledgementKey] = tt.config
+			}
+			if got := widget.Acknowledgement(); *got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, *got)
+			}
+		})
+	}
+}
+
+func TestParseFieldFilters(t *testing.T) {
+	fields, err := ParseFieldFilters([]string{"country:US", " source : ads ", "url:https://example.com"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := map[string]string{"country": "US", "source": "ads", "url": "https://example.com"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected %v, got %v", expected, fields)
+	}
+
+	for _, invalid := range []string{"country", ":US"} {
+		if _, err := ParseFieldFilters([]string{invalid}); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestFieldConditions_Matches(t *testing.T) {
+	data := map[string]interface{}{"budget": float64(12000), "email": "Lead@Example.com", "score": "7"}
+
+	tests := []struct {
+		condition FieldCondition
+		want      bool
+	}{
+		{FieldCondition{Field: "budget", Op: ConditionGreater, Value: float64(10000)}, true},
+		{FieldCondition{Field: "budget", Op: ConditionGreaterEqual, Value: "12000"}, true},
+		{FieldCondition{Field: "budget", Op: ConditionLess, Value: float64(10000)}, false},
+		{FieldCondition{Field: "score", Op: ConditionLessEqual, Value: float64(7)}, true},
+		{FieldCondition{Field: "budget", Op: ConditionEquals, Value: "12000.0"}, true},
+		{FieldCondition{Field: "email", Op: ConditionEquals, Value: "lead@example.com"}, true},
+		{FieldCondition{Field: "email", Op: ConditionNotEquals, Value: "other@example.com"}, true},
+		{FieldCondition{Field: "email", Op: ConditionContains, Value: "EXAMPLE"}, true},
+		{FieldCondition{Field: "email", Op: ConditionGreater, Value: float64(1)}, false},
+		{FieldCondition{Field: "phone", Op: ConditionExists}, false},
+		{FieldCondition{Field: "phone", Op: ConditionNotEquals, Value: "x"}, true},
+		{FieldCondition{Field: "budget", Op: "between", Value: float64(1)}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.condition.Matches(data); got != tt.want {
+			t.Errorf("%+v: expected %v, got %v", tt.condition, tt.want, got)
+		}
+	}
+
+	conditions := ParseFieldConditions([]interface{}{
+		map[string]interface{}{"field": "budget", "op": "gt", "value": float64(10000)},
+		map[string]interface{}{"op": "eq"}, // Malformed, skipped
+	})
+	if len(conditions) != 1 || !conditions.Matches(data) {
+		t.Errorf("Expected one parsed matching condition, got %+v", conditions)
+	}
+	if !FieldConditions(nil).Matches(data) {
+		t.Error("Expected empty conditions to match")
+	}
+}
+
+func TestSubmissionFilter_SearchNested(t *testing.T) {
+	submission := &Submission{Data: map[string]interface{}{
+		"name":    "Jane",
+		"phone":   float64(5551234567),
+		"contact": map[string]interface{}{"email": "Jane.Doe@Example.com", "tags": []interface{}{"VIP", float64(42)}},
+		"consent": true,
+		"note":    nil,
+	}}
+
+	tests := map[string]bool{
+		"jane":                 true,
+		"jane.doe@example.COM": true, // Nested object value
+		"vip":                  true, // Nested array value
+		"42":                   true,
+		"5551234":              true, // Numbers match without exponent
+		"true":                 true,
+		"contact":              false, // Keys don't match
+		"nil":                  false,
+		"john":                 false,
+	}
+	for search, want := range tests {
+		if got := (SubmissionFilter{Search: search}).Matches(submission); got != want {
+			t.Errorf("Search %q: expected %v, got %v", search, want, got)
+		}
+	}
+}
+
+func TestGeoRestrictions_Allows(t *testing.T) {
+	widget := &Widget{Config: map[string]interface{}{
+		WidgetConfigGeoKey: map[string]interface{}{
+			"allowed_countries": []interface{}{"DE", "fr", ""},
+			"blocked_countries": []interface{}{"de-by-mistake"},
+		},
+	}}
+	restrictions := widget.GeoRestrictions()
+	if len(restrictions.AllowedCountries) != 2 {
+		t.Fatalf("Expected empty entries to be skipped, got %v", restrictions.AllowedCountries)
+	}
+
+	tests := []struct {
+		restrictions GeoRestrictions
+		country      string
+		want         bool
+	}{
+		{restrictions, "DE", true},
+		{restrictions, "FR", true},
+		{restrictions, "US", false},
+		{restrictions, "", true},
+		{GeoRestrictions{BlockedCountries: []string{"RU"}}, "ru", false},
+		{GeoRestrictions{BlockedCountries: []string{"RU"}}, "US", true},
+		{GeoRestrictions{AllowedCountries: []string{"RU"}, BlockedCountries: []string{"RU"}}, "RU", false},
+		{GeoRestrictions{}, "US", true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.restrictions.Allows(tt.country); got != tt.want {
+			t.Errorf("%+v allows %q: expected %v, got %v", tt.restrictions, tt.country, tt.want, got)
+		}
+	}
+}
+
+func TestReservedFieldNames(t *testing.T) {
+	reserved := NewReservedFieldNames(DefaultReservedFieldNames)
+
+	for _, name := range []string{"id", "ID", "createdAt", "Created-At", "widget_version"} {
+		if !reserved.Contains(name) {
+			t.Errorf("Expected %q to be reserved", name)
+		}
+	}
+	for _, name := range []string{"email", "identity", "field_id"} {
+		if reserved.Contains(name) {
+			t.Errorf("Expected %q not to be reserved", name)
+		}
+	}
+
+	errs := reserved.Validate(map[string]interface{}{"email": "a@example.com", "id": "1", "createdAt": "x"})
+	if len(errs) != 2 || errs[0].Field != "data.createdAt" || errs[1].Field != "data.id" {
+		t.Errorf("Expected errors for createdAt and id, got %v", errs)
+	}
+
+	data := map[string]interface{}{"id": "1", "field_id": "taken", "ttl": 5, "email": "a@example.com"}
+	reserved.Prefix(data)
+	expected := map[string]interface{}{"field_field_id": "1", "field_id": "taken", "field_ttl": 5, "email": "a@example.com"}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("Expected reserved fields to be prefixed, got %v", data)
+	}
+}
+
+func TestPublicPolicy_Evaluate(t *testing.T) {
+	now := time.Now()
+	started := now.Add(-10 * time.Second)
+	justStarted := now.Add(-time.Second)
+
+	widget := &Widget{Config: map[string]interface{}{
+		WidgetConfigPublicPolicyKey: map[string]interface{}{
+			"allowed_methods":  []interface{}{"public"},
+			"allowed_domains":  []interface{}{"example.com"},
+			"required_headers": map[string]interface{}{"X-Widget-Token": "secret"},
+			"honeypot_field":   "website",
+			"min_fill_seconds": float64(3),
+			"allowed_fields":   []interface{}{"name", "email"},
+		},
+	}}
+	policy := widget.PublicPolicy()
+
+	header := func(origin string) http.Header {
+		h := http.Header{}
+		h.Set("Origin", origin)
+		h.Set("X-Widget-Token", "secret")
+		return h
+	}
+
+	tests := []struct {
+		name   string
+		input  PolicyInput
+		reason string
+	}{
+		{
+			name:  "all rules pass",
+			input: PolicyInput{Header: header("https://shop.example.com"), Data: map[string]interface{}{"email": "a@example.com", "website": ""}, StartedAt: &started, Now: now},
+		},
+		{
+			name:   "token submissions not allowed",
+			input:  PolicyInput{Trusted: true, Data: map[string]interface{}{"email": "a@example.com"}, Now: now},
+			reason: PolicyReasonMethodNotAllowed,
+		},
+		{
+			name:   "foreign origin",
+			input:  PolicyInput{Header: header("https://example.com.evil.test"), Data: map[string]interface{}{"email": "a@example.com"}, StartedAt: &started, Now: now},
+			reason: PolicyReasonDomainNotAllowed,
+		},
+		{
+			name:   "missing header",
+			input:  PolicyInput{Header: http.Header{"Origin": []string{"https://example.com"}}, Data: map[string]interface{}{"email": "a@example.com"}, StartedAt: &started, Now: now},
+			reason: PolicyReasonMissingHeaders,
+		},
+		{
+			name:   "honeypot filled",
+			input:  PolicyInput{Header: header("https://example.com"), Data: map[string]interface{}{"email": "a@example.com", "website": "spam.test"}, StartedAt: &started, Now: now},
+			reason: PolicyReasonHoneypot,
+		},
+		{
+			name:   "filled too fast",
+			input:  PolicyInput{Header: header("https://example.com"), Data: map[string]interface{}{"email": "a@example.com"}, StartedAt: &justStarted, Now: now},
+			reason: PolicyReasonTooFast,
+		},
+		{
+			name:   "no start time",
+			input:  PolicyInput{Header: header("https://example.com"), Data: map[string]interface{}{"email": "a@example.com"}, Now: now},
+			reason: PolicyReasonTooFast,
+		},
+		{
+			name:   "unknown field",
+			input:  PolicyInput{Header: header("https://example.com"), Data: map[string]interface{}{"email": "a@example.com", "phone": "123"}, StartedAt: &started, Now: now},
+			reason: PolicyReasonFieldNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Evaluate(tt.input)
+			if decision.Allowed != (tt.reason == "") || decision.Reason != tt.reason {
+				t.Errorf("Expected reason %q, got %+v", tt.reason, decision)
+			}
+		})
+	}
+
+	// Without a policy only the legacy required headers apply
+	legacy := (&Widget{Config: map[string]interface{}{
+		WidgetConfigRequiredHeadersKey: map[string]interface{}{"X-Widget-Token": ""},
+	}}).PublicPolicy()
+	if decision := legacy.Evaluate(PolicyInput{Trusted: true, Now: now}); !decision.Allowed {
+		t.Errorf("Expected token submissions to be allowed by default, got %+v", decision)
+	}
+	decision := legacy.Evaluate(PolicyInput{Header: http.Header{}, Now: now})
+	if _, ok := decision.Err().(*MissingHeadersError); !ok {
+		t.Errorf("Expected a missing headers error, got %v", decision.Err())
+	}
+}
+
+func TestParseSubmissionCursor_Invalid(t *testing.T) {
+	for _, value := range []string{"not base64!", "bm9jb2xvbg", "YWJjOmlk", "MTIzOg"} {
+		if _, err := ParseSubmissionCursor(value); err == nil {
+			t.Errorf("Expected an error for cursor %q", value)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WidgetConfigPublicPolicyKey is the widget config key holding the policy gating public
+// submissions, e.g. {"allowed_methods": ["public"], "allowed_domains": ["example.com"],
+// "required_headers": {"X-Widget-Token": "secret"}, "honeypot_field": "website",
+// "min_fill_seconds": 3, "allowed_fields": ["name", "email"]}
+const WidgetConfigPublicPolicyKey = "public_policy"
+
+// Submission methods a public policy can allow
+const (
+	SubmissionMethodPublic = "public" // Anonymous submissions from the embedded widget
+	SubmissionMethodToken  = "token"  // Submissions authenticated with a widget-scoped token
+)
+
+// Reason codes of rejected policy decisions
+const (
+	PolicyReasonMethodNotAllowed = "method_not_allowed"
+	PolicyReasonDomainNotAllowed = "domain_not_allowed"
+	PolicyReasonMissingHeaders   = "missing_headers"
+	PolicyReasonHoneypot         = "honeypot"
+	PolicyReasonTooFast          = "too_fast"
+	PolicyReasonFieldNotAllowed  = "field_not_allowed"
+)
+
+// PublicPolicy holds the anti-abuse rules applied to a widget's submissions. Zero
+// values disable a rule; token submissions only go through the method and field rules.
+type PublicPolicy struct {
+	AllowedMethods  []string          // Defaults to both methods
+	AllowedDomains  []string          // Origin (or Referer) hosts, subdomains included
+	RequiredHeaders map[string]string // As in WidgetConfigRequiredHeadersKey
+	HoneypotField   string            // Hidden field bots fill in, submissions filling it are dropped as spam
+	MinFillTime     time.Duration     // Minimum time between the form's render and the submission
+	AllowedFields   []string          // Data fields accepted, all when empty
+}
+
+// PublicPolicy returns the widget's public submission policy. Without one, both methods
+// are allowed and only the legacy required_headers setting applies.
+func (f *Widget) PublicPolicy() PublicPolicy {
+	policy := PublicPolicy{
+		AllowedMethods:  []string{SubmissionMethodPublic, SubmissionMethodToken},
+		RequiredHeaders: f.RequiredHeaders(),
+	}
+
+	settings, ok := f.Config[WidgetConfigPublicPolicyKey].(map[string]interface{})
+	if !ok {
+		return policy
+	}
+	if methods := stringList(settings["allowed_methods"]); len(methods) > 0 {
+		policy.AllowedMethods = methods
+	}
+	policy.AllowedDomains = stringList(settings["allowed_domains"])
+	if headers, ok := settings["required_headers"].(map[string]interface{}); ok {
+		policy.RequiredHeaders = make(map[string]string, len(headers))
+		for name, value := range headers {
+			if name == "" {
+				continue
+			}
+			expected, _ := value.(string)
+			policy.RequiredHeaders[name] = expected
+		}
+	}
+	if honeypot, ok := settings["honeypot_field"].(string); ok {
+		policy.HoneypotField = strings.TrimSpace(honeypot)
+	}
+	if seconds, ok := settings["min_fill_seconds"].(float64); ok && seconds > 0 {
+		policy.MinFillTime = time.Duration(seconds * float64(time.Second))
+	}
+	policy.AllowedFields = stringList(settings["allowed_fields"])
+	return policy
+}
+
+// PolicyInput is the submission a public policy is evaluated against
+type PolicyInput struct {
+	Trusted   bool // Submitted with a widget-scoped token
+	Header    http.Header
+	Data      map[string]interface{}
+	StartedAt *time.Time // Time the form was shown, from the form token or client
+	Now       time.Time
+}
+
+// PolicyDecision is the outcome of a policy evaluation, with the reason code and
+// offending names (headers or fields) of rejections
+type PolicyDecision struct {
+	Allowed bool
+	Reason  string
+	Details []string
+}
+
+// Err returns the error of a rejected decision, nil when allowed. Missing headers keep
+// their dedicated error.
+func (d PolicyDecision) Err() error {
+	switch {
+	case d.Allowed:
+		return nil
+	case d.Reason == PolicyReasonMissingHeaders:
+		return &MissingHeadersError{Headers: d.Details}
+	default:
+		return &PolicyViolationError{Reason: d.Reason, Details: d.Details}
+	}
+}
+
+// Evaluate checks the submission against the policy rules in order and returns the
+// first rejection
+func (p PublicPolicy) Evaluate(input PolicyInput) PolicyDecision {
+	method := SubmissionMethodPublic
+	if input.Trusted {
+		method = SubmissionMethodToken
+	}
+	if !containsFold(p.AllowedMethods, method) {
+		return PolicyDecision{Reason: PolicyReasonMethodNotAllowed}
+	}
+
+	if !input.Trusted {
+		if len(p.AllowedDomains) > 0 && !p.allowsDomain(input.Header) {
+			return PolicyDecision{Reason: PolicyReasonDomainNotAllowed}
+		}
+		if missing := MissingRequiredHeaders(input.Header, p.RequiredHeaders); len(missing) > 0 {
+			return PolicyDecision{Reason: PolicyReasonMissingHeaders, Details: missing}
+		}
+		if p.HoneypotField != "" && !isBlankValue(input.Data[p.HoneypotField]) {
+			return PolicyDecision{Reason: PolicyReasonHoneypot}
+		}
+		// Submissions without a start time didn't run the embed script
+		if p.MinFillTime > 0 && (input.StartedAt == nil || input.Now.Sub(*input.StartedAt) < p.MinFillTime) {
+			return PolicyDecision{Reason: PolicyReasonTooFast}
+		}
+	}
+
+	if len(p.AllowedFields) > 0 {
+		var unknown []string
+		for name := range input.Data {
+			if name != p.HoneypotField && !containsFold(p.AllowedFields, name) {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return PolicyDecision{Reason: PolicyReasonFieldNotAllowed, Details: unknown}
+		}
+	}
+
+	return PolicyDecision{Allowed: true}
+}
+
+// allowsDomain reports whether the Origin, or the Referer when there is no Origin,
+// is one of the allowed domains or their subdomains
+func (p PublicPolicy) allowsDomain(header http.Header) bool {
+	source := header.Get("Origin")
+	if source == "" || source == "null" {
+		source = header.Get("Referer")
+	}
+	u, err := url.Parse(source)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range p.AllowedDomains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether the list holds the value, ignoring case
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlankValue reports whether a submitted value is absent or empty
+func isBlankValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	case bool:
+		return !v
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// Reserved field name handling modes
+const (
+	ReservedFieldsReject = "reject" // Submissions using a reserved name fail validation
+	ReservedFieldsPrefix = "prefix" // Reserved names are stored with ReservedFieldPrefix
+	ReservedFieldsOff    = "off"    // Reserved names aren't checked
+)
+
+// ReservedFieldPrefix namespaces submitted fields whose names are reserved, e.g. "id" becomes "field_id"
+const ReservedFieldPrefix = "field_"
+
+// DefaultReservedFieldNames are the submission attributes that data fields could shadow in exports
+var DefaultReservedFieldNames = []string{"id", "widget_id", "created_at", "received_at", "ttl", "region", "trusted", "widget_version"}
+
+// IsValidReservedFieldsMode checks if the reserved field name handling mode is supported
+func IsValidReservedFieldsMode(mode string) bool {
+	switch mode {
+	case ReservedFieldsReject, ReservedFieldsPrefix, ReservedFieldsOff:
+		return true
+	}
+	return false
+}
+
+// ReservedFieldNames matches field names against a reserved list ignoring case and
+// separators, so "createdAt", "Created-At" and "created_at" are the same name
+type ReservedFieldNames map[string]struct{}
+
+// NewReservedFieldNames creates a matcher for the given names
+func NewReservedFieldNames(names []string) ReservedFieldNames {
+	reserved := make(ReservedFieldNames, len(names))
+	for _, name := range names {
+		if key := normalizeFieldName(name); key != "" {
+			reserved[key] = struct{}{}
+		}
+	}
+	return reserved
+}
+
+// Contains reports whether the field name is reserved
+func (r ReservedFieldNames) Contains(name string) bool {
+	_, ok := r[normalizeFieldName(name)]
+	return ok
+}
+
+// Validate reports a validation error for every data field with a reserved name
+func (r ReservedFieldNames) Validate(data map[string]interface{}) FieldErrors {
+	var errs FieldErrors
+	for _, name := range r.used(data) {
+		errs = append(errs, &FieldError{Field: "data." + name, Message: "Field name is reserved"})
+	}
+	return errs
+}
+
+// Prefix renames data fields with reserved names to ReservedFieldPrefix + name,
+// prefixing again while the new name is taken or still reserved
+func (r ReservedFieldNames) Prefix(data map[string]interface{}) {
+	for _, name := range r.used(data) {
+		renamed := ReservedFieldPrefix + name
+		for _, taken := data[renamed]; taken || r.Contains(renamed); _, taken = data[renamed] {
+			renamed = ReservedFieldPrefix + renamed
+		}
+		data[renamed] = data[name]
+		delete(data, name)
+	}
+}
+
+// used returns the reserved names present in data, sorted for stable results
+func (r ReservedFieldNames) used(data map[string]interface{}) []string {
+	var names []string
+	for name := range data {
+		if r.Contains(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeFieldName lowercases the name and drops separators
+func normalizeFieldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '_', '-', ' ', '.':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(name)))
+}
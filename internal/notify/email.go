@@ -0,0 +1,208 @@
+// Package notify emails submission notifications to widget owners.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ad/leads-core/internal/config"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/notify/format"
+	"github.com/ad/leads-core/internal/services"
+	"github.com/ad/leads-core/pkg/logger"
+	"github.com/ad/leads-core/pkg/metrics"
+)
+
+// SendTimeout bounds a whole SMTP conversation
+const SendTimeout = 10 * time.Second
+
+// Transport sends an email message to its recipients
+type Transport interface {
+	Send(from string, to []string, message []byte) error
+}
+
+// EmailNotifier emails notifications of widgets with email enabled to their recipient
+// in the background, then passes every notification on to the next notifier
+type EmailNotifier struct {
+	transport Transport
+	from      string
+	next      services.Notifier
+	queue     *services.NotificationQueue
+}
+
+// NewEmailNotifier creates a new email notifier sending from the given address
+func NewEmailNotifier(transport Transport, from string, next services.Notifier) *EmailNotifier {
+	return &EmailNotifier{
+		transport: transport,
+		from:      from,
+		next:      next,
+		queue:     services.NewNotificationQueue("email", services.DefaultNotificationWorkers, services.DefaultNotificationQueueSize),
+	}
+}
+
+// Notify queues the notification email and passes the notification on. Emails are sent
+// without waiting, so a slow or failing mail server never holds up the submission;
+// failures and emails dropped by a full queue are logged.
+func (n *EmailNotifier) Notify(ctx context.Context, widget *models.Widget, submissions []*models.Submission) error {
+	if email := widget.EmailNotification(); email != nil {
+		message, err := FormatEmail(n.from, email.Recipient, widget, submissions, time.Now())
+		if err != nil {
+			logger.Error("failed to format notification email", map[string]interface{}{
+				"action":    "notify_email",
+				"widget_id": widget.ID,
+				"error":     err.Error(),
+			})
+		} else {
+			n.queue.Enqueue(func() { n.send(widget.ID, email.Recipient, message) })
+		}
+	}
+
+	if n.next == nil {
+		return nil
+	}
+	return n.next.Notify(ctx, widget, submissions)
+}
+
+// send delivers a formatted email, logging failures
+func (n *EmailNotifier) send(widgetID, recipient string, message []byte) {
+	from := n.from
+	if address, err := mail.ParseAddress(n.from); err == nil {
+		from = address.Address
+	}
+
+	if err := n.transport.Send(from, []string{recipient}, message); err != nil {
+		metrics.Inc("notification_emails_failed_total", nil, "Total notification emails that failed to send")
+		logger.Error("failed to send notification email", map[string]interface{}{
+			"action":    "notify_email",
+			"widget_id": widgetID,
+			"error":     err.Error(),
+		})
+		return
+	}
+	metrics.Inc("notification_emails_sent_total", nil, "Total notification emails sent")
+}
+
+// FormatEmail formats submissions as a plain-text email: a subject naming the widget,
+// then per submission its data fields sorted by name and a time and ID line
+func FormatEmail(from, to string, widget *models.Widget, submissions []*models.Submission, now time.Time) ([]byte, error) {
+	subject := format.Title(widget, len(submissions))
+
+	var body strings.Builder
+	body.WriteString(subject + "\n")
+
+	listed, more := format.Submissions(submissions)
+	for _, submission := range listed {
+		body.WriteString("\n")
+		fields := format.Fields(submission)
+		if len(fields) == 0 {
+			body.WriteString("No data\n")
+		}
+		for _, field := range fields {
+			body.WriteString(field.Name + ": " + field.Value + "\n")
+		}
+		body.WriteString(format.Footer(submission) + "\n")
+	}
+	if more > 0 {
+		fmt.Fprintf(&body, "\nand %d more\n", more)
+	}
+
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", from},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, header := range headers {
+		message.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+	message.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&message)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body.String(), "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return message.Bytes(), nil
+}
+
+// SMTPTransport sends email through an SMTP server, upgrading to TLS when the server
+// supports STARTTLS
+type SMTPTransport struct {
+	host     string
+	addr     string
+	username string
+	password string
+}
+
+// NewSMTPTransport creates a new SMTP transport for the configured server
+func NewSMTPTransport(cfg config.SMTPConfig) *SMTPTransport {
+	return &SMTPTransport{
+		host:     cfg.Host,
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		username: cfg.Username,
+		password: cfg.Password,
+	}
+}
+
+// Send delivers the message within SendTimeout
+func (t *SMTPTransport) Send(from string, to []string, message []byte) error {
+	conn, err := net.DialTimeout("tcp", t.addr, SendTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(SendTimeout))
+
+	client, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: t.host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if t.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.username, t.password, t.host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("sender rejected: %w", err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("recipient rejected: %w", err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return client.Quit()
+}
@@ -0,0 +1,257 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/services"
+)
+
+// sentEmail is a message handed to the mock transport
+type sentEmail struct {
+	from    string
+	to      []string
+	message []byte
+}
+
+// mockTransport records sent messages and fails with err when set
+type mockTransport struct {
+	sent chan sentEmail
+	err  error
+}
+
+func newMockTransport() *mockTransport {
+	return &mockTransport{sent: make(chan sentEmail, 10)}
+}
+
+func (t *mockTransport) Send(from string, to []string, message []byte) error {
+	t.sent <- sentEmail{from: from, to: to, message: message}
+	return t.err
+}
+
+// countingNotifier counts the notifications passed on to it
+type countingNotifier struct {
+	calls int
+}
+
+func (n *countingNotifier) Notify(ctx context.Context, widget *models.Widget, submissions []*models.Submission) error {
+	n.calls++
+	return nil
+}
+
+// emailTestWidget returns a widget emailing notifications to owner@example.com
+func emailTestWidget() *models.Widget {
+	return &models.Widget{
+		ID:   "widget-1",
+		Name: "Pricing form",
+		Config: map[string]interface{}{
+			"notifications": map[string]interface{}{
+				"email": map[string]interface{}{"enabled": true, "recipient": "Owner <owner@example.com>"},
+			},
+		},
+	}
+}
+
+// emailTestSubmission returns the sample submission formatted in the email tests
+func emailTestSubmission() *models.Submission {
+	return &models.Submission{
+		ID:       "sub-1",
+		WidgetID: "widget-1",
+		Data: map[string]interface{}{
+			"name":    "Zoë Smith",
+			"email":   "zoe@example.com",
+			"seats":   float64(5),
+			"options": []interface{}{"sso", "audit"},
+			"comment": "",
+		},
+		CreatedAt: time.Date(2024, 3, 4, 9, 15, 0, 0, time.UTC),
+	}
+}
+
+// readEmail parses a sent message and returns its headers and decoded body
+func readEmail(t *testing.T, message []byte) (mail.Header, string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(string(message)))
+	if err != nil {
+		t.Fatalf("Failed to parse email: %v", err)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatalf("Failed to decode email body: %v", err)
+	}
+	return msg.Header, string(body)
+}
+
+func TestFormatEmail(t *testing.T) {
+	now := time.Date(2024, 3, 4, 9, 16, 0, 0, time.UTC)
+	message, err := FormatEmail("leads@example.com", "owner@example.com", emailTestWidget(), []*models.Submission{emailTestSubmission()}, now)
+	if err != nil {
+		t.Fatalf("FormatEmail failed: %v", err)
+	}
+
+	header, body := readEmail(t, message)
+	if header.Get("To") != "owner@example.com" || header.Get("From") != "leads@example.com" {
+		t.Errorf("Unexpected addresses: from %q, to %q", header.Get("From"), header.Get("To"))
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(header.Get("Subject")); subject != "New submission: Pricing form" {
+		t.Errorf("Unexpected subject %q", subject)
+	}
+
+	expected := "New submission: Pricing form\r\n" +
+		"\r\n" +
+		"comment: -\r\n" +
+		"email: zoe@example.com\r\n" +
+		"name: Zoë Smith\r\n" +
+		"options: [\"sso\",\"audit\"]\r\n" +
+		"seats: 5\r\n" +
+		"Submitted 2024-03-04 09:15 UTC · ID sub-1\r\n"
+	if body != expected {
+		t.Errorf("Expected body\n%s\ngot\n%s", expected, body)
+	}
+}
+
+func TestFormatEmail_Digest(t *testing.T) {
+	submissions := make([]*models.Submission, 12)
+	for i := range submissions {
+		submissions[i] = emailTestSubmission()
+	}
+	widget := emailTestWidget()
+	widget.Name = "Pricing\r\nBcc: victim@example.com"
+
+	message, err := FormatEmail("leads@example.com", "owner@example.com", widget, submissions, time.Now())
+	if err != nil {
+		t.Fatalf("FormatEmail failed: %v", err)
+	}
+
+	header, body := readEmail(t, message)
+	if header.Get("Bcc") != "" {
+		t.Error("Widget name must not inject headers")
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(header.Get("Subject")); subject != "12 new submissions: Pricing Bcc: victim@example.com" {
+		t.Errorf("Unexpected subject %q", subject)
+	}
+	if count := strings.Count(body, "ID sub-1"); count != 10 {
+		t.Errorf("Expected 10 listed submissions, got %d", count)
+	}
+	if !strings.HasSuffix(body, "and 2 more\r\n") {
+		t.Errorf("Expected the rest to be counted, got\n%s", body)
+	}
+}
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	transport := newMockTransport()
+	next := &countingNotifier{}
+	notifier := NewEmailNotifier(transport, "Leads <leads@example.com>", next)
+
+	if err := notifier.Notify(context.Background(), emailTestWidget(), []*models.Submission{emailTestSubmission()}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	select {
+	case sent := <-transport.sent:
+		if sent.from != "leads@example.com" || len(sent.to) != 1 || sent.to[0] != "owner@example.com" {
+			t.Errorf("Unexpected envelope: from %q, to %v", sent.from, sent.to)
+		}
+		_, body := readEmail(t, sent.message)
+		for _, value := range []string{"Zoë Smith", "zoe@example.com", "seats: 5", "sub-1"} {
+			if !strings.Contains(body, value) {
+				t.Errorf("Expected body to contain %q, got\n%s", value, body)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected an email to be sent")
+	}
+
+	if next.calls != 1 {
+		t.Errorf("Expected the notification to be passed on once, got %d", next.calls)
+	}
+}
+
+func TestEmailNotifier_Notify_FailureDoesNotFail(t *testing.T) {
+	transport := newMockTransport()
+	transport.err = errors.New("connection refused")
+	notifier := NewEmailNotifier(transport, "leads@example.com", nil)
+
+	if err := notifier.Notify(context.Background(), emailTestWidget(), []*models.Submission{emailTestSubmission()}); err != nil {
+		t.Fatalf("Expected send failures not to fail the notification, got %v", err)
+	}
+	select {
+	case <-transport.sent:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a send attempt")
+	}
+}
+
+func TestEmailNotifier_Notify_Disabled(t *testing.T) {
+	transport := newMockTransport()
+	next := &countingNotifier{}
+	notifier := NewEmailNotifier(transport, "leads@example.com", next)
+
+	widgets := []*models.Widget{
+		{ID: "no-config"},
+		{ID: "disabled", Config: map[string]interface{}{"notifications": map[string]interface{}{
+			"email": map[string]interface{}{"enabled": false, "recipient": "owner@example.com"},
+		}}},
+		{ID: "bad-address", Config: map[string]interface{}{"notifications": map[string]interface{}{
+			"email": map[string]interface{}{"enabled": true, "recipient": "not an address"},
+		}}},
+	}
+	for _, widget := range widgets {
+		if err := notifier.Notify(context.Background(), widget, []*models.Submission{emailTestSubmission()}); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+	}
+
+	select {
+	case sent := <-transport.sent:
+		t.Errorf("Expected no email, got one to %v", sent.to)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if next.calls != len(widgets) {
+		t.Errorf("Expected every notification to be passed on, got %d", next.calls)
+	}
+}
+
+// blockingTransport counts send attempts and holds them until released
+type blockingTransport struct {
+	attempts atomic.Int32
+	release  chan struct{}
+}
+
+func (t *blockingTransport) Send(from string, to []string, message []byte) error {
+	t.attempts.Add(1)
+	<-t.release
+	return nil
+}
+
+func TestEmailNotifier_Notify_BoundedSends(t *testing.T) {
+	transport := &blockingTransport{release: make(chan struct{})}
+	defer close(transport.release)
+	notifier := NewEmailNotifier(transport, "leads@example.com", nil)
+	notifier.queue = services.NewNotificationQueue("email", 2, 3)
+
+	// A burst against a stuck mail server neither blocks nor opens more sends than workers
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		if err := notifier.Notify(context.Background(), emailTestWidget(), []*models.Submission{emailTestSubmission()}); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected Notify to return without waiting, took %v", elapsed)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if attempts := transport.attempts.Load(); attempts != 2 {
+		t.Errorf("Expected 2 sends in flight, got %d", attempts)
+	}
+}
@@ -0,0 +1,103 @@
+// Package format formats submission notifications the same way for every channel:
+// titles naming the widget, data fields sorted by name and a time and ID line, within
+// the limits below.
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ad/leads-core/internal/models"
+)
+
+// Limits keeping notifications readable and within Slack's and Teams' message size limits
+const (
+	MaxSubmissions = 10  // Submissions listed per notification, the rest are counted
+	MaxFields      = 20  // Data fields listed per submission
+	MaxValueLength = 500 // Characters per field value
+	MaxTitleLength = 150 // Characters of the title
+)
+
+// Field is a data field of a submission formatted for a notification
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Title returns the notification title, naming the widget (its ID when unnamed).
+// Whitespace in the name is collapsed, so it can't break a line or an email header.
+func Title(widget *models.Widget, count int) string {
+	name := strings.Join(strings.Fields(widget.Name), " ")
+	if name == "" {
+		name = widget.ID
+	}
+	title := "New submission: " + name
+	if count > 1 {
+		title = fmt.Sprintf("%d new submissions: %s", count, name)
+	}
+	return TruncateRunes(title, MaxTitleLength)
+}
+
+// Submissions returns the submissions listed in a notification and the number left out
+func Submissions(submissions []*models.Submission) ([]*models.Submission, int) {
+	if len(submissions) > MaxSubmissions {
+		return submissions[:MaxSubmissions], len(submissions) - MaxSubmissions
+	}
+	return submissions, 0
+}
+
+// Fields returns the submission's data fields sorted by name, formatted and truncated
+func Fields(submission *models.Submission) []Field {
+	names := make([]string, 0, len(submission.Data))
+	for name := range submission.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > MaxFields {
+		names = names[:MaxFields]
+	}
+
+	fields := make([]Field, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, Field{Name: name, Value: TruncateRunes(Value(submission.Data[name]), MaxValueLength)})
+	}
+	return fields
+}
+
+// Value formats a submitted value, lists and objects as JSON
+func Value(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		if v == "" {
+			return "-"
+		}
+		return v
+	case []interface{}, map[string]interface{}:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Footer returns the submission's time and ID line
+func Footer(submission *models.Submission) string {
+	return fmt.Sprintf("Submitted %s · ID %s", submission.CreatedAt.UTC().Format("2006-01-02 15:04 MST"), submission.ID)
+}
+
+// TruncateRunes shortens s to at most max characters, ending in an ellipsis when cut
+func TruncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,63 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ad/leads-core/internal/models"
+)
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		widget   *models.Widget
+		count    int
+		expected string
+	}{
+		{&models.Widget{ID: "widget-1", Name: "Pricing form"}, 1, "New submission: Pricing form"},
+		{&models.Widget{ID: "widget-1", Name: "Pricing\r\nBcc: x@example.com"}, 3, "3 new submissions: Pricing Bcc: x@example.com"},
+		{&models.Widget{ID: "widget-1", Name: "  "}, 1, "New submission: widget-1"},
+	}
+	for _, tt := range tests {
+		if got := Title(tt.widget, tt.count); got != tt.expected {
+			t.Errorf("Expected title %q, got %q", tt.expected, got)
+		}
+	}
+
+	long := Title(&models.Widget{Name: strings.Repeat("é", 200)}, 1)
+	if n := len([]rune(long)); n != MaxTitleLength || !strings.HasSuffix(long, "…") {
+		t.Errorf("Expected a %d-character title ending in an ellipsis, got %d characters", MaxTitleLength, n)
+	}
+}
+
+func TestFields(t *testing.T) {
+	submission := &models.Submission{Data: map[string]interface{}{
+		"name":    strings.Repeat("a", MaxValueLength+10),
+		"seats":   float64(5),
+		"options": []interface{}{"sso", "audit"},
+		"comment": "",
+	}}
+
+	fields := Fields(submission)
+	expected := []Field{
+		{Name: "comment", Value: "-"},
+		{Name: "name", Value: strings.Repeat("a", MaxValueLength-1) + "…"},
+		{Name: "options", Value: `["sso","audit"]`},
+		{Name: "seats", Value: "5"},
+	}
+	if len(fields) != len(expected) {
+		t.Fatalf("Expected %d fields, got %v", len(expected), fields)
+	}
+	for i := range expected {
+		if fields[i] != expected[i] {
+			t.Errorf("Expected field %v, got %v", expected[i], fields[i])
+		}
+	}
+}
+
+func TestSubmissions(t *testing.T) {
+	submissions := make([]*models.Submission, MaxSubmissions+2)
+	listed, more := Submissions(submissions)
+	if len(listed) != MaxSubmissions || more != 2 {
+		t.Errorf("Expected %d listed and 2 more, got %d and %d", MaxSubmissions, len(listed), more)
+	}
+}
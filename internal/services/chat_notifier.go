@@ -0,0 +1,223 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/notify/format"
+	"github.com/ad/leads-core/pkg/logger"
+	"github.com/ad/leads-core/pkg/metrics"
+)
+
+// slackFieldsPerItem is the number of fields Slack accepts per section block
+const slackFieldsPerItem = 10
+
+// ChatPostTimeout bounds a background webhook post
+const ChatPostTimeout = 5 * time.Second
+
+// ChatNotifier posts notifications of widgets with a chat channel to the channel's
+// incoming webhook, formatted for Slack or Teams. Notifications of other widgets go to
+// the fallback notifier.
+type ChatNotifier struct {
+	fallback Notifier
+	client   *http.Client
+	queue    *NotificationQueue
+}
+
+// NewChatNotifier creates a new chat notifier
+func NewChatNotifier(fallback Notifier) *ChatNotifier {
+	if fallback == nil {
+		fallback = NoopNotifier{}
+	}
+	return &ChatNotifier{
+		fallback: fallback,
+		client:   &http.Client{Timeout: ChatPostTimeout},
+		queue:    NewNotificationQueue("chat", DefaultNotificationWorkers, DefaultNotificationQueueSize),
+	}
+}
+
+// Notify queues the formatted notification for posting to the widget's chat channel.
+// The post runs in the background with its own timeout, so a slow or dead webhook never
+// holds up the submission; failures and notifications dropped by a full queue are logged.
+func (n *ChatNotifier) Notify(ctx context.Context, widget *models.Widget, submissions []*models.Submission) error {
+	channel := widget.NotificationChannel()
+	if channel == nil {
+		return n.fallback.Notify(ctx, widget, submissions)
+	}
+
+	var message map[string]interface{}
+	switch channel.Type {
+	case models.NotificationChannelTeams:
+		message = FormatTeamsMessage(widget, submissions)
+	default:
+		message = FormatSlackMessage(widget, submissions)
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", channel.Type, err)
+	}
+
+	n.queue.Enqueue(func() { n.send(widget.ID, channel, body) })
+	return nil
+}
+
+// send posts a message to the channel's webhook, logging failures
+func (n *ChatNotifier) send(widgetID string, channel *models.NotificationChannel, body []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), ChatPostTimeout)
+	defer cancel()
+
+	if err := n.post(ctx, channel, body); err != nil {
+		metrics.Inc("notification_chat_posts_failed_total", nil, "Total chat notification posts that failed")
+		logger.Error("failed to post chat notification", map[string]interface{}{
+			"action":    "notify_chat",
+			"widget_id": widgetID,
+			"channel":   channel.Type,
+			"error":     err.Error(),
+		})
+	}
+}
+
+// post sends a message to the channel's incoming webhook
+func (n *ChatNotifier) post(ctx context.Context, channel *models.NotificationChannel, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post %s message: %w", channel.Type, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to post %s message: status %d: %s", channel.Type, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// FormatSlackMessage formats submissions as a Slack message: a header, then per
+// submission its data fields and a context line, separated by dividers
+func FormatSlackMessage(widget *models.Widget, submissions []*models.Submission) map[string]interface{} {
+	title := format.Title(widget, len(submissions))
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": title},
+		},
+	}
+
+	listed, more := format.Submissions(submissions)
+	for i, submission := range listed {
+		if i > 0 {
+			blocks = append(blocks, map[string]interface{}{"type": "divider"})
+		}
+
+		var fields []interface{}
+		for _, field := range format.Fields(submission) {
+			fields = append(fields, map[string]interface{}{
+				"type": "mrkdwn",
+				"text": "*" + escapeSlack(field.Name) + "*\n" + escapeSlack(field.Value),
+			})
+		}
+		if len(fields) == 0 {
+			blocks = append(blocks, map[string]interface{}{
+				"type": "section",
+				"text": map[string]interface{}{"type": "mrkdwn", "text": "_No data_"},
+			})
+		}
+		for start := 0; start < len(fields); start += slackFieldsPerItem {
+			blocks = append(blocks, map[string]interface{}{
+				"type":   "section",
+				"fields": fields[start:min(start+slackFieldsPerItem, len(fields))],
+			})
+		}
+
+		blocks = append(blocks, map[string]interface{}{
+			"type": "context",
+			"elements": []interface{}{
+				map[string]interface{}{"type": "mrkdwn", "text": escapeSlack(format.Footer(submission))},
+			},
+		})
+	}
+	if more > 0 {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "context",
+			"elements": []interface{}{
+				map[string]interface{}{"type": "mrkdwn", "text": fmt.Sprintf("_and %d more_", more)},
+			},
+		})
+	}
+
+	// text is shown in push notifications and clients without block support
+	return map[string]interface{}{"text": title, "blocks": blocks}
+}
+
+// FormatTeamsMessage formats submissions as a Teams message holding an Adaptive Card:
+// a title, then per submission a fact set of its data fields and a subtle footer line
+func FormatTeamsMessage(widget *models.Widget, submissions []*models.Submission) map[string]interface{} {
+	body := []interface{}{
+		map[string]interface{}{
+			"type":   "TextBlock",
+			"text":   format.Title(widget, len(submissions)),
+			"weight": "Bolder",
+			"size":   "Medium",
+			"wrap":   true,
+		},
+	}
+
+	listed, more := format.Submissions(submissions)
+	for _, submission := range listed {
+		facts := []interface{}{}
+		for _, field := range format.Fields(submission) {
+			facts = append(facts, map[string]interface{}{"title": field.Name, "value": field.Value})
+		}
+		body = append(body,
+			map[string]interface{}{"type": "FactSet", "facts": facts, "separator": true},
+			map[string]interface{}{
+				"type":     "TextBlock",
+				"text":     format.Footer(submission),
+				"isSubtle": true,
+				"size":     "Small",
+				"wrap":     true,
+				"spacing":  "None",
+			},
+		)
+	}
+	if more > 0 {
+		body = append(body, map[string]interface{}{
+			"type":     "TextBlock",
+			"text":     fmt.Sprintf("and %d more", more),
+			"isSubtle": true,
+			"wrap":     true,
+		})
+	}
+
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]interface{}{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body":    body,
+				},
+			},
+		},
+	}
+}
+
+// escapeSlack escapes the characters Slack treats as control sequences in message text
+func escapeSlack(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
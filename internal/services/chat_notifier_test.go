@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/notify/format"
+)
+
+// chatTestSubmission returns the sample submission formatted in the chat notifier tests
+func chatTestSubmission() *models.Submission {
+	return &models.Submission{
+		ID:       "sub-1",
+		WidgetID: "widget-1",
+		Data: map[string]interface{}{
+			"name":    "Ann <admin>",
+			"plan":    "pro",
+			"seats":   float64(5),
+			"options": []interface{}{"sso", "audit"},
+			"comment": "",
+		},
+		CreatedAt: time.Date(2024, 3, 4, 9, 15, 0, 0, time.UTC),
+	}
+}
+
+// assertJSONEqual compares a message with the expected JSON document
+func assertJSONEqual(t *testing.T, message map[string]interface{}, expected string) {
+	t.Helper()
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("Failed to encode message: %v", err)
+	}
+	var got, want interface{}
+	json.Unmarshal(encoded, &got)
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		t.Fatalf("Invalid expected JSON: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected message\n%s\ngot\n%s", expected, encoded)
+	}
+}
+
+func TestFormatSlackMessage(t *testing.T) {
+	widget := &models.Widget{ID: "widget-1", Name: "Pricing form"}
+
+	assertJSONEqual(t, FormatSlackMessage(widget, []*models.Submission{chatTestSubmission()}), `{
+		"text": "New submission: Pricing form",
+		"blocks": [
+			{"type": "header", "text": {"type": "plain_text", "text": "New submission: Pricing form"}},
+			{"type": "section", "fields": [
+				{"type": "mrkdwn", "text": "*comment*\n-"},
+				{"type": "mrkdwn", "text": "*name*\nAnn &lt;admin&gt;"},
+				{"type": "mrkdwn", "text": "*options*\n[\"sso\",\"audit\"]"},
+				{"type": "mrkdwn", "text": "*plan*\npro"},
+				{"type": "mrkdwn", "text": "*seats*\n5"}
+			]},
+			{"type": "context", "elements": [{"type": "mrkdwn", "text": "Submitted 2024-03-04 09:15 UTC · ID sub-1"}]}
+		]
+	}`)
+}
+
+func TestFormatSlackMessage_Digest(t *testing.T) {
+	widget := &models.Widget{ID: "widget-1"}
+	submissions := make([]*models.Submission, format.MaxSubmissions+2)
+	for i := range submissions {
+		submissions[i] = &models.Submission{ID: "sub", Data: map[string]interface{}{}}
+	}
+
+	message := FormatSlackMessage(widget, submissions)
+	if message["text"] != "12 new submissions: widget-1" {
+		t.Errorf("Expected digest title naming the widget ID, got %v", message["text"])
+	}
+
+	blocks := message["blocks"].([]interface{})
+	// Header, section and context per listed submission, dividers between them, and the overflow line
+	if len(blocks) != 1+format.MaxSubmissions*3 {
+		t.Fatalf("Expected %d blocks, got %d", 1+format.MaxSubmissions*3, len(blocks))
+	}
+	last := blocks[len(blocks)-1].(map[string]interface{})["elements"].([]interface{})[0].(map[string]interface{})
+	if last["text"] != "_and 2 more_" {
+		t.Errorf("Expected overflow line, got %v", last["text"])
+	}
+}
+
+func TestFormatTeamsMessage(t *testing.T) {
+	widget := &models.Widget{ID: "widget-1", Name: "Pricing form"}
+
+	assertJSONEqual(t, FormatTeamsMessage(widget, []*models.Submission{chatTestSubmission()}), `{
+		"type": "message",
+		"attachments": [{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": {
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type": "AdaptiveCard",
+				"version": "1.4",
+				"body": [
+					{"type": "TextBlock", "text": "New submission: Pricing form", "weight": "Bolder", "size": "Medium", "wrap": true},
+					{"type": "FactSet", "separator": true, "facts": [
+						{"title": "comment", "value": "-"},
+						{"title": "name", "value": "Ann <admin>"},
+						{"title": "options", "value": "[\"sso\",\"audit\"]"},
+						{"title": "plan", "value": "pro"},
+						{"title": "seats", "value": "5"}
+					]},
+					{"type": "TextBlock", "text": "Submitted 2024-03-04 09:15 UTC · ID sub-1", "isSubtle": true, "size": "Small", "wrap": true, "spacing": "None"}
+				]
+			}
+		}]
+	}`)
+}
+
+func TestChatNotifier_PostsToWebhook(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected JSON POST, got %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		var message map[string]interface{}
+		json.Unmarshal(body, &message)
+		received <- message
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	fallback := &recordingNotifier{}
+	notifier := NewChatNotifier(fallback)
+	notifier.client = server.Client()
+
+	widget := chatTestWidget(server.URL + "/services/T000/B000/XXX")
+	if err := notifier.Notify(context.Background(), widget, []*models.Submission{chatTestSubmission()}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	select {
+	case message := <-received:
+		if message["text"] != "New submission: Pricing form" {
+			t.Errorf("Expected Slack message to be posted, got %v", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Slack message to be posted")
+	}
+	if len(fallback.batches) != 0 {
+		t.Errorf("Expected fallback notifier to be skipped, got %v", fallback.batches)
+	}
+
+	// Widgets without a chat channel use the fallback notifier
+	if err := notifier.Notify(context.Background(), &models.Widget{ID: "widget-2"}, []*models.Submission{chatTestSubmission()}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(fallback.batches) != 1 {
+		t.Errorf("Expected fallback notification, got %v", fallback.batches)
+	}
+}
+
+func TestChatNotifier_SlowWebhookDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	notifier := NewChatNotifier(nil)
+	notifier.client = server.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	err := notifier.Notify(ctx, chatTestWidget(server.URL+"/hook"), []*models.Submission{chatTestSubmission()})
+	cancel() // The submit request ending must not cut the post short either
+	if err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected Notify to return without waiting for the webhook, took %v", elapsed)
+	}
+}
+
+// chatTestWidget returns a widget posting notifications to the given Slack webhook
+func chatTestWidget(webhookURL string) *models.Widget {
+	return &models.Widget{
+		ID:   "widget-1",
+		Name: "Pricing form",
+		Config: map[string]interface{}{
+			models.WidgetConfigNotificationsKey: map[string]interface{}{
+				"channel": map[string]interface{}{"type": "slack", "webhook_url": webhookURL},
+			},
+		},
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportDestination stores finished export job files outside of Redis. Without a
+// destination, export job results are kept in Redis for inline download.
+type ExportDestination interface {
+	// Upload stores the file under key and returns a URL it can be downloaded from until expiresAt
+	Upload(ctx context.Context, key, contentType string, data []byte) (downloadURL string, expiresAt time.Time, err error)
+}
+
+// S3DestinationConfig holds S3-compatible object storage settings
+type S3DestinationConfig struct {
+	Endpoint  string // e.g. https://s3.eu-central-1.amazonaws.com or https://minio.example.com
+	Region    string // Signing region, e.g. us-east-1
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Prefix    string        // Prepended to object keys, e.g. exports/
+	URLTTL    time.Duration // Lifetime of presigned download URLs (max 7 days)
+}
+
+// S3ExportDestination uploads export files to an S3-compatible bucket (path-style
+// addressing, AWS Signature Version 4) and returns presigned download URLs
+type S3ExportDestination struct {
+	config S3DestinationConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3ExportDestination creates a new S3-compatible export destination
+func NewS3ExportDestination(config S3DestinationConfig) *S3ExportDestination {
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.URLTTL <= 0 || config.URLTTL > 7*24*time.Hour {
+		config.URLTTL = time.Hour
+	}
+	return &S3ExportDestination{
+		config: config,
+		client: &http.Client{Timeout: 5 * time.Minute},
+		now:    time.Now,
+	}
+}
+
+// Upload puts the file into the bucket and presigns a GET URL for it
+func (d *S3ExportDestination) Upload(ctx context.Context, key, contentType string, data []byte) (string, time.Time, error) {
+	objectURL, err := url.Parse(d.config.Endpoint + awsURIEncode("/"+d.config.Bucket+"/"+d.config.Prefix+key, false))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid object storage endpoint: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL.String(), bytes.NewReader(data))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	d.signRequest(req, data)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to upload export: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", time.Time{}, fmt.Errorf("failed to upload export: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	now := d.now()
+	return d.presignGet(objectURL, now), now.Add(d.config.URLTTL), nil
+}
+
+// signRequest adds the SigV4 Authorization header to an upload request
+func (d *S3ExportDestination) signRequest(req *http.Request, payload []byte) {
+	now := d.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"content-type":         req.Header.Get("Content-Type"),
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsURIEncode(req.URL.Path, false),
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := d.scope(now)
+	signature := d.signature(now, scope, canonicalRequest)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		d.config.AccessKey, scope, signedHeaders, signature))
+}
+
+// presignGet builds a query-signed GET URL valid for the configured TTL
+func (d *S3ExportDestination) presignGet(objectURL *url.URL, now time.Time) string {
+	now = now.UTC()
+	scope := d.scope(now)
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    d.config.AccessKey + "/" + scope,
+		"X-Amz-Date":          now.Format("20060102T150405Z"),
+		"X-Amz-Expires":       strconv.Itoa(int(d.config.URLTTL.Seconds())),
+		"X-Amz-SignedHeaders": "host",
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, awsURIEncode(name, true)+"="+awsURIEncode(query[name], true))
+	}
+	canonicalQuery := strings.Join(pairs, "&")
+
+	path := awsURIEncode(objectURL.Path, false)
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		path,
+		canonicalQuery,
+		"host:" + objectURL.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	signature := d.signature(now, scope, canonicalRequest)
+	return objectURL.Scheme + "://" + objectURL.Host + path + "?" + canonicalQuery + "&X-Amz-Signature=" + signature
+}
+
+// scope returns the SigV4 credential scope for the given time
+func (d *S3ExportDestination) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + d.config.Region + "/s3/aws4_request"
+}
+
+// signature signs the canonical request with the derived SigV4 key
+func (d *S3ExportDestination) signature(now time.Time, scope, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format("20060102T150405Z"),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+d.config.SecretKey), now.Format("20060102"))
+	key = hmacSHA256(key, d.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// awsURIEncode percent-encodes everything but unreserved characters, as SigV4
+// requires; slashes are kept in paths
+func awsURIEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// DefaultExportFilenameTemplate reproduces the historical "<name>_submissions_<date>" filenames
+	DefaultExportFilenameTemplate = "{name}_{kind}_{date}"
+	// DefaultExportDateFormat is the Go time layout used for {date}
+	DefaultExportDateFormat = "2006-01-02"
+
+	// maxExportFilenameLength bounds the filename length (without extension) in runes
+	maxExportFilenameLength = 120
+)
+
+// ExportFilenameConfig configures export filenames. Templates may use the placeholders
+// {name} (widget name), {id} (widget ID), {kind} (submissions or submissions_filtered)
+// and {date} (export date in DateFormat); the extension is always appended by format.
+type ExportFilenameConfig struct {
+	Template   string            // Used for formats without their own template
+	PerFormat  map[string]string // Format (csv, json, xlsx, tsv, ndjson) -> template
+	DateFormat string
+}
+
+// render builds the sanitized filename for an export
+func (c ExportFilenameConfig) render(format, widgetID, widgetName, kind string, now time.Time) string {
+	template := c.PerFormat[format]
+	if template == "" {
+		template = c.Template
+	}
+	if template == "" {
+		template = DefaultExportFilenameTemplate
+	}
+	dateFormat := c.DateFormat
+	if dateFormat == "" {
+		dateFormat = DefaultExportDateFormat
+	}
+
+	base := strings.NewReplacer(
+		"{name}", widgetName,
+		"{id}", widgetID,
+		"{kind}", kind,
+		"{date}", now.Format(dateFormat),
+	).Replace(template)
+
+	return SanitizeFilename(base) + "." + format
+}
+
+// SanitizeFilename turns s into a filesystem-safe name. Unicode letters and digits are
+// kept (so Cyrillic names survive), runs of anything else except "-", "_" and "." become
+// a single "_". An empty result falls back to "export".
+func SanitizeFilename(s string) string {
+	var b strings.Builder
+	pendingSeparator := false
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+			if pendingSeparator && b.Len() > 0 {
+				b.WriteRune('_')
+			}
+			pendingSeparator = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingSeparator = true
+	}
+
+	// Leading dots would hide the file on Unix, trailing ones are dropped by Windows
+	name := strings.Trim(b.String(), "._-")
+	if runes := []rune(name); len(runes) > maxExportFilenameLength {
+		name = strings.Trim(string(runes[:maxExportFilenameLength]), "._-")
+	}
+	if name == "" {
+		return "export"
+	}
+	return name
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/storage"
+	"github.com/ad/leads-core/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// ExportJobService runs submission exports in the background. Jobs are queued in
+// memory and processed by workers; their records and results live in Redis, so a
+// job queued on an instance that restarts stays queued until it expires.
+type ExportJobService struct {
+	repo          storage.ExportJobRepository
+	widgetRepo    storage.WidgetRepository
+	exportService *ExportService
+	destination   ExportDestination
+	queue         chan *models.ExportJob
+	maxAttempts   int
+	retryBackoff  time.Duration
+}
+
+// NewExportJobService creates a new export job service holding up to queueSize pending jobs
+func NewExportJobService(
+	repo storage.ExportJobRepository,
+	widgetRepo storage.WidgetRepository,
+	exportService *ExportService,
+	queueSize int,
+) *ExportJobService {
+	return &ExportJobService{
+		repo:          repo,
+		widgetRepo:    widgetRepo,
+		exportService: exportService,
+		queue:         make(chan *models.ExportJob, queueSize),
+		maxAttempts:   1,
+	}
+}
+
+// SetRetryPolicy retries failed jobs until maxAttempts runs were made, waiting
+// backoff before the first retry and doubling it for each following one
+func (s *ExportJobService) SetRetryPolicy(maxAttempts int, backoff time.Duration) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	s.maxAttempts = maxAttempts
+	s.retryBackoff = backoff
+}
+
+// SetDestination uploads job results to the destination instead of keeping them in
+// Redis (nil restores inline download)
+func (s *ExportJobService) SetDestination(destination ExportDestination) {
+	s.destination = destination
+}
+
+// checkOwnership verifies that the widget exists and belongs to the user
+func (s *ExportJobService) checkOwnership(ctx context.Context, widgetID, userID string) error {
+	widget, err := s.widgetRepo.GetByID(ctx, widgetID)
+	if err != nil {
+		return errors.ErrNotFound
+	}
+	if widget.OwnerID != userID {
+		return errors.ErrAccessDenied
+	}
+	return nil
+}
+
+// CreateJob queues an export of the widget's submissions. ErrBusy is returned
+// when the queue is full.
+func (s *ExportJobService) CreateJob(ctx context.Context, widgetID, userID string, options models.ExportOptions) (*models.ExportJob, error) {
+	if err := s.checkOwnership(ctx, widgetID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.exportService.CheckRange(ctx, widgetID, options); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &models.ExportJob{
+		ID:        uuid.NewString(),
+		WidgetID:  widgetID,
+		OwnerID:   userID,
+		Format:    options.Format,
+		Status:    models.ExportJobQueued,
+		Options:   options,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.repo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	select {
+	case s.queue <- job:
+		return job, nil
+	default:
+		if err := s.repo.Fail(ctx, widgetID, job.ID, "Export queue is full"); err != nil {
+			logger.Error("failed to mark export job as failed", map[string]interface{}{
+				"widget_id": widgetID,
+				"job_id":    job.ID,
+				"error":     err.Error(),
+			})
+		}
+		return nil, errors.ErrBusy
+	}
+}
+
+// GetJob retrieves a job of an owned widget
+func (s *ExportJobService) GetJob(ctx context.Context, widgetID, userID, jobID string) (*models.ExportJob, error) {
+	if err := s.checkOwnership(ctx, widgetID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, widgetID, jobID)
+}
+
+// ListJobs retrieves jobs of an owned widget, newest first, optionally filtered by status
+func (s *ExportJobService) ListJobs(ctx context.Context, widgetID, userID, status string, opts models.PaginationOptions) ([]*models.ExportJob, int, error) {
+	if err := s.checkOwnership(ctx, widgetID, userID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.List(ctx, widgetID, status, opts)
+}
+
+// CancelJob cancels a queued job, or flags a running one so its result is discarded.
+// ErrJobFinished is returned for jobs that already reached a final status.
+func (s *ExportJobService) CancelJob(ctx context.Context, widgetID, userID, jobID string) (*models.ExportJob, error) {
+	if err := s.checkOwnership(ctx, widgetID, userID); err != nil {
+		return nil, err
+	}
+
+	previous, err := s.repo.Cancel(ctx, widgetID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if previous != models.ExportJobQueued && previous != models.ExportJobRunning && previous != models.ExportJobRetrying {
+		return nil, errors.ErrJobFinished
+	}
+
+	return s.repo.GetByID(ctx, widgetID, jobID)
+}
+
+// GetJobResult retrieves a completed job and its export file. Files uploaded to an
+// export destination aren't returned, the job carries their download URL instead.
+func (s *ExportJobService) GetJobResult(ctx context.Context, widgetID, userID, jobID string) (*models.ExportJob, []byte, error) {
+	job, err := s.GetJob(ctx, widgetID, userID, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != models.ExportJobCompleted || job.DownloadURL != "" {
+		return job, nil, nil
+	}
+
+	data, err := s.repo.GetResult(ctx, widgetID, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, data, nil
+}
+
+// Start processes queued jobs with the given number of workers until ctx is done
+func (s *ExportJobService) Start(ctx context.Context, workers int) {
+	logger.Info("Starting export job workers", map[string]interface{}{
+		"workers": workers,
+	})
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-s.queue:
+					s.process(ctx, job)
+				}
+			}
+		}()
+	}
+}
+
+// storeResult keeps the export file in Redis, or uploads it when a destination is set.
+// Both overwrite the result of an earlier attempt, so retried jobs leave one file.
+func (s *ExportJobService) storeResult(ctx context.Context, job *models.ExportJob, filename string, data []byte) (bool, error) {
+	if s.destination == nil {
+		return s.repo.Complete(ctx, job.WidgetID, job.ID, filename, data)
+	}
+
+	key := job.WidgetID + "/" + job.ID + "/" + filename
+	downloadURL, expiresAt, err := s.destination.Upload(ctx, key, models.ExportContentType(job.Format), data)
+	if err != nil {
+		return false, err
+	}
+	return s.repo.CompleteUploaded(ctx, job.WidgetID, job.ID, filename, len(data), downloadURL, expiresAt)
+}
+
+// process runs a single job unless it was cancelled while queued. Jobs stay queued
+// while all export slots are taken.
+func (s *ExportJobService) process(ctx context.Context, job *models.ExportJob) {
+	release, err := s.exportService.AcquireSlot(ctx)
+	if err != nil {
+		if busyErr, ok := err.(*models.ExportBusyError); ok {
+			s.requeue(ctx, job, busyErr.RetryAfter)
+		}
+		return
+	}
+	defer release()
+
+	attempt, err := s.repo.Claim(ctx, job.WidgetID, job.ID)
+	if err != nil {
+		logger.Error("failed to claim export job", map[string]interface{}{
+			"widget_id": job.WidgetID,
+			"job_id":    job.ID,
+			"error":     err.Error(),
+		})
+		return
+	}
+	if attempt == 0 {
+		return
+	}
+
+	data, filename, err := s.exportService.ExportSubmissions(ctx, job.WidgetID, job.OwnerID, job.Options)
+	if err != nil {
+		s.retryOrFail(ctx, job, attempt, "Failed to export submissions", err)
+		return
+	}
+
+	// Only jobs that succeed count towards the owner's daily quota; parallel jobs past
+	// the limit fail without retries
+	releaseQuota, err := s.exportService.ReserveDailyExport(ctx, job.OwnerID, job.Options.DailyQuota)
+	if err != nil {
+		s.retryOrFail(ctx, job, s.maxAttempts, "Daily export limit reached", err)
+		return
+	}
+
+	completed, err := s.storeResult(ctx, job, filename, data)
+	if err != nil {
+		releaseQuota()
+		s.retryOrFail(ctx, job, attempt, "Failed to store export", err)
+		return
+	}
+	if !completed {
+		// Cancelled while running, the result was discarded
+		releaseQuota()
+	}
+
+	logger.Info("Export job finished", map[string]interface{}{
+		"widget_id": job.WidgetID,
+		"job_id":    job.ID,
+		"completed": completed,
+		"size":      len(data),
+	})
+}
+
+// retryOrFail schedules another attempt of a failed job after the backoff, or marks
+// it failed once attempts are exhausted
+func (s *ExportJobService) retryOrFail(ctx context.Context, job *models.ExportJob, attempt int, message string, cause error) {
+	fields := map[string]interface{}{
+		"widget_id": job.WidgetID,
+		"job_id":    job.ID,
+		"attempt":   attempt,
+		"error":     cause.Error(),
+	}
+
+	if attempt < s.maxAttempts {
+		retrying, err := s.repo.Retry(ctx, job.WidgetID, job.ID, message)
+		if err == nil {
+			if retrying {
+				logger.Warn("Export job attempt failed, retrying", fields)
+				s.requeue(ctx, job, s.retryBackoff<<(attempt-1))
+			}
+			return
+		}
+		fields["retry_error"] = err.Error()
+	}
+
+	logger.Error("Export job failed", fields)
+	if err := s.repo.Fail(ctx, job.WidgetID, job.ID, message); err != nil {
+		logger.Error("failed to mark export job as failed", map[string]interface{}{
+			"widget_id": job.WidgetID,
+			"job_id":    job.ID,
+			"error":     err.Error(),
+		})
+	}
+}
+
+// requeue puts a retrying or waiting job back into the queue after delay, unless the
+// service stops first; the job then keeps its status until it expires
+func (s *ExportJobService) requeue(ctx context.Context, job *models.ExportJob, delay time.Duration) {
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		select {
+		case s.queue <- job:
+		default:
+			if err := s.repo.Fail(ctx, job.WidgetID, job.ID, "Export queue is full"); err != nil {
+				logger.Error("failed to mark export job as failed", map[string]interface{}{
+					"widget_id": job.WidgetID,
+					"job_id":    job.ID,
+					"error":     err.Error(),
+				})
+			}
+		}
+	}()
+}
@@ -7,7 +7,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ad/leads-core/internal/models"
@@ -20,8 +22,27 @@ import (
 type ExportService struct {
 	submissionRepo storage.SubmissionRepository
 	widgetRepo     storage.WidgetRepository
+	filenames      ExportFilenameConfig
+	quotaRepo      storage.ExportQuotaRepository
+	dailyLimits    map[string]int // Plan -> max exports per user and UTC day
+	rowLimits      map[string]int // Plan -> max submissions per export
+	maxRange       time.Duration  // Widest from/to span (0 = unlimited)
+	maxUnbounded   int            // Submissions above which 'from' is required (0 = unlimited)
+	now            func() time.Time
+
+	localSlots   chan struct{} // Running exports on this instance (nil = unlimited)
+	slotRepo     storage.ExportSlotRepository
+	clusterLimit int // Running exports across all instances (0 = unlimited)
+	slotLeaseTTL time.Duration
 }
 
+const (
+	// exportBusyRetryAfter is the delay suggested to clients when all export slots are taken
+	exportBusyRetryAfter = 5 * time.Second
+	// defaultExportSlotLeaseTTL is used when no positive lease TTL is configured
+	defaultExportSlotLeaseTTL = 10 * time.Minute
+)
+
 // NewExportService creates a new export service
 func NewExportService(
 	submissionRepo storage.SubmissionRepository,
@@ -30,11 +51,223 @@ func NewExportService(
 	return &ExportService{
 		submissionRepo: submissionRepo,
 		widgetRepo:     widgetRepo,
+		now:            time.Now,
+	}
+}
+
+// SetFilenameConfig sets the templates used to name export files
+func (s *ExportService) SetFilenameConfig(config ExportFilenameConfig) {
+	s.filenames = config
+}
+
+// SetDailyQuota limits the number of exports per user and UTC day by plan.
+// Plans without a positive limit are unlimited.
+func (s *ExportService) SetDailyQuota(limits map[string]int, repo storage.ExportQuotaRepository) {
+	s.dailyLimits = limits
+	s.quotaRepo = repo
+}
+
+// SetRowLimits caps the number of submissions per export by plan. Plans without a
+// positive limit are unlimited.
+func (s *ExportService) SetRowLimits(limits map[string]int) {
+	s.rowLimits = limits
+}
+
+// RowLimit returns the maximum number of submissions per export for the plan (0 = unlimited)
+func (s *ExportService) RowLimit(plan string) int {
+	if limit := s.rowLimits[plan]; limit > 0 {
+		return limit
+	}
+	return 0
+}
+
+// SetRangeLimit limits the export time span to maxRange and requires a 'from' on
+// widgets with more than maxUnbounded submissions (0 disables either limit)
+func (s *ExportService) SetRangeLimit(maxRange time.Duration, maxUnbounded int) {
+	s.maxRange = maxRange
+	s.maxUnbounded = maxUnbounded
+}
+
+// SetConcurrencyLimit caps the exports running at once on this instance and across all
+// instances (0 disables either limit). Cluster-wide slots are leased for leaseTTL, so
+// slots of a crashed instance free up once their lease expires.
+func (s *ExportService) SetConcurrencyLimit(instance, cluster int, leaseTTL time.Duration, repo storage.ExportSlotRepository) {
+	s.localSlots = nil
+	if instance > 0 {
+		s.localSlots = make(chan struct{}, instance)
+	}
+	if leaseTTL <= 0 {
+		leaseTTL = defaultExportSlotLeaseTTL
+	}
+	s.clusterLimit = cluster
+	s.slotLeaseTTL = leaseTTL
+	s.slotRepo = repo
+}
+
+// AcquireSlot takes an export slot, returning an ExportBusyError when the instance or
+// cluster limit is reached. The returned function releases the slot. Lease failures
+// don't block exports.
+func (s *ExportService) AcquireSlot(ctx context.Context) (func(), error) {
+	releaseLocal := func() {}
+	if s.localSlots != nil {
+		select {
+		case s.localSlots <- struct{}{}:
+			releaseLocal = func() { <-s.localSlots }
+		default:
+			return nil, &models.ExportBusyError{Limit: cap(s.localSlots), RetryAfter: exportBusyRetryAfter}
+		}
+	}
+
+	if s.slotRepo == nil || s.clusterLimit <= 0 {
+		return releaseLocal, nil
+	}
+	leaseID, ok, err := s.slotRepo.AcquireExportSlot(ctx, s.clusterLimit, s.now(), s.slotLeaseTTL)
+	if err != nil {
+		logger.Error("failed to acquire export slot", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return releaseLocal, nil
+	}
+	if !ok {
+		releaseLocal()
+		return nil, &models.ExportBusyError{Limit: s.clusterLimit, Cluster: true, RetryAfter: exportBusyRetryAfter}
+	}
+
+	return func() {
+		// Release even when the request was cancelled meanwhile
+		if err := s.slotRepo.ReleaseExportSlot(context.WithoutCancel(ctx), leaseID); err != nil {
+			logger.Error("failed to release export slot", map[string]interface{}{
+				"lease_id": leaseID,
+				"error":    err.Error(),
+			})
+		}
+		releaseLocal()
+	}, nil
+}
+
+// CheckRange returns an ExportRangeError when the export spans more than the maximum
+// range (an open end counts up to now) or has no 'from' on a widget with more than
+// maxUnbounded submissions. Counter lookup failures don't block exports.
+func (s *ExportService) CheckRange(ctx context.Context, widgetID string, options models.ExportOptions) error {
+	if options.From != nil {
+		to := s.now()
+		if options.To != nil {
+			to = *options.To
+		}
+		if s.maxRange > 0 && to.Sub(*options.From) > s.maxRange {
+			return &models.ExportRangeError{MaxRange: s.maxRange}
+		}
+		return nil
+	}
+
+	if s.maxUnbounded <= 0 {
+		return nil
+	}
+	_, total, err := s.submissionRepo.GetByWidgetID(ctx, widgetID, models.PaginationOptions{Page: 1, PerPage: 1})
+	if err != nil {
+		logger.Error("failed to count submissions for export range check", map[string]interface{}{
+			"widget_id": widgetID,
+			"error":     err.Error(),
+		})
+		return nil
+	}
+	if total > s.maxUnbounded {
+		return &models.ExportRangeError{MaxRange: s.maxRange, Unbounded: true}
+	}
+	return nil
+}
+
+// DailyQuota returns the plan's number of exports per UTC day (0 = unlimited)
+func (s *ExportService) DailyQuota(plan string) int {
+	if limit := s.dailyLimits[plan]; limit > 0 && s.quotaRepo != nil {
+		return limit
+	}
+	return 0
+}
+
+// CheckDailyQuota returns a QuotaExceededError when the user used up their plan's
+// daily exports, without counting an export. Counter lookup failures don't block exports.
+func (s *ExportService) CheckDailyQuota(ctx context.Context, userID, plan string) error {
+	limit := s.DailyQuota(plan)
+	if limit <= 0 {
+		return nil
+	}
+
+	now := s.now().UTC()
+	count, err := s.quotaRepo.GetDailyExports(ctx, userID, now)
+	if err != nil {
+		logger.Error("failed to get daily export count", map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return nil
 	}
+	if count >= limit {
+		return quotaExceeded(limit, now)
+	}
+	return nil
+}
+
+// ReserveDailyExport counts an export towards the user's daily quota of limit exports
+// (0 = unlimited), checking and counting in one atomic step so parallel exports can't
+// go over it. The returned release gives the export back when it doesn't succeed. A
+// QuotaExceededError is returned when the quota is used up; counter failures don't
+// block exports.
+func (s *ExportService) ReserveDailyExport(ctx context.Context, userID string, limit int) (func(), error) {
+	if limit <= 0 || s.quotaRepo == nil {
+		return func() {}, nil
+	}
+
+	now := s.now().UTC()
+	ok, err := s.quotaRepo.ReserveDailyExport(ctx, userID, now, limit)
+	if err != nil {
+		logger.Error("failed to count export", map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return func() {}, nil
+	}
+	if !ok {
+		return nil, quotaExceeded(limit, now)
+	}
+
+	return func() {
+		// Release even when the request was cancelled meanwhile
+		if err := s.quotaRepo.ReleaseDailyExport(context.WithoutCancel(ctx), userID, now); err != nil {
+			logger.Error("failed to give back export", map[string]interface{}{
+				"user_id": userID,
+				"error":   err.Error(),
+			})
+		}
+	}, nil
+}
+
+// quotaExceeded returns the error for a used up daily quota, retried at the next UTC day
+func quotaExceeded(limit int, now time.Time) error {
+	nextDay := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return &models.QuotaExceededError{Limit: limit, RetryAfter: nextDay.Sub(now)}
+}
+
+// ExportResult is an export file and what it holds
+type ExportResult struct {
+	Data      []byte
+	Filename  string
+	Rows      int  // Submissions exported
+	Truncated bool // Whether submissions beyond options.MaxRows were left out
 }
 
 // ExportSubmissions exports submissions for a widget in the specified format
 func (s *ExportService) ExportSubmissions(ctx context.Context, widgetID, userID string, options models.ExportOptions) ([]byte, string, error) {
+	result, err := s.Export(ctx, widgetID, userID, options)
+	if err != nil {
+		return nil, "", err
+	}
+	return result.Data, result.Filename, nil
+}
+
+// Export exports submissions for a widget in the specified format, keeping the newest
+// options.MaxRows submissions when set
+func (s *ExportService) Export(ctx context.Context, widgetID, userID string, options models.ExportOptions) (*ExportResult, error) {
 	// Verify widget ownership
 	widget, err := s.widgetRepo.GetByID(ctx, widgetID)
 	if err != nil {
@@ -44,7 +277,7 @@ func (s *ExportService) ExportSubmissions(ctx context.Context, widgetID, userID
 			"user_id":   userID,
 			"error":     err.Error(),
 		})
-		return nil, "", fmt.Errorf("widget not found")
+		return nil, fmt.Errorf("widget not found")
 	}
 
 	if widget.OwnerID != userID {
@@ -54,7 +287,11 @@ func (s *ExportService) ExportSubmissions(ctx context.Context, widgetID, userID
 			"user_id":   userID,
 			"owner_id":  widget.OwnerID,
 		})
-		return nil, "", fmt.Errorf("unauthorized")
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	if err := s.CheckRange(ctx, widgetID, options); err != nil {
+		return nil, err
 	}
 
 	// Get all submissions for the widget with time filter
@@ -66,24 +303,40 @@ func (s *ExportService) ExportSubmissions(ctx context.Context, widgetID, userID
 			"user_id":   userID,
 			"error":     err.Error(),
 		})
-		return nil, "", err
+		return nil, err
+	}
+
+	// Plans may cap the rows per export; submissions are ordered newest first
+	truncated := false
+	if options.MaxRows > 0 && len(submissions) > options.MaxRows {
+		submissions = submissions[:options.MaxRows]
+		truncated = true
 	}
 
 	var data []byte
-	var filename string
+
+	// Filtered exports hold only a subset, make that visible in the filename
+	kind := "submissions"
+	if !options.Filter.IsEmpty() {
+		kind = "submissions_filtered"
+	}
+
+	headers := newExportHeaders(widget, options)
+	flattener := newExportFlattener(options)
 
 	switch options.Format {
 	case "csv":
-		data, err = s.exportToCSV(submissions, widget)
-		filename = fmt.Sprintf("%s_submissions_%s.csv", widget.Name, time.Now().Format("2006-01-02"))
+		data, err = s.exportToCSV(submissions, widget, headers, flattener, options.IncludeMeta, ',')
+	case "tsv":
+		data, err = s.exportToCSV(submissions, widget, headers, flattener, options.IncludeMeta, '\t')
 	case "json":
-		data, err = s.exportToJSON(submissions, widget)
-		filename = fmt.Sprintf("%s_submissions_%s.json", widget.Name, time.Now().Format("2006-01-02"))
+		data, err = s.exportToJSON(submissions, widget, headers, options.IncludeMeta, options.Fields)
+	case "ndjson":
+		data, err = s.exportToNDJSON(submissions, headers, options.IncludeMeta, options.Fields)
 	case "xlsx":
-		data, err = s.exportToXLSX(submissions, widget)
-		filename = fmt.Sprintf("%s_submissions_%s.xlsx", widget.Name, time.Now().Format("2006-01-02"))
+		data, err = s.exportToXLSX(submissions, widget, headers, flattener, options.IncludeMeta)
 	default:
-		return nil, "", fmt.Errorf("unsupported format: %s", options.Format)
+		return nil, fmt.Errorf("unsupported format: %s", options.Format)
 	}
 
 	if err != nil {
@@ -94,22 +347,25 @@ func (s *ExportService) ExportSubmissions(ctx context.Context, widgetID, userID
 			"format":    options.Format,
 			"error":     err.Error(),
 		})
-		return nil, "", err
+		return nil, err
 	}
 
+	filename := s.filenames.render(options.Format, widget.ID, widget.Name, kind, time.Now())
+
 	logger.Info("Submissions exported successfully", map[string]interface{}{
 		"action":    "export_submissions",
 		"widget_id": widgetID,
 		"user_id":   userID,
 		"format":    options.Format,
 		"count":     len(submissions),
+		"truncated": truncated,
 		"filename":  filename,
 	})
 
-	return data, filename, nil
+	return &ExportResult{Data: data, Filename: filename, Rows: len(submissions), Truncated: truncated}, nil
 }
 
-// getFilteredSubmissions retrieves submissions with optional time filtering
+// getFilteredSubmissions retrieves submissions with optional time, region and field filtering
 func (s *ExportService) getFilteredSubmissions(ctx context.Context, widgetID string, options models.ExportOptions) ([]*models.Submission, error) {
 	// Get all submissions using pagination with large limit
 	allSubmissions, _, err := s.submissionRepo.GetByWidgetID(ctx, widgetID, models.PaginationOptions{
@@ -120,7 +376,7 @@ func (s *ExportService) getFilteredSubmissions(ctx context.Context, widgetID str
 		return nil, err
 	}
 
-	if options.From == nil && options.To == nil {
+	if options.From == nil && options.To == nil && options.Region == "" && options.Filter.IsEmpty() {
 		return allSubmissions, nil
 	}
 
@@ -136,6 +392,14 @@ func (s *ExportService) getFilteredSubmissions(ctx context.Context, widgetID str
 			include = false
 		}
 
+		if options.Region != "" && !strings.EqualFold(submission.Region, options.Region) {
+			include = false
+		}
+
+		if include && !options.Filter.Matches(submission) {
+			include = false
+		}
+
 		if include {
 			filtered = append(filtered, submission)
 		}
@@ -144,41 +408,100 @@ func (s *ExportService) getFilteredSubmissions(ctx context.Context, widgetID str
 	return filtered, nil
 }
 
-// exportToCSV exports submissions to CSV format
-func (s *ExportService) exportToCSV(submissions []*models.Submission, widget *models.Widget) ([]byte, error) {
+// metaColumnPrefix keeps meta columns apart from data fields of the same name
+const metaColumnPrefix = "meta."
+
+// exportHeaders renames export columns; unmapped columns keep their names
+type exportHeaders map[string]string
+
+// newExportHeaders merges the widget's export_headers with the request's mapping,
+// request entries taking precedence
+func newExportHeaders(widget *models.Widget, options models.ExportOptions) exportHeaders {
+	headers := exportHeaders(widget.ExportHeaders())
+	if len(options.Headers) > 0 && headers == nil {
+		headers = make(exportHeaders, len(options.Headers))
+	}
+	for column, header := range options.Headers {
+		headers[column] = header
+	}
+	return headers
+}
+
+// name returns the header of a column
+func (h exportHeaders) name(column string) string {
+	if header, ok := h[column]; ok {
+		return header
+	}
+	return column
+}
+
+// names returns the headers of the columns, in order
+func (h exportHeaders) names(columns []string) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = h.name(column)
+	}
+	return names
+}
+
+// exportToCSV exports submissions to CSV format, or TSV with a tab comma
+func (s *ExportService) exportToCSV(submissions []*models.Submission, widget *models.Widget, headers exportHeaders, flattener exportFlattener, includeMeta bool, comma rune) ([]byte, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
+	writer.Comma = comma
 
 	if len(submissions) == 0 {
 		// Write header only
-		header := []string{"ID", "Created At"}
+		header := headers.names(append([]string{"ID", "Created At"}, flattener.fields...))
 		writer.Write(header)
 		writer.Flush()
 		return buf.Bytes(), nil
 	}
 
-	// Collect all possible field names from all submissions
-	fieldNames := s.collectFieldNames(submissions)
+	// Collect all possible field columns from all submissions
+	fieldNames, rows := s.flattenSubmissions(submissions, flattener)
+	var metaNames []string
+	if includeMeta {
+		metaNames = s.collectMetaNames(submissions)
+	}
+
+	// Region column is only present when submissions carry region metadata
+	withRegion := s.hasRegions(submissions)
+	withVersion := s.hasWidgetVersions(submissions)
 
 	// Write header
 	header := []string{"ID", "Created At"}
+	if withRegion {
+		header = append(header, "Region")
+	}
+	if withVersion {
+		header = append(header, "Widget Version")
+	}
 	header = append(header, fieldNames...)
-	writer.Write(header)
+	for _, metaName := range metaNames {
+		header = append(header, metaColumnPrefix+metaName)
+	}
+	writer.Write(headers.names(header))
 
 	// Write data rows
-	for _, submission := range submissions {
+	for i, submission := range submissions {
 		row := []string{
 			submission.ID,
 			submission.CreatedAt.Format(time.RFC3339),
 		}
+		if withRegion {
+			row = append(row, submission.Region)
+		}
+		if withVersion {
+			row = append(row, strconv.Itoa(submission.WidgetVersion))
+		}
 
 		// Add field values in the same order as header
 		for _, fieldName := range fieldNames {
-			value := ""
-			if val, exists := submission.Data[fieldName]; exists {
-				value = s.formatValue(val)
-			}
-			row = append(row, value)
+			row = append(row, rows[i][fieldName])
+		}
+		for _, metaName := range metaNames {
+			row = append(row, submission.Meta[metaName])
 		}
 
 		writer.Write(row)
@@ -188,8 +511,11 @@ func (s *ExportService) exportToCSV(submissions []*models.Submission, widget *mo
 	return buf.Bytes(), writer.Error()
 }
 
-// exportToJSON exports submissions to JSON format
-func (s *ExportService) exportToJSON(submissions []*models.Submission, widget *models.Widget) ([]byte, error) {
+// exportToJSON exports submissions to JSON format; header mappings rename data field
+// keys, selected fields narrow and order them and meta is left out unless included
+func (s *ExportService) exportToJSON(submissions []*models.Submission, widget *models.Widget, headers exportHeaders, includeMeta bool, fields []string) ([]byte, error) {
+	exported := s.jsonSubmissions(submissions, headers, includeMeta, fields)
+
 	exportData := map[string]interface{}{
 		"widget": map[string]interface{}{
 			"id":   widget.ID,
@@ -197,15 +523,97 @@ func (s *ExportService) exportToJSON(submissions []*models.Submission, widget *m
 			"type": widget.Type,
 		},
 		"exported_at": time.Now().Format(time.RFC3339),
-		"total_count": len(submissions),
-		"submissions": submissions,
+		"total_count": len(exported),
+		"submissions": exported,
 	}
 
 	return json.MarshalIndent(exportData, "", "  ")
 }
 
+// exportToNDJSON exports submissions as newline-delimited JSON, one submission object
+// per line; header mappings, selected fields and meta are handled as in JSON exports
+func (s *ExportService) exportToNDJSON(submissions []*models.Submission, headers exportHeaders, includeMeta bool, fields []string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, submission := range s.jsonSubmissions(submissions, headers, includeMeta, fields) {
+		if err := encoder.Encode(submission); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonSubmissions returns the submissions as written to JSON exports: data field keys
+// renamed by the header mappings, only the selected fields in their order when fields
+// are given, and meta left out unless included
+func (s *ExportService) jsonSubmissions(submissions []*models.Submission, headers exportHeaders, includeMeta bool, fields []string) []interface{} {
+	exported := make([]interface{}, len(submissions))
+	for i, submission := range submissions {
+		if len(headers) == 0 && includeMeta && len(fields) == 0 {
+			exported[i] = submission
+			continue
+		}
+
+		copied := *submission
+		if len(headers) > 0 {
+			copied.Data = make(map[string]interface{}, len(submission.Data))
+			for field, value := range submission.Data {
+				copied.Data[headers.name(field)] = value
+			}
+		}
+		if !includeMeta {
+			copied.Meta = nil
+		}
+		if len(fields) == 0 {
+			exported[i] = &copied
+			continue
+		}
+
+		selected := exportDataFields{names: headers.names(fields), values: copied.Data}
+		exported[i] = &fieldSelectedSubmission{Submission: &copied, Data: selected}
+	}
+	return exported
+}
+
+// fieldSelectedSubmission is a submission whose data holds only the selected fields
+type fieldSelectedSubmission struct {
+	*models.Submission
+	Data exportDataFields `json:"data"`
+}
+
+// exportDataFields is a submission's data reduced to the named fields, written to JSON
+// in name order; fields the submission doesn't have are null
+type exportDataFields struct {
+	names  []string
+	values map[string]interface{}
+}
+
+// MarshalJSON writes the fields as a JSON object, keeping their order
+func (d exportDataFields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range d.names {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(d.values[name])
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
 // exportToXLSX exports submissions to Excel format
-func (s *ExportService) exportToXLSX(submissions []*models.Submission, widget *models.Widget) ([]byte, error) {
+func (s *ExportService) exportToXLSX(submissions []*models.Submission, widget *models.Widget, headers exportHeaders, flattener exportFlattener, includeMeta bool) ([]byte, error) {
 	f := excelize.NewFile()
 	sheetName := "Submissions"
 
@@ -214,8 +622,11 @@ func (s *ExportService) exportToXLSX(submissions []*models.Submission, widget *m
 
 	if len(submissions) == 0 {
 		// Write header only
-		f.SetCellValue(sheetName, "A1", "ID")
-		f.SetCellValue(sheetName, "B1", "Created At")
+		f.SetCellValue(sheetName, "A1", headers.name("ID"))
+		f.SetCellValue(sheetName, "B1", headers.name("Created At"))
+		for i, field := range flattener.fields {
+			f.SetCellValue(sheetName, s.numberToColumnName(i+3)+"1", headers.name(field))
+		}
 
 		var buf bytes.Buffer
 		if err := f.Write(&buf); err != nil {
@@ -224,16 +635,46 @@ func (s *ExportService) exportToXLSX(submissions []*models.Submission, widget *m
 		return buf.Bytes(), nil
 	}
 
-	// Collect all possible field names
-	fieldNames := s.collectFieldNames(submissions)
+	// Collect all possible field columns
+	fieldNames, rows := s.flattenSubmissions(submissions, flattener)
+	var metaNames []string
+	if includeMeta {
+		metaNames = s.collectMetaNames(submissions)
+	}
+
+	// Region column is only present when submissions carry region metadata
+	withRegion := s.hasRegions(submissions)
+	withVersion := s.hasWidgetVersions(submissions)
+	firstFieldCol := 3 // Start from column C
+	regionCol, versionCol := "", ""
+	if withRegion {
+		regionCol = s.numberToColumnName(firstFieldCol)
+		firstFieldCol++
+	}
+	if withVersion {
+		versionCol = s.numberToColumnName(firstFieldCol)
+		firstFieldCol++
+	}
+	firstMetaCol := firstFieldCol + len(fieldNames)
+	columnCount := len(metaNames) + firstMetaCol - 1
 
 	// Write header
-	f.SetCellValue(sheetName, "A1", "ID")
-	f.SetCellValue(sheetName, "B1", "Created At")
+	f.SetCellValue(sheetName, "A1", headers.name("ID"))
+	f.SetCellValue(sheetName, "B1", headers.name("Created At"))
+	if withRegion {
+		f.SetCellValue(sheetName, regionCol+"1", headers.name("Region"))
+	}
+	if withVersion {
+		f.SetCellValue(sheetName, versionCol+"1", headers.name("Widget Version"))
+	}
 
 	for i, fieldName := range fieldNames {
-		col := s.numberToColumnName(i + 3) // Start from column C
-		f.SetCellValue(sheetName, col+"1", fieldName)
+		col := s.numberToColumnName(i + firstFieldCol)
+		f.SetCellValue(sheetName, col+"1", headers.name(fieldName))
+	}
+	for i, metaName := range metaNames {
+		col := s.numberToColumnName(i + firstMetaCol)
+		f.SetCellValue(sheetName, col+"1", headers.name(metaColumnPrefix+metaName))
 	}
 
 	// Style header row
@@ -242,7 +683,7 @@ func (s *ExportService) exportToXLSX(submissions []*models.Submission, widget *m
 		Fill: excelize.Fill{Type: "pattern", Color: []string{"F2F2F2"}, Pattern: 1},
 	})
 
-	headerRange := fmt.Sprintf("A1:%s1", s.numberToColumnName(len(fieldNames)+2))
+	headerRange := fmt.Sprintf("A1:%s1", s.numberToColumnName(columnCount))
 	f.SetCellStyle(sheetName, "A1", headerRange, headerStyle)
 
 	// Write data rows
@@ -250,19 +691,25 @@ func (s *ExportService) exportToXLSX(submissions []*models.Submission, widget *m
 		rowNum := i + 2
 		f.SetCellValue(sheetName, fmt.Sprintf("A%d", rowNum), submission.ID)
 		f.SetCellValue(sheetName, fmt.Sprintf("B%d", rowNum), submission.CreatedAt.Format(time.RFC3339))
+		if withRegion {
+			f.SetCellValue(sheetName, fmt.Sprintf("%s%d", regionCol, rowNum), submission.Region)
+		}
+		if withVersion {
+			f.SetCellValue(sheetName, fmt.Sprintf("%s%d", versionCol, rowNum), submission.WidgetVersion)
+		}
 
 		for j, fieldName := range fieldNames {
-			col := s.numberToColumnName(j + 3)
-			value := ""
-			if val, exists := submission.Data[fieldName]; exists {
-				value = s.formatValue(val)
-			}
-			f.SetCellValue(sheetName, fmt.Sprintf("%s%d", col, rowNum), value)
+			col := s.numberToColumnName(j + firstFieldCol)
+			f.SetCellValue(sheetName, fmt.Sprintf("%s%d", col, rowNum), rows[i][fieldName])
+		}
+		for j, metaName := range metaNames {
+			col := s.numberToColumnName(j + firstMetaCol)
+			f.SetCellValue(sheetName, fmt.Sprintf("%s%d", col, rowNum), submission.Meta[metaName])
 		}
 	}
 
 	// Auto-fit columns
-	for i := 0; i < len(fieldNames)+2; i++ {
+	for i := 0; i < columnCount; i++ {
 		col := s.numberToColumnName(i + 1)
 		f.SetColWidth(sheetName, col, col, 15)
 	}
@@ -275,23 +722,180 @@ func (s *ExportService) exportToXLSX(submissions []*models.Submission, widget *m
 	return buf.Bytes(), nil
 }
 
-// collectFieldNames collects all unique field names from submissions
+// hasRegions checks if any submission has a region set
+func (s *ExportService) hasRegions(submissions []*models.Submission) bool {
+	for _, submission := range submissions {
+		if submission.Region != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWidgetVersions checks if any submission carries a widget version stamp
+func (s *ExportService) hasWidgetVersions(submissions []*models.Submission) bool {
+	for _, submission := range submissions {
+		if submission.WidgetVersion > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFieldNames collects all unique field names from submissions in a stable
+// order: by the first submission having them, and alphabetically among the new fields
+// of one submission, whose data map has no order of its own
 func (s *ExportService) collectFieldNames(submissions []*models.Submission) []string {
 	fieldSet := make(map[string]bool)
 	var fieldNames []string
 
 	for _, submission := range submissions {
+		first := len(fieldNames)
 		for fieldName := range submission.Data {
 			if !fieldSet[fieldName] {
 				fieldSet[fieldName] = true
 				fieldNames = append(fieldNames, fieldName)
 			}
 		}
+		sort.Strings(fieldNames[first:])
 	}
 
 	return fieldNames
 }
 
+// flattenSubmissions writes the submissions' data fields as columns, returning the
+// column names (grouped by field, in first-seen order) and each submission's values
+func (s *ExportService) flattenSubmissions(submissions []*models.Submission, flattener exportFlattener) ([]string, []map[string]string) {
+	rows := make([]map[string]string, len(submissions))
+	for i := range rows {
+		rows[i] = make(map[string]string)
+	}
+
+	var columns []string
+	for _, fieldName := range s.collectFieldNames(submissions) {
+		seen := make(map[string]bool)
+		for i, submission := range submissions {
+			value, exists := submission.Data[fieldName]
+			if !exists {
+				continue
+			}
+			s.flattenValue(flattener, fieldName, value, 0, func(column, formatted string) {
+				if !seen[column] {
+					seen[column] = true
+					columns = append(columns, column)
+				}
+				rows[i][column] = formatted
+			})
+		}
+	}
+	if len(flattener.fields) > 0 {
+		columns = selectColumns(columns, flattener.fields)
+	}
+	return columns, rows
+}
+
+// selectColumns returns the columns of the selected fields in the given order: a
+// field's columns when it was found (several when expanded), otherwise a column of the
+// field's name, which stays empty
+func selectColumns(columns, fields []string) []string {
+	selected := make([]string, 0, len(fields))
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		found := false
+		for _, column := range columns {
+			if column != field && !strings.HasPrefix(column, field+".") {
+				continue
+			}
+			found = true
+			if !seen[column] {
+				seen[column] = true
+				selected = append(selected, column)
+			}
+		}
+		if !found && !seen[field] {
+			seen[field] = true
+			selected = append(selected, field)
+		}
+	}
+	return selected
+}
+
+// exportArraySeparator joins array elements in ExportArrayJoin mode
+const exportArraySeparator = ";"
+
+// exportFlattener expands nested data fields into dotted columns. The depth limit
+// decides which levels are expanded, the array mode how arrays within it are written;
+// joined arrays are a single value, so they are joined at any level reached. Selected
+// fields, when set, pick and order the resulting columns.
+type exportFlattener struct {
+	arrayMode string
+	maxDepth  int
+	fields    []string
+}
+
+// newExportFlattener creates the flattener of the export options, bounding the depth
+func newExportFlattener(options models.ExportOptions) exportFlattener {
+	flattener := exportFlattener{arrayMode: options.ArrayMode, maxDepth: options.MaxDepth, fields: options.Fields}
+	if !models.IsValidExportArrayMode(flattener.arrayMode) {
+		flattener.arrayMode = models.ExportArrayJSON
+	}
+	flattener.maxDepth = max(0, min(flattener.maxDepth, models.MaxExportDepth))
+	return flattener
+}
+
+// flattenValue emits the columns of a value found at the given nesting depth; object
+// keys are expanded in sorted order so columns are stable
+func (s *ExportService) flattenValue(f exportFlattener, column string, value interface{}, depth int, emit func(column, value string)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if depth < f.maxDepth {
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				s.flattenValue(f, column+"."+key, v[key], depth+1, emit)
+			}
+			return
+		}
+	case []interface{}:
+		switch {
+		case f.arrayMode == models.ExportArrayJoin:
+			elements := make([]string, len(v))
+			for i, element := range v {
+				elements[i] = s.formatValue(element)
+			}
+			emit(column, strings.Join(elements, exportArraySeparator))
+			return
+		case f.arrayMode == models.ExportArrayIndex && depth < f.maxDepth:
+			for i, element := range v {
+				s.flattenValue(f, column+"."+strconv.Itoa(i), element, depth+1, emit)
+			}
+			return
+		}
+	}
+	emit(column, s.formatValue(value))
+}
+
+// collectMetaNames collects the unique meta param names of submissions, sorted
+func (s *ExportService) collectMetaNames(submissions []*models.Submission) []string {
+	metaSet := make(map[string]bool)
+	var metaNames []string
+
+	for _, submission := range submissions {
+		for metaName := range submission.Meta {
+			if !metaSet[metaName] {
+				metaSet[metaName] = true
+				metaNames = append(metaNames, metaName)
+			}
+		}
+	}
+
+	sort.Strings(metaNames)
+	return metaNames
+}
+
 // formatValue converts interface{} to string for export
 func (s *ExportService) formatValue(value interface{}) string {
 	if value == nil {
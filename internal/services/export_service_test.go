@@ -1,13 +1,23 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/xuri/excelize/v2"
 )
 
 // MockWidgetRepository is a mock implementation of WidgetRepository
@@ -39,11 +49,49 @@ func (m *MockWidgetRepository) GetByID(ctx context.Context, id string) (*models.
 	return widget, nil
 }
 
+func (m *MockWidgetRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Widget, error) {
+	widgets := make([]*models.Widget, 0, len(ids))
+	for _, id := range ids {
+		if widget, exists := m.widgets[id]; exists {
+			widgets = append(widgets, widget)
+		}
+	}
+	return widgets, nil
+}
+
 func (m *MockWidgetRepository) RebuildIndexes(ctx context.Context) error {
 	// Mock implementation - no-op for benchmarks
 	return nil
 }
 
+func (m *MockWidgetRepository) CleanupExpiredWidgets(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *MockWidgetRepository) GetIndexMembership(ctx context.Context, id string) (*models.WidgetIndexReport, error) {
+	return nil, nil
+}
+
+func (m *MockWidgetRepository) PushConfigHistory(ctx context.Context, widgetID string, version *models.WidgetConfigVersion, limit int) error {
+	return nil
+}
+
+func (m *MockWidgetRepository) GetConfigHistory(ctx context.Context, widgetID string) ([]*models.WidgetConfigVersion, error) {
+	return nil, nil
+}
+
+func (m *MockWidgetRepository) GetPIIWidgetIDs(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockWidgetRepository) GetVisibleWidgetIDs(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockWidgetRepository) GetWidgetIDByName(ctx context.Context, userID, name string) (string, error) {
+	return "", nil
+}
+
 func (m *MockWidgetRepository) GetTypeStats(ctx context.Context, userID string) ([]*models.TypeStats, error) {
 	// Simple mock implementation for benchmarks
 	typeCounts := make(map[string]int)
@@ -140,6 +188,39 @@ func (m *MockSubmissionRepository) GetByWidgetID(ctx context.Context, widgetID s
 	return submissions, len(submissions), nil
 }
 
+func (m *MockSubmissionRepository) GetByWidgetIDAfter(ctx context.Context, widgetID string, cursor *models.SubmissionCursor, limit int) ([]*models.Submission, int, *models.SubmissionCursor, error) {
+	return []*models.Submission{}, 0, nil, nil
+}
+
+func (m *MockSubmissionRepository) GetCreatedBetween(ctx context.Context, widgetID string, after, until int64, limit int) ([]*models.Submission, error) {
+	var submissions []*models.Submission
+	for _, submission := range m.submissions[widgetID] {
+		created := submission.CreatedAt.Unix()
+		if created > after && created <= until && len(submissions) < limit {
+			submissions = append(submissions, submission)
+		}
+	}
+	return submissions, nil
+}
+
+func (m *MockSubmissionRepository) GetByCorrelation(ctx context.Context, widgetID, value string) (*models.Submission, error) {
+	submissions := m.submissions[widgetID]
+	for i := len(submissions) - 1; i >= 0; i-- {
+		if submissions[i].CorrelationValue == value {
+			return submissions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("submission not found")
+}
+
+func (m *MockSubmissionRepository) ClaimDedup(ctx context.Context, widgetID, fingerprint, submissionID string, window time.Duration) (string, error) {
+	return "", nil
+}
+
+func (m *MockSubmissionRepository) ReleaseDedup(ctx context.Context, widgetID, fingerprint string) error {
+	return nil
+}
+
 func (m *MockSubmissionRepository) GetByID(ctx context.Context, widgetID, submissionID string) (*models.Submission, error) {
 	submissions, exists := m.submissions[widgetID]
 	if !exists {
@@ -161,6 +242,59 @@ func (m *MockSubmissionRepository) UpdateWidgetSubmissionsTTL(ctx context.Contex
 	return nil
 }
 
+func (m *MockSubmissionRepository) RedactPII(ctx context.Context, widgetID string, fields []string, cutoff time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *MockSubmissionRepository) EvictOldest(ctx context.Context, widgetID string, keep int) (int, error) {
+	submissions := m.submissions[widgetID]
+	if len(submissions) <= keep {
+		return 0, nil
+	}
+	evicted := len(submissions) - keep
+	m.submissions[widgetID] = submissions[evicted:]
+	return evicted, nil
+}
+
+func (m *MockSubmissionRepository) Delete(ctx context.Context, widgetID, submissionID string) error {
+	submissions := m.submissions[widgetID]
+	for i, submission := range submissions {
+		if submission.ID == submissionID {
+			m.submissions[widgetID] = append(submissions[:i:i], submissions[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("submission not found")
+}
+
+func (m *MockSubmissionRepository) SearchByWidgetID(ctx context.Context, widgetID string, filter models.SubmissionFilter, opts models.PaginationOptions) ([]*models.Submission, int, error) {
+	var matches []*models.Submission
+	for _, submission := range m.submissions[widgetID] {
+		if filter.Matches(submission) {
+			matches = append(matches, submission)
+		}
+	}
+	start := (opts.Page - 1) * opts.PerPage
+	if start >= len(matches) {
+		return []*models.Submission{}, len(matches), nil
+	}
+	return matches[start:min(start+opts.PerPage, len(matches))], len(matches), nil
+}
+
+func (m *MockSubmissionRepository) DeleteByRange(ctx context.Context, widgetID string, from, to time.Time) (int, error) {
+	var kept []*models.Submission
+	deleted := 0
+	for _, submission := range m.submissions[widgetID] {
+		if (!from.IsZero() && submission.CreatedAt.Before(from)) || (!to.IsZero() && submission.CreatedAt.After(to)) {
+			kept = append(kept, submission)
+		} else {
+			deleted++
+		}
+	}
+	m.submissions[widgetID] = kept
+	return deleted, nil
+}
+
 func TestExportService_ExportSubmissions(t *testing.T) {
 	ctx := context.Background()
 	widgetID := "test-widget-id"
@@ -255,8 +389,8 @@ func TestExportService_ExportSubmissions(t *testing.T) {
 		if !strings.Contains(csvData, "Jane Smith") {
 			t.Error("Expected data to contain 'Jane Smith'")
 		}
-		if !strings.Contains(csvData, "name,email") {
-			t.Error("Expected data to contain headers 'name,email'")
+		if !strings.Contains(csvData, "ID,Created At,email,name,message\n") {
+			t.Error("Expected data to contain headers 'ID,Created At,email,name,message'")
 		}
 	})
 
@@ -282,6 +416,62 @@ func TestExportService_ExportSubmissions(t *testing.T) {
 		}
 	})
 
+	t.Run("Export as TSV", func(t *testing.T) {
+		options := models.ExportOptions{
+			Format: "tsv",
+		}
+
+		data, filename, err := exportService.ExportSubmissions(ctx, widgetID, userID, options)
+
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+		if !strings.HasSuffix(filename, ".tsv") {
+			t.Errorf("Expected filename to end with '.tsv', got: %s", filename)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected header and 2 rows, got %d lines", len(lines))
+		}
+		if !strings.HasPrefix(lines[0], "ID\tCreated At\t") {
+			t.Errorf("Expected tab-separated header, got: %q", lines[0])
+		}
+		if !strings.Contains(lines[1], "\tJohn Doe") || !strings.Contains(lines[2], "\tJane Smith") {
+			t.Errorf("Expected tab-separated rows, got: %q", lines[1:])
+		}
+		if strings.Contains(string(data), ",") {
+			t.Error("Expected no comma separators in TSV data")
+		}
+	})
+
+	t.Run("Export as NDJSON", func(t *testing.T) {
+		options := models.ExportOptions{
+			Format: "ndjson",
+		}
+
+		data, filename, err := exportService.ExportSubmissions(ctx, widgetID, userID, options)
+
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+		if !strings.HasSuffix(filename, ".ndjson") {
+			t.Errorf("Expected filename to end with '.ndjson', got: %s", filename)
+		}
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 lines, got %d: %q", len(lines), data)
+		}
+		for i, expected := range []string{"John Doe", "Jane Smith"} {
+			var submission models.Submission
+			if err := json.Unmarshal([]byte(lines[i]), &submission); err != nil {
+				t.Fatalf("Expected line %d to be a JSON object, got %q: %v", i+1, lines[i], err)
+			}
+			if submission.Data["name"] != expected {
+				t.Errorf("Expected line %d to hold %q, got %v", i+1, expected, submission.Data["name"])
+			}
+		}
+	})
+
 	t.Run("Unauthorized access", func(t *testing.T) {
 		wrongUserID := "wrong-user-id"
 		options := models.ExportOptions{
@@ -314,6 +504,470 @@ func TestExportService_ExportSubmissions(t *testing.T) {
 	})
 }
 
+func TestExportService_ExportSubmissionsByRegion(t *testing.T) {
+	ctx := context.Background()
+	widgetID := "test-widget-id"
+	userID := "test-user-id"
+
+	mockWidgetRepo := NewMockWidgetRepository()
+	mockSubmissionRepo := NewMockSubmissionRepository()
+	exportService := NewExportService(mockSubmissionRepo, mockWidgetRepo)
+
+	mockWidgetRepo.widgets[widgetID] = &models.Widget{ID: widgetID, OwnerID: userID, Name: "Test Widget", Type: "lead-form"}
+	mockSubmissionRepo.submissions[widgetID] = []*models.Submission{
+		{ID: "sub-eu", WidgetID: widgetID, Region: "eu", Data: map[string]interface{}{"name": "Anna"}, CreatedAt: time.Now()},
+		{ID: "sub-us", WidgetID: widgetID, Region: "us", Data: map[string]interface{}{"name": "Bob"}, CreatedAt: time.Now()},
+	}
+
+	data, _, err := exportService.ExportSubmissions(ctx, widgetID, userID, models.ExportOptions{Format: "csv", Region: "EU"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	dataStr := string(data)
+	if !strings.HasPrefix(dataStr, "ID,Created At,Region,name") {
+		t.Errorf("Expected Region column in CSV header, got: %s", dataStr)
+	}
+	if !strings.Contains(dataStr, "sub-eu") || strings.Contains(dataStr, "sub-us") {
+		t.Errorf("Expected only eu submissions, got: %s", dataStr)
+	}
+}
+
+func TestExportService_ExportSubmissionsWithFieldFilter(t *testing.T) {
+	ctx := context.Background()
+	widgetID := "test-widget-id"
+	userID := "test-user-id"
+
+	mockWidgetRepo := NewMockWidgetRepository()
+	mockSubmissionRepo := NewMockSubmissionRepository()
+	exportService := NewExportService(mockSubmissionRepo, mockWidgetRepo)
+
+	mockWidgetRepo.widgets[widgetID] = &models.Widget{ID: widgetID, OwnerID: userID, Name: "Test Widget", Type: "lead-form"}
+	mockSubmissionRepo.submissions[widgetID] = []*models.Submission{
+		{ID: "sub-us-1", WidgetID: widgetID, Data: map[string]interface{}{"country": "US", "message": "Need a quote"}, CreatedAt: time.Now()},
+		{ID: "sub-us-2", WidgetID: widgetID, Data: map[string]interface{}{"country": "us", "message": "Just browsing"}, CreatedAt: time.Now()},
+		{ID: "sub-de", WidgetID: widgetID, Data: map[string]interface{}{"country": "DE", "message": "Need a quote"}, CreatedAt: time.Now()},
+		{ID: "sub-none", WidgetID: widgetID, Data: map[string]interface{}{"message": "Need a quote"}, CreatedAt: time.Now()},
+	}
+
+	tests := []struct {
+		name     string
+		filter   models.SubmissionFilter
+		included []string
+		excluded []string
+	}{
+		{name: "field", filter: models.SubmissionFilter{Fields: map[string]string{"country": "US"}}, included: []string{"sub-us-1", "sub-us-2"}, excluded: []string{"sub-de", "sub-none"}},
+		{name: "search", filter: models.SubmissionFilter{Search: "QUOTE"}, included: []string{"sub-us-1", "sub-de", "sub-none"}, excluded: []string{"sub-us-2"}},
+		{name: "field and search", filter: models.SubmissionFilter{Fields: map[string]string{"country": "us"}, Search: "quote"}, included: []string{"sub-us-1"}, excluded: []string{"sub-us-2", "sub-de", "sub-none"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, filename, err := exportService.ExportSubmissions(ctx, widgetID, userID, models.ExportOptions{Format: "csv", Filter: tt.filter})
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if !strings.Contains(filename, "_submissions_filtered_") || !strings.HasSuffix(filename, ".csv") {
+				t.Errorf("Expected filtered CSV filename, got: %s", filename)
+			}
+
+			dataStr := string(data)
+			for _, id := range tt.included {
+				if !strings.Contains(dataStr, id) {
+					t.Errorf("Expected %s in export, got: %s", id, dataStr)
+				}
+			}
+			for _, id := range tt.excluded {
+				if strings.Contains(dataStr, id) {
+					t.Errorf("Expected %s not to be exported, got: %s", id, dataStr)
+				}
+			}
+		})
+	}
+
+	// Unfiltered exports keep the regular filename
+	_, filename, err := exportService.ExportSubmissions(ctx, widgetID, userID, models.ExportOptions{Format: "csv"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if strings.Contains(filename, "filtered") {
+		t.Errorf("Expected regular filename for unfiltered export, got: %s", filename)
+	}
+}
+
+func TestExportService_ExportFlattening(t *testing.T) {
+	ctx := context.Background()
+	widgetID := "test-widget-id"
+	userID := "test-user-id"
+
+	mockWidgetRepo := NewMockWidgetRepository()
+	mockSubmissionRepo := NewMockSubmissionRepository()
+	exportService := NewExportService(mockSubmissionRepo, mockWidgetRepo)
+
+	mockWidgetRepo.widgets[widgetID] = &models.Widget{ID: widgetID, OwnerID: userID, Name: "Test Widget", Type: "lead-form"}
+	mockSubmissionRepo.submissions[widgetID] = []*models.Submission{
+		{ID: "sub1", WidgetID: widgetID, CreatedAt: time.Now(), Data: map[string]interface{}{
+			"tags": []interface{}{"a", "b"},
+			"address": map[string]interface{}{
+				"city": "Berlin",
+				"geo":  map[string]interface{}{"lat": 52.5, "lng": 13.4},
+			},
+		}},
+	}
+
+	exportColumns := func(t *testing.T, options models.ExportOptions) map[string]string {
+		t.Helper()
+		options.Format = "csv"
+		data, _, err := exportService.ExportSubmissions(ctx, widgetID, userID, options)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil || len(records) != 2 {
+			t.Fatalf("Expected header and one row, got %v (%v)", records, err)
+		}
+		row := make(map[string]string)
+		for i, header := range records[0][2:] {
+			row[header] = records[1][i+2]
+		}
+		return row
+	}
+
+	t.Run("default keeps JSON", func(t *testing.T) {
+		row := exportColumns(t, models.ExportOptions{})
+		if len(row) != 2 {
+			t.Fatalf("Expected one column per field, got %v", row)
+		}
+		var tags []interface{}
+		if err := json.Unmarshal([]byte(row["tags"]), &tags); err != nil || !reflect.DeepEqual(tags, []interface{}{"a", "b"}) {
+			t.Errorf("Expected tags to round-trip as JSON, got %q", row["tags"])
+		}
+		var address map[string]interface{}
+		if err := json.Unmarshal([]byte(row["address"]), &address); err != nil || address["city"] != "Berlin" {
+			t.Errorf("Expected address to round-trip as JSON, got %q", row["address"])
+		}
+	})
+
+	t.Run("index", func(t *testing.T) {
+		row := exportColumns(t, models.ExportOptions{ArrayMode: models.ExportArrayIndex, MaxDepth: 1})
+		if row["tags.0"] != "a" || row["tags.1"] != "b" {
+			t.Errorf("Expected indexed tag columns, got %v", row)
+		}
+		if row["address.city"] != "Berlin" {
+			t.Errorf("Expected address.city column, got %v", row)
+		}
+	})
+
+	t.Run("join", func(t *testing.T) {
+		row := exportColumns(t, models.ExportOptions{ArrayMode: models.ExportArrayJoin})
+		if got := strings.Split(row["tags"], ";"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+			t.Errorf("Expected joined tags to split back into the array, got %q", row["tags"])
+		}
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		row := exportColumns(t, models.ExportOptions{MaxDepth: 1})
+		if row["address.city"] != "Berlin" {
+			t.Errorf("Expected address.city column, got %v", row)
+		}
+		if _, ok := row["address.geo.lat"]; ok {
+			t.Errorf("Expected nesting below the depth limit to stay in one column, got %v", row)
+		}
+		var geo map[string]interface{}
+		if err := json.Unmarshal([]byte(row["address.geo"]), &geo); err != nil || geo["lat"] != 52.5 || geo["lng"] != 13.4 {
+			t.Errorf("Expected truncated address.geo to round-trip as JSON, got %q", row["address.geo"])
+		}
+
+		row = exportColumns(t, models.ExportOptions{MaxDepth: 2})
+		if row["address.geo.lat"] != "52.5" || row["address.geo.lng"] != "13.4" {
+			t.Errorf("Expected fully expanded geo columns, got %v", row)
+		}
+	})
+}
+
+func TestExportService_ExportHeaderMapping(t *testing.T) {
+	ctx := context.Background()
+	widgetID := "test-widget-id"
+	userID := "test-user-id"
+
+	mockWidgetRepo := NewMockWidgetRepository()
+	mockSubmissionRepo := NewMockSubmissionRepository()
+	exportService := NewExportService(mockSubmissionRepo, mockWidgetRepo)
+
+	mockWidgetRepo.widgets[widgetID] = &models.Widget{
+		ID: widgetID, OwnerID: userID, Name: "Test Widget", Type: "lead-form",
+		Config: map[string]interface{}{
+			models.WidgetConfigExportHeadersKey: map[string]interface{}{"email": "EmailAddress", "name": "FullName", "ID": "LeadID"},
+		},
+	}
+	mockSubmissionRepo.submissions[widgetID] = []*models.Submission{
+		{ID: "sub1", WidgetID: widgetID, Data: map[string]interface{}{"name": "John Doe", "email": "john@example.com", "phone": "+100"}, CreatedAt: time.Now()},
+	}
+
+	// The request mapping overrides the widget's for name
+	options := models.ExportOptions{Headers: map[string]string{"name": "ContactName"}}
+	expected := map[string]string{"LeadID": "sub1", "EmailAddress": "john@example.com", "ContactName": "John Doe", "phone": "+100"}
+
+	t.Run("CSV", func(t *testing.T) {
+		options.Format = "csv"
+		data, _, err := exportService.ExportSubmissions(ctx, widgetID, userID, options)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil || len(records) != 2 {
+			t.Fatalf("Expected header and one row, got %v (%v)", records, err)
+		}
+		row := make(map[string]string)
+		for i, header := range records[0] {
+			row[header] = records[1][i]
+		}
+		for header, value := range expected {
+			if row[header] != value {
+				t.Errorf("Expected column %s=%q, got %q (header %v)", header, value, row[header], records[0])
+			}
+		}
+		for _, original := range []string{"ID", "email", "name", "FullName"} {
+			if _, ok := row[original]; ok {
+				t.Errorf("Expected column %s to be renamed, got header %v", original, records[0])
+			}
+		}
+	})
+
+	t.Run("XLSX", func(t *testing.T) {
+		options.Format = "xlsx"
+		data, _, err := exportService.ExportSubmissions(ctx, widgetID, userID, options)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Failed to open XLSX: %v", err)
+		}
+		rows, err := f.GetRows("Submissions")
+		if err != nil || len(rows) != 2 {
+			t.Fatalf("Expected header and one row, got %v (%v)", rows, err)
+		}
+		row := make(map[string]string)
+		for i, header := range rows[0] {
+			row[header] = rows[1][i]
+		}
+		for header, value := range expected {
+			if row[header] != value {
+				t.Errorf("Expected column %s=%q, got %q (header %v)", header, value, row[header], rows[0])
+			}
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		options.Format = "json"
+		data, _, err := exportService.ExportSubmissions(ctx, widgetID, userID, options)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		var export struct {
+			Submissions []models.Submission `json:"submissions"`
+		}
+		if err := json.Unmarshal(data, &export); err != nil || len(export.Submissions) != 1 {
+			t.Fatalf("Expected one exported submission, got %s (%v)", data, err)
+		}
+		got := export.Submissions[0]
+		if got.ID != "sub1" || got.Data["EmailAddress"] != "john@example.com" || got.Data["ContactName"] != "John Doe" || got.Data["phone"] != "+100" {
+			t.Errorf("Expected renamed data keys, got %+v", got)
+		}
+	})
+
+	// Stored data keeps its field names
+	stored := mockSubmissionRepo.submissions[widgetID][0].Data
+	if stored["email"] != "john@example.com" || stored["EmailAddress"] != nil {
+		t.Errorf("Expected stored data to be untouched, got %v", stored)
+	}
+}
+
+func TestExportService_ExportFieldSelection(t *testing.T) {
+	ctx := context.Background()
+	widgetID := "test-widget-id"
+	userID := "test-user-id"
+
+	mockWidgetRepo := NewMockWidgetRepository()
+	mockSubmissionRepo := NewMockSubmissionRepository()
+	exportService := NewExportService(mockSubmissionRepo, mockWidgetRepo)
+
+	mockWidgetRepo.widgets[widgetID] = &models.Widget{ID: widgetID, OwnerID: userID, Name: "Test Widget", Type: "lead-form"}
+	mockSubmissionRepo.submissions[widgetID] = []*models.Submission{
+		{ID: "sub1", WidgetID: widgetID, Data: map[string]interface{}{
+			"name": "John Doe", "email": "john@example.com", "message": "Hi",
+			"address": map[string]interface{}{"city": "Berlin", "zip": "10115"},
+		}, CreatedAt: time.Now()},
+	}
+
+	// phone is unknown, message is left out, address expands into its columns
+	options := models.ExportOptions{Fields: []string{"phone", "email", "name", "address"}, MaxDepth: 1}
+	expectedHeader := []string{"ID", "Created At", "phone", "email", "name", "address.city", "address.zip"}
+	expectedRow := []string{"sub1", "", "", "john@example.com", "John Doe", "Berlin", "10115"}
+
+	checkRows := func(t *testing.T, rows [][]string) {
+		t.Helper()
+		if len(rows) != 2 {
+			t.Fatalf("Expected header and one row, got %v", rows)
+		}
+		if !reflect.DeepEqual(rows[0], expectedHeader) {
+			t.Errorf("Expected header %v, got %v", expectedHeader, rows[0])
+		}
+		row := append([]string(nil), rows[1]...)
+		for len(row) < len(expectedRow) {
+			row = append(row, "") // Trailing empty cells are omitted by XLSX readers
+		}
+		row[1] = ""
+		if !reflect.DeepEqual(row, expectedRow) {
+			t.Errorf("Expected row %v (Created At ignored), got %v", expectedRow, rows[1])
+		}
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		options.Format = "csv"
+		data, _, err := exportService.ExportSubmissions(ctx, widgetID, userID, options)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to read CSV: %v", err)
+		}
+		checkRows(t, records)
+	})
+
+	t.Run("XLSX", func(t *testing.T) {
+		options.Format = "xlsx"
+		data, _, err := exportService.ExportSubmissions(ctx, widgetID, userID, options)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Failed to open XLSX: %v", err)
+		}
+		rows, err := f.GetRows("Submissions")
+		if err != nil {
+			t.Fatalf("Failed to read XLSX rows: %v", err)
+		}
+		checkRows(t, rows)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		options.Format = "json"
+		data, _, err := exportService.ExportSubmissions(ctx, widgetID, userID, options)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expected := `"data": {
+        "phone": null,
+        "email": "john@example.com",
+        "name": "John Doe",
+        "address": {
+          "city": "Berlin",
+          "zip": "10115"
+        }
+      }`
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected selected fields in order, got %s", data)
+		}
+
+		var export struct {
+			Submissions []models.Submission `json:"submissions"`
+		}
+		if err := json.Unmarshal(data, &export); err != nil || len(export.Submissions) != 1 {
+			t.Fatalf("Expected one exported submission, got %s (%v)", data, err)
+		}
+		if got := export.Submissions[0]; got.ID != "sub1" || got.WidgetID != widgetID {
+			t.Errorf("Expected submission attributes to be kept, got %+v", got)
+		}
+	})
+
+	t.Run("no submissions", func(t *testing.T) {
+		emptyWidgetID := "empty-widget-id"
+		mockWidgetRepo.widgets[emptyWidgetID] = &models.Widget{ID: emptyWidgetID, OwnerID: userID, Name: "Empty", Type: "lead-form"}
+
+		data, _, err := exportService.ExportSubmissions(ctx, emptyWidgetID, userID, models.ExportOptions{Format: "csv", Fields: []string{"email", "name"}})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got := strings.TrimSpace(string(data)); got != "ID,Created At,email,name" {
+			t.Errorf("Expected header with the selected fields, got %q", got)
+		}
+	})
+}
+
+func TestExportService_ExportFilename(t *testing.T) {
+	ctx := context.Background()
+	widgetID := "test-widget-id"
+	userID := "test-user-id"
+	date := time.Now().Format("2006-01-02")
+
+	mockWidgetRepo := NewMockWidgetRepository()
+	mockSubmissionRepo := NewMockSubmissionRepository()
+	mockWidgetRepo.widgets[widgetID] = &models.Widget{ID: widgetID, OwnerID: userID, Name: "Отдел/продаж: лиды", Type: "lead-form"}
+	mockSubmissionRepo.submissions[widgetID] = []*models.Submission{
+		{ID: "sub1", WidgetID: widgetID, Data: map[string]interface{}{"name": "John"}, CreatedAt: time.Now()},
+	}
+
+	tests := []struct {
+		name     string
+		config   ExportFilenameConfig
+		format   string
+		expected string
+	}{
+		{name: "default template", format: "csv", expected: "Отдел_продаж_лиды_submissions_" + date + ".csv"},
+		{name: "per-format template", config: ExportFilenameConfig{
+			Template:  "{name}_{date}",
+			PerFormat: map[string]string{"xlsx": "{id}-{kind}"},
+		}, format: "xlsx", expected: "test-widget-id-submissions.xlsx"},
+		{name: "fallback to common template", config: ExportFilenameConfig{
+			Template:  "{name}_{date}",
+			PerFormat: map[string]string{"xlsx": "{id}-{kind}"},
+		}, format: "json", expected: "Отдел_продаж_лиды_" + date + ".json"},
+		{name: "date format with separators", config: ExportFilenameConfig{
+			Template:   "{id}_{date}",
+			DateFormat: "2006/01/02",
+		}, format: "csv", expected: "test-widget-id_" + time.Now().Format("2006_01_02") + ".csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exportService := NewExportService(mockSubmissionRepo, mockWidgetRepo)
+			exportService.SetFilenameConfig(tt.config)
+
+			_, filename, err := exportService.ExportSubmissions(ctx, widgetID, userID, models.ExportOptions{Format: tt.format})
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if filename != tt.expected {
+				t.Errorf("Expected filename %q, got %q", tt.expected, filename)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := map[string]string{
+		"Contact Form":         "Contact_Form",
+		"Отдел/продаж: лиды":   "Отдел_продаж_лиды",
+		`a\b<c>d|e?f*g"h`:      "a_b_c_d_e_f_g_h",
+		"../../etc/passwd":     "etc_passwd",
+		"  ...  ":              "export",
+		"report-2024.01_final": "report-2024.01_final",
+	}
+
+	for input, expected := range tests {
+		if got := SanitizeFilename(input); got != expected {
+			t.Errorf("SanitizeFilename(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
 func TestExportService_CollectFieldNames(t *testing.T) {
 	exportService := &ExportService{}
 
@@ -339,27 +993,14 @@ func TestExportService_CollectFieldNames(t *testing.T) {
 		},
 	}
 
-	fieldNames := exportService.collectFieldNames(submissions)
-
-	// Check if all expected field names are present
-	expectedFields := []string{"name", "email", "message", "phone"}
-	for _, expected := range expectedFields {
-		found := false
-		for _, field := range fieldNames {
-			if field == expected {
-				found = true
-				break
-			}
-		}
-		if !found {
-			t.Errorf("Expected field '%s' not found in fieldNames", expected)
+	// Fields of the first submission come first, new fields of one submission sorted
+	expected := []string{"email", "name", "message", "phone"}
+	for i := 0; i < 20; i++ {
+		fieldNames := exportService.collectFieldNames(submissions)
+		if !reflect.DeepEqual(fieldNames, expected) {
+			t.Fatalf("Expected field names %v, got %v", expected, fieldNames)
 		}
 	}
-
-	// Check length
-	if len(fieldNames) != 4 {
-		t.Errorf("Expected 4 field names, got %d", len(fieldNames))
-	}
 }
 
 func TestExportService_FormatValue(t *testing.T) {
@@ -389,3 +1030,235 @@ func TestExportService_FormatValue(t *testing.T) {
 		})
 	}
 }
+
+func TestExportService_DailyQuota(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	client := storage.NewRedisClientWithUniversal(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	service := NewExportService(NewMockSubmissionRepository(), NewMockWidgetRepository())
+	service.SetDailyQuota(map[string]int{"free": 2, "pro": 5}, storage.NewRedisExportQuotaRepository(client))
+	now := time.Date(2024, 1, 15, 22, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	var release func()
+	for i := 0; i < 2; i++ {
+		if err := service.CheckDailyQuota(ctx, "user-1", "free"); err != nil {
+			t.Fatalf("Expected export %d to be allowed, got %v", i+1, err)
+		}
+		if release, err = service.ReserveDailyExport(ctx, "user-1", service.DailyQuota("free")); err != nil {
+			t.Fatalf("Expected export %d to be counted, got %v", i+1, err)
+		}
+	}
+
+	err = service.CheckDailyQuota(ctx, "user-1", "free")
+	quotaErr, ok := err.(*models.QuotaExceededError)
+	if !ok {
+		t.Fatalf("Expected quota error for the third export, got %v", err)
+	}
+	if quotaErr.Limit != 2 || quotaErr.RetryAfter != 2*time.Hour {
+		t.Errorf("Expected limit 2 resetting in 2h, got %d in %v", quotaErr.Limit, quotaErr.RetryAfter)
+	}
+	if _, err := service.ReserveDailyExport(ctx, "user-1", service.DailyQuota("free")); err == nil {
+		t.Error("Expected the third export not to be counted")
+	}
+
+	// A failed export gives its count back
+	release()
+	if err := service.CheckDailyQuota(ctx, "user-1", "free"); err != nil {
+		t.Errorf("Expected a released export to free the quota, got %v", err)
+	}
+	if _, err := service.ReserveDailyExport(ctx, "user-1", service.DailyQuota("free")); err != nil {
+		t.Errorf("Expected the released export to be counted again, got %v", err)
+	}
+
+	// A higher plan limit, other users and unlisted plans aren't affected
+	if err := service.CheckDailyQuota(ctx, "user-1", "pro"); err != nil {
+		t.Errorf("Expected pro plan to allow more exports, got %v", err)
+	}
+	if err := service.CheckDailyQuota(ctx, "user-2", "free"); err != nil {
+		t.Errorf("Expected other user to be allowed, got %v", err)
+	}
+	if err := service.CheckDailyQuota(ctx, "user-1", "enterprise"); err != nil {
+		t.Errorf("Expected unlisted plan to be unlimited, got %v", err)
+	}
+
+	// The counter resets on the next UTC day
+	now = now.Add(3 * time.Hour)
+	if err := service.CheckDailyQuota(ctx, "user-1", "free"); err != nil {
+		t.Errorf("Expected quota to reset the next day, got %v", err)
+	}
+}
+
+func TestExportService_DailyQuotaParallel(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	client := storage.NewRedisClientWithUniversal(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	service := NewExportService(NewMockSubmissionRepository(), NewMockWidgetRepository())
+	service.SetDailyQuota(map[string]int{"free": 5}, storage.NewRedisExportQuotaRepository(client))
+
+	// Parallel exports are checked and counted atomically, so none goes over the limit
+	var wg sync.WaitGroup
+	var counted atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.ReserveDailyExport(context.Background(), "user-1", service.DailyQuota("free")); err == nil {
+				counted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := counted.Load(); got != 5 {
+		t.Errorf("Expected 5 exports to be counted, got %d", got)
+	}
+	if count, _ := storage.NewRedisExportQuotaRepository(client).GetDailyExports(context.Background(), "user-1", time.Now()); count != 5 {
+		t.Errorf("Expected a counter of 5, got %d", count)
+	}
+}
+
+func TestExportService_ConcurrencyLimit(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	client := storage.NewRedisClientWithUniversal(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	slots := storage.NewRedisExportSlotRepository(client)
+	ctx := context.Background()
+
+	// Two instances share the cluster-wide cap of 2
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	instances := make([]*ExportService, 2)
+	for i := range instances {
+		instances[i] = NewExportService(NewMockSubmissionRepository(), NewMockWidgetRepository())
+		instances[i].SetConcurrencyLimit(0, 2, time.Minute, slots)
+		instances[i].now = func() time.Time { return now }
+	}
+
+	releaseA, err := instances[0].AcquireSlot(ctx)
+	if err != nil {
+		t.Fatalf("Expected first slot, got %v", err)
+	}
+	if _, err := instances[1].AcquireSlot(ctx); err != nil {
+		t.Fatalf("Expected second slot, got %v", err)
+	}
+
+	_, err = instances[0].AcquireSlot(ctx)
+	busyErr, ok := err.(*models.ExportBusyError)
+	if !ok {
+		t.Fatalf("Expected busy error above the cluster cap, got %v", err)
+	}
+	if !busyErr.Cluster || busyErr.Limit != 2 || busyErr.RetryAfter <= 0 {
+		t.Errorf("Expected cluster limit 2 with a retry delay, got %+v", busyErr)
+	}
+
+	// Releasing frees the slot for any instance
+	releaseA()
+	releaseC, err := instances[1].AcquireSlot(ctx)
+	if err != nil {
+		t.Fatalf("Expected released slot to be reused, got %v", err)
+	}
+	releaseC()
+
+	// The second instance "crashes" holding its lease, which expires after the TTL
+	if _, err := instances[0].AcquireSlot(ctx); err != nil {
+		t.Fatalf("Expected slot, got %v", err)
+	}
+	if _, err := instances[0].AcquireSlot(ctx); err == nil {
+		t.Fatal("Expected busy error while the crashed lease is held")
+	}
+	now = now.Add(time.Minute + time.Second)
+	if _, err := instances[0].AcquireSlot(ctx); err != nil {
+		t.Errorf("Expected expired leases to free their slots, got %v", err)
+	}
+	if held, _ := client.GetClient().ZCard(ctx, storage.ExportSlotsKey).Result(); held != 1 {
+		t.Errorf("Expected only the new lease to be held, got %d", held)
+	}
+}
+
+func TestExportService_InstanceConcurrencyLimit(t *testing.T) {
+	service := NewExportService(NewMockSubmissionRepository(), NewMockWidgetRepository())
+	service.SetConcurrencyLimit(1, 0, 0, nil)
+	ctx := context.Background()
+
+	release, err := service.AcquireSlot(ctx)
+	if err != nil {
+		t.Fatalf("Expected a slot, got %v", err)
+	}
+	_, err = service.AcquireSlot(ctx)
+	if busyErr, ok := err.(*models.ExportBusyError); !ok || busyErr.Cluster || busyErr.Limit != 1 {
+		t.Fatalf("Expected instance busy error, got %v", err)
+	}
+
+	release()
+	if _, err := service.AcquireSlot(ctx); err != nil {
+		t.Errorf("Expected the released slot to be free, got %v", err)
+	}
+}
+
+func TestExportService_RangeLimit(t *testing.T) {
+	ctx := context.Background()
+	mockWidgetRepo := NewMockWidgetRepository()
+	mockSubmissionRepo := NewMockSubmissionRepository()
+	service := NewExportService(mockSubmissionRepo, mockWidgetRepo)
+	service.SetRangeLimit(30*24*time.Hour, 2)
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	mockWidgetRepo.widgets["small"] = &models.Widget{ID: "small", OwnerID: "user-1", Name: "Small", Type: "lead-form"}
+	mockWidgetRepo.widgets["large"] = &models.Widget{ID: "large", OwnerID: "user-1", Name: "Large", Type: "lead-form"}
+	mockSubmissionRepo.submissions["small"] = []*models.Submission{{ID: "s-1", WidgetID: "small", CreatedAt: now}}
+	for i := 0; i < 3; i++ {
+		mockSubmissionRepo.submissions["large"] = append(mockSubmissionRepo.submissions["large"],
+			&models.Submission{ID: fmt.Sprintf("l-%d", i), WidgetID: "large", CreatedAt: now})
+	}
+
+	at := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+
+	tests := []struct {
+		name      string
+		widgetID  string
+		from, to  *time.Time
+		rejected  bool
+		unbounded bool
+	}{
+		{name: "within the span", widgetID: "large", from: at(40), to: at(20)},
+		{name: "over the span", widgetID: "large", from: at(60), to: at(20), rejected: true},
+		{name: "open end counts up to now", widgetID: "large", from: at(31), rejected: true},
+		{name: "unbounded small widget", widgetID: "small"},
+		{name: "unbounded large widget", widgetID: "large", to: at(1), rejected: true, unbounded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := service.ExportSubmissions(ctx, tt.widgetID, "user-1", models.ExportOptions{Format: "json", From: tt.from, To: tt.to})
+			rangeErr, ok := err.(*models.ExportRangeError)
+			if !tt.rejected {
+				if err != nil {
+					t.Fatalf("Expected export to proceed, got %v", err)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Expected a range error, got %v", err)
+			}
+			if rangeErr.Unbounded != tt.unbounded || !strings.Contains(rangeErr.Error(), "30 days") {
+				t.Errorf("Expected unbounded=%v and the max span in the message, got %q", tt.unbounded, rangeErr.Error())
+			}
+		})
+	}
+}
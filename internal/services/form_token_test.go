@@ -0,0 +1,41 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormTokenSigner_Verify(t *testing.T) {
+	signer := NewFormTokenSigner("secret")
+	now := time.Unix(1700000000, 0)
+	renderedAt := now.Add(-10 * time.Second)
+	token := signer.Issue("widget-1", renderedAt)
+
+	if got, ok := signer.Verify("widget-1", token, now); !ok || !got.Equal(renderedAt) {
+		t.Errorf("Expected render time %v, got %v (ok=%v)", renderedAt, got, ok)
+	}
+
+	issued, _, _ := strings.Cut(token, ".")
+	tests := []struct {
+		name     string
+		widgetID string
+		token    string
+		now      time.Time
+	}{
+		{"empty", "widget-1", "", now},
+		{"malformed", "widget-1", "not-a-token", now},
+		{"other widget", "widget-2", token, now},
+		{"other secret", "widget-1", NewFormTokenSigner("other").Issue("widget-1", renderedAt), now},
+		{"changed time", "widget-1", "1699999000." + strings.TrimPrefix(token, issued+"."), now},
+		{"expired", "widget-1", token, renderedAt.Add(FormTokenMaxAge + time.Second)},
+		{"from the future", "widget-1", signer.Issue("widget-1", now.Add(time.Hour)), now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := signer.Verify(tt.widgetID, tt.token, tt.now); ok {
+				t.Errorf("Expected token %q to be rejected", tt.token)
+			}
+		})
+	}
+}
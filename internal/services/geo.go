@@ -0,0 +1,15 @@
+package services
+
+// GeoLocator resolves the region of a client IP (e.g. an ISO country code).
+// Implementations return an empty string when the region is unknown.
+type GeoLocator interface {
+	Locate(ip string) string
+}
+
+// NoopGeoLocator is the default GeoLocator used when geo lookup is disabled
+type NoopGeoLocator struct{}
+
+// Locate never resolves a region
+func (NoopGeoLocator) Locate(ip string) string {
+	return ""
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/validation"
+	"github.com/ad/leads-core/pkg/logger"
+)
+
+const (
+	// MaxImportErrors caps the number of line errors returned in an import summary
+	MaxImportErrors = 100
+	// MaxImportRows caps the number of rows read from an import file
+	MaxImportRows = 50000
+	// MaxImportLineSize bounds the memory used for a single NDJSON import line
+	MaxImportLineSize = 1 << 20
+)
+
+// ImportService imports historical submissions into widgets from uploaded files.
+// Rows are stored as they are read, so memory stays bounded for large files.
+type ImportService struct {
+	widgetService *WidgetService
+	validator     *validation.SchemaValidator
+}
+
+// NewImportService creates a new import service
+func NewImportService(widgetService *WidgetService, validator *validation.SchemaValidator) *ImportService {
+	return &ImportService{
+		widgetService: widgetService,
+		validator:     validator,
+	}
+}
+
+// ImportNDJSON imports NDJSON lines, one {"data": {...}, "created_at": "..."} submission
+// per line, into the user's widget as a stream. Failed lines are reported with their
+// line number; in strict mode the import stops at the first one. It fails only when the
+// widget can't be imported into.
+func (s *ImportService) ImportNDJSON(ctx context.Context, widgetID, userID string, r io.Reader, strict bool) (*models.ImportSummary, error) {
+	widget, err := s.importWidget(ctx, widgetID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ImportSummary{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxImportLineSize)
+
+	line, rows := 0, 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		if rows >= MaxImportRows {
+			summary.AddError(&models.ImportLineError{Line: line, Error: fmt.Sprintf("Import exceeds %d rows", MaxImportRows)}, MaxImportErrors)
+			summary.Aborted = true
+			return summary, nil
+		}
+		rows++
+
+		if lineErr := s.importLine(ctx, widget, line, raw); lineErr != nil {
+			summary.AddError(lineErr, MaxImportErrors)
+			if strict {
+				summary.Aborted = true
+				return summary, nil
+			}
+			continue
+		}
+		summary.Imported++
+	}
+
+	// A line over the size limit (or a read error) leaves the stream unreadable
+	if err := scanner.Err(); err != nil {
+		message := "Failed to read line"
+		if err == bufio.ErrTooLong {
+			message = fmt.Sprintf("Line exceeds %d bytes", MaxImportLineSize)
+		}
+		summary.AddError(&models.ImportLineError{Line: line + 1, Error: message}, MaxImportErrors)
+		summary.Aborted = true
+	}
+
+	return summary, nil
+}
+
+// ImportCSV imports the rows of a CSV file into the user's widget. The header row names
+// the data fields; an optional created_at column (RFC3339) keeps the original submission
+// time. Empty cells are left out. Failed rows are reported with their line number in the
+// file; in strict mode the import stops at the first one. It fails only when the widget
+// can't be imported into or the header row is missing or invalid (*models.ImportFileError).
+func (s *ImportService) ImportCSV(ctx context.Context, widgetID, userID string, r io.Reader, strict bool) (*models.ImportSummary, error) {
+	widget, err := s.importWidget(ctx, widgetID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, &models.ImportFileError{Message: "CSV file is empty"}
+	}
+	if err != nil {
+		return nil, &models.ImportFileError{Message: "Invalid CSV header row"}
+	}
+
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // Byte order mark of spreadsheet exports
+		}
+		if name == "" || seen[name] {
+			return nil, &models.ImportFileError{Message: "CSV header row has an empty or duplicate column name"}
+		}
+		seen[name] = true
+		header[i] = name
+	}
+
+	summary := &models.ImportSummary{}
+	rows := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var lineErr *models.ImportLineError
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			lineErr = &models.ImportLineError{Line: parseErr.StartLine, Error: "Invalid CSV row: " + parseErr.Err.Error()}
+		} else if err != nil {
+			summary.AddError(&models.ImportLineError{Line: rows + 2, Error: "Failed to read row"}, MaxImportErrors)
+			summary.Aborted = true
+			break
+		} else {
+			line, _ := reader.FieldPos(0)
+			if rows >= MaxImportRows {
+				summary.AddError(&models.ImportLineError{Line: line, Error: fmt.Sprintf("Import exceeds %d rows", MaxImportRows)}, MaxImportErrors)
+				summary.Aborted = true
+				break
+			}
+			req, rowErr := csvImportRequest(header, record, line)
+			if lineErr = rowErr; lineErr == nil {
+				lineErr = s.importSubmission(ctx, widget, line, req)
+			}
+		}
+		rows++
+
+		if lineErr != nil {
+			summary.AddError(lineErr, MaxImportErrors)
+			if strict {
+				summary.Aborted = true
+				break
+			}
+			continue
+		}
+		summary.Imported++
+	}
+
+	return summary, nil
+}
+
+// importWidget returns the user's widget to import into, rejecting archived widgets
+func (s *ImportService) importWidget(ctx context.Context, widgetID, userID string) (*models.Widget, error) {
+	widget, err := s.widgetService.GetWidget(ctx, widgetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if widget.Archived {
+		return nil, errors.ErrWidgetArchived
+	}
+	return widget, nil
+}
+
+// csvImportRequest builds the import request of the CSV row on the given line
+func csvImportRequest(header, record []string, line int) (models.ImportSubmissionRequest, *models.ImportLineError) {
+	req := models.ImportSubmissionRequest{Data: make(map[string]interface{}, len(record))}
+	for i, value := range record {
+		if value == "" {
+			continue
+		}
+		if header[i] == "created_at" {
+			createdAt, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+			if err != nil {
+				return req, &models.ImportLineError{Line: line, Error: "Invalid created_at, expected RFC3339"}
+			}
+			req.CreatedAt = &createdAt
+			continue
+		}
+		req.Data[header[i]] = value
+	}
+	if len(req.Data) == 0 {
+		return req, &models.ImportLineError{Line: line, Error: "Row has no data"}
+	}
+	return req, nil
+}
+
+// importLine validates and stores a single NDJSON import line
+func (s *ImportService) importLine(ctx context.Context, widget *models.Widget, line int, raw []byte) *models.ImportLineError {
+	var req models.ImportSubmissionRequest
+	if err := s.validator.ValidateAndDecodeBytes(raw, "submission-import", &req); err != nil {
+		if valErr, ok := err.(*validation.ValidationError); ok {
+			return &models.ImportLineError{Line: line, Error: "Validation error", Details: valErr.Errors}
+		}
+		if limitErr, ok := err.(*validation.LimitError); ok {
+			return &models.ImportLineError{Line: line, Error: limitErr.Error()}
+		}
+		return &models.ImportLineError{Line: line, Error: "Invalid JSON"}
+	}
+
+	return s.importSubmission(ctx, widget, line, req)
+}
+
+// importSubmission stores a single import row, returning the error reported for its line
+func (s *ImportService) importSubmission(ctx context.Context, widget *models.Widget, line int, req models.ImportSubmissionRequest) *models.ImportLineError {
+	if _, err := s.widgetService.ImportSubmission(ctx, widget, req); err != nil {
+		if fieldErrs, ok := err.(models.FieldErrors); ok {
+			return &models.ImportLineError{Line: line, Error: "Validation error", Details: fieldErrs}
+		}
+		logger.Error("Failed to import submission", map[string]interface{}{
+			"action":    "import_widget_submissions",
+			"widget_id": widget.ID,
+			"line":      line,
+			"error":     err.Error(),
+		})
+		return &models.ImportLineError{Line: line, Error: "Failed to store submission"}
+	}
+
+	return nil
+}
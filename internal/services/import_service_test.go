@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/storage"
+	"github.com/ad/leads-core/internal/validation"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func setupImportService(t *testing.T) (*ImportService, *storage.RedisSubmissionRepository) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client := storage.NewRedisClientWithUniversal(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	statsRepo := storage.NewRedisStatsRepository(client)
+	widgetRepo := storage.NewRedisWidgetRepository(client, statsRepo)
+	submissionRepo := storage.NewRedisSubmissionRepository(client)
+	widgetService := NewWidgetService(widgetRepo, submissionRepo, statsRepo, TTLConfig{FreeDays: 30})
+
+	now := time.Now()
+	for _, widget := range []*models.Widget{
+		{ID: "widget-1", OwnerID: "user-1", Name: "Lead Form", Type: "lead-form", IsVisible: true, CreatedAt: now, UpdatedAt: now},
+		{ID: "archived", OwnerID: "user-1", Name: "Old Form", Type: "lead-form", Archived: true, CreatedAt: now, UpdatedAt: now},
+		{ID: "normalized", OwnerID: "user-1", Name: "Signup", Type: "lead-form", IsVisible: true, CreatedAt: now, UpdatedAt: now, Config: map[string]interface{}{
+			"fields":           map[string]interface{}{"email": map[string]interface{}{"type": "email", "required": true}},
+			"field_transforms": map[string]interface{}{"email": []interface{}{"trim", "lower"}},
+			"field_defaults":   map[string]interface{}{"source": "import"},
+		}},
+	} {
+		if err := widgetRepo.Create(context.Background(), widget); err != nil {
+			t.Fatalf("Failed to create widget: %v", err)
+		}
+	}
+
+	validator, err := validation.NewSchemaValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	return NewImportService(widgetService, validator), submissionRepo
+}
+
+func TestImportService_ImportCSV(t *testing.T) {
+	ctx := context.Background()
+	service, submissionRepo := setupImportService(t)
+
+	csvData := "email,name,created_at\n" +
+		"a@example.com,Alice,2024-01-02T03:04:05Z\n" +
+		"b@example.com,Bob,yesterday\n" +
+		",,\n" +
+		"c@example.com,Carol\n" +
+		"d@example.com,,\n"
+
+	summary, err := service.ImportCSV(ctx, "widget-1", "user-1", strings.NewReader(csvData), false)
+	if err != nil {
+		t.Fatalf("ImportCSV failed: %v", err)
+	}
+	if summary.Imported != 2 || summary.Failed != 3 || summary.Aborted {
+		t.Errorf("Expected 2 imported and 3 failed, got %+v", summary)
+	}
+	for i, line := range []int{3, 4, 5} {
+		if summary.Errors[i].Line != line {
+			t.Errorf("Expected error %d on line %d, got line %d", i, line, summary.Errors[i].Line)
+		}
+	}
+
+	submissions, total, err := submissionRepo.GetByWidgetID(ctx, "widget-1", models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil || total != 2 {
+		t.Fatalf("Expected 2 stored submissions, got %d (err: %v)", total, err)
+	}
+	for _, submission := range submissions {
+		if submission.Data["email"] == "a@example.com" && !submission.CreatedAt.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+			t.Errorf("Expected created_at to be kept, got %v", submission.CreatedAt)
+		}
+	}
+
+	// Strict imports stop at the first bad row
+	summary, err = service.ImportCSV(ctx, "widget-1", "user-1", strings.NewReader(csvData), true)
+	if err != nil || summary.Imported != 1 || summary.Failed != 1 || !summary.Aborted {
+		t.Errorf("Expected strict import to stop after 1 row, got %+v (err: %v)", summary, err)
+	}
+}
+
+func TestImportService_ImportCSVRejected(t *testing.T) {
+	ctx := context.Background()
+	service, _ := setupImportService(t)
+
+	if _, err := service.ImportCSV(ctx, "widget-1", "user-2", strings.NewReader("email\na@example.com\n"), false); err != errors.ErrAccessDenied {
+		t.Errorf("Expected ErrAccessDenied for another user's widget, got %v", err)
+	}
+	if _, err := service.ImportCSV(ctx, "archived", "user-1", strings.NewReader("email\na@example.com\n"), false); err != errors.ErrWidgetArchived {
+		t.Errorf("Expected ErrWidgetArchived, got %v", err)
+	}
+
+	for name, csvData := range map[string]string{
+		"empty file":       "",
+		"empty column":     "email,,name\n",
+		"duplicate column": "email,email\n",
+	} {
+		_, err := service.ImportCSV(ctx, "widget-1", "user-1", strings.NewReader(csvData), false)
+		if _, ok := err.(*models.ImportFileError); !ok {
+			t.Errorf("%s: expected an ImportFileError, got %v", name, err)
+		}
+	}
+}
+
+func TestImportService_ImportNDJSON(t *testing.T) {
+	ctx := context.Background()
+	service, submissionRepo := setupImportService(t)
+
+	ndjson := `{"data": {"email": "a@example.com"}, "created_at": "2024-01-02T03:04:05Z"}
+
+not json
+{"data": {}}
+{"data": {"email": "b@example.com"}}
+`
+
+	summary, err := service.ImportNDJSON(ctx, "widget-1", "user-1", strings.NewReader(ndjson), false)
+	if err != nil {
+		t.Fatalf("ImportNDJSON failed: %v", err)
+	}
+	if summary.Imported != 2 || summary.Failed != 2 || summary.Aborted {
+		t.Errorf("Expected 2 imported and 2 failed, got %+v", summary)
+	}
+	if summary.Errors[0].Line != 3 || summary.Errors[0].Error != "Invalid JSON" {
+		t.Errorf("Expected invalid JSON on line 3, got %+v", summary.Errors[0])
+	}
+	if summary.Errors[1].Line != 4 || summary.Errors[1].Error != "Validation error" {
+		t.Errorf("Expected a validation error on line 4, got %+v", summary.Errors[1])
+	}
+	if _, total, err := submissionRepo.GetByWidgetID(ctx, "widget-1", models.PaginationOptions{Page: 1, PerPage: 10}); err != nil || total != 2 {
+		t.Errorf("Expected 2 stored submissions, got %d (err: %v)", total, err)
+	}
+
+	// Strict imports stop at the first bad line
+	summary, err = service.ImportNDJSON(ctx, "widget-1", "user-1", strings.NewReader(ndjson), true)
+	if err != nil || summary.Imported != 1 || summary.Failed != 1 || !summary.Aborted {
+		t.Errorf("Expected strict import to stop after 1 line, got %+v (err: %v)", summary, err)
+	}
+
+	if _, err := service.ImportNDJSON(ctx, "widget-1", "user-2", strings.NewReader(ndjson), false); err != errors.ErrAccessDenied {
+		t.Errorf("Expected ErrAccessDenied for another user's widget, got %v", err)
+	}
+	if _, err := service.ImportNDJSON(ctx, "archived", "user-1", strings.NewReader(ndjson), false); err != errors.ErrWidgetArchived {
+		t.Errorf("Expected ErrWidgetArchived, got %v", err)
+	}
+}
+
+func TestImportService_ImportNDJSONRowLimit(t *testing.T) {
+	service, _ := setupImportService(t)
+
+	// Lines failing to parse still count as rows
+	ndjson := strings.Repeat("x\n", MaxImportRows+1)
+	summary, err := service.ImportNDJSON(context.Background(), "widget-1", "user-1", strings.NewReader(ndjson), false)
+	if err != nil {
+		t.Fatalf("ImportNDJSON failed: %v", err)
+	}
+	if !summary.Aborted || summary.Failed != MaxImportRows+1 {
+		t.Errorf("Expected the import to abort after %d rows, got %+v", MaxImportRows, summary)
+	}
+}
+
+func TestImportService_ImportNormalizesData(t *testing.T) {
+	ctx := context.Background()
+	service, submissionRepo := setupImportService(t)
+
+	// Imports get the widget's transforms, defaults and validation mode like submits
+	ndjson := `{"data": {"email": "  Alice@Example.COM "}}
+{"data": {"name": "Bob"}}
+{"data": {"email": "not-an-email"}}
+`
+	summary, err := service.ImportNDJSON(ctx, "normalized", "user-1", strings.NewReader(ndjson), false)
+	if err != nil {
+		t.Fatalf("ImportNDJSON failed: %v", err)
+	}
+	if summary.Imported != 1 || summary.Failed != 2 {
+		t.Fatalf("Expected 1 imported and 2 failed, got %+v", summary)
+	}
+	for _, lineErr := range summary.Errors {
+		if lineErr.Error != "Validation error" {
+			t.Errorf("Expected a validation error on line %d, got %q", lineErr.Line, lineErr.Error)
+		}
+	}
+
+	csvData := "email\n BOB@example.com\n"
+	if summary, err := service.ImportCSV(ctx, "normalized", "user-1", strings.NewReader(csvData), false); err != nil || summary.Imported != 1 {
+		t.Fatalf("Expected the CSV row to be imported, got %+v (err: %v)", summary, err)
+	}
+
+	submissions, _, err := submissionRepo.GetByWidgetID(ctx, "normalized", models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil || len(submissions) != 2 {
+		t.Fatalf("Expected 2 stored submissions, got %d (err: %v)", len(submissions), err)
+	}
+	emails := map[interface{}]bool{}
+	for _, submission := range submissions {
+		emails[submission.Data["email"]] = true
+		if submission.Data["source"] != "import" {
+			t.Errorf("Expected the source default to be applied, got %v", submission.Data["source"])
+		}
+	}
+	if !emails["alice@example.com"] || !emails["bob@example.com"] {
+		t.Errorf("Expected transformed emails, got %v", emails)
+	}
+}
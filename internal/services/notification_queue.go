@@ -0,0 +1,59 @@
+package services
+
+import (
+	"github.com/ad/leads-core/pkg/logger"
+	"github.com/ad/leads-core/pkg/metrics"
+)
+
+// Default sizing of the background delivery queues of chat and email notifications
+const (
+	DefaultNotificationWorkers   = 4
+	DefaultNotificationQueueSize = 1000
+)
+
+// NotificationQueue delivers notifications in the background on a fixed number of
+// workers, so a burst of submissions can't start an unbounded number of goroutines and
+// connections. Deliveries arriving while the queue is full are dropped and logged.
+type NotificationQueue struct {
+	channel    string
+	deliveries chan func()
+}
+
+// NewNotificationQueue creates a notification queue for the named channel and starts
+// its workers
+func NewNotificationQueue(channel string, workers, queueSize int) *NotificationQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	q := &NotificationQueue{
+		channel:    channel,
+		deliveries: make(chan func(), queueSize),
+	}
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// Enqueue queues a delivery without waiting, returning false when it was dropped
+// because the queue is full
+func (q *NotificationQueue) Enqueue(deliver func()) bool {
+	select {
+	case q.deliveries <- deliver:
+		return true
+	default:
+		metrics.Inc("notifications_dropped_total", map[string]string{"channel": q.channel}, "Total notifications dropped because the delivery queue was full")
+		logger.Warn("notification queue is full, dropping notification", map[string]interface{}{
+			"action":  "notify_" + q.channel,
+			"channel": q.channel,
+		})
+		return false
+	}
+}
+
+// work runs queued deliveries one at a time
+func (q *NotificationQueue) work() {
+	for deliver := range q.deliveries {
+		deliver()
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotificationQueue_BoundsDeliveries(t *testing.T) {
+	queue := NewNotificationQueue("test", 2, 3)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	var running, maxRunning, delivered atomic.Int32
+	deliver := func() {
+		n := running.Add(1)
+		for {
+			max := maxRunning.Load()
+			if n <= max || maxRunning.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		started <- struct{}{}
+		<-release
+		running.Add(-1)
+		delivered.Add(1)
+	}
+
+	// Two deliveries run on the workers, three wait in the queue
+	for i := 0; i < 2; i++ {
+		if !queue.Enqueue(deliver) {
+			t.Fatalf("Expected delivery %d to be queued", i)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("Expected the workers to start deliveries")
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if !queue.Enqueue(deliver) {
+			t.Fatalf("Expected queued delivery %d to be accepted", i)
+		}
+	}
+
+	// A full queue drops further deliveries instead of blocking
+	if queue.Enqueue(deliver) {
+		t.Error("Expected a delivery to be dropped while the queue is full")
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for delivered.Load() < 5 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := delivered.Load(); got != 5 {
+		t.Errorf("Expected 5 deliveries, got %d", got)
+	}
+	if got := maxRunning.Load(); got != 2 {
+		t.Errorf("Expected at most 2 concurrent deliveries, got %d", got)
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/storage"
+	"github.com/ad/leads-core/pkg/logger"
+	"github.com/ad/leads-core/pkg/metrics"
+)
+
+// NotificationService sends submission notifications according to each widget's
+// notification mode (immediate, throttled or digest)
+type NotificationService struct {
+	repo           storage.NotificationRepository
+	widgetRepo     storage.WidgetRepository
+	submissionRepo storage.SubmissionRepository
+	notifier       Notifier
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(
+	repo storage.NotificationRepository,
+	widgetRepo storage.WidgetRepository,
+	submissionRepo storage.SubmissionRepository,
+	notifier Notifier,
+) *NotificationService {
+	if notifier == nil {
+		notifier = NoopNotifier{}
+	}
+	return &NotificationService{
+		repo:           repo,
+		widgetRepo:     widgetRepo,
+		submissionRepo: submissionRepo,
+		notifier:       notifier,
+	}
+}
+
+// NotifySubmission notifies the widget owner about a new submission. Submissions not
+// matching the widget's notification conditions are not forwarded.
+func (s *NotificationService) NotifySubmission(ctx context.Context, widget *models.Widget, submission *models.Submission) error {
+	if !widget.NotificationConditions().Matches(submission.Data) {
+		metrics.Inc("notifications_filtered_total", nil, "Total notifications skipped by widget conditions")
+		return nil
+	}
+
+	mode, interval := widget.NotificationSettings()
+
+	switch mode {
+	case models.NotificationModeThrottled:
+		allowed, err := s.repo.AcquireThrottle(ctx, widget.ID, interval)
+		if err != nil {
+			return fmt.Errorf("failed to check notification throttle: %w", err)
+		}
+		if !allowed {
+			metrics.Inc("notifications_suppressed_total", nil, "Total notifications suppressed by throttling")
+			return nil
+		}
+	case models.NotificationModeDigest:
+		if err := s.repo.AddToDigest(ctx, widget.ID, submission.ID); err != nil {
+			return fmt.Errorf("failed to queue submission for digest: %w", err)
+		}
+		return nil
+	}
+
+	return s.send(ctx, widget, mode, []*models.Submission{submission})
+}
+
+// FlushDigests sends a summary for every widget with queued submissions and
+// returns the number of digests sent
+func (s *NotificationService) FlushDigests(ctx context.Context) (int, error) {
+	widgetIDs, err := s.repo.GetPendingDigests(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending digests: %w", err)
+	}
+
+	sent := 0
+	for _, widgetID := range widgetIDs {
+		submissionIDs, err := s.repo.PopDigest(ctx, widgetID)
+		if err != nil {
+			logger.Error("failed to pop notification digest", map[string]interface{}{
+				"widget_id": widgetID,
+				"error":     err.Error(),
+			})
+			continue
+		}
+		if len(submissionIDs) == 0 {
+			continue
+		}
+
+		widget, err := s.widgetRepo.GetByID(ctx, widgetID)
+		if err != nil {
+			// Widget was deleted since the submissions were queued
+			continue
+		}
+
+		submissions := make([]*models.Submission, 0, len(submissionIDs))
+		for _, submissionID := range submissionIDs {
+			submission, err := s.submissionRepo.GetByID(ctx, widgetID, submissionID)
+			if err != nil {
+				// Submission expired meanwhile
+				continue
+			}
+			submissions = append(submissions, submission)
+		}
+		if len(submissions) == 0 {
+			continue
+		}
+
+		if err := s.send(ctx, widget, models.NotificationModeDigest, submissions); err != nil {
+			logger.Error("failed to send notification digest", map[string]interface{}{
+				"widget_id": widgetID,
+				"error":     err.Error(),
+			})
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// StartDigestFlusher periodically flushes notification digests. It returns at once
+// for a non-positive interval.
+func (s *NotificationService) StartDigestFlusher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		logger.Error("Notification digest flusher not started, interval must be positive", map[string]interface{}{
+			"interval": interval.String(),
+		})
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info("Starting notification digest flusher", map[string]interface{}{
+		"interval": interval.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Notification digest flusher stopped")
+			return
+		case <-ticker.C:
+			if _, err := s.FlushDigests(ctx); err != nil {
+				logger.Error("failed to flush notification digests", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+}
+
+// send delivers a notification and records it in metrics
+func (s *NotificationService) send(ctx context.Context, widget *models.Widget, mode models.NotificationMode, submissions []*models.Submission) error {
+	if err := s.notifier.Notify(ctx, widget, submissions); err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	metrics.Inc("notifications_sent_total", map[string]string{"mode": string(mode)}, "Total submission notifications sent")
+	return nil
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// recordingNotifier records the size of every notification it receives
+type recordingNotifier struct {
+	batches []int
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, widget *models.Widget, submissions []*models.Submission) error {
+	n.batches = append(n.batches, len(submissions))
+	return nil
+}
+
+func setupNotificationService(t *testing.T, mode models.NotificationMode) (*NotificationService, *recordingNotifier, *MockSubmissionRepository, *models.Widget, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client := storage.NewRedisClientWithUniversal(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	widget := &models.Widget{
+		ID:      "widget-1",
+		OwnerID: "user-1",
+		Config: map[string]interface{}{
+			models.WidgetConfigNotificationsKey: map[string]interface{}{"mode": string(mode), "interval_minutes": float64(10)},
+		},
+	}
+	widgetRepo := NewMockWidgetRepository()
+	widgetRepo.widgets[widget.ID] = widget
+	submissionRepo := NewMockSubmissionRepository()
+	notifier := &recordingNotifier{}
+
+	service := NewNotificationService(storage.NewRedisNotificationRepository(client), widgetRepo, submissionRepo, notifier)
+	return service, notifier, submissionRepo, widget, mr
+}
+
+func submitForNotification(t *testing.T, service *NotificationService, submissionRepo *MockSubmissionRepository, widget *models.Widget, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		submission := &models.Submission{ID: fmt.Sprintf("submission-%d", len(submissionRepo.submissions[widget.ID])), WidgetID: widget.ID}
+		submissionRepo.Create(context.Background(), submission)
+		if err := service.NotifySubmission(context.Background(), widget, submission); err != nil {
+			t.Fatalf("NotifySubmission failed: %v", err)
+		}
+	}
+}
+
+func TestNotificationService_Immediate(t *testing.T) {
+	service, notifier, submissionRepo, widget, _ := setupNotificationService(t, models.NotificationModeImmediate)
+
+	submitForNotification(t, service, submissionRepo, widget, 3)
+
+	if len(notifier.batches) != 3 {
+		t.Errorf("Expected 3 notifications, got %d", len(notifier.batches))
+	}
+}
+
+func TestNotificationService_ThrottledSuppressesRapidNotifications(t *testing.T) {
+	service, notifier, submissionRepo, widget, mr := setupNotificationService(t, models.NotificationModeThrottled)
+
+	submitForNotification(t, service, submissionRepo, widget, 5)
+	if len(notifier.batches) != 1 {
+		t.Fatalf("Expected 1 notification within the interval, got %d", len(notifier.batches))
+	}
+
+	// Once the interval passes the next submission notifies again
+	mr.FastForward(10*time.Minute + time.Second)
+	submitForNotification(t, service, submissionRepo, widget, 2)
+	if len(notifier.batches) != 2 {
+		t.Errorf("Expected 2 notifications after the interval, got %d", len(notifier.batches))
+	}
+}
+
+func TestNotificationService_DigestBatchesSubmissions(t *testing.T) {
+	service, notifier, submissionRepo, widget, _ := setupNotificationService(t, models.NotificationModeDigest)
+	ctx := context.Background()
+
+	submitForNotification(t, service, submissionRepo, widget, 4)
+	if len(notifier.batches) != 0 {
+		t.Fatalf("Expected no notifications before the flush, got %d", len(notifier.batches))
+	}
+
+	sent, err := service.FlushDigests(ctx)
+	if err != nil {
+		t.Fatalf("FlushDigests failed: %v", err)
+	}
+	if sent != 1 || len(notifier.batches) != 1 || notifier.batches[0] != 4 {
+		t.Fatalf("Expected a single digest of 4 submissions, got sent=%d batches=%v", sent, notifier.batches)
+	}
+
+	// Nothing is left to flush
+	if sent, err := service.FlushDigests(ctx); err != nil || sent != 0 {
+		t.Errorf("Expected empty second flush, got sent=%d err=%v", sent, err)
+	}
+}
+
+func TestNotificationService_Conditions(t *testing.T) {
+	service, notifier, submissionRepo, widget, _ := setupNotificationService(t, models.NotificationModeImmediate)
+	ctx := context.Background()
+
+	widget.Config[models.WidgetConfigNotificationsKey] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"field": "budget", "op": "gt", "value": float64(10000)},
+			map[string]interface{}{"field": "country", "op": "eq", "value": "us"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		data   map[string]interface{}
+		notify bool
+	}{
+		{"all conditions match", map[string]interface{}{"budget": float64(25000), "country": "US"}, true},
+		{"numeric string matches", map[string]interface{}{"budget": "15000", "country": "US"}, true},
+		{"below threshold", map[string]interface{}{"budget": float64(5000), "country": "US"}, false},
+		{"one condition fails", map[string]interface{}{"budget": float64(25000), "country": "DE"}, false},
+		{"field missing", map[string]interface{}{"country": "US"}, false},
+		{"non-numeric value", map[string]interface{}{"budget": "a lot", "country": "US"}, false},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(notifier.batches)
+			submission := &models.Submission{ID: fmt.Sprintf("submission-%d", i), WidgetID: widget.ID, Data: tt.data}
+			submissionRepo.Create(ctx, submission)
+			if err := service.NotifySubmission(ctx, widget, submission); err != nil {
+				t.Fatalf("NotifySubmission failed: %v", err)
+			}
+			if notified := len(notifier.batches) > before; notified != tt.notify {
+				t.Errorf("Expected notify=%v, got %v", tt.notify, notified)
+			}
+			// The submission is stored either way
+			if _, err := submissionRepo.GetByID(ctx, widget.ID, submission.ID); err != nil {
+				t.Errorf("Expected submission to be stored: %v", err)
+			}
+		})
+	}
+}
+
+func TestNotificationService_StartDigestFlusherRejectsNonPositiveInterval(t *testing.T) {
+	service, _, _, _, _ := setupNotificationService(t, models.NotificationModeDigest)
+
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		done := make(chan struct{})
+		go func() {
+			service.StartDigestFlusher(context.Background(), interval)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the flusher not to start for interval %v", interval)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/pkg/logger"
+)
+
+// Notifier delivers submission notifications to a widget owner (e.g. email or webhook).
+// Digests pass several submissions at once.
+type Notifier interface {
+	Notify(ctx context.Context, widget *models.Widget, submissions []*models.Submission) error
+}
+
+// NoopNotifier is the default Notifier used when no delivery channel is configured
+type NoopNotifier struct{}
+
+// Notify discards the notification
+func (NoopNotifier) Notify(ctx context.Context, widget *models.Widget, submissions []*models.Submission) error {
+	return nil
+}
+
+// LogNotifier writes notifications to the application log
+type LogNotifier struct{}
+
+// Notify logs the notification
+func (LogNotifier) Notify(ctx context.Context, widget *models.Widget, submissions []*models.Submission) error {
+	logger.Info("Submission notification", map[string]interface{}{
+		"action":      "notify_submissions",
+		"widget_id":   widget.ID,
+		"owner_id":    widget.OwnerID,
+		"submissions": len(submissions),
+	})
+	return nil
+}
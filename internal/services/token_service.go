@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/ad/leads-core/internal/auth"
+	"github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/storage"
+)
+
+// TokenService issues access tokens with refresh tokens and exchanges refresh tokens
+// for new access tokens. Refresh tokens are opaque random strings tracked in storage,
+// so they can be revoked. A refresh token never outlives the access token it was issued
+// with and is revoked together with it.
+type TokenService struct {
+	validator  *auth.JWTValidator
+	repo       storage.RefreshTokenRepository
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+// NewTokenService creates a new token service issuing access tokens valid for accessTTL
+// and refresh tokens valid for refreshTTL
+func NewTokenService(validator *auth.JWTValidator, repo storage.RefreshTokenRepository, accessTTL, refreshTTL time.Duration) *TokenService {
+	return &TokenService{
+		validator:  validator,
+		repo:       repo,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+	}
+}
+
+// IssueTokens issues an access and refresh token pair for the user authenticated with
+// issuingToken (empty for the demo user). The refresh token expires with issuingToken at
+// the latest and is rejected once issuingToken's jti is revoked.
+func (s *TokenService) IssueTokens(ctx context.Context, user *models.User, issuingToken string) (*models.TokenPair, error) {
+	now := s.now()
+	record := &models.RefreshToken{
+		User:      *user,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}
+	if issuingToken != "" {
+		claims, err := s.validator.ParseToken(issuingToken)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errors.ErrTokenInvalid, err)
+		}
+		record.TokenID = claims.ID
+		if claims.ExpiresAt != nil && claims.ExpiresAt.Before(record.ExpiresAt) {
+			record.ExpiresAt = claims.ExpiresAt.Time
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(secret)
+	if err := s.repo.Create(ctx, hashRefreshToken(refreshToken), record); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	pair, err := s.issueAccessToken(user, now, record.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	pair.RefreshToken = refreshToken
+	pair.RefreshExpiresIn = int(record.ExpiresAt.Sub(now).Seconds())
+	return pair, nil
+}
+
+// Refresh exchanges a refresh token for a new access token carrying the user claims
+// the refresh token was issued with
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	if refreshToken == "" {
+		return nil, errors.ErrRefreshTokenInvalid
+	}
+
+	record, err := s.repo.Get(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if err == errors.ErrNotFound {
+			return nil, errors.ErrRefreshTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	now := s.now()
+	switch {
+	case record.RevokedAt != nil:
+		return nil, errors.ErrRefreshTokenRevoked
+	case !now.Before(record.ExpiresAt):
+		return nil, errors.ErrRefreshTokenExpired
+	}
+
+	// Revoking the access token the refresh token was issued with revokes it as well
+	revoked, err := s.validator.IsTokenRevoked(ctx, record.TokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return nil, errors.ErrRefreshTokenRevoked
+	}
+
+	return s.issueAccessToken(&record.User, now, record.ExpiresAt)
+}
+
+// Revoke revokes a refresh token, reporting whether it was valid until now
+func (s *TokenService) Revoke(ctx context.Context, refreshToken string) (bool, error) {
+	if refreshToken == "" {
+		return false, nil
+	}
+	return s.repo.Revoke(ctx, hashRefreshToken(refreshToken), s.now())
+}
+
+// issueAccessToken signs an access token for the user, expiring by the refresh token's
+// expiry at the latest
+func (s *TokenService) issueAccessToken(user *models.User, now, refreshExpiresAt time.Time) (*models.TokenPair, error) {
+	ttl := s.accessTTL
+	if remaining := refreshExpiresAt.Sub(now); remaining < ttl {
+		ttl = remaining
+	}
+	accessToken, err := s.validator.IssueToken(user, now, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return &models.TokenPair{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+	}, nil
+}
+
+// hashRefreshToken returns the hex SHA-256 refresh tokens are stored by
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/internal/auth"
+	"github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func setupTokenService(t *testing.T) (*TokenService, *auth.JWTValidator) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client := storage.NewRedisClientWithUniversal(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	validator := auth.NewJWTValidator("test-secret")
+	validator.SetRevocationList(storage.NewRedisRevokedTokenRepository(client))
+	return NewTokenService(validator, storage.NewRedisRefreshTokenRepository(client), 15*time.Minute, time.Hour), validator
+}
+
+func TestTokenService_Refresh(t *testing.T) {
+	ctx := context.Background()
+	service, validator := setupTokenService(t)
+	user := &models.User{ID: "user-1", Username: "alice", Plan: "pro"}
+
+	pair, err := service.IssueTokens(ctx, user, "")
+	if err != nil {
+		t.Fatalf("IssueTokens failed: %v", err)
+	}
+	if pair.RefreshToken == "" || pair.RefreshExpiresIn != 3600 || pair.ExpiresIn != 900 {
+		t.Fatalf("Unexpected token pair: %+v", pair)
+	}
+
+	refreshed, err := service.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if refreshed.RefreshToken != "" {
+		t.Error("Expected refresh to return an access token only")
+	}
+	got, err := validator.ValidateToken(refreshed.AccessToken)
+	if err != nil {
+		t.Fatalf("Refreshed access token is invalid: %v", err)
+	}
+	if got.ID != user.ID || got.Username != user.Username || got.Plan != user.Plan {
+		t.Errorf("Expected claims of %+v, got %+v", user, got)
+	}
+}
+
+func TestTokenService_RefreshRejected(t *testing.T) {
+	ctx := context.Background()
+	service, _ := setupTokenService(t)
+	user := &models.User{ID: "user-1"}
+
+	if _, err := service.Refresh(ctx, "unknown"); err != errors.ErrRefreshTokenInvalid {
+		t.Errorf("Expected ErrRefreshTokenInvalid for an unknown token, got %v", err)
+	}
+
+	expiring, err := service.IssueTokens(ctx, user, "")
+	if err != nil {
+		t.Fatalf("IssueTokens failed: %v", err)
+	}
+	service.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := service.Refresh(ctx, expiring.RefreshToken); err != errors.ErrRefreshTokenExpired {
+		t.Errorf("Expected ErrRefreshTokenExpired, got %v", err)
+	}
+	service.now = time.Now
+
+	revoked, err := service.IssueTokens(ctx, user, "")
+	if err != nil {
+		t.Fatalf("IssueTokens failed: %v", err)
+	}
+	if ok, err := service.Revoke(ctx, revoked.RefreshToken); err != nil || !ok {
+		t.Fatalf("Expected revoke to succeed, got %v, %v", ok, err)
+	}
+	if _, err := service.Refresh(ctx, revoked.RefreshToken); err != errors.ErrRefreshTokenRevoked {
+		t.Errorf("Expected ErrRefreshTokenRevoked, got %v", err)
+	}
+	if ok, err := service.Revoke(ctx, revoked.RefreshToken); err != nil || ok {
+		t.Errorf("Expected second revoke to report an already revoked token, got %v, %v", ok, err)
+	}
+}
+
+func TestTokenService_RefreshBoundToIssuingToken(t *testing.T) {
+	ctx := context.Background()
+	service, validator := setupTokenService(t)
+	user := &models.User{ID: "user-1", Plan: "pro"}
+
+	issuing, err := validator.IssueToken(user, time.Now(), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+	pair, err := service.IssueTokens(ctx, user, "Bearer "+issuing)
+	if err != nil {
+		t.Fatalf("IssueTokens failed: %v", err)
+	}
+
+	// The refresh token can't outlive the token it was issued with
+	if pair.RefreshExpiresIn > 600 || pair.ExpiresIn > 600 {
+		t.Errorf("Expected the pair to expire with the issuing token, got %+v", pair)
+	}
+	if _, err := service.Refresh(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	// Revoking the issuing token revokes the refresh token
+	if _, err := validator.RevokeToken(ctx, issuing, time.Now()); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if _, err := service.Refresh(ctx, pair.RefreshToken); err != errors.ErrRefreshTokenRevoked {
+		t.Errorf("Expected ErrRefreshTokenRevoked, got %v", err)
+	}
+
+	// A revoked token can't issue new pairs
+	if _, err := service.IssueTokens(ctx, user, issuing); !stderrors.Is(err, errors.ErrTokenInvalid) {
+		t.Errorf("Expected ErrTokenInvalid for a revoked token, got %v", err)
+	}
+}
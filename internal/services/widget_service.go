@@ -2,13 +2,23 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/ad/leads-core/internal/errors"
 	"github.com/ad/leads-core/internal/models"
 	"github.com/ad/leads-core/internal/storage"
+	"github.com/ad/leads-core/internal/transform"
+	"github.com/ad/leads-core/internal/validation"
 	"github.com/ad/leads-core/pkg/logger"
+	"github.com/ad/leads-core/pkg/metrics"
 	"github.com/google/uuid"
 )
 
@@ -18,13 +28,35 @@ type WidgetService struct {
 	submissionRepo storage.SubmissionRepository
 	statsRepo      storage.StatsRepository
 	config         TTLConfig
+	typeRegistry   *models.TypeRegistry
+	defaultRegion  string
+	geoLocator     GeoLocator
+	maxFieldLength int
+	notifications  *NotificationService
+	maxWidgets     int
+	locks          storage.LockRepository
+	lockTTL        time.Duration
+	maxNameLength  int
+	uniqueNames    bool
+	preferences    storage.PreferencesRepository
+	clientMaxAge   time.Duration
+	clientMaxSkew  time.Duration
+	reservedFields models.ReservedFieldNames
+	reservedMode   string
+	pausedTypes    storage.PausedTypesRepository
+	tailMaxWait    time.Duration
+	formTokens     *FormTokenSigner
+	historyLimit   int
 }
 
 // TTLConfig holds TTL configuration
 type TTLConfig struct {
-	DemoDays int
-	FreeDays int
-	ProDays  int
+	DemoDays          int
+	FreeDays          int
+	ProDays           int
+	ExpireDemoWidgets bool // Demo-plan widgets (and their data) expire after DemoDays
+	PIIRetentionDays  int  // Default age after which configured PII fields are redacted
+	DailyStatsDays    int  // How long daily stats counters are kept (0 = storage default)
 }
 
 // NewWidgetService creates a new widget service
@@ -39,9 +71,297 @@ func NewWidgetService(
 		submissionRepo: submissionRepo,
 		statsRepo:      statsRepo,
 		config:         ttlConfig,
+		geoLocator:     NoopGeoLocator{},
 	}
 }
 
+// SetRegion sets the region recorded on new submissions. The geo locator result
+// takes precedence over the configured default region when it resolves one.
+func (s *WidgetService) SetRegion(defaultRegion string, locator GeoLocator) {
+	if locator == nil {
+		locator = NoopGeoLocator{}
+	}
+	s.defaultRegion = defaultRegion
+	s.geoLocator = locator
+}
+
+// resolveRegion determines the data residency region for a submission
+func (s *WidgetService) resolveRegion(clientIP string) string {
+	if clientIP != "" {
+		if region := s.geoLocator.Locate(clientIP); region != "" {
+			return region
+		}
+	}
+	return s.defaultRegion
+}
+
+// checkGeoRestrictions rejects clients whose country the widget doesn't accept. The
+// country comes from the geo locator, so nothing is blocked while geo lookup is disabled.
+func (s *WidgetService) checkGeoRestrictions(widget *models.Widget, clientIP string) error {
+	restrictions := widget.GeoRestrictions()
+	if restrictions.IsEmpty() || clientIP == "" {
+		return nil
+	}
+
+	country := s.geoLocator.Locate(clientIP)
+	if !restrictions.Allows(country) {
+		metrics.Inc("submissions_geo_blocked_total", nil, "Total public submissions rejected by widget geo restrictions")
+		return &models.GeoBlockedError{Country: strings.ToUpper(country)}
+	}
+	return nil
+}
+
+// SetTypeRegistry sets the per-plan widget type registry (nil allows all types)
+func (s *WidgetService) SetTypeRegistry(registry *models.TypeRegistry) {
+	s.typeRegistry = registry
+}
+
+// SetMaxFieldLength sets the default max length of submission field values (0 disables the check).
+// Widgets may override it per field in their config.
+func (s *WidgetService) SetMaxFieldLength(maxLength int) {
+	s.maxFieldLength = maxLength
+}
+
+// SetClientTimeWindow sets how far in the past (maxAge) and future (maxSkew) a client
+// occurred_at may be. Client timestamps are rejected until a window is set.
+func (s *WidgetService) SetClientTimeWindow(maxAge, maxSkew time.Duration) {
+	s.clientMaxAge = maxAge
+	s.clientMaxSkew = maxSkew
+}
+
+// SetFormTokenSigner enables form tokens: the minimum fill time of widget policies is
+// then checked against the signed render time instead of the client's started_at
+func (s *WidgetService) SetFormTokenSigner(signer *FormTokenSigner) {
+	s.formTokens = signer
+}
+
+// IssueFormToken returns a form token of the widget rendered now
+func (s *WidgetService) IssueFormToken(ctx context.Context, widgetID string) (*models.FormToken, error) {
+	if s.formTokens == nil {
+		return nil, errors.ErrFormTokensDisabled
+	}
+	widget, err := s.widgetRepo.GetByID(ctx, widgetID)
+	if err != nil {
+		return nil, errors.ErrNotFound
+	}
+	if widget.Archived {
+		return nil, errors.ErrWidgetArchived
+	}
+
+	now := time.Now()
+	return &models.FormToken{Token: s.formTokens.Issue(widgetID, now), IssuedAt: now.Truncate(time.Second)}, nil
+}
+
+// SetReservedFieldNames protects submission attributes from data fields with the same
+// names: depending on mode (models.ReservedFields*) such submissions are rejected or the
+// fields are stored prefixed. Nothing is checked until names are set.
+func (s *WidgetService) SetReservedFieldNames(names []string, mode string) {
+	s.reservedFields = models.NewReservedFieldNames(names)
+	s.reservedMode = mode
+}
+
+// checkReservedFields rejects or renames data fields with reserved names
+func (s *WidgetService) checkReservedFields(data map[string]interface{}) error {
+	switch s.reservedMode {
+	case models.ReservedFieldsReject:
+		if fieldErrs := s.reservedFields.Validate(data); len(fieldErrs) > 0 {
+			return fieldErrs
+		}
+	case models.ReservedFieldsPrefix:
+		s.reservedFields.Prefix(data)
+	}
+	return nil
+}
+
+// SetPausedTypesRepository enables pausing submissions for whole widget types
+func (s *WidgetService) SetPausedTypesRepository(pausedTypes storage.PausedTypesRepository) {
+	s.pausedTypes = pausedTypes
+}
+
+// PauseWidgetType blocks submissions to all widgets of the type until it is resumed
+func (s *WidgetService) PauseWidgetType(ctx context.Context, widgetType string) error {
+	if s.pausedTypes == nil {
+		return fmt.Errorf("pausing widget types is not enabled")
+	}
+	return s.pausedTypes.Pause(ctx, widgetType)
+}
+
+// ResumeWidgetType lets widgets of the type accept submissions again, reporting
+// whether the type was paused
+func (s *WidgetService) ResumeWidgetType(ctx context.Context, widgetType string) (bool, error) {
+	if s.pausedTypes == nil {
+		return false, nil
+	}
+	return s.pausedTypes.Resume(ctx, widgetType)
+}
+
+// ListPausedWidgetTypes returns the widget types with submissions paused
+func (s *WidgetService) ListPausedWidgetTypes(ctx context.Context) ([]*models.PausedWidgetType, error) {
+	if s.pausedTypes == nil {
+		return []*models.PausedWidgetType{}, nil
+	}
+	return s.pausedTypes.List(ctx)
+}
+
+// GetWidgetIndexMembership reports which index keys hold the widget, for diagnosing
+// index drift (admin endpoint, no ownership check)
+func (s *WidgetService) GetWidgetIndexMembership(ctx context.Context, widgetID string) (*models.WidgetIndexReport, error) {
+	return s.widgetRepo.GetIndexMembership(ctx, widgetID)
+}
+
+// RebuildWidgetIndexes reconstructs all widget indexes from the widget records, for
+// repairing index drift (admin endpoint)
+func (s *WidgetService) RebuildWidgetIndexes(ctx context.Context) error {
+	return s.widgetRepo.RebuildIndexes(ctx)
+}
+
+// isTypePaused reports whether submissions to widgets of the type are paused. Lookup
+// errors are logged and let the submission through.
+func (s *WidgetService) isTypePaused(ctx context.Context, widgetType string) bool {
+	if s.pausedTypes == nil {
+		return false
+	}
+	paused, err := s.pausedTypes.IsPaused(ctx, widgetType)
+	if err != nil {
+		logger.Error("failed to check whether widget type is paused", map[string]interface{}{
+			"type":  widgetType,
+			"error": err.Error(),
+		})
+		return false
+	}
+	return paused
+}
+
+// resolveCreatedAt returns the submission creation time and, when a client timestamp
+// is used, the server receipt time. occurred_at is ignored unless the widget allows it.
+func (s *WidgetService) resolveCreatedAt(widget *models.Widget, occurredAt *time.Time, now time.Time) (time.Time, *time.Time, error) {
+	if occurredAt == nil || !widget.AllowsClientTimestamps() {
+		return now, nil, nil
+	}
+
+	switch {
+	case occurredAt.After(now.Add(s.clientMaxSkew)):
+		return time.Time{}, nil, models.FieldErrors{{Field: "occurred_at", Message: "Timestamp is too far in the future"}}
+	case occurredAt.Before(now.Add(-s.clientMaxAge)):
+		return time.Time{}, nil, models.FieldErrors{{Field: "occurred_at", Message: "Timestamp is too far in the past"}}
+	}
+	return *occurredAt, &now, nil
+}
+
+// SetNotificationService sets the service notifying owners about new submissions (nil disables notifications)
+func (s *WidgetService) SetNotificationService(notifications *NotificationService) {
+	s.notifications = notifications
+}
+
+// SetWidgetLimit caps the number of widgets a user may own (0 disables the cap).
+// The count check and the create run under a per-user lock held for at most lockTTL,
+// so parallel creates can't overshoot the cap.
+func (s *WidgetService) SetWidgetLimit(maxWidgets int, locks storage.LockRepository, lockTTL time.Duration) {
+	s.maxWidgets = maxWidgets
+	s.locks = locks
+	s.lockTTL = lockTTL
+}
+
+// SetWidgetNameRules sets the max widget name length in characters (0 disables the check)
+// and whether a user's widget names must be unique (compared case-insensitively)
+func (s *WidgetService) SetWidgetNameRules(maxLength int, unique bool) {
+	s.maxNameLength = maxLength
+	s.uniqueNames = unique
+}
+
+// SetTailMaxWait sets how long a submissions tail request is held open waiting for
+// new submissions
+func (s *WidgetService) SetTailMaxWait(maxWait time.Duration) {
+	s.tailMaxWait = maxWait
+}
+
+// SetConfigHistoryLimit sets how many replaced configs are kept per widget
+func (s *WidgetService) SetConfigHistoryLimit(limit int) {
+	s.historyLimit = limit
+}
+
+// validateNameLength reports a validation error when the name exceeds maxNameLength
+func (s *WidgetService) validateNameLength(name string) error {
+	if s.maxNameLength > 0 && utf8.RuneCountInString(name) > s.maxNameLength {
+		return models.FieldErrors{{
+			Field:   "name",
+			Message: fmt.Sprintf("String length must be less than or equal to %d", s.maxNameLength),
+		}}
+	}
+	return nil
+}
+
+// checkNameAvailable reports a validation error when another widget of the user has the name
+func (s *WidgetService) checkNameAvailable(ctx context.Context, userID, name, widgetID string) error {
+	existingID, err := s.widgetRepo.GetWidgetIDByName(ctx, userID, name)
+	if err != nil {
+		return fmt.Errorf("failed to check widget name: %w", err)
+	}
+	if existingID != "" && existingID != widgetID {
+		return models.FieldErrors{{
+			Field:   "name",
+			Message: "Widget name is already used",
+		}}
+	}
+	return nil
+}
+
+// lockUser acquires the user's lock, waiting up to lockTTL for a concurrent holder,
+// and returns the function releasing it
+func (s *WidgetService) lockUser(ctx context.Context, userID string) (func(), error) {
+	const retryInterval = 10 * time.Millisecond
+
+	deadline := time.Now().Add(s.lockTTL)
+	for {
+		token, ok, err := s.locks.AcquireUserLock(ctx, userID, s.lockTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire user lock: %w", err)
+		}
+		if ok {
+			return func() {
+				// Release even if the request was cancelled meanwhile
+				if err := s.locks.ReleaseUserLock(context.WithoutCancel(ctx), userID, token); err != nil {
+					logger.Error("failed to release user lock", map[string]interface{}{
+						"user_id": userID,
+						"error":   err.Error(),
+					})
+				}
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.ErrBusy
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// checkWidgetLimit reports ErrLimitReached when the user already owns maxWidgets widgets
+func (s *WidgetService) checkWidgetLimit(ctx context.Context, userID string) error {
+	_, total, err := s.widgetRepo.GetByUserID(ctx, userID, models.PaginationOptions{Page: 1, PerPage: 1})
+	if err != nil {
+		return fmt.Errorf("failed to count widgets: %w", err)
+	}
+	if total >= s.maxWidgets {
+		return errors.ErrLimitReached
+	}
+	return nil
+}
+
+// AllowedWidgetTypes returns the widget types available to the plan
+func (s *WidgetService) AllowedWidgetTypes(plan string) map[string]bool {
+	return s.typeRegistry.TypesForPlan(plan)
+}
+
+// IsWidgetTypeAllowed checks if the plan may use the widget type
+func (s *WidgetService) IsWidgetTypeAllowed(plan, widgetType string) bool {
+	return s.typeRegistry.IsAllowed(plan, widgetType)
+}
+
 // generateWidgetID generates a UUID v5 using user_id as namespace
 func (s *WidgetService) generateWidgetID(userID string) string {
 	// Create a namespace UUID from user_id
@@ -76,212 +396,1465 @@ func (s *WidgetService) CreateWidget(ctx context.Context, userID string, req mod
 	if req.Name == "" {
 		return nil, fmt.Errorf("widget name is required")
 	}
-	if req.Type == "" {
-		return nil, fmt.Errorf("widget type is required")
+	if req.Type == "" {
+		return nil, fmt.Errorf("widget type is required")
+	}
+	if err := s.validateNameLength(req.Name); err != nil {
+		return nil, err
+	}
+
+	// Generate UUID v5 using user_id as namespace
+	widgetID := s.generateWidgetID(userID)
+
+	// Create widget
+	widget := &models.Widget{
+		ID:        widgetID,
+		OwnerID:   userID,
+		Type:      req.Type,
+		Name:      req.Name,
+		Config:    req.Config,
+		Version:   1,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if req.Status != nil {
+		widget.SetStatus(*req.Status)
+	} else {
+		widget.SetVisible(req.IsVisible)
+	}
+
+	// Trial widgets don't accumulate forever
+	if s.config.ExpireDemoWidgets && req.Plan == "demo" && s.config.DemoDays > 0 {
+		expiresAt := models.DemoWidgetExpiry(widget.CreatedAt, s.config.DemoDays)
+		widget.ExpiresAt = &expiresAt
+	}
+
+	if s.maxWidgets > 0 || s.uniqueNames {
+		if s.locks != nil {
+			unlock, err := s.lockUser(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			defer unlock()
+		}
+
+		if s.maxWidgets > 0 {
+			if err := s.checkWidgetLimit(ctx, userID); err != nil {
+				return nil, err
+			}
+		}
+		if s.uniqueNames {
+			if err := s.checkNameAvailable(ctx, userID, widget.Name, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.widgetRepo.Create(ctx, widget); err != nil {
+		return nil, fmt.Errorf("failed to create widget: %w", err)
+	}
+
+	return widget, nil
+}
+
+// CleanupExpiredWidgets removes expired demo widgets from indexes
+func (s *WidgetService) CleanupExpiredWidgets(ctx context.Context) (int, error) {
+	return s.widgetRepo.CleanupExpiredWidgets(ctx, time.Now())
+}
+
+// StartExpiredWidgetsCleanup periodically removes expired demo widgets
+func (s *WidgetService) StartExpiredWidgetsCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info("Starting expired widgets cleanup", map[string]interface{}{
+		"interval": interval.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Expired widgets cleanup stopped")
+			return
+		case <-ticker.C:
+			cleaned, err := s.CleanupExpiredWidgets(ctx)
+			if err != nil {
+				logger.Error("failed to clean up expired widgets", map[string]interface{}{
+					"error": err.Error(),
+				})
+				continue
+			}
+			if cleaned > 0 {
+				logger.Info("Expired widgets cleaned up", map[string]interface{}{
+					"count": cleaned,
+				})
+			}
+		}
+	}
+}
+
+// RedactExpiredPII blanks configured PII fields of submissions older than the
+// widget's retention window, returning the number of submissions redacted
+func (s *WidgetService) RedactExpiredPII(ctx context.Context) (int, error) {
+	widgetIDs, err := s.widgetRepo.GetPIIWidgetIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get widgets with PII redaction: %w", err)
+	}
+
+	now := time.Now()
+	total := 0
+	for _, widgetID := range widgetIDs {
+		widget, err := s.widgetRepo.GetByID(ctx, widgetID)
+		if err != nil {
+			continue // Skip widgets that can't be loaded
+		}
+
+		fields, days := widget.PIISettings()
+		if days == 0 {
+			days = s.config.PIIRetentionDays
+		}
+		if len(fields) == 0 || days <= 0 {
+			continue
+		}
+
+		redacted, err := s.submissionRepo.RedactPII(ctx, widgetID, fields, now.AddDate(0, 0, -days))
+		total += redacted
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// StartPIIRedaction periodically redacts PII from submissions past their retention window
+func (s *WidgetService) StartPIIRedaction(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info("Starting PII redaction", map[string]interface{}{
+		"interval": interval.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("PII redaction stopped")
+			return
+		case <-ticker.C:
+			redacted, err := s.RedactExpiredPII(ctx)
+			if err != nil {
+				logger.Error("failed to redact PII", map[string]interface{}{
+					"error": err.Error(),
+				})
+				continue
+			}
+			if redacted > 0 {
+				logger.Info("PII redacted from submissions", map[string]interface{}{
+					"count": redacted,
+				})
+			}
+		}
+	}
+}
+
+// DisableInactiveWidgets hides visible widgets without views, submissions or updates
+// within the threshold, returning the number of widgets disabled. Owners re-enable them
+// as usual, the update restarting the threshold.
+func (s *WidgetService) DisableInactiveWidgets(ctx context.Context, threshold time.Duration) (int, error) {
+	widgetIDs, err := s.widgetRepo.GetVisibleWidgetIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get visible widgets: %w", err)
+	}
+
+	cutoff := time.Now().Add(-threshold)
+	disabled := 0
+	for _, widgetID := range widgetIDs {
+		widget, err := s.widgetRepo.GetByID(ctx, widgetID)
+		if err != nil || widget.Archived || widget.UpdatedAt.After(cutoff) {
+			continue // Skip widgets that can't be loaded or were recently updated
+		}
+
+		stats, err := s.statsRepo.GetWidgetStats(ctx, widgetID)
+		if err != nil {
+			return disabled, fmt.Errorf("failed to get stats of widget %s: %w", widgetID, err)
+		}
+		lastActivity := widget.UpdatedAt
+		if stats.LastActivity().After(lastActivity) {
+			lastActivity = stats.LastActivity()
+		}
+		if lastActivity.After(cutoff) {
+			continue
+		}
+
+		widget.SetVisible(false)
+		if err := s.widgetRepo.Update(ctx, widget); err != nil {
+			return disabled, fmt.Errorf("failed to disable widget %s: %w", widgetID, err)
+		}
+		disabled++
+
+		logger.Info("Inactive widget disabled", map[string]interface{}{
+			"widget_id":     widgetID,
+			"user_id":       widget.OwnerID,
+			"last_activity": lastActivity.Format(time.RFC3339),
+		})
+		metrics.Inc("widgets_auto_disabled_total", nil, "Total widgets disabled by the inactivity sweep")
+	}
+
+	return disabled, nil
+}
+
+// StartInactiveWidgetsSweep periodically disables widgets inactive for longer than the
+// threshold. It returns at once for a non-positive interval.
+func (s *WidgetService) StartInactiveWidgetsSweep(ctx context.Context, interval, threshold time.Duration) {
+	if interval <= 0 {
+		logger.Error("Inactive widgets sweep not started, interval must be positive", map[string]interface{}{
+			"interval": interval.String(),
+		})
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info("Starting inactive widgets sweep", map[string]interface{}{
+		"interval":  interval.String(),
+		"threshold": threshold.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Inactive widgets sweep stopped")
+			return
+		case <-ticker.C:
+			disabled, err := s.DisableInactiveWidgets(ctx, threshold)
+			if err != nil {
+				logger.Error("failed to disable inactive widgets", map[string]interface{}{
+					"error": err.Error(),
+				})
+				continue
+			}
+			if disabled > 0 {
+				logger.Info("Inactive widgets disabled", map[string]interface{}{
+					"count": disabled,
+				})
+			}
+		}
+	}
+}
+
+// GetWidget retrieves a widget by ID with ownership check
+func (s *WidgetService) GetWidget(ctx context.Context, widgetID, userID string) (*models.Widget, error) {
+	widget, err := s.widgetRepo.GetByID(ctx, widgetID)
+	if err != nil {
+		return nil, errors.ErrNotFound
+	}
+
+	// Check ownership
+	if widget.OwnerID != userID {
+		return nil, errors.ErrAccessDenied
+	}
+
+	return widget, nil
+}
+
+// GetWidgetsByIDs retrieves the given widgets owned by the user in request order.
+// Duplicate, missing and non-owned IDs are omitted from the result.
+func (s *WidgetService) GetWidgetsByIDs(ctx context.Context, userID string, widgetIDs []string) ([]*models.Widget, error) {
+	seen := make(map[string]bool, len(widgetIDs))
+	unique := make([]string, 0, len(widgetIDs))
+	for _, widgetID := range widgetIDs {
+		if widgetID == "" || seen[widgetID] {
+			continue
+		}
+		seen[widgetID] = true
+		unique = append(unique, widgetID)
+	}
+
+	widgets, err := s.widgetRepo.GetByIDs(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make([]*models.Widget, 0, len(widgets))
+	for _, widget := range widgets {
+		if widget.OwnerID == userID {
+			owned = append(owned, widget)
+		}
+	}
+
+	return owned, nil
+}
+
+// UpdateWidget updates an existing widget
+func (s *WidgetService) UpdateWidget(ctx context.Context, widgetID, userID string, req models.UpdateWidgetRequest) (*models.Widget, error) {
+	// Get existing widget
+	widget, err := s.GetWidget(ctx, widgetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if widget.Archived {
+		return nil, errors.ErrWidgetArchived
+	}
+
+	// Update fields
+	if req.Name != nil {
+		if err := s.validateNameLength(*req.Name); err != nil {
+			return nil, err
+		}
+		if s.uniqueNames && models.NormalizeWidgetName(*req.Name) != models.NormalizeWidgetName(widget.Name) {
+			if s.locks != nil {
+				unlock, err := s.lockUser(ctx, userID)
+				if err != nil {
+					return nil, err
+				}
+				defer unlock()
+			}
+			if err := s.checkNameAvailable(ctx, userID, *req.Name, widget.ID); err != nil {
+				return nil, err
+			}
+		}
+		widget.Name = *req.Name
+	}
+	if req.Type != nil {
+		widget.Type = *req.Type
+	}
+	if req.IsVisible != nil {
+		widget.SetVisible(*req.IsVisible)
+	}
+	if req.Status != nil {
+		widget.SetStatus(*req.Status)
+	}
+
+	widget.UpdatedAt = time.Now()
+
+	if err := s.widgetRepo.Update(ctx, widget); err != nil {
+		return nil, fmt.Errorf("failed to update widget: %w", err)
+	}
+
+	return widget, nil
+}
+
+// UpdateWidgetConfig updates the configuration of a widget
+func (s *WidgetService) UpdateWidgetConfig(ctx context.Context, widgetID, userID string, req *models.UpdateWidgetConfigRequest) (*models.Widget, error) {
+	// Check ownership first
+	widget, err := s.GetWidget(ctx, widgetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if widget.Archived {
+		return nil, errors.ErrWidgetArchived
+	}
+
+	if err := s.replaceConfig(ctx, widget, req.Config); err != nil {
+		return nil, err
+	}
+
+	return widget, nil
+}
+
+// DefaultConfigHistoryLimit is the number of replaced configs kept per widget when
+// none is configured
+const DefaultConfigHistoryLimit = 20
+
+// replaceConfig gives the widget a new config under the next version, keeping the
+// replaced one in the config history
+func (s *WidgetService) replaceConfig(ctx context.Context, widget *models.Widget, config map[string]interface{}) error {
+	limit := s.historyLimit
+	if limit <= 0 {
+		limit = DefaultConfigHistoryLimit
+	}
+	now := time.Now()
+	replaced := &models.WidgetConfigVersion{Version: widget.Version, Config: widget.Config, ReplacedAt: now}
+	if err := s.widgetRepo.PushConfigHistory(ctx, widget.ID, replaced, limit); err != nil {
+		return fmt.Errorf("failed to save config history: %w", err)
+	}
+
+	widget.Config = config
+	widget.Version++
+	widget.UpdatedAt = now
+
+	if err := s.widgetRepo.Update(ctx, widget); err != nil {
+		return fmt.Errorf("failed to update widget config: %w", err)
+	}
+	return nil
+}
+
+// GetConfigHistory returns the widget's replaced configs, newest first
+func (s *WidgetService) GetConfigHistory(ctx context.Context, widgetID, userID string) ([]*models.WidgetConfigVersion, error) {
+	// Check ownership
+	if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+		return nil, err
+	}
+
+	history, err := s.widgetRepo.GetConfigHistory(ctx, widgetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config history: %w", err)
+	}
+	return history, nil
+}
+
+// RestoreWidgetConfig reinstates the config the widget had in the given version from
+// its config history. The restored config becomes a new version, and the one it
+// replaces goes to the history like on any config update.
+func (s *WidgetService) RestoreWidgetConfig(ctx context.Context, widgetID, userID string, version int) (*models.Widget, error) {
+	widget, err := s.GetWidget(ctx, widgetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if widget.Archived {
+		return nil, errors.ErrWidgetArchived
+	}
+
+	history, err := s.widgetRepo.GetConfigHistory(ctx, widgetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config history: %w", err)
+	}
+	var restored *models.WidgetConfigVersion
+	for _, entry := range history {
+		if entry.Version == version {
+			restored = entry
+			break
+		}
+	}
+	if restored == nil {
+		return nil, errors.ErrConfigVersionNotFound
+	}
+
+	if err := s.replaceConfig(ctx, widget, restored.Config); err != nil {
+		return nil, err
+	}
+	return widget, nil
+}
+
+// SetWidgetArchived archives or unarchives a widget. Archiving keeps the widget and its
+// data but leaves it out of listings and makes it read-only; repeating it is a no-op.
+func (s *WidgetService) SetWidgetArchived(ctx context.Context, widgetID, userID string, archived bool) (*models.Widget, error) {
+	widget, err := s.GetWidget(ctx, widgetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if widget.Archived == archived {
+		return widget, nil
+	}
+
+	now := time.Now()
+	widget.Archived = archived
+	widget.ArchivedAt = nil
+	if archived {
+		widget.ArchivedAt = &now
+	}
+	widget.UpdatedAt = now
+
+	if err := s.widgetRepo.Update(ctx, widget); err != nil {
+		return nil, fmt.Errorf("failed to update widget archival: %w", err)
+	}
+
+	return widget, nil
+}
+
+// DeleteWidget deletes a widget
+func (s *WidgetService) DeleteWidget(ctx context.Context, widgetID, userID string) error {
+	// Check ownership first
+	_, err := s.GetWidget(ctx, widgetID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.widgetRepo.Delete(ctx, widgetID); err != nil {
+		return fmt.Errorf("failed to delete widget: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserWidgets retrieves widgets for a user with pagination and optional filtering
+func (s *WidgetService) GetUserWidgets(ctx context.Context, userID string, opts models.PaginationOptions) ([]*models.Widget, int, error) {
+	// Validate and clean filter options if provided
+	if opts.Filters != nil {
+		opts.Filters = models.ValidateFilterOptions(opts.Filters)
+	}
+
+	// Use the filtering-capable repository method
+	widgets, total, err := s.widgetRepo.GetByUserIDWithFilters(ctx, userID, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get user widgets: %w", err)
+	}
+
+	return widgets, total, nil
+}
+
+// GetUserWidgetsWithStats retrieves widgets for a user with pagination, filtering and type statistics
+func (s *WidgetService) GetUserWidgetsWithStats(ctx context.Context, userID string, opts models.PaginationOptions) ([]*models.Widget, int, []*models.TypeStats, error) {
+	// Get widgets
+	widgets, total, err := s.GetUserWidgets(ctx, userID, opts)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	// Get type statistics
+	typeStats, err := s.widgetRepo.GetTypeStats(ctx, userID)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to get type stats: %w", err)
+	}
+
+	return widgets, total, typeStats, nil
+}
+
+// GetWidgetStats retrieves statistics for a widget, with previewFields of the latest
+// submission when given
+func (s *WidgetService) GetWidgetStats(ctx context.Context, widgetID, userID string, previewFields []string) (*models.WidgetStats, error) {
+	// Check ownership
+	widget, err := s.GetWidget(ctx, widgetID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.statsRepo.GetWidgetStats(ctx, widgetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get widget stats: %w", err)
+	}
+
+	// The preview costs a submission read, so it's only done on request and when the
+	// stats recorded a submission at all
+	if len(previewFields) > 0 && !stats.LastSubmit.IsZero() {
+		latest, _, err := s.submissionRepo.GetByWidgetID(ctx, widgetID, models.PaginationOptions{Page: 1, PerPage: 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest submission: %w", err)
+		}
+		if len(latest) > 0 {
+			stats.Preview = latest[0].Project(widget.PreviewFields(previewFields))
+		}
+	}
+
+	return stats, nil
+}
+
+// GetWidgetSubmissions retrieves submissions for a widget
+func (s *WidgetService) GetWidgetSubmissions(ctx context.Context, widgetID, userID string, opts models.PaginationOptions) ([]*models.Submission, int, error) {
+	// Check ownership
+	_, err := s.GetWidget(ctx, widgetID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	submissions, total, err := s.submissionRepo.GetByWidgetID(ctx, widgetID, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get widget submissions: %w", err)
+	}
+
+	return submissions, total, nil
+}
+
+// GetWidgetSubmissionsAfter retrieves a page of widget submissions following the cursor
+// (from the newest without one), with the total count and the next page's cursor
+func (s *WidgetService) GetWidgetSubmissionsAfter(ctx context.Context, widgetID, userID string, cursor *models.SubmissionCursor, limit int) ([]*models.Submission, int, *models.SubmissionCursor, error) {
+	// Check ownership
+	if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+		return nil, 0, nil, err
+	}
+
+	submissions, total, next, err := s.submissionRepo.GetByWidgetIDAfter(ctx, widgetID, cursor, limit)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to get widget submissions: %w", err)
+	}
+
+	return submissions, total, next, nil
+}
+
+// Submission tail bounds
+const (
+	MaxTailSubmissions  = 100
+	DefaultTailMaxWait  = 25 * time.Second
+	tailPollingInterval = 500 * time.Millisecond
+)
+
+// TailSubmissions waits up to the configured max wait for submissions created after
+// the cursor (a Unix time in seconds) and returns them, oldest first, as soon as any
+// exist; on timeout the result is empty. Only whole past seconds are read, so
+// submissions arriving later within the same second are not skipped by the cursor.
+func (s *WidgetService) TailSubmissions(ctx context.Context, widgetID, userID string, cursor int64) (*models.SubmissionTail, error) {
+	// Check ownership
+	if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+		return nil, err
+	}
+
+	maxWait := s.tailMaxWait
+	if maxWait <= 0 {
+		maxWait = DefaultTailMaxWait
+	}
+	timeout := time.NewTimer(maxWait)
+	defer timeout.Stop()
+	ticker := time.NewTicker(tailPollingInterval)
+	defer ticker.Stop()
+
+	for {
+		settled := time.Now().Unix() - 1
+		if settled > cursor {
+			submissions, err := s.submissionRepo.GetCreatedBetween(ctx, widgetID, cursor, settled, MaxTailSubmissions)
+			if err != nil {
+				return nil, fmt.Errorf("failed to tail widget submissions: %w", err)
+			}
+			if len(submissions) == 0 {
+				cursor = settled
+			} else {
+				return newSubmissionTail(submissions, settled), nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return newSubmissionTail(nil, cursor), nil
+		case <-timeout.C:
+			return newSubmissionTail(nil, cursor), nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// newSubmissionTail builds a tail result. A full page may end partway through a
+// second, so its trailing submissions from that second are left for the next call.
+func newSubmissionTail(submissions []*models.Submission, settled int64) *models.SubmissionTail {
+	cursor := settled
+	if len(submissions) >= MaxTailSubmissions {
+		last := submissions[len(submissions)-1].CreatedAt.Unix()
+		end := len(submissions)
+		for end > 0 && submissions[end-1].CreatedAt.Unix() == last {
+			end--
+		}
+		if end > 0 {
+			submissions = submissions[:end]
+			last = submissions[end-1].CreatedAt.Unix()
+		}
+		cursor = last
+	}
+	if submissions == nil {
+		submissions = []*models.Submission{}
+	}
+	return &models.SubmissionTail{
+		Submissions: submissions,
+		Cursor:      strconv.FormatInt(cursor, 10),
+	}
+}
+
+// GetSubmissionByCorrelation retrieves the latest submission whose correlation field
+// (set in the widget config) holds the value
+func (s *WidgetService) GetSubmissionByCorrelation(ctx context.Context, widgetID, userID, value string) (*models.Submission, error) {
+	// Check ownership
+	if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.submissionRepo.GetByCorrelation(ctx, widgetID, value)
+}
+
+// DeleteSubmission deletes a submission of the user's widget, e.g. spam or a test
+func (s *WidgetService) DeleteSubmission(ctx context.Context, widgetID, userID, submissionID string) error {
+	// Check ownership
+	if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+		return err
+	}
+
+	return s.submissionRepo.Delete(ctx, widgetID, submissionID)
+}
+
+// DeleteSubmissionsInRange deletes the submissions of the user's widget created between
+// from and to, unbounded when zero, and returns the number deleted
+func (s *WidgetService) DeleteSubmissionsInRange(ctx context.Context, widgetID, userID string, from, to time.Time) (int, error) {
+	// Check ownership
+	if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.submissionRepo.DeleteByRange(ctx, widgetID, from, to)
+	if err != nil {
+		return deleted, fmt.Errorf("failed to delete submissions: %w", err)
+	}
+	return deleted, nil
+}
+
+// Submission sample bounds for schema inference
+const (
+	DefaultSchemaSampleSize = 200
+	MaxSchemaSampleSize     = 1000
+)
+
+// InferSubmissionSchema infers field names and types from the newest submissions of a
+// widget, scanning at most sampleSize of them (DefaultSchemaSampleSize when not positive)
+func (s *WidgetService) InferSubmissionSchema(ctx context.Context, widgetID, userID string, sampleSize int) (*models.InferredSchema, error) {
+	// Check ownership
+	if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+		return nil, err
+	}
+
+	if sampleSize <= 0 {
+		sampleSize = DefaultSchemaSampleSize
+	}
+	if sampleSize > MaxSchemaSampleSize {
+		sampleSize = MaxSchemaSampleSize
+	}
+
+	fields := make(map[string]*models.InferredField)
+	scanned := 0
+	for page := 1; scanned < sampleSize; page++ {
+		opts := models.PaginationOptions{Page: page, PerPage: min(sampleSize-scanned, 100)}
+		submissions, total, err := s.submissionRepo.GetByWidgetID(ctx, widgetID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get widget submissions: %w", err)
+		}
+		if len(submissions) > sampleSize-scanned {
+			submissions = submissions[:sampleSize-scanned]
+		}
+
+		for _, submission := range submissions {
+			for name, value := range submission.Data {
+				field, ok := fields[name]
+				if !ok {
+					field = &models.InferredField{Name: name, Types: make(map[string]int)}
+					fields[name] = field
+				}
+				field.Count++
+				field.Types[jsonType(value)]++
+			}
+		}
+		scanned += len(submissions)
+
+		if len(submissions) < opts.PerPage || page*opts.PerPage >= total {
+			break
+		}
+	}
+
+	schema := &models.InferredSchema{
+		WidgetID:   widgetID,
+		SampleSize: scanned,
+		Fields:     make([]*models.InferredField, 0, len(fields)),
+	}
+	for _, field := range fields {
+		for fieldType, count := range field.Types {
+			if count > field.Types[field.Type] || (count == field.Types[field.Type] && fieldType < field.Type) {
+				field.Type = fieldType
+			}
+		}
+		schema.Fields = append(schema.Fields, field)
+	}
+	sort.Slice(schema.Fields, func(i, j int) bool {
+		return schema.Fields[i].Name < schema.Fields[j].Name
+	})
+
+	return schema, nil
+}
+
+// MaxHeatmapScan bounds the number of submissions scanned for a heatmap
+const MaxHeatmapScan = 10000
+
+// GetSubmissionHeatmap buckets the widget's submissions created within [from, to] by
+// weekday and hour in loc, scanning newest first and at most MaxHeatmapScan of them
+func (s *WidgetService) GetSubmissionHeatmap(ctx context.Context, widgetID, userID string, from, to *time.Time, loc *time.Location) (*models.SubmissionHeatmap, error) {
+	// Check ownership
+	if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+		return nil, err
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+	heatmap := &models.SubmissionHeatmap{
+		WidgetID: widgetID,
+		Timezone: loc.String(),
+		From:     from,
+		To:       to,
+	}
+
+	truncated, err := s.scanSubmissionsInRange(ctx, widgetID, from, to, func(submission *models.Submission) {
+		local := submission.CreatedAt.In(loc)
+		heatmap.Counts[local.Weekday()][local.Hour()]++
+		heatmap.Total++
+	})
+	if err != nil {
+		return nil, err
+	}
+	heatmap.Truncated = truncated
+
+	return heatmap, nil
+}
+
+// MaxStatsSeriesDays bounds the number of days in a stats time series
+const MaxStatsSeriesDays = 366
+
+// DailyStatsDays returns how many days daily stats counters are kept for
+func (s *WidgetService) DailyStatsDays() int {
+	if s.config.DailyStatsDays > 0 {
+		return s.config.DailyStatsDays
+	}
+	return storage.DefaultDailyStatsDays
+}
+
+// GetWidgetStatsSeries returns the widget's daily counts of the metric for the days
+// overlapping [from, to]. Days follow the server's time zone the counters are kept in;
+// days past the retention window count 0.
+func (s *WidgetService) GetWidgetStatsSeries(ctx context.Context, widgetID, userID, metric string, from, to time.Time) ([]models.DailyStat, error) {
+	// Check ownership
+	if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.statsRepo.GetDailySeries(ctx, widgetID, metric, from.In(time.Local), to.In(time.Local))
+}
+
+// MaxDistributionBuckets bounds the number of distinct values returned in a field
+// distribution, less frequent values are counted as other
+const MaxDistributionBuckets = 50
+
+// GetFieldDistribution counts the widget's submissions created within [from, to] by the
+// value of the field, scanning newest first and at most MaxHeatmapScan of them. Each
+// element of list values counts, e.g. for multi-select fields.
+func (s *WidgetService) GetFieldDistribution(ctx context.Context, widgetID, userID, field string, from, to *time.Time) (*models.FieldDistribution, error) {
+	// Check ownership
+	if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+		return nil, err
+	}
+
+	distribution := &models.FieldDistribution{
+		WidgetID: widgetID,
+		Field:    field,
+		From:     from,
+		To:       to,
+	}
+
+	counts := make(map[string]int)
+	truncated, err := s.scanSubmissionsInRange(ctx, widgetID, from, to, func(submission *models.Submission) {
+		distribution.Total++
+		values := distributionValues(submission.Data[field])
+		if len(values) == 0 {
+			distribution.Missing++
+			return
+		}
+		for _, value := range values {
+			counts[value]++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	distribution.Truncated = truncated
+
+	// Keep the most frequent values, ties broken by value for stable results
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	distribution.Buckets = make(map[string]int, min(len(values), MaxDistributionBuckets))
+	for i, value := range values {
+		if i < MaxDistributionBuckets {
+			distribution.Buckets[value] = counts[value]
+		} else {
+			distribution.Other += counts[value]
+		}
+	}
+
+	return distribution, nil
+}
+
+// distributionValues returns the bucket keys of a submitted value, none for blank values
+// and objects
+func distributionValues(value interface{}) []string {
+	switch v := value.(type) {
+	case nil, map[string]interface{}:
+		return nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if _, nested := item.([]interface{}); !nested {
+				values = append(values, distributionValues(item)...)
+			}
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// scanSubmissionsInRange calls fn for the widget's submissions created within [from, to],
+// newest first, scanning at most MaxHeatmapScan of them. It reports whether the cap was
+// hit before the range was covered.
+func (s *WidgetService) scanSubmissionsInRange(ctx context.Context, widgetID string, from, to *time.Time, fn func(*models.Submission)) (bool, error) {
+	const perPage = 100
+	scanned := 0
+	for page := 1; ; page++ {
+		submissions, total, err := s.submissionRepo.GetByWidgetID(ctx, widgetID, models.PaginationOptions{Page: page, PerPage: perPage})
+		if err != nil {
+			return false, fmt.Errorf("failed to get widget submissions: %w", err)
+		}
+
+		for _, submission := range submissions {
+			if scanned >= MaxHeatmapScan {
+				return true, nil
+			}
+			scanned++
+
+			if to != nil && submission.CreatedAt.After(*to) {
+				continue
+			}
+			// Submissions come newest first, the rest are older still
+			if from != nil && submission.CreatedAt.Before(*from) {
+				return false, nil
+			}
+
+			fn(submission)
+		}
+
+		if len(submissions) < perPage || page*perPage >= total {
+			return false, nil
+		}
+	}
+}
+
+// CompareWidgets compares the stats of two widgets owned by the user. Without a time
+// range the all-time counters are compared; with one, submissions are counted within
+// [from, to] and views by whole days, as far back as daily view counters are kept.
+func (s *WidgetService) CompareWidgets(ctx context.Context, userID, widgetA, widgetB string, from, to *time.Time) (*models.WidgetComparison, error) {
+	// Stats come with the batch-loaded widgets
+	widgets, err := s.widgetRepo.GetByIDs(ctx, []string{widgetA, widgetB})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.Widget, len(widgets))
+	for _, widget := range widgets {
+		byID[widget.ID] = widget
+	}
+
+	comparison := &models.WidgetComparison{From: from, To: to}
+	var truncatedA, truncatedB bool
+	if comparison.A, truncatedA, err = s.compareWidget(ctx, byID[widgetA], widgetA, userID, from, to); err != nil {
+		return nil, err
+	}
+	if comparison.B, truncatedB, err = s.compareWidget(ctx, byID[widgetB], widgetB, userID, from, to); err != nil {
+		return nil, err
+	}
+	comparison.Truncated = truncatedA || truncatedB
+
+	if comparison.A.ConversionRate > 0 {
+		lift := (comparison.B.ConversionRate - comparison.A.ConversionRate) / comparison.A.ConversionRate
+		comparison.Lift = &lift
+	}
+
+	return comparison, nil
+}
+
+// compareWidget checks ownership of a batch-loaded widget (nil when missing) and
+// collects its side of a comparison
+func (s *WidgetService) compareWidget(ctx context.Context, widget *models.Widget, widgetID, userID string, from, to *time.Time) (*models.ComparedWidget, bool, error) {
+	if widget == nil {
+		return nil, false, fmt.Errorf("widget %s: %w", widgetID, errors.ErrNotFound)
+	}
+	if widget.OwnerID != userID {
+		return nil, false, fmt.Errorf("widget %s: %w", widgetID, errors.ErrAccessDenied)
+	}
+
+	stats := &models.WidgetStats{WidgetID: widget.ID}
+	truncated := false
+	if from == nil && to == nil {
+		if widget.Stats != nil {
+			stats = widget.Stats
+		}
+	} else {
+		var err error
+		truncated, err = s.scanSubmissionsInRange(ctx, widget.ID, from, to, func(*models.Submission) {
+			stats.Submits++
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		if stats.Views, err = s.countDailyViews(ctx, widget.ID, from, to); err != nil {
+			return nil, false, err
+		}
+	}
+
+	compared := &models.ComparedWidget{
+		WidgetID:       widget.ID,
+		Name:           widget.Name,
+		Type:           widget.Type,
+		Views:          stats.Views,
+		Submits:        stats.Submits,
+		ConversionRate: stats.ConversionRate(),
+	}
+	if from == nil && to == nil {
+		compared.Closes = &stats.Closes
+	}
+	return compared, truncated, nil
+}
+
+// countDailyViews sums the daily view counters of the days overlapping [from, to],
+// limited to the days the counters are kept for
+func (s *WidgetService) countDailyViews(ctx context.Context, widgetID string, from, to *time.Time) (int64, error) {
+	now := time.Now()
+	end := now
+	if to != nil && to.Before(now) {
+		end = to.In(now.Location())
+	}
+	oldest := now.AddDate(0, 0, -(s.DailyStatsDays() - 1))
+	start := oldest
+	if from != nil && from.After(oldest) {
+		start = from.In(now.Location())
+	}
+
+	var views int64
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, now.Location())
+	for ; !day.After(end); day = day.AddDate(0, 0, 1) {
+		count, err := s.statsRepo.GetDailyViews(ctx, widgetID, day.Format("2006-01-02"))
+		if err != nil {
+			return 0, fmt.Errorf("failed to get daily views: %w", err)
+		}
+		views += count
+	}
+	return views, nil
+}
+
+// jsonType returns the JSON type name of a decoded submission value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64, int:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// GetWidgetSubmissionsInRegion retrieves submissions for a widget recorded in the given region
+func (s *WidgetService) GetWidgetSubmissionsInRegion(ctx context.Context, widgetID, userID, region string, opts models.PaginationOptions) ([]*models.Submission, int, error) {
+	// Check ownership
+	_, err := s.GetWidget(ctx, widgetID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Region is not indexed, so filter over all submissions and paginate in memory
+	allSubmissions, _, err := s.submissionRepo.GetByWidgetID(ctx, widgetID, models.PaginationOptions{
+		Page:    1,
+		PerPage: 10000,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get widget submissions: %w", err)
+	}
+
+	filtered := make([]*models.Submission, 0, len(allSubmissions))
+	for _, submission := range allSubmissions {
+		if strings.EqualFold(submission.Region, region) {
+			filtered = append(filtered, submission)
+		}
+	}
+
+	total := len(filtered)
+	start := (opts.Page - 1) * opts.PerPage
+	if start >= total {
+		return []*models.Submission{}, total, nil
+	}
+	end := start + opts.PerPage
+	if end > total {
+		end = total
 	}
 
-	// Generate UUID v5 using user_id as namespace
-	widgetID := s.generateWidgetID(userID)
+	return filtered[start:end], total, nil
+}
 
-	// Create widget
-	widget := &models.Widget{
-		ID:        widgetID,
-		OwnerID:   userID,
-		Type:      req.Type,
-		Name:      req.Name,
-		IsVisible: req.IsVisible,
-		Config:    req.Config,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+// SearchWidgetSubmissions retrieves the page of a widget's submissions matching the
+// filter and the number of matches, scanning all submissions of the widget
+func (s *WidgetService) SearchWidgetSubmissions(ctx context.Context, widgetID, userID string, filter models.SubmissionFilter, opts models.PaginationOptions) ([]*models.Submission, int, error) {
+	// Check ownership
+	if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+		return nil, 0, err
 	}
 
-	if err := s.widgetRepo.Create(ctx, widget); err != nil {
-		return nil, fmt.Errorf("failed to create widget: %w", err)
+	submissions, total, err := s.submissionRepo.SearchByWidgetID(ctx, widgetID, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search widget submissions: %w", err)
 	}
+	return submissions, total, nil
+}
 
-	return widget, nil
+// ResetWidgetsStats zeroes the counters of each owned widget, keeping their submissions.
+// Results are reported per widget ID in request order.
+func (s *WidgetService) ResetWidgetsStats(ctx context.Context, userID string, widgetIDs []string, resetDaily bool) []*models.BulkOperationResult {
+	results := make([]*models.BulkOperationResult, 0, len(widgetIDs))
+
+	for _, widgetID := range widgetIDs {
+		result := &models.BulkOperationResult{ID: widgetID}
+		results = append(results, result)
+
+		// Check ownership
+		if _, err := s.GetWidget(ctx, widgetID, userID); err != nil {
+			result.Error = "Widget not found"
+			continue
+		}
+
+		if err := s.statsRepo.ResetStats(ctx, widgetID); err != nil {
+			logger.Error("failed to reset widget stats", map[string]interface{}{
+				"widget_id": widgetID,
+				"error":     err.Error(),
+			})
+			result.Error = "Failed to reset stats"
+			continue
+		}
+
+		if resetDaily {
+			if err := s.statsRepo.ResetDailyStats(ctx, widgetID); err != nil {
+				logger.Error("failed to reset widget daily stats", map[string]interface{}{
+					"widget_id": widgetID,
+					"error":     err.Error(),
+				})
+				result.Error = "Failed to reset daily stats"
+				continue
+			}
+		}
+
+		result.Success = true
+	}
+
+	return results
 }
 
-// GetWidget retrieves a widget by ID with ownership check
-func (s *WidgetService) GetWidget(ctx context.Context, widgetID, userID string) (*models.Widget, error) {
+// SubmitWidget submits data to a widget (public endpoint). The returned submission only
+// carries its data when the widget echoes it.
+func (s *WidgetService) SubmitWidget(ctx context.Context, widgetID string, req models.SubmissionRequest) (*models.Submission, error) {
+	// Get widget (no ownership check for public endpoint)
 	widget, err := s.widgetRepo.GetByID(ctx, widgetID)
 	if err != nil {
 		return nil, errors.ErrNotFound
 	}
 
-	// Check ownership
-	if widget.OwnerID != userID {
-		return nil, errors.ErrAccessDenied
+	if widget.Archived {
+		return nil, errors.ErrWidgetArchived
 	}
 
-	return widget, nil
-}
+	// GET submissions from tracking pixels are opt-in per widget
+	if req.Pixel && !widget.AllowsPixelTracking() {
+		return nil, errors.ErrPixelTrackingDisabled
+	}
 
-// UpdateWidget updates an existing widget
-func (s *WidgetService) UpdateWidget(ctx context.Context, widgetID, userID string, req models.UpdateWidgetRequest) (*models.Widget, error) {
-	// Get existing widget
-	widget, err := s.GetWidget(ctx, widgetID, userID)
-	if err != nil {
-		return nil, err
+	// Closed widgets reject submissions, paused widgets still store them
+	status := widget.EffectiveStatus()
+	if status == models.WidgetStatusClosed {
+		return nil, errors.ErrWidgetDisabled
 	}
 
-	// Update fields
-	if req.Name != nil {
-		widget.Name = *req.Name
+	// Operators may pause a whole widget type, e.g. during a spam wave
+	if s.isTypePaused(ctx, widget.Type) {
+		return nil, errors.ErrTypePaused
 	}
-	if req.Type != nil {
-		widget.Type = *req.Type
+
+	// The widget's public policy decides who may submit and which fields; widget-scoped
+	// tokens identify trusted integrations and skip the browser checks
+	policy := widget.PublicPolicy()
+	now := time.Now()
+	startedAt := req.StartedAt
+	if s.formTokens != nil {
+		startedAt = nil // Only signed render times count once form tokens are enabled
+		if renderedAt, ok := s.formTokens.Verify(widgetID, req.FormToken, now); ok {
+			startedAt = &renderedAt
+		}
 	}
-	if req.IsVisible != nil {
-		widget.IsVisible = *req.IsVisible
+	decision := policy.Evaluate(models.PolicyInput{
+		Trusted:   req.Trusted,
+		Header:    req.Header,
+		Data:      req.Data,
+		StartedAt: startedAt,
+		Now:       now,
+	})
+	// Bots filling the honeypot get a normal receipt, so they don't adapt
+	if decision.Reason == models.PolicyReasonHoneypot {
+		return s.dropSpamSubmission(ctx, widget, req), nil
+	}
+	if err := decision.Err(); err != nil {
+		return nil, err
+	}
+	if policy.HoneypotField != "" {
+		delete(req.Data, policy.HoneypotField)
 	}
 
-	widget.UpdatedAt = time.Now()
-
-	if err := s.widgetRepo.Update(ctx, widget); err != nil {
-		return nil, fmt.Errorf("failed to update widget: %w", err)
+	// Untrusted submissions must come from an accepted country
+	if !req.Trusted {
+		if err := s.checkGeoRestrictions(widget, req.ClientIP); err != nil {
+			return nil, err
+		}
 	}
 
-	return widget, nil
-}
+	// Validate and normalize the data as the widget is configured to
+	data, warnings, err := s.prepareSubmissionData(widget, req.Data)
+	if err != nil {
+		return nil, err
+	}
+	req.Data = data
 
-// UpdateWidgetConfig updates the configuration of a widget
-func (s *WidgetService) UpdateWidgetConfig(ctx context.Context, widgetID, userID string, req *models.UpdateWidgetConfigRequest) (*models.Widget, error) {
-	// Check ownership first
-	widget, err := s.GetWidget(ctx, widgetID, userID)
+	// Offline embeds may supply the original capture time
+	createdAt, receivedAt, err := s.resolveCreatedAt(widget, req.OccurredAt, time.Now())
 	if err != nil {
 		return nil, err
 	}
 
-	// Update config
-	widget.Config = req.Config
-	widget.UpdatedAt = time.Now()
+	// Generate submission ID using UUID v5
+	submissionID := s.generateSubmissionID(widgetID)
 
-	if err := s.widgetRepo.Update(ctx, widget); err != nil {
-		return nil, fmt.Errorf("failed to update widget config: %w", err)
-	}
+	// Calculate TTL based on widget owner's plan (this would come from user data)
+	// For now, use default TTL
+	ttl := time.Duration(s.config.FreeDays) * 24 * time.Hour
 
-	return widget, nil
-}
+	// Create submission
+	submission := &models.Submission{
+		ID:                  submissionID,
+		WidgetID:            widgetID,
+		Data:                req.Data,
+		CreatedAt:           createdAt,
+		ReceivedAt:          receivedAt,
+		WidgetVersion:       widget.Version,
+		TTL:                 ttl,
+		Trusted:             req.Trusted,
+		Region:              s.resolveRegion(req.ClientIP),
+		ReceivedWhilePaused: status == models.WidgetStatusPaused,
+		ValidationWarnings:  warnings,
+		EncryptedFields:     widget.EncryptedFields(),
+		CorrelationValue:    models.CorrelationValue(req.Data, widget.CorrelationField()),
+		Meta:                models.CapturedMeta(req.Meta, widget.CaptureParams()),
+	}
 
-// DeleteWidget deletes a widget
-func (s *WidgetService) DeleteWidget(ctx context.Context, widgetID, userID string) error {
-	// Check ownership first
-	_, err := s.GetWidget(ctx, widgetID, userID)
-	if err != nil {
-		return err
+	// Repeated identical submissions from the client within the widget's dedup window
+	// (double clicks, retries) return the first one without being stored or counted again
+	var fingerprint string
+	if window := widget.SubmitDedupWindow(); window > 0 {
+		fingerprint = submissionFingerprint(req)
+		if existing := s.findDuplicateSubmission(ctx, widgetID, fingerprint, submissionID, window); existing != nil {
+			return submissionReceipt(widget, existing), nil
+		}
 	}
 
-	if err := s.widgetRepo.Delete(ctx, widgetID); err != nil {
-		return fmt.Errorf("failed to delete widget: %w", err)
+	if err := s.submissionRepo.Create(ctx, submission); err != nil {
+		if fingerprint != "" {
+			s.submissionRepo.ReleaseDedup(ctx, widgetID, fingerprint)
+		}
+		return nil, fmt.Errorf("failed to create submission: %w", err)
 	}
+	submission.Acknowledgement = widget.Acknowledgement()
 
-	return nil
-}
+	s.evictOldestSubmissions(ctx, widget)
 
-// GetUserWidgets retrieves widgets for a user with pagination and optional filtering
-func (s *WidgetService) GetUserWidgets(ctx context.Context, userID string, opts models.PaginationOptions) ([]*models.Widget, int, error) {
-	// Validate and clean filter options if provided
-	if opts.Filters != nil {
-		opts.Filters = models.ValidateFilterOptions(opts.Filters)
+	// Increment submit count
+	if err := s.statsRepo.IncrementSubmits(ctx, widgetID); err != nil {
+		// Log error but don't fail the submission
+		logger.Error("failed to increment submit count for widget", map[string]interface{}{
+			"widget_id": widgetID,
+			"error":     err,
+		})
 	}
 
-	// Use the filtering-capable repository method
-	widgets, total, err := s.widgetRepo.GetByUserIDWithFilters(ctx, userID, opts)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get user widgets: %w", err)
+	// Notify the owner, a failed notification doesn't fail the submission
+	if s.notifications != nil {
+		if err := s.notifications.NotifySubmission(ctx, widget, submission); err != nil {
+			logger.Error("failed to notify about submission", map[string]interface{}{
+				"widget_id": widgetID,
+				"error":     err.Error(),
+			})
+		}
 	}
 
-	return widgets, total, nil
+	return submissionReceipt(widget, submission), nil
 }
 
-// GetUserWidgetsWithStats retrieves widgets for a user with pagination, filtering and type statistics
-func (s *WidgetService) GetUserWidgetsWithStats(ctx context.Context, userID string, opts models.PaginationOptions) ([]*models.Widget, int, []*models.TypeStats, error) {
-	// Get widgets
-	widgets, total, err := s.GetUserWidgets(ctx, userID, opts)
-	if err != nil {
-		return nil, 0, nil, err
+// prepareSubmissionData validates and normalizes submitted or imported data for the
+// widget: reserved field names, field lengths, transforms, defaults and the widget's
+// field definitions. Under the lenient validation mode failures are returned as
+// warnings instead of rejecting the data.
+func (s *WidgetService) prepareSubmissionData(widget *models.Widget, data map[string]interface{}) (map[string]interface{}, models.FieldErrors, error) {
+	// Keep data fields from shadowing submission attributes
+	if err := s.checkReservedFields(data); err != nil {
+		return nil, nil, err
 	}
 
-	// Get type statistics
-	typeStats, err := s.widgetRepo.GetTypeStats(ctx, userID)
-	if err != nil {
-		return nil, 0, nil, fmt.Errorf("failed to get type stats: %w", err)
+	// Check field value lengths against the global limit and widget overrides; the
+	// widget's validation mode decides whether failures reject the submission
+	var warnings models.FieldErrors
+	switch widget.ValidationMode() {
+	case models.ValidationModeStrict:
+		if fieldErrs := models.ValidateFieldLengths(data, s.maxFieldLength, widget.FieldMaxLengths()); len(fieldErrs) > 0 {
+			return nil, nil, fieldErrs
+		}
+	case models.ValidationModeLenient:
+		warnings = models.ValidateFieldLengths(data, s.maxFieldLength, widget.FieldMaxLengths())
 	}
 
-	return widgets, total, typeStats, nil
+	// Normalize field values (trim, lowercase, phone numbers...) before storage
+	transform.Apply(data, widget.FieldTransforms())
+
+	// Fill in missing or empty fields after validation, so configured defaults don't
+	// count against field limits; defaults never introduce reserved names
+	if defaults := widget.FieldDefaults(); len(defaults) > 0 {
+		if s.reservedMode != models.ReservedFieldsOff {
+			for field := range defaults {
+				if s.reservedFields.Contains(field) {
+					delete(defaults, field)
+				}
+			}
+		}
+		if data == nil {
+			data = make(map[string]interface{})
+		}
+		models.ApplyFieldDefaults(data, defaults)
+	}
+
+	// Check required fields and field types of the widget's field definitions after
+	// transforms and defaults, under the same validation mode as field lengths
+	if mode := widget.ValidationMode(); mode != models.ValidationModeOff {
+		if err := validation.ValidateSubmission(widget, data); err != nil {
+			if mode == models.ValidationModeStrict {
+				return nil, nil, err
+			}
+			if fieldErrs, ok := err.(models.FieldErrors); ok {
+				warnings = append(warnings, fieldErrs...)
+			}
+		}
+	}
+
+	return data, warnings, nil
 }
 
-// GetWidgetStats retrieves statistics for a widget
-func (s *WidgetService) GetWidgetStats(ctx context.Context, widgetID, userID string) (*models.WidgetStats, error) {
-	// Check ownership
-	_, err := s.GetWidget(ctx, widgetID, userID)
-	if err != nil {
-		return nil, err
+// dropSpamSubmission counts a submission caught as spam and returns a receipt of a
+// submission that is never stored, counted as submit or notified about
+func (s *WidgetService) dropSpamSubmission(ctx context.Context, widget *models.Widget, req models.SubmissionRequest) *models.Submission {
+	if err := s.statsRepo.IncrementSpam(ctx, widget.ID); err != nil {
+		logger.Error("failed to increment spam count for widget", map[string]interface{}{
+			"widget_id": widget.ID,
+			"error":     err.Error(),
+		})
 	}
+	metrics.Inc("submissions_spam_dropped_total", nil, "Total public submissions silently dropped as spam")
 
-	stats, err := s.statsRepo.GetWidgetStats(ctx, widgetID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get widget stats: %w", err)
+	return submissionReceipt(widget, &models.Submission{
+		ID:        s.generateSubmissionID(widget.ID),
+		WidgetID:  widget.ID,
+		Data:      req.Data,
+		CreatedAt: time.Now(),
+	})
+}
+
+// submissionReceipt returns the submission as returned to the submitter: with the
+// widget's acknowledgement, and without data unless the widget echoes it
+func submissionReceipt(widget *models.Widget, submission *models.Submission) *models.Submission {
+	submission.Acknowledgement = widget.Acknowledgement()
+	if !widget.EchoesData() {
+		receipt := *submission
+		receipt.Data = nil
+		return &receipt
 	}
+	return submission
+}
 
-	return stats, nil
+// submissionFingerprint identifies a submission's data and client for deduplication.
+// Data is encoded with sorted keys, so field order doesn't matter; the IP is only hashed.
+func submissionFingerprint(req models.SubmissionRequest) string {
+	data, _ := json.Marshal(req.Data)
+	sum := sha256.Sum256(append([]byte(req.ClientIP+"|"), data...))
+	return hex.EncodeToString(sum[:16])
 }
 
-// GetWidgetSubmissions retrieves submissions for a widget
-func (s *WidgetService) GetWidgetSubmissions(ctx context.Context, widgetID, userID string, opts models.PaginationOptions) ([]*models.Submission, int, error) {
-	// Check ownership
-	_, err := s.GetWidget(ctx, widgetID, userID)
+// findDuplicateSubmission claims the fingerprint for the new submission, returning the
+// submission that claimed it earlier within the window. Lookup failures, and earlier
+// submissions that can't be loaded (still being stored, or evicted), let the new
+// submission through.
+func (s *WidgetService) findDuplicateSubmission(ctx context.Context, widgetID, fingerprint, submissionID string, window time.Duration) *models.Submission {
+	existingID, err := s.submissionRepo.ClaimDedup(ctx, widgetID, fingerprint, submissionID, window)
 	if err != nil {
-		return nil, 0, err
+		logger.Error("failed to check submission deduplication", map[string]interface{}{
+			"widget_id": widgetID,
+			"error":     err.Error(),
+		})
+		return nil
+	}
+	if existingID == "" {
+		return nil
 	}
 
-	submissions, total, err := s.submissionRepo.GetByWidgetID(ctx, widgetID, opts)
+	existing, err := s.submissionRepo.GetByID(ctx, widgetID, existingID)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get widget submissions: %w", err)
+		return nil
 	}
+	logger.Debug("Duplicate submission returned the earlier one", map[string]interface{}{
+		"widget_id":     widgetID,
+		"submission_id": existingID,
+	})
+	return existing
+}
 
-	return submissions, total, nil
+// evictOldestSubmissions drops the oldest submissions of widgets keeping only the latest
+// max_submissions. The submit counter drops with them, as when submissions are deleted.
+func (s *WidgetService) evictOldestSubmissions(ctx context.Context, widget *models.Widget) {
+	maxSubmissions := widget.MaxSubmissions()
+	if maxSubmissions == 0 {
+		return
+	}
+	if _, err := s.submissionRepo.EvictOldest(ctx, widget.ID, maxSubmissions); err != nil {
+		// Log error but don't fail the submission, the next one evicts again
+		logger.Error("failed to evict oldest submissions", map[string]interface{}{
+			"widget_id": widget.ID,
+			"error":     err.Error(),
+		})
+	}
 }
 
-// SubmitWidget submits data to a widget (public endpoint)
-func (s *WidgetService) SubmitWidget(ctx context.Context, widgetID string, req models.SubmissionRequest) (*models.Submission, error) {
-	// Get widget (no ownership check for public endpoint)
-	widget, err := s.widgetRepo.GetByID(ctx, widgetID)
+// ImportSubmission stores an imported submission for a widget the caller already owns.
+// The data is validated and normalized as in SubmitWidget, but the widget status and
+// public policy are ignored and the original creation time is kept.
+func (s *WidgetService) ImportSubmission(ctx context.Context, widget *models.Widget, req models.ImportSubmissionRequest) (*models.Submission, error) {
+	data, warnings, err := s.prepareSubmissionData(widget, req.Data)
 	if err != nil {
-		return nil, errors.ErrNotFound
+		return nil, err
 	}
+	req.Data = data
 
-	// Check if widget is enabled
-	if !widget.IsVisible {
-		return nil, errors.ErrWidgetDisabled
+	createdAt := time.Now()
+	if req.CreatedAt != nil {
+		createdAt = *req.CreatedAt
 	}
 
-	// Generate submission ID using UUID v5
-	submissionID := s.generateSubmissionID(widgetID)
-
-	// Calculate TTL based on widget owner's plan (this would come from user data)
-	// For now, use default TTL
-	ttl := time.Duration(s.config.FreeDays) * 24 * time.Hour
-
-	// Create submission
 	submission := &models.Submission{
-		ID:        submissionID,
-		WidgetID:  widgetID,
-		Data:      req.Data,
-		CreatedAt: time.Now(),
-		TTL:       ttl,
+		ID:                 s.generateSubmissionID(widget.ID),
+		WidgetID:           widget.ID,
+		Data:               req.Data,
+		CreatedAt:          createdAt,
+		WidgetVersion:      widget.Version,
+		TTL:                time.Duration(s.config.FreeDays) * 24 * time.Hour,
+		Region:             s.resolveRegion(""),
+		ValidationWarnings: warnings,
+		EncryptedFields:    widget.EncryptedFields(),
+		CorrelationValue:   models.CorrelationValue(req.Data, widget.CorrelationField()),
 	}
 
 	if err := s.submissionRepo.Create(ctx, submission); err != nil {
 		return nil, fmt.Errorf("failed to create submission: %w", err)
 	}
+	s.evictOldestSubmissions(ctx, widget)
 
-	// Increment submit count
-	if err := s.statsRepo.IncrementSubmits(ctx, widgetID); err != nil {
-		// Log error but don't fail the submission
+	if err := s.statsRepo.IncrementSubmits(ctx, widget.ID); err != nil {
+		// Log error but don't fail the import
 		logger.Error("failed to increment submit count for widget", map[string]interface{}{
-			"widget_id": widgetID,
+			"widget_id": widget.ID,
 			"error":     err,
 		})
 	}
@@ -291,31 +1864,72 @@ func (s *WidgetService) SubmitWidget(ctx context.Context, widgetID string, req m
 
 // RegisterWidgetEvent registers a widget event (view, close)
 func (s *WidgetService) RegisterWidgetEvent(ctx context.Context, widgetID string, eventType string) error {
+	_, err := s.RegisterEvent(ctx, widgetID, models.EventRequest{Type: eventType})
+	return err
+}
+
+// RegisterEvent registers a widget event (view, close). Widgets with view deduplication
+// count one view per visitor and window: views are skipped when the visitor's cookie was
+// seen or, for cookieless clients, when the client IP viewed the widget within the window.
+// It returns the window (0 without deduplication) for the handler to set the cookie.
+func (s *WidgetService) RegisterEvent(ctx context.Context, widgetID string, req models.EventRequest) (time.Duration, error) {
 	// Check if widget exists and is enabled
 	widget, err := s.widgetRepo.GetByID(ctx, widgetID)
 	if err != nil {
-		return fmt.Errorf("widget not found: %w", err)
+		return 0, fmt.Errorf("widget not found: %w", err)
 	}
 
 	if !widget.IsVisible {
-		return fmt.Errorf("widget is disabled")
+		return 0, fmt.Errorf("widget is disabled")
+	}
+
+	// GET events from tracking pixels are opt-in per widget
+	if req.Pixel && !widget.AllowsPixelTracking() {
+		return 0, errors.ErrPixelTrackingDisabled
 	}
 
 	// Register event
-	switch eventType {
+	switch req.Type {
 	case "view":
+		window := widget.ViewDedupWindow()
+		if window > 0 && !s.isFirstView(ctx, widgetID, req, window) {
+			return window, nil
+		}
 		if err := s.statsRepo.IncrementViews(ctx, widgetID); err != nil {
-			return fmt.Errorf("failed to register view event: %w", err)
+			return 0, fmt.Errorf("failed to register view event: %w", err)
 		}
+		return window, nil
 	case "close":
 		if err := s.statsRepo.IncrementCloses(ctx, widgetID); err != nil {
-			return fmt.Errorf("failed to register close event: %w", err)
+			return 0, fmt.Errorf("failed to register close event: %w", err)
 		}
 	default:
-		return fmt.Errorf("unknown event type: %s", eventType)
+		return 0, fmt.Errorf("unknown event type: %s", req.Type)
 	}
 
-	return nil
+	return 0, nil
+}
+
+// isFirstView reports whether the visitor's view is the first within the window. The
+// IP is only hashed for the check; lookup failures count the view.
+func (s *WidgetService) isFirstView(ctx context.Context, widgetID string, req models.EventRequest, window time.Duration) bool {
+	if req.Seen {
+		return false
+	}
+	if req.ClientIP == "" {
+		return true
+	}
+
+	sum := sha256.Sum256([]byte(widgetID + "|" + req.ClientIP))
+	first, err := s.statsRepo.MarkViewSeen(ctx, widgetID, hex.EncodeToString(sum[:16]), window)
+	if err != nil {
+		logger.Error("failed to check view deduplication", map[string]interface{}{
+			"widget_id": widgetID,
+			"error":     err.Error(),
+		})
+		return true
+	}
+	return first
 }
 
 // UpdateUserTTL updates TTL for all submissions of a user
@@ -337,6 +1951,33 @@ func (s *WidgetService) UpdateUserTTL(ctx context.Context, userID string, plan s
 	return nil
 }
 
+// SetPreferencesRepository sets the store of user preferences (nil keeps server defaults for everyone)
+func (s *WidgetService) SetPreferencesRepository(preferences storage.PreferencesRepository) {
+	s.preferences = preferences
+}
+
+// GetUserPreferences retrieves the user's preferences
+func (s *WidgetService) GetUserPreferences(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	if s.preferences == nil {
+		return &models.UserPreferences{}, nil
+	}
+	return s.preferences.Get(ctx, userID)
+}
+
+// UpdateUserPreferences validates and stores the user's preferences
+func (s *WidgetService) UpdateUserPreferences(ctx context.Context, userID string, preferences *models.UserPreferences) error {
+	if preferences.ExportFormat != "" && !models.IsValidExportFormat(preferences.ExportFormat) {
+		return models.FieldErrors{{
+			Field:   "export_format",
+			Message: "must be one of: " + strings.Join(models.ExportFormats, ", "),
+		}}
+	}
+	if s.preferences == nil {
+		return fmt.Errorf("user preferences are not enabled")
+	}
+	return s.preferences.Set(ctx, userID, preferences)
+}
+
 // UpdateUserSubmissionsTTL updates TTL for all submissions of a user
 func (s *WidgetService) UpdateUserSubmissionsTTL(ctx context.Context, userID string, ttlDays int) error {
 	const perPage = 100 // Process in batches of 100
@@ -376,6 +2017,62 @@ func (s *WidgetService) UpdateUserSubmissionsTTL(ctx context.Context, userID str
 	return nil
 }
 
+// GetTypeStats returns the number of the user's widgets per type
+func (s *WidgetService) GetTypeStats(ctx context.Context, userID string) ([]*models.TypeStats, error) {
+	return s.widgetRepo.GetTypeStats(ctx, userID)
+}
+
+// GetWidgetTypesOverview aggregates the user's widgets per type, ordered by type.
+// Stats come with the batch-loaded widgets, so no per-widget lookups are needed.
+func (s *WidgetService) GetWidgetTypesOverview(ctx context.Context, userID string) ([]*models.WidgetTypeOverview, error) {
+	byType := make(map[string]*models.WidgetTypeOverview)
+	const perPage = 100 // Process in batches of 100
+	page := 1
+
+	for {
+		widgets, _, err := s.widgetRepo.GetByUserID(ctx, userID, models.PaginationOptions{
+			Page:    page,
+			PerPage: perPage,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user widgets for types overview on page %d: %w", page, err)
+		}
+
+		for _, widget := range widgets {
+			overview, ok := byType[widget.Type]
+			if !ok {
+				overview = &models.WidgetTypeOverview{Type: widget.Type}
+				byType[widget.Type] = overview
+			}
+			overview.Count++
+
+			if widget.Stats == nil {
+				continue
+			}
+			overview.TotalSubmissions += widget.Stats.Submits
+			if lastActivity := widget.Stats.LastActivity(); !lastActivity.IsZero() &&
+				(overview.LastActivity == nil || lastActivity.After(*overview.LastActivity)) {
+				overview.LastActivity = &lastActivity
+			}
+		}
+
+		// If we received fewer widgets than we asked for, it's the last page
+		if len(widgets) < perPage {
+			break
+		}
+
+		page++
+	}
+
+	overviews := make([]*models.WidgetTypeOverview, 0, len(byType))
+	for _, overview := range byType {
+		overviews = append(overviews, overview)
+	}
+	sort.Slice(overviews, func(i, j int) bool { return overviews[i].Type < overviews[j].Type })
+
+	return overviews, nil
+}
+
 // GetWidgetsSummary returns a summary of user's widgets
 func (s *WidgetService) GetWidgetsSummary(ctx context.Context, userID string) (*models.WidgetsSummary, error) {
 	summary := &models.WidgetsSummary{}
@@ -427,5 +2124,9 @@ func (s *WidgetService) GetWidgetsSummary(ctx context.Context, userID string) (*
 		page++
 	}
 
+	if summary.TotalViews > 0 {
+		summary.ConversionRate = float64(summary.TotalSubmissions) / float64(summary.TotalViews)
+	}
+
 	return summary, nil
 }
@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ad/leads-core/internal/models"
 	"github.com/google/uuid"
@@ -269,3 +272,114 @@ func TestGetUserWidgets_BackwardCompatibility(t *testing.T) {
 		t.Error("Expected filters to remain nil for backward compatibility")
 	}
 }
+
+func TestCreateWidget_DemoWidgetExpiry(t *testing.T) {
+	service := &WidgetService{
+		widgetRepo: NewMockWidgetRepository(),
+		config:     TTLConfig{DemoDays: 7, ExpireDemoWidgets: true},
+	}
+	ctx := context.Background()
+
+	demo, err := service.CreateWidget(ctx, "demo-user", models.CreateWidgetRequest{Type: "lead-form", Name: "Trial", Plan: "demo"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if demo.ExpiresAt == nil || !demo.ExpiresAt.Equal(demo.CreatedAt.AddDate(0, 0, 7)) {
+		t.Errorf("Expected demo widget to expire 7 days after creation, got %v", demo.ExpiresAt)
+	}
+
+	pro, err := service.CreateWidget(ctx, "pro-user", models.CreateWidgetRequest{Type: "lead-form", Name: "Paid", Plan: "pro"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pro.ExpiresAt != nil {
+		t.Errorf("Expected non-demo widget not to expire, got %v", pro.ExpiresAt)
+	}
+}
+
+func TestInferSubmissionSchema(t *testing.T) {
+	widgetRepo := NewMockWidgetRepository()
+	submissionRepo := NewMockSubmissionRepository()
+	service := &WidgetService{
+		widgetRepo:     widgetRepo,
+		submissionRepo: submissionRepo,
+	}
+	ctx := context.Background()
+
+	widgetRepo.Create(ctx, &models.Widget{ID: "widget-1", OwnerID: "user-1", Type: "lead-form"})
+	for i, data := range []map[string]interface{}{
+		{"email": "a@example.com", "age": float64(30), "subscribed": true},
+		{"email": "b@example.com", "age": "thirty", "address": map[string]interface{}{"city": "Berlin"}},
+		{"email": "c@example.com", "age": float64(41), "tags": []interface{}{"vip"}},
+	} {
+		submissionRepo.Create(ctx, &models.Submission{ID: fmt.Sprintf("sub-%d", i), WidgetID: "widget-1", Data: data})
+	}
+
+	schema, err := service.InferSubmissionSchema(ctx, "widget-1", "user-1", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if schema.SampleSize != 3 {
+		t.Errorf("Expected sample size 3, got %d", schema.SampleSize)
+	}
+
+	expected := map[string]struct {
+		fieldType string
+		count     int
+		types     map[string]int
+	}{
+		"address":    {"object", 1, map[string]int{"object": 1}},
+		"age":        {"number", 3, map[string]int{"number": 2, "string": 1}},
+		"email":      {"string", 3, map[string]int{"string": 3}},
+		"subscribed": {"bool", 1, map[string]int{"bool": 1}},
+		"tags":       {"array", 1, map[string]int{"array": 1}},
+	}
+	if len(schema.Fields) != len(expected) {
+		t.Fatalf("Expected %d fields, got %d", len(expected), len(schema.Fields))
+	}
+	for i, field := range schema.Fields {
+		if i > 0 && schema.Fields[i-1].Name >= field.Name {
+			t.Errorf("Expected fields sorted by name, got %s after %s", field.Name, schema.Fields[i-1].Name)
+		}
+		want, ok := expected[field.Name]
+		if !ok {
+			t.Errorf("Unexpected field %s", field.Name)
+			continue
+		}
+		if field.Type != want.fieldType || field.Count != want.count || !reflect.DeepEqual(field.Types, want.types) {
+			t.Errorf("Field %s: expected %s/%d/%v, got %s/%d/%v", field.Name, want.fieldType, want.count, want.types, field.Type, field.Count, field.Types)
+		}
+	}
+
+	// Sample size bounds the number of scanned submissions
+	schema, err = service.InferSubmissionSchema(ctx, "widget-1", "user-1", 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if schema.SampleSize != 2 {
+		t.Errorf("Expected sample size 2, got %d", schema.SampleSize)
+	}
+
+	// Other users can't read the schema
+	if _, err := service.InferSubmissionSchema(ctx, "widget-1", "user-2", 0); err == nil {
+		t.Error("Expected access denied for another user")
+	}
+}
+
+func TestStartInactiveWidgetsSweep_RejectsNonPositiveInterval(t *testing.T) {
+	service := NewWidgetService(NewMockWidgetRepository(), NewMockSubmissionRepository(), nil, TTLConfig{})
+
+	for _, interval := range []time.Duration{0, -time.Hour} {
+		done := make(chan struct{})
+		go func() {
+			service.StartInactiveWidgetsSweep(context.Background(), interval, 24*time.Hour)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the sweep not to start for interval %v", interval)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/pkg/monitoring"
+	"github.com/redis/go-redis/v9"
+)
+
+// claimJobScript moves a queued or retrying job to running and counts the attempt,
+// so a job cancelled while waiting in the queue is never started. It returns the
+// attempt number, or 0 when the job can't be claimed.
+var claimJobScript = redis.NewScript(`
+local status = redis.call("HGET", KEYS[1], "status")
+if status ~= "queued" and status ~= "retrying" then
+	return 0
+end
+redis.call("HSET", KEYS[1], "status", "running", "updated_at", ARGV[1])
+return redis.call("HINCRBY", KEYS[1], "attempts", 1)
+`)
+
+// cancelJobScript cancels a queued job right away and flags a running one for the
+// worker. It returns the status the job had, or "" when the job doesn't exist.
+var cancelJobScript = redis.NewScript(`
+local status = redis.call("HGET", KEYS[1], "status")
+if not status then
+	return ""
+end
+if status == "queued" or status == "retrying" then
+	redis.call("HSET", KEYS[1], "status", "cancelled", "cancel_requested", "true", "updated_at", ARGV[1])
+elseif status == "running" then
+	redis.call("HSET", KEYS[1], "cancel_requested", "true", "updated_at", ARGV[1])
+end
+return status
+`)
+
+// completeJobScript stores the export result and marks the job completed, unless
+// cancellation was requested meanwhile. The result expires together with the job.
+var completeJobScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "cancel_requested") == "true" then
+	redis.call("HSET", KEYS[1], "status", "cancelled", "updated_at", ARGV[4])
+	return 0
+end
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl > 0 then
+	redis.call("SET", KEYS[2], ARGV[1], "PX", ttl)
+else
+	redis.call("SET", KEYS[2], ARGV[1])
+end
+redis.call("HSET", KEYS[1], "status", "completed", "filename", ARGV[2], "size", ARGV[3], "updated_at", ARGV[4])
+return 1
+`)
+
+// completeUploadedJobScript marks a job whose file went to object storage completed,
+// recording its download URL, unless cancellation was requested meanwhile
+var completeUploadedJobScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "cancel_requested") == "true" then
+	redis.call("HSET", KEYS[1], "status", "cancelled", "updated_at", ARGV[5])
+	return 0
+end
+redis.call("HSET", KEYS[1], "status", "completed", "filename", ARGV[1], "size", ARGV[2], "download_url", ARGV[3], "download_expires_at", ARGV[4], "updated_at", ARGV[5])
+return 1
+`)
+
+// retryJobScript marks a failed running job as waiting for another attempt, unless
+// cancellation was requested meanwhile
+var retryJobScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "cancel_requested") == "true" then
+	redis.call("HSET", KEYS[1], "status", "cancelled", "updated_at", ARGV[2])
+	return 0
+end
+redis.call("HSET", KEYS[1], "status", "retrying", "error", ARGV[1], "updated_at", ARGV[2])
+return 1
+`)
+
+// ExportJobRepository defines interface for asynchronous export job storage
+type ExportJobRepository interface {
+	Create(ctx context.Context, job *models.ExportJob) error
+	GetByID(ctx context.Context, widgetID, jobID string) (*models.ExportJob, error)
+	List(ctx context.Context, widgetID, status string, opts models.PaginationOptions) ([]*models.ExportJob, int, error)
+	Claim(ctx context.Context, widgetID, jobID string) (int, error)
+	Complete(ctx context.Context, widgetID, jobID, filename string, data []byte) (bool, error)
+	CompleteUploaded(ctx context.Context, widgetID, jobID, filename string, size int, downloadURL string, expiresAt time.Time) (bool, error)
+	Retry(ctx context.Context, widgetID, jobID, message string) (bool, error)
+	Fail(ctx context.Context, widgetID, jobID, message string) error
+	Cancel(ctx context.Context, widgetID, jobID string) (string, error)
+	GetResult(ctx context.Context, widgetID, jobID string) ([]byte, error)
+}
+
+// RedisExportJobRepository implements ExportJobRepository for Redis.
+// Job records and results expire after ttl.
+type RedisExportJobRepository struct {
+	client *RedisClient
+	ttl    time.Duration
+}
+
+// NewRedisExportJobRepository creates a new Redis export job repository
+func NewRedisExportJobRepository(client *RedisClient, ttl time.Duration) *RedisExportJobRepository {
+	return &RedisExportJobRepository{client: client, ttl: ttl}
+}
+
+// Create stores a new job and adds it to the widget's job index
+func (r *RedisExportJobRepository) Create(ctx context.Context, job *models.ExportJob) error {
+	jobKey := GenerateExportJobKey(job.WidgetID, job.ID)
+	jobsKey := GenerateWidgetExportJobsKey(job.WidgetID)
+
+	// All job keys use {widgetID} hash tag, so they'll be in same slot
+	pipe := r.client.client.TxPipeline()
+	pipe.HSet(ctx, jobKey, job.ToRedisHash())
+	pipe.ZAdd(ctx, jobsKey, redis.Z{Score: float64(job.CreatedAt.Unix()), Member: job.ID})
+	if r.ttl > 0 {
+		pipe.Expire(ctx, jobKey, r.ttl)
+		pipe.Expire(ctx, jobsKey, r.ttl)
+		// Drop index entries of jobs that expired already
+		pipe.ZRemRangeByScore(ctx, jobsKey, "-inf", strconv.FormatInt(job.CreatedAt.Add(-r.ttl).Unix(), 10))
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetByID retrieves a specific job
+func (r *RedisExportJobRepository) GetByID(ctx context.Context, widgetID, jobID string) (*models.ExportJob, error) {
+	hash, err := r.client.client.HGetAll(ctx, GenerateExportJobKey(widgetID, jobID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) == 0 {
+		return nil, errors.ErrJobNotFound
+	}
+
+	job := &models.ExportJob{}
+	if err := job.FromRedisHash(hash); err != nil {
+		return nil, fmt.Errorf("failed to parse export job data: %w", err)
+	}
+	return job, nil
+}
+
+// List retrieves the widget's jobs, newest first, optionally only those with the given status
+func (r *RedisExportJobRepository) List(ctx context.Context, widgetID, status string, opts models.PaginationOptions) ([]*models.ExportJob, int, error) {
+	jobsKey := GenerateWidgetExportJobsKey(widgetID)
+
+	queryStart := time.Now()
+	jobIDs, err := r.client.client.ZRevRange(ctx, jobsKey, 0, -1).Result()
+	monitoring.TrackQuery("ZREVRANGE", keyPattern(WidgetExportJobsKey), queryStart)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(jobIDs) == 0 {
+		return []*models.ExportJob{}, 0, nil
+	}
+
+	// Status isn't indexed, so load all jobs (bounded by the TTL) and filter
+	pipe := r.client.client.Pipeline()
+	commands := make([]*redis.MapStringStringCmd, len(jobIDs))
+	for i, jobID := range jobIDs {
+		commands[i] = pipe.HGetAll(ctx, GenerateExportJobKey(widgetID, jobID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, 0, fmt.Errorf("failed to load export jobs: %w", err)
+	}
+
+	jobs := make([]*models.ExportJob, 0, len(jobIDs))
+	for i, jobID := range jobIDs {
+		hash, err := commands[i].Result()
+		if err != nil || len(hash) == 0 {
+			// Expired job, drop it from the index
+			r.client.client.ZRem(ctx, jobsKey, jobID)
+			continue
+		}
+
+		job := &models.ExportJob{}
+		if err := job.FromRedisHash(hash); err != nil {
+			continue // Skip jobs with invalid data
+		}
+		if status != "" && job.Status != status {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	total := len(jobs)
+	start := (opts.Page - 1) * opts.PerPage
+	if start >= total {
+		return []*models.ExportJob{}, total, nil
+	}
+	end := start + opts.PerPage
+	if end > total {
+		end = total
+	}
+
+	return jobs[start:end], total, nil
+}
+
+// Claim marks a queued or retrying job as running and returns its attempt number,
+// 0 when the job was no longer waiting (e.g. cancelled)
+func (r *RedisExportJobRepository) Claim(ctx context.Context, widgetID, jobID string) (int, error) {
+	return claimJobScript.Run(ctx, r.client.client, []string{GenerateExportJobKey(widgetID, jobID)}, time.Now().Unix()).Int()
+}
+
+// Complete stores the job result and reports whether the job completed; it is
+// cancelled instead when cancellation was requested while it was running
+func (r *RedisExportJobRepository) Complete(ctx context.Context, widgetID, jobID, filename string, data []byte) (bool, error) {
+	keys := []string{GenerateExportJobKey(widgetID, jobID), GenerateExportJobDataKey(widgetID, jobID)}
+	completed, err := completeJobScript.Run(ctx, r.client.client, keys, data, filename, len(data), time.Now().Unix()).Int()
+	return completed == 1, err
+}
+
+// CompleteUploaded marks the job completed with a download URL instead of a stored
+// result; like Complete, it is cancelled instead when cancellation was requested
+func (r *RedisExportJobRepository) CompleteUploaded(ctx context.Context, widgetID, jobID, filename string, size int, downloadURL string, expiresAt time.Time) (bool, error) {
+	completed, err := completeUploadedJobScript.Run(ctx, r.client.client, []string{GenerateExportJobKey(widgetID, jobID)},
+		filename, size, downloadURL, expiresAt.Unix(), time.Now().Unix()).Int()
+	return completed == 1, err
+}
+
+// Retry marks a failed attempt with the given message and reports whether the job
+// waits for another one; it is cancelled instead when cancellation was requested
+func (r *RedisExportJobRepository) Retry(ctx context.Context, widgetID, jobID, message string) (bool, error) {
+	retrying, err := retryJobScript.Run(ctx, r.client.client, []string{GenerateExportJobKey(widgetID, jobID)}, message, time.Now().Unix()).Int()
+	return retrying == 1, err
+}
+
+// Fail marks the job as failed with the given message
+func (r *RedisExportJobRepository) Fail(ctx context.Context, widgetID, jobID, message string) error {
+	return r.client.client.HSet(ctx, GenerateExportJobKey(widgetID, jobID), map[string]interface{}{
+		"status":     models.ExportJobFailed,
+		"error":      message,
+		"updated_at": time.Now().Unix(),
+	}).Err()
+}
+
+// Cancel cancels a queued job or requests cancellation of a running one, and
+// returns the status the job had before
+func (r *RedisExportJobRepository) Cancel(ctx context.Context, widgetID, jobID string) (string, error) {
+	status, err := cancelJobScript.Run(ctx, r.client.client, []string{GenerateExportJobKey(widgetID, jobID)}, time.Now().Unix()).Text()
+	if err != nil {
+		return "", err
+	}
+	if status == "" {
+		return "", errors.ErrJobNotFound
+	}
+	return status, nil
+}
+
+// GetResult retrieves the generated export file of a completed job
+func (r *RedisExportJobRepository) GetResult(ctx context.Context, widgetID, jobID string) ([]byte, error) {
+	data, err := r.client.client.Get(ctx, GenerateExportJobDataKey(widgetID, jobID)).Bytes()
+	if err == redis.Nil {
+		return nil, errors.ErrJobNotFound
+	}
+	return data, err
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// exportCounterTTL keeps daily export counters a bit past their day
+const exportCounterTTL = 48 * time.Hour
+
+// reserveExportScript counts an export unless the counter already reached the limit,
+// so parallel exports can't go over it. It returns the new count, or 0 when the limit
+// was reached.
+var reserveExportScript = redis.NewScript(`
+if tonumber(redis.call("GET", KEYS[1]) or "0") >= tonumber(ARGV[1]) then
+	return 0
+end
+local count = redis.call("INCR", KEYS[1])
+redis.call("EXPIRE", KEYS[1], ARGV[2])
+return count
+`)
+
+// releaseExportScript gives back a counted export, never going below zero
+var releaseExportScript = redis.NewScript(`
+if tonumber(redis.call("GET", KEYS[1]) or "0") > 0 then
+	return redis.call("DECR", KEYS[1])
+end
+return 0
+`)
+
+// ExportQuotaRepository defines interface for per-user daily export counters
+type ExportQuotaRepository interface {
+	GetDailyExports(ctx context.Context, userID string, day time.Time) (int, error)
+	ReserveDailyExport(ctx context.Context, userID string, day time.Time, limit int) (bool, error)
+	ReleaseDailyExport(ctx context.Context, userID string, day time.Time) error
+}
+
+// RedisExportQuotaRepository implements ExportQuotaRepository for Redis
+type RedisExportQuotaRepository struct {
+	client *RedisClient
+}
+
+// NewRedisExportQuotaRepository creates a new Redis export quota repository
+func NewRedisExportQuotaRepository(client *RedisClient) *RedisExportQuotaRepository {
+	return &RedisExportQuotaRepository{client: client}
+}
+
+// GetDailyExports returns the number of exports the user made on the day (UTC)
+func (r *RedisExportQuotaRepository) GetDailyExports(ctx context.Context, userID string, day time.Time) (int, error) {
+	count, err := r.client.client.Get(ctx, GenerateUserExportsKey(userID, day.UTC().Format("2006-01-02"))).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+// ReserveDailyExport counts an export of the user on the day (UTC) while fewer than
+// limit were counted, checking and incrementing in one step. It returns whether the
+// export was counted.
+func (r *RedisExportQuotaRepository) ReserveDailyExport(ctx context.Context, userID string, day time.Time, limit int) (bool, error) {
+	key := GenerateUserExportsKey(userID, day.UTC().Format("2006-01-02"))
+	count, err := reserveExportScript.Run(ctx, r.client.client, []string{key}, limit, int(exportCounterTTL.Seconds())).Int()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ReleaseDailyExport gives back an export counted on the day (UTC) by ReserveDailyExport
+func (r *RedisExportQuotaRepository) ReleaseDailyExport(ctx context.Context, userID string, day time.Time) error {
+	key := GenerateUserExportsKey(userID, day.UTC().Format("2006-01-02"))
+	return releaseExportScript.Run(ctx, r.client.client, []string{key}).Err()
+}
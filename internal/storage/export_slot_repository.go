@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireSlotScript drops expired leases and adds a new one while fewer than the
+// limit are held. Leases are scored by expiry (unix ms), so a crashed instance's
+// slots free up on their own.
+var acquireSlotScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], ARGV[3], ARGV[4])
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+return 1
+`)
+
+// ExportSlotRepository defines interface for the cluster-wide export semaphore
+type ExportSlotRepository interface {
+	AcquireExportSlot(ctx context.Context, limit int, now time.Time, ttl time.Duration) (string, bool, error)
+	ReleaseExportSlot(ctx context.Context, leaseID string) error
+}
+
+// RedisExportSlotRepository implements ExportSlotRepository for Redis
+type RedisExportSlotRepository struct {
+	client *RedisClient
+}
+
+// NewRedisExportSlotRepository creates a new Redis export slot repository
+func NewRedisExportSlotRepository(client *RedisClient) *RedisExportSlotRepository {
+	return &RedisExportSlotRepository{client: client}
+}
+
+// AcquireExportSlot takes one of limit slots, leased for ttl from now. It returns the
+// lease ID needed to release the slot and whether a slot was free.
+func (r *RedisExportSlotRepository) AcquireExportSlot(ctx context.Context, limit int, now time.Time, ttl time.Duration) (string, bool, error) {
+	leaseID := uuid.NewString()
+	acquired, err := acquireSlotScript.Run(ctx, r.client.client, []string{ExportSlotsKey},
+		now.UnixMilli(), limit, now.Add(ttl).UnixMilli(), leaseID, ttl.Milliseconds()).Int()
+	if err != nil || acquired == 0 {
+		return "", false, err
+	}
+	return leaseID, true, nil
+}
+
+// ReleaseExportSlot frees the lease's slot; releasing an expired lease is a no-op
+func (r *RedisExportSlotRepository) ReleaseExportSlot(ctx context.Context, leaseID string) error {
+	return r.client.client.ZRem(ctx, ExportSlotsKey, leaseID).Err()
+}
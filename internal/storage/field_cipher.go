@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// encryptedValuePrefix marks submission field values stored encrypted
+const encryptedValuePrefix = "enc:v1:"
+
+// FieldCipher encrypts individual submission field values with AES-256-GCM.
+// Values are JSON-encoded before encryption, so non-string values round-trip.
+type FieldCipher struct {
+	aead cipher.AEAD
+}
+
+// NewFieldCipher creates a field cipher from a base64-encoded 32-byte key
+func NewFieldCipher(key string) (*FieldCipher, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key encoding: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("invalid encryption key length: got %d bytes, want 32", len(raw))
+	}
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FieldCipher{aead: aead}, nil
+}
+
+// Encrypt encrypts a field value into a prefixed base64 string
+func (c *FieldCipher) Encrypt(value interface{}) (string, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode field value: %w", err)
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, plaintext, nil)
+	return encryptedValuePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt
+func (c *FieldCipher) Decrypt(value string) (interface{}, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedValuePrefix)
+	if !ok {
+		return nil, fmt.Errorf("value is not encrypted")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted value encoding: %w", err)
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted value too short")
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt field value: %w", err)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(plaintext, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode field value: %w", err)
+	}
+	return decoded, nil
+}
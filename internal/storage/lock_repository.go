@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// releaseLockScript deletes a lock only while it's still held with the given token,
+// so a holder whose lock already expired can't release someone else's
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// LockRepository defines interface for short-lived per-user locks
+type LockRepository interface {
+	AcquireUserLock(ctx context.Context, userID string, ttl time.Duration) (string, bool, error)
+	ReleaseUserLock(ctx context.Context, userID, token string) error
+}
+
+// RedisLockRepository implements LockRepository for Redis
+type RedisLockRepository struct {
+	client *RedisClient
+}
+
+// NewRedisLockRepository creates a new Redis lock repository
+func NewRedisLockRepository(client *RedisClient) *RedisLockRepository {
+	return &RedisLockRepository{client: client}
+}
+
+// AcquireUserLock tries to take the user's lock for ttl. It returns the token needed
+// to release the lock and whether the lock was acquired.
+func (r *RedisLockRepository) AcquireUserLock(ctx context.Context, userID string, ttl time.Duration) (string, bool, error) {
+	token := uuid.NewString()
+	ok, err := r.client.client.SetNX(ctx, GenerateUserLockKey(userID), token, ttl).Result()
+	if err != nil || !ok {
+		return "", false, err
+	}
+	return token, true, nil
+}
+
+// ReleaseUserLock releases the user's lock if it's still held with token
+func (r *RedisLockRepository) ReleaseUserLock(ctx context.Context, userID, token string) error {
+	return releaseLockScript.Run(ctx, r.client.client, []string{GenerateUserLockKey(userID)}, token).Err()
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MaintenanceRepository defines interface for the maintenance mode flag shared by all
+// instances
+type MaintenanceRepository interface {
+	SetEnabled(ctx context.Context, enabled bool) error
+	Get(ctx context.Context) (enabled bool, set bool, err error)
+}
+
+// RedisMaintenanceRepository implements MaintenanceRepository for Redis. The flag lives
+// in one global key, so every instance sees it.
+type RedisMaintenanceRepository struct {
+	client *RedisClient
+}
+
+// NewRedisMaintenanceRepository creates a new Redis maintenance repository
+func NewRedisMaintenanceRepository(client *RedisClient) *RedisMaintenanceRepository {
+	return &RedisMaintenanceRepository{client: client}
+}
+
+// SetEnabled turns maintenance mode on or off for all instances
+func (r *RedisMaintenanceRepository) SetEnabled(ctx context.Context, enabled bool) error {
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+	return r.client.client.Set(ctx, MaintenanceKey, value, 0).Err()
+}
+
+// Get returns the maintenance mode flag; set is false until it was first turned on or off
+func (r *RedisMaintenanceRepository) Get(ctx context.Context) (bool, bool, error) {
+	value, err := r.client.client.Get(ctx, MaintenanceKey).Result()
+	if err == redis.Nil {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return value == "1", true, nil
+}
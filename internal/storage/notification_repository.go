@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// NotificationRepository defines interface for submission notification state
+type NotificationRepository interface {
+	AcquireThrottle(ctx context.Context, widgetID string, interval time.Duration) (bool, error)
+	AddToDigest(ctx context.Context, widgetID, submissionID string) error
+	GetPendingDigests(ctx context.Context) ([]string, error)
+	PopDigest(ctx context.Context, widgetID string) ([]string, error)
+}
+
+// RedisNotificationRepository implements NotificationRepository for Redis
+type RedisNotificationRepository struct {
+	client *RedisClient
+}
+
+// NewRedisNotificationRepository creates a new Redis notification repository
+func NewRedisNotificationRepository(client *RedisClient) *RedisNotificationRepository {
+	return &RedisNotificationRepository{client: client}
+}
+
+// AcquireThrottle reports whether a notification may be sent now and, if so,
+// blocks further notifications for the widget until the interval passes
+func (r *RedisNotificationRepository) AcquireThrottle(ctx context.Context, widgetID string, interval time.Duration) (bool, error) {
+	return r.client.client.SetNX(ctx, GenerateNotifyThrottleKey(widgetID), time.Now().Unix(), interval).Result()
+}
+
+// AddToDigest queues a submission for the widget's next digest
+func (r *RedisNotificationRepository) AddToDigest(ctx context.Context, widgetID, submissionID string) error {
+	if err := r.client.client.RPush(ctx, GenerateNotifyDigestKey(widgetID), submissionID).Err(); err != nil {
+		return err
+	}
+
+	// Pending set is global, so it can't share a transaction with the widget's list
+	return r.client.client.SAdd(ctx, NotifyDigestPendingKey, widgetID).Err()
+}
+
+// GetPendingDigests returns IDs of widgets with queued digest submissions
+func (r *RedisNotificationRepository) GetPendingDigests(ctx context.Context) ([]string, error) {
+	return r.client.client.SMembers(ctx, NotifyDigestPendingKey).Result()
+}
+
+// PopDigest returns and clears the queued submission IDs of a widget
+func (r *RedisNotificationRepository) PopDigest(ctx context.Context, widgetID string) ([]string, error) {
+	// Unmark first: a submission queued meanwhile marks the widget again
+	if err := r.client.client.SRem(ctx, NotifyDigestPendingKey, widgetID).Err(); err != nil {
+		return nil, err
+	}
+
+	digestKey := GenerateNotifyDigestKey(widgetID)
+
+	pipe := r.client.client.TxPipeline()
+	idsCmd := pipe.LRange(ctx, digestKey, 0, -1)
+	pipe.Del(ctx, digestKey)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return idsCmd.Val(), nil
+}
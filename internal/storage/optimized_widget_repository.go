@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
@@ -89,14 +88,10 @@ func (r *OptimizedWidgetRepository) GetByUserIDWithFiltersOptimized(ctx context.
 func (r *OptimizedWidgetRepository) getFilteredWidgetIDsOptimized(ctx context.Context, userID string, filters *models.FilterOptions) ([]string, error) {
 	userWidgetsKey := GenerateUserWidgetsKey(userID)
 
-	// If we only have user widgets (no additional filters), get all user widgets
+	// If we only have user widgets (no additional filters), get all user widgets in the
+	// creation date range, newest first
 	if !filters.HasTypeFilter() && !filters.HasVisibilityFilter() {
-		// Get all user widgets sorted by creation time (newest first)
-		widgetIDs, err := r.client.client.ZRevRange(ctx, userWidgetsKey, 0, -1).Result()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get user widgets: %w", err)
-		}
-		return widgetIDs, nil
+		return r.getUserWidgetIDsCreatedIn(ctx, userWidgetsKey, filters)
 	}
 
 	// For multiple type filters, we need special handling
@@ -111,10 +106,10 @@ func (r *OptimizedWidgetRepository) getFilteredWidgetIDsOptimized(ctx context.Co
 	tempUserSetKey := fmt.Sprintf("temp:user_set:%s:%d", userID, time.Now().UnixNano())
 	defer r.client.client.Del(ctx, tempUserSetKey) // Clean up temp key
 
-	// Get all user widget IDs and add them to a temporary SET
-	userWidgetIDs, err := r.client.client.ZRange(ctx, userWidgetsKey, 0, -1).Result()
+	// Get the user widget IDs in the creation date range and add them to a temporary SET
+	userWidgetIDs, err := r.getUserWidgetIDsCreatedIn(ctx, userWidgetsKey, filters)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user widgets: %w", err)
+		return nil, err
 	}
 
 	if len(userWidgetIDs) == 0 {
@@ -179,10 +174,10 @@ func (r *OptimizedWidgetRepository) getFilteredWidgetIDsWithMultipleTypesOptimiz
 	tempUserSetKey := fmt.Sprintf("temp:user_set:%s:%d", userID, time.Now().UnixNano())
 	defer r.client.client.Del(ctx, tempUserSetKey) // Clean up temp key
 
-	// Get all user widget IDs and add them to a temporary SET with pre-allocation
-	userWidgetIDs, err := r.client.client.ZRange(ctx, userWidgetsKey, 0, -1).Result()
+	// Get the user widget IDs in the creation date range and add them to a temporary SET
+	userWidgetIDs, err := r.getUserWidgetIDsCreatedIn(ctx, userWidgetsKey, filters)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user widgets: %w", err)
+		return nil, err
 	}
 
 	if len(userWidgetIDs) == 0 {
@@ -251,9 +246,10 @@ func (r *OptimizedWidgetRepository) sortWidgetIDsByCreationTimeOptimized(ctx con
 		}
 	}
 
-	// Sort by creation time (highest score/newest first) using standard sort
+	// Sort by creation time (highest score/newest first), then by ID
 	sort.Slice(widgetsWithTime, func(i, j int) bool {
-		return widgetsWithTime[i].time > widgetsWithTime[j].time // Descending order (newest first)
+		a, b := widgetsWithTime[i], widgetsWithTime[j]
+		return newerWidget(int64(a.time), a.id, int64(b.time), b.id)
 	})
 
 	// Extract sorted widget IDs
@@ -375,9 +371,9 @@ func (r *OptimizedWidgetRepository) batchLoadWidgetsBatch(ctx context.Context, w
 		statsCommands[i] = pipe.HGetAll(ctx, statsKey)
 	}
 
-	// Execute all commands at once
+	// Execute all commands at once; errors of single commands are handled per widget
 	_, err := pipe.Exec(ctx)
-	if err != nil {
+	if _, isCommandErr := err.(redis.Error); err != nil && !isCommandErr {
 		return nil, fmt.Errorf("failed to batch load widget data: %w", err)
 	}
 
@@ -397,11 +393,11 @@ func (r *OptimizedWidgetRepository) batchLoadWidgetsBatch(ctx context.Context, w
 			continue // Skip widgets with invalid data
 		}
 
-		// Parse stats data - this is optional
-		if statsHash, err := statsCommands[i].Result(); err == nil && len(statsHash) > 0 {
-			if stats := r.parseWidgetStats(widgetID, statsHash); stats != nil {
-				widget.Stats = stats
-			}
+		// Parse stats data, a missing stats hash means no events yet
+		if statsHash, err := statsCommands[i].Result(); err != nil {
+			setListingStats(widget, nil, err)
+		} else {
+			setListingStats(widget, parseWidgetStatsHash(widgetID, statsHash), nil)
 		}
 
 		widgets = append(widgets, widget)
@@ -409,35 +405,3 @@ func (r *OptimizedWidgetRepository) batchLoadWidgetsBatch(ctx context.Context, w
 
 	return widgets, nil
 }
-
-// parseWidgetStats parses widget stats from Redis hash with error handling
-func (r *OptimizedWidgetRepository) parseWidgetStats(widgetID string, statsHash map[string]string) *models.WidgetStats {
-	stats := &models.WidgetStats{WidgetID: widgetID}
-
-	// Parse numeric fields with error handling
-	if viewsStr, ok := statsHash["views"]; ok {
-		if views, err := strconv.ParseInt(viewsStr, 10, 64); err == nil {
-			stats.Views = views
-		}
-	}
-
-	if submitsStr, ok := statsHash["submits"]; ok {
-		if submits, err := strconv.ParseInt(submitsStr, 10, 64); err == nil {
-			stats.Submits = submits
-		}
-	}
-
-	if closesStr, ok := statsHash["closes"]; ok {
-		if closes, err := strconv.ParseInt(closesStr, 10, 64); err == nil {
-			stats.Closes = closes
-		}
-	}
-
-	if lastViewStr, ok := statsHash["last_view"]; ok {
-		if timestamp, err := strconv.ParseInt(lastViewStr, 10, 64); err == nil {
-			stats.LastView = time.Unix(timestamp, 0)
-		}
-	}
-
-	return stats
-}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ad/leads-core/internal/models"
+)
+
+// PausedTypesRepository defines interface for widget types with submissions paused
+type PausedTypesRepository interface {
+	Pause(ctx context.Context, widgetType string) error
+	Resume(ctx context.Context, widgetType string) (bool, error)
+	IsPaused(ctx context.Context, widgetType string) (bool, error)
+	List(ctx context.Context) ([]*models.PausedWidgetType, error)
+}
+
+// RedisPausedTypesRepository implements PausedTypesRepository for Redis. The flags
+// live in one global hash, so every instance sees them.
+type RedisPausedTypesRepository struct {
+	client *RedisClient
+}
+
+// NewRedisPausedTypesRepository creates a new Redis paused types repository
+func NewRedisPausedTypesRepository(client *RedisClient) *RedisPausedTypesRepository {
+	return &RedisPausedTypesRepository{client: client}
+}
+
+// Pause blocks submissions to widgets of the type, keeping the original pause time
+// when the type is already paused
+func (r *RedisPausedTypesRepository) Pause(ctx context.Context, widgetType string) error {
+	return r.client.client.HSetNX(ctx, PausedTypesKey, widgetType, time.Now().Unix()).Err()
+}
+
+// Resume lifts the pause and reports whether the type was paused
+func (r *RedisPausedTypesRepository) Resume(ctx context.Context, widgetType string) (bool, error) {
+	removed, err := r.client.client.HDel(ctx, PausedTypesKey, widgetType).Result()
+	return removed > 0, err
+}
+
+// IsPaused reports whether submissions to widgets of the type are paused
+func (r *RedisPausedTypesRepository) IsPaused(ctx context.Context, widgetType string) (bool, error) {
+	return r.client.client.HExists(ctx, PausedTypesKey, widgetType).Result()
+}
+
+// List returns the paused types sorted by name
+func (r *RedisPausedTypesRepository) List(ctx context.Context) ([]*models.PausedWidgetType, error) {
+	hash, err := r.client.client.HGetAll(ctx, PausedTypesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]*models.PausedWidgetType, 0, len(hash))
+	for widgetType, pausedAt := range hash {
+		paused := &models.PausedWidgetType{Type: widgetType}
+		if timestamp, err := strconv.ParseInt(pausedAt, 10, 64); err == nil {
+			paused.PausedAt = time.Unix(timestamp, 0)
+		}
+		types = append(types, paused)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Type < types[j].Type
+	})
+	return types, nil
+}
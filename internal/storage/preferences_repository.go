@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/ad/leads-core/internal/models"
+)
+
+// PreferencesRepository defines interface for user preferences storage
+type PreferencesRepository interface {
+	Get(ctx context.Context, userID string) (*models.UserPreferences, error)
+	Set(ctx context.Context, userID string, preferences *models.UserPreferences) error
+}
+
+// RedisPreferencesRepository implements PreferencesRepository for Redis
+type RedisPreferencesRepository struct {
+	client *RedisClient
+}
+
+// NewRedisPreferencesRepository creates a new Redis preferences repository
+func NewRedisPreferencesRepository(client *RedisClient) *RedisPreferencesRepository {
+	return &RedisPreferencesRepository{client: client}
+}
+
+// Get retrieves the user's preferences; users without stored preferences get empty ones
+func (r *RedisPreferencesRepository) Get(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	hash, err := r.client.client.HGetAll(ctx, GenerateUserPreferencesKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserPreferences{ExportFormat: hash["export_format"]}, nil
+}
+
+// Set replaces the user's preferences, dropping empty values
+func (r *RedisPreferencesRepository) Set(ctx context.Context, userID string, preferences *models.UserPreferences) error {
+	key := GenerateUserPreferencesKey(userID)
+
+	pipe := r.client.client.TxPipeline()
+	if preferences.ExportFormat != "" {
+		pipe.HSet(ctx, key, "export_format", preferences.ExportFormat)
+	} else {
+		pipe.HDel(ctx, key, "export_format")
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ad/leads-core/internal/config"
@@ -160,30 +161,77 @@ func (r *RedisClient) GetClient() redis.UniversalClient {
 // Redis key patterns with hash tags for cluster compatibility
 const (
 	// Widgets - use {widgetID} hash tag to ensure related keys are in same slot
-	WidgetKey          = "{%s}:widget"          // HASH - widget data
-	WidgetsByTimeKey   = "widgets:by_time"      // ZSET - all widgets by timestamp (global)
-	UserWidgetsKey     = "{%s}:user:widgets"    // SET - user's widgets
-	WidgetsByTypeKey   = "widgets:type:%s"      // SET - widgets by type (global)
-	WidgetsByStatusKey = "widgets:isVisible:%s" // SET - widgets by status (0|1) (global)
+	WidgetKey          = "{%s}:widget"            // HASH - widget data
+	WidgetsByTimeKey   = "widgets:by_time"        // ZSET - all widgets by timestamp (global)
+	UserWidgetsKey     = "{%s}:user:widgets"      // SET - user's widgets
+	WidgetsByTypeKey   = "widgets:type:%s"        // SET - widgets by type (global)
+	WidgetsByStatusKey = "widgets:isVisible:%s"   // SET - widgets by status (0|1) (global)
+	WidgetsExpiringKey = "widgets:expiring"       // ZSET - auto-expiring widgets by expiry timestamp (global)
+	WidgetsExpiryIndex = "widgets:expiring:index" // HASH - widget ID -> index entries to clean up after expiry (global)
+	WidgetsPIIKey      = "widgets:pii"            // SET - widgets with PII redaction configured (global)
+	PausedTypesKey     = "widget_types:paused"    // HASH - widget type -> pause timestamp, submissions blocked (global)
+	MaintenanceKey     = "maintenance:enabled"    // STRING - "1" or "0", maintenance mode toggled at runtime (global)
+	UserLockKey        = "{%s}:user:lock"         // STRING - per-user lock token, held while creating widgets
+	UserWidgetNamesKey = "{%s}:user:widget_names" // HASH - normalized widget name -> widget ID, per user
+	UserArchivedKey    = "{%s}:user:archived"     // SET - user's archived widgets
+	UserPreferencesKey = "{%s}:user:preferences"  // HASH - user preferences
+	UserExportsKey     = "{%s}:user:exports:%s"   // STRING - number of exports by the user on a day (YYYY-MM-DD, UTC)
+
+	// Config history - use {widgetID} hash tag to group with widget data
+	WidgetConfigHistoryKey = "{%s}:config:history" // LIST - JSON of replaced widget configs, newest first
 
 	// Submissions - use {widgetID} hash tag to group with widget data
 	SubmissionKey        = "{%s}:submission:%s" // HASH - submission data
 	WidgetSubmissionsKey = "{%s}:submissions"   // ZSET - widget submissions by timestamp
+	PIIRedactedUntilKey  = "{%s}:pii:redacted"  // STRING - timestamp up to which submissions had PII redacted
+
+	SubmissionCorrelationKey = "{%s}:submissions:correlation" // HASH - SHA-256 of correlation value -> latest submission ID
+	SubmissionDedupKey       = "{%s}:submissions:dedup:%s"    // STRING - ID of the first submission with a fingerprint, expires with the dedup window
+
+	// Export jobs - use {widgetID} hash tag to group with widget data
+	ExportJobKey        = "{%s}:export_job:%s"      // HASH - export job record
+	ExportJobDataKey    = "{%s}:export_job:%s:data" // STRING - generated export file
+	WidgetExportJobsKey = "{%s}:export_jobs"        // ZSET - widget export jobs by creation timestamp
+	ExportSlotsKey      = "exports:slots"           // ZSET - export slot lease ID -> lease expiry (unix ms) (global)
 
 	// Statistics - use {widgetID} hash tag to group with widget data
-	WidgetStatsKey = "{%s}:stats"    // HASH - widget statistics
-	DailyViewsKey  = "{%s}:views:%s" // INCR - daily views (YYYY-MM-DD)
+	WidgetStatsKey  = "{%s}:stats"        // HASH - widget statistics
+	DailyViewsKey   = "{%s}:views:%s"     // INCR - daily views (YYYY-MM-DD)
+	DailySubmitsKey = "{%s}:submits:%s"   // INCR - daily submits (YYYY-MM-DD)
+	DailyClosesKey  = "{%s}:closes:%s"    // INCR - daily closes (YYYY-MM-DD)
+	ViewSeenKey     = "{%s}:view_seen:%s" // STRING - present while a visitor's views are not counted again
+
+	// Notifications - use {widgetID} hash tag to group with widget data
+	NotifyThrottleKey      = "{%s}:notify:throttle"  // STRING - present while notifications are throttled
+	NotifyDigestKey        = "{%s}:notify:digest"    // LIST - submission IDs waiting for the next digest
+	NotifyDigestPendingKey = "notify:digest:pending" // SET - widgets with a pending digest (global)
+
+	// Refresh tokens - keyed by the token's SHA-256, never the token itself
+	RefreshTokenKey = "auth:refresh:%s" // HASH - refresh token record, expires with the token (global)
+	RevokedTokenKey = "auth:revoked:%s" // STRING - revoked access token jti, expires with the token (global)
 
 	// Rate limiting with hash tags for cluster compatibility
-	RateLimitIPKey     = "rate_limit:{%s}:ip:%s"  // INCR - IP rate limit with hash tag
-	RateLimitGlobalKey = "rate_limit:{%s}:global" // INCR - global rate limit with hash tag
+	RateLimitIPKey     = "rate_limit:{%s}:ip:%s"     // INCR - IP rate limit with hash tag
+	RateLimitGlobalKey = "rate_limit:{%s}:global"    // INCR - global rate limit with hash tag
+	RateLimitWidgetKey = "rate_limit:{%s}:widget:%s" // INCR - per-widget rate limit with hash tag
 )
 
+// keyPattern turns a key format into a pattern (e.g. "{%s}:widget" -> "{*}:widget")
+// used to label metrics and logs without exposing concrete keys
+func keyPattern(format string) string {
+	return strings.ReplaceAll(format, "%s", "*")
+}
+
 // GenerateWidgetKey generates a widget key with hash tag
 func GenerateWidgetKey(widgetID string) string {
 	return fmt.Sprintf(WidgetKey, widgetID)
 }
 
+// GenerateWidgetConfigHistoryKey generates a widget config history key with hash tag
+func GenerateWidgetConfigHistoryKey(widgetID string) string {
+	return fmt.Sprintf(WidgetConfigHistoryKey, widgetID)
+}
+
 // GenerateUserWidgetsKey generates a user widgets key with hash tag
 func GenerateUserWidgetsKey(userID string) string {
 	return fmt.Sprintf(UserWidgetsKey, userID)
@@ -213,21 +261,116 @@ func GenerateWidgetSubmissionsKey(widgetID string) string {
 	return fmt.Sprintf(WidgetSubmissionsKey, widgetID)
 }
 
+// GeneratePIIRedactedUntilKey generates a PII redaction cursor key with hash tag
+func GeneratePIIRedactedUntilKey(widgetID string) string {
+	return fmt.Sprintf(PIIRedactedUntilKey, widgetID)
+}
+
+// GenerateSubmissionCorrelationKey generates a submission correlation index key with hash tag
+func GenerateSubmissionCorrelationKey(widgetID string) string {
+	return fmt.Sprintf(SubmissionCorrelationKey, widgetID)
+}
+
+// GenerateExportJobKey generates an export job key with hash tag
+func GenerateExportJobKey(widgetID, jobID string) string {
+	return fmt.Sprintf(ExportJobKey, widgetID, jobID)
+}
+
+// GenerateExportJobDataKey generates an export job data key with hash tag
+func GenerateExportJobDataKey(widgetID, jobID string) string {
+	return fmt.Sprintf(ExportJobDataKey, widgetID, jobID)
+}
+
+// GenerateWidgetExportJobsKey generates a widget export jobs key with hash tag
+func GenerateWidgetExportJobsKey(widgetID string) string {
+	return fmt.Sprintf(WidgetExportJobsKey, widgetID)
+}
+
 // GenerateWidgetStatsKey generates a widget stats key with hash tag
 func GenerateWidgetStatsKey(widgetID string) string {
 	return fmt.Sprintf(WidgetStatsKey, widgetID)
 }
 
+// GenerateUserLockKey generates a user lock key with hash tag
+func GenerateUserLockKey(userID string) string {
+	return fmt.Sprintf(UserLockKey, userID)
+}
+
+// GenerateUserPreferencesKey generates a user preferences key with hash tag
+func GenerateUserPreferencesKey(userID string) string {
+	return fmt.Sprintf(UserPreferencesKey, userID)
+}
+
+// GenerateUserExportsKey generates a user daily exports counter key with hash tag
+func GenerateUserExportsKey(userID, date string) string {
+	return fmt.Sprintf(UserExportsKey, userID, date)
+}
+
+// GenerateUserWidgetNamesKey generates a user widget names key with hash tag
+func GenerateUserWidgetNamesKey(userID string) string {
+	return fmt.Sprintf(UserWidgetNamesKey, userID)
+}
+
+// GenerateUserArchivedKey generates the user's archived widgets key with hash tag
+func GenerateUserArchivedKey(userID string) string {
+	return fmt.Sprintf(UserArchivedKey, userID)
+}
+
 // GenerateDailyViewsKey generates a daily views key with hash tag
 func GenerateDailyViewsKey(widgetID, date string) string {
 	return fmt.Sprintf(DailyViewsKey, widgetID, date)
 }
 
+// GenerateDailySubmitsKey generates a daily submits key with hash tag
+func GenerateDailySubmitsKey(widgetID, date string) string {
+	return fmt.Sprintf(DailySubmitsKey, widgetID, date)
+}
+
+// GenerateDailyClosesKey generates a daily closes key with hash tag
+func GenerateDailyClosesKey(widgetID, date string) string {
+	return fmt.Sprintf(DailyClosesKey, widgetID, date)
+}
+
+// GenerateSubmissionDedupKey generates a submission deduplication key with hash tag
+func GenerateSubmissionDedupKey(widgetID, fingerprint string) string {
+	return fmt.Sprintf(SubmissionDedupKey, widgetID, fingerprint)
+}
+
+// GenerateViewSeenKey generates a view deduplication key with hash tag
+func GenerateViewSeenKey(widgetID, visitor string) string {
+	return fmt.Sprintf(ViewSeenKey, widgetID, visitor)
+}
+
+// GenerateNotifyThrottleKey generates a notification throttle key with hash tag
+func GenerateNotifyThrottleKey(widgetID string) string {
+	return fmt.Sprintf(NotifyThrottleKey, widgetID)
+}
+
+// GenerateNotifyDigestKey generates a notification digest key with hash tag
+func GenerateNotifyDigestKey(widgetID string) string {
+	return fmt.Sprintf(NotifyDigestKey, widgetID)
+}
+
+// GenerateRefreshTokenKey generates a refresh token key from the token hash
+func GenerateRefreshTokenKey(tokenHash string) string {
+	return fmt.Sprintf(RefreshTokenKey, tokenHash)
+}
+
+// GenerateRevokedTokenKey generates a revoked access token key from the token's jti
+func GenerateRevokedTokenKey(jti string) string {
+	return fmt.Sprintf(RevokedTokenKey, jti)
+}
+
 // GenerateRateLimitIPKey generates a rate limit IP key
 func GenerateRateLimitIPKey(ip, window string) string {
 	return fmt.Sprintf(RateLimitIPKey, window, ip)
 }
 
+// GenerateRateLimitWidgetKey generates a per-widget rate limit key
+func GenerateRateLimitWidgetKey(widgetID, window string) string {
+	return fmt.Sprintf(RateLimitWidgetKey, window, widgetID)
+}
+
 // GenerateRateLimitGlobalKey generates a rate limit global key
 func GenerateRateLimitGlobalKey(window string) string {
 	return fmt.Sprintf(RateLimitGlobalKey, window)
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// revokeRefreshTokenScript marks a stored refresh token revoked, keeping the first
+// revocation time; missing (e.g. just expired) records aren't recreated
+var revokeRefreshTokenScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+return redis.call("HSETNX", KEYS[1], "revoked_at", ARGV[1])
+`)
+
+// RefreshTokenRepository defines interface for refresh token storage. Tokens are
+// stored by their hash, so a leaked database doesn't leak usable tokens.
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, tokenHash string, token *models.RefreshToken) error
+	Get(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	Revoke(ctx context.Context, tokenHash string, now time.Time) (bool, error)
+}
+
+// RedisRefreshTokenRepository implements RefreshTokenRepository for Redis. Records
+// expire with their token; revoked ones are kept until then to tell them apart.
+type RedisRefreshTokenRepository struct {
+	client *RedisClient
+}
+
+// NewRedisRefreshTokenRepository creates a new Redis refresh token repository
+func NewRedisRefreshTokenRepository(client *RedisClient) *RedisRefreshTokenRepository {
+	return &RedisRefreshTokenRepository{client: client}
+}
+
+// Create stores the refresh token record until the token expires
+func (r *RedisRefreshTokenRepository) Create(ctx context.Context, tokenHash string, token *models.RefreshToken) error {
+	key := GenerateRefreshTokenKey(tokenHash)
+
+	pipe := r.client.client.TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"user_id":    token.User.ID,
+		"username":   token.User.Username,
+		"plan":       token.User.Plan,
+		"role":       token.User.Role,
+		"token_id":   token.TokenID,
+		"created_at": token.CreatedAt.Unix(),
+		"expires_at": token.ExpiresAt.Unix(),
+	})
+	pipe.ExpireAt(ctx, key, token.ExpiresAt)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Get retrieves a refresh token record, errors.ErrNotFound for unknown or expired tokens
+func (r *RedisRefreshTokenRepository) Get(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	hash, err := r.client.client.HGetAll(ctx, GenerateRefreshTokenKey(tokenHash)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) == 0 {
+		return nil, errors.ErrNotFound
+	}
+
+	token := &models.RefreshToken{
+		User: models.User{
+			ID:       hash["user_id"],
+			Username: hash["username"],
+			Plan:     hash["plan"],
+			Role:     hash["role"],
+		},
+		TokenID: hash["token_id"],
+	}
+	if timestamp, err := strconv.ParseInt(hash["created_at"], 10, 64); err == nil {
+		token.CreatedAt = time.Unix(timestamp, 0)
+	}
+	if timestamp, err := strconv.ParseInt(hash["expires_at"], 10, 64); err == nil {
+		token.ExpiresAt = time.Unix(timestamp, 0)
+	}
+	if timestamp, err := strconv.ParseInt(hash["revoked_at"], 10, 64); err == nil {
+		revokedAt := time.Unix(timestamp, 0)
+		token.RevokedAt = &revokedAt
+	}
+	return token, nil
+}
+
+// Revoke marks the refresh token revoked and reports whether it was stored and not
+// revoked yet
+func (r *RedisRefreshTokenRepository) Revoke(ctx context.Context, tokenHash string, now time.Time) (bool, error) {
+	revoked, err := revokeRefreshTokenScript.Run(ctx, r.client.client, []string{GenerateRefreshTokenKey(tokenHash)}, now.Unix()).Int()
+	return revoked == 1, err
+}
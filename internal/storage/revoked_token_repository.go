@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// RevokedTokenRepository defines interface for the revocation list of access tokens,
+// keyed by their jti claim
+type RevokedTokenRepository interface {
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// RedisRevokedTokenRepository implements RevokedTokenRepository for Redis. Entries
+// expire together with the token, so the list only holds tokens that would still pass
+// validation otherwise.
+type RedisRevokedTokenRepository struct {
+	client *RedisClient
+}
+
+// NewRedisRevokedTokenRepository creates a new Redis revoked token repository
+func NewRedisRevokedTokenRepository(client *RedisClient) *RedisRevokedTokenRepository {
+	return &RedisRevokedTokenRepository{client: client}
+}
+
+// Revoke adds the jti to the revocation list for ttl, for good when ttl is 0
+func (r *RedisRevokedTokenRepository) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	return r.client.client.Set(ctx, GenerateRevokedTokenKey(jti), time.Now().Unix(), ttl).Err()
+}
+
+// IsRevoked reports whether the jti is on the revocation list
+func (r *RedisRevokedTokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	count, err := r.client.client.Exists(ctx, GenerateRevokedTokenKey(jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/pkg/logger"
+)
+
+// maxCachedStats bounds the number of widgets whose stats are cached; entries past the
+// stale limit are dropped when it's reached
+const maxCachedStats = 10000
+
+// CachedStatsRepository caches widget stats in-process for a short TTL, so hot
+// dashboards don't read Redis on every request. When a fresh read takes longer than
+// the latency budget or fails, the last cached stats (up to maxStale old) are served
+// marked stale instead. Resets invalidate the cache; counters rely on the short TTL.
+type CachedStatsRepository struct {
+	StatsRepository
+	ttl      time.Duration
+	budget   time.Duration
+	maxStale time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedStats
+}
+
+// cachedStats is a cached stats read and when it was made
+type cachedStats struct {
+	stats    models.WidgetStats
+	storedAt time.Time
+}
+
+// NewCachedStatsRepository wraps the stats repository with a cache of stats reads valid
+// for ttl; fresh reads get at most budget (0 = no limit) before stale stats up to
+// maxStale old are served
+func NewCachedStatsRepository(repo StatsRepository, ttl, budget, maxStale time.Duration) *CachedStatsRepository {
+	if maxStale < ttl {
+		maxStale = ttl
+	}
+	return &CachedStatsRepository{
+		StatsRepository: repo,
+		ttl:             ttl,
+		budget:          budget,
+		maxStale:        maxStale,
+		now:             time.Now,
+		entries:         make(map[string]cachedStats),
+	}
+}
+
+// GetWidgetStats returns cached stats within the TTL, otherwise reads them, falling back
+// to stale cached stats when the read is too slow or fails
+func (r *CachedStatsRepository) GetWidgetStats(ctx context.Context, widgetID string) (*models.WidgetStats, error) {
+	cached, ok := r.get(widgetID)
+	age := r.now().Sub(cached.storedAt)
+	if ok && age < r.ttl {
+		return r.result(cached.stats, models.StatsCacheHit, false), nil
+	}
+
+	readCtx := ctx
+	if r.budget > 0 {
+		var cancel context.CancelFunc
+		readCtx, cancel = context.WithTimeout(ctx, r.budget)
+		defer cancel()
+	}
+
+	stats, err := r.StatsRepository.GetWidgetStats(readCtx, widgetID)
+	if err == nil {
+		r.set(widgetID, *stats)
+		return r.result(*stats, models.StatsCacheMiss, false), nil
+	}
+
+	// The caller gave up, stale stats wouldn't reach anyone
+	if ok && age < r.maxStale && ctx.Err() == nil {
+		logger.Warn("Serving stale widget stats", map[string]interface{}{
+			"action":    "get_widget_stats",
+			"widget_id": widgetID,
+			"age":       age.String(),
+			"error":     err.Error(),
+		})
+		return r.result(cached.stats, models.StatsCacheHit, true), nil
+	}
+	return nil, err
+}
+
+// ResetStats resets the stats and drops them from the cache
+func (r *CachedStatsRepository) ResetStats(ctx context.Context, widgetID string) error {
+	err := r.StatsRepository.ResetStats(ctx, widgetID)
+	r.mu.Lock()
+	delete(r.entries, widgetID)
+	r.mu.Unlock()
+	return err
+}
+
+// get returns the cached stats of the widget
+func (r *CachedStatsRepository) get(widgetID string) (cachedStats, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cached, ok := r.entries[widgetID]
+	return cached, ok
+}
+
+// set caches the stats of the widget, first dropping entries too old to be served
+// when the cache is full
+func (r *CachedStatsRepository) set(widgetID string, stats models.WidgetStats) {
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[widgetID]; !ok && len(r.entries) >= maxCachedStats {
+		for id, cached := range r.entries {
+			if now.Sub(cached.storedAt) >= r.maxStale {
+				delete(r.entries, id)
+			}
+		}
+		if len(r.entries) >= maxCachedStats {
+			return
+		}
+	}
+	r.entries[widgetID] = cachedStats{stats: stats, storedAt: now}
+}
+
+// result returns a copy of the stats, so callers can't change the cached ones
+func (r *CachedStatsRepository) result(stats models.WidgetStats, status string, stale bool) *models.WidgetStats {
+	stats.CacheStatus = status
+	stats.Stale = stale
+	return &stats
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/internal/models"
+)
+
+// fakeStatsRepository returns its views count as stats, after its delay or with its error
+type fakeStatsRepository struct {
+	StatsRepository
+	views int64
+	delay time.Duration
+	err   error
+	reads int
+}
+
+func (f *fakeStatsRepository) GetWidgetStats(ctx context.Context, widgetID string) (*models.WidgetStats, error) {
+	f.reads++
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &models.WidgetStats{WidgetID: widgetID, Views: f.views}, nil
+}
+
+func (f *fakeStatsRepository) ResetStats(ctx context.Context, widgetID string) error {
+	f.views = 0
+	return nil
+}
+
+func TestCachedStatsRepository(t *testing.T) {
+	ctx := context.Background()
+	inner := &fakeStatsRepository{views: 5}
+	repo := NewCachedStatsRepository(inner, time.Minute, 20*time.Millisecond, 10*time.Minute)
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+
+	stats, err := repo.GetWidgetStats(ctx, "widget-1")
+	if err != nil {
+		t.Fatalf("GetWidgetStats failed: %v", err)
+	}
+	if stats.Views != 5 || stats.CacheStatus != models.StatsCacheMiss || stats.Stale {
+		t.Fatalf("Expected fresh stats with 5 views, got %+v", stats)
+	}
+
+	// Within the TTL stats come from the cache, and changing them doesn't change the cache
+	stats.Views = 100
+	inner.views = 6
+	stats, err = repo.GetWidgetStats(ctx, "widget-1")
+	if err != nil {
+		t.Fatalf("GetWidgetStats failed: %v", err)
+	}
+	if stats.Views != 5 || stats.CacheStatus != models.StatsCacheHit || stats.Stale || inner.reads != 1 {
+		t.Fatalf("Expected cached stats with 5 views, got %+v after %d reads", stats, inner.reads)
+	}
+
+	// Past the TTL stats are read again
+	now = now.Add(2 * time.Minute)
+	stats, err = repo.GetWidgetStats(ctx, "widget-1")
+	if err != nil {
+		t.Fatalf("GetWidgetStats failed: %v", err)
+	}
+	if stats.Views != 6 || stats.CacheStatus != models.StatsCacheMiss {
+		t.Fatalf("Expected fresh stats with 6 views, got %+v", stats)
+	}
+
+	// Failing reads serve the cached stats marked stale
+	now = now.Add(2 * time.Minute)
+	inner.err = fmt.Errorf("connection refused")
+	stats, err = repo.GetWidgetStats(ctx, "widget-1")
+	if err != nil {
+		t.Fatalf("Expected stale stats, got error: %v", err)
+	}
+	if stats.Views != 6 || stats.CacheStatus != models.StatsCacheHit || !stats.Stale {
+		t.Fatalf("Expected stale stats with 6 views, got %+v", stats)
+	}
+
+	// So do reads slower than the latency budget
+	inner.err = nil
+	inner.delay = time.Second
+	stats, err = repo.GetWidgetStats(ctx, "widget-1")
+	if err != nil {
+		t.Fatalf("Expected stale stats, got error: %v", err)
+	}
+	if !stats.Stale || stats.Views != 6 {
+		t.Fatalf("Expected stale stats with 6 views, got %+v", stats)
+	}
+
+	// Stats older than the max stale age are not served
+	now = now.Add(15 * time.Minute)
+	if _, err := repo.GetWidgetStats(ctx, "widget-1"); err == nil {
+		t.Fatal("Expected an error once cached stats are too old")
+	}
+
+	// Without cached stats the read error is returned
+	if _, err := repo.GetWidgetStats(ctx, "widget-2"); err == nil {
+		t.Fatal("Expected an error for uncached stats")
+	}
+}
+
+func TestCachedStatsRepository_ResetInvalidates(t *testing.T) {
+	ctx := context.Background()
+	inner := &fakeStatsRepository{views: 5}
+	repo := NewCachedStatsRepository(inner, time.Minute, 0, time.Minute)
+
+	if _, err := repo.GetWidgetStats(ctx, "widget-1"); err != nil {
+		t.Fatalf("GetWidgetStats failed: %v", err)
+	}
+	if err := repo.ResetStats(ctx, "widget-1"); err != nil {
+		t.Fatalf("ResetStats failed: %v", err)
+	}
+
+	stats, err := repo.GetWidgetStats(ctx, "widget-1")
+	if err != nil {
+		t.Fatalf("GetWidgetStats failed: %v", err)
+	}
+	if stats.Views != 0 || stats.CacheStatus != models.StatsCacheMiss {
+		t.Fatalf("Expected fresh reset stats, got %+v", stats)
+	}
+}
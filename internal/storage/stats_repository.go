@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -12,20 +13,52 @@ import (
 // StatsRepository defines interface for statistics operations
 type StatsRepository interface {
 	IncrementViews(ctx context.Context, widgetID string) error
+	MarkViewSeen(ctx context.Context, widgetID, visitor string, window time.Duration) (bool, error)
 	IncrementSubmits(ctx context.Context, widgetID string) error
 	IncrementCloses(ctx context.Context, widgetID string) error
+	IncrementSpam(ctx context.Context, widgetID string) error
 	GetWidgetStats(ctx context.Context, widgetID string) (*models.WidgetStats, error)
 	GetDailyViews(ctx context.Context, widgetID, date string) (int64, error)
+	GetDailySubmits(ctx context.Context, widgetID, date string) (int64, error)
+	GetDailyCloses(ctx context.Context, widgetID, date string) (int64, error)
+	GetDailySeries(ctx context.Context, widgetID, metric string, from, to time.Time) ([]models.DailyStat, error)
+	ResetStats(ctx context.Context, widgetID string) error
+	ResetDailyStats(ctx context.Context, widgetID string) error
 }
 
+// DefaultDailyStatsDays is how long daily view, submit and close counters are kept
+// unless configured otherwise
+const DefaultDailyStatsDays = 30
+
+// dailyStatsMetrics lists the metrics with daily counters
+var dailyStatsMetrics = []string{models.StatsMetricViews, models.StatsMetricSubmits, models.StatsMetricCloses}
+
 // RedisStatsRepository implements StatsRepository for Redis
 type RedisStatsRepository struct {
-	client *RedisClient
+	client    *RedisClient
+	dailyDays int // Days daily counters are kept for
+	now       func() time.Time
 }
 
 // NewRedisStatsRepository creates a new Redis stats repository
 func NewRedisStatsRepository(client *RedisClient) *RedisStatsRepository {
-	return &RedisStatsRepository{client: client}
+	return &RedisStatsRepository{client: client, dailyDays: DefaultDailyStatsDays, now: time.Now}
+}
+
+// SetDailyRetention sets how many days daily counters are kept for; counters written
+// before keep their expiry. Non-positive values keep the default.
+func (r *RedisStatsRepository) SetDailyRetention(days int) {
+	if days <= 0 {
+		days = DefaultDailyStatsDays
+	}
+	r.dailyDays = days
+}
+
+// incrementDaily counts an event of the metric on today's daily counter
+func (r *RedisStatsRepository) incrementDaily(ctx context.Context, pipe redis.Pipeliner, widgetID, metric string, now time.Time) {
+	dailyKey := dailyStatKey(widgetID, metric, now.Format("2006-01-02"))
+	pipe.Incr(ctx, dailyKey)
+	pipe.Expire(ctx, dailyKey, time.Duration(r.dailyDays)*24*time.Hour)
 }
 
 // IncrementViews increments view count for a widget
@@ -34,30 +67,53 @@ func (r *RedisStatsRepository) IncrementViews(ctx context.Context, widgetID stri
 	pipe := r.client.client.TxPipeline()
 
 	// Increment total views
+	now := r.now()
 	statsKey := GenerateWidgetStatsKey(widgetID)
 	pipe.HIncrBy(ctx, statsKey, "views", 1)
-	pipe.HSet(ctx, statsKey, "last_view", time.Now().Unix())
+	pipe.HSet(ctx, statsKey, "last_view", now.Unix())
 
 	// Increment daily views (same slot due to hash tag)
-	today := time.Now().Format("2006-01-02")
-	dailyKey := GenerateDailyViewsKey(widgetID, today)
-	pipe.Incr(ctx, dailyKey)
-	pipe.Expire(ctx, dailyKey, 30*24*time.Hour) // Keep daily stats for 30 days
+	r.incrementDaily(ctx, pipe, widgetID, models.StatsMetricViews, now)
 
 	_, err := pipe.Exec(ctx)
 	return err
 }
 
+// MarkViewSeen records a visitor's view for the window, reporting whether it is the
+// first one within the window
+func (r *RedisStatsRepository) MarkViewSeen(ctx context.Context, widgetID, visitor string, window time.Duration) (bool, error) {
+	return r.client.client.SetNX(ctx, GenerateViewSeenKey(widgetID, visitor), 1, window).Result()
+}
+
 // IncrementSubmits increments submit count for a widget
 func (r *RedisStatsRepository) IncrementSubmits(ctx context.Context, widgetID string) error {
+	now := r.now()
 	statsKey := GenerateWidgetStatsKey(widgetID)
-	return r.client.client.HIncrBy(ctx, statsKey, "submits", 1).Err()
+
+	pipe := r.client.client.TxPipeline()
+	pipe.HIncrBy(ctx, statsKey, "submits", 1)
+	pipe.HSet(ctx, statsKey, "last_submit", now.Unix())
+	r.incrementDaily(ctx, pipe, widgetID, models.StatsMetricSubmits, now)
+
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
 // IncrementCloses increments close count for a widget
 func (r *RedisStatsRepository) IncrementCloses(ctx context.Context, widgetID string) error {
 	statsKey := GenerateWidgetStatsKey(widgetID)
-	return r.client.client.HIncrBy(ctx, statsKey, "closes", 1).Err()
+
+	pipe := r.client.client.TxPipeline()
+	pipe.HIncrBy(ctx, statsKey, "closes", 1)
+	r.incrementDaily(ctx, pipe, widgetID, models.StatsMetricCloses, r.now())
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IncrementSpam increments the count of submissions dropped as spam for a widget
+func (r *RedisStatsRepository) IncrementSpam(ctx context.Context, widgetID string) error {
+	return r.client.client.HIncrBy(ctx, GenerateWidgetStatsKey(widgetID), "spam", 1).Err()
 }
 
 // GetWidgetStats retrieves statistics for a widget
@@ -68,51 +124,106 @@ func (r *RedisStatsRepository) GetWidgetStats(ctx context.Context, widgetID stri
 		return nil, err
 	}
 
-	if len(hash) == 0 {
-		// Return empty stats if not found
-		return &models.WidgetStats{
-			WidgetID: widgetID,
-			Views:    0,
-			Submits:  0,
-			Closes:   0,
-		}, nil
-	}
+	// A missing hash means no events yet, all counters zero
+	return parseWidgetStatsHash(widgetID, hash), nil
+}
 
-	stats := &models.WidgetStats{WidgetID: widgetID}
+// GetDailyViews retrieves daily view count for a specific date
+func (r *RedisStatsRepository) GetDailyViews(ctx context.Context, widgetID, date string) (int64, error) {
+	return r.getDailyCount(ctx, GenerateDailyViewsKey(widgetID, date))
+}
 
-	if viewsStr, ok := hash["views"]; ok {
-		if views, err := strconv.ParseInt(viewsStr, 10, 64); err == nil {
-			stats.Views = views
-		}
+// GetDailySubmits retrieves daily submit count for a specific date
+func (r *RedisStatsRepository) GetDailySubmits(ctx context.Context, widgetID, date string) (int64, error) {
+	return r.getDailyCount(ctx, GenerateDailySubmitsKey(widgetID, date))
+}
+
+// GetDailyCloses retrieves daily close count for a specific date
+func (r *RedisStatsRepository) GetDailyCloses(ctx context.Context, widgetID, date string) (int64, error) {
+	return r.getDailyCount(ctx, GenerateDailyClosesKey(widgetID, date))
+}
+
+// getDailyCount reads a daily counter, 0 when there were no events that day
+func (r *RedisStatsRepository) getDailyCount(ctx context.Context, dailyKey string) (int64, error) {
+	count, err := r.client.client.Get(ctx, dailyKey).Int64()
+	if err == redis.Nil {
+		return 0, nil
 	}
+	return count, err
+}
 
-	if submitsStr, ok := hash["submits"]; ok {
-		if submits, err := strconv.ParseInt(submitsStr, 10, 64); err == nil {
-			stats.Submits = submits
+// GetDailySeries retrieves a metric's daily counts for the days from through to, dated
+// in from's location; days without a counter count 0
+func (r *RedisStatsRepository) GetDailySeries(ctx context.Context, widgetID, metric string, from, to time.Time) ([]models.DailyStat, error) {
+	series := []models.DailyStat{}
+	var keys []string
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for ; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		key := dailyStatKey(widgetID, metric, date)
+		if key == "" {
+			return nil, fmt.Errorf("unknown stats metric %q", metric)
 		}
+		series = append(series, models.DailyStat{Date: date})
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return series, nil
 	}
 
-	if closesStr, ok := hash["closes"]; ok {
-		if closes, err := strconv.ParseInt(closesStr, 10, 64); err == nil {
-			stats.Closes = closes
+	// All daily keys share the {widgetID} hash tag, so one MGET reads them
+	values, err := r.client.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		if count, ok := value.(string); ok {
+			series[i].Value, _ = strconv.ParseInt(count, 10, 64)
 		}
 	}
+	return series, nil
+}
 
-	if lastViewStr, ok := hash["last_view"]; ok {
-		if timestamp, err := strconv.ParseInt(lastViewStr, 10, 64); err == nil {
-			stats.LastView = time.Unix(timestamp, 0)
-		}
+// dailyStatKey returns the daily counter key of a metric, "" for unknown metrics
+func dailyStatKey(widgetID, metric, date string) string {
+	switch metric {
+	case models.StatsMetricViews:
+		return GenerateDailyViewsKey(widgetID, date)
+	case models.StatsMetricSubmits:
+		return GenerateDailySubmitsKey(widgetID, date)
+	case models.StatsMetricCloses:
+		return GenerateDailyClosesKey(widgetID, date)
+	default:
+		return ""
 	}
+}
+
+// ResetStats zeroes the view, submit, close and spam counters of a widget
+func (r *RedisStatsRepository) ResetStats(ctx context.Context, widgetID string) error {
+	statsKey := GenerateWidgetStatsKey(widgetID)
 
-	return stats, nil
+	pipe := r.client.client.TxPipeline()
+	pipe.HSet(ctx, statsKey, "views", 0, "submits", 0, "closes", 0, "spam", 0)
+	pipe.HDel(ctx, statsKey, "last_view", "last_submit")
+
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
-// GetDailyViews retrieves daily view count for a specific date
-func (r *RedisStatsRepository) GetDailyViews(ctx context.Context, widgetID, date string) (int64, error) {
-	dailyKey := GenerateDailyViewsKey(widgetID, date)
-	count, err := r.client.client.Get(ctx, dailyKey).Int64()
-	if err == redis.Nil {
-		return 0, nil // No views for this date
+// ResetDailyStats removes the daily view, submit and close counters of a widget within
+// the retention window
+func (r *RedisStatsRepository) ResetDailyStats(ctx context.Context, widgetID string) error {
+	// All daily keys share the {widgetID} hash tag, so they are in the same slot
+	pipe := r.client.client.TxPipeline()
+
+	now := r.now()
+	for i := 0; i < r.dailyDays; i++ {
+		date := now.AddDate(0, 0, -i).Format("2006-01-02")
+		for _, metric := range dailyStatsMetrics {
+			pipe.Del(ctx, dailyStatKey(widgetID, metric, date))
+		}
 	}
-	return count, err
+
+	_, err := pipe.Exec(ctx)
+	return err
 }
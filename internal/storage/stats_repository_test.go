@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/internal/models"
+)
+
+func TestRedisStatsRepository_GetDailySeries(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	repo := NewRedisStatsRepository(redisClient)
+	ctx := context.Background()
+
+	seed := map[string]int64{
+		GenerateDailySubmitsKey("widget-1", "2024-03-01"): 4,
+		GenerateDailySubmitsKey("widget-1", "2024-03-03"): 2,
+		GenerateDailyViewsKey("widget-1", "2024-03-02"):   9,
+		GenerateDailySubmitsKey("widget-2", "2024-03-02"): 7,
+	}
+	for key, value := range seed {
+		if err := redisClient.client.Set(ctx, key, value, 0).Err(); err != nil {
+			t.Fatalf("Failed to seed %s: %v", key, err)
+		}
+	}
+
+	from := time.Date(2024, 2, 29, 18, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 3, 9, 0, 0, 0, time.UTC)
+	series, err := repo.GetDailySeries(ctx, "widget-1", models.StatsMetricSubmits, from, to)
+	if err != nil {
+		t.Fatalf("GetDailySeries failed: %v", err)
+	}
+
+	// Days without a counter, and other metrics and widgets, count 0
+	expected := []models.DailyStat{
+		{Date: "2024-02-29", Value: 0},
+		{Date: "2024-03-01", Value: 4},
+		{Date: "2024-03-02", Value: 0},
+		{Date: "2024-03-03", Value: 2},
+	}
+	if !reflect.DeepEqual(series, expected) {
+		t.Errorf("Expected series %v, got %v", expected, series)
+	}
+
+	if _, err := repo.GetDailySeries(ctx, "widget-1", "clicks", from, to); err == nil {
+		t.Error("Expected an error for an unknown metric")
+	}
+}
+
+func TestRedisStatsRepository_DailyCounters(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	repo := NewRedisStatsRepository(redisClient)
+	ctx := context.Background()
+
+	for _, increment := range []func(context.Context, string) error{repo.IncrementViews, repo.IncrementSubmits, repo.IncrementSubmits, repo.IncrementCloses} {
+		if err := increment(ctx, "widget-1"); err != nil {
+			t.Fatalf("Failed to increment: %v", err)
+		}
+	}
+
+	now := time.Now()
+	for metric, expected := range map[string]int64{models.StatsMetricViews: 1, models.StatsMetricSubmits: 2, models.StatsMetricCloses: 1} {
+		series, err := repo.GetDailySeries(ctx, "widget-1", metric, now, now)
+		if err != nil || len(series) != 1 || series[0].Value != expected {
+			t.Errorf("Expected today's %s to be %d, got %v (%v)", metric, expected, series, err)
+		}
+	}
+
+	if err := repo.ResetDailyStats(ctx, "widget-1"); err != nil {
+		t.Fatalf("ResetDailyStats failed: %v", err)
+	}
+	for _, metric := range dailyStatsMetrics {
+		series, err := repo.GetDailySeries(ctx, "widget-1", metric, now, now)
+		if err != nil || len(series) != 1 || series[0].Value != 0 {
+			t.Errorf("Expected today's %s to be reset, got %v (%v)", metric, series, err)
+		}
+	}
+}
+
+func TestRedisStatsRepository_DailyCountersAcrossDays(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	repo := NewRedisStatsRepository(redisClient)
+	repo.SetDailyRetention(7)
+	ctx := context.Background()
+
+	day1 := time.Date(2024, 3, 1, 23, 59, 0, 0, time.Local)
+	day2 := day1.Add(2 * time.Minute)
+
+	repo.now = func() time.Time { return day1 }
+	for _, increment := range []func(context.Context, string) error{repo.IncrementSubmits, repo.IncrementSubmits, repo.IncrementCloses} {
+		if err := increment(ctx, "widget-1"); err != nil {
+			t.Fatalf("Failed to increment: %v", err)
+		}
+	}
+	repo.now = func() time.Time { return day2 }
+	for _, increment := range []func(context.Context, string) error{repo.IncrementSubmits, repo.IncrementCloses, repo.IncrementCloses, repo.IncrementCloses} {
+		if err := increment(ctx, "widget-1"); err != nil {
+			t.Fatalf("Failed to increment: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		get      func(context.Context, string, string) (int64, error)
+		date     string
+		expected int64
+	}{
+		{"submits on day 1", repo.GetDailySubmits, "2024-03-01", 2},
+		{"submits on day 2", repo.GetDailySubmits, "2024-03-02", 1},
+		{"closes on day 1", repo.GetDailyCloses, "2024-03-01", 1},
+		{"closes on day 2", repo.GetDailyCloses, "2024-03-02", 3},
+		{"closes without events", repo.GetDailyCloses, "2024-03-03", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := tt.get(ctx, "widget-1", tt.date)
+			if err != nil {
+				t.Fatalf("Failed to get daily count: %v", err)
+			}
+			if count != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, count)
+			}
+		})
+	}
+
+	// Daily counters expire after the configured retention
+	ttl, err := redisClient.client.TTL(ctx, GenerateDailySubmitsKey("widget-1", "2024-03-02")).Result()
+	if err != nil || ttl != 7*24*time.Hour {
+		t.Errorf("Expected a 7 day TTL, got %v (%v)", ttl, err)
+	}
+
+	// Cumulative counters span both days
+	stats, err := repo.GetWidgetStats(ctx, "widget-1")
+	if err != nil || stats.Submits != 3 || stats.Closes != 4 {
+		t.Errorf("Expected 3 submits and 4 closes in total, got %+v (%v)", stats, err)
+	}
+}
@@ -2,10 +2,18 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ad/leads-core/internal/errors"
 	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/pkg/logger"
+	"github.com/ad/leads-core/pkg/monitoring"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -13,14 +21,67 @@ import (
 type SubmissionRepository interface {
 	Create(ctx context.Context, submission *models.Submission) error
 	GetByWidgetID(ctx context.Context, widgetID string, opts models.PaginationOptions) ([]*models.Submission, int, error)
+	GetByWidgetIDAfter(ctx context.Context, widgetID string, cursor *models.SubmissionCursor, limit int) ([]*models.Submission, int, *models.SubmissionCursor, error)
+	SearchByWidgetID(ctx context.Context, widgetID string, filter models.SubmissionFilter, opts models.PaginationOptions) ([]*models.Submission, int, error)
 	GetByID(ctx context.Context, widgetID, submissionID string) (*models.Submission, error)
+	GetCreatedBetween(ctx context.Context, widgetID string, after, until int64, limit int) ([]*models.Submission, error)
+	GetByCorrelation(ctx context.Context, widgetID, value string) (*models.Submission, error)
+	ClaimDedup(ctx context.Context, widgetID, fingerprint, submissionID string, window time.Duration) (string, error)
+	ReleaseDedup(ctx context.Context, widgetID, fingerprint string) error
 	UpdateTTL(ctx context.Context, userID string, newTTL time.Duration) error
 	UpdateWidgetSubmissionsTTL(ctx context.Context, widgetID string, ttlDays int) error
+	RedactPII(ctx context.Context, widgetID string, fields []string, cutoff time.Time) (int, error)
+	EvictOldest(ctx context.Context, widgetID string, keep int) (int, error)
+	Delete(ctx context.Context, widgetID, submissionID string) error
+	DeleteByRange(ctx context.Context, widgetID string, from, to time.Time) (int, error)
 }
 
+// deleteSubmissionScript deletes a submission, drops it from the widget's index and
+// decrements the widget's submit count, never below zero. It returns 0 when the
+// submission doesn't exist.
+var deleteSubmissionScript = redis.NewScript(`
+redis.call("ZREM", KEYS[2], ARGV[1])
+if redis.call("DEL", KEYS[1]) == 0 then
+	return 0
+end
+if tonumber(redis.call("HGET", KEYS[3], "submits") or "0") > 0 then
+	redis.call("HINCRBY", KEYS[3], "submits", -1)
+end
+return 1
+`)
+
+// decrementSubmitsScript decrements the widget's submit count by ARGV[1], never below zero
+var decrementSubmitsScript = redis.NewScript(`
+local submits = tonumber(redis.call("HGET", KEYS[1], "submits") or "0")
+if submits > 0 then
+	redis.call("HSET", KEYS[1], "submits", math.max(submits - tonumber(ARGV[1]), 0))
+end
+return 1
+`)
+
+// evictSubmissionsScript deletes the submissions ARGV from KEYS[3..] and the widget's
+// index KEYS[1], then decrements the submit count in KEYS[2] by the number deleted, never
+// below zero. Index entries of expired submissions are dropped without counting, and a
+// submission already deleted by a concurrent call isn't counted twice.
+var evictSubmissionsScript = redis.NewScript(`
+local deleted = 0
+for i = 1, #ARGV do
+	redis.call("ZREM", KEYS[1], ARGV[i])
+	deleted = deleted + redis.call("DEL", KEYS[i + 2])
+end
+if deleted > 0 then
+	local submits = tonumber(redis.call("HGET", KEYS[2], "submits") or "0")
+	if submits > 0 then
+		redis.call("HSET", KEYS[2], "submits", math.max(submits - deleted, 0))
+	end
+end
+return deleted
+`)
+
 // RedisSubmissionRepository implements SubmissionRepository for Redis
 type RedisSubmissionRepository struct {
 	client *RedisClient
+	cipher *FieldCipher
 }
 
 // NewRedisSubmissionRepository creates a new Redis submission repository
@@ -28,14 +89,28 @@ func NewRedisSubmissionRepository(client *RedisClient) *RedisSubmissionRepositor
 	return &RedisSubmissionRepository{client: client}
 }
 
+// SetFieldCipher enables encryption of the submission fields designated by widgets.
+// Without a cipher such fields are stored in plaintext.
+func (r *RedisSubmissionRepository) SetFieldCipher(cipher *FieldCipher) {
+	r.cipher = cipher
+}
+
 // Create creates a new submission with TTL
 func (r *RedisSubmissionRepository) Create(ctx context.Context, submission *models.Submission) error {
+	hash := submission.ToRedisHash()
+	data, encrypted, err := r.sealData(submission.Data, submission.EncryptedFields)
+	if err != nil {
+		return err
+	}
+	hash["data"] = data
+	hash["encrypted_fields"] = strings.Join(encrypted, ",")
+
 	// All submission-related keys use {widgetID} hash tag, so they'll be in same slot
 	pipe := r.client.client.TxPipeline()
 
 	// Store submission data
 	submissionKey := GenerateSubmissionKey(submission.WidgetID, submission.ID)
-	pipe.HSet(ctx, submissionKey, submission.ToRedisHash())
+	pipe.HSet(ctx, submissionKey, hash)
 
 	// Set TTL if specified
 	if submission.TTL > 0 {
@@ -47,10 +122,70 @@ func (r *RedisSubmissionRepository) Create(ctx context.Context, submission *mode
 	timestamp := float64(submission.CreatedAt.Unix())
 	pipe.ZAdd(ctx, widgetSubmissionsKey, redis.Z{Score: timestamp, Member: submission.ID})
 
-	_, err := pipe.Exec(ctx)
+	// Index the correlation value, hashed as the field may be encrypted; the index
+	// lives as long as the newest submission
+	if submission.CorrelationValue != "" {
+		correlationKey := GenerateSubmissionCorrelationKey(submission.WidgetID)
+		pipe.HSet(ctx, correlationKey, correlationHash(submission.CorrelationValue), submission.ID)
+		if submission.TTL > 0 {
+			pipe.Expire(ctx, correlationKey, submission.TTL)
+		}
+	}
+
+	_, err = pipe.Exec(ctx)
 	return err
 }
 
+// ClaimDedup claims the submission fingerprint for the submission ID within the window.
+// It returns the ID of the submission that claimed it earlier, or "" when the claim
+// succeeded.
+func (r *RedisSubmissionRepository) ClaimDedup(ctx context.Context, widgetID, fingerprint, submissionID string, window time.Duration) (string, error) {
+	key := GenerateSubmissionDedupKey(widgetID, fingerprint)
+	claimed, err := r.client.client.SetNX(ctx, key, submissionID, window).Result()
+	if err != nil || claimed {
+		return "", err
+	}
+
+	existingID, err := r.client.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil // Expired in between, the next submission claims it again
+	}
+	return existingID, err
+}
+
+// ReleaseDedup drops the claim of a submission fingerprint, e.g. when storing the
+// submission failed
+func (r *RedisSubmissionRepository) ReleaseDedup(ctx context.Context, widgetID, fingerprint string) error {
+	return r.client.client.Del(ctx, GenerateSubmissionDedupKey(widgetID, fingerprint)).Err()
+}
+
+// GetByCorrelation retrieves the latest submission carrying the correlation value.
+// Entries of expired or evicted submissions are dropped when looked up.
+func (r *RedisSubmissionRepository) GetByCorrelation(ctx context.Context, widgetID, value string) (*models.Submission, error) {
+	correlationKey := GenerateSubmissionCorrelationKey(widgetID)
+	hash := correlationHash(value)
+
+	submissionID, err := r.client.client.HGet(ctx, correlationKey, hash).Result()
+	if err == redis.Nil {
+		return nil, errors.ErrSubmissionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	submission, err := r.GetByID(ctx, widgetID, submissionID)
+	if err == errors.ErrSubmissionNotFound {
+		r.client.client.HDel(ctx, correlationKey, hash)
+	}
+	return submission, err
+}
+
+// correlationHash hashes a correlation value for the index
+func correlationHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
 // GetByWidgetID retrieves submissions for a specific widget with pagination
 func (r *RedisSubmissionRepository) GetByWidgetID(ctx context.Context, widgetID string, opts models.PaginationOptions) ([]*models.Submission, int, error) {
 	widgetSubmissionsKey := GenerateWidgetSubmissionsKey(widgetID)
@@ -66,7 +201,9 @@ func (r *RedisSubmissionRepository) GetByWidgetID(ctx context.Context, widgetID
 	end := start + int64(opts.PerPage) - 1
 
 	// Get submission IDs (sorted by timestamp, newest first)
+	queryStart := time.Now()
 	submissionIDs, err := r.client.client.ZRevRange(ctx, widgetSubmissionsKey, start, end).Result()
+	monitoring.TrackQuery("ZREVRANGE", keyPattern(WidgetSubmissionsKey), queryStart)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -88,6 +225,145 @@ func (r *RedisSubmissionRepository) GetByWidgetID(ctx context.Context, widgetID
 	return submissions, int(total), nil
 }
 
+// GetByWidgetIDAfter retrieves up to limit submissions following the cursor (from the
+// newest without one), newest first with submissions of the same second ordered by
+// descending ID as ZREVRANGE does. It returns the total count and the cursor of the next
+// page, nil on the last one. Unlike offsets, cursors don't shift as submissions arrive.
+func (r *RedisSubmissionRepository) GetByWidgetIDAfter(ctx context.Context, widgetID string, cursor *models.SubmissionCursor, limit int) ([]*models.Submission, int, *models.SubmissionCursor, error) {
+	widgetSubmissionsKey := GenerateWidgetSubmissionsKey(widgetID)
+
+	total, err := r.client.client.ZCard(ctx, widgetSubmissionsKey).Result()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	// The window starts at the cursor's second, skipping the submissions of that second
+	// at or before the cursor ID in the order
+	window := &redis.ZRangeBy{Min: "-inf", Max: "+inf", Count: int64(limit) + 1}
+	if cursor != nil {
+		score := strconv.FormatInt(cursor.Score, 10)
+		window.Max = score
+
+		sameSecond, err := r.client.client.ZRangeByScore(ctx, widgetSubmissionsKey, &redis.ZRangeBy{Min: score, Max: score}).Result()
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		for _, submissionID := range sameSecond {
+			if submissionID >= cursor.ID {
+				window.Offset++
+			}
+		}
+	}
+
+	// Read one submission more than the page to know whether another page follows
+	queryStart := time.Now()
+	entries, err := r.client.client.ZRevRangeByScoreWithScores(ctx, widgetSubmissionsKey, window).Result()
+	monitoring.TrackQuery("ZREVRANGEBYSCORE", keyPattern(WidgetSubmissionsKey), queryStart)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	var next *models.SubmissionCursor
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[len(entries)-1]
+		next = &models.SubmissionCursor{Score: int64(last.Score), ID: fmt.Sprint(last.Member)}
+	}
+
+	submissions := make([]*models.Submission, 0, len(entries))
+	for _, entry := range entries {
+		submission, err := r.GetByID(ctx, widgetID, fmt.Sprint(entry.Member))
+		if err != nil {
+			continue // Skip submissions that can't be loaded (expired, etc.)
+		}
+		submissions = append(submissions, submission)
+	}
+
+	return submissions, int(total), next, nil
+}
+
+// searchBatchSize is the number of submissions loaded per round trip by SearchByWidgetID
+const searchBatchSize = 200
+
+// SearchByWidgetID retrieves the page of the widget's submissions matching the filter,
+// newest first, and the number of matches. Data is stored as JSON, so this is a linear
+// scan loading every submission of the widget in batches; use it for interactive
+// lookups, not on hot paths.
+func (r *RedisSubmissionRepository) SearchByWidgetID(ctx context.Context, widgetID string, filter models.SubmissionFilter, opts models.PaginationOptions) ([]*models.Submission, int, error) {
+	widgetSubmissionsKey := GenerateWidgetSubmissionsKey(widgetID)
+	skip := (opts.Page - 1) * opts.PerPage
+
+	matches := make([]*models.Submission, 0, opts.PerPage)
+	total := 0
+	for start := int64(0); ; start += searchBatchSize {
+		queryStart := time.Now()
+		submissionIDs, err := r.client.client.ZRevRange(ctx, widgetSubmissionsKey, start, start+searchBatchSize-1).Result()
+		monitoring.TrackQuery("ZREVRANGE", keyPattern(WidgetSubmissionsKey), queryStart)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(submissionIDs) == 0 {
+			break
+		}
+
+		pipe := r.client.client.Pipeline()
+		hashes := make([]*redis.MapStringStringCmd, len(submissionIDs))
+		for i, submissionID := range submissionIDs {
+			hashes[i] = pipe.HGetAll(ctx, GenerateSubmissionKey(widgetID, submissionID))
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, 0, err
+		}
+
+		for i, cmd := range hashes {
+			hash := cmd.Val()
+			if len(hash) == 0 {
+				continue // Expired since it was indexed
+			}
+			submission, err := r.parseSubmission(submissionIDs[i], hash)
+			if err != nil || !filter.Matches(submission) {
+				continue
+			}
+			if total >= skip && len(matches) < opts.PerPage {
+				matches = append(matches, submission)
+			}
+			total++
+		}
+
+		if len(submissionIDs) < searchBatchSize {
+			break
+		}
+	}
+
+	return matches, total, nil
+}
+
+// GetCreatedBetween retrieves up to limit submissions created after the Unix second
+// after and up to and including until, oldest first
+func (r *RedisSubmissionRepository) GetCreatedBetween(ctx context.Context, widgetID string, after, until int64, limit int) ([]*models.Submission, error) {
+	queryStart := time.Now()
+	submissionIDs, err := r.client.client.ZRangeByScore(ctx, GenerateWidgetSubmissionsKey(widgetID), &redis.ZRangeBy{
+		Min:   "(" + strconv.FormatInt(after, 10),
+		Max:   strconv.FormatInt(until, 10),
+		Count: int64(limit),
+	}).Result()
+	monitoring.TrackQuery("ZRANGEBYSCORE", keyPattern(WidgetSubmissionsKey), queryStart)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions := make([]*models.Submission, 0, len(submissionIDs))
+	for _, submissionID := range submissionIDs {
+		submission, err := r.GetByID(ctx, widgetID, submissionID)
+		if err != nil {
+			continue // Skip submissions that can't be loaded (expired, etc.)
+		}
+		submissions = append(submissions, submission)
+	}
+
+	return submissions, nil
+}
+
 // GetByID retrieves a specific submission
 func (r *RedisSubmissionRepository) GetByID(ctx context.Context, widgetID, submissionID string) (*models.Submission, error) {
 	submissionKey := GenerateSubmissionKey(widgetID, submissionID)
@@ -97,17 +373,86 @@ func (r *RedisSubmissionRepository) GetByID(ctx context.Context, widgetID, submi
 	}
 
 	if len(hash) == 0 {
-		return nil, fmt.Errorf("submission not found")
+		return nil, errors.ErrSubmissionNotFound
 	}
 
+	return r.parseSubmission(submissionID, hash)
+}
+
+// parseSubmission parses a stored submission hash, decrypting its encrypted fields
+func (r *RedisSubmissionRepository) parseSubmission(submissionID string, hash map[string]string) (*models.Submission, error) {
 	submission := &models.Submission{}
 	if err := submission.FromRedisHash(hash); err != nil {
 		return nil, fmt.Errorf("failed to parse submission data: %w", err)
 	}
 
+	if encrypted := hash["encrypted_fields"]; encrypted != "" {
+		submission.EncryptedFields = strings.Split(encrypted, ",")
+		if err := r.openData(submission.Data, submission.EncryptedFields); err != nil {
+			return nil, fmt.Errorf("failed to decrypt submission %s: %w", submissionID, err)
+		}
+	}
+
 	return submission, nil
 }
 
+// sealData serializes submission data for storage, encrypting the given fields.
+// It returns the JSON data and the fields that were actually encrypted.
+func (r *RedisSubmissionRepository) sealData(data map[string]interface{}, fields []string) (string, []string, error) {
+	if len(fields) > 0 && r.cipher == nil {
+		logger.Warn("submission fields designated for encryption stored in plaintext, no encryption key configured", map[string]interface{}{
+			"fields": fields,
+		})
+	}
+
+	var encrypted []string
+	if r.cipher != nil && len(fields) > 0 {
+		// Encrypt a copy, the caller keeps working with plaintext values
+		sealed := make(map[string]interface{}, len(data))
+		for key, value := range data {
+			sealed[key] = value
+		}
+		for _, field := range fields {
+			value, ok := sealed[field]
+			if !ok {
+				continue
+			}
+			ciphertext, err := r.cipher.Encrypt(value)
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to encrypt field %s: %w", field, err)
+			}
+			sealed[field] = ciphertext
+			encrypted = append(encrypted, field)
+		}
+		data = sealed
+	}
+
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode submission data: %w", err)
+	}
+	return string(dataJSON), encrypted, nil
+}
+
+// openData decrypts the given fields of stored submission data in place
+func (r *RedisSubmissionRepository) openData(data map[string]interface{}, fields []string) error {
+	if r.cipher == nil {
+		return fmt.Errorf("no encryption key configured")
+	}
+	for _, field := range fields {
+		ciphertext, ok := data[field].(string)
+		if !ok {
+			continue
+		}
+		value, err := r.cipher.Decrypt(ciphertext)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field, err)
+		}
+		data[field] = value
+	}
+	return nil
+}
+
 // UpdateTTL updates TTL for all submissions of a user
 func (r *RedisSubmissionRepository) UpdateTTL(ctx context.Context, userID string, newTTL time.Duration) error {
 	// Get all widgets for the user
@@ -166,3 +511,156 @@ func (r *RedisSubmissionRepository) UpdateWidgetSubmissionsTTL(ctx context.Conte
 
 	return nil
 }
+
+// RedactPII blanks the given fields of widget submissions created up to cutoff and
+// returns the number of submissions changed. Submissions stay indexed, so counts and
+// timestamps are preserved. Progress is remembered per widget, so each run only
+// scans submissions that crossed the cutoff since the previous one.
+func (r *RedisSubmissionRepository) RedactPII(ctx context.Context, widgetID string, fields []string, cutoff time.Time) (int, error) {
+	cursorKey := GeneratePIIRedactedUntilKey(widgetID)
+	minScore := "-inf"
+	if redactedUntil, err := r.client.client.Get(ctx, cursorKey).Result(); err == nil {
+		minScore = "(" + redactedUntil
+	} else if err != redis.Nil {
+		return 0, fmt.Errorf("failed to get PII redaction cursor: %w", err)
+	}
+
+	maxScore := strconv.FormatInt(cutoff.Unix(), 10)
+	submissionIDs, err := r.client.client.ZRangeByScore(ctx, GenerateWidgetSubmissionsKey(widgetID), &redis.ZRangeBy{
+		Min: minScore,
+		Max: maxScore,
+	}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get submissions for PII redaction: %w", err)
+	}
+
+	redacted := 0
+	for _, submissionID := range submissionIDs {
+		submission, err := r.GetByID(ctx, widgetID, submissionID)
+		if err != nil {
+			continue // Skip submissions that can't be loaded (expired, etc.)
+		}
+		if !models.RedactFields(submission.Data, fields) {
+			continue
+		}
+
+		// Fields that were stored encrypted stay encrypted
+		data, encrypted, err := r.sealData(submission.Data, submission.EncryptedFields)
+		if err != nil {
+			return redacted, fmt.Errorf("failed to redact submission %s: %w", submissionID, err)
+		}
+		// HSET keeps the submission TTL
+		if err := r.client.client.HSet(ctx, GenerateSubmissionKey(widgetID, submissionID), map[string]interface{}{
+			"data":             data,
+			"encrypted_fields": strings.Join(encrypted, ","),
+			"pii_redacted":     "true",
+		}).Err(); err != nil {
+			return redacted, fmt.Errorf("failed to redact submission %s: %w", submissionID, err)
+		}
+		redacted++
+	}
+
+	if err := r.client.client.Set(ctx, cursorKey, maxScore, 0).Err(); err != nil {
+		return redacted, fmt.Errorf("failed to update PII redaction cursor: %w", err)
+	}
+
+	return redacted, nil
+}
+
+// EvictOldest deletes the widget's oldest submissions so that at most keep remain,
+// decrements the widget's submit count by the number deleted and returns it. Concurrent
+// calls select the same oldest entries, so racing submits never evict more than needed.
+func (r *RedisSubmissionRepository) EvictOldest(ctx context.Context, widgetID string, keep int) (int, error) {
+	widgetSubmissionsKey := GenerateWidgetSubmissionsKey(widgetID)
+
+	// Everything ranked below the newest keep entries
+	submissionIDs, err := r.client.client.ZRange(ctx, widgetSubmissionsKey, 0, -int64(keep)-1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get submissions to evict: %w", err)
+	}
+	if len(submissionIDs) == 0 {
+		return 0, nil
+	}
+
+	// All submission-related keys use {widgetID} hash tag, so they'll be in same slot
+	keys := make([]string, 0, len(submissionIDs)+2)
+	keys = append(keys, widgetSubmissionsKey, GenerateWidgetStatsKey(widgetID))
+	args := make([]interface{}, len(submissionIDs))
+	for i, submissionID := range submissionIDs {
+		keys = append(keys, GenerateSubmissionKey(widgetID, submissionID))
+		args[i] = submissionID
+	}
+	deleted, err := evictSubmissionsScript.Run(ctx, r.client.client, keys, args...).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to evict submissions: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// Delete deletes a submission of the widget and decrements the widget's submit count.
+// Correlation index entries pointing at it are dropped when next looked up.
+func (r *RedisSubmissionRepository) Delete(ctx context.Context, widgetID, submissionID string) error {
+	// All submission-related keys use {widgetID} hash tag, so they'll be in same slot
+	keys := []string{
+		GenerateSubmissionKey(widgetID, submissionID),
+		GenerateWidgetSubmissionsKey(widgetID),
+		GenerateWidgetStatsKey(widgetID),
+	}
+	deleted, err := deleteSubmissionScript.Run(ctx, r.client.client, keys, submissionID).Int()
+	if err != nil {
+		return fmt.Errorf("failed to delete submission: %w", err)
+	}
+	if deleted == 0 {
+		return errors.ErrSubmissionNotFound
+	}
+	return nil
+}
+
+// DeleteByRange deletes the widget's submissions created between from and to, both
+// inclusive and unbounded when zero, decrements the widget's submit count by the number
+// deleted and returns it
+func (r *RedisSubmissionRepository) DeleteByRange(ctx context.Context, widgetID string, from, to time.Time) (int, error) {
+	widgetSubmissionsKey := GenerateWidgetSubmissionsKey(widgetID)
+
+	rangeBy := &redis.ZRangeBy{Min: "-inf", Max: "+inf"}
+	if !from.IsZero() {
+		rangeBy.Min = strconv.FormatInt(from.Unix(), 10)
+	}
+	if !to.IsZero() {
+		rangeBy.Max = strconv.FormatInt(to.Unix(), 10)
+	}
+	submissionIDs, err := r.client.client.ZRangeByScore(ctx, widgetSubmissionsKey, rangeBy).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get submissions to delete: %w", err)
+	}
+	if len(submissionIDs) == 0 {
+		return 0, nil
+	}
+
+	// All submission-related keys use {widgetID} hash tag, so they'll be in same slot
+	pipe := r.client.client.TxPipeline()
+	members := make([]interface{}, len(submissionIDs))
+	dels := make([]*redis.IntCmd, len(submissionIDs))
+	for i, submissionID := range submissionIDs {
+		dels[i] = pipe.Del(ctx, GenerateSubmissionKey(widgetID, submissionID))
+		members[i] = submissionID
+	}
+	pipe.ZRem(ctx, widgetSubmissionsKey, members...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to delete submissions: %w", err)
+	}
+
+	// Index entries of expired submissions are dropped without counting
+	deleted := 0
+	for _, del := range dels {
+		deleted += int(del.Val())
+	}
+	if deleted > 0 {
+		if err := decrementSubmitsScript.Run(ctx, r.client.client, []string{GenerateWidgetStatsKey(widgetID)}, deleted).Err(); err != nil {
+			return deleted, fmt.Errorf("failed to update submit count: %w", err)
+		}
+	}
+
+	return deleted, nil
+}
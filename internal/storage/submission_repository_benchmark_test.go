@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/internal/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// BenchmarkRedisSubmissionRepository_SearchByWidgetID benchmarks the linear scan of a
+// widget's submissions
+func BenchmarkRedisSubmissionRepository_SearchByWidgetID(b *testing.B) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		b.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	repo := NewRedisSubmissionRepository(&RedisClient{client: client})
+	ctx := context.Background()
+	base := time.Now()
+	for i := 0; i < 1000; i++ {
+		if err := repo.Create(ctx, &models.Submission{
+			ID:       fmt.Sprintf("submission-%d", i),
+			WidgetID: "widget-1",
+			Data: map[string]interface{}{
+				"name":    fmt.Sprintf("Lead %d", i),
+				"email":   fmt.Sprintf("lead-%d@example.com", i),
+				"message": "Interested in a quote",
+				"address": map[string]interface{}{"city": "Berlin", "zip": float64(10115)},
+			},
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+		}); err != nil {
+			b.Fatalf("Failed to create submission: %v", err)
+		}
+	}
+
+	filter := models.SubmissionFilter{Search: "lead-999@"}
+	opts := models.PaginationOptions{Page: 1, PerPage: 20}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, total, err := repo.SearchByWidgetID(ctx, "widget-1", filter, opts); err != nil || total != 1 {
+			b.Fatalf("SearchByWidgetID failed: %d matches, %v", total, err)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad/leads-core/internal/errors"
+	"github.com/ad/leads-core/internal/models"
+)
+
+func TestRedisSubmissionRepository_GetByWidgetIDAfter(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	repo := NewRedisSubmissionRepository(redisClient)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	// "b", "c" and "d" share their creation second
+	created := map[string]time.Time{
+		"a": base,
+		"b": base.Add(time.Second),
+		"c": base.Add(time.Second),
+		"d": base.Add(time.Second),
+		"e": base.Add(2 * time.Second),
+	}
+	for id, createdAt := range created {
+		if err := repo.Create(ctx, &models.Submission{ID: id, WidgetID: "widget-1", Data: map[string]interface{}{"n": id}, CreatedAt: createdAt}); err != nil {
+			t.Fatalf("Failed to create submission %s: %v", id, err)
+		}
+	}
+
+	// Pages of two follow the ZREVRANGE order, ties by descending ID
+	var ids []string
+	var cursor *models.SubmissionCursor
+	for page := 0; ; page++ {
+		if page > len(created) {
+			t.Fatal("Cursor pagination did not end")
+		}
+		submissions, total, next, err := repo.GetByWidgetIDAfter(ctx, "widget-1", cursor, 2)
+		if err != nil {
+			t.Fatalf("GetByWidgetIDAfter failed: %v", err)
+		}
+		if total != len(created) {
+			t.Errorf("Expected total %d, got %d", len(created), total)
+		}
+		for _, submission := range submissions {
+			ids = append(ids, submission.ID)
+		}
+		if next == nil {
+			break
+		}
+
+		// Cursors survive the round trip through their opaque form
+		cursor, err = models.ParseSubmissionCursor(next.String())
+		if err != nil {
+			t.Fatalf("Failed to parse cursor %q: %v", next.String(), err)
+		}
+	}
+	if got := strings.Join(ids, ","); got != "e,d,c,b,a" {
+		t.Errorf("Expected order e,d,c,b,a, got %s", got)
+	}
+
+	// Submissions arriving later don't shift the following pages
+	first, _, next, err := repo.GetByWidgetIDAfter(ctx, "widget-1", nil, 2)
+	if err != nil || len(first) != 2 || next == nil {
+		t.Fatalf("Expected a first page with a cursor, got %d submissions, %v, %v", len(first), next, err)
+	}
+	if err := repo.Create(ctx, &models.Submission{ID: "f", WidgetID: "widget-1", CreatedAt: base.Add(3 * time.Second)}); err != nil {
+		t.Fatalf("Failed to create submission: %v", err)
+	}
+	second, _, _, err := repo.GetByWidgetIDAfter(ctx, "widget-1", next, 2)
+	if err != nil {
+		t.Fatalf("GetByWidgetIDAfter failed: %v", err)
+	}
+	if len(second) != 2 || second[0].ID != "c" || second[1].ID != "b" {
+		t.Errorf("Expected the second page c,b, got %v", second)
+	}
+}
+
+func TestRedisSubmissionRepository_Delete(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	repo := NewRedisSubmissionRepository(redisClient)
+	stats := NewRedisStatsRepository(redisClient)
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, &models.Submission{ID: "s1", WidgetID: "widget-1", Data: map[string]interface{}{"n": 1}, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to create submission: %v", err)
+	}
+	if err := stats.IncrementSubmits(ctx, "widget-1"); err != nil {
+		t.Fatalf("Failed to increment submits: %v", err)
+	}
+
+	if err := repo.Delete(ctx, "widget-1", "s1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "widget-1", "s1"); err != errors.ErrSubmissionNotFound {
+		t.Errorf("Expected the submission to be gone, got %v", err)
+	}
+	if _, total, err := repo.GetByWidgetID(ctx, "widget-1", models.PaginationOptions{Page: 1, PerPage: 10}); err != nil || total != 0 {
+		t.Errorf("Expected an empty index, got %d, %v", total, err)
+	}
+	if widgetStats, err := stats.GetWidgetStats(ctx, "widget-1"); err != nil || widgetStats.Submits != 0 {
+		t.Errorf("Expected the submit count to drop to 0, got %+v, %v", widgetStats, err)
+	}
+
+	// Missing submissions are reported and leave the count alone
+	if err := repo.Delete(ctx, "widget-1", "s1"); err != errors.ErrSubmissionNotFound {
+		t.Errorf("Expected ErrSubmissionNotFound, got %v", err)
+	}
+	if widgetStats, err := stats.GetWidgetStats(ctx, "widget-1"); err != nil || widgetStats.Submits != 0 {
+		t.Errorf("Expected the submit count to stay at 0, got %+v, %v", widgetStats, err)
+	}
+}
+
+func TestRedisSubmissionRepository_EvictOldest(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	repo := NewRedisSubmissionRepository(redisClient)
+	stats := NewRedisStatsRepository(redisClient)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		if err := repo.Create(ctx, &models.Submission{ID: id, WidgetID: "widget-1", Data: map[string]interface{}{"n": id}, CreatedAt: base.Add(time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatalf("Failed to create submission %s: %v", id, err)
+		}
+		if err := stats.IncrementSubmits(ctx, "widget-1"); err != nil {
+			t.Fatalf("Failed to increment submits: %v", err)
+		}
+	}
+	// An expired submission is dropped from the index without counting
+	redisClient.client.Del(ctx, GenerateSubmissionKey("widget-1", "a"))
+
+	evicted, err := repo.EvictOldest(ctx, "widget-1", 2)
+	if err != nil {
+		t.Fatalf("EvictOldest failed: %v", err)
+	}
+	if evicted != 2 {
+		t.Errorf("Expected 2 evicted, got %d", evicted)
+	}
+	if count, _ := redisClient.client.ZCard(ctx, GenerateWidgetSubmissionsKey("widget-1")).Result(); count != 2 {
+		t.Errorf("Expected 2 indexed submissions, got %d", count)
+	}
+	for id, exists := range map[string]bool{"b": false, "c": false, "d": true, "e": true} {
+		if _, err := repo.GetByID(ctx, "widget-1", id); (err == nil) != exists {
+			t.Errorf("Expected submission %s to exist: %v, got %v", id, exists, err)
+		}
+	}
+	if widgetStats, err := stats.GetWidgetStats(ctx, "widget-1"); err != nil || widgetStats.Submits != 3 {
+		t.Errorf("Expected the submit count to drop to 3, got %+v, %v", widgetStats, err)
+	}
+
+	// Nothing left to evict
+	if evicted, err := repo.EvictOldest(ctx, "widget-1", 2); err != nil || evicted != 0 {
+		t.Errorf("Expected nothing evicted, got %d, %v", evicted, err)
+	}
+}
+
+func TestRedisSubmissionRepository_DeleteByRange(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	repo := NewRedisSubmissionRepository(redisClient)
+	stats := NewRedisStatsRepository(redisClient)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"a", "b", "c", "d"} {
+		if err := repo.Create(ctx, &models.Submission{ID: id, WidgetID: "widget-1", Data: map[string]interface{}{"n": id}, CreatedAt: base.Add(time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatalf("Failed to create submission %s: %v", id, err)
+		}
+		if err := stats.IncrementSubmits(ctx, "widget-1"); err != nil {
+			t.Fatalf("Failed to increment submits: %v", err)
+		}
+	}
+
+	// Bounds are inclusive
+	deleted, err := repo.DeleteByRange(ctx, "widget-1", base.Add(time.Hour), base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteByRange failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 deleted, got %d", deleted)
+	}
+	for id, exists := range map[string]bool{"a": true, "b": false, "c": false, "d": true} {
+		_, err := repo.GetByID(ctx, "widget-1", id)
+		if exists && err != nil {
+			t.Errorf("Expected out-of-range submission %s to survive, got %v", id, err)
+		}
+		if !exists && err != errors.ErrSubmissionNotFound {
+			t.Errorf("Expected submission %s to be deleted, got %v", id, err)
+		}
+	}
+	if widgetStats, err := stats.GetWidgetStats(ctx, "widget-1"); err != nil || widgetStats.Submits != 2 {
+		t.Errorf("Expected the submit count to drop to 2, got %+v, %v", widgetStats, err)
+	}
+
+	// An open lower bound reaches back to the first submission
+	if deleted, err := repo.DeleteByRange(ctx, "widget-1", time.Time{}, base.Add(90*time.Minute)); err != nil || deleted != 1 {
+		t.Errorf("Expected 1 deleted up to the bound, got %d, %v", deleted, err)
+	}
+
+	// Without bounds everything goes, and the count never drops below zero
+	if err := stats.ResetStats(ctx, "widget-1"); err != nil {
+		t.Fatalf("Failed to reset stats: %v", err)
+	}
+	if deleted, err := repo.DeleteByRange(ctx, "widget-1", time.Time{}, time.Time{}); err != nil || deleted != 1 {
+		t.Errorf("Expected the last submission deleted, got %d, %v", deleted, err)
+	}
+	if _, total, err := repo.GetByWidgetID(ctx, "widget-1", models.PaginationOptions{Page: 1, PerPage: 10}); err != nil || total != 0 {
+		t.Errorf("Expected an empty index, got %d, %v", total, err)
+	}
+	if widgetStats, err := stats.GetWidgetStats(ctx, "widget-1"); err != nil || widgetStats.Submits != 0 {
+		t.Errorf("Expected the submit count to stay at 0, got %+v, %v", widgetStats, err)
+	}
+}
+
+func TestRedisSubmissionRepository_SearchByWidgetID(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	repo := NewRedisSubmissionRepository(redisClient)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	// More submissions than fit one batch, every 50th with a nested match
+	count := searchBatchSize + 50
+	for i := 0; i < count; i++ {
+		data := map[string]interface{}{"name": fmt.Sprintf("Lead %d", i)}
+		if i%50 == 0 {
+			data["contact"] = map[string]interface{}{"emails": []interface{}{fmt.Sprintf("Target-%d@Example.com", i)}}
+		}
+		if err := repo.Create(ctx, &models.Submission{ID: fmt.Sprintf("s%03d", i), WidgetID: "widget-1", Data: data, CreatedAt: base.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatalf("Failed to create submission: %v", err)
+		}
+	}
+
+	filter := models.SubmissionFilter{Search: "target-"}
+	submissions, total, err := repo.SearchByWidgetID(ctx, "widget-1", filter, models.PaginationOptions{Page: 1, PerPage: 2})
+	if err != nil {
+		t.Fatalf("SearchByWidgetID failed: %v", err)
+	}
+	if total != 5 {
+		t.Errorf("Expected 5 matches, got %d", total)
+	}
+	if len(submissions) != 2 || submissions[0].ID != "s200" || submissions[1].ID != "s150" {
+		t.Errorf("Expected the newest matches s200 and s150, got %v", submissionIDs(submissions))
+	}
+
+	// The last page holds the rest, from the second batch
+	submissions, _, err = repo.SearchByWidgetID(ctx, "widget-1", filter, models.PaginationOptions{Page: 3, PerPage: 2})
+	if err != nil {
+		t.Fatalf("SearchByWidgetID failed: %v", err)
+	}
+	if len(submissions) != 1 || submissions[0].ID != "s000" {
+		t.Errorf("Expected the oldest match s000, got %v", submissionIDs(submissions))
+	}
+
+	if submissions, total, err := repo.SearchByWidgetID(ctx, "widget-1", models.SubmissionFilter{Search: "nobody"}, models.PaginationOptions{Page: 1, PerPage: 10}); err != nil || total != 0 || len(submissions) != 0 {
+		t.Errorf("Expected no matches, got %d, %v", total, err)
+	}
+}
+
+func submissionIDs(submissions []*models.Submission) []string {
+	ids := make([]string, 0, len(submissions))
+	for _, submission := range submissions {
+		ids = append(ids, submission.ID)
+	}
+	return ids
+}
@@ -1,14 +1,18 @@
 package storage
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ad/leads-core/internal/errors"
 	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/pkg/monitoring"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -16,6 +20,7 @@ import (
 type WidgetRepository interface {
 	Create(ctx context.Context, widget *models.Widget) error
 	GetByID(ctx context.Context, id string) (*models.Widget, error)
+	GetByIDs(ctx context.Context, ids []string) ([]*models.Widget, error)
 	GetByUserID(ctx context.Context, userID string, opts models.PaginationOptions) ([]*models.Widget, int, error)
 	GetByUserIDWithFilters(ctx context.Context, userID string, opts models.PaginationOptions) ([]*models.Widget, int, error)
 	Update(ctx context.Context, widget *models.Widget) error
@@ -24,6 +29,27 @@ type WidgetRepository interface {
 	GetWidgetsByStatus(ctx context.Context, enabled bool, opts models.PaginationOptions) ([]*models.Widget, error)
 	GetTypeStats(ctx context.Context, userID string) ([]*models.TypeStats, error)
 	RebuildIndexes(ctx context.Context) error
+	CleanupExpiredWidgets(ctx context.Context, now time.Time) (int, error)
+	GetPIIWidgetIDs(ctx context.Context) ([]string, error)
+	GetVisibleWidgetIDs(ctx context.Context) ([]string, error)
+	GetWidgetIDByName(ctx context.Context, userID, name string) (string, error)
+	GetIndexMembership(ctx context.Context, id string) (*models.WidgetIndexReport, error)
+	PushConfigHistory(ctx context.Context, widgetID string, version *models.WidgetConfigVersion, limit int) error
+	GetConfigHistory(ctx context.Context, widgetID string) ([]*models.WidgetConfigVersion, error)
+}
+
+// expiringWidgetIndex holds what is needed to remove an expired widget from indexes,
+// since its own hash is already gone by then
+type expiringWidgetIndex struct {
+	OwnerID string `json:"owner_id"`
+	Type    string `json:"type"`
+	Name    string `json:"name,omitempty"`
+}
+
+// newExpiringWidgetIndex returns the encoded expiry index entry of a widget
+func newExpiringWidgetIndex(widget *models.Widget) string {
+	index, _ := json.Marshal(expiringWidgetIndex{OwnerID: widget.OwnerID, Type: widget.Type, Name: widget.Name})
+	return string(index)
 }
 
 // RedisWidgetRepository implements WidgetRepository for Redis
@@ -58,32 +84,47 @@ func (r *RedisWidgetRepository) Create(ctx context.Context, widget *models.Widge
 		"closes":    0,
 	})
 
+	// Auto-expiring widgets (demo plan) drop their data on expiry
+	if widget.ExpiresAt != nil {
+		widgetSlotPipe.ExpireAt(ctx, widgetKey, *widget.ExpiresAt)
+		widgetSlotPipe.ExpireAt(ctx, statsKey, *widget.ExpiresAt)
+	}
+
 	_, err := widgetSlotPipe.Exec(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to store widget data: %w", err)
 	}
 
-	// Step 2: Update user widgets index (separate slot)
+	// Step 2: Update the user and global indexes. They live in other slots, so they
+	// can't join the transaction above; one pipeline sends them together, and a failure
+	// rolls the widget back so no record is left that listings can't find. Should the
+	// rollback fail too, RebuildIndexes indexes the remaining record.
+	indexPipe := r.client.client.Pipeline()
+
 	userWidgetsKey := GenerateUserWidgetsKey(widget.OwnerID)
-	timestamp := float64(widget.CreatedAt.UnixNano())
-	if err := r.client.client.ZAdd(ctx, userWidgetsKey, redis.Z{Score: timestamp, Member: widget.ID}).Err(); err != nil {
-		return fmt.Errorf("failed to update user widgets index: %w", err)
-	}
+	indexPipe.ZAdd(ctx, userWidgetsKey, redis.Z{Score: float64(widget.CreatedAt.UnixNano()), Member: widget.ID})
+	indexPipe.ZAdd(ctx, WidgetsByTimeKey, redis.Z{Score: float64(widget.CreatedAt.Unix()), Member: widget.ID})
+	indexPipe.SAdd(ctx, GenerateWidgetsByTypeKey(widget.Type), widget.ID)
+	indexPipe.SAdd(ctx, GenerateWidgetsByStatusKey(widget.IsVisible), widget.ID)
 
-	// Step 3: Update global indexes (separate operations to avoid cross-slot issues)
-	timestamp = float64(widget.CreatedAt.Unix())
-	if err := r.client.client.ZAdd(ctx, WidgetsByTimeKey, redis.Z{Score: timestamp, Member: widget.ID}).Err(); err != nil {
-		return fmt.Errorf("failed to update time index: %w", err)
+	// Track auto-expiring widgets for the cleanup sweep
+	if widget.ExpiresAt != nil {
+		indexPipe.HSet(ctx, WidgetsExpiryIndex, widget.ID, newExpiringWidgetIndex(widget))
+		indexPipe.ZAdd(ctx, WidgetsExpiringKey, redis.Z{Score: float64(widget.ExpiresAt.Unix()), Member: widget.ID})
 	}
 
-	typeKey := GenerateWidgetsByTypeKey(widget.Type)
-	if err := r.client.client.SAdd(ctx, typeKey, widget.ID).Err(); err != nil {
-		return fmt.Errorf("failed to update type index: %w", err)
+	// Track widgets with PII redaction for the redaction job
+	if fields, _ := widget.PIISettings(); len(fields) > 0 {
+		indexPipe.SAdd(ctx, WidgetsPIIKey, widget.ID)
 	}
 
-	statusKey := GenerateWidgetsByStatusKey(widget.IsVisible)
-	if err := r.client.client.SAdd(ctx, statusKey, widget.ID).Err(); err != nil {
-		return fmt.Errorf("failed to update status index: %w", err)
+	// Update user widget names index (used by the name uniqueness check)
+	indexPipe.HSet(ctx, GenerateUserWidgetNamesKey(widget.OwnerID), models.NormalizeWidgetName(widget.Name), widget.ID)
+
+	if _, err := indexPipe.Exec(ctx); err != nil {
+		r.removeFromIndexes(ctx, widget)
+		r.client.client.Del(ctx, widgetKey, statsKey)
+		return fmt.Errorf("failed to update widget indexes: %w", err)
 	}
 
 	return nil
@@ -109,6 +150,15 @@ func (r *RedisWidgetRepository) GetByID(ctx context.Context, id string) (*models
 	return widget, nil
 }
 
+// GetByIDs retrieves several widgets at once, in the given order. Missing IDs are skipped.
+func (r *RedisWidgetRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Widget, error) {
+	widgets, err := r.batchLoadWidgets(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to batch load widgets: %w", err)
+	}
+	return widgets, nil
+}
+
 // GetByUserID retrieves widgets for a specific user with pagination
 func (r *RedisWidgetRepository) GetByUserID(ctx context.Context, userID string, opts models.PaginationOptions) ([]*models.Widget, int, error) {
 	userWidgetsKey := GenerateUserWidgetsKey(userID)
@@ -124,7 +174,9 @@ func (r *RedisWidgetRepository) GetByUserID(ctx context.Context, userID string,
 	end := start + int64(opts.PerPage) - 1
 
 	// Get widget IDs for the user, sorted by creation time (newest first)
+	queryStart := time.Now()
 	widgetIDs, err := r.client.client.ZRevRange(ctx, userWidgetsKey, start, end).Result()
+	monitoring.TrackQuery("ZREVRANGE", keyPattern(UserWidgetsKey), queryStart)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -142,24 +194,40 @@ func (r *RedisWidgetRepository) GetByUserID(ctx context.Context, userID string,
 	return widgets, int(total), nil
 }
 
-// GetByUserIDWithFilters retrieves widgets for a specific user with filtering and pagination
+// GetByUserIDWithFilters retrieves widgets for a specific user with filtering and pagination.
+// Archived widgets are left out unless the filters include them.
 func (r *RedisWidgetRepository) GetByUserIDWithFilters(ctx context.Context, userID string, opts models.PaginationOptions) ([]*models.Widget, int, error) {
-	// If no filters are applied, use the existing method for optimal performance
-	if opts.Filters == nil || !opts.Filters.HasFilters() {
-		return r.GetByUserID(ctx, userID, opts)
+	var archived map[string]bool
+	if opts.Filters == nil || !opts.Filters.IncludeArchived {
+		var err error
+		if archived, err = r.getArchivedWidgetIDs(ctx, userID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// Orders other than newest first depend on widget data or stats, so they are sorted
+	// after loading
+	if !opts.IsDefaultOrder() {
+		return r.getByUserIDSorted(ctx, userID, opts, archived)
 	}
 
 	// Validate and clean filter options
 	filters := models.ValidateFilterOptions(opts.Filters)
-	if filters == nil || !filters.HasFilters() {
+
+	// If no filters are applied and nothing is archived, use the existing method for optimal performance
+	if (filters == nil || !filters.HasFilters()) && len(archived) == 0 {
 		return r.GetByUserID(ctx, userID, opts)
 	}
+	if filters == nil {
+		filters = &models.FilterOptions{}
+	}
 
 	// Get filtered widget IDs using Redis set operations
 	filteredWidgetIDs, err := r.getFilteredWidgetIDs(ctx, userID, filters)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get filtered widget IDs: %w", err)
 	}
+	filteredWidgetIDs = withoutWidgetIDs(filteredWidgetIDs, archived)
 
 	// Apply name search filter if specified
 	if filters.HasSearchFilter() {
@@ -194,6 +262,128 @@ func (r *RedisWidgetRepository) GetByUserIDWithFilters(ctx context.Context, user
 	return widgets, total, nil
 }
 
+// getArchivedWidgetIDs returns the set of the user's archived widgets
+func (r *RedisWidgetRepository) getArchivedWidgetIDs(ctx context.Context, userID string) (map[string]bool, error) {
+	ids, err := r.client.client.SMembers(ctx, GenerateUserArchivedKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived widgets: %w", err)
+	}
+	archived := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		archived[id] = true
+	}
+	return archived, nil
+}
+
+// withoutWidgetIDs removes the excluded IDs, keeping the order
+func withoutWidgetIDs(widgetIDs []string, excluded map[string]bool) []string {
+	if len(excluded) == 0 {
+		return widgetIDs
+	}
+	kept := make([]string, 0, len(widgetIDs))
+	for _, id := range widgetIDs {
+		if !excluded[id] {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// getByUserIDSorted loads all matching widgets of a user with their stats, orders them
+// by the sort key and direction, and paginates the result. Widgets without stats count
+// as zero, ties keep the newest-first order.
+func (r *RedisWidgetRepository) getByUserIDSorted(ctx context.Context, userID string, opts models.PaginationOptions, archived map[string]bool) ([]*models.Widget, int, error) {
+	var widgetIDs []string
+	var err error
+
+	filters := models.ValidateFilterOptions(opts.Filters)
+	if filters != nil && filters.HasFilters() {
+		widgetIDs, err = r.getFilteredWidgetIDs(ctx, userID, filters)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get filtered widget IDs: %w", err)
+		}
+		if filters.HasSearchFilter() {
+			widgetIDs, err = r.applyNameSearchFilter(ctx, widgetIDs, filters.Search)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to apply name search filter: %w", err)
+			}
+		}
+	} else {
+		queryStart := time.Now()
+		widgetIDs, err = r.client.client.ZRevRange(ctx, GenerateUserWidgetsKey(userID), 0, -1).Result()
+		monitoring.TrackQuery("ZREVRANGE", keyPattern(UserWidgetsKey), queryStart)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	widgetIDs = withoutWidgetIDs(widgetIDs, archived)
+
+	widgets, err := r.batchLoadWidgets(ctx, widgetIDs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to batch load widgets: %w", err)
+	}
+
+	sort.Slice(widgets, func(i, j int) bool {
+		if c := compareWidgetOrder(widgets[i], widgets[j], opts); c != 0 {
+			return c > 0
+		}
+		return newerWidget(widgets[i].CreatedAt.UnixNano(), widgets[i].ID, widgets[j].CreatedAt.UnixNano(), widgets[j].ID)
+	})
+
+	total := len(widgets)
+	start := (opts.Page - 1) * opts.PerPage
+	if start >= total {
+		return []*models.Widget{}, total, nil
+	}
+	end := start + opts.PerPage
+	if end > total {
+		end = total
+	}
+
+	return widgets[start:end], total, nil
+}
+
+// compareWidgetOrder compares two widgets by the sort key in the sort direction,
+// returning a positive number when a goes first
+func compareWidgetOrder(a, b *models.Widget, opts models.PaginationOptions) int {
+	statsA, statsB := a.Stats, b.Stats
+	if statsA == nil {
+		statsA = &models.WidgetStats{}
+	}
+	if statsB == nil {
+		statsB = &models.WidgetStats{}
+	}
+
+	var c int
+	switch opts.SortBy {
+	case models.SortByName:
+		c = cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	case models.SortByLastActivity:
+		c = statsA.LastActivity().Compare(statsB.LastActivity())
+	case models.SortBySubmits:
+		c = cmp.Compare(statsA.Submits, statsB.Submits)
+	case models.SortByViews:
+		c = cmp.Compare(statsA.Views, statsB.Views)
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+
+	// Descending puts the larger value first
+	if !opts.SortDescending() {
+		return -c
+	}
+	return c
+}
+
+// newerWidget orders widgets newest first by creation time, then by descending ID as
+// ZREVRANGE does for equal scores, so every pair of distinct widgets is ordered
+func newerWidget(createdA int64, idA string, createdB int64, idB string) bool {
+	if createdA != createdB {
+		return createdA > createdB
+	}
+	return idA > idB
+}
+
 // Update updates an existing widget
 func (r *RedisWidgetRepository) Update(ctx context.Context, widget *models.Widget) error {
 	// Get existing widget to compare indexes
@@ -224,6 +414,28 @@ func (r *RedisWidgetRepository) Update(ctx context.Context, widget *models.Widge
 		r.client.client.SAdd(ctx, newStatusKey, widget.ID)
 	}
 
+	if fields, _ := widget.PIISettings(); len(fields) > 0 {
+		r.client.client.SAdd(ctx, WidgetsPIIKey, widget.ID)
+	} else {
+		r.client.client.SRem(ctx, WidgetsPIIKey, widget.ID)
+	}
+
+	if widget.Archived {
+		r.client.client.SAdd(ctx, GenerateUserArchivedKey(widget.OwnerID), widget.ID)
+	} else if existingWidget.Archived {
+		r.client.client.SRem(ctx, GenerateUserArchivedKey(widget.OwnerID), widget.ID)
+	}
+
+	if models.NormalizeWidgetName(existingWidget.Name) != models.NormalizeWidgetName(widget.Name) {
+		r.removeWidgetName(ctx, widget.OwnerID, existingWidget.Name, widget.ID)
+		r.client.client.HSet(ctx, GenerateUserWidgetNamesKey(widget.OwnerID), models.NormalizeWidgetName(widget.Name), widget.ID)
+	}
+
+	// Keep the expiry index entry in step, cleanup removes the entries it names
+	if widget.ExpiresAt != nil {
+		r.client.client.HSet(ctx, WidgetsExpiryIndex, widget.ID, newExpiringWidgetIndex(widget))
+	}
+
 	return nil
 }
 
@@ -252,6 +464,10 @@ func (r *RedisWidgetRepository) Delete(ctx context.Context, id string) error {
 		widgetSlotPipe.Del(ctx, submissionKey)
 	}
 	widgetSlotPipe.Del(ctx, submissionsKey)
+	widgetSlotPipe.Del(ctx, GenerateSubmissionCorrelationKey(id))
+	widgetSlotPipe.Del(ctx, GeneratePIIRedactedUntilKey(id))
+	widgetSlotPipe.Del(ctx, GenerateWidgetConfigHistoryKey(id))
+	r.deleteExportJobs(ctx, widgetSlotPipe, id)
 
 	_, err = widgetSlotPipe.Exec(ctx)
 	if err != nil {
@@ -259,20 +475,161 @@ func (r *RedisWidgetRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	// Step 2: Remove from global indexes (separate operations)
-	r.client.client.ZRem(ctx, WidgetsByTimeKey, id)
+	r.removeFromIndexes(ctx, widget)
 
-	userWidgetsKey := GenerateUserWidgetsKey(widget.OwnerID)
-	r.client.client.ZRem(ctx, userWidgetsKey, id)
+	return nil
+}
+
+// removeFromIndexes removes the widget from the user and global indexes, best effort.
+// Both status sets are cleared, so callers holding only the expiry index entry of a
+// widget don't need its visibility.
+func (r *RedisWidgetRepository) removeFromIndexes(ctx context.Context, widget *models.Widget) {
+	r.client.client.ZRem(ctx, WidgetsByTimeKey, widget.ID)
+	r.client.client.ZRem(ctx, GenerateUserWidgetsKey(widget.OwnerID), widget.ID)
+	r.client.client.SRem(ctx, GenerateWidgetsByTypeKey(widget.Type), widget.ID)
+	r.client.client.SRem(ctx, GenerateWidgetsByStatusKey(true), widget.ID)
+	r.client.client.SRem(ctx, GenerateWidgetsByStatusKey(false), widget.ID)
+	r.client.client.ZRem(ctx, WidgetsExpiringKey, widget.ID)
+	r.client.client.HDel(ctx, WidgetsExpiryIndex, widget.ID)
+	r.client.client.SRem(ctx, WidgetsPIIKey, widget.ID)
+	r.client.client.SRem(ctx, GenerateUserArchivedKey(widget.OwnerID), widget.ID)
+	r.removeWidgetName(ctx, widget.OwnerID, widget.Name, widget.ID)
+}
 
-	typeKey := GenerateWidgetsByTypeKey(widget.Type)
-	r.client.client.SRem(ctx, typeKey, id)
+// deleteExportJobs queues deletion of the widget's export jobs and their results
+func (r *RedisWidgetRepository) deleteExportJobs(ctx context.Context, pipe redis.Pipeliner, widgetID string) {
+	exportJobsKey := GenerateWidgetExportJobsKey(widgetID)
+	jobIDs, _ := r.client.client.ZRange(ctx, exportJobsKey, 0, -1).Result()
+	for _, jobID := range jobIDs {
+		pipe.Del(ctx, GenerateExportJobKey(widgetID, jobID), GenerateExportJobDataKey(widgetID, jobID))
+	}
+	pipe.Del(ctx, exportJobsKey)
+}
 
-	statusKey := GenerateWidgetsByStatusKey(widget.IsVisible)
-	r.client.client.SRem(ctx, statusKey, id)
+// CleanupExpiredWidgets removes index entries and remaining data of auto-expiring
+// widgets whose expiry has passed, returning the number of widgets cleaned up
+func (r *RedisWidgetRepository) CleanupExpiredWidgets(ctx context.Context, now time.Time) (int, error) {
+	widgetIDs, err := r.client.client.ZRangeByScore(ctx, WidgetsExpiringKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired widgets: %w", err)
+	}
+
+	cleaned := 0
+	for _, id := range widgetIDs {
+		var index expiringWidgetIndex
+		if raw, err := r.client.client.HGet(ctx, WidgetsExpiryIndex, id).Result(); err == nil {
+			json.Unmarshal([]byte(raw), &index)
+		}
+
+		// Step 1: Delete widget data and submissions in same slot
+		// (widget hash and stats normally expired already)
+		widgetSlotPipe := r.client.client.TxPipeline()
+		widgetSlotPipe.Del(ctx, GenerateWidgetKey(id))
+		widgetSlotPipe.Del(ctx, GenerateWidgetStatsKey(id))
+
+		submissionsKey := GenerateWidgetSubmissionsKey(id)
+		submissionIDs, _ := r.client.client.ZRange(ctx, submissionsKey, 0, -1).Result()
+		for _, submissionID := range submissionIDs {
+			widgetSlotPipe.Del(ctx, GenerateSubmissionKey(id, submissionID))
+		}
+		widgetSlotPipe.Del(ctx, submissionsKey)
+		widgetSlotPipe.Del(ctx, GenerateSubmissionCorrelationKey(id))
+		widgetSlotPipe.Del(ctx, GeneratePIIRedactedUntilKey(id))
+		widgetSlotPipe.Del(ctx, GenerateWidgetConfigHistoryKey(id))
+		r.deleteExportJobs(ctx, widgetSlotPipe, id)
+
+		if _, err := widgetSlotPipe.Exec(ctx); err != nil {
+			return cleaned, fmt.Errorf("failed to delete expired widget data: %w", err)
+		}
 
+		// Step 2: Remove from the user and global indexes (separate operations)
+		r.removeFromIndexes(ctx, &models.Widget{ID: id, OwnerID: index.OwnerID, Type: index.Type, Name: index.Name})
+		cleaned++
+	}
+
+	return cleaned, nil
+}
+
+// PushConfigHistory adds a replaced config to the front of the widget's config history,
+// keeping the newest limit entries
+func (r *RedisWidgetRepository) PushConfigHistory(ctx context.Context, widgetID string, version *models.WidgetConfigVersion, limit int) error {
+	data, err := json.Marshal(version)
+	if err != nil {
+		return fmt.Errorf("failed to encode config version: %w", err)
+	}
+
+	historyKey := GenerateWidgetConfigHistoryKey(widgetID)
+	pipe := r.client.client.TxPipeline()
+	pipe.LPush(ctx, historyKey, data)
+	pipe.LTrim(ctx, historyKey, 0, int64(limit-1))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to push config history: %w", err)
+	}
 	return nil
 }
 
+// GetConfigHistory returns the widget's replaced configs, newest first
+func (r *RedisWidgetRepository) GetConfigHistory(ctx context.Context, widgetID string) ([]*models.WidgetConfigVersion, error) {
+	entries, err := r.client.client.LRange(ctx, GenerateWidgetConfigHistoryKey(widgetID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config history: %w", err)
+	}
+
+	history := make([]*models.WidgetConfigVersion, 0, len(entries))
+	for _, entry := range entries {
+		var version models.WidgetConfigVersion
+		if err := json.Unmarshal([]byte(entry), &version); err != nil {
+			continue
+		}
+		history = append(history, &version)
+	}
+	return history, nil
+}
+
+// GetWidgetIDByName returns the ID of the user's widget with the given name (compared
+// case-insensitively), or "" when there is none. Entries of widgets that no longer
+// exist are ignored.
+func (r *RedisWidgetRepository) GetWidgetIDByName(ctx context.Context, userID, name string) (string, error) {
+	widgetID, err := r.client.client.HGet(ctx, GenerateUserWidgetNamesKey(userID), models.NormalizeWidgetName(name)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	exists, err := r.client.client.Exists(ctx, GenerateWidgetKey(widgetID)).Result()
+	if err != nil {
+		return "", err
+	}
+	if exists == 0 {
+		return "", nil
+	}
+	return widgetID, nil
+}
+
+// removeWidgetName drops the name from the user's names index if it still points to the widget
+func (r *RedisWidgetRepository) removeWidgetName(ctx context.Context, userID, name, widgetID string) {
+	namesKey := GenerateUserWidgetNamesKey(userID)
+	normalized := models.NormalizeWidgetName(name)
+	if current, err := r.client.client.HGet(ctx, namesKey, normalized).Result(); err == nil && current == widgetID {
+		r.client.client.HDel(ctx, namesKey, normalized)
+	}
+}
+
+// GetPIIWidgetIDs returns IDs of widgets that have PII redaction configured
+func (r *RedisWidgetRepository) GetPIIWidgetIDs(ctx context.Context) ([]string, error) {
+	return r.client.client.SMembers(ctx, WidgetsPIIKey).Result()
+}
+
+// GetVisibleWidgetIDs returns IDs of all visible widgets
+func (r *RedisWidgetRepository) GetVisibleWidgetIDs(ctx context.Context) ([]string, error) {
+	return r.client.client.SMembers(ctx, GenerateWidgetsByStatusKey(true)).Result()
+}
+
 // GetWidgetsByType retrieves widgets by type with pagination
 func (r *RedisWidgetRepository) GetWidgetsByType(ctx context.Context, widgetType string, opts models.PaginationOptions) ([]*models.Widget, error) {
 	typeKey := GenerateWidgetsByTypeKey(widgetType)
@@ -293,9 +650,8 @@ func (r *RedisWidgetRepository) GetWidgetsByType(ctx context.Context, widgetType
 		}
 
 		// Load stats for the widget
-		if stats, err := r.statsRepo.GetWidgetStats(ctx, widgetID); err == nil {
-			widget.Stats = stats
-		}
+		stats, err := r.statsRepo.GetWidgetStats(ctx, widgetID)
+		setListingStats(widget, stats, err)
 
 		widgets = append(widgets, widget)
 	}
@@ -323,9 +679,8 @@ func (r *RedisWidgetRepository) GetWidgetsByStatus(ctx context.Context, enabled
 		}
 
 		// Load stats for the widget
-		if stats, err := r.statsRepo.GetWidgetStats(ctx, widgetID); err == nil {
-			widget.Stats = stats
-		}
+		stats, err := r.statsRepo.GetWidgetStats(ctx, widgetID)
+		setListingStats(widget, stats, err)
 
 		widgets = append(widgets, widget)
 	}
@@ -354,14 +709,10 @@ func (r *RedisWidgetRepository) getFilteredWidgetIDs(ctx context.Context, userID
 		setsToIntersect = append(setsToIntersect, statusKey)
 	}
 
-	// If we only have user widgets (no additional filters), get all user widgets
+	// If we only have user widgets (no additional filters), get all user widgets in the
+	// creation date range, newest first
 	if len(setsToIntersect) == 1 {
-		// Get all user widgets sorted by creation time (newest first)
-		widgetIDs, err := r.client.client.ZRevRange(ctx, userWidgetsKey, 0, -1).Result()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get user widgets: %w", err)
-		}
-		return widgetIDs, nil
+		return r.getUserWidgetIDsCreatedIn(ctx, userWidgetsKey, filters)
 	}
 
 	// Use SINTER to find intersection of all sets
@@ -375,10 +726,10 @@ func (r *RedisWidgetRepository) getFilteredWidgetIDs(ctx context.Context, userID
 	tempUserSetKey := fmt.Sprintf("temp:user_set:%s:%d", userID, time.Now().UnixNano())
 	defer r.client.client.Del(ctx, tempUserSetKey) // Clean up temp key
 
-	// Get all user widget IDs and add them to a temporary SET
-	userWidgetIDs, err := r.client.client.ZRange(ctx, userWidgetsKey, 0, -1).Result()
+	// Get the user widget IDs in the creation date range and add them to a temporary SET
+	userWidgetIDs, err := r.getUserWidgetIDsCreatedIn(ctx, userWidgetsKey, filters)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user widgets: %w", err)
+		return nil, err
 	}
 
 	if len(userWidgetIDs) == 0 {
@@ -394,7 +745,13 @@ func (r *RedisWidgetRepository) getFilteredWidgetIDs(ctx context.Context, userID
 	setsToIntersect[0] = tempUserSetKey
 
 	// Single type or visibility filter - use direct SINTER
+	indexPattern := keyPattern(WidgetsByStatusKey)
+	if filters.HasTypeFilter() {
+		indexPattern = keyPattern(WidgetsByTypeKey)
+	}
+	queryStart := time.Now()
 	widgetIDs, err := r.client.client.SInter(ctx, setsToIntersect...).Result()
+	monitoring.TrackQuery("SINTER", indexPattern, queryStart)
 	if err != nil {
 		return nil, fmt.Errorf("failed to intersect widget sets: %w", err)
 	}
@@ -403,6 +760,26 @@ func (r *RedisWidgetRepository) getFilteredWidgetIDs(ctx context.Context, userID
 	return r.sortWidgetIDsByCreationTime(ctx, userWidgetsKey, widgetIDs)
 }
 
+// getUserWidgetIDsCreatedIn returns the user's widget IDs created within the filters'
+// date bounds, newest first, by user widgets ZSET score (creation time in nanoseconds)
+func (r *RedisWidgetRepository) getUserWidgetIDsCreatedIn(ctx context.Context, userWidgetsKey string, filters *models.FilterOptions) ([]string, error) {
+	scoreRange := &redis.ZRangeBy{Min: "-inf", Max: "+inf"}
+	if filters.CreatedFrom != nil {
+		scoreRange.Min = strconv.FormatInt(filters.CreatedFrom.UnixNano(), 10)
+	}
+	if filters.CreatedTo != nil {
+		scoreRange.Max = strconv.FormatInt(filters.CreatedTo.UnixNano(), 10)
+	}
+
+	queryStart := time.Now()
+	widgetIDs, err := r.client.client.ZRevRangeByScore(ctx, userWidgetsKey, scoreRange).Result()
+	monitoring.TrackQuery("ZREVRANGEBYSCORE", keyPattern(UserWidgetsKey), queryStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user widgets: %w", err)
+	}
+	return widgetIDs, nil
+}
+
 // getFilteredWidgetIDsWithMultipleTypes handles filtering when multiple types are specified
 func (r *RedisWidgetRepository) getFilteredWidgetIDsWithMultipleTypes(ctx context.Context, userID string, filters *models.FilterOptions) ([]string, error) {
 	userWidgetsKey := GenerateUserWidgetsKey(userID)
@@ -417,7 +794,10 @@ func (r *RedisWidgetRepository) getFilteredWidgetIDsWithMultipleTypes(ctx contex
 	typeUnionKey := fmt.Sprintf("temp:type_union:%s:%d", userID, time.Now().UnixNano())
 	defer r.client.client.Del(ctx, typeUnionKey) // Clean up temp key
 
-	if err := r.client.client.SUnionStore(ctx, typeUnionKey, typeKeys...).Err(); err != nil {
+	queryStart := time.Now()
+	err := r.client.client.SUnionStore(ctx, typeUnionKey, typeKeys...).Err()
+	monitoring.TrackQuery("SUNIONSTORE", keyPattern(WidgetsByTypeKey), queryStart)
+	if err != nil {
 		return nil, fmt.Errorf("failed to create type union: %w", err)
 	}
 
@@ -425,10 +805,10 @@ func (r *RedisWidgetRepository) getFilteredWidgetIDsWithMultipleTypes(ctx contex
 	tempUserSetKey := fmt.Sprintf("temp:user_set:%s:%d", userID, time.Now().UnixNano())
 	defer r.client.client.Del(ctx, tempUserSetKey) // Clean up temp key
 
-	// Get all user widget IDs and add them to a temporary SET
-	userWidgetIDs, err := r.client.client.ZRange(ctx, userWidgetsKey, 0, -1).Result()
+	// Get the user widget IDs in the creation date range and add them to a temporary SET
+	userWidgetIDs, err := r.getUserWidgetIDsCreatedIn(ctx, userWidgetsKey, filters)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user widgets: %w", err)
+		return nil, err
 	}
 
 	if len(userWidgetIDs) == 0 {
@@ -448,7 +828,9 @@ func (r *RedisWidgetRepository) getFilteredWidgetIDsWithMultipleTypes(ctx contex
 		setsToIntersect = append(setsToIntersect, statusKey)
 	}
 
+	queryStart = time.Now()
 	widgetIDs, err := r.client.client.SInter(ctx, setsToIntersect...).Result()
+	monitoring.TrackQuery("SINTER", keyPattern(WidgetsByTypeKey), queryStart)
 	if err != nil {
 		return nil, fmt.Errorf("failed to intersect widget sets with multiple types: %w", err)
 	}
@@ -471,7 +853,9 @@ func (r *RedisWidgetRepository) sortWidgetIDsByCreationTime(ctx context.Context,
 		scoreCommands[i] = pipe.ZScore(ctx, userWidgetsKey, widgetID)
 	}
 
+	queryStart := time.Now()
 	_, err := pipe.Exec(ctx)
+	monitoring.TrackQuery("ZSCORE", keyPattern(UserWidgetsKey), queryStart)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get widget scores: %w", err)
 	}
@@ -495,14 +879,11 @@ func (r *RedisWidgetRepository) sortWidgetIDsByCreationTime(ctx context.Context,
 		})
 	}
 
-	// Sort by timestamp descending (newest first)
-	for i := 0; i < len(widgetsWithTime)-1; i++ {
-		for j := i + 1; j < len(widgetsWithTime); j++ {
-			if widgetsWithTime[i].timestamp < widgetsWithTime[j].timestamp {
-				widgetsWithTime[i], widgetsWithTime[j] = widgetsWithTime[j], widgetsWithTime[i]
-			}
-		}
-	}
+	// Sort by timestamp descending (newest first), then by ID
+	sort.Slice(widgetsWithTime, func(i, j int) bool {
+		a, b := widgetsWithTime[i], widgetsWithTime[j]
+		return newerWidget(int64(a.timestamp), a.id, int64(b.timestamp), b.id)
+	})
 
 	// Extract sorted widget IDs
 	sortedWidgetIDs := make([]string, len(widgetsWithTime))
@@ -532,7 +913,9 @@ func (r *RedisWidgetRepository) applyNameSearchFilter(ctx context.Context, widge
 	}
 
 	// Execute all commands at once
+	queryStart := time.Now()
 	_, err := pipe.Exec(ctx)
+	monitoring.TrackQuery("HGET", keyPattern(WidgetKey), queryStart)
 	if err != nil {
 		return nil, fmt.Errorf("failed to batch load widget names: %w", err)
 	}
@@ -574,9 +957,12 @@ func (r *RedisWidgetRepository) batchLoadWidgets(ctx context.Context, widgetIDs
 		statsCommands[i] = pipe.HGetAll(ctx, statsKey)
 	}
 
-	// Execute all commands at once
+	// Execute all commands at once; errors of single commands (e.g. a stats key
+	// holding the wrong type) are handled per widget
+	queryStart := time.Now()
 	_, err := pipe.Exec(ctx)
-	if err != nil {
+	monitoring.TrackQuery("HGETALL", keyPattern(WidgetKey), queryStart)
+	if _, isCommandErr := err.(redis.Error); err != nil && !isCommandErr {
 		return nil, fmt.Errorf("failed to batch load widget data: %w", err)
 	}
 
@@ -594,163 +980,336 @@ func (r *RedisWidgetRepository) batchLoadWidgets(ctx context.Context, widgetIDs
 			continue // Skip widgets with invalid data
 		}
 
-		// Parse stats data
+		// Parse stats data, a missing stats hash means no events yet
 		statsHash, err := statsCommands[i].Result()
-		if err == nil && len(statsHash) > 0 {
-			stats := &models.WidgetStats{WidgetID: widgetID}
+		if err != nil {
+			setListingStats(widget, nil, err)
+		} else {
+			setListingStats(widget, parseWidgetStatsHash(widgetID, statsHash), nil)
+		}
 
-			if viewsStr, ok := statsHash["views"]; ok {
-				if views, err := strconv.ParseInt(viewsStr, 10, 64); err == nil {
-					stats.Views = views
-				}
-			}
+		widgets = append(widgets, widget)
+	}
 
-			if submitsStr, ok := statsHash["submits"]; ok {
-				if submits, err := strconv.ParseInt(submitsStr, 10, 64); err == nil {
-					stats.Submits = submits
-				}
-			}
+	return widgets, nil
+}
 
-			if closesStr, ok := statsHash["closes"]; ok {
-				if closes, err := strconv.ParseInt(closesStr, 10, 64); err == nil {
-					stats.Closes = closes
-				}
-			}
+// setListingStats attaches stats to a listed widget. Unreadable stats leave a zeroed
+// stats object with StatsAvailable false, so listings always carry the field.
+func setListingStats(widget *models.Widget, stats *models.WidgetStats, err error) {
+	if err != nil || stats == nil {
+		widget.Stats = &models.WidgetStats{WidgetID: widget.ID}
+		widget.StatsAvailable = false
+		return
+	}
+	widget.Stats = stats
+	widget.StatsAvailable = true
+}
 
-			if lastViewStr, ok := statsHash["last_view"]; ok {
-				if timestamp, err := strconv.ParseInt(lastViewStr, 10, 64); err == nil {
-					stats.LastView = time.Unix(timestamp, 0)
-				}
-			}
+// parseWidgetStatsHash parses a widget stats hash, unparsable fields stay zero
+func parseWidgetStatsHash(widgetID string, statsHash map[string]string) *models.WidgetStats {
+	stats := &models.WidgetStats{WidgetID: widgetID}
 
-			widget.Stats = stats
+	if viewsStr, ok := statsHash["views"]; ok {
+		if views, err := strconv.ParseInt(viewsStr, 10, 64); err == nil {
+			stats.Views = views
 		}
+	}
 
-		widgets = append(widgets, widget)
+	if submitsStr, ok := statsHash["submits"]; ok {
+		if submits, err := strconv.ParseInt(submitsStr, 10, 64); err == nil {
+			stats.Submits = submits
+		}
 	}
 
-	return widgets, nil
+	if closesStr, ok := statsHash["closes"]; ok {
+		if closes, err := strconv.ParseInt(closesStr, 10, 64); err == nil {
+			stats.Closes = closes
+		}
+	}
+
+	if spamStr, ok := statsHash["spam"]; ok {
+		if spam, err := strconv.ParseInt(spamStr, 10, 64); err == nil {
+			stats.Spam = spam
+		}
+	}
+
+	if lastViewStr, ok := statsHash["last_view"]; ok {
+		if timestamp, err := strconv.ParseInt(lastViewStr, 10, 64); err == nil {
+			stats.LastView = time.Unix(timestamp, 0)
+		}
+	}
+
+	if lastSubmitStr, ok := statsHash["last_submit"]; ok {
+		if timestamp, err := strconv.ParseInt(lastSubmitStr, 10, 64); err == nil {
+			stats.LastSubmit = time.Unix(timestamp, 0)
+		}
+	}
+
+	stats.ComputeRates()
+	return stats
 }
 
-// RebuildIndexes rebuilds all Redis indexes for widgets
-// This method should be called during application startup or when index corruption is detected
+// RebuildIndexes reconstructs the widget indexes (time, type, status, PII and the
+// owners' widgets, names and archived keys) from the widget records, dropping entries
+// of widgets that no longer exist. Each index key is replaced in its own transaction,
+// so readers never see it half-built. The expiry indexes are only added to, as the
+// cleanup sweep still needs the entries of widgets that have already expired.
+// Widgets whose record can't be parsed are left out.
 func (r *RedisWidgetRepository) RebuildIndexes(ctx context.Context) error {
-	// Get all widget keys
-	pattern := "{*}:widget"
-	widgetKeys, err := r.client.client.Keys(ctx, pattern).Result()
+	widgetKeys, err := r.scanKeys(ctx, keyPattern(WidgetKey))
 	if err != nil {
 		return fmt.Errorf("failed to get widget keys: %w", err)
 	}
 
-	// Clear existing index keys using a transaction for atomicity
-	pipe := r.client.client.TxPipeline()
-
-	// Clear visibility indexes
-	pipe.Del(ctx, GenerateWidgetsByStatusKey(true))
-	pipe.Del(ctx, GenerateWidgetsByStatusKey(false))
-
-	// Clear type indexes for all known types
-	supportedTypes := models.AllWidgetTypes()
-	for _, widgetType := range supportedTypes {
-		pipe.Del(ctx, GenerateWidgetsByTypeKey(widgetType))
+	// Every known index key starts out empty, so keys without widgets are cleared
+	sets := map[string][]interface{}{
+		GenerateWidgetsByStatusKey(true):  nil,
+		GenerateWidgetsByStatusKey(false): nil,
+		WidgetsPIIKey:                     nil,
+	}
+	for _, widgetType := range models.AllWidgetTypes() {
+		sets[GenerateWidgetsByTypeKey(widgetType)] = nil
 	}
+	zsets := map[string][]redis.Z{WidgetsByTimeKey: nil}
+	hashes := map[string]map[string]interface{}{}
 
-	// Execute the cleanup
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to clear existing indexes: %w", err)
+	for _, pattern := range []string{keyPattern(UserWidgetsKey), keyPattern(UserWidgetNamesKey), keyPattern(UserArchivedKey)} {
+		keys, err := r.scanKeys(ctx, pattern)
+		if err != nil {
+			return fmt.Errorf("failed to get user index keys: %w", err)
+		}
+		for _, key := range keys {
+			switch pattern {
+			case keyPattern(UserWidgetsKey):
+				zsets[key] = nil
+			case keyPattern(UserWidgetNamesKey):
+				hashes[key] = map[string]interface{}{}
+			default:
+				sets[key] = nil
+			}
+		}
 	}
 
-	// Rebuild indexes from widget data
-	rebuiltCount := 0
 	for _, widgetKey := range widgetKeys {
-		// Get widget data
-		widgetData, err := r.client.client.HGetAll(ctx, widgetKey).Result()
+		hash, err := r.client.client.HGetAll(ctx, widgetKey).Result()
 		if err != nil {
-			continue // Skip this widget, but continue with others
+			return fmt.Errorf("failed to load widget %s: %w", widgetKey, err)
 		}
 
-		widgetID, ok := widgetData["id"]
-		if !ok || widgetID == "" {
+		widget := &models.Widget{}
+		if err := widget.FromRedisHash(hash); err != nil || widget.ID == "" || widget.Type == "" {
 			continue
 		}
 
-		widgetType, ok := widgetData["type"]
-		if !ok || widgetType == "" {
-			continue
+		zsets[WidgetsByTimeKey] = append(zsets[WidgetsByTimeKey], redis.Z{Score: float64(widget.CreatedAt.Unix()), Member: widget.ID})
+		typeKey := GenerateWidgetsByTypeKey(widget.Type)
+		sets[typeKey] = append(sets[typeKey], widget.ID)
+		statusKey := GenerateWidgetsByStatusKey(widget.IsVisible)
+		sets[statusKey] = append(sets[statusKey], widget.ID)
+		if fields, _ := widget.PIISettings(); len(fields) > 0 {
+			sets[WidgetsPIIKey] = append(sets[WidgetsPIIKey], widget.ID)
 		}
 
-		isVisibleStr, ok := widgetData["isVisible"]
-		if !ok {
-			continue
+		if widget.OwnerID != "" {
+			// Records keep whole seconds, so widgets created within a second lose their order
+			userWidgetsKey := GenerateUserWidgetsKey(widget.OwnerID)
+			zsets[userWidgetsKey] = append(zsets[userWidgetsKey], redis.Z{Score: float64(widget.CreatedAt.UnixNano()), Member: widget.ID})
+
+			namesKey := GenerateUserWidgetNamesKey(widget.OwnerID)
+			if hashes[namesKey] == nil {
+				hashes[namesKey] = map[string]interface{}{}
+			}
+			hashes[namesKey][models.NormalizeWidgetName(widget.Name)] = widget.ID
+
+			if widget.Archived {
+				archivedKey := GenerateUserArchivedKey(widget.OwnerID)
+				sets[archivedKey] = append(sets[archivedKey], widget.ID)
+			}
 		}
 
-		isVisible := isVisibleStr == "true"
+		if widget.ExpiresAt != nil {
+			index, _ := json.Marshal(expiringWidgetIndex{OwnerID: widget.OwnerID, Type: widget.Type, Name: widget.Name})
+			if err := r.client.client.HSet(ctx, WidgetsExpiryIndex, widget.ID, index).Err(); err != nil {
+				return fmt.Errorf("failed to update expiry index: %w", err)
+			}
+			if err := r.client.client.ZAdd(ctx, WidgetsExpiringKey, redis.Z{Score: float64(widget.ExpiresAt.Unix()), Member: widget.ID}).Err(); err != nil {
+				return fmt.Errorf("failed to update expiry index: %w", err)
+			}
+		}
+	}
 
-		// Add to indexes using a transaction for consistency
-		indexPipe := r.client.client.TxPipeline()
+	// Replace each index key; keys live in different slots, so one transaction each
+	replace := func(key string, add func(pipe redis.Pipeliner)) error {
+		pipe := r.client.client.TxPipeline()
+		pipe.Del(ctx, key)
+		add(pipe)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to rebuild index %s: %w", key, err)
+		}
+		return nil
+	}
+	for key, members := range sets {
+		if err := replace(key, func(pipe redis.Pipeliner) {
+			if len(members) > 0 {
+				pipe.SAdd(ctx, key, members...)
+			}
+		}); err != nil {
+			return err
+		}
+	}
+	for key, members := range zsets {
+		if err := replace(key, func(pipe redis.Pipeliner) {
+			if len(members) > 0 {
+				pipe.ZAdd(ctx, key, members...)
+			}
+		}); err != nil {
+			return err
+		}
+	}
+	for key, fields := range hashes {
+		if err := replace(key, func(pipe redis.Pipeliner) {
+			if len(fields) > 0 {
+				pipe.HSet(ctx, key, fields)
+			}
+		}); err != nil {
+			return err
+		}
+	}
 
-		// Add to type index
-		typeKey := GenerateWidgetsByTypeKey(widgetType)
-		indexPipe.SAdd(ctx, typeKey, widgetID)
+	return nil
+}
 
-		// Add to visibility index
-		statusKey := GenerateWidgetsByStatusKey(isVisible)
-		indexPipe.SAdd(ctx, statusKey, widgetID)
+// scanKeys returns the keys matching the pattern, iterating with SCAN so Redis isn't
+// blocked the way KEYS would
+func (r *RedisWidgetRepository) scanKeys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := r.client.client.Scan(ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
 
-		// Execute the index updates
-		_, err = indexPipe.Exec(ctx)
-		if err != nil {
-			// Log error but continue with other widgets
-			continue
-		}
+// GetIndexMembership checks, read-only, which type, status, time, owner and archived index keys
+// hold the widget and whether that matches its record. Without a record the widget
+// belongs nowhere, and its owner index can't be checked. ErrNotFound is returned when
+// neither the record nor any index entry exists.
+func (r *RedisWidgetRepository) GetIndexMembership(ctx context.Context, id string) (*models.WidgetIndexReport, error) {
+	report := &models.WidgetIndexReport{WidgetID: id}
+	widget, err := r.GetByID(ctx, id)
+	switch {
+	case err == nil:
+		report.Exists = true
+		report.Type = widget.Type
+		report.IsVisible = widget.IsVisible
+		report.OwnerID = widget.OwnerID
+	case err != errors.ErrNotFound:
+		return nil, err
+	}
 
-		rebuiltCount++
+	type setCheck struct {
+		key      string
+		expected bool
+		member   *redis.BoolCmd
+	}
+	type zsetCheck struct {
+		key      string
+		expected bool
+		score    *redis.FloatCmd
 	}
 
-	return nil
+	// Plain pipeline, the keys live in different slots
+	pipe := r.client.client.Pipeline()
+	var sets []setCheck
+	for _, widgetType := range models.AllWidgetTypes() {
+		key := GenerateWidgetsByTypeKey(widgetType)
+		sets = append(sets, setCheck{key: key, expected: report.Exists && widget.Type == widgetType, member: pipe.SIsMember(ctx, key, id)})
+	}
+	for _, visible := range []bool{true, false} {
+		key := GenerateWidgetsByStatusKey(visible)
+		sets = append(sets, setCheck{key: key, expected: report.Exists && widget.IsVisible == visible, member: pipe.SIsMember(ctx, key, id)})
+	}
+	zsets := []zsetCheck{{key: WidgetsByTimeKey, expected: report.Exists, score: pipe.ZScore(ctx, WidgetsByTimeKey, id)}}
+	if report.Exists {
+		key := GenerateUserWidgetsKey(widget.OwnerID)
+		zsets = append(zsets, zsetCheck{key: key, expected: true, score: pipe.ZScore(ctx, key, id)})
+		key = GenerateUserArchivedKey(widget.OwnerID)
+		sets = append(sets, setCheck{key: key, expected: widget.Archived, member: pipe.SIsMember(ctx, key, id)})
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to check index membership: %w", err)
+	}
+
+	found := false
+	for _, check := range sets {
+		entry := models.WidgetIndexEntry{Key: check.key, Expected: check.expected, Member: check.member.Val()}
+		report.Indexes = append(report.Indexes, entry)
+		found = found || entry.Member
+	}
+	for _, check := range zsets {
+		entry := models.WidgetIndexEntry{Key: check.key, Expected: check.expected}
+		if score, err := check.score.Result(); err == nil {
+			entry.Member = true
+			entry.Score = &score
+		}
+		report.Indexes = append(report.Indexes, entry)
+		found = found || entry.Member
+	}
+	for _, entry := range report.Indexes {
+		if entry.Member != entry.Expected {
+			report.Drift = true
+		}
+	}
+
+	if !report.Exists && !found {
+		return nil, errors.ErrNotFound
+	}
+	return report, nil
 }
 
-// GetTypeStats returns statistics about widget types for a specific user
+// GetTypeStats returns the number of the user's widgets per type, counted by
+// intersecting the user's widgets with each type index. Types without widgets are left out.
 func (r *RedisWidgetRepository) GetTypeStats(ctx context.Context, userID string) ([]*models.TypeStats, error) {
-	// Get all user widgets without pagination
 	userWidgetsKey := GenerateUserWidgetsKey(userID)
 	widgetIDs, err := r.client.client.ZRange(ctx, userWidgetsKey, 0, -1).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user widgets: %w", err)
 	}
 
+	stats := []*models.TypeStats{}
 	if len(widgetIDs) == 0 {
-		return []*models.TypeStats{}, nil
+		return stats, nil
 	}
 
-	// Count widgets by type
-	typeCounts := make(map[string]int)
+	// Create a temporary SET from user widgets ZSET for intersection
+	tempUserSetKey := fmt.Sprintf("temp:user_set:%s:%d", userID, time.Now().UnixNano())
+	defer r.client.client.Del(ctx, tempUserSetKey) // Clean up temp key
 
-	// Load widgets in batches to count by type
-	widgets, err := r.batchLoadWidgets(ctx, widgetIDs)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load widgets for stats: %w", err)
+	if err := r.client.client.SAdd(ctx, tempUserSetKey, widgetIDs).Err(); err != nil {
+		return nil, fmt.Errorf("failed to create temporary user set: %w", err)
 	}
 
-	// Count by type
-	for _, widget := range widgets {
-		if widget != nil {
-			typeCounts[widget.Type]++
-		}
+	allTypes := models.AllWidgetTypes()
+	pipe := r.client.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(allTypes))
+	for i, widgetType := range allTypes {
+		cmds[i] = pipe.SInterCard(ctx, 0, tempUserSetKey, GenerateWidgetsByTypeKey(widgetType))
 	}
 
-	// Convert to TypeStats slice, ordered by the types defined in models
-	var stats []*models.TypeStats
-	allTypes := models.AllWidgetTypes()
+	queryStart := time.Now()
+	_, err = pipe.Exec(ctx)
+	monitoring.TrackQuery("SINTERCARD", keyPattern(WidgetsByTypeKey), queryStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count widgets by type: %w", err)
+	}
 
-	for _, widgetType := range allTypes {
-		typeStr := string(widgetType)
-		count := typeCounts[typeStr]
-		if count > 0 { // Only include types that have widgets
+	// Ordered by the types defined in models
+	for i, widgetType := range allTypes {
+		if count := cmds[i].Val(); count > 0 {
 			stats = append(stats, &models.TypeStats{
-				Type:  typeStr,
-				Count: count,
+				Type:  widgetType,
+				Count: int(count),
 			})
 		}
 	}
@@ -239,6 +239,16 @@ func (m *MockBenchmarkWidgetRepository) GetByID(ctx context.Context, id string)
 	return nil, fmt.Errorf("widget not found")
 }
 
+func (m *MockBenchmarkWidgetRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Widget, error) {
+	widgets := make([]*models.Widget, 0, len(ids))
+	for _, id := range ids {
+		if widget, exists := m.widgets[id]; exists {
+			widgets = append(widgets, widget)
+		}
+	}
+	return widgets, nil
+}
+
 func (m *MockBenchmarkWidgetRepository) GetByUserID(ctx context.Context, userID string, opts models.PaginationOptions) ([]*models.Widget, int, error) {
 	return m.GetByUserIDWithFilters(ctx, userID, opts)
 }
@@ -248,6 +258,34 @@ func (m *MockBenchmarkWidgetRepository) RebuildIndexes(ctx context.Context) erro
 	return nil
 }
 
+func (m *MockBenchmarkWidgetRepository) CleanupExpiredWidgets(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *MockBenchmarkWidgetRepository) GetIndexMembership(ctx context.Context, id string) (*models.WidgetIndexReport, error) {
+	return nil, nil
+}
+
+func (m *MockBenchmarkWidgetRepository) PushConfigHistory(ctx context.Context, widgetID string, version *models.WidgetConfigVersion, limit int) error {
+	return nil
+}
+
+func (m *MockBenchmarkWidgetRepository) GetConfigHistory(ctx context.Context, widgetID string) ([]*models.WidgetConfigVersion, error) {
+	return nil, nil
+}
+
+func (m *MockBenchmarkWidgetRepository) GetPIIWidgetIDs(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockBenchmarkWidgetRepository) GetVisibleWidgetIDs(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockBenchmarkWidgetRepository) GetWidgetIDByName(ctx context.Context, userID, name string) (string, error) {
+	return "", nil
+}
+
 func (m *MockBenchmarkWidgetRepository) GetTypeStats(ctx context.Context, userID string) ([]*models.TypeStats, error) {
 	// Simple mock implementation for benchmarks
 	typeCounts := make(map[string]int)
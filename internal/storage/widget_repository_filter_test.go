@@ -1,12 +1,17 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/ad/leads-core/internal/errors"
 	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/pkg/logger"
+	"github.com/ad/leads-core/pkg/monitoring"
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 )
@@ -290,6 +295,102 @@ func TestRedisWidgetRepository_GetByUserIDWithFilters_VisibilityFilter(t *testin
 	}
 }
 
+func TestRedisWidgetRepository_GetByUserIDWithFilters_CreatedFilter(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	statsRepo := NewRedisStatsRepository(redisClient)
+	repo := NewRedisWidgetRepository(redisClient, statsRepo)
+	ctx := context.Background()
+
+	userID := "user-123"
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Create test widgets a day apart
+	widgets := []*models.Widget{
+		createTestWidget("widget-1", userID, "Widget 1", "lead-form", true, base),
+		createTestWidget("widget-2", userID, "Widget 2", "banner", true, base.AddDate(0, 0, 1)),
+		createTestWidget("widget-3", userID, "Widget 3", "lead-form", false, base.AddDate(0, 0, 2)),
+		createTestWidget("widget-4", userID, "Widget 4", "lead-form", true, base.AddDate(0, 0, 3)),
+		createTestWidget("widget-5", "other-user", "Other User Widget", "lead-form", true, base.AddDate(0, 0, 2)),
+	}
+
+	for _, widget := range widgets {
+		err := repo.Create(ctx, widget)
+		if err != nil {
+			t.Fatalf("Failed to create widget %s: %v", widget.ID, err)
+		}
+	}
+
+	day := func(days int) *time.Time {
+		t := base.AddDate(0, 0, days)
+		return &t
+	}
+
+	tests := []struct {
+		name            string
+		filters         *models.FilterOptions
+		expectedWidgets []string
+	}{
+		{
+			name:            "from only, inclusive",
+			filters:         &models.FilterOptions{CreatedFrom: day(2)},
+			expectedWidgets: []string{"widget-4", "widget-3"},
+		},
+		{
+			name:            "to only, inclusive",
+			filters:         &models.FilterOptions{CreatedTo: day(1)},
+			expectedWidgets: []string{"widget-2", "widget-1"},
+		},
+		{
+			name:            "both bounds",
+			filters:         &models.FilterOptions{CreatedFrom: day(1), CreatedTo: day(2)},
+			expectedWidgets: []string{"widget-3", "widget-2"},
+		},
+		{
+			name:            "bounds with type filter",
+			filters:         &models.FilterOptions{Types: []string{"lead-form"}, CreatedFrom: day(1)},
+			expectedWidgets: []string{"widget-4", "widget-3"},
+		},
+		{
+			name:            "bounds with multiple types and visibility",
+			filters:         &models.FilterOptions{Types: []string{"lead-form", "banner"}, IsVisible: boolPtr(true), CreatedTo: day(2)},
+			expectedWidgets: []string{"widget-2", "widget-1"},
+		},
+		{
+			name:            "empty range",
+			filters:         &models.FilterOptions{CreatedFrom: day(3), CreatedTo: day(1)},
+			expectedWidgets: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := models.PaginationOptions{
+				Page:    1,
+				PerPage: 10,
+				Filters: tt.filters,
+			}
+
+			result, total, err := repo.GetByUserIDWithFilters(ctx, userID, opts)
+			if err != nil {
+				t.Fatalf("GetByUserIDWithFilters failed: %v", err)
+			}
+
+			if total != len(tt.expectedWidgets) || len(result) != len(tt.expectedWidgets) {
+				t.Fatalf("Expected %d widgets, got %d (total %d)", len(tt.expectedWidgets), len(result), total)
+			}
+
+			// Verify correct widgets are returned, newest first
+			for i, expectedID := range tt.expectedWidgets {
+				if result[i].ID != expectedID {
+					t.Errorf("Expected widget %s at index %d, got %s", expectedID, i, result[i].ID)
+				}
+			}
+		})
+	}
+}
+
 func TestRedisWidgetRepository_GetByUserIDWithFilters_SearchFilter(t *testing.T) {
 	redisClient, cleanup := setupTestRedisForFiltering(t)
 	defer cleanup()
@@ -769,3 +870,520 @@ func TestRedisWidgetRepository_GetByUserIDWithFilters_Performance(t *testing.T)
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+// slowCommandHook delays the named Redis command to simulate a slow query
+type slowCommandHook struct {
+	command string
+	delay   time.Duration
+}
+
+func (h slowCommandHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h slowCommandHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == h.command {
+			time.Sleep(h.delay)
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h slowCommandHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestRedisWidgetRepository_SlowQueryLog(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	var logOutput bytes.Buffer
+	logger.Init("leads-core-test", "test")
+	logger.SetOutput(&logOutput)
+	defer logger.Init("leads-core-test", "test")
+
+	monitoring.SetSlowQueryThreshold(10 * time.Millisecond)
+	defer monitoring.SetSlowQueryThreshold(monitoring.DefaultSlowQueryThreshold)
+
+	statsRepo := NewRedisStatsRepository(redisClient)
+	repo := NewRedisWidgetRepository(redisClient, statsRepo)
+	ctx := context.Background()
+
+	userID := "user-123"
+	now := time.Now()
+	if err := repo.Create(ctx, createTestWidget("widget-1", userID, "Form", "lead-form", true, now)); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+
+	redisClient.client.AddHook(slowCommandHook{command: "sinter", delay: 20 * time.Millisecond})
+
+	opts := models.PaginationOptions{
+		Page:    1,
+		PerPage: 10,
+		Filters: &models.FilterOptions{Types: []string{"lead-form"}},
+	}
+	if _, _, err := repo.GetByUserIDWithFilters(ctx, userID, opts); err != nil {
+		t.Fatalf("GetByUserIDWithFilters failed: %v", err)
+	}
+
+	output := logOutput.String()
+	if !strings.Contains(output, "Slow query detected") {
+		t.Fatalf("Expected slow query log entry, got: %s", output)
+	}
+	if !strings.Contains(output, `"operation":"SINTER"`) || !strings.Contains(output, `"key_pattern":"widgets:type:*"`) {
+		t.Errorf("Expected SINTER with key pattern in slow query log, got: %s", output)
+	}
+	if strings.Contains(output, "widgets:type:lead-form") {
+		t.Errorf("Slow query log must not contain concrete keys, got: %s", output)
+	}
+}
+
+func TestRedisWidgetRepository_CleanupExpiredWidgets(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	statsRepo := NewRedisStatsRepository(redisClient)
+	submissionRepo := NewRedisSubmissionRepository(redisClient)
+	repo := NewRedisWidgetRepository(redisClient, statsRepo)
+	ctx := context.Background()
+	now := time.Now()
+
+	demo := createTestWidget("demo-widget", "user1", "Demo", "lead-form", true, now)
+	expiresAt := now.Add(time.Hour)
+	demo.ExpiresAt = &expiresAt
+	regular := createTestWidget("regular-widget", "user1", "Regular", "lead-form", true, now)
+
+	for _, widget := range []*models.Widget{demo, regular} {
+		if err := repo.Create(ctx, widget); err != nil {
+			t.Fatalf("Failed to create widget: %v", err)
+		}
+	}
+	if err := submissionRepo.Create(ctx, &models.Submission{ID: "s1", WidgetID: demo.ID, Data: map[string]interface{}{"a": "b"}, CreatedAt: now, TTL: 24 * time.Hour}); err != nil {
+		t.Fatalf("Failed to create submission: %v", err)
+	}
+
+	// Expiring widget keys carry a TTL, regular ones don't
+	if ttl := redisClient.client.TTL(ctx, GenerateWidgetKey(demo.ID)).Val(); ttl <= 0 {
+		t.Errorf("Expected demo widget key to have a TTL, got %v", ttl)
+	}
+	if ttl := redisClient.client.TTL(ctx, GenerateWidgetKey(regular.ID)).Val(); ttl != -1 {
+		t.Errorf("Expected regular widget key to have no TTL, got %v", ttl)
+	}
+
+	// Nothing expired yet
+	if cleaned, err := repo.CleanupExpiredWidgets(ctx, now); err != nil || cleaned != 0 {
+		t.Fatalf("Expected no cleanup before expiry, got cleaned=%d err=%v", cleaned, err)
+	}
+
+	cleaned, err := repo.CleanupExpiredWidgets(ctx, now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("CleanupExpiredWidgets failed: %v", err)
+	}
+	if cleaned != 1 {
+		t.Errorf("Expected 1 widget cleaned up, got %d", cleaned)
+	}
+
+	checks := []struct {
+		name    string
+		present bool
+	}{
+		{name: "user index", present: redisClient.client.ZScore(ctx, GenerateUserWidgetsKey("user1"), demo.ID).Err() == nil},
+		{name: "time index", present: redisClient.client.ZScore(ctx, WidgetsByTimeKey, demo.ID).Err() == nil},
+		{name: "type index", present: redisClient.client.SIsMember(ctx, GenerateWidgetsByTypeKey("lead-form"), demo.ID).Val()},
+		{name: "status index", present: redisClient.client.SIsMember(ctx, GenerateWidgetsByStatusKey(true), demo.ID).Val()},
+		{name: "expiry index", present: redisClient.client.ZScore(ctx, WidgetsExpiringKey, demo.ID).Err() == nil},
+		{name: "submission", present: redisClient.client.Exists(ctx, GenerateSubmissionKey(demo.ID, "s1")).Val() == 1},
+	}
+	for _, check := range checks {
+		if check.present {
+			t.Errorf("Expected expired widget to be removed from %s", check.name)
+		}
+	}
+
+	widgets, total, err := repo.GetByUserID(ctx, "user1", models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatalf("GetByUserID failed: %v", err)
+	}
+	if total != 1 || len(widgets) != 1 || widgets[0].ID != regular.ID {
+		t.Errorf("Expected only the regular widget to remain, got %d widgets", total)
+	}
+}
+
+func TestRedisWidgetRepository_CleanupExpiredWidgets_AfterUpdate(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	repo := NewRedisWidgetRepository(redisClient, NewRedisStatsRepository(redisClient))
+	ctx := context.Background()
+	now := time.Now()
+
+	demo := createTestWidget("demo-widget", "user1", "Demo", "lead-form", true, now)
+	expiresAt := now.Add(time.Hour)
+	demo.ExpiresAt = &expiresAt
+	if err := repo.Create(ctx, demo); err != nil {
+		t.Fatalf("Failed to create widget: %v", err)
+	}
+
+	// Rename, retype, hide and archive the widget after creation
+	demo.Name = "Renamed"
+	demo.Type = "banner"
+	demo.IsVisible = false
+	demo.Archived = true
+	if err := repo.Update(ctx, demo); err != nil {
+		t.Fatalf("Failed to update widget: %v", err)
+	}
+
+	if cleaned, err := repo.CleanupExpiredWidgets(ctx, now.Add(2*time.Hour)); err != nil || cleaned != 1 {
+		t.Fatalf("Expected 1 widget cleaned up, got cleaned=%d err=%v", cleaned, err)
+	}
+
+	checks := []struct {
+		name    string
+		present bool
+	}{
+		{name: "new type index", present: redisClient.client.SIsMember(ctx, GenerateWidgetsByTypeKey("banner"), demo.ID).Val()},
+		{name: "old type index", present: redisClient.client.SIsMember(ctx, GenerateWidgetsByTypeKey("lead-form"), demo.ID).Val()},
+		{name: "hidden status index", present: redisClient.client.SIsMember(ctx, GenerateWidgetsByStatusKey(false), demo.ID).Val()},
+		{name: "new name index", present: redisClient.client.HExists(ctx, GenerateUserWidgetNamesKey("user1"), models.NormalizeWidgetName("Renamed")).Val()},
+		{name: "old name index", present: redisClient.client.HExists(ctx, GenerateUserWidgetNamesKey("user1"), models.NormalizeWidgetName("Demo")).Val()},
+		{name: "expiry index entry", present: redisClient.client.HExists(ctx, WidgetsExpiryIndex, demo.ID).Val()},
+		{name: "archived index", present: redisClient.client.SIsMember(ctx, GenerateUserArchivedKey("user1"), demo.ID).Val()},
+	}
+	for _, check := range checks {
+		if check.present {
+			t.Errorf("Expected expired widget to be removed from %s", check.name)
+		}
+	}
+}
+
+func TestRedisWidgetRepository_GetByUserIDWithFilters_SortByLastActivity(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	statsRepo := NewRedisStatsRepository(redisClient)
+	repo := NewRedisWidgetRepository(redisClient, statsRepo)
+	ctx := context.Background()
+	now := time.Now()
+
+	widgets := []*models.Widget{
+		createTestWidget("viewed", "user1", "Recently Viewed", "lead-form", true, now.Add(-4*time.Hour)),
+		createTestWidget("submitted", "user1", "Recently Submitted", "lead-form", true, now.Add(-3*time.Hour)),
+		createTestWidget("old", "user1", "Old Activity", "banner", true, now.Add(-2*time.Hour)),
+		createTestWidget("idle-1", "user1", "Idle One", "banner", true, now.Add(-1*time.Hour)),
+		createTestWidget("idle-2", "user1", "Idle Two", "lead-form", true, now),
+	}
+	for _, widget := range widgets {
+		if err := repo.Create(ctx, widget); err != nil {
+			t.Fatalf("Failed to create widget: %v", err)
+		}
+	}
+
+	setActivity := func(widgetID, field string, at time.Time) {
+		if err := redisClient.client.HSet(ctx, GenerateWidgetStatsKey(widgetID), field, at.Unix()).Err(); err != nil {
+			t.Fatalf("Failed to set %s: %v", field, err)
+		}
+	}
+	setActivity("viewed", "last_view", now.Add(-10*time.Minute))
+	setActivity("viewed", "last_submit", now.Add(-5*24*time.Hour))
+	setActivity("submitted", "last_view", now.Add(-2*24*time.Hour))
+	setActivity("submitted", "last_submit", now.Add(-time.Minute))
+	setActivity("old", "last_view", now.Add(-3*24*time.Hour))
+
+	tests := []struct {
+		name     string
+		opts     models.PaginationOptions
+		expected []string
+	}{
+		{
+			name:     "all widgets",
+			opts:     models.PaginationOptions{Page: 1, PerPage: 10, SortBy: models.SortByLastActivity},
+			expected: []string{"submitted", "viewed", "old", "idle-2", "idle-1"},
+		},
+		{
+			name:     "second page",
+			opts:     models.PaginationOptions{Page: 2, PerPage: 2, SortBy: models.SortByLastActivity},
+			expected: []string{"old", "idle-2"},
+		},
+		{
+			name:     "with type filter",
+			opts:     models.PaginationOptions{Page: 1, PerPage: 10, SortBy: models.SortByLastActivity, Filters: &models.FilterOptions{Types: []string{"lead-form"}}},
+			expected: []string{"submitted", "viewed", "idle-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, err := repo.GetByUserIDWithFilters(ctx, "user1", tt.opts)
+			if err != nil {
+				t.Fatalf("GetByUserIDWithFilters failed: %v", err)
+			}
+
+			ids := make([]string, len(result))
+			for i, widget := range result {
+				ids[i] = widget.ID
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected order %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+
+	// Submissions record their time for last-activity ordering
+	if err := statsRepo.IncrementSubmits(ctx, "idle-1"); err != nil {
+		t.Fatalf("IncrementSubmits failed: %v", err)
+	}
+	stats, err := statsRepo.GetWidgetStats(ctx, "idle-1")
+	if err != nil {
+		t.Fatalf("GetWidgetStats failed: %v", err)
+	}
+	if stats.LastSubmit.IsZero() || !stats.LastActivity().Equal(stats.LastSubmit) {
+		t.Errorf("Expected last submission to be tracked, got %v", stats.LastSubmit)
+	}
+}
+
+func TestRedisWidgetRepository_GetByUserIDWithFilters_SortBySubmissionsTies(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	statsRepo := NewRedisStatsRepository(redisClient)
+	repo := NewRedisWidgetRepository(redisClient, statsRepo)
+	ctx := context.Background()
+	now := time.Now()
+
+	// "tie-a" and "tie-b" share both their submission count and creation time
+	widgets := []*models.Widget{
+		createTestWidget("top", "user1", "Top", "lead-form", true, now.Add(-3*time.Hour)),
+		createTestWidget("tie-a", "user1", "Tie A", "lead-form", true, now.Add(-2*time.Hour)),
+		createTestWidget("tie-b", "user1", "Tie B", "banner", true, now.Add(-2*time.Hour)),
+		createTestWidget("tie-new", "user1", "Tie New", "lead-form", true, now.Add(-time.Hour)),
+		createTestWidget("none", "user1", "No Submissions", "banner", true, now),
+	}
+	for _, widget := range widgets {
+		if err := repo.Create(ctx, widget); err != nil {
+			t.Fatalf("Failed to create widget: %v", err)
+		}
+	}
+	for widgetID, submits := range map[string]int{"top": 3, "tie-a": 2, "tie-b": 2, "tie-new": 2} {
+		for i := 0; i < submits; i++ {
+			if err := statsRepo.IncrementSubmits(ctx, widgetID); err != nil {
+				t.Fatalf("IncrementSubmits failed: %v", err)
+			}
+		}
+	}
+
+	expected := "top,tie-new,tie-b,tie-a,none"
+	for attempt := 0; attempt < 20; attempt++ {
+		var ids []string
+		for page := 1; page <= 3; page++ {
+			result, total, err := repo.GetByUserIDWithFilters(ctx, "user1", models.PaginationOptions{Page: page, PerPage: 2, SortBy: models.SortBySubmits})
+			if err != nil {
+				t.Fatalf("GetByUserIDWithFilters failed: %v", err)
+			}
+			if total != len(widgets) {
+				t.Fatalf("Expected total %d, got %d", len(widgets), total)
+			}
+			for _, widget := range result {
+				ids = append(ids, widget.ID)
+			}
+		}
+		if got := strings.Join(ids, ","); got != expected {
+			t.Fatalf("Attempt %d: expected order %s, got %s", attempt, expected, got)
+		}
+	}
+}
+
+func TestRedisWidgetRepository_GetByUserIDWithFilters_SortByKey(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	statsRepo := NewRedisStatsRepository(redisClient)
+	repo := NewRedisWidgetRepository(redisClient, statsRepo)
+	ctx := context.Background()
+	now := time.Now()
+
+	widgets := []*models.Widget{
+		createTestWidget("w1", "user1", "charlie", "lead-form", true, now.Add(-3*time.Hour)),
+		createTestWidget("w2", "user1", "Alpha", "banner", true, now.Add(-2*time.Hour)),
+		createTestWidget("w3", "user1", "bravo", "lead-form", false, now.Add(-time.Hour)),
+	}
+	for _, widget := range widgets {
+		if err := repo.Create(ctx, widget); err != nil {
+			t.Fatalf("Failed to create widget: %v", err)
+		}
+	}
+	counts := map[string][2]int{"w1": {1, 5}, "w2": {3, 1}, "w3": {2, 9}} // submits, views
+	for widgetID, count := range counts {
+		for i := 0; i < count[0]; i++ {
+			if err := statsRepo.IncrementSubmits(ctx, widgetID); err != nil {
+				t.Fatalf("IncrementSubmits failed: %v", err)
+			}
+		}
+		for i := 0; i < count[1]; i++ {
+			if err := statsRepo.IncrementViews(ctx, widgetID); err != nil {
+				t.Fatalf("IncrementViews failed: %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		sortBy    string
+		sortOrder string
+		filters   *models.FilterOptions
+		expected  string
+	}{
+		{"", "", nil, "w3,w2,w1"},
+		{models.SortByCreated, models.SortOrderDesc, nil, "w3,w2,w1"},
+		{models.SortByCreated, models.SortOrderAsc, nil, "w1,w2,w3"},
+		{models.SortByName, "", nil, "w2,w3,w1"},
+		{models.SortByName, models.SortOrderDesc, nil, "w1,w3,w2"},
+		{models.SortBySubmits, "", nil, "w2,w3,w1"},
+		{models.SortBySubmits, models.SortOrderAsc, nil, "w1,w3,w2"},
+		{models.SortByViews, models.SortOrderDesc, nil, "w3,w1,w2"},
+		{models.SortByViews, models.SortOrderAsc, nil, "w2,w1,w3"},
+		{models.SortByName, models.SortOrderAsc, &models.FilterOptions{Types: []string{"lead-form"}}, "w3,w1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy+"_"+tt.sortOrder, func(t *testing.T) {
+			// Page through one widget at a time, so sorting must happen before pagination
+			var ids []string
+			for page := 1; page <= len(widgets); page++ {
+				result, _, err := repo.GetByUserIDWithFilters(ctx, "user1", models.PaginationOptions{
+					Page:      page,
+					PerPage:   1,
+					Filters:   tt.filters,
+					SortBy:    tt.sortBy,
+					SortOrder: tt.sortOrder,
+				})
+				if err != nil {
+					t.Fatalf("GetByUserIDWithFilters failed: %v", err)
+				}
+				for _, widget := range result {
+					ids = append(ids, widget.ID)
+				}
+			}
+			if got := strings.Join(ids, ","); got != tt.expected {
+				t.Errorf("Expected order %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRedisWidgetRepository_ListingStatsFallback(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	statsRepo := NewRedisStatsRepository(redisClient)
+	repo := NewRedisWidgetRepository(redisClient, statsRepo)
+	optimizedRepo := NewOptimizedWidgetRepository(redisClient, statsRepo)
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, widget := range []*models.Widget{
+		createTestWidget("counted", "user1", "Counted", "lead-form", true, now.Add(-2*time.Hour)),
+		createTestWidget("no-stats", "user1", "No Stats", "lead-form", true, now.Add(-time.Hour)),
+		createTestWidget("broken-stats", "user1", "Broken Stats", "banner", true, now),
+	} {
+		if err := repo.Create(ctx, widget); err != nil {
+			t.Fatalf("Failed to create widget: %v", err)
+		}
+	}
+	if err := statsRepo.IncrementViews(ctx, "counted"); err != nil {
+		t.Fatalf("IncrementViews failed: %v", err)
+	}
+	// A stats key of the wrong type can't be read as a hash
+	if err := redisClient.client.Set(ctx, GenerateWidgetStatsKey("broken-stats"), "garbage", 0).Err(); err != nil {
+		t.Fatalf("Failed to corrupt stats key: %v", err)
+	}
+
+	expected := map[string]struct {
+		views     int64
+		available bool
+	}{
+		"counted":      {1, true},
+		"no-stats":     {0, true},
+		"broken-stats": {0, false},
+	}
+
+	listings := map[string]func() ([]*models.Widget, error){
+		"default": func() ([]*models.Widget, error) {
+			widgets, _, err := repo.GetByUserID(ctx, "user1", models.PaginationOptions{Page: 1, PerPage: 10})
+			return widgets, err
+		},
+		"optimized": func() ([]*models.Widget, error) {
+			return optimizedRepo.batchLoadWidgetsOptimized(ctx, []string{"counted", "no-stats", "broken-stats"})
+		},
+	}
+	for name, list := range listings {
+		t.Run(name, func(t *testing.T) {
+			widgets, err := list()
+			if err != nil {
+				t.Fatalf("Listing failed: %v", err)
+			}
+			if len(widgets) != len(expected) {
+				t.Fatalf("Expected %d widgets, got %d", len(expected), len(widgets))
+			}
+			for _, widget := range widgets {
+				want := expected[widget.ID]
+				if widget.Stats == nil {
+					t.Errorf("Expected %s to carry a stats object", widget.ID)
+					continue
+				}
+				if widget.Stats.Views != want.views || widget.StatsAvailable != want.available {
+					t.Errorf("Expected %s to have %d views and stats_available=%v, got %d and %v",
+						widget.ID, want.views, want.available, widget.Stats.Views, widget.StatsAvailable)
+				}
+			}
+		})
+	}
+}
+
+func TestRedisWidgetRepository_CreateRollsBackOnIndexFailure(t *testing.T) {
+	redisClient, cleanup := setupTestRedisForFiltering(t)
+	defer cleanup()
+
+	statsRepo := NewRedisStatsRepository(redisClient)
+	repo := NewRedisWidgetRepository(redisClient, statsRepo)
+	ctx := context.Background()
+	client := redisClient.client
+
+	// A type index holding the wrong type makes its SADD fail after the widget is stored
+	typeKey := GenerateWidgetsByTypeKey("quiz")
+	if err := client.Set(ctx, typeKey, "corrupt", 0).Err(); err != nil {
+		t.Fatalf("Failed to corrupt type index: %v", err)
+	}
+
+	userID := "user-123"
+	widget := createTestWidget("widget-1", userID, "Quiz", "quiz", true, time.Now())
+	if err := repo.Create(ctx, widget); err == nil {
+		t.Fatal("Expected create to fail when an index write fails")
+	}
+
+	if exists, _ := client.Exists(ctx, GenerateWidgetKey(widget.ID), GenerateWidgetStatsKey(widget.ID)).Result(); exists != 0 {
+		t.Errorf("Expected the widget record and stats to be rolled back, %d remain", exists)
+	}
+	if _, err := client.ZScore(ctx, GenerateUserWidgetsKey(userID), widget.ID).Result(); err != redis.Nil {
+		t.Errorf("Expected widget removed from the user index, got %v", err)
+	}
+	if _, err := client.ZScore(ctx, WidgetsByTimeKey, widget.ID).Result(); err != redis.Nil {
+		t.Errorf("Expected widget removed from the time index, got %v", err)
+	}
+	if member, _ := client.SIsMember(ctx, GenerateWidgetsByStatusKey(true), widget.ID).Result(); member {
+		t.Error("Expected widget removed from the status index")
+	}
+	if exists, _ := client.HExists(ctx, GenerateUserWidgetNamesKey(userID), models.NormalizeWidgetName(widget.Name)).Result(); exists {
+		t.Error("Expected the widget name to be released")
+	}
+	if _, err := repo.GetByID(ctx, widget.ID); err != errors.ErrNotFound {
+		t.Errorf("Expected no orphan widget, got %v", err)
+	}
+
+	// With the index repaired the same widget can be created
+	client.Del(ctx, typeKey)
+	if err := repo.Create(ctx, widget); err != nil {
+		t.Fatalf("Failed to create widget after repair: %v", err)
+	}
+	widgets, total, err := repo.GetByUserID(ctx, userID, models.PaginationOptions{Page: 1, PerPage: 10})
+	if err != nil || total != 1 || len(widgets) != 1 {
+		t.Errorf("Expected the widget listed once, got %d (%v)", total, err)
+	}
+}
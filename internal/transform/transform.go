@@ -0,0 +1,69 @@
+// Package transform implements named string transformations applied to
+// submission field values before they are stored.
+package transform
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Func transforms a single field value
+type Func func(string) string
+
+// Supported transform names
+const (
+	Trim           = "trim"
+	Lower          = "lower"
+	Upper          = "upper"
+	PhoneNormalize = "phone-normalize"
+)
+
+var registry = map[string]Func{
+	Trim:           strings.TrimSpace,
+	Lower:          strings.ToLower,
+	Upper:          strings.ToUpper,
+	PhoneNormalize: normalizePhone,
+}
+
+// Lookup returns the transform registered under name
+func Lookup(name string) (Func, bool) {
+	fn, ok := registry[name]
+	return fn, ok
+}
+
+// Chain applies the named transforms to value in order, skipping unknown names
+func Chain(value string, names []string) string {
+	for _, name := range names {
+		if fn, ok := registry[name]; ok {
+			value = fn(value)
+		}
+	}
+	return value
+}
+
+// Apply runs the configured transforms (field -> ordered transform names) on the
+// string values of data in place. Missing fields and non-string values are left as is.
+func Apply(data map[string]interface{}, transforms map[string][]string) {
+	for field, names := range transforms {
+		if value, ok := data[field].(string); ok {
+			data[field] = Chain(value, names)
+		}
+	}
+}
+
+// normalizePhone keeps the digits of a phone number and a leading plus sign,
+// e.g. "+7 (900) 123-45-67" becomes "+79001234567"
+func normalizePhone(value string) string {
+	value = strings.TrimSpace(value)
+
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case unicode.IsDigit(r) && r <= unicode.MaxASCII:
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
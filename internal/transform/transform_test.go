@@ -0,0 +1,71 @@
+package transform
+
+import "testing"
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{Trim, "  John Doe \n", "John Doe"},
+		{Lower, "John@Example.COM", "john@example.com"},
+		{Upper, "ab-12", "AB-12"},
+		{PhoneNormalize, " +7 (900) 123-45-67 ", "+79001234567"},
+		{PhoneNormalize, "8 900 123 45 67 ext. 5", "890012345675"},
+		{PhoneNormalize, "12+34", "1234"},
+	}
+
+	for _, tt := range tests {
+		fn, ok := Lookup(tt.name)
+		if !ok {
+			t.Fatalf("Expected transform %q to be registered", tt.name)
+		}
+		if got := fn(tt.value); got != tt.expected {
+			t.Errorf("%s(%q): expected %q, got %q", tt.name, tt.value, tt.expected, got)
+		}
+	}
+
+	if _, ok := Lookup("reverse"); ok {
+		t.Error("Expected unknown transform not to be registered")
+	}
+}
+
+func TestChain(t *testing.T) {
+	if got := Chain("  John@Example.COM ", []string{Trim, Lower}); got != "john@example.com" {
+		t.Errorf("Expected trimmed lowercase email, got %q", got)
+	}
+	// Order is preserved: upper after lower wins
+	if got := Chain("MiXeD", []string{Lower, Upper}); got != "MIXED" {
+		t.Errorf("Expected transforms applied in order, got %q", got)
+	}
+	if got := Chain(" x ", []string{"unknown", Trim}); got != "x" {
+		t.Errorf("Expected unknown transforms to be skipped, got %q", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	data := map[string]interface{}{
+		"email": " Lead@Example.com ",
+		"phone": "+1 (555) 010-9999",
+		"age":   float64(30),
+		"name":  " Kept ",
+	}
+
+	Apply(data, map[string][]string{
+		"email":   {Trim, Lower},
+		"phone":   {PhoneNormalize},
+		"age":     {Trim},
+		"missing": {Trim},
+	})
+
+	if data["email"] != "lead@example.com" || data["phone"] != "+15550109999" {
+		t.Errorf("Expected configured fields to be transformed, got %v", data)
+	}
+	if data["age"] != float64(30) || data["name"] != " Kept " {
+		t.Errorf("Expected non-string and unconfigured fields to be left as is, got %v", data)
+	}
+	if _, ok := data["missing"]; ok {
+		t.Error("Expected missing fields not to be added")
+	}
+}
@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Default JSON complexity limits, generous for real forms and configs
+const (
+	DefaultMaxJSONDepth = 32
+	DefaultMaxJSONKeys  = 1000
+)
+
+// LimitError reports a JSON document exceeding the nesting depth or key count limit
+type LimitError struct {
+	Reason string
+}
+
+func (e *LimitError) Error() string {
+	return e.Reason
+}
+
+// SetJSONLimits sets the max nesting depth and total object key count of decoded
+// documents (0 disables a limit)
+func (v *SchemaValidator) SetJSONLimits(maxDepth, maxKeys int) {
+	v.maxDepth = maxDepth
+	v.maxKeys = maxKeys
+}
+
+// jsonFrame is an open object or array while scanning a document
+type jsonFrame struct {
+	object    bool
+	expectKey bool
+}
+
+// checkJSONLimits scans the document token by token, so oversized documents are
+// rejected before anything is allocated for them. Malformed JSON is left for the
+// decoder to report.
+func checkJSONLimits(body []byte, maxDepth, maxKeys int) error {
+	if maxDepth <= 0 && maxKeys <= 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var stack []jsonFrame
+	keys := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = &stack[len(stack)-1]
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			if top != nil && top.object {
+				top.expectKey = true // The container is the value, a key follows it
+			}
+			stack = append(stack, jsonFrame{object: token == json.Delim('{'), expectKey: true})
+			if maxDepth > 0 && len(stack) > maxDepth {
+				return &LimitError{Reason: fmt.Sprintf("JSON nesting depth exceeds the limit of %d", maxDepth)}
+			}
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+		default:
+			if top == nil || !top.object {
+				continue
+			}
+			if top.expectKey {
+				keys++
+				if maxKeys > 0 && keys > maxKeys {
+					return &LimitError{Reason: fmt.Sprintf("JSON key count exceeds the limit of %d", maxKeys)}
+				}
+			}
+			top.expectKey = !top.expectKey
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package validation
+
+import (
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/pkg/logger"
+)
+
+// ValidateSubmission checks submission data against the field definitions of the widget
+// config: required fields must hold a non-empty value and values must match their
+// field's type. It returns models.FieldErrors, sorted by field. Fields without a
+// definition are allowed and only logged.
+func ValidateSubmission(widget *models.Widget, data map[string]interface{}) error {
+	definitions := widget.FieldDefinitions()
+	if len(definitions) == 0 {
+		return nil
+	}
+
+	fields := make([]string, 0, len(definitions))
+	for field := range definitions {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var errs models.FieldErrors
+	for _, field := range fields {
+		definition := definitions[field]
+		value, ok := data[field]
+		if !ok || isEmptyValue(value) {
+			if definition.Required {
+				errs = append(errs, &models.FieldError{Field: "data." + field, Message: "Field is required"})
+			}
+			continue
+		}
+		if message := checkFieldType(definition.Type, value); message != "" {
+			errs = append(errs, &models.FieldError{Field: "data." + field, Message: message})
+		}
+	}
+
+	var undefined []string
+	for field := range data {
+		if _, ok := definitions[field]; !ok {
+			undefined = append(undefined, field)
+		}
+	}
+	if len(undefined) > 0 {
+		sort.Strings(undefined)
+		logger.Info("Submission has fields without a definition", map[string]interface{}{
+			"action":    "validate_submission",
+			"widget_id": widget.ID,
+			"fields":    undefined,
+		})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// isEmptyValue reports whether a submitted value counts as missing
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// checkFieldType returns why the value doesn't match the field type, empty when it does
+func checkFieldType(fieldType string, value interface{}) string {
+	switch fieldType {
+	case models.FieldTypeText:
+		if _, ok := value.(string); !ok {
+			return "Must be a string"
+		}
+	case models.FieldTypeEmail:
+		s, ok := value.(string)
+		if !ok || !isEmailAddress(s) {
+			return "Must be a valid email address"
+		}
+	case models.FieldTypeNumber:
+		switch v := value.(type) {
+		case float64:
+		case string:
+			// Form fields often submit numbers as strings
+			if _, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+				return "Must be a number"
+			}
+		default:
+			return "Must be a number"
+		}
+	}
+	return ""
+}
+
+// isEmailAddress reports whether s is a bare email address with a dotted domain,
+// without a display name
+func isEmailAddress(s string) bool {
+	s = strings.TrimSpace(s)
+	address, err := mail.ParseAddress(s)
+	if err != nil || address.Address != s || address.Name != "" {
+		return false
+	}
+	_, domain, _ := strings.Cut(s, "@")
+	return strings.Contains(strings.Trim(domain, "."), ".")
+}
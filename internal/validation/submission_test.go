@@ -0,0 +1,91 @@
+package validation
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ad/leads-core/internal/models"
+	"github.com/ad/leads-core/pkg/logger"
+)
+
+func TestValidateSubmission(t *testing.T) {
+	widget := &models.Widget{
+		ID: "widget-1",
+		Config: map[string]interface{}{
+			models.WidgetConfigFieldsKey: map[string]interface{}{
+				"name":  map[string]interface{}{"type": "text", "required": true},
+				"email": map[string]interface{}{"type": "email", "required": true},
+				"age":   map[string]interface{}{"type": "number"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		data   map[string]interface{}
+		errors []string // Fields with errors, in order
+	}{
+		{"valid", map[string]interface{}{"name": "Jane", "email": "jane@example.com", "age": 30.0}, nil},
+		{"number as string", map[string]interface{}{"name": "Jane", "email": "jane@example.com", "age": " 30.5 "}, nil},
+		{"optional field missing", map[string]interface{}{"name": "Jane", "email": "jane@example.com"}, nil},
+		{"missing required fields", map[string]interface{}{"age": 30.0}, []string{"data.email", "data.name"}},
+		{"blank required field", map[string]interface{}{"name": "  ", "email": "jane@example.com"}, []string{"data.name"}},
+		{"bad email", map[string]interface{}{"name": "Jane", "email": "jane@"}, []string{"data.email"}},
+		{"email with display name", map[string]interface{}{"name": "Jane", "email": "Jane <jane@example.com>"}, []string{"data.email"}},
+		{"email without dotted domain", map[string]interface{}{"name": "Jane", "email": "jane@localhost"}, []string{"data.email"}},
+		{"bad types", map[string]interface{}{"name": true, "email": "jane@example.com", "age": "thirty"}, []string{"data.age", "data.name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSubmission(widget, tt.data)
+			if len(tt.errors) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			fieldErrs, ok := err.(models.FieldErrors)
+			if !ok {
+				t.Fatalf("Expected field errors, got %v", err)
+			}
+			var fields []string
+			for _, fieldErr := range fieldErrs {
+				fields = append(fields, fieldErr.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.errors, ",") {
+				t.Errorf("Expected errors for %v, got %v", tt.errors, fieldErrs)
+			}
+		})
+	}
+}
+
+func TestValidateSubmission_UndefinedFieldsAllowed(t *testing.T) {
+	var logOutput bytes.Buffer
+	logger.Init("leads-core-test", "test")
+	logger.SetOutput(&logOutput)
+	defer logger.Init("leads-core-test", "test")
+
+	widget := &models.Widget{
+		ID: "widget-1",
+		Config: map[string]interface{}{
+			models.WidgetConfigFieldsKey: map[string]interface{}{
+				"email": map[string]interface{}{"type": "email", "required": true},
+			},
+		},
+	}
+
+	if err := ValidateSubmission(widget, map[string]interface{}{"email": "jane@example.com", "utm_source": "ads"}); err != nil {
+		t.Fatalf("Expected undefined fields to be allowed, got %v", err)
+	}
+	if !strings.Contains(logOutput.String(), "utm_source") {
+		t.Errorf("Expected undefined fields to be logged, got %q", logOutput.String())
+	}
+
+	// Widgets without field definitions accept anything
+	if err := ValidateSubmission(&models.Widget{ID: "widget-2"}, map[string]interface{}{"any": 1.0}); err != nil {
+		t.Errorf("Expected no error without field definitions, got %v", err)
+	}
+}
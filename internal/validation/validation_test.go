@@ -54,6 +54,24 @@ func TestSchemaValidator_ValidateRequest(t *testing.T) {
 			requestBody: `{"type":"lead-form","name":"","isVisible":true,"config":{"name":"text"}}`,
 			expectError: true,
 		},
+		{
+			name:        "valid widget config public policy",
+			schemaName:  "widget-config-update",
+			requestBody: `{"config":{"public_policy":{"allowed_methods":["public"],"allowed_domains":["example.com"],"honeypot_field":"website","min_fill_seconds":2.5,"allowed_fields":["email"]}}}`,
+			expectError: false,
+		},
+		{
+			name:        "invalid widget config public policy - unknown method",
+			schemaName:  "widget-config-update",
+			requestBody: `{"config":{"public_policy":{"allowed_methods":["email"]}}}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid widget creation - unknown public policy rule",
+			schemaName:  "widget-create",
+			requestBody: `{"type":"lead-form","name":"Test Widget","isVisible":true,"config":{"public_policy":{"captcha":true}}}`,
+			expectError: true,
+		},
 		{
 			name:        "valid submission",
 			schemaName:  "submission",
@@ -199,3 +217,69 @@ func TestSchemaValidator_ValidateAndDecode(t *testing.T) {
 		t.Errorf("Expected isVisible to be true, got %v", widget.IsVisible)
 	}
 }
+
+func TestSchemaValidator_JSONLimits(t *testing.T) {
+	validator, err := NewSchemaValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	validator.SetJSONLimits(4, 5)
+
+	tests := []struct {
+		name        string
+		body        string
+		expectLimit bool
+	}{
+		{
+			name:        "within limits",
+			body:        `{"data": {"name": "John", "tags": [{"a": 1}]}}`,
+			expectLimit: false,
+		},
+		{
+			name:        "depth exceeded",
+			body:        `{"data": {"a": {"b": {"c": {"d": 1}}}}}`,
+			expectLimit: true,
+		},
+		{
+			name:        "depth exceeded in arrays",
+			body:        `{"data": {"list": [[[1]]]}}`,
+			expectLimit: true,
+		},
+		{
+			name:        "key count exceeded",
+			body:        `{"data": {"a": 1, "b": 2, "c": 3, "d": 4, "e": 5}}`,
+			expectLimit: true,
+		},
+		{
+			name:        "string values are not counted as keys",
+			body:        `{"data": {"a": "x", "b": "y", "c": ["k", "l", "m"]}}`,
+			expectLimit: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req models.SubmissionRequest
+			err := validator.ValidateAndDecodeBytes([]byte(tt.body), "submission", &req)
+
+			_, isLimit := err.(*LimitError)
+			if isLimit != tt.expectLimit {
+				t.Errorf("Expected limit error: %v, got: %v", tt.expectLimit, err)
+			}
+		})
+	}
+}
+
+func TestSchemaValidator_JSONLimitsDisabled(t *testing.T) {
+	validator, err := NewSchemaValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	validator.SetJSONLimits(0, 0)
+
+	var req models.UpdateWidgetConfigRequest
+	body := `{"config": {"a": {"b": {"c": {"d": {"e": 1}}}}, "f": 1, "g": 2}}`
+	if err := validator.ValidateAndDecodeBytes([]byte(body), "widget-config-update", &req); err != nil {
+		t.Errorf("Expected no error with limits disabled, got: %v", err)
+	}
+}
@@ -16,13 +16,17 @@ var schemaFS embed.FS
 
 // SchemaValidator handles JSON schema validation
 type SchemaValidator struct {
-	schemas map[string]*gojsonschema.Schema
+	schemas  map[string]*gojsonschema.Schema
+	maxDepth int
+	maxKeys  int
 }
 
 // NewSchemaValidator creates a new schema validator
 func NewSchemaValidator() (*SchemaValidator, error) {
 	validator := &SchemaValidator{
-		schemas: make(map[string]*gojsonschema.Schema),
+		schemas:  make(map[string]*gojsonschema.Schema),
+		maxDepth: DefaultMaxJSONDepth,
+		maxKeys:  DefaultMaxJSONKeys,
 	}
 
 	// Load all schemas
@@ -32,6 +36,17 @@ func NewSchemaValidator() (*SchemaValidator, error) {
 		"widget-config-update.json",
 		"submission.json",
 		"event.json",
+		"widget-bulk-stats-reset.json",
+		"submission-import.json",
+		"submission-bulk-delete.json",
+		"maintenance-update.json",
+		"refresh-token.json",
+		"token-revoke.json",
+	}
+
+	// Shared schemas request schemas reference by their $id
+	sharedSchemaNames := []string{
+		"public-policy.json",
 	}
 
 	for _, schemaName := range schemaNames {
@@ -40,8 +55,18 @@ func NewSchemaValidator() (*SchemaValidator, error) {
 			return nil, fmt.Errorf("failed to read schema %s: %w", schemaName, err)
 		}
 
-		schemaLoader := gojsonschema.NewBytesLoader(schemaData)
-		schema, err := gojsonschema.NewSchema(schemaLoader)
+		loader := gojsonschema.NewSchemaLoader()
+		for _, sharedName := range sharedSchemaNames {
+			sharedData, err := schemaFS.ReadFile("schemas/" + sharedName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read schema %s: %w", sharedName, err)
+			}
+			if err := loader.AddSchemas(gojsonschema.NewBytesLoader(sharedData)); err != nil {
+				return nil, fmt.Errorf("failed to add schema %s: %w", sharedName, err)
+			}
+		}
+
+		schema, err := loader.Compile(gojsonschema.NewBytesLoader(schemaData))
 		if err != nil {
 			return nil, fmt.Errorf("failed to compile schema %s: %w", schemaName, err)
 		}
@@ -67,6 +92,10 @@ func (v *SchemaValidator) ValidateRequest(r *http.Request, schemaName string) (m
 		return nil, fmt.Errorf("failed to read request body: %w", err)
 	}
 
+	if err := checkJSONLimits(body, v.maxDepth, v.maxKeys); err != nil {
+		return nil, err
+	}
+
 	// Parse JSON
 	var data map[string]interface{}
 	if err := json.Unmarshal(body, &data); err != nil {
@@ -106,15 +135,24 @@ func (e *ValidationError) Error() string {
 
 // ValidateAndDecode validates request and decodes into target struct
 func (v *SchemaValidator) ValidateAndDecode(r *http.Request, schemaName string, target interface{}) error {
+	// Read and parse request body once
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	return v.ValidateAndDecodeBytes(body, schemaName, target)
+}
+
+// ValidateAndDecodeBytes validates a JSON document and decodes it into target struct
+func (v *SchemaValidator) ValidateAndDecodeBytes(body []byte, schemaName string, target interface{}) error {
 	schema, exists := v.schemas[schemaName]
 	if !exists {
 		return fmt.Errorf("schema %s not found", schemaName)
 	}
 
-	// Read and parse request body once
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read request body: %w", err)
+	if err := checkJSONLimits(body, v.maxDepth, v.maxKeys); err != nil {
+		return err
 	}
 
 	// Parse JSON into target struct
@@ -274,6 +274,13 @@ func Init(service, version string) {
 	defaultLogger = New(service, version)
 }
 
+// SetOutput sets the output destination for the global logger
+func SetOutput(w io.Writer) {
+	if defaultLogger != nil {
+		defaultLogger.SetOutput(w)
+	}
+}
+
 // Global logging functions
 func Debug(message string, fields ...map[string]interface{}) {
 	if defaultLogger != nil {
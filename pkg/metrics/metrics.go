@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -34,6 +36,7 @@ type MetricsCollector struct {
 	mu        sync.RWMutex
 	metrics   map[string]*Metric
 	startTime time.Time
+	routes    *RouteTemplates
 }
 
 // NewCollector creates a new metrics collector
@@ -44,18 +47,29 @@ func NewCollector() *MetricsCollector {
 	}
 }
 
-// metricKey generates a unique key for a metric
+// SetRouteTemplates sets the templates used for the route label of HTTP metrics.
+// Without templates the raw request path is used.
+func (mc *MetricsCollector) SetRouteTemplates(routes *RouteTemplates) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.routes = routes
+}
+
+// metricKey generates a unique key for a metric, labels are sorted by name
 func (mc *MetricsCollector) metricKey(name string, labels map[string]string) string {
 	key := name
 	if len(labels) > 0 {
-		labelStr := ""
-		for k, v := range labels {
-			if labelStr != "" {
-				labelStr += ","
-			}
-			labelStr += fmt.Sprintf("%s=%s", k, v)
+		names := make([]string, 0, len(labels))
+		for k := range labels {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		pairs := make([]string, 0, len(names))
+		for _, k := range names {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", k, labels[k]))
 		}
-		key += "{" + labelStr + "}"
+		key += "{" + strings.Join(pairs, ",") + "}"
 	}
 	return key
 }
@@ -209,6 +223,15 @@ func (mc *MetricsCollector) HTTPMetricsMiddleware(next http.Handler) http.Handle
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		// Resolve the route before calling the handler, routers rewrite the path
+		mc.mu.RLock()
+		routes := mc.routes
+		mc.mu.RUnlock()
+		route := r.URL.Path
+		if routes != nil {
+			route = routes.Match(route)
+		}
+
 		// Wrap the response writer to capture status code
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
@@ -217,10 +240,13 @@ func (mc *MetricsCollector) HTTPMetricsMiddleware(next http.Handler) http.Handle
 
 		// Record metrics
 		duration := time.Since(start).Seconds()
+		status := fmt.Sprintf("%d", wrapped.statusCode)
+		statusClass := StatusClass(wrapped.statusCode)
 		labels := map[string]string{
-			"method": r.Method,
-			"path":   r.URL.Path,
-			"status": fmt.Sprintf("%d", wrapped.statusCode),
+			"method":       r.Method,
+			"route":        route,
+			"status":       status,
+			"status_class": statusClass,
 		}
 
 		mc.Inc("http_requests_total", labels, "Total HTTP requests")
@@ -228,7 +254,8 @@ func (mc *MetricsCollector) HTTPMetricsMiddleware(next http.Handler) http.Handle
 
 		// Record status code specific metrics
 		statusLabels := map[string]string{
-			"status": fmt.Sprintf("%d", wrapped.statusCode),
+			"status":       status,
+			"status_class": statusClass,
 		}
 		mc.Inc("http_responses_total", statusLabels, "Total HTTP responses by status code")
 	})
@@ -291,6 +318,12 @@ func Observe(name string, value float64, labels map[string]string, help string)
 	}
 }
 
+func SetRouteTemplates(routes *RouteTemplates) {
+	if defaultCollector != nil {
+		defaultCollector.SetRouteTemplates(routes)
+	}
+}
+
 func HTTPMiddleware(next http.Handler) http.Handler {
 	if defaultCollector != nil {
 		return defaultCollector.HTTPMetricsMiddleware(next)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -171,7 +172,7 @@ func TestHTTPMetricsMiddleware(t *testing.T) {
 	// Debug: print all metric keys
 	found := false
 	for key := range metrics {
-		if key == "http_requests_total{method=GET,path=/test,status=200}" {
+		if key == "http_requests_total{method=GET,route=/test,status=200,status_class=2xx}" {
 			found = true
 			break
 		}
@@ -339,3 +340,92 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestHTTPMetricsMiddleware_RouteTemplates(t *testing.T) {
+	collector := NewCollector()
+	collector.SetRouteTemplates(NewRouteTemplates(
+		"/api/v1/widgets",
+		"/api/v1/widgets/summary",
+		"/api/v1/widgets/{id}",
+		"/api/v1/widgets/{id}/stats",
+	))
+
+	// Routers rewrite the path, the route label must not depend on it
+	handler := collector.HTTPMetricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = "/widgets" + strings.TrimPrefix(r.URL.Path, "/api/v1/widgets")
+		switch {
+		case strings.Contains(r.URL.Path, "missing"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.Contains(r.URL.Path, "broken"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	for _, path := range []string{
+		"/api/v1/widgets/a1/stats",
+		"/api/v1/widgets/b2/stats",
+		"/api/v1/widgets/missing/stats",
+		"/api/v1/widgets/broken",
+		"/api/v1/widgets/summary",
+		"/wp-admin/setup.php",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Scrape the metrics endpoint
+	rr := httptest.NewRecorder()
+	collector.MetricsHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	var response struct {
+		Metrics map[string]*Metric `json:"metrics"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse metrics response: %v", err)
+	}
+
+	expected := map[string]float64{
+		"http_requests_total{method=GET,route=/api/v1/widgets/{id}/stats,status=200,status_class=2xx}": 2,
+		"http_requests_total{method=GET,route=/api/v1/widgets/{id}/stats,status=404,status_class=4xx}": 1,
+		"http_requests_total{method=GET,route=/api/v1/widgets/{id},status=500,status_class=5xx}":       1,
+		"http_requests_total{method=GET,route=/api/v1/widgets/summary,status=200,status_class=2xx}":    1,
+		"http_requests_total{method=GET,route=other,status=200,status_class=2xx}":                      1,
+		"http_responses_total{status=200,status_class=2xx}":                                            4,
+	}
+	for key, value := range expected {
+		metric, ok := response.Metrics[key]
+		if !ok {
+			t.Errorf("Expected metric %s not found", key)
+			continue
+		}
+		if metric.Value != value {
+			t.Errorf("Expected %s = %v, got %v", key, value, metric.Value)
+		}
+	}
+
+	for key, metric := range response.Metrics {
+		if metric.Name == "http_requests_total" && (strings.Contains(key, "a1") || strings.Contains(key, "b2")) {
+			t.Errorf("Expected IDs to be normalized out of route label, got %s", key)
+		}
+	}
+}
+
+func TestRouteTemplates_Match(t *testing.T) {
+	routes := NewRouteTemplates("/widgets/{id}/submit", "/api/v1/widgets/{id}", "/api/v1/widgets/summary")
+
+	tests := map[string]string{
+		"/widgets/abc/submit":      "/widgets/{id}/submit",
+		"/widgets/abc/submit/":     "/widgets/{id}/submit",
+		"/api/v1/widgets/summary":  "/api/v1/widgets/summary",
+		"/api/v1/widgets/abc":      "/api/v1/widgets/{id}",
+		"/api/v1/widgets//":        UnmatchedRoute,
+		"/widgets/abc/submit/more": UnmatchedRoute,
+	}
+	for path, expected := range tests {
+		if got := routes.Match(path); got != expected {
+			t.Errorf("Match(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
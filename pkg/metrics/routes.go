@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnmatchedRoute is the route label for paths matching no template
+const UnmatchedRoute = "other"
+
+// RouteTemplates maps request paths onto route templates such as
+// /api/v1/widgets/{id}/stats, keeping IDs out of metric labels.
+// Segments in braces match any single path segment.
+type RouteTemplates struct {
+	templates []routeTemplate
+}
+
+type routeTemplate struct {
+	route     string
+	segments  []string
+	wildcards int
+}
+
+// NewRouteTemplates creates route templates from patterns like "/widgets/{id}/submit"
+func NewRouteTemplates(patterns ...string) *RouteTemplates {
+	routes := &RouteTemplates{}
+	for _, pattern := range patterns {
+		template := routeTemplate{route: pattern, segments: splitPath(pattern)}
+		for _, segment := range template.segments {
+			if isWildcard(segment) {
+				template.wildcards++
+			}
+		}
+		routes.templates = append(routes.templates, template)
+	}
+	return routes
+}
+
+// Match returns the template matching path, preferring the one with the fewest
+// wildcards (so /api/v1/widgets/summary wins over /api/v1/widgets/{id}),
+// or UnmatchedRoute when none matches
+func (t *RouteTemplates) Match(path string) string {
+	segments := splitPath(path)
+
+	best := -1
+	for i, template := range t.templates {
+		if !template.matches(segments) {
+			continue
+		}
+		if best == -1 || template.wildcards < t.templates[best].wildcards {
+			best = i
+		}
+	}
+
+	if best == -1 {
+		return UnmatchedRoute
+	}
+	return t.templates[best].route
+}
+
+func (t routeTemplate) matches(segments []string) bool {
+	if len(segments) != len(t.segments) {
+		return false
+	}
+	for i, segment := range t.segments {
+		if segment != segments[i] && (!isWildcard(segment) || segments[i] == "") {
+			return false
+		}
+	}
+	return true
+}
+
+// StatusClass returns the status class label of an HTTP status code, e.g. "4xx"
+func StatusClass(code int) string {
+	return fmt.Sprintf("%dxx", code/100)
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func isWildcard(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
@@ -0,0 +1,44 @@
+package monitoring
+
+import (
+	"sync"
+	"time"
+)
+
+// HealthState tracks since when a dependency has been failing health checks.
+// It's updated by the health checker and consulted by request middleware.
+type HealthState struct {
+	mu             sync.RWMutex
+	unhealthySince time.Time
+}
+
+// NewHealthState creates a health state that starts out healthy
+func NewHealthState() *HealthState {
+	return &HealthState{}
+}
+
+// MarkHealthy records a successful health check
+func (h *HealthState) MarkHealthy() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unhealthySince = time.Time{}
+}
+
+// MarkUnhealthy records a failed health check; consecutive failures keep the first failure time
+func (h *HealthState) MarkUnhealthy(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.unhealthySince.IsZero() {
+		h.unhealthySince = now
+	}
+}
+
+// UnhealthyFor returns how long the dependency has been unhealthy (0 while healthy)
+func (h *HealthState) UnhealthyFor(now time.Time) time.Duration {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.unhealthySince.IsZero() {
+		return 0
+	}
+	return now.Sub(h.unhealthySince)
+}
@@ -212,6 +212,7 @@ func (mrc *monitoredRedisClient) Ping(ctx context.Context) *redis.StatusCmd {
 type ConnectionMonitor struct {
 	client redis.UniversalClient
 	logger *logger.FieldLogger
+	health *HealthState
 }
 
 // NewConnectionMonitor creates a new connection monitor
@@ -221,9 +222,15 @@ func NewConnectionMonitor(client redis.UniversalClient) *ConnectionMonitor {
 		logger: logger.WithFields(map[string]interface{}{
 			"component": "redis_connection_monitor",
 		}),
+		health: NewHealthState(),
 	}
 }
 
+// Health returns the connection health state updated by the health checks
+func (cm *ConnectionMonitor) Health() *HealthState {
+	return cm.health
+}
+
 // MonitorHealth checks Redis connection health and records metrics
 func (cm *ConnectionMonitor) MonitorHealth(ctx context.Context) {
 	start := time.Now()
@@ -232,6 +239,7 @@ func (cm *ConnectionMonitor) MonitorHealth(ctx context.Context) {
 	duration := time.Since(start)
 
 	if err != nil {
+		cm.health.MarkUnhealthy(start)
 		metrics.Inc("redis_connection_errors_total", nil, "Total Redis connection errors")
 		metrics.Set("redis_connection_up", 0, nil, "Redis connection status (1=up, 0=down)")
 
@@ -240,6 +248,10 @@ func (cm *ConnectionMonitor) MonitorHealth(ctx context.Context) {
 			"duration": duration.Milliseconds(),
 		})
 	} else {
+		if cm.health.UnhealthyFor(start) > 0 {
+			cm.logger.Info("Redis connection recovered")
+		}
+		cm.health.MarkHealthy()
 		metrics.Set("redis_connection_up", 1, nil, "Redis connection status (1=up, 0=down)")
 
 		cm.logger.Debug("Redis connection health check successful", map[string]interface{}{
@@ -0,0 +1,54 @@
+package monitoring
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/ad/leads-core/pkg/logger"
+	"github.com/ad/leads-core/pkg/metrics"
+)
+
+// DefaultSlowQueryThreshold is used until SetSlowQueryThreshold is called
+const DefaultSlowQueryThreshold = 100 * time.Millisecond
+
+var slowQueryThreshold = int64(DefaultSlowQueryThreshold)
+
+// SetSlowQueryThreshold sets the duration above which storage operations are logged as slow.
+// A zero or negative threshold disables the slow-query log.
+func SetSlowQueryThreshold(threshold time.Duration) {
+	atomic.StoreInt64(&slowQueryThreshold, int64(threshold))
+}
+
+// SlowQueryThreshold returns the current slow-query threshold
+func SlowQueryThreshold() time.Duration {
+	return time.Duration(atomic.LoadInt64(&slowQueryThreshold))
+}
+
+// TrackQuery records the duration of a storage operation started at start.
+// keyPattern must be a key pattern (e.g. "widgets:type:*"), never a concrete key,
+// to keep metric cardinality bounded.
+func TrackQuery(operation, keyPattern string, start time.Time) {
+	duration := time.Since(start)
+
+	labels := map[string]string{
+		"operation":   operation,
+		"key_pattern": keyPattern,
+	}
+
+	metrics.Observe("storage_query_duration_seconds", duration.Seconds(), labels, "Storage operation duration in seconds")
+
+	threshold := SlowQueryThreshold()
+	if threshold <= 0 || duration < threshold {
+		return
+	}
+
+	metrics.Inc("storage_slow_queries_total", labels, "Total storage operations exceeding the slow-query threshold")
+
+	logger.Warn("Slow query detected", map[string]interface{}{
+		"action":       "slow_query",
+		"operation":    operation,
+		"key_pattern":  keyPattern,
+		"duration":     duration.Milliseconds(),
+		"threshold_ms": threshold.Milliseconds(),
+	})
+}